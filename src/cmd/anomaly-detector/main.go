@@ -5,18 +5,24 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/yourusername/aiops-infra/src/internal/api"
 	"github.com/yourusername/aiops-infra/src/internal/config"
 	"github.com/yourusername/aiops-infra/src/internal/datasource"
 	"github.com/yourusername/aiops-infra/src/internal/detector"
+	"github.com/yourusername/aiops-infra/src/internal/grpcingest"
 	"github.com/yourusername/aiops-infra/src/internal/orchestrator"
+	"github.com/yourusername/aiops-infra/src/internal/tracing"
 	"github.com/yourusername/aiops-infra/src/internal/types"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -41,31 +47,130 @@ func main() {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	// Включаем трассировку (без настроек - no-op)
+	shutdownTracing, err := tracing.Setup(tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("Invalid tracing config: %v", err)
+	}
+
+	// Клиенты Prometheus и Loki используют общий пул соединений API,
+	// чтобы его настройки (OptimizeForLoad) применялись и к запросам к источникам
+	datasource.SetHTTPClient(api.GlobalConnectionPool.GetClient())
+
 	// Создаем корневой контекст с отменой
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Инициализируем оркестратор
 	orch := orchestrator.NewOrchestrator()
+	if policy := toRetryPolicy(cfg.Orchestrator.DefaultRetry); policy != nil {
+		orch.SetDefaultRetryPolicy(policy)
+	}
+	if cfg.Orchestrator.DefaultTimeout > 0 {
+		orch.SetDefaultTimeout(cfg.Orchestrator.DefaultTimeout)
+	}
 
 	// Инициализируем обработчики действий
-	initActionHandlers(orch, *scriptsDir, *kubeconfigPath, *slackWebhook)
+	notifHandler := initActionHandlers(orch, *scriptsDir, *kubeconfigPath, *slackWebhook,
+		toNotificationRoutes(cfg.Orchestrator.NotificationRoutes), toDigestConfig(cfg.Orchestrator.NotificationDigest))
+	if format := cfg.Orchestrator.NotificationPayloadFormat; format != "" {
+		if err := notifHandler.SetDefaultPayloadFormat(format); err != nil {
+			log.Fatalf("Invalid notification payload format: %v", err)
+		}
+	}
+
+	// Создаем сервер API
+	server := api.NewServer(orch)
+	if cfg.API.DetectorGC.Enabled {
+		server.SetDetectorGC(cfg.API.DetectorGC.TTL, cfg.API.DetectorGC.Interval)
+	}
+	server.SetCORSConfig(toCORSConfig(cfg.API.CORS))
+	server.SetWebSocketConfig(toWebSocketConfig(cfg.API.WebSocket))
+	if cfg.API.MaxQueryRange != 0 {
+		server.SetMaxQueryRange(max(cfg.API.MaxQueryRange, 0))
+	}
+	if cfg.API.NonFiniteFloats != "" {
+		if err := server.SetNonFiniteFloats(cfg.API.NonFiniteFloats); err != nil {
+			log.Fatalf("Invalid API config: %v", err)
+		}
+	}
+	if cfg.API.Detection.MaxConcurrent != 0 {
+		server.SetDetectionConcurrency(cfg.API.Detection.MaxConcurrent, cfg.API.Detection.QueueTimeout)
+	}
+	server.SetAnalyzeDefaults(cfg.API.Analyze.DefaultWindow, cfg.API.Analyze.TargetPoints)
+	if persistence := cfg.API.AnomalyPersistence; persistence.Path != "" {
+		sink, err := api.NewFileAnomalySink(persistence.Path)
+		if err != nil {
+			log.Fatalf("Failed to open anomaly file: %v", err)
+		}
+		server.SetAnomalyPersistence(sink, api.AnomalyWriterConfig{
+			BatchSize:     persistence.BatchSize,
+			FlushInterval: persistence.FlushInterval,
+			QueueSize:     persistence.QueueSize,
+		})
+	}
+	if len(cfg.Profiles) > 0 {
+		if err := server.SetDetectorProfiles(toDetectorProfiles(cfg.Profiles)); err != nil {
+			log.Fatalf("Invalid detector profiles: %v", err)
+		}
+	}
+	if len(cfg.SavedQueries) > 0 {
+		if err := server.SetSavedQueries(toSavedQueries(cfg.SavedQueries)); err != nil {
+			log.Fatalf("Invalid saved queries: %v", err)
+		}
+	}
+
+	if len(cfg.AlertRoutes) > 0 {
+		server.SetAlertRoutes(toAlertRoutes(cfg.AlertRoutes))
+	}
+
+	if len(cfg.Enrichment) > 0 {
+		if err := server.SetAnomalyEnrichment(toEnrichmentRules(cfg.Enrichment)); err != nil {
+			log.Fatalf("Invalid anomaly enrichment: %v", err)
+		}
+	}
+
+	// Эффективная конфигурация (со скрытыми секретами) для GET /api/config
+	if effective, err := cfg.RedactedMap(); err != nil {
+		log.Printf("Warning: Failed to prepare effective config: %v", err)
+	} else {
+		server.SetEffectiveConfig(effective)
+	}
+
+	// Корреляция аномалий логов и метрик в инциденты
+	var correlator *api.Correlator
+	if cfg.Correlation.Enabled {
+		correlator = server.EnableCorrelation(cfg.Correlation.Window, cfg.Correlation.MatchLabels)
+	}
 
 	// Инициализируем Prometheus коллектор, если включен
+	// Коллектор работает с первым источником, остальные доступны в check/analyze по имени
 	var promDetector *detector.PrometheusAnomalyDetector
+	promSources := cfg.Prometheus.SourceList()
 	if cfg.Prometheus.Enabled {
-		promDetector, err = initPrometheusDetector(ctx, cfg.Prometheus.URL, orch)
+		promDetector, err = initPrometheusDetector(ctx, promSources[0].URL, toTLSConfig(promSources[0].TLS), orch, server)
 		if err != nil {
 			log.Printf("Warning: Failed to initialize Prometheus detector: %v", err)
 		} else {
-			log.Printf("Prometheus integration started with URL: %s", cfg.Prometheus.URL)
+			log.Printf("Prometheus integration started with URL: %s", promSources[0].URL)
+			// Экземпляр не готов (/ready), пока сбор метрик не запущен и Prometheus не ответил
+			api.RegisterReadinessCheck("prometheus", promDetector.Ready)
+			if correlator != nil {
+				correlator.WatchPrometheus(promDetector)
+			}
 		}
 	}
 
 	// Инициализируем Loki коллектор, если включен
 	var logsDetector *detector.LogsAnomalyDetector
+	var logsDone <-chan struct{}
 	if cfg.Loki.Enabled {
-		logsDetector, err = initLokiDetector(ctx, cfg.Loki.URL, *lokiPatternsPath, orch)
+		logsDetector, logsDone, err = initLokiDetector(ctx, cfg.Loki, *lokiPatternsPath, orch, server, correlator)
 		if err != nil {
 			log.Printf("Warning: Failed to initialize Loki detector: %v", err)
 		} else {
@@ -73,18 +178,63 @@ func main() {
 		}
 	}
 
-	// Создаем сервер API
-	server := api.NewServer(orch)
-
 	// Регистрируем детекторы в API
 	if promDetector != nil {
 		server.RegisterPrometheusDetector(promDetector)
+		server.RegisterPrometheusSource(promSources[0].Name, promDetector)
+		for _, source := range promSources[1:] {
+			sourceDetector, err := detector.NewPrometheusAnomalyDetectorWithTLS(source.URL, time.Minute, toTLSConfig(source.TLS))
+			if err != nil {
+				log.Printf("Warning: Failed to initialize Prometheus source %s: %v", source.Name, err)
+				continue
+			}
+			server.RegisterPrometheusSource(source.Name, sourceDetector)
+			log.Printf("Prometheus source %s registered with URL: %s", source.Name, source.URL)
+		}
 	}
 
 	if logsDetector != nil {
 		server.RegisterLogsDetector(logsDetector)
 	}
 
+	// Менеджер источников данных обслуживает /api/datasources; метрики, оставшиеся
+	// в его буфере, проверяет детектор Prometheus (в том числе при остановке).
+	// Конвейер метрик передает значения детекторам API, а их аномалии - на callback URL
+	if cfg.Prometheus.Enabled || cfg.Loki.Enabled {
+		dataSources, err := initDataSourceManager(cfg, promSources, server.PipelineDetectorStore())
+		if err != nil {
+			log.Printf("Warning: Failed to initialize data source manager: %v", err)
+		} else {
+			if promDetector != nil {
+				dataSources.SetBufferFlushHandler(promDetector.ProcessMetrics)
+			}
+			dataSources.SetAnomalyHandler(func(detectorID string, point datasource.DataPoint, result datasource.DetectionResult) {
+				if anomaly, ok := result.Anomaly.(*detector.Anomaly); ok {
+					server.NotifyDetection(detectorID, point.Value, anomaly)
+				}
+			})
+			if err := dataSources.Start(ctx); err != nil {
+				log.Printf("Warning: Failed to start data source manager: %v", err)
+			}
+			server.RegisterDataSourceAPI(api.NewDataSourceAPI(dataSources))
+		}
+	}
+
+	// Экспортируем метрики детекторов для Prometheus
+	if err := server.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+		log.Printf("Warning: Failed to register detector metrics: %v", err)
+	}
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.Handler())
+	metricsServer := &http.Server{Addr: *metricsAddr, Handler: metricsMux}
+
+	go func() {
+		log.Printf("Starting metrics server on %s", *metricsAddr)
+		if err := metricsServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Printf("Metrics server error: %v", err)
+		}
+	}()
+
 	// Запускаем HTTP сервер
 	go func() {
 		log.Printf("Starting HTTP server on %s", *listenAddr)
@@ -93,6 +243,31 @@ func main() {
 		}
 	}()
 
+	// Запускаем gRPC-сервис потоковой детекции, если задан порт
+	grpcServer := grpcingest.NewServer(server)
+	if cfg.API.GRPCPort > 0 {
+		grpcAddr := fmt.Sprintf("%s:%d", cfg.API.Host, cfg.API.GRPCPort)
+		grpcListener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC on %s: %v", grpcAddr, err)
+		}
+		go func() {
+			log.Printf("Starting gRPC server on %s", grpcAddr)
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				log.Printf("gRPC server error: %v", err)
+			}
+		}()
+	}
+
+	// SIGUSR1 переключает уровень логирования API между debug и прежним
+	logLevelSignal := make(chan os.Signal, 1)
+	signal.Notify(logLevelSignal, syscall.SIGUSR1)
+	go func() {
+		for range logLevelSignal {
+			log.Printf("API log level set to %s", api.ToggleDebugLogging())
+		}
+	}()
+
 	// Ожидаем сигнала завершения
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
@@ -108,17 +283,40 @@ func main() {
 	if err := server.Stop(shutdownCtx); err != nil {
 		log.Printf("HTTP server shutdown error: %v", err)
 	}
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Metrics server shutdown error: %v", err)
+	}
+	stopGRPCServer(shutdownCtx, grpcServer)
 
-	// Останавливаем детекторы
+	// Останавливаем детекторы; аномалии логов, обнаруженные до остановки, обрабатываются
 	if promDetector != nil {
 		promDetector.Stop()
 	}
+	cancel()
+	if logsDone != nil {
+		select {
+		case <-logsDone:
+		case <-shutdownCtx.Done():
+			log.Printf("Log anomaly processing shutdown error: %v", shutdownCtx.Err())
+		}
+	}
+
+	// Отправляем накопленные сводки уведомлений
+	if err := notifHandler.FlushDigests(shutdownCtx); err != nil {
+		log.Printf("Notification digest flush error: %v", err)
+	}
+
+	// Отправляем оставшиеся спаны
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Tracing shutdown error: %v", err)
+	}
 
 	log.Println("Shutdown complete")
 }
 
-// initActionHandlers инициализирует обработчики действий для оркестратора
-func initActionHandlers(orch *orchestrator.Orchestrator, scriptsDir, kubeconfigPath, slackWebhook string) {
+// initActionHandlers инициализирует обработчики действий для оркестратора и
+// возвращает обработчик уведомлений
+func initActionHandlers(orch *orchestrator.Orchestrator, scriptsDir, kubeconfigPath, slackWebhook string, routes []orchestrator.NotificationRoute, digest orchestrator.DigestConfig) *orchestrator.NotificationHandler {
 	// Обработчик для скриптов
 	scriptHandler := orchestrator.NewScriptHandler(scriptsDir)
 	orch.RegisterHandler(scriptHandler)
@@ -145,14 +343,159 @@ func initActionHandlers(orch *orchestrator.Orchestrator, scriptsDir, kubeconfigP
 	if slackWebhook != "" {
 		notifHandler.SetDefaultSlackWebhook(slackWebhook)
 	}
+	if err := notifHandler.SetRoutes(routes); err != nil {
+		log.Fatalf("Invalid notification routes: %v", err)
+	}
+	if err := notifHandler.SetDigest(digest); err != nil {
+		log.Fatalf("Invalid notification digest: %v", err)
+	}
 	orch.RegisterHandler(notifHandler)
+	return notifHandler
+}
+
+// toDigestConfig преобразует настройки сводки уведомлений из конфигурации
+func toDigestConfig(cfg config.NotificationDigestConfig) orchestrator.DigestConfig {
+	return orchestrator.DigestConfig{Window: cfg.Window, TopMetrics: cfg.TopMetrics}
+}
+
+// toNotificationRoutes преобразует правила маршрутизации уведомлений из конфигурации
+func toNotificationRoutes(routes []config.NotificationRouteConfig) []orchestrator.NotificationRoute {
+	result := make([]orchestrator.NotificationRoute, len(routes))
+	for i, route := range routes {
+		result[i] = orchestrator.NotificationRoute{
+			Match:       route.Match,
+			Type:        route.Type,
+			WebhookURL:  route.WebhookURL,
+			ToAddresses: route.To,
+		}
+	}
+	return result
 }
 
-// initPrometheusDetector инициализирует детектор аномалий для Prometheus
-func initPrometheusDetector(ctx context.Context, promURL string, orch *orchestrator.Orchestrator) (*detector.PrometheusAnomalyDetector, error) {
+// toTLSConfig преобразует настройки TLS из конфигурации в настройки источников данных
+func toTLSConfig(cfg config.TLSConfig) *datasource.TLSConfig {
+	return &datasource.TLSConfig{
+		CertFile:           cfg.CertFile,
+		KeyFile:            cfg.KeyFile,
+		CAFile:             cfg.CAFile,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+}
+
+// toCORSConfig дополняет настройки CORS по умолчанию значениями из конфигурации
+func toCORSConfig(cfg config.CORSConfig) api.CORSConfig {
+	cors := api.DefaultCORSConfig()
+	if len(cfg.AllowedOrigins) > 0 {
+		cors.AllowedOrigins = cfg.AllowedOrigins
+	}
+	if len(cfg.AllowedMethods) > 0 {
+		cors.AllowedMethods = cfg.AllowedMethods
+	}
+	if len(cfg.AllowedHeaders) > 0 {
+		cors.AllowedHeaders = cfg.AllowedHeaders
+	}
+	if cfg.MaxAge > 0 {
+		cors.MaxAge = cfg.MaxAge
+	}
+	cors.AllowCredentials = cfg.AllowCredentials
+	return cors
+}
+
+// toWebSocketConfig преобразует настройки шлюза событий из конфигурации
+func toWebSocketConfig(cfg config.WebSocketConfig) api.WebSocketConfig {
+	return api.WebSocketConfig{
+		MaxConnections:          cfg.MaxConnections,
+		DedupWindow:             cfg.DedupWindow,
+		QueueCapacity:           cfg.QueueCapacity,
+		SendTimeout:             cfg.SendTimeout,
+		BatchInterval:           cfg.BatchInterval,
+		StreamHeartbeatInterval: cfg.StreamHeartbeatInterval,
+	}
+}
+
+// toRetryPolicy преобразует политику повторов из конфигурации (nil, если повторы отключены)
+func toRetryPolicy(cfg config.RetryConfig) *orchestrator.RetryPolicy {
+	if cfg.MaxRetries <= 0 {
+		return nil
+	}
+	return &orchestrator.RetryPolicy{
+		MaxRetries:    cfg.MaxRetries,
+		RetryInterval: cfg.Interval,
+		MaxInterval:   cfg.MaxInterval,
+		Multiplier:    cfg.Multiplier,
+		Jitter:        cfg.Jitter,
+		MaxDuration:   cfg.MaxDuration,
+	}
+}
+
+// toDetectorProfiles преобразует профили из конфигурации в профили детекторов
+func toDetectorProfiles(profiles map[string]map[string]config.DetectorProfileConfig) map[string]detector.DetectorProfile {
+	result := make(map[string]detector.DetectorProfile, len(profiles))
+	for name, types := range profiles {
+		profile := detector.DetectorProfile{
+			Name:  name,
+			Types: make(map[detector.DetectorType]detector.ProfileSettings, len(types)),
+		}
+		for detectorType, settings := range types {
+			profile.Types[detector.DetectorType(detectorType)] = detector.ProfileSettings{
+				Threshold:  settings.Threshold,
+				Parameters: settings.Parameters,
+			}
+		}
+		result[name] = profile
+	}
+	return result
+}
+
+// toSavedQueries преобразует шаблоны запросов из конфигурации
+func toSavedQueries(queries map[string]config.SavedQueryConfig) []api.SavedQuery {
+	result := make([]api.SavedQuery, 0, len(queries))
+	for name, query := range queries {
+		result = append(result, api.SavedQuery{
+			Name:        name,
+			Source:      query.Source,
+			Query:       query.Query,
+			Description: query.Description,
+		})
+	}
+	return result
+}
+
+// toAlertRoutes преобразует правила алертов Alertmanager из конфигурации
+func toAlertRoutes(routes []config.AlertRouteConfig) []api.AlertRoute {
+	result := make([]api.AlertRoute, len(routes))
+	for i, route := range routes {
+		result[i] = api.AlertRoute{
+			AlertName: route.AlertName,
+			Severity:  route.Severity,
+			Action: orchestrator.Action{
+				Type:       orchestrator.ActionType(route.Action.Type),
+				Target:     route.Action.Target,
+				Namespace:  route.Action.Namespace,
+				Parameters: route.Action.Parameters,
+				Timeout:    route.Action.Timeout,
+			},
+		}
+	}
+	return result
+}
+
+// toEnrichmentRules преобразует правила обогащения аномалий из конфигурации
+func toEnrichmentRules(rules []config.EnrichmentRuleConfig) []api.EnrichmentRule {
+	result := make([]api.EnrichmentRule, len(rules))
+	for i, rule := range rules {
+		result[i] = api.EnrichmentRule{Match: rule.Match, Fields: rule.Fields}
+	}
+	return result
+}
+
+// initPrometheusDetector инициализирует детектор аномалий для Prometheus;
+// аномалии обогащаются правилами сервера API перед уведомлением
+func initPrometheusDetector(ctx context.Context, promURL string, tlsConfig *datasource.TLSConfig, orch *orchestrator.Orchestrator, server *api.Server) (*detector.PrometheusAnomalyDetector, error) {
 	collectInterval := 1 * time.Minute
 
-	promDetector, err := detector.NewPrometheusAnomalyDetector(promURL, collectInterval)
+	promDetector, err := detector.NewPrometheusAnomalyDetectorWithTLS(promURL, collectInterval, tlsConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Prometheus detector: %w", err)
 	}
@@ -162,22 +505,17 @@ func initPrometheusDetector(ctx context.Context, promURL string, orch *orchestra
 		log.Printf("Detected anomaly: %s, value: %f, score: %f",
 			anomaly.MetricName, anomaly.Value, anomaly.Score)
 
-		// Запускаем действия по устранению аномалии через оркестратор
-		action := orchestrator.Action{
-			Type: "notification",
-			Parameters: map[string]string{
-				"title":     "Prometheus Anomaly Alert",
-				"message":   anomaly.Description,
-				"level":     "warning",
-				"source":    "prometheus",
-				"metric":    anomaly.MetricName,
-				"value":     fmt.Sprintf("%.2f", anomaly.Value),
-				"score":     fmt.Sprintf("%.2f", anomaly.Score),
-				"timestamp": anomaly.Timestamp.Format(time.RFC3339),
-			},
+		// Обогащаем аномалию по ее меткам (команда, runbook)
+		fields := server.EnrichmentFields(anomaly.Labels)
+		if len(fields) > 0 {
+			if anomaly.Details == nil {
+				anomaly.Details = make(map[string]interface{})
+			}
+			anomaly.Details["enrichment"] = fields
 		}
 
-		_, err := orch.ExecuteAction(ctx, action)
+		// Запускаем действия по устранению аномалии через оркестратор
+		_, err := orch.ExecuteAction(ctx, prometheusAnomalyAction(anomaly, fields))
 		if err != nil {
 			log.Printf("Failed to execute action for anomaly: %v", err)
 		}
@@ -191,12 +529,59 @@ func initPrometheusDetector(ctx context.Context, promURL string, orch *orchestra
 	return promDetector, nil
 }
 
+// prometheusAnomalyAction создает уведомление об аномалии Prometheus; серьезность
+// берется из аномалии, чтобы маршруты по severity выбирали получателя
+func prometheusAnomalyAction(anomaly *detector.AnomalyEvent, fields map[string]string) orchestrator.Action {
+	severity := anomaly.Severity
+	if severity == "" {
+		severity = "warning"
+	}
+
+	action := orchestrator.Action{
+		Type: orchestrator.ActionNotify,
+		Parameters: map[string]string{
+			"subject":   "Prometheus Anomaly Alert",
+			"message":   anomaly.Description,
+			"severity":  severity,
+			"source":    "prometheus",
+			"metric":    anomaly.MetricName,
+			"value":     fmt.Sprintf("%.2f", anomaly.Value),
+			"score":     fmt.Sprintf("%.2f", anomaly.Score),
+			"timestamp": anomaly.Timestamp.Format(time.RFC3339),
+		},
+	}
+	setNotificationLabels(action.Parameters, anomaly.Labels)
+	setEnrichmentFields(action.Parameters, fields)
+	return action
+}
+
+// initDataSourceManager создает менеджер источников Prometheus и Loki из конфигурации
+func initDataSourceManager(cfg *config.Config, promSources []config.PrometheusSourceConfig, detectorStore datasource.DetectorStore) (*datasource.DataSourceManager, error) {
+	dsConfig := datasource.DefaultDataSourceConfig()
+	dsConfig.EnableMetrics = cfg.Prometheus.Enabled
+	dsConfig.PrometheusSources = make([]datasource.PrometheusServer, 0, len(promSources))
+	for _, source := range promSources {
+		dsConfig.PrometheusSources = append(dsConfig.PrometheusSources, datasource.PrometheusServer{
+			Name: source.Name,
+			URL:  source.URL,
+			TLS:  toTLSConfig(source.TLS),
+		})
+	}
+	dsConfig.EnableLogs = cfg.Loki.Enabled
+	dsConfig.LokiURL = cfg.Loki.URL
+	dsConfig.LokiTLS = toTLSConfig(cfg.Loki.TLS)
+	dsConfig.LogLevelFields = cfg.Loki.LevelFields
+
+	return datasource.NewDataSourceManager(dsConfig, detectorStore)
+}
+
 // initLokiDetector инициализирует детектор аномалий для логов
-func initLokiDetector(ctx context.Context, lokiURL, patternsPath string, orch *orchestrator.Orchestrator) (*detector.LogsAnomalyDetector, error) {
+// Возвращаемый канал закрывается, когда после отмены ctx обработаны все обнаруженные аномалии
+func initLokiDetector(ctx context.Context, lokiCfg config.LokiConfig, patternsPath string, orch *orchestrator.Orchestrator, server *api.Server, correlator *api.Correlator) (*detector.LogsAnomalyDetector, <-chan struct{}, error) {
 	// Загружаем шаблоны и настройки
 	patterns, err := config.LoadLokiPatterns(patternsPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load Loki patterns: %w", err)
+		return nil, nil, fmt.Errorf("failed to load Loki patterns: %w", err)
 	}
 
 	// Создаем функцию обратного вызова для обработки логов
@@ -206,11 +591,13 @@ func initLokiDetector(ctx context.Context, lokiURL, patternsPath string, orch *o
 	}
 
 	// Создаем коллектор логов
-	collector, err := datasource.NewLokiCollector(lokiURL, 1*time.Minute, 5*time.Minute, logCallback)
+	collector, err := datasource.NewLokiCollectorWithTLS(lokiCfg.URL, 1*time.Minute, 5*time.Minute, logCallback, toTLSConfig(lokiCfg.TLS))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Loki collector: %w", err)
+		return nil, nil, fmt.Errorf("failed to create Loki collector: %w", err)
 	}
 
+	collector.SetLevelFields(lokiCfg.LevelFields...)
+
 	// Создаем детектор аномалий
 	logsDetector, err := detector.NewLogsAnomalyDetector(
 		patterns.Thresholds.Errors.Warning,
@@ -218,41 +605,76 @@ func initLokiDetector(ctx context.Context, lokiURL, patternsPath string, orch *o
 		time.Duration(patterns.Thresholds.TimeWindow)*time.Minute,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create logs detector: %w", err)
+		return nil, nil, fmt.Errorf("failed to create logs detector: %w", err)
 	}
 
 	// Устанавливаем коллектор Loki
 	logsDetector.SetLokiCollector(collector)
 
-	// Регистрируем шаблоны
+	// Недавние аномалии хранятся ограниченное время и удаляются в фоне
+	logsDetector.SetAnomalyRetention(lokiCfg.AnomalyRetention, lokiCfg.MaxAnomalies)
+	logsDetector.StartAnomalyPruning(ctx, time.Minute)
+
+	// Регистрируем шаблоны; сверх лимита шаблоны не добавляются
+	if lokiCfg.MaxPatterns != 0 {
+		logsDetector.SetMaxPatterns(lokiCfg.MaxPatterns)
+	}
 	for _, pattern := range patterns.Patterns {
 		if err := logsDetector.AddPattern(pattern.Pattern, pattern.Severity, pattern.Description, pattern.Labels); err != nil {
 			log.Printf("Failed to add pattern '%s': %v", pattern.Name, err)
 		}
 	}
 
+	// Регистрируем пороги для отдельных наборов меток
+	for _, override := range patterns.Thresholds.Overrides {
+		if err := logsDetector.AddThresholdOverride(override.Labels, override.Errors, override.Warnings); err != nil {
+			log.Printf("Failed to add threshold override for %v: %v", override.Labels, err)
+		}
+	}
+
 	// Регистрируем запросы
 	for _, query := range patterns.Queries {
 		collector.AddQuery(query.Name, query.Query)
 	}
 
-	// Запускаем коллектор
+	// Запускаем коллектор; экземпляр не готов, пока сбор логов не запущен и Loki не ответил
 	collector.Start(ctx)
+	api.RegisterReadinessCheck("loki", collector.Ready)
 
 	// Обрабатываем аномалии
+	// После отмены ctx обрабатываем уже обнаруженные аномалии и закрываем done
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
+
+		process := func(ctx context.Context, anomaly detector.Anomaly) {
+			server.EnrichAnomaly(&anomaly)
+			handleLogAnomaly(ctx, anomaly, orch)
+			if correlator != nil {
+				correlator.AddLogAnomaly(anomaly)
+			}
+		}
+
 		anomalyChan := logsDetector.GetAnomalyChan()
 		for {
 			select {
 			case <-ctx.Done():
-				return
+				drainCtx := context.WithoutCancel(ctx)
+				for {
+					select {
+					case anomaly := <-anomalyChan:
+						process(drainCtx, anomaly)
+					default:
+						return
+					}
+				}
 			case anomaly := <-anomalyChan:
-				handleLogAnomaly(ctx, anomaly, orch)
+				process(ctx, anomaly)
 			}
 		}
 	}()
 
-	return logsDetector, nil
+	return logsDetector, done, nil
 }
 
 // handleLogAnomaly обрабатывает аномалию в логах
@@ -266,7 +688,7 @@ func handleLogAnomaly(ctx context.Context, anomaly detector.Anomaly, orch *orche
 		Parameters: map[string]string{
 			"title":     "Log Anomaly Alert",
 			"message":   fmt.Sprintf("Detected log anomaly: %s", anomaly.Type),
-			"level":     anomaly.Severity,
+			"severity":  anomaly.Severity,
 			"source":    anomaly.Source,
 			"type":      anomaly.Type,
 			"value":     fmt.Sprintf("%.2f", anomaly.Value),
@@ -274,9 +696,49 @@ func handleLogAnomaly(ctx context.Context, anomaly detector.Anomaly, orch *orche
 			"timestamp": anomaly.Timestamp.Format(time.RFC3339),
 		},
 	}
+	if labels, ok := anomaly.Details["labels"].(map[string]string); ok {
+		setNotificationLabels(action.Parameters, labels)
+	}
+	if fields, ok := anomaly.Details["enrichment"].(map[string]string); ok {
+		setEnrichmentFields(action.Parameters, fields)
+	}
 
 	_, err := orch.ExecuteAction(ctx, action)
 	if err != nil {
 		log.Printf("Failed to execute action for log anomaly: %v", err)
 	}
 }
+
+// setNotificationLabels добавляет метки аномалии в параметры уведомления (label_*),
+// чтобы маршруты уведомлений могли выбирать канал по меткам
+func setNotificationLabels(params map[string]string, labels map[string]string) {
+	for name, value := range labels {
+		params[orchestrator.LabelParameterPrefix+name] = value
+	}
+}
+
+// setEnrichmentFields добавляет поля обогащения аномалии (команда, runbook) в
+// параметры уведомления, не перезаписывая уже заданные параметры
+func setEnrichmentFields(params map[string]string, fields map[string]string) {
+	for name, value := range fields {
+		if _, exists := params[name]; !exists {
+			params[name] = value
+		}
+	}
+}
+
+// stopGRPCServer дожидается завершения активных потоков детекции, а по
+// истечении ctx закрывает их принудительно
+func stopGRPCServer(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
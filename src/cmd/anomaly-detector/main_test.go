@@ -1,127 +1,73 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
 	"net/http"
-	"os"
+	"net/http/httptest"
 	"testing"
 	"time"
 
-	"github.com/yourusername/aiops-infra/src/internal/api"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+	"github.com/yourusername/aiops-infra/src/internal/orchestrator"
 )
 
-func TestAnomalyDetectorService(t *testing.T) {
-	// Start the service in a goroutine
-	go main()
-
-	// Wait for the service to start
-	time.Sleep(2 * time.Second)
-
-	// Test health endpoint
-	t.Run("health check", func(t *testing.T) {
-		resp, err := http.Get("http://localhost:8080/health")
-		if err != nil {
-			t.Fatalf("Failed to call health endpoint: %v", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			t.Errorf("Expected status OK, got %v", resp.Status)
-		}
-	})
-
-	// Test metrics endpoint
-	t.Run("metrics endpoint", func(t *testing.T) {
-		resp, err := http.Get("http://localhost:8080/metrics")
-		if err != nil {
-			t.Fatalf("Failed to call metrics endpoint: %v", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			t.Errorf("Expected status OK, got %v", resp.Status)
-		}
-	})
-
-	// Test configuration update
-	t.Run("config update", func(t *testing.T) {
-		config := api.DetectorConfig{
-			Mean:      75,
-			StdDev:    12,
-			Threshold: 2,
-			DataType:  "cpu",
-		}
-
-		body, err := json.Marshal(config)
-		if err != nil {
-			t.Fatalf("Failed to marshal config: %v", err)
-		}
-
-		resp, err := http.Post(
-			"http://localhost:8080/api/v1/config?name=cpu",
-			"application/json",
-			bytes.NewBuffer(body),
-		)
-		if err != nil {
-			t.Fatalf("Failed to update config: %v", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			t.Errorf("Expected status OK, got %v", resp.Status)
-		}
+func TestPrometheusAnomalyAction_Routing(t *testing.T) {
+	received := make(map[string]int)
+	newReceiver := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received[name]++
+		}))
+	}
+	pager, team := newReceiver("pager"), newReceiver("team")
+	defer pager.Close()
+	defer team.Close()
+
+	handler := orchestrator.NewNotificationHandler()
+	err := handler.SetRoutes([]orchestrator.NotificationRoute{
+		{Match: map[string]string{"severity": "critical"}, Type: "webhook", WebhookURL: pager.URL},
+		{Match: map[string]string{"team": "payments"}, Type: "webhook", WebhookURL: team.URL},
 	})
+	if err != nil {
+		t.Fatalf("SetRoutes failed: %v", err)
+	}
 
-	// Test invalid configuration update
-	t.Run("invalid config update", func(t *testing.T) {
-		config := api.DetectorConfig{
-			Mean:      75,
-			StdDev:    -12, // Negative StdDev should fail
-			Threshold: 2,
-			DataType:  "cpu",
+	for _, tt := range []struct {
+		severity string
+		want     string
+	}{
+		{severity: "critical", want: "pager"},
+		{severity: "warning", want: "team"},
+	} {
+		anomaly := &detector.AnomalyEvent{
+			MetricName: "http_request_duration_seconds",
+			Timestamp:  time.Now(),
+			Value:      2.5,
+			Labels:     map[string]string{"team": "payments"},
+			Score:      7,
+			Severity:   tt.severity,
 		}
-
-		body, err := json.Marshal(config)
-		if err != nil {
-			t.Fatalf("Failed to marshal config: %v", err)
+		action := prometheusAnomalyAction(anomaly, nil)
+		if !handler.CanHandle(action.Type) {
+			t.Fatalf("expected the notification handler to accept %s actions", action.Type)
 		}
 
-		resp, err := http.Post(
-			"http://localhost:8080/api/v1/config?name=cpu",
-			"application/json",
-			bytes.NewBuffer(body),
-		)
-		if err != nil {
-			t.Fatalf("Failed to call config endpoint: %v", err)
+		before := received[tt.want]
+		if _, err := handler.Execute(context.Background(), action); err != nil {
+			t.Fatalf("%s notification failed: %v", tt.severity, err)
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusBadRequest {
-			t.Errorf("Expected status BadRequest, got %v", resp.Status)
+		if received[tt.want] != before+1 {
+			t.Errorf("expected the %s anomaly to be routed to %s, got %v", tt.severity, tt.want, received)
 		}
-	})
+	}
 }
 
-func TestMain(m *testing.M) {
-	// Create a context with timeout for all tests
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Run tests
-	done := make(chan int, 1)
-	go func() {
-		done <- m.Run()
-	}()
+func TestPrometheusAnomalyAction_DefaultSeverity(t *testing.T) {
+	action := prometheusAnomalyAction(&detector.AnomalyEvent{MetricName: "cpu"}, map[string]string{"severity": "critical", "runbook": "https://runbooks/cpu"})
 
-	// Wait for either context timeout or test completion
-	select {
-	case <-ctx.Done():
-		fmt.Println("Tests timed out")
-		os.Exit(1)
-	case code := <-done:
-		os.Exit(code)
+	if action.Parameters["severity"] != "warning" {
+		t.Errorf("expected an ungraded anomaly to be a warning, got %q", action.Parameters["severity"])
+	}
+	if action.Parameters["runbook"] != "https://runbooks/cpu" {
+		t.Errorf("expected enrichment fields to be added, got %v", action.Parameters)
 	}
 }
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/orchestrator"
+)
+
+// AlertmanagerWebhook is the standard Alertmanager webhook payload (version 4)
+type AlertmanagerWebhook struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	TruncatedAlerts   int                 `json:"truncatedAlerts"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []AlertmanagerAlert `json:"alerts" binding:"required"`
+}
+
+// AlertmanagerAlert is a single alert within an Alertmanager webhook payload
+type AlertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// AlertRoute maps alerts to an orchestrator action.
+// Empty AlertName or Severity match any value.
+type AlertRoute struct {
+	AlertName string              `json:"alertname,omitempty"`
+	Severity  string              `json:"severity,omitempty"`
+	Action    orchestrator.Action `json:"action"`
+}
+
+// AlertIngestResult reports what was done for a single alert
+type AlertIngestResult struct {
+	AlertName   string                     `json:"alertname"`
+	Fingerprint string                     `json:"fingerprint,omitempty"`
+	Status      string                     `json:"status"`
+	ActionType  orchestrator.ActionType    `json:"action_type,omitempty"`
+	Target      string                     `json:"target,omitempty"`
+	Result      *orchestrator.ActionResult `json:"result,omitempty"`
+	Error       string                     `json:"error,omitempty"`
+}
+
+// alertRouter holds the configured alert-to-action routes
+type alertRouter struct {
+	routes []AlertRoute
+	mu     sync.RWMutex
+}
+
+// SetAlertRoutes replaces the routes used to map Alertmanager alerts to actions.
+// Alerts matching no route produce a notification action.
+func (s *Server) SetAlertRoutes(routes []AlertRoute) {
+	s.alertRouter.mu.Lock()
+	defer s.alertRouter.mu.Unlock()
+
+	s.alertRouter.routes = make([]AlertRoute, len(routes))
+	copy(s.alertRouter.routes, routes)
+}
+
+// match returns the first route matching the alert labels
+func (r *alertRouter) match(labels map[string]string) (AlertRoute, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, route := range r.routes {
+		if route.AlertName != "" && route.AlertName != labels["alertname"] {
+			continue
+		}
+		if route.Severity != "" && route.Severity != labels["severity"] {
+			continue
+		}
+		return route, true
+	}
+	return AlertRoute{}, false
+}
+
+// handleAlertmanagerWebhook turns Alertmanager alerts into orchestrator actions.
+// Firing alerts run the routed action; resolved alerts only produce notifications.
+// The response is always 200 once the payload is valid so Alertmanager does not
+// redeliver alerts whose actions already ran; per-alert outcomes are in the body.
+func (s *Server) handleAlertmanagerWebhook(c *gin.Context) {
+	var payload AlertmanagerWebhook
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]AlertIngestResult, 0, len(payload.Alerts))
+	for _, alert := range payload.Alerts {
+		labels := mergeLabels(payload.CommonLabels, alert.Labels)
+		annotations := mergeLabels(payload.CommonAnnotations, alert.Annotations)
+
+		result := AlertIngestResult{
+			AlertName:   labels["alertname"],
+			Fingerprint: alert.Fingerprint,
+		}
+
+		action := s.actionForAlert(alert, labels, annotations)
+		if alert.Status == "resolved" && action.Type != orchestrator.ActionNotify {
+			result.Status = "skipped"
+			result.ActionType = action.Type
+			result.Target = action.Target
+			results = append(results, result)
+			continue
+		}
+
+		result.ActionType = action.Type
+		result.Target = action.Target
+
+		actionResult, err := s.orchestrator.ExecuteAction(c.Request.Context(), action)
+		result.Result = actionResult
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+		} else {
+			result.Status = "executed"
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"receiver": payload.Receiver,
+		"status":   payload.Status,
+		"results":  results,
+		"count":    len(results),
+	})
+}
+
+// actionForAlert builds the orchestrator action for an alert from the matching route
+func (s *Server) actionForAlert(alert AlertmanagerAlert, labels, annotations map[string]string) orchestrator.Action {
+	route, found := s.alertRouter.match(labels)
+
+	action := orchestrator.Action{Type: orchestrator.ActionNotify}
+	if found {
+		action = route.Action
+	}
+
+	// Copy parameters so the route template is not modified
+	params := make(map[string]string, len(action.Parameters)+len(labels)+6)
+	for k, v := range action.Parameters {
+		params[k] = v
+	}
+	// Labels select the notification route
+	for k, v := range labels {
+		params[orchestrator.LabelParameterPrefix+k] = v
+	}
+	params["alertname"] = labels["alertname"]
+	params["severity"] = labels["severity"]
+	params["alert_status"] = alert.Status
+	if alert.Fingerprint != "" {
+		params["fingerprint"] = alert.Fingerprint
+	}
+	if _, ok := params["subject"]; !ok {
+		params["subject"] = fmt.Sprintf("[%s] %s", alert.Status, alertSummary(labels, annotations))
+	}
+	if _, ok := params["message"]; !ok && annotations["description"] != "" {
+		params["message"] = annotations["description"]
+	}
+	action.Parameters = params
+
+	if action.Target == "" {
+		action.Target = alertTarget(labels)
+	}
+
+	return action
+}
+
+// alertSummary returns the summary annotation or falls back to the alert name
+func alertSummary(labels, annotations map[string]string) string {
+	if summary := annotations["summary"]; summary != "" {
+		return summary
+	}
+	return labels["alertname"]
+}
+
+// alertTarget picks the most specific label identifying the affected component
+func alertTarget(labels map[string]string) string {
+	for _, key := range []string{"deployment", "service", "job", "instance", "alertname"} {
+		if v := labels[key]; v != "" {
+			return v
+		}
+	}
+	return "alertmanager"
+}
+
+// mergeLabels overlays specific labels on top of common ones
+func mergeLabels(common, specific map[string]string) map[string]string {
+	merged := make(map[string]string, len(common)+len(specific))
+	for k, v := range common {
+		merged[k] = v
+	}
+	for k, v := range specific {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/orchestrator"
+)
+
+// recordingHandler records the actions it executes
+type recordingHandler struct {
+	mu      sync.Mutex
+	actions []orchestrator.Action
+}
+
+func (h *recordingHandler) Execute(ctx context.Context, action orchestrator.Action) (*orchestrator.ActionResult, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.actions = append(h.actions, action)
+	return &orchestrator.ActionResult{Success: true}, nil
+}
+
+func (h *recordingHandler) CanHandle(actionType orchestrator.ActionType) bool {
+	return true
+}
+
+func TestHandleAlertmanagerWebhook_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &recordingHandler{}
+	orch := orchestrator.NewOrchestrator()
+	orch.RegisterHandler(handler)
+
+	s := &Server{orchestrator: orch}
+	s.SetAlertRoutes([]AlertRoute{
+		{AlertName: "PodCrashLooping", Severity: "critical", Action: orchestrator.Action{
+			Type:       orchestrator.ActionRestart,
+			Namespace:  "prod",
+			Parameters: map[string]string{"reason": "crashloop"},
+		}},
+		{Severity: "critical", Action: orchestrator.Action{Type: orchestrator.ActionNotify, Target: "oncall"}},
+	})
+
+	router := gin.New()
+	router.POST("/api/alerts/alertmanager", s.handleAlertmanagerWebhook)
+
+	payload := `{
+		"receiver": "aiops",
+		"status": "firing",
+		"commonLabels": {"severity": "critical"},
+		"alerts": [
+			{"status": "firing", "labels": {"alertname": "PodCrashLooping", "deployment": "api"}, "fingerprint": "a1"},
+			{"status": "firing", "labels": {"alertname": "DiskFull", "instance": "node-1"}},
+			{"status": "firing", "labels": {"alertname": "HighLatency", "severity": "warning", "service": "web"}},
+			{"status": "resolved", "labels": {"alertname": "PodCrashLooping", "deployment": "api"}}
+		]
+	}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/alerts/alertmanager", strings.NewReader(payload)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Results []AlertIngestResult `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	expected := []struct {
+		status     string
+		actionType orchestrator.ActionType
+		target     string
+	}{
+		{"executed", orchestrator.ActionRestart, "api"},
+		{"executed", orchestrator.ActionNotify, "oncall"},
+		{"executed", orchestrator.ActionNotify, "web"}, // no route matches
+		{"skipped", orchestrator.ActionRestart, "api"}, // resolved alerts are not remediated
+	}
+	if len(resp.Results) != len(expected) {
+		t.Fatalf("expected %d results, got %+v", len(expected), resp.Results)
+	}
+	for i, want := range expected {
+		got := resp.Results[i]
+		if got.Status != want.status || got.ActionType != want.actionType || got.Target != want.target {
+			t.Errorf("alert %d: expected %s %s on %s, got %s %s on %s",
+				i, want.status, want.actionType, want.target, got.Status, got.ActionType, got.Target)
+		}
+	}
+
+	if len(handler.actions) != 3 {
+		t.Fatalf("expected 3 executed actions, got %d", len(handler.actions))
+	}
+	restart := handler.actions[0]
+	if restart.Namespace != "prod" || restart.Parameters["reason"] != "crashloop" || restart.Parameters["fingerprint"] != "a1" {
+		t.Errorf("expected the route's action with the alert parameters, got %+v", restart)
+	}
+}
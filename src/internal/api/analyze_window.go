@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultAnalyzeWindow is the time range analyzed when a request omits start
+	DefaultAnalyzeWindow = time.Hour
+	// DefaultAnalyzeTargetPoints is the number of points an automatically chosen step aims for
+	DefaultAnalyzeTargetPoints = 500
+	// MaxAnalyzePoints bounds the points of a range query; Prometheus itself
+	// rejects queries resolving to more than 11,000 points per series
+	MaxAnalyzePoints = 11000
+)
+
+// analyzeSteps are the resolutions an automatic step is rounded up to
+var analyzeSteps = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// autoStep picks the smallest round step that resolves the range into at most
+// targetPoints points
+func autoStep(start, end time.Time, targetPoints int) time.Duration {
+	if targetPoints <= 0 {
+		targetPoints = DefaultAnalyzeTargetPoints
+	}
+	raw := end.Sub(start) / time.Duration(targetPoints)
+	for _, step := range analyzeSteps {
+		if step >= raw {
+			return step
+		}
+	}
+	// Beyond a day, use whole days
+	days := (raw + 24*time.Hour - 1) / (24 * time.Hour)
+	return days * 24 * time.Hour
+}
+
+// validatePointCount checks that the range resolved at step stays within
+// maxPoints. It returns a VALIDATION_ERROR APIError.
+func validatePointCount(start, end time.Time, step time.Duration, maxPoints int) error {
+	if step <= 0 {
+		return NewValidationError("step", "step must be positive")
+	}
+
+	points := int64(end.Sub(start)/step) + 1
+	if maxPoints > 0 && points > int64(maxPoints) {
+		apiError := NewAPIError(ErrorCodeValidation, "Too many points",
+			fmt.Sprintf("Range of %s at step %s resolves to %d points, more than the maximum of %d",
+				end.Sub(start), step, points, maxPoints))
+		apiError.Context = map[string]interface{}{
+			"points":     points,
+			"max_points": maxPoints,
+			"min_step":   autoStep(start, end, maxPoints).String(),
+		}
+		return apiError
+	}
+	return nil
+}
+
+// SetAnalyzeDefaults sets the time range analyzed when start is omitted and the
+// number of points an automatically chosen step aims for (non-positive values
+// keep the current settings)
+func (s *Server) SetAnalyzeDefaults(window time.Duration, targetPoints int) {
+	if window > 0 {
+		s.perfConfig.AnalyzeWindow = window
+	}
+	if targetPoints > 0 {
+		s.perfConfig.AnalyzeTargetPoints = targetPoints
+	}
+}
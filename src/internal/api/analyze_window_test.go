@@ -0,0 +1,51 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoStep(t *testing.T) {
+	end := time.Unix(1700000000, 0)
+
+	for _, tc := range []struct {
+		span time.Duration
+		want time.Duration
+	}{
+		{5 * time.Minute, time.Second},
+		{time.Hour, 10 * time.Second},
+		{24 * time.Hour, 5 * time.Minute},
+		{7 * 24 * time.Hour, 30 * time.Minute},
+		{2000 * 24 * time.Hour, 4 * 24 * time.Hour},
+	} {
+		step := autoStep(end.Add(-tc.span), end, DefaultAnalyzeTargetPoints)
+		if step != tc.want {
+			t.Errorf("range %s: expected step %s, got %s", tc.span, tc.want, step)
+		}
+		if points := int(tc.span/step) + 1; points > DefaultAnalyzeTargetPoints+1 {
+			t.Errorf("range %s: step %s resolves to %d points", tc.span, step, points)
+		}
+	}
+}
+
+func TestValidatePointCount(t *testing.T) {
+	end := time.Unix(1700000000, 0)
+	start := end.Add(-24 * time.Hour)
+
+	if err := validatePointCount(start, end, time.Minute, MaxAnalyzePoints); err != nil {
+		t.Errorf("expected 1441 points to be accepted, got %v", err)
+	}
+
+	err := validatePointCount(start, end, time.Second, MaxAnalyzePoints)
+	apiError, ok := err.(*APIError)
+	if !ok || apiError.Code != ErrorCodeValidation {
+		t.Fatalf("expected a validation error for 86401 points, got %v", err)
+	}
+	if context, ok := apiError.Context.(map[string]interface{}); !ok || context["min_step"] != "10s" {
+		t.Errorf("expected the error to suggest a 10s step, got %v", apiError.Context)
+	}
+
+	if err := validatePointCount(start, end, 0, MaxAnalyzePoints); err == nil {
+		t.Error("expected a non-positive step to be rejected")
+	}
+}
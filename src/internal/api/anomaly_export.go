@@ -0,0 +1,123 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// anomalyExportBatch is how many records are copied per store lock during an
+// export; the response is flushed after each batch
+const anomalyExportBatch = 256
+
+// Range calls fn for the anomalies matching filter and detected in [from, to),
+// oldest first, stopping at the first error fn returns. Records are copied in
+// batches so the store is not locked while fn runs (e.g. writing to a slow
+// client); anomalies added during the iteration are included, evicted ones
+// are skipped. A zero from or to leaves that end of the range open.
+func (as *AnomalyStore) Range(filter AnomalyFilter, from, to time.Time, fn func(AnomalyRecord) error) error {
+	after := 0
+	for {
+		batch := as.batchAfter(filter, from, to, after)
+		if len(batch) == 0 {
+			return nil
+		}
+		for _, record := range batch {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		after = batch[len(batch)-1].seq
+	}
+}
+
+// batchAfter copies up to anomalyExportBatch matching records inserted after seq
+func (as *AnomalyStore) batchAfter(filter AnomalyFilter, from, to time.Time, seq int) []AnomalyRecord {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	start := sort.Search(len(as.records), func(i int) bool { return as.records[i].seq > seq })
+	batch := make([]AnomalyRecord, 0, anomalyExportBatch)
+	for _, record := range as.records[start:] {
+		if len(batch) == anomalyExportBatch {
+			break
+		}
+		if !filter.matches(record) {
+			continue
+		}
+		if !from.IsZero() && record.DetectedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !record.DetectedAt.Before(to) {
+			continue
+		}
+		batch = append(batch, *record)
+	}
+	return batch
+}
+
+// handleExportAnomalies streams the namespace's stored anomalies as
+// newline-delimited JSON, oldest first, selected by ?from=, ?to= (RFC3339 or
+// unix seconds) and ?detector_id=. Records are written as they are read from
+// the store, so a slow client slows the export down instead of it being buffered.
+func (s *Server) handleExportAnomalies(c *gin.Context) {
+	if format := c.DefaultQuery("format", "ndjson"); format != "ndjson" {
+		HandleValidationError(c, "format", fmt.Sprintf("unsupported format %q (expected ndjson)", format))
+		return
+	}
+
+	from, err := parseTimeParam(c, "from", time.Time{})
+	if err != nil {
+		HandleValidationError(c, "from", err.Error())
+		return
+	}
+	to, err := parseTimeParam(c, "to", time.Time{})
+	if err != nil {
+		HandleValidationError(c, "to", err.Error())
+		return
+	}
+	if !from.IsZero() && !to.IsZero() {
+		if err := validateTimeRange(from, to, 0); err != nil {
+			HandleError(c, err)
+			return
+		}
+	}
+
+	filter := AnomalyFilter{
+		Namespace:  namespaceOf(c),
+		DetectorID: c.Query("detector_id"),
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	ctx := c.Request.Context()
+	written := 0
+	err = s.anomalyStore.Range(filter, from, to, func(record AnomalyRecord) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := marshalFinite(record, s.perfConfig.NonFiniteFloats)
+		if err != nil {
+			return err
+		}
+		if _, err := c.Writer.Write(append(data, '\n')); err != nil {
+			return err
+		}
+		written++
+		if written%anomalyExportBatch == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// The status line is already sent; the client sees a truncated stream
+		globalLogger().Warn("Anomaly export aborted", map[string]interface{}{"written": written, "error": err.Error()})
+		return
+	}
+	c.Writer.Flush()
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestAnomalyStore_Range(t *testing.T) {
+	store := NewAnomalyStore(2 * anomalyExportBatch)
+	for i := 0; i < 2*anomalyExportBatch; i++ {
+		store.Add(DefaultNamespace, "detector_1", "cpu", float64(i), &detector.Anomaly{})
+	}
+
+	// Anomalies added while iterating are picked up by the next batch, and
+	// evicting the oldest ones does not make the iteration repeat or skip records
+	var values []float64
+	err := store.Range(AnomalyFilter{}, time.Time{}, time.Time{}, func(record AnomalyRecord) error {
+		if len(values) == 0 {
+			store.Add(DefaultNamespace, "detector_1", "cpu", float64(2*anomalyExportBatch), &detector.Anomaly{})
+		}
+		values = append(values, record.Value)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("range failed: %v", err)
+	}
+	if len(values) != 2*anomalyExportBatch+1 {
+		t.Fatalf("expected %d records, got %d", 2*anomalyExportBatch+1, len(values))
+	}
+	for i, value := range values {
+		if value != float64(i) {
+			t.Fatalf("expected record %d to have value %d, got %v", i, i, value)
+		}
+	}
+
+	stop := errors.New("client gone")
+	calls := 0
+	err = store.Range(AnomalyFilter{}, time.Time{}, time.Time{}, func(AnomalyRecord) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("expected the iteration to stop at the first error, got %v after %d calls", err, calls)
+	}
+}
+
+func TestHandleExportAnomalies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, detectorID := range []string{"detector_1", "detector_2", "detector_1", "detector_1"} {
+		s.anomalyStore.Add(DefaultNamespace, detectorID, "cpu", float64(i), &detector.Anomaly{})
+		s.anomalyStore.records[len(s.anomalyStore.records)-1].DetectedAt = base.Add(time.Duration(i) * time.Minute)
+	}
+	s.anomalyStore.Add("team-a", "detector_1", "cpu", 99, &detector.Anomaly{})
+
+	router := gin.New()
+	router.GET("/api/anomalies/export", s.handleExportAnomalies)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	w := get("/api/anomalies/export?from=2024-01-01T12:01:00Z&to=2024-01-01T12:03:00Z")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("expected application/x-ndjson, got %q", ct)
+	}
+
+	var values []float64
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var record AnomalyRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		values = append(values, record.Value)
+	}
+	if len(values) != 2 || values[0] != 1 || values[1] != 2 {
+		t.Errorf("expected the anomalies at 12:01 and 12:02, got %v", values)
+	}
+
+	w = get("/api/anomalies/export?detector_id=detector_1")
+	scanner = bufio.NewScanner(w.Body)
+	count := 0
+	for scanner.Scan() {
+		count++
+	}
+	if count != 3 {
+		t.Errorf("expected 3 anomalies of detector_1 in the default namespace, got %d", count)
+	}
+
+	if w := get("/api/anomalies/export?format=csv"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unsupported format, got %d", w.Code)
+	}
+	if w := get("/api/anomalies/export?from=2024-01-01T13:00:00Z&to=2024-01-01T12:00:00Z"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an inverted range, got %d", w.Code)
+	}
+}
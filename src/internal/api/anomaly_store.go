@@ -0,0 +1,338 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// Anomaly workflow states
+const (
+	AnomalyStateOpen         = "open"
+	AnomalyStateAcknowledged = "acknowledged"
+	AnomalyStateResolved     = "resolved"
+)
+
+// DefaultAnomalyStoreCapacity is the number of anomalies kept before the oldest are evicted
+const DefaultAnomalyStoreCapacity = 1000
+
+var (
+	// ErrAnomalyNotFound is returned for an unknown anomaly ID
+	ErrAnomalyNotFound = errors.New("anomaly not found")
+	// ErrInvalidAnomalyState is returned when a state transition is not allowed
+	ErrInvalidAnomalyState = errors.New("invalid anomaly state transition")
+)
+
+// AnomalyRecord is a detected anomaly with a stable ID and workflow state
+type AnomalyRecord struct {
+	ID             string            `json:"id"`
+	Namespace      string            `json:"namespace"`
+	DetectorID     string            `json:"detector_id"`
+	DetectorName   string            `json:"detector_name"`
+	Value          float64           `json:"value"`
+	Anomaly        *detector.Anomaly `json:"anomaly"`
+	State          string            `json:"state"`
+	DetectedAt     time.Time         `json:"detected_at"`
+	AcknowledgedBy string            `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time        `json:"acknowledged_at,omitempty"`
+	ResolvedBy     string            `json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time        `json:"resolved_at,omitempty"`
+	// Shadow is set for anomalies of shadow detectors, which were not published
+	Shadow bool `json:"shadow,omitempty"`
+	// seq orders records by insertion, for resuming iteration across evictions
+	seq int
+}
+
+// AnomalyFilter selects anomalies in AnomalyStore.List; empty fields match everything
+type AnomalyFilter struct {
+	Namespace  string
+	State      string
+	DetectorID string
+	// Shadow, when set, selects only shadow (true) or only published (false) anomalies
+	Shadow *bool
+}
+
+// matches reports whether a record passes every criterion set in the filter
+func (f AnomalyFilter) matches(record *AnomalyRecord) bool {
+	if f.Namespace != "" && record.Namespace != f.Namespace {
+		return false
+	}
+	if f.State != "" && record.State != f.State {
+		return false
+	}
+	if f.DetectorID != "" && record.DetectorID != f.DetectorID {
+		return false
+	}
+	if f.Shadow != nil && record.Shadow != *f.Shadow {
+		return false
+	}
+	return true
+}
+
+// AnomalyStore keeps recent anomalies in memory, evicting the oldest beyond capacity
+type AnomalyStore struct {
+	records  []*AnomalyRecord // oldest first
+	byID     map[string]*AnomalyRecord
+	capacity int
+	nextID   int
+	mu       sync.RWMutex
+	// writer, when set, persists every added anomaly
+	writer *AnomalyWriter
+}
+
+// NewAnomalyStore creates a store. A non-positive capacity uses DefaultAnomalyStoreCapacity.
+func NewAnomalyStore(capacity int) *AnomalyStore {
+	if capacity <= 0 {
+		capacity = DefaultAnomalyStoreCapacity
+	}
+	return &AnomalyStore{
+		byID:     make(map[string]*AnomalyRecord),
+		capacity: capacity,
+		nextID:   1,
+	}
+}
+
+// Add records a new open anomaly of a namespace's detector and returns it with its assigned ID
+func (as *AnomalyStore) Add(namespace, detectorID, detectorName string, value float64, anomaly *detector.Anomaly) AnomalyRecord {
+	return as.add(namespace, detectorID, detectorName, value, anomaly, false)
+}
+
+// AddShadow records an anomaly of a shadow detector, which is kept for
+// comparison but was not published
+func (as *AnomalyStore) AddShadow(namespace, detectorID, detectorName string, value float64, anomaly *detector.Anomaly) AnomalyRecord {
+	return as.add(namespace, detectorID, detectorName, value, anomaly, true)
+}
+
+// add records an anomaly, evicting the oldest one beyond capacity
+func (as *AnomalyStore) add(namespace, detectorID, detectorName string, value float64, anomaly *detector.Anomaly, shadow bool) AnomalyRecord {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	record := &AnomalyRecord{
+		ID:           fmt.Sprintf("anomaly_%d", as.nextID),
+		Namespace:    namespace,
+		DetectorID:   detectorID,
+		DetectorName: detectorName,
+		Value:        value,
+		Anomaly:      anomaly,
+		State:        AnomalyStateOpen,
+		DetectedAt:   time.Now(),
+		Shadow:       shadow,
+		seq:          as.nextID,
+	}
+	as.nextID++
+
+	as.records = append(as.records, record)
+	as.byID[record.ID] = record
+	if as.writer != nil {
+		as.writer.Enqueue(*record)
+	}
+
+	if len(as.records) > as.capacity {
+		evicted := as.records[0]
+		as.records = as.records[1:]
+		delete(as.byID, evicted.ID)
+	}
+
+	return *record
+}
+
+// Get returns an anomaly by ID
+func (as *AnomalyStore) Get(id string) (AnomalyRecord, bool) {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	record, ok := as.byID[id]
+	if !ok {
+		return AnomalyRecord{}, false
+	}
+	return *record, true
+}
+
+// List returns matching anomalies, newest first. A non-positive limit returns all matches.
+func (as *AnomalyStore) List(filter AnomalyFilter, limit int) []AnomalyRecord {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	result := make([]AnomalyRecord, 0)
+	for i := len(as.records) - 1; i >= 0; i-- {
+		record := as.records[i]
+		if !filter.matches(record) {
+			continue
+		}
+		result = append(result, *record)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// SetWriter persists anomalies added from now on through w
+func (as *AnomalyStore) SetWriter(w *AnomalyWriter) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.writer = w
+}
+
+// Acknowledge moves an open anomaly to acknowledged
+func (as *AnomalyStore) Acknowledge(id, by string) (AnomalyRecord, error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	record, ok := as.byID[id]
+	if !ok {
+		return AnomalyRecord{}, ErrAnomalyNotFound
+	}
+	if record.State != AnomalyStateOpen {
+		return *record, fmt.Errorf("%w: cannot acknowledge a %s anomaly", ErrInvalidAnomalyState, record.State)
+	}
+
+	now := time.Now()
+	record.State = AnomalyStateAcknowledged
+	record.AcknowledgedBy = by
+	record.AcknowledgedAt = &now
+	return *record, nil
+}
+
+// Resolve moves an open or acknowledged anomaly to resolved
+func (as *AnomalyStore) Resolve(id, by string) (AnomalyRecord, error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	record, ok := as.byID[id]
+	if !ok {
+		return AnomalyRecord{}, ErrAnomalyNotFound
+	}
+	if record.State == AnomalyStateResolved {
+		return *record, fmt.Errorf("%w: anomaly is already resolved", ErrInvalidAnomalyState)
+	}
+
+	now := time.Now()
+	record.State = AnomalyStateResolved
+	record.ResolvedBy = by
+	record.ResolvedAt = &now
+	return *record, nil
+}
+
+// setupAnomalyRoutes configures the anomaly workflow API routes
+func (s *Server) setupAnomalyRoutes() {
+	anomaliesGroup := s.engine.Group("/api/anomalies")
+	{
+		anomaliesGroup.GET("", s.handleListAnomalies)
+		anomaliesGroup.GET("/export", s.handleExportAnomalies)
+		anomaliesGroup.GET("/:id", s.handleGetAnomaly)
+		anomaliesGroup.POST("/:id/ack", s.handleAcknowledgeAnomaly)
+		anomaliesGroup.POST("/:id/resolve", s.handleResolveAnomaly)
+	}
+}
+
+// handleListAnomalies lists recent anomalies, filtered by ?state= and ?detector_id=
+func (s *Server) handleListAnomalies(c *gin.Context) {
+	filter := AnomalyFilter{
+		Namespace:  namespaceOf(c),
+		State:      c.Query("state"),
+		DetectorID: c.Query("detector_id"),
+	}
+
+	switch filter.State {
+	case "", AnomalyStateOpen, AnomalyStateAcknowledged, AnomalyStateResolved:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid state: %s", filter.State)})
+		return
+	}
+
+	if shadowStr := c.Query("shadow"); shadowStr != "" {
+		shadow, err := strconv.ParseBool(shadowStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid shadow: %s", shadowStr)})
+			return
+		}
+		filter.Shadow = &shadow
+	}
+
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	anomalies := s.anomalyStore.List(filter, limit)
+	s.respondJSON(c, http.StatusOK, gin.H{
+		"anomalies": anomalies,
+		"count":     len(anomalies),
+	})
+}
+
+// handleGetAnomaly returns a single anomaly
+func (s *Server) handleGetAnomaly(c *gin.Context) {
+	record, ok := s.anomalyStore.Get(c.Param("id"))
+	if !ok || record.Namespace != namespaceOf(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrAnomalyNotFound.Error()})
+		return
+	}
+	s.respondJSON(c, http.StatusOK, record)
+}
+
+// anomalyStateRequest identifies who changes an anomaly's state
+type anomalyStateRequest struct {
+	By string `json:"by"`
+}
+
+// handleAcknowledgeAnomaly marks an anomaly as acknowledged
+func (s *Server) handleAcknowledgeAnomaly(c *gin.Context) {
+	s.updateAnomalyState(c, s.anomalyStore.Acknowledge)
+}
+
+// handleResolveAnomaly marks an anomaly as resolved
+func (s *Server) handleResolveAnomaly(c *gin.Context) {
+	s.updateAnomalyState(c, s.anomalyStore.Resolve)
+}
+
+// updateAnomalyState applies a state transition and broadcasts the updated anomaly
+func (s *Server) updateAnomalyState(c *gin.Context, transition func(id, by string) (AnomalyRecord, error)) {
+	var req anomalyStateRequest
+	// The body is optional
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Anomalies of other namespaces are not found
+	if record, ok := s.anomalyStore.Get(c.Param("id")); !ok || record.Namespace != namespaceOf(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrAnomalyNotFound.Error()})
+		return
+	}
+
+	record, err := transition(c.Param("id"), req.By)
+	switch {
+	case errors.Is(err, ErrAnomalyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrInvalidAnomalyState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.wsGateway.SendEvent(Event{
+		Type:      EventAnomalyUpdated,
+		Topic:     TopicAnomalies,
+		Data:      record,
+		Timestamp: time.Now(),
+		Namespace: record.Namespace,
+	})
+
+	s.respondJSON(c, http.StatusOK, record)
+}
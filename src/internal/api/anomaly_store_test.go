@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestAnomalyStore_StateTransitions(t *testing.T) {
+	store := NewAnomalyStore(10)
+	record := store.Add(DefaultNamespace, "detector_1", "cpu", 42, &detector.Anomaly{Value: 42, Severity: "high"})
+
+	if record.ID == "" || record.State != AnomalyStateOpen {
+		t.Fatalf("expected an open anomaly with an ID, got %+v", record)
+	}
+
+	acked, err := store.Acknowledge(record.ID, "alice")
+	if err != nil {
+		t.Fatalf("acknowledge failed: %v", err)
+	}
+	if acked.State != AnomalyStateAcknowledged || acked.AcknowledgedBy != "alice" || acked.AcknowledgedAt == nil {
+		t.Errorf("unexpected acknowledged record: %+v", acked)
+	}
+
+	if _, err := store.Acknowledge(record.ID, "bob"); !errors.Is(err, ErrInvalidAnomalyState) {
+		t.Errorf("expected ErrInvalidAnomalyState on second acknowledge, got %v", err)
+	}
+
+	resolved, err := store.Resolve(record.ID, "bob")
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if resolved.State != AnomalyStateResolved || resolved.ResolvedBy != "bob" || resolved.AcknowledgedBy != "alice" {
+		t.Errorf("unexpected resolved record: %+v", resolved)
+	}
+
+	if _, err := store.Resolve(record.ID, "bob"); !errors.Is(err, ErrInvalidAnomalyState) {
+		t.Errorf("expected ErrInvalidAnomalyState on second resolve, got %v", err)
+	}
+	if _, err := store.Acknowledge("anomaly_missing", "bob"); !errors.Is(err, ErrAnomalyNotFound) {
+		t.Errorf("expected ErrAnomalyNotFound, got %v", err)
+	}
+}
+
+func TestAnomalyStore_ListAndEviction(t *testing.T) {
+	store := NewAnomalyStore(3)
+	first := store.Add(DefaultNamespace, "detector_1", "cpu", 1, &detector.Anomaly{})
+	store.Add(DefaultNamespace, "detector_2", "mem", 2, &detector.Anomaly{})
+	third := store.Add(DefaultNamespace, "detector_1", "cpu", 3, &detector.Anomaly{})
+	store.Add(DefaultNamespace, "detector_1", "cpu", 4, &detector.Anomaly{})
+
+	if _, ok := store.Get(first.ID); ok {
+		t.Error("expected the oldest anomaly to be evicted")
+	}
+
+	if _, err := store.Acknowledge(third.ID, "alice"); err != nil {
+		t.Fatalf("acknowledge failed: %v", err)
+	}
+
+	all := store.List(AnomalyFilter{}, 0)
+	if len(all) != 3 {
+		t.Fatalf("expected 3 anomalies, got %d", len(all))
+	}
+	if all[0].Value != 4 {
+		t.Errorf("expected newest anomaly first, got value %v", all[0].Value)
+	}
+
+	open := store.List(AnomalyFilter{State: AnomalyStateOpen, DetectorID: "detector_1"}, 0)
+	if len(open) != 1 || open[0].Value != 4 {
+		t.Errorf("expected one open anomaly for detector_1, got %+v", open)
+	}
+
+	if limited := store.List(AnomalyFilter{}, 2); len(limited) != 2 {
+		t.Errorf("expected limit to cap results at 2, got %d", len(limited))
+	}
+}
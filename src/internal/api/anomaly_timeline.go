@@ -0,0 +1,137 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// DefaultTimelineBucket is the bucket width used when the request omits bucket
+const DefaultTimelineBucket = 5 * time.Minute
+
+// DefaultTimelineRange is the range covered when the request omits from
+const DefaultTimelineRange = 24 * time.Hour
+
+// MaxTimelineBuckets caps the number of buckets a timeline request may produce
+const MaxTimelineBuckets = 1000
+
+// AnomalyTimelineBucket is the number of anomalies detected in one time bucket
+type AnomalyTimelineBucket struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Count       int       `json:"count"`
+	// MaxScore is the highest score reported in the bucket, 0 when no anomaly had one
+	MaxScore float64 `json:"max_score"`
+}
+
+// Timeline counts a detector's anomalies detected in [from, to) per bucket.
+// Buckets are aligned to multiples of the bucket width and empty buckets are
+// included, so the result can be plotted directly.
+func (as *AnomalyStore) Timeline(detectorID string, from, to time.Time, bucket time.Duration) []AnomalyTimelineBucket {
+	start := from.Truncate(bucket)
+	buckets := make([]AnomalyTimelineBucket, 0, int(to.Sub(start)/bucket)+1)
+	for t := start; t.Before(to); t = t.Add(bucket) {
+		buckets = append(buckets, AnomalyTimelineBucket{BucketStart: t})
+	}
+
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	for _, record := range as.records {
+		if record.DetectorID != detectorID || record.DetectedAt.Before(from) || !record.DetectedAt.Before(to) {
+			continue
+		}
+
+		b := &buckets[int(record.DetectedAt.Sub(start)/bucket)]
+		b.Count++
+		if score := anomalyScore(record.Anomaly); score > b.MaxScore {
+			b.MaxScore = score
+		}
+	}
+	return buckets
+}
+
+// anomalyScore returns the score a detector reported in the anomaly details, or 0
+func anomalyScore(anomaly *detector.Anomaly) float64 {
+	if anomaly == nil {
+		return 0
+	}
+	score, ok := anomaly.Details["score"].(float64)
+	if !ok || math.IsNaN(score) || math.IsInf(score, 0) {
+		return 0
+	}
+	return math.Abs(score)
+}
+
+// parseTimeParam parses a query time given as RFC3339 or unix seconds,
+// returning def when the parameter is empty
+func parseTimeParam(c *gin.Context, name string, def time.Time) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return def, nil
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: expected RFC3339 or unix seconds, got %q", name, value)
+	}
+	return t, nil
+}
+
+// handleGetAnomalyTimeline returns a detector's anomaly counts per time bucket,
+// selected by ?from=, ?to= and ?bucket= (e.g. 5m)
+func (s *Server) handleGetAnomalyTimeline(c *gin.Context) {
+	id := c.Param("id")
+
+	s.detectorManager.mu.RLock()
+	_, exists := s.detectorManager.get(namespaceOf(c), id)
+	s.detectorManager.mu.RUnlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
+		return
+	}
+
+	to, err := parseTimeParam(c, "to", time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	from, err := parseTimeParam(c, "from", to.Add(-DefaultTimelineRange))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateTimeRange(from, to, s.perfConfig.MaxQueryRange); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	bucket := DefaultTimelineBucket
+	if bucketStr := c.Query("bucket"); bucketStr != "" {
+		bucket, err = time.ParseDuration(bucketStr)
+		if err != nil || bucket <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid bucket: %s", bucketStr)})
+			return
+		}
+	}
+	if n := to.Sub(from.Truncate(bucket)) / bucket; n >= MaxTimelineBuckets {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf(
+			"range of %s with bucket %s exceeds %d buckets", to.Sub(from), bucket, MaxTimelineBuckets)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"detector_id": id,
+		"from":        from,
+		"to":          to,
+		"bucket":      bucket.String(),
+		"timeline":    s.anomalyStore.Timeline(id, from, to, bucket),
+	})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestAnomalyStore_Timeline(t *testing.T) {
+	store := NewAnomalyStore(10)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	add := func(detectorID string, at time.Duration, score float64) {
+		store.Add(DefaultNamespace, detectorID, "cpu", 1, &detector.Anomaly{Details: map[string]interface{}{"score": score}})
+		store.records[len(store.records)-1].DetectedAt = base.Add(at)
+	}
+	add("detector_1", time.Minute, 3)
+	add("detector_1", 2*time.Minute, -5)
+	add("detector_1", 11*time.Minute, 2)
+	add("detector_2", time.Minute, 9)
+	add("detector_1", 20*time.Minute, 4) // outside the range
+
+	timeline := store.Timeline("detector_1", base.Add(2*time.Minute), base.Add(15*time.Minute), 5*time.Minute)
+
+	// Buckets start at the aligned 12:00 and the first anomaly is before from
+	expected := []AnomalyTimelineBucket{
+		{BucketStart: base, Count: 1, MaxScore: 5},
+		{BucketStart: base.Add(5 * time.Minute)},
+		{BucketStart: base.Add(10 * time.Minute), Count: 1, MaxScore: 2},
+	}
+	if len(timeline) != len(expected) {
+		t.Fatalf("expected %d buckets, got %+v", len(expected), timeline)
+	}
+	for i, bucket := range expected {
+		if !timeline[i].BucketStart.Equal(bucket.BucketStart) || timeline[i].Count != bucket.Count ||
+			timeline[i].MaxScore != bucket.MaxScore {
+			t.Errorf("bucket %d: expected %+v, got %+v", i, bucket, timeline[i])
+		}
+	}
+}
+
+func TestHandleGetAnomalyTimeline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	s.anomalyStore.Add(DefaultNamespace, "detector_1", "custom", 50, &detector.Anomaly{})
+
+	router := gin.New()
+	router.GET("/api/detectors/:id/anomalies/timeline", s.handleGetAnomalyTimeline)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	w := get("/api/detectors/detector_1/anomalies/timeline?bucket=1h")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Timeline []AnomalyTimelineBucket `json:"timeline"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	total := 0
+	for _, bucket := range resp.Timeline {
+		total += bucket.Count
+	}
+	if total != 1 || len(resp.Timeline) < 24 {
+		t.Errorf("expected 1 anomaly over the last 24 hourly buckets, got %d in %d buckets", total, len(resp.Timeline))
+	}
+
+	for url, code := range map[string]int{
+		"/api/detectors/missing/anomalies/timeline":                                                      http.StatusNotFound,
+		"/api/detectors/detector_1/anomalies/timeline?bucket=0s":                                         http.StatusBadRequest,
+		"/api/detectors/detector_1/anomalies/timeline?from=yesterday":                                    http.StatusBadRequest,
+		"/api/detectors/detector_1/anomalies/timeline?bucket=1s":                                         http.StatusBadRequest,
+		"/api/detectors/detector_1/anomalies/timeline?from=2000&to=1000":                                 http.StatusBadRequest,
+		"/api/detectors/detector_1/anomalies/timeline?from=2024-01-01T00:00:00Z&to=2024-01-01T01:00:00Z": http.StatusOK,
+	} {
+		if w := get(url); w.Code != code {
+			t.Errorf("%s: expected %d, got %d: %s", url, code, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/metrics"
+)
+
+// Defaults for AnomalyWriterConfig fields left at zero
+const (
+	DefaultAnomalyWriteBatchSize     = 100
+	DefaultAnomalyWriteFlushInterval = time.Second
+	DefaultAnomalyWriteQueueSize     = 10000
+)
+
+// AnomalySink persists batches of anomalies, e.g. to a file or a database.
+// WriteAnomalies is only called from the writer's goroutine and must not keep
+// the slice after returning.
+type AnomalySink interface {
+	WriteAnomalies(ctx context.Context, records []AnomalyRecord) error
+}
+
+// AnomalyWriterConfig tunes the write-behind buffer in front of an AnomalySink
+type AnomalyWriterConfig struct {
+	// BatchSize is the number of anomalies written at once
+	BatchSize int
+	// FlushInterval bounds how long an anomaly waits for its batch to fill
+	FlushInterval time.Duration
+	// QueueSize bounds the anomalies waiting to be written; beyond it new ones are dropped
+	QueueSize int
+}
+
+// AnomalyWriterStats counts the anomalies handled by an AnomalyWriter
+type AnomalyWriterStats struct {
+	Written int64 `json:"written"`
+	Dropped int64 `json:"dropped"`
+	Failed  int64 `json:"failed"`
+	Queued  int   `json:"queued"`
+}
+
+// AnomalyWriter is a write-behind buffer that persists anomalies in batches,
+// so detection does not wait for the sink during an anomaly storm. Anomalies
+// arriving while the queue is full are dropped and counted.
+type AnomalyWriter struct {
+	sink   AnomalySink
+	config AnomalyWriterConfig
+	queue  chan AnomalyRecord
+
+	closed    atomic.Bool
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+	// closeCtx bounds the final flush, set before done is closed
+	closeCtx context.Context
+
+	written atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// NewAnomalyWriter starts a writer flushing to sink
+func NewAnomalyWriter(sink AnomalySink, config AnomalyWriterConfig) *AnomalyWriter {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultAnomalyWriteBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultAnomalyWriteFlushInterval
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultAnomalyWriteQueueSize
+	}
+
+	w := &AnomalyWriter{
+		sink:    sink,
+		config:  config,
+		queue:   make(chan AnomalyRecord, config.QueueSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Enqueue queues an anomaly for writing without blocking. It returns false
+// when the anomaly is dropped because the queue is full or the writer is closed.
+func (w *AnomalyWriter) Enqueue(record AnomalyRecord) bool {
+	if w.closed.Load() {
+		w.drop("closed")
+		return false
+	}
+	select {
+	case w.queue <- record:
+		return true
+	default:
+		w.drop("queue_full")
+		return false
+	}
+}
+
+// drop counts an anomaly that will not be persisted
+func (w *AnomalyWriter) drop(reason string) {
+	w.dropped.Add(1)
+	metrics.AnomalyWritesDropped.WithLabelValues(reason).Inc()
+}
+
+// Stats returns the writer's counters
+func (w *AnomalyWriter) Stats() AnomalyWriterStats {
+	return AnomalyWriterStats{
+		Written: w.written.Load(),
+		Dropped: w.dropped.Load(),
+		Failed:  w.failed.Load(),
+		Queued:  len(w.queue),
+	}
+}
+
+// Close stops accepting anomalies, writes the queued ones and closes the sink
+// if it is an io.Closer. It returns ctx's error if the final flush does not
+// finish in time.
+func (w *AnomalyWriter) Close(ctx context.Context) error {
+	w.closeOnce.Do(func() {
+		w.closed.Store(true)
+		w.closeCtx = ctx
+		close(w.done)
+	})
+
+	select {
+	case <-w.stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("anomaly writer: %w", ctx.Err())
+	}
+	// Anomalies enqueued while Close was draining are not written
+	for n := len(w.queue); n > 0; n-- {
+		<-w.queue
+		w.drop("closed")
+	}
+
+	if closer, ok := w.sink.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// run collects queued anomalies into batches, writing a batch when it is full
+// or FlushInterval has passed
+func (w *AnomalyWriter) run() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]AnomalyRecord, 0, w.config.BatchSize)
+	for {
+		select {
+		case record := <-w.queue:
+			batch = append(batch, record)
+			if len(batch) >= w.config.BatchSize {
+				batch = w.flush(context.Background(), batch)
+			}
+		case <-ticker.C:
+			batch = w.flush(context.Background(), batch)
+		case <-w.done:
+			// Drain what was queued before Close
+			for {
+				select {
+				case record := <-w.queue:
+					batch = append(batch, record)
+					if len(batch) >= w.config.BatchSize {
+						batch = w.flush(w.closeCtx, batch)
+					}
+				default:
+					w.flush(w.closeCtx, batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes a batch and returns an empty one to fill next
+func (w *AnomalyWriter) flush(ctx context.Context, batch []AnomalyRecord) []AnomalyRecord {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := w.sink.WriteAnomalies(ctx, batch); err != nil {
+		w.failed.Add(int64(len(batch)))
+		metrics.AnomalyWritesDropped.WithLabelValues("write_error").Add(float64(len(batch)))
+		globalLogger().Warn("Failed to persist anomalies", map[string]interface{}{
+			"count": len(batch),
+			"error": err.Error(),
+		})
+	} else {
+		w.written.Add(int64(len(batch)))
+	}
+	return make([]AnomalyRecord, 0, w.config.BatchSize)
+}
+
+// FileAnomalySink appends anomalies to a file as newline-delimited JSON,
+// syncing the file after each batch
+type FileAnomalySink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAnomalySink opens (or creates) the file at path for appending
+func NewFileAnomalySink(path string) (*FileAnomalySink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening anomaly file: %w", err)
+	}
+	return &FileAnomalySink{file: file}, nil
+}
+
+// WriteAnomalies appends the batch in a single write
+func (s *FileAnomalySink) WriteAnomalies(ctx context.Context, records []AnomalyRecord) error {
+	var buf bytes.Buffer
+	for _, record := range records {
+		data, err := marshalFinite(record, NonFiniteNull)
+		if err != nil {
+			return fmt.Errorf("error marshaling anomaly %s: %w", record.ID, err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("error writing anomalies: %w", err)
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file
+func (s *FileAnomalySink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// SetAnomalyPersistence persists every anomaly recorded by the server to sink
+// through a write-behind buffer, which Stop flushes
+func (s *Server) SetAnomalyPersistence(sink AnomalySink, config AnomalyWriterConfig) {
+	s.anomalyWriter = NewAnomalyWriter(sink, config)
+	s.anomalyStore.SetWriter(s.anomalyWriter)
+}
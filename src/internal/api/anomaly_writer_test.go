@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// recordingSink records the size of each batch, blocking writes while gate is held
+type recordingSink struct {
+	mu      sync.Mutex
+	gate    sync.Mutex
+	batches []int
+	err     error
+}
+
+func (s *recordingSink) WriteAnomalies(ctx context.Context, records []AnomalyRecord) error {
+	s.gate.Lock()
+	defer s.gate.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, len(records))
+	return s.err
+}
+
+func (s *recordingSink) batchSizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.batches...)
+}
+
+func TestAnomalyWriter_BatchesAndFlushesOnClose(t *testing.T) {
+	sink := &recordingSink{}
+	writer := NewAnomalyWriter(sink, AnomalyWriterConfig{BatchSize: 3, FlushInterval: time.Hour, QueueSize: 10})
+
+	for i := 0; i < 7; i++ {
+		if !writer.Enqueue(AnomalyRecord{ID: "anomaly"}) {
+			t.Fatalf("expected anomaly %d to be queued", i)
+		}
+	}
+	if err := writer.Close(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	// Two full batches, and the rest flushed on close
+	if sizes := sink.batchSizes(); len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 3 || sizes[2] != 1 {
+		t.Errorf("expected batches of 3, 3 and 1, got %v", sizes)
+	}
+	if stats := writer.Stats(); stats.Written != 7 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	if writer.Enqueue(AnomalyRecord{}) {
+		t.Error("expected a closed writer to drop anomalies")
+	}
+}
+
+func TestAnomalyWriter_FlushInterval(t *testing.T) {
+	sink := &recordingSink{}
+	writer := NewAnomalyWriter(sink, AnomalyWriterConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer writer.Close(context.Background())
+
+	writer.Enqueue(AnomalyRecord{})
+	deadline := time.Now().Add(time.Second)
+	for len(sink.batchSizes()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected a partial batch to be flushed after the interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAnomalyWriter_DropsWhenQueueFull(t *testing.T) {
+	sink := &recordingSink{}
+	sink.gate.Lock()
+	writer := NewAnomalyWriter(sink, AnomalyWriterConfig{BatchSize: 1, FlushInterval: time.Hour, QueueSize: 2})
+
+	// The first anomaly blocks in the sink, two more fill the queue
+	writer.Enqueue(AnomalyRecord{})
+	deadline := time.Now().Add(time.Second)
+	for writer.Stats().Queued != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the writer to pick up the first anomaly")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	writer.Enqueue(AnomalyRecord{})
+	writer.Enqueue(AnomalyRecord{})
+	if writer.Enqueue(AnomalyRecord{}) {
+		t.Error("expected an anomaly beyond the queue size to be dropped")
+	}
+
+	sink.gate.Unlock()
+	if err := writer.Close(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if stats := writer.Stats(); stats.Written != 3 || stats.Dropped != 1 {
+		t.Errorf("expected 3 written and 1 dropped, got %+v", stats)
+	}
+}
+
+func TestAnomalyWriter_CountsFailedWrites(t *testing.T) {
+	sink := &recordingSink{err: errors.New("disk full")}
+	writer := NewAnomalyWriter(sink, AnomalyWriterConfig{BatchSize: 2})
+	writer.Enqueue(AnomalyRecord{})
+	writer.Enqueue(AnomalyRecord{})
+	writer.Close(context.Background())
+
+	if stats := writer.Stats(); stats.Failed != 2 || stats.Written != 0 {
+		t.Errorf("expected 2 failed anomalies, got %+v", stats)
+	}
+}
+
+func TestFileAnomalySink_PersistsStoreAnomalies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "anomalies", "anomalies.ndjson")
+	sink, err := NewFileAnomalySink(path)
+	if err != nil {
+		t.Fatalf("failed to open sink: %v", err)
+	}
+
+	s := &Server{anomalyStore: NewAnomalyStore(10), wsGateway: NewWebSocketGateway()}
+	s.SetAnomalyPersistence(sink, AnomalyWriterConfig{BatchSize: 10, FlushInterval: time.Hour})
+	s.anomalyStore.Add(DefaultNamespace, "detector_1", "cpu", 42, &detector.Anomaly{Severity: "high"})
+	s.anomalyStore.Add(DefaultNamespace, "detector_1", "cpu", 43, &detector.Anomaly{Severity: "high"})
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open anomaly file: %v", err)
+	}
+	defer file.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AnomalyRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, record.ID)
+	}
+	if len(ids) != 2 || ids[0] != "anomaly_1" || ids[1] != "anomaly_2" {
+		t.Errorf("expected both anomalies to be persisted on stop, got %v", ids)
+	}
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes one request field that failed validation
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// NewBindingError translates a ShouldBindJSON error into a validation error
+// with field-level messages. Fields are named by their JSON path in obj, the
+// value that was bound (e.g. "config.threshold" rather than
+// "DetectorRequest.Config.Threshold").
+func NewBindingError(err error, obj interface{}) *APIError {
+	var fields []FieldError
+
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:  jsonFieldPath(reflect.TypeOf(obj), fe.StructNamespace()),
+				Reason: validationReason(fe),
+			})
+		}
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		fields = append(fields, FieldError{Field: field, Reason: fmt.Sprintf("must be %s", jsonTypeName(typeErr.Type))})
+	case errors.As(err, &syntaxErr):
+		fields = append(fields, FieldError{Field: "body", Reason: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)})
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		fields = append(fields, FieldError{Field: "body", Reason: "malformed JSON: unexpected end of input"})
+	case errors.Is(err, io.EOF):
+		fields = append(fields, FieldError{Field: "body", Reason: "request body is empty"})
+	default:
+		fields = append(fields, FieldError{Field: "body", Reason: err.Error()})
+	}
+
+	details := make([]string, len(fields))
+	for i, field := range fields {
+		details[i] = fmt.Sprintf("Field '%s': %s", field.Field, field.Reason)
+	}
+
+	apiError := NewAPIError(ErrorCodeValidation, "Validation failed", strings.Join(details, "; "))
+	apiError.Context = map[string][]FieldError{"fields": fields}
+	return apiError
+}
+
+// HandleBindingError responds with a validation error for a ShouldBindJSON failure
+func HandleBindingError(c *gin.Context, err error, obj interface{}) {
+	HandleError(c, NewBindingError(err, obj))
+}
+
+// validationReason is a human-readable message for a failed validation tag
+func validationReason(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "url":
+		return "must be a valid URL"
+	}
+	return fmt.Sprintf("failed the '%s' check", fe.Tag())
+}
+
+// jsonTypeName names a Go type the way a JSON client sees it
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return t.String()
+}
+
+// jsonFieldPath converts a validator struct namespace ("DetectorRequest.Config.Threshold")
+// into the JSON path of the field in t ("config.threshold"). Embedded structs
+// are flattened as encoding/json does; unknown segments are kept as they are.
+func jsonFieldPath(t reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")
+	if len(segments) > 1 {
+		// The first segment is the name of the bound type
+		segments = segments[1:]
+	}
+
+	path := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		name, index := segment, ""
+		if i := strings.Index(segment, "["); i >= 0 {
+			name, index = segment[:i], segment[i:]
+		}
+
+		t = elemType(t)
+		if t == nil || t.Kind() != reflect.Struct {
+			path = append(path, segment)
+			continue
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			path = append(path, segment)
+			t = nil
+			continue
+		}
+
+		t = field.Type
+		if index != "" {
+			t = elemType(t)
+		}
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && jsonName == "" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		path = append(path, jsonName+index)
+	}
+	return strings.Join(path, ".")
+}
+
+// elemType dereferences pointers and, for collections, returns the element type
+func elemType(t reflect.Type) reflect.Type {
+	for t != nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+	return nil
+}
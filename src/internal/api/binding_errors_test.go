@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewBindingError_Fields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type item struct {
+		Name string `json:"name" binding:"required"`
+	}
+	type request struct {
+		Limit int    `json:"limit" binding:"min=1"`
+		Items []item `json:"items" binding:"dive"`
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"limit": 0, "items": [{}]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	var req request
+	err := c.ShouldBindJSON(&req)
+	if err == nil {
+		t.Fatal("expected binding to fail")
+	}
+	apiError := NewBindingError(err, &req)
+	if apiError.Code != ErrorCodeValidation {
+		t.Errorf("expected a validation error, got %s", apiError.Code)
+	}
+
+	fields := apiError.Context.(map[string][]FieldError)["fields"]
+	want := map[string]string{"limit": "must be at least 1", "items[0].name": "is required"}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %d field errors, got %+v", len(want), fields)
+	}
+	for _, field := range fields {
+		if want[field.Field] != field.Reason {
+			t.Errorf("unexpected field error %+v", field)
+		}
+	}
+}
+
+func TestHandleCreateDetector_ValidationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{detectorManager: newDetectorManager()}
+	router := gin.New()
+	router.POST("/api/detectors", s.handleCreateDetector)
+
+	tests := []struct {
+		name   string
+		body   string
+		field  string
+		reason string
+	}{
+		{"missing name", `{"type": "statistical", "config": {"threshold": 2}}`, "name", "is required"},
+		{"wrong type", `{"name": 5, "type": "statistical", "config": {"threshold": 2}}`, "name", "must be a string"},
+		{"truncated", `{"name": `, "body", "malformed JSON"},
+		{"empty", ``, "body", "request body is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/detectors", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Error struct {
+					Code    ErrorCode `json:"code"`
+					Context struct {
+						Fields []FieldError `json:"fields"`
+					} `json:"context"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if resp.Error.Code != ErrorCodeValidation || len(resp.Error.Context.Fields) == 0 {
+				t.Fatalf("expected field-level validation errors, got %s", w.Body.String())
+			}
+			if got := resp.Error.Context.Fields[0]; got.Field != tt.field || !strings.HasPrefix(got.Reason, tt.reason) {
+				t.Errorf("expected %s: %s, got %+v", tt.field, tt.reason, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// EventIncidentCorrelated is sent on TopicAnomalies when log and metric anomalies are grouped
+const EventIncidentCorrelated = "incident_correlated"
+
+// DefaultCorrelationWindow is the default time window for grouping anomalies
+const DefaultCorrelationWindow = 5 * time.Minute
+
+// CorrelatedIncident groups log and metric anomalies that occurred close together
+// and are therefore likely to describe the same incident
+type CorrelatedIncident struct {
+	ID              string                  `json:"id"`
+	Labels          map[string]string       `json:"labels,omitempty"`
+	StartedAt       time.Time               `json:"started_at"`
+	LastSeen        time.Time               `json:"last_seen"`
+	LogAnomalies    []detector.Anomaly      `json:"log_anomalies"`
+	MetricAnomalies []detector.AnomalyEvent `json:"metric_anomalies"`
+}
+
+// correlationGroup collects anomalies sharing the same match-label values
+type correlationGroup struct {
+	incident CorrelatedIncident
+	emitted  bool
+}
+
+// Correlator groups log anomalies and Prometheus anomalies that occur within
+// a time window (and, optionally, share the values of matchLabels) and emits
+// a single CorrelatedIncident once both kinds have been seen
+type Correlator struct {
+	window      time.Duration
+	matchLabels []string
+	emit        func(CorrelatedIncident)
+
+	groups map[string]*correlationGroup
+	nextID int
+	mu     sync.Mutex
+}
+
+// NewCorrelator creates a correlator. A non-positive window uses DefaultCorrelationWindow.
+func NewCorrelator(window time.Duration, matchLabels []string, emit func(CorrelatedIncident)) *Correlator {
+	if window <= 0 {
+		window = DefaultCorrelationWindow
+	}
+	return &Correlator{
+		window:      window,
+		matchLabels: matchLabels,
+		emit:        emit,
+		groups:      make(map[string]*correlationGroup),
+		nextID:      1,
+	}
+}
+
+// WatchPrometheus registers the correlator as an alert callback of the Prometheus detector
+func (c *Correlator) WatchPrometheus(promDetector *detector.PrometheusAnomalyDetector) {
+	promDetector.RegisterAlertCallback(func(anomaly *detector.AnomalyEvent) error {
+		c.AddMetricAnomaly(*anomaly)
+		return nil
+	})
+}
+
+// AddLogAnomaly records an anomaly from the logs detector.
+// Stream labels are read from anomaly.Details["labels"] when present.
+func (c *Correlator) AddLogAnomaly(anomaly detector.Anomaly) {
+	labels, _ := anomaly.Details["labels"].(map[string]string)
+	c.add(anomaly.Timestamp, labels, func(incident *CorrelatedIncident) {
+		incident.LogAnomalies = append(incident.LogAnomalies, anomaly)
+	})
+}
+
+// AddMetricAnomaly records an anomaly from the Prometheus detector
+func (c *Correlator) AddMetricAnomaly(anomaly detector.AnomalyEvent) {
+	c.add(anomaly.Timestamp, anomaly.Labels, func(incident *CorrelatedIncident) {
+		incident.MetricAnomalies = append(incident.MetricAnomalies, anomaly)
+	})
+}
+
+// add appends an anomaly to its group and emits the incident the first time
+// the group contains both log and metric anomalies
+func (c *Correlator) add(ts time.Time, labels map[string]string, appendTo func(*CorrelatedIncident)) {
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	key, matched := c.groupKey(labels)
+
+	c.mu.Lock()
+	c.prune(ts)
+
+	group, exists := c.groups[key]
+	if !exists {
+		group = &correlationGroup{
+			incident: CorrelatedIncident{
+				ID:        fmt.Sprintf("incident_%d", c.nextID),
+				Labels:    matched,
+				StartedAt: ts,
+			},
+		}
+		c.nextID++
+		c.groups[key] = group
+	}
+
+	appendTo(&group.incident)
+	if ts.After(group.incident.LastSeen) {
+		group.incident.LastSeen = ts
+	}
+
+	var incident *CorrelatedIncident
+	if !group.emitted && len(group.incident.LogAnomalies) > 0 && len(group.incident.MetricAnomalies) > 0 {
+		group.emitted = true
+		snapshot := group.incident
+		snapshot.LogAnomalies = append([]detector.Anomaly(nil), group.incident.LogAnomalies...)
+		snapshot.MetricAnomalies = append([]detector.AnomalyEvent(nil), group.incident.MetricAnomalies...)
+		incident = &snapshot
+	}
+	c.mu.Unlock()
+
+	if incident != nil && c.emit != nil {
+		c.emit(*incident)
+	}
+}
+
+// prune drops groups whose last anomaly is older than the window. Caller holds c.mu.
+func (c *Correlator) prune(now time.Time) {
+	for key, group := range c.groups {
+		if now.Sub(group.incident.LastSeen) > c.window {
+			delete(c.groups, key)
+		}
+	}
+}
+
+// groupKey builds the grouping key from the values of matchLabels
+func (c *Correlator) groupKey(labels map[string]string) (string, map[string]string) {
+	if len(c.matchLabels) == 0 {
+		return "", nil
+	}
+
+	names := append([]string(nil), c.matchLabels...)
+	sort.Strings(names)
+
+	matched := make(map[string]string, len(names))
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := labels[name]
+		matched[name] = value
+		parts = append(parts, name+"="+value)
+	}
+	return strings.Join(parts, ","), matched
+}
+
+// EnableCorrelation creates a correlator that publishes incidents to WebSocket clients
+func (s *Server) EnableCorrelation(window time.Duration, matchLabels []string) *Correlator {
+	return NewCorrelator(window, matchLabels, func(incident CorrelatedIncident) {
+		s.wsGateway.SendEvent(Event{
+			Type:           EventIncidentCorrelated,
+			Topic:          TopicAnomalies,
+			Data:           incident,
+			Timestamp:      time.Now(),
+			CorrelationKey: incident.ID,
+		})
+	})
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestCorrelator_GroupsLogAndMetricAnomalies(t *testing.T) {
+	var incidents []CorrelatedIncident
+	c := NewCorrelator(time.Minute, []string{"service"}, func(incident CorrelatedIncident) {
+		incidents = append(incidents, incident)
+	})
+
+	now := time.Now()
+	c.AddLogAnomaly(detector.Anomaly{
+		Timestamp: now,
+		Type:      "high_error_rate",
+		Details:   map[string]interface{}{"labels": map[string]string{"service": "api"}},
+	})
+	c.AddMetricAnomaly(detector.AnomalyEvent{
+		MetricName: "latency",
+		Timestamp:  now.Add(10 * time.Second),
+		Labels:     map[string]string{"service": "billing"},
+	})
+	if len(incidents) != 0 {
+		t.Fatalf("anomalies with different service labels should not correlate, got %d incidents", len(incidents))
+	}
+
+	c.AddMetricAnomaly(detector.AnomalyEvent{
+		MetricName: "latency",
+		Timestamp:  now.Add(20 * time.Second),
+		Labels:     map[string]string{"service": "api"},
+	})
+	if len(incidents) != 1 {
+		t.Fatalf("expected 1 incident, got %d", len(incidents))
+	}
+	incident := incidents[0]
+	if incident.Labels["service"] != "api" {
+		t.Errorf("expected service=api, got %v", incident.Labels)
+	}
+	if len(incident.LogAnomalies) != 1 || len(incident.MetricAnomalies) != 1 {
+		t.Errorf("expected 1 log and 1 metric anomaly, got %d and %d", len(incident.LogAnomalies), len(incident.MetricAnomalies))
+	}
+
+	// Further anomalies in the same window are absorbed into the emitted incident
+	c.AddMetricAnomaly(detector.AnomalyEvent{
+		MetricName: "errors",
+		Timestamp:  now.Add(30 * time.Second),
+		Labels:     map[string]string{"service": "api"},
+	})
+	if len(incidents) != 1 {
+		t.Errorf("expected no new incident within the window, got %d", len(incidents))
+	}
+}
+
+func TestCorrelator_Window(t *testing.T) {
+	emitted := 0
+	c := NewCorrelator(time.Minute, nil, func(CorrelatedIncident) { emitted++ })
+
+	now := time.Now()
+	c.AddLogAnomaly(detector.Anomaly{Timestamp: now})
+	c.AddMetricAnomaly(detector.AnomalyEvent{Timestamp: now.Add(2 * time.Minute)})
+	if emitted != 0 {
+		t.Fatal("anomalies further apart than the window should not correlate")
+	}
+
+	c.AddLogAnomaly(detector.Anomaly{Timestamp: now.Add(150 * time.Second)})
+	if emitted != 1 {
+		t.Errorf("expected 1 incident, got %d", emitted)
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig configures cross-origin access for browser clients
+type CORSConfig struct {
+	// AllowedOrigins lists origins allowed to call the API; "*" allows any origin
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig allows any origin without credentials. Lock AllowedOrigins
+// to the dashboard origins in production.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID", NamespaceHeader},
+		MaxAge:         12 * time.Hour,
+	}
+}
+
+// CORSPolicy holds the active CORS configuration. It is shared by CORSMiddleware
+// and the WebSocket origin check so both honour the same allowlist.
+type CORSPolicy struct {
+	mu     sync.RWMutex
+	config CORSConfig
+}
+
+// NewCORSPolicy creates a policy with the given configuration
+func NewCORSPolicy(config CORSConfig) *CORSPolicy {
+	return &CORSPolicy{config: config}
+}
+
+// Set replaces the active configuration
+func (p *CORSPolicy) Set(config CORSConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = config
+}
+
+// Config returns the active configuration
+func (p *CORSPolicy) Config() CORSConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.config
+}
+
+// AllowOrigin reports whether the origin is in the allowlist
+func (p *CORSPolicy) AllowOrigin(origin string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, allowed := range p.config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrigin is a websocket.Upgrader origin check. Requests without an Origin
+// header (non-browser clients) are allowed.
+func (p *CORSPolicy) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || p.AllowOrigin(origin)
+}
+
+// CORSMiddleware adds CORS headers for allowed origins and answers preflight requests
+func CORSMiddleware(policy *CORSPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !policy.AllowOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// Without CORS headers the browser blocks the response
+			c.Next()
+			return
+		}
+
+		config := policy.Config()
+		header := c.Writer.Header()
+		if config.AllowCredentials || !containsString(config.AllowedOrigins, "*") {
+			// Credentialed requests require the exact origin instead of "*"
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Add("Vary", "Origin")
+		} else {
+			header.Set("Access-Control-Allow-Origin", "*")
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+			header.Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+			if config.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCORSConfig replaces the CORS configuration used by the API and WebSocket origin check
+func (s *Server) SetCORSConfig(config CORSConfig) {
+	s.cors.Set(config)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCORSTestEngine(config CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(CORSMiddleware(NewCORSPolicy(config)))
+	engine.GET("/api/detectors", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine
+}
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	config := DefaultCORSConfig()
+	config.AllowedOrigins = []string{"https://dashboard.example.com"}
+	config.AllowCredentials = true
+	engine := newCORSTestEngine(config)
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/detectors", nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for preflight, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://dashboard.example.com" {
+		t.Errorf("expected the origin to be echoed, got %q", got)
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("expected credentials to be allowed")
+	}
+	if w.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Error("expected allowed methods in preflight response")
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, "/api/detectors", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a disallowed origin, got %d", w.Code)
+	}
+}
+
+func TestCORSMiddleware_SimpleRequest(t *testing.T) {
+	engine := newCORSTestEngine(DefaultCORSConfig())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/detectors", nil)
+	req.Header.Set("Origin", "https://any.example.com")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("expected wildcard origin, got %q", got)
+	}
+}
+
+func TestCORSPolicy_CheckOrigin(t *testing.T) {
+	policy := NewCORSPolicy(CORSConfig{AllowedOrigins: []string{"https://dashboard.example.com"}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/ws", nil)
+	if !policy.CheckOrigin(req) {
+		t.Error("requests without Origin should be allowed")
+	}
+
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	if !policy.CheckOrigin(req) {
+		t.Error("allowlisted origin should be allowed")
+	}
+
+	req.Header.Set("Origin", "https://evil.example.com")
+	if policy.CheckOrigin(req) {
+		t.Error("unknown origin should be rejected")
+	}
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -10,16 +12,23 @@ import (
 
 // DataSourceAPI handles data source related API endpoints
 type DataSourceAPI struct {
-	manager *datasource.DataSourceManager
+	manager       *datasource.DataSourceManager
+	maxQueryRange time.Duration
 }
 
 // NewDataSourceAPI creates a new data source API handler
 func NewDataSourceAPI(manager *datasource.DataSourceManager) *DataSourceAPI {
 	return &DataSourceAPI{
-		manager: manager,
+		manager:       manager,
+		maxQueryRange: DefaultMaxQueryRange,
 	}
 }
 
+// SetMaxQueryRange sets the maximum time span accepted by range queries (0 disables the check)
+func (api *DataSourceAPI) SetMaxQueryRange(maxSpan time.Duration) {
+	api.maxQueryRange = maxSpan
+}
+
 // SetupRoutes configures data source API routes
 func (api *DataSourceAPI) SetupRoutes(router *gin.RouterGroup) {
 	// Data source health and status
@@ -29,6 +38,7 @@ func (api *DataSourceAPI) SetupRoutes(router *gin.RouterGroup) {
 	// Prometheus endpoints
 	prometheus := router.Group("/prometheus")
 	{
+		prometheus.GET("/sources", api.handleGetPrometheusSources)
 		prometheus.POST("/query", api.handlePrometheusQuery)
 		prometheus.POST("/query-builder", api.handlePrometheusQueryBuilder)
 		prometheus.POST("/batch-query", api.handlePrometheusBatchQuery)
@@ -46,6 +56,7 @@ func (api *DataSourceAPI) SetupRoutes(router *gin.RouterGroup) {
 	// Detector data source configuration
 	router.POST("/detectors/:id/datasources", api.handleConfigureDetectorDataSources)
 	router.DELETE("/detectors/:id/datasources", api.handleRemoveDetectorDataSources)
+	router.PUT("/detectors/:id/datasources/interval", api.handleUpdateCollectionInterval)
 }
 
 // handleGetDataSourceHealth returns the health status of all data sources
@@ -60,16 +71,36 @@ func (api *DataSourceAPI) handleGetDataSourceHealth(c *gin.Context) {
 	c.JSON(httpStatus, gin.H{
 		"prometheus": gin.H{
 			"healthy": status.PrometheusHealthy,
+			"state":   healthState(status.PrometheusHealthy, status.PrometheusFlapping),
+			"flapping": status.PrometheusFlapping,
 			"error":   status.PrometheusError,
+			"circuit_breaker": status.PrometheusBreaker,
 		},
 		"loki": gin.H{
 			"healthy": status.LokiHealthy,
+			"state":   healthState(status.LokiHealthy, status.LokiFlapping),
+			"flapping": status.LokiFlapping,
 			"error":   status.LokiError,
+			"circuit_breaker": status.LokiBreaker,
 		},
+		"transitions": status.Transitions,
 		"last_check": status.LastCheck,
 	})
 }
 
+// healthState summarizes a data source as "healthy", "down" or, when its status
+// keeps changing, "flapping"
+func healthState(healthy, flapping bool) string {
+	switch {
+	case flapping:
+		return "flapping"
+	case healthy:
+		return "healthy"
+	default:
+		return "down"
+	}
+}
+
 // handleGetCollectors returns the status of all metric collectors
 func (api *DataSourceAPI) handleGetCollectors(c *gin.Context) {
 	collectors := api.manager.GetCollectorStatus()
@@ -80,9 +111,21 @@ func (api *DataSourceAPI) handleGetCollectors(c *gin.Context) {
 	})
 }
 
+// handleGetPrometheusSources lists the configured Prometheus sources, the default first
+func (api *DataSourceAPI) handleGetPrometheusSources(c *gin.Context) {
+	sources := api.manager.PrometheusSources()
+	
+	c.JSON(http.StatusOK, gin.H{
+		"sources": sources,
+		"count":   len(sources),
+	})
+}
+
 // PrometheusQueryRequest represents a Prometheus query request
 type PrometheusQueryRequest struct {
 	Query string `json:"query" binding:"required"`
+	// Source names the Prometheus source to query (the default one when empty)
+	Source string `json:"source,omitempty"`
 }
 
 // handlePrometheusQuery executes a Prometheus query
@@ -94,9 +137,9 @@ func (api *DataSourceAPI) handlePrometheusQuery(c *gin.Context) {
 	}
 	
 	ctx := c.Request.Context()
-	results, err := api.manager.QueryMetrics(ctx, req.Query)
+	results, err := api.manager.QueryMetricsFrom(ctx, req.Source, req.Query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondQueryError(c, err)
 		return
 	}
 	
@@ -114,6 +157,7 @@ type PrometheusQueryBuilderRequest struct {
 	Range      string            `json:"range,omitempty"`
 	GroupBy    []string          `json:"group_by,omitempty"`
 	Conditions []string          `json:"conditions,omitempty"`
+	Source     string            `json:"source,omitempty"`
 }
 
 // handlePrometheusQueryBuilder executes a Prometheus query using the builder
@@ -154,9 +198,9 @@ func (api *DataSourceAPI) handlePrometheusQueryBuilder(c *gin.Context) {
 	
 	// Execute query
 	ctx := c.Request.Context()
-	results, err := api.manager.QueryMetricsWithBuilder(ctx, builder)
+	results, err := api.manager.QueryMetricsWithBuilderFrom(ctx, req.Source, builder)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondQueryError(c, err)
 		return
 	}
 	
@@ -229,10 +273,15 @@ func (api *DataSourceAPI) handleLokiQuery(c *gin.Context) {
 		req.Start = req.End.Add(-1 * time.Hour)
 	}
 	
+	if err := validateTimeRange(req.Start, req.End, api.maxQueryRange); err != nil {
+		HandleError(c, err)
+		return
+	}
+	
 	ctx := c.Request.Context()
 	results, err := api.manager.QueryLogs(ctx, req.Query, req.Start, req.End)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondQueryError(c, err)
 		return
 	}
 	
@@ -297,12 +346,15 @@ func (api *DataSourceAPI) handleLokiQueryBuilder(c *gin.Context) {
 	}
 	
 	// Add aggregation
+	metricQuery := false
 	if req.Aggregation != "" && req.Duration != "" {
 		switch req.Aggregation {
 		case "rate":
 			builder.Rate(req.Duration)
+			metricQuery = true
 		case "count_over_time":
 			builder.CountOverTime(req.Duration)
+			metricQuery = true
 		}
 		
 		if len(req.GroupBy) > 0 {
@@ -318,11 +370,33 @@ func (api *DataSourceAPI) handleLokiQueryBuilder(c *gin.Context) {
 		req.Start = req.End.Add(-1 * time.Hour)
 	}
 	
+	if err := validateTimeRange(req.Start, req.End, api.maxQueryRange); err != nil {
+		HandleError(c, err)
+		return
+	}
+	
 	// Execute query
 	ctx := c.Request.Context()
+	
+	// Aggregated queries return matrix results instead of log streams
+	if metricQuery {
+		series, err := api.manager.QueryLogMetrics(ctx, builder.Build(), req.Start, req.End)
+		if err != nil {
+			respondQueryError(c, err)
+			return
+		}
+		
+		c.JSON(http.StatusOK, gin.H{
+			"query":  builder.Build(),
+			"series": series,
+			"count":  len(series),
+		})
+		return
+	}
+	
 	results, err := api.manager.QueryLogsWithBuilder(ctx, builder, req.Start, req.End)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondQueryError(c, err)
 		return
 	}
 	
@@ -358,10 +432,16 @@ func (api *DataSourceAPI) handleLokiAnalyze(c *gin.Context) {
 		duration = parsed
 	}
 	
+	now := time.Now()
+	if err := validateTimeRange(now.Add(-duration), now, api.maxQueryRange); err != nil {
+		HandleError(c, err)
+		return
+	}
+	
 	ctx := c.Request.Context()
 	results, err := api.manager.AnalyzeLogs(ctx, req.Query, duration)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondQueryError(c, err)
 		return
 	}
 	
@@ -373,6 +453,12 @@ type DetectorDataSourceRequest struct {
 	MetricQuery        string `json:"metric_query,omitempty"`
 	LogQuery           string `json:"log_query,omitempty"`
 	CollectionInterval string `json:"collection_interval,omitempty"`
+	Transformers       []string `json:"transformers,omitempty"`
+	// Source names the Prometheus source the metric query runs on
+	Source             string `json:"source,omitempty"`
+	// MetricType is gauge (default), counter or histogram; counters and
+	// histograms are fed as rates unless transformers are given
+	MetricType         string `json:"metric_type,omitempty"`
 }
 
 // handleConfigureDetectorDataSources configures data sources for a detector
@@ -400,11 +486,20 @@ func (api *DataSourceAPI) handleConfigureDetectorDataSources(c *gin.Context) {
 		interval = parsed
 	}
 	
+	metricType, err := datasource.ParseMetricType(req.MetricType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
 	// Configure data sources
 	config := &datasource.DetectorDataSourceConfig{
+		Source:             req.Source,
 		MetricQuery:        req.MetricQuery,
 		LogQuery:           req.LogQuery,
 		CollectionInterval: interval,
+		Transformers:       req.Transformers,
+		MetricType:         metricType,
 	}
 	
 	// TODO: Need to get data source integration from manager
@@ -431,4 +526,61 @@ func (api *DataSourceAPI) handleRemoveDetectorDataSources(c *gin.Context) {
 		"detector_id": detectorID,
 		"message":     "data sources removed",
 	})
-} 
\ No newline at end of file
+} 
+
+// CollectionIntervalRequest changes how often a detector's metrics are collected
+type CollectionIntervalRequest struct {
+	CollectionInterval string `json:"collection_interval" binding:"required"`
+}
+
+// handleUpdateCollectionInterval changes the collection interval of a detector's
+// metric collector live, keeping its schedule
+func (api *DataSourceAPI) handleUpdateCollectionInterval(c *gin.Context) {
+	detectorID := c.Param("id")
+	
+	var req CollectionIntervalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	interval, err := time.ParseDuration(req.CollectionInterval)
+	if err != nil || interval <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection interval format"})
+		return
+	}
+	
+	if err := api.manager.UpdateMetricCollectorInterval(detectorID, interval); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, datasource.ErrCollectorNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":              "success",
+		"detector_id":         detectorID,
+		"collection_interval": interval.String(),
+	})
+}
+
+// respondQueryError responds with VALIDATION_ERROR (400) for an unknown
+// Prometheus source, with QUERY_ERROR (400) when the backend rejected the query
+// itself, and with a generic 500 otherwise
+func respondQueryError(c *gin.Context, err error) {
+	if errors.Is(err, datasource.ErrUnknownSource) {
+		HandleValidationError(c, "source", err.Error())
+		return
+	}
+
+	if queryErr, ok := datasource.AsQueryError(err); ok {
+		apiError := NewAPIError(ErrorCodeQueryError, fmt.Sprintf("Invalid %s query", queryErr.Source), queryErr.Message)
+		apiError.Context = map[string]string{"source": queryErr.Source, "query": queryErr.Query}
+		HandleError(c, apiError)
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+)
+
+func TestHandleUpdateCollectionInterval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := datasource.DefaultDataSourceConfig()
+	config.EnableLogs = false
+	manager, err := datasource.NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	if err := manager.AddMetricCollector("detector_1", "up", time.Minute); err != nil {
+		t.Fatalf("failed to add collector: %v", err)
+	}
+
+	router := gin.New()
+	NewDataSourceAPI(manager).SetupRoutes(router.Group("/api/datasources"))
+
+	put := func(detectorID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/datasources/detectors/"+detectorID+"/datasources/interval",
+			strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := put("detector_1", `{"collection_interval": "15s"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if interval := manager.GetCollectorStatus()["detector_detector_1"].Interval; interval != 15*time.Second {
+		t.Errorf("expected a 15s interval, got %s", interval)
+	}
+
+	for _, tc := range []struct {
+		detectorID, body string
+		code             int
+	}{
+		{"missing", `{"collection_interval": "15s"}`, http.StatusNotFound},
+		{"detector_1", `{"collection_interval": "soon"}`, http.StatusBadRequest},
+		{"detector_1", `{"collection_interval": "-5s"}`, http.StatusBadRequest},
+		{"detector_1", `{}`, http.StatusBadRequest},
+	} {
+		if w := put(tc.detectorID, tc.body); w.Code != tc.code {
+			t.Errorf("%s %s: expected %d, got %d: %s", tc.detectorID, tc.body, tc.code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRegisterDataSourceAPI_MountsRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := datasource.DefaultDataSourceConfig()
+	config.EnableLogs = false
+	manager, err := datasource.NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	if err := manager.AddMetricCollector("detector_1", "up", time.Minute); err != nil {
+		t.Fatalf("failed to add collector: %v", err)
+	}
+
+	// The API is registered after NewServer has set up its routes, as in main
+	s := NewServer(nil)
+	s.RegisterDataSourceAPI(NewDataSourceAPI(manager))
+
+	for _, tc := range []struct {
+		method, path, body string
+		code               int
+	}{
+		// No backend is reachable, so health reports the sources as down
+		{http.MethodGet, "/api/datasources/health", "", http.StatusServiceUnavailable},
+		{http.MethodGet, "/api/datasources/prometheus/sources", "", http.StatusOK},
+		{http.MethodPut, "/api/datasources/detectors/detector_1/datasources/interval", `{"collection_interval": "15s"}`, http.StatusOK},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.engine.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Errorf("%s %s: expected %d, got %d: %s", tc.method, tc.path, tc.code, w.Code, w.Body.String())
+		}
+		if tc.path == "/api/datasources/health" && !strings.Contains(w.Body.String(), `"circuit_breaker"`) {
+			t.Errorf("expected breaker state in the health response, got %s", w.Body.String())
+		}
+	}
+}
+
+func TestHandlePrometheusQuery_QueryError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error at char 6: unclosed left parenthesis"}`))
+	}))
+	defer prometheus.Close()
+
+	config := datasource.DefaultDataSourceConfig()
+	config.PrometheusURL = prometheus.URL
+	config.EnableLogs = false
+	manager, err := datasource.NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	router := gin.New()
+	NewDataSourceAPI(manager).SetupRoutes(router.Group("/api/datasources"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/datasources/prometheus/query",
+		strings.NewReader(`{"query": "rate(up"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, want := range []string{`"QUERY_ERROR"`, "unclosed left parenthesis", `"query":"rate(up"`} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("expected response to contain %s, got %s", want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultMaxConcurrentDetections bounds the detections running at once across all detectors
+	DefaultMaxConcurrentDetections = 32
+	// DefaultDetectionQueueTimeout is how long a detection waits for a free slot before it is rejected
+	DefaultDetectionQueueTimeout = 100 * time.Millisecond
+)
+
+// ErrDetectionCapacity is returned when every detection slot stays busy for the queue timeout
+var ErrDetectionCapacity = errors.New("too many concurrent detections")
+
+// DetectionLimiter is a semaphore around detection execution. Scoring (isolation
+// forest in particular) is CPU-bound, so under spikes excess detections are
+// rejected instead of slowing down every request.
+type DetectionLimiter struct {
+	mu           sync.RWMutex
+	slots        chan struct{}
+	limit        int
+	queueTimeout time.Duration
+
+	active   int64
+	waiting  int64
+	rejected int64
+}
+
+// NewDetectionLimiter creates a limiter; a non-positive limit disables it
+func NewDetectionLimiter(limit int, queueTimeout time.Duration) *DetectionLimiter {
+	dl := &DetectionLimiter{}
+	dl.SetLimit(limit, queueTimeout)
+	return dl
+}
+
+// SetLimit changes the concurrency limit and queue timeout. Detections already
+// holding a slot release it against the limit they were admitted under.
+func (dl *DetectionLimiter) SetLimit(limit int, queueTimeout time.Duration) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	dl.limit = limit
+	dl.queueTimeout = queueTimeout
+	dl.slots = nil
+	if limit > 0 {
+		dl.slots = make(chan struct{}, limit)
+	}
+}
+
+// Acquire takes a detection slot, waiting up to the queue timeout for one to
+// free up. The returned function releases the slot.
+func (dl *DetectionLimiter) Acquire(ctx context.Context) (func(), error) {
+	dl.mu.RLock()
+	slots, queueTimeout := dl.slots, dl.queueTimeout
+	dl.mu.RUnlock()
+
+	if slots != nil {
+		if err := dl.enqueue(ctx, slots, queueTimeout); err != nil {
+			return nil, err
+		}
+	}
+
+	atomic.AddInt64(&dl.active, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt64(&dl.active, -1)
+			if slots != nil {
+				<-slots
+			}
+		})
+	}, nil
+}
+
+// enqueue blocks until a slot of slots is taken, the queue timeout passes or ctx is done
+func (dl *DetectionLimiter) enqueue(ctx context.Context, slots chan struct{}, queueTimeout time.Duration) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if queueTimeout <= 0 {
+		atomic.AddInt64(&dl.rejected, 1)
+		return ErrDetectionCapacity
+	}
+
+	atomic.AddInt64(&dl.waiting, 1)
+	defer atomic.AddInt64(&dl.waiting, -1)
+
+	timer := time.NewTimer(queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		atomic.AddInt64(&dl.rejected, 1)
+		return ErrDetectionCapacity
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetStats returns the limiter's configuration and current concurrency
+func (dl *DetectionLimiter) GetStats() map[string]interface{} {
+	dl.mu.RLock()
+	limit, queueTimeout := dl.limit, dl.queueTimeout
+	dl.mu.RUnlock()
+
+	return map[string]interface{}{
+		"max_concurrent": limit,
+		"queue_timeout":  queueTimeout.String(),
+		"active":         atomic.LoadInt64(&dl.active),
+		"waiting":        atomic.LoadInt64(&dl.waiting),
+		"rejected_total": atomic.LoadInt64(&dl.rejected),
+	}
+}
+
+// GlobalDetectionLimiter bounds detections run by the API, ingestion and streaming paths
+var GlobalDetectionLimiter = NewDetectionLimiter(DefaultMaxConcurrentDetections, DefaultDetectionQueueTimeout)
+
+// SetDetectionConcurrency sets the maximum number of parallel detections and how
+// long a detection may wait for a free slot (a non-positive limit disables the limit)
+func (s *Server) SetDetectionConcurrency(limit int, queueTimeout time.Duration) {
+	s.perfConfig.MaxConcurrentDetections = limit
+	s.perfConfig.DetectionQueueTimeout = queueTimeout
+	GlobalDetectionLimiter.SetLimit(limit, queueTimeout)
+}
+
+// acquireDetectionSlot takes a detection slot for a request, responding with
+// 429 when detection capacity is saturated
+func acquireDetectionSlot(c *gin.Context) (func(), bool) {
+	release, err := GlobalDetectionLimiter.Acquire(c.Request.Context())
+	if err != nil {
+		respondDetectionCapacity(c, err)
+		return nil, false
+	}
+	return release, true
+}
+
+// respondDetectionCapacity reports a failure to acquire a detection slot
+func respondDetectionCapacity(c *gin.Context, err error) {
+	if errors.Is(err, ErrDetectionCapacity) {
+		c.Header("Retry-After", "1")
+		HandleError(c, NewAPIError(ErrorCodeRateLimit, err.Error(), "detection capacity is saturated, retry later"))
+		return
+	}
+	HandleError(c, NewAPIError(ErrorCodeServiceDown, "detection aborted", err.Error()))
+}
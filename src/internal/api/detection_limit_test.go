@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDetectionLimiter(t *testing.T) {
+	limiter := NewDetectionLimiter(1, 20*time.Millisecond)
+
+	release, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected a free slot: %v", err)
+	}
+	if stats := limiter.GetStats(); stats["active"] != int64(1) {
+		t.Errorf("expected 1 active detection, got %v", stats["active"])
+	}
+
+	// The only slot is busy for longer than the queue timeout
+	if _, err := limiter.Acquire(context.Background()); !errors.Is(err, ErrDetectionCapacity) {
+		t.Fatalf("expected ErrDetectionCapacity, got %v", err)
+	}
+
+	// A queued detection gets the slot once it is released
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release()
+	}()
+	second, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected the queued detection to get the released slot: %v", err)
+	}
+	second()
+	release()
+
+	stats := limiter.GetStats()
+	if stats["active"] != int64(0) || stats["rejected_total"] != int64(1) {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+
+	// Without a limit detections are only counted
+	limiter.SetLimit(0, 0)
+	for i := 0; i < 3; i++ {
+		if _, err := limiter.Acquire(context.Background()); err != nil {
+			t.Fatalf("expected no limit: %v", err)
+		}
+	}
+	if stats := limiter.GetStats(); stats["active"] != int64(3) {
+		t.Errorf("expected 3 active detections, got %v", stats["active"])
+	}
+}
+
+func TestHandleRunDetection_CapacitySaturated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	previous := GlobalDetectionLimiter
+	GlobalDetectionLimiter = NewDetectionLimiter(1, 0)
+	defer func() { GlobalDetectionLimiter = previous }()
+
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	router := gin.New()
+	router.POST("/api/detectors/:id/detect", s.handleRunDetection)
+
+	detect := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/detectors/detector_1/detect", strings.NewReader(`{"value": 5}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	release, err := GlobalDetectionLimiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("failed to take the only slot: %v", err)
+	}
+
+	w := detect()
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), string(ErrorCodeRateLimit)) {
+		t.Fatalf("expected 429 %s, got %d: %s", ErrorCodeRateLimit, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+	if _, err := s.DetectValue(context.Background(), "", "detector_1", 5, time.Time{}); !errors.Is(err, ErrDetectionCapacity) {
+		t.Errorf("expected DetectValue to be limited too, got %v", err)
+	}
+
+	release()
+	if w := detect(); w.Code != http.StatusOK {
+		t.Errorf("expected 200 once the slot is free, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// MaxBootstrapAge is the age beyond which cached collector results are too
+// stale to bootstrap a detector from
+const MaxBootstrapAge = 15 * time.Minute
+
+// bootstrapFromCache trains a starting detector that opted in with
+// BootstrapFromCache on the cached result of its collector query, shortening
+// its warmup without querying Prometheus again. It returns the number of
+// values trained on.
+func (s *Server) bootstrapFromCache(instance *DetectorInstance) int {
+	s.detectorManager.mu.RLock()
+	enabled := instance.BootstrapFromCache
+	s.detectorManager.mu.RUnlock()
+
+	if !enabled || s.dataSourceAPI == nil || s.dataSourceAPI.manager == nil {
+		return 0
+	}
+	trainable, ok := instance.Detector.(detector.TrainableDetector)
+	if !ok {
+		return 0
+	}
+
+	metrics, cachedAt, found := s.dataSourceAPI.manager.CachedCollectorMetrics(instance.ID)
+	if !found || len(metrics) == 0 || time.Since(cachedAt) > MaxBootstrapAge {
+		return 0
+	}
+
+	// The cached slice is shared, sort a copy
+	ordered := append([]datasource.MetricResult(nil), metrics...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+	})
+	values := make([]float64, len(ordered))
+	for i, metric := range ordered {
+		values[i] = metric.Value
+	}
+
+	if err := trainable.Train(values); err != nil {
+		log.Printf("Failed to bootstrap detector %s from cache: %v", instance.ID, err)
+		return 0
+	}
+
+	log.Printf("Bootstrapped detector %s from %d cached values", instance.ID, len(values))
+	return len(values)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+)
+
+func TestHandleStartDetector_BootstrapFromCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+			`{"metric":{"instance":"b"},"value":[1700000060,"3"]},` +
+			`{"metric":{"instance":"a"},"value":[1700000000,"2"]}]}}`))
+	}))
+	defer prometheus.Close()
+
+	config := datasource.DefaultDataSourceConfig()
+	config.PrometheusURL = prometheus.URL
+	config.EnableLogs = false
+	manager, err := datasource.NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	if err := manager.AddMetricCollector("detector_1", "up", time.Minute); err != nil {
+		t.Fatalf("failed to add collector: %v", err)
+	}
+	// A collection caches the query result
+	if _, err := manager.QueryMetrics(context.Background(), "up"); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+
+	det := &thresholdDetector{limit: 10}
+	s := newIngestTestServer("stopped", det)
+	s.dataSourceAPI = NewDataSourceAPI(manager)
+
+	router := gin.New()
+	router.POST("/api/detectors/:id/start", s.handleStartDetector)
+	router.POST("/api/detectors/:id/stop", s.handleStopDetector)
+
+	start := func() map[string]interface{} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/detectors/detector_1/start", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	// Bootstrapping is opt-in
+	if resp := start(); resp["bootstrapped_samples"] != nil || len(det.trained) != 0 {
+		t.Fatalf("expected no bootstrap without opting in, got %v, trained %v", resp, det.trained)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/detectors/detector_1/stop", nil))
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.BootstrapFromCache = true
+
+	resp := start()
+	if resp["bootstrapped_samples"] != float64(2) {
+		t.Errorf("expected 2 bootstrapped samples, got %v", resp["bootstrapped_samples"])
+	}
+	// Values are trained on in timestamp order
+	if len(det.trained) != 2 || det.trained[0] != 2 || det.trained[1] != 3 {
+		t.Errorf("expected training on [2 3], got %v", det.trained)
+	}
+}
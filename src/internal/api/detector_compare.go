@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// CompareRequest describes two detector configurations run over the same data.
+// The data is either inline Values or the series returned by Query.
+type CompareRequest struct {
+	A      detector.DetectorConfig `json:"a" binding:"required"`
+	B      detector.DetectorConfig `json:"b" binding:"required"`
+	Values []float64               `json:"values,omitempty"`
+	Query  *TrainFromQueryRequest  `json:"query,omitempty"`
+	// Warmup is the number of leading values used to train both detectors
+	// instead of being checked; detectors that cannot be trained skip them
+	Warmup int `json:"warmup,omitempty"`
+}
+
+// ComparePoint is a checked value and its position in the data
+type ComparePoint struct {
+	Index int     `json:"index"`
+	Value float64 `json:"value"`
+}
+
+// CompareResponse is the difference between the anomalies flagged by A and B
+type CompareResponse struct {
+	Points        int            `json:"points"`
+	FlaggedA      int            `json:"flagged_a"`
+	FlaggedB      int            `json:"flagged_b"`
+	OnlyA         []ComparePoint `json:"only_a"`
+	OnlyB         []ComparePoint `json:"only_b"`
+	Both          []ComparePoint `json:"both"`
+	AgreementRate float64        `json:"agreement_rate"`
+}
+
+// handleCompareDetectors runs two detector configurations over the same data and
+// returns the points flagged by A only, B only and both
+func (s *Server) handleCompareDetectors(c *gin.Context) {
+	var req CompareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if (len(req.Values) > 0) == (req.Query != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of values or query is required"})
+		return
+	}
+	if req.Warmup < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "warmup must not be negative"})
+		return
+	}
+
+	values := req.Values
+	if req.Query != nil {
+		if req.Query.Query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "query.query is required"})
+			return
+		}
+		series, ok := s.querySeries(c, *req.Query)
+		if !ok {
+			return
+		}
+		values = seriesValues(series)
+	}
+
+	if !checkValuesLimit(c, "values", len(values), s.perfConfig.MaxTrainingValues) {
+		return
+	}
+	if req.Warmup >= len(values) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no values left to compare after warmup"})
+		return
+	}
+
+	detectorA, err := newComparisonDetector(req.A)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("detector a: %v", err)})
+		return
+	}
+	detectorB, err := newComparisonDetector(req.B)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("detector b: %v", err)})
+		return
+	}
+
+	release, ok := acquireDetectionSlot(c)
+	if !ok {
+		return
+	}
+	defer release()
+
+	resp, err := compareDetectors(c.Request.Context(), detectorA, detectorB, values, req.Warmup)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.respondJSON(c, http.StatusOK, resp)
+}
+
+// newComparisonDetector creates a throwaway detector for a comparison. It records
+// no metrics, so comparisons leave no series behind in the detector collectors.
+// Background detectors react to time rather than values and are rejected.
+func newComparisonDetector(config detector.DetectorConfig) (detector.Detector, error) {
+	if err := detector.ValidateParameters(config.Type, config.Parameters); err != nil {
+		return nil, err
+	}
+
+	config.DisableMetrics = true
+
+	det, err := detector.NewDetector(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if background, ok := det.(detector.BackgroundDetector); ok {
+		background.Stop()
+		return nil, fmt.Errorf("%s detectors cannot be compared over values", config.Type)
+	}
+
+	if configurable, ok := det.(detector.ConfigurableDetector); ok && len(config.Parameters) > 0 {
+		if err := configurable.Configure(config); err != nil {
+			return nil, err
+		}
+	}
+	return det, nil
+}
+
+// compareDetectors trains both detectors on the warmup values and runs them over
+// the rest, collecting where their verdicts differ
+func compareDetectors(ctx context.Context, a, b detector.Detector, values []float64, warmup int) (*CompareResponse, error) {
+	if warmup > 0 {
+		for _, det := range []detector.Detector{a, b} {
+			if trainable, ok := det.(detector.TrainableDetector); ok {
+				if err := trainable.Train(values[:warmup]); err != nil {
+					return nil, fmt.Errorf("failed to train %s detector: %w", det.Type(), err)
+				}
+			}
+		}
+	}
+
+	resp := &CompareResponse{
+		OnlyA: []ComparePoint{},
+		OnlyB: []ComparePoint{},
+		Both:  []ComparePoint{},
+	}
+
+	agreed := 0
+	for i := warmup; i < len(values); i++ {
+		anomalyA, err := a.Detect(ctx, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("detector a, value %d: %w", i, err)
+		}
+		anomalyB, err := b.Detect(ctx, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("detector b, value %d: %w", i, err)
+		}
+
+		point := ComparePoint{Index: i, Value: values[i]}
+		switch {
+		case anomalyA != nil && anomalyB != nil:
+			resp.Both = append(resp.Both, point)
+		case anomalyA != nil:
+			resp.OnlyA = append(resp.OnlyA, point)
+		case anomalyB != nil:
+			resp.OnlyB = append(resp.OnlyB, point)
+		}
+		if (anomalyA != nil) == (anomalyB != nil) {
+			agreed++
+		}
+		resp.Points++
+	}
+
+	resp.FlaggedA = len(resp.OnlyA) + len(resp.Both)
+	resp.FlaggedB = len(resp.OnlyB) + len(resp.Both)
+	resp.AgreementRate = float64(agreed) / float64(resp.Points)
+	return resp, nil
+}
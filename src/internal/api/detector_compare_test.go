@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+	"github.com/yourusername/aiops-infra/src/internal/metrics"
+)
+
+func TestCompareDetectors(t *testing.T) {
+	a := &thresholdDetector{limit: 10}
+	b := &thresholdDetector{limit: 20}
+	values := []float64{100, 5, 15, 25, 8}
+
+	resp, err := compareDetectors(context.Background(), a, b, values, 1)
+	if err != nil {
+		t.Fatalf("compare failed: %v", err)
+	}
+
+	if len(a.trained) != 1 || len(b.trained) != 1 {
+		t.Errorf("expected both detectors to be trained on the warmup value, got %v and %v", a.trained, b.trained)
+	}
+	if resp.Points != 4 || resp.FlaggedA != 2 || resp.FlaggedB != 1 {
+		t.Errorf("unexpected counts: %+v", resp)
+	}
+	if len(resp.OnlyA) != 1 || resp.OnlyA[0].Index != 2 {
+		t.Errorf("expected value 15 flagged by a only, got %+v", resp.OnlyA)
+	}
+	if len(resp.Both) != 1 || resp.Both[0].Index != 3 {
+		t.Errorf("expected value 25 flagged by both, got %+v", resp.Both)
+	}
+	if len(resp.OnlyB) != 0 {
+		t.Errorf("expected nothing flagged by b only, got %+v", resp.OnlyB)
+	}
+	if resp.AgreementRate != 0.75 {
+		t.Errorf("expected agreement rate 0.75, got %v", resp.AgreementRate)
+	}
+}
+
+func TestNewComparisonDetector_RejectsBackground(t *testing.T) {
+	_, err := newComparisonDetector(detector.DetectorConfig{
+		Type:       detector.TypeDeadman,
+		Parameters: map[string]interface{}{"maxGap": "1m"},
+	})
+	if err == nil {
+		t.Error("expected deadman detectors to be rejected")
+	}
+}
+
+func TestNewComparisonDetector_NoMetrics(t *testing.T) {
+	const dataType = "comparison_metrics_test"
+	det, err := newComparisonDetector(detector.DetectorConfig{Type: detector.TypeStatistical, DataType: dataType, Threshold: 2})
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+	if _, err := compareDetectors(context.Background(), det, &thresholdDetector{limit: 10}, []float64{9, 11, 10, 50}, 3); err != nil {
+		t.Fatalf("compare failed: %v", err)
+	}
+
+	// Throwaway detectors leave no series behind in the detector collectors
+	if metrics.ProcessedSamples.DeleteLabelValues(string(detector.TypeStatistical), dataType) ||
+		metrics.DetectorStatus.DeleteLabelValues(string(detector.TypeStatistical), dataType) {
+		t.Error("expected no metrics for a comparison detector")
+	}
+}
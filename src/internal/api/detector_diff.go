@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// ConfigChange is one field changed by a detector update
+type ConfigChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// UpdatePreview describes what an update would change, returned instead of
+// applying it when the update is a dry run
+type UpdatePreview struct {
+	DryRun  bool           `json:"dry_run"`
+	Changes []ConfigChange `json:"changes"`
+	// RequiresReset is set when the update discards learned state (window
+	// values, baselines, score counts) or only takes effect on a new detector
+	RequiresReset bool     `json:"requires_reset"`
+	ResetReasons  []string `json:"reset_reasons,omitempty"`
+}
+
+// previewDetectorUpdate computes the changes req makes to the detector
+// (caller must hold the detector manager lock)
+func previewDetectorUpdate(instance *DetectorInstance, req *DetectorRequest) *UpdatePreview {
+	preview := &UpdatePreview{DryRun: true, Changes: []ConfigChange{}}
+	change := func(field string, old, new interface{}) {
+		if !reflect.DeepEqual(old, new) {
+			preview.Changes = append(preview.Changes, ConfigChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	change("name", instance.Name, req.Name)
+	change("callback_url", instance.CallbackURL, req.CallbackURL)
+	change("payload_format", instance.PayloadFormat, req.PayloadFormat)
+	change("bootstrap_from_cache", instance.BootstrapFromCache, req.BootstrapFromCache)
+	change("gated_by", instance.GatedBy, req.GatedBy)
+	change("gate_window", instance.GateWindow, req.GateWindow)
+	change("shadow", instance.Shadow, req.Shadow)
+	change("escalation", instance.Escalation, req.Escalation)
+	change("tags", instance.Tags, req.Tags)
+	change("labels", instance.Labels, req.Labels)
+	scoreBucketsChanged := !equalScoreBuckets(instance.ScoreBuckets, req.ScoreBuckets)
+	if scoreBucketsChanged {
+		preview.Changes = append(preview.Changes, ConfigChange{Field: "score_buckets", Old: instance.ScoreBuckets, New: req.ScoreBuckets})
+	}
+
+	old, new := instance.Config, req.Config
+	configChanges := len(preview.Changes)
+	change("config.threshold", old.Threshold, new.Threshold)
+	change("config.dataType", old.DataType, new.DataType)
+	change("config.minSamples", old.MinSamples, new.MinSamples)
+	change("config.windowSize", old.WindowSize, new.WindowSize)
+	change("config.numTrees", old.NumTrees, new.NumTrees)
+	change("config.sampleSize", old.SampleSize, new.SampleSize)
+	for _, key := range parameterKeys(old.Parameters, new.Parameters) {
+		change("config.parameters."+key, old.Parameters[key], new.Parameters[key])
+	}
+	configChanged := len(preview.Changes) > configChanges
+
+	reset := func(reason string) {
+		preview.RequiresReset = true
+		preview.ResetReasons = append(preview.ResetReasons, reason)
+	}
+	if configChanged {
+		if _, ok := instance.Detector.(detector.ConfigurableDetector); !ok {
+			reset(fmt.Sprintf("%s detectors cannot be reconfigured in place, the new config takes effect only when the detector is recreated", instance.Type))
+		}
+	}
+	if oldSize, newSize := configWindowSize(old), configWindowSize(new); newSize > 0 && newSize < oldSize {
+		reset(fmt.Sprintf("the window shrinks from %d to %d values, the oldest values are dropped", oldSize, newSize))
+	}
+	if instance.Type == detector.TypeStatistical && !reflect.DeepEqual(old.Parameters["buckets"], new.Parameters["buckets"]) {
+		if _, ok := new.Parameters["buckets"]; ok {
+			reset("changing the time-of-day buckets discards the learned seasonal baselines")
+		}
+	}
+	if _, ok := new.Parameters["targetAnomalyRate"]; ok && configChanged {
+		reset("threshold auto-tuning starts over")
+	}
+	if scoreBucketsChanged {
+		reset("the score histogram is reset for the new buckets")
+	}
+	return preview
+}
+
+// parameterKeys returns the sorted union of the parameter names
+func parameterKeys(a, b map[string]interface{}) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	keys := make([]string, 0, len(a)+len(b))
+	for _, params := range []map[string]interface{}{a, b} {
+		for key := range params {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configWindowSize returns the window size set by the config, 0 if unset
+func configWindowSize(config detector.DetectorConfig) int {
+	if v, ok := config.Parameters["windowSize"].(float64); ok && v > 0 {
+		return int(v)
+	}
+	return config.WindowSize
+}
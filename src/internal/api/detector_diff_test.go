@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestUpdateDetector_DryRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	det, err := detector.NewDetector(detector.DetectorConfig{Type: detector.TypeWindow, Threshold: 3, WindowSize: 100})
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+	instance := &DetectorInstance{
+		ID:       "detector_1",
+		Name:     "cpu",
+		Type:     detector.TypeWindow,
+		Status:   "running",
+		Detector: det,
+		Config: detector.DetectorConfig{
+			Type:       detector.TypeWindow,
+			Threshold:  3,
+			Parameters: map[string]interface{}{"windowSize": float64(100)},
+		},
+	}
+	s := &Server{detectorManager: detectorManagerWith(instance)}
+	router := gin.New()
+	router.PUT("/api/detectors/:id", s.handleUpdateDetector)
+
+	body := `{"name":"cpu","type":"window","config":{"type":"window","threshold":2.5,"parameters":{"windowSize":50}}}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/detectors/detector_1?dry_run=true", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var preview UpdatePreview
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if !preview.DryRun || len(preview.Changes) != 2 {
+		t.Fatalf("expected the threshold and window changes, got %+v", preview)
+	}
+	if preview.Changes[0].Field != "config.threshold" || preview.Changes[1].Field != "config.parameters.windowSize" {
+		t.Errorf("unexpected changes: %+v", preview.Changes)
+	}
+	if !preview.RequiresReset || len(preview.ResetReasons) != 1 {
+		t.Errorf("expected the shrinking window to require a reset, got %+v", preview)
+	}
+
+	// Nothing was applied
+	if instance.Config.Threshold != 3 || instance.Config.Parameters["windowSize"] != float64(100) {
+		t.Errorf("expected the config to be unchanged, got %+v", instance.Config)
+	}
+	if stats := det.(detector.ConfigurableDetector).GetStatistics(); stats["threshold"] != float64(3) {
+		t.Errorf("expected the detector to be unchanged, got %+v", stats)
+	}
+}
+
+func TestPreviewDetectorUpdate_NotConfigurable(t *testing.T) {
+	instance := &DetectorInstance{
+		Name:     "custom",
+		Type:     detector.TypeIsolationForest,
+		Detector: &thresholdDetector{limit: 10},
+		Config:   detector.DetectorConfig{NumTrees: 100},
+		Tags:     []string{"a"},
+	}
+
+	preview := previewDetectorUpdate(instance, &DetectorRequest{
+		Name:   "custom",
+		Config: detector.DetectorConfig{NumTrees: 200},
+		Tags:   []string{"a"},
+	})
+	if len(preview.Changes) != 1 || preview.Changes[0].Field != "config.numTrees" {
+		t.Fatalf("expected only numTrees to change, got %+v", preview.Changes)
+	}
+	if !preview.RequiresReset {
+		t.Error("expected a config change of a detector without Configure to require a reset")
+	}
+
+	// Metadata changes apply in place
+	preview = previewDetectorUpdate(instance, &DetectorRequest{Name: "renamed", Config: instance.Config, Tags: []string{"a"}})
+	if len(preview.Changes) != 1 || preview.RequiresReset {
+		t.Errorf("expected a rename without reset, got %+v", preview)
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// detectorExportVersion is bumped when the export document format changes
+const detectorExportVersion = 1
+
+// DetectorExport is a portable description of a detector, suitable for
+// keeping in version control and importing into another environment
+type DetectorExport struct {
+	Version            int                     `json:"version"`
+	Name               string                  `json:"name" binding:"required"`
+	Type               detector.DetectorType   `json:"type" binding:"required"`
+	Tags               []string                `json:"tags,omitempty"`
+	Labels             map[string]string       `json:"labels,omitempty"`
+	CallbackURL        string                  `json:"callback_url,omitempty"`
+	PayloadFormat      string                  `json:"payload_format,omitempty"`
+	ScoreBuckets       []float64               `json:"score_buckets,omitempty"`
+	BootstrapFromCache bool                    `json:"bootstrap_from_cache,omitempty"`
+	Shadow             bool                    `json:"shadow,omitempty"`
+	Escalation         []SeverityEscalation    `json:"escalation,omitempty"`
+	GatedBy            string                  `json:"gated_by,omitempty"`
+	GateWindow         string                  `json:"gate_window,omitempty"`
+	Config             detector.DetectorConfig `json:"config"`
+	State              json.RawMessage         `json:"state,omitempty"`
+	ExportedAt         time.Time               `json:"exported_at"`
+}
+
+// handleExportDetector returns a detector's configuration as a portable document.
+// With ?include_state=true the learned model is included for detectors that support it.
+func (s *Server) handleExportDetector(c *gin.Context) {
+	id := c.Param("id")
+
+	s.detectorManager.mu.RLock()
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
+	var export DetectorExport
+	if exists {
+		export = DetectorExport{
+			Version:            detectorExportVersion,
+			Name:               detectorInstance.Name,
+			Type:               detectorInstance.Type,
+			Tags:               detectorInstance.Tags,
+			Labels:             detectorInstance.Labels,
+			CallbackURL:        detectorInstance.CallbackURL,
+			PayloadFormat:      detectorInstance.PayloadFormat,
+			ScoreBuckets:       detectorInstance.ScoreBuckets,
+			BootstrapFromCache: detectorInstance.BootstrapFromCache,
+			Shadow:             detectorInstance.Shadow,
+			Escalation:         detectorInstance.Escalation,
+			GatedBy:            detectorInstance.GatedBy,
+			GateWindow:         detectorInstance.GateWindow,
+			Config:             detectorInstance.Config,
+			ExportedAt:         time.Now(),
+		}
+	}
+	s.detectorManager.mu.RUnlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
+		return
+	}
+
+	if c.Query("include_state") == "true" {
+		stateful, ok := detectorInstance.Detector.(detector.StatefulDetector)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "detector does not support state export"})
+			return
+		}
+
+		state, err := stateful.ExportState()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		export.State = state
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// handleImportDetector recreates a detector from an export document
+func (s *Server) handleImportDetector(c *gin.Context) {
+	var export DetectorExport
+	if err := c.ShouldBindJSON(&export); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if export.Version > detectorExportVersion {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported export version"})
+		return
+	}
+
+	if export.Config.Type == "" {
+		export.Config.Type = export.Type
+	}
+
+	if err := detector.ValidateParameters(export.Config.Type, export.Config.Parameters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	detectorInstance, err := s.createDetectorInstance(DetectorRequest{
+		Name:               export.Name,
+		Type:               export.Type,
+		Config:             export.Config,
+		CallbackURL:        export.CallbackURL,
+		PayloadFormat:      export.PayloadFormat,
+		ScoreBuckets:       export.ScoreBuckets,
+		BootstrapFromCache: export.BootstrapFromCache,
+		Shadow:             export.Shadow,
+		Escalation:         export.Escalation,
+		GatedBy:            export.GatedBy,
+		GateWindow:         export.GateWindow,
+		Tags:               export.Tags,
+		Labels:             export.Labels,
+	})
+	if isInvalidDetectorRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(export.State) > 0 {
+		stateful, ok := detectorInstance.Detector.(detector.StatefulDetector)
+		if !ok {
+			releaseDetector(detectorInstance)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "detector does not support state import"})
+			return
+		}
+		if err := stateful.ImportState(export.State); err != nil {
+			releaseDetector(detectorInstance)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	detectorInstance.Namespace = namespaceOf(c)
+	s.detectorManager.mu.Lock()
+	if err := s.detectorManager.validateGate(detectorInstance.Namespace, detectorInstance.ID, detectorInstance.GatedBy); err != nil {
+		s.detectorManager.mu.Unlock()
+		releaseDetector(detectorInstance)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.detectorManager.add(detectorInstance)
+	s.detectorManager.mu.Unlock()
+
+	s.wsGateway.SendEvent(Event{
+		Type:      EventDetectorCreated,
+		Topic:     TopicDetectors,
+		Data:      detectorInstance,
+		Timestamp: time.Now(),
+		Namespace: detectorInstance.Namespace,
+	})
+
+	response := &DetectorResponse{DetectorInstance: detectorInstance}
+	c.JSON(http.StatusCreated, response)
+}
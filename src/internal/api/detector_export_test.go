@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleImportDetector_InvalidRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{
+		detectorManager: newDetectorManager(),
+		wsGateway:       NewWebSocketGateway(),
+	}
+	router := gin.New()
+	router.POST("/api/detectors/import", s.handleImportDetector)
+	router.POST("/api/detectors", s.handleCreateDetector)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	const base = `"name": "imported", "type": "statistical", "config": {"type": "statistical", "threshold": 3}`
+	for _, tc := range []struct {
+		name, body, reason string
+	}{
+		{"blocked callback", `{` + base + `, "callback_url": "http://127.0.0.1:8080/hook"}`, "loopback"},
+		{"relative callback", `{` + base + `, "callback_url": "/hook"}`, "absolute http(s) URL"},
+		{"payload format", `{` + base + `, "callback_url": "https://hooks.example.com", "payload_format": "xml"}`, "unsupported payload format"},
+		{"score buckets", `{` + base + `, "score_buckets": [2, 1]}`, "strictly increasing"},
+		{"gate window", `{` + base + `, "gate_window": "soon"}`, "invalid gate_window"},
+		{"escalation", `{` + base + `, "escalation": [{"after": "5m", "severity": "fatal"}]}`, "unknown severity"},
+	} {
+		w := post("/api/detectors/import", tc.body)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tc.reason) {
+			t.Errorf("%s: expected 400 for %q, got %d: %s", tc.name, tc.reason, w.Code, w.Body.String())
+		}
+	}
+	if count := len(s.detectorManager.detectors); count != 0 {
+		t.Errorf("expected no detector to be imported, got %d", count)
+	}
+
+	if w := post("/api/detectors", `{`+base+`, "callback_url": "http://localhost/hook"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 creating a detector with a blocked callback, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := post("/api/detectors/import", `{`+base+`, "callback_url": "https://hooks.example.com"}`); w.Code != http.StatusCreated {
+		t.Errorf("expected a valid import to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// DefaultGateWindow is how recent the gating detector's anomaly must be for an
+// anomaly of a gated detector to be reported
+const DefaultGateWindow = 5 * time.Minute
+
+// ErrInvalidGate is returned for a gated_by reference that is unknown, self-referencing or cyclic
+var ErrInvalidGate = errors.New("invalid gated_by detector")
+
+// parseGateWindow parses a gate_window duration, DefaultGateWindow when empty
+func parseGateWindow(window string) (time.Duration, error) {
+	if window == "" {
+		return DefaultGateWindow, nil
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%w: invalid gate_window %q", ErrInvalidGate, window)
+	}
+	return d, nil
+}
+
+// validateGate checks that gatedBy is another detector of the namespace and that
+// following gated_by references from it never leads back to id (caller must hold dm.mu)
+func (dm *DetectorManager) validateGate(namespace, id, gatedBy string) error {
+	if gatedBy == "" {
+		return nil
+	}
+	if gatedBy == id {
+		return fmt.Errorf("%w: detector cannot gate itself", ErrInvalidGate)
+	}
+	if _, exists := dm.get(namespace, gatedBy); !exists {
+		return fmt.Errorf("%w: detector %s not found", ErrInvalidGate, gatedBy)
+	}
+
+	visited := map[string]bool{}
+	for current := gatedBy; current != "" && !visited[current]; {
+		visited[current] = true
+		gate, exists := dm.get(namespace, current)
+		if !exists {
+			break
+		}
+		if gate.GatedBy == id {
+			return fmt.Errorf("%w: gating %s by %s creates a cycle", ErrInvalidGate, id, gatedBy)
+		}
+		current = gate.GatedBy
+	}
+	return nil
+}
+
+// ErrDetectorGatesOthers is returned when deleting a detector other detectors are gated by
+var ErrDetectorGatesOthers = errors.New("detector gates other detectors")
+
+// gatedDetectors returns the IDs of the detectors of the namespace gated by id,
+// sorted (caller must hold dm.mu)
+func (dm *DetectorManager) gatedDetectors(namespace, id string) []string {
+	var gated []string
+	for key, instance := range dm.detectors {
+		if key.Namespace == namespace && instance.GatedBy == id {
+			gated = append(gated, instance.ID)
+		}
+	}
+	sort.Strings(gated)
+	return gated
+}
+
+// recentAnomaly reports whether a detector of the namespace raised an anomaly
+// within window before now (caller must hold dm.mu)
+func (dm *DetectorManager) recentAnomaly(namespace, id string, window time.Duration, now time.Time) bool {
+	instance, exists := dm.get(namespace, id)
+	if !exists || instance.Metrics.LastAnomaly == nil {
+		return false
+	}
+	return now.Sub(*instance.Metrics.LastAnomaly) <= window
+}
+
+// gateOpen reports whether anomalies of instance may be reported: always for
+// ungated detectors, otherwise only while its gating detector, which is also
+// returned, has a recent anomaly
+func (s *Server) gateOpen(instance *DetectorInstance) (string, bool) {
+	s.detectorManager.mu.RLock()
+	defer s.detectorManager.mu.RUnlock()
+
+	if instance.GatedBy == "" {
+		return "", true
+	}
+	return instance.GatedBy, s.detectorManager.recentAnomaly(instance.Namespace, instance.GatedBy, instance.gateWindow, time.Now())
+}
+
+// gateAnomaly returns nil and the gating detector when the anomaly of a gated
+// detector is suppressed, otherwise the anomaly, annotated with its gating
+// detector if it has one
+func (s *Server) gateAnomaly(instance *DetectorInstance, anomaly *detector.Anomaly) (*detector.Anomaly, string) {
+	if anomaly == nil {
+		return nil, ""
+	}
+	gatedBy, open := s.gateOpen(instance)
+	if !open {
+		return nil, gatedBy
+	}
+	if gatedBy != "" {
+		if anomaly.Details == nil {
+			anomaly.Details = make(map[string]interface{})
+		}
+		anomaly.Details["gated_by"] = gatedBy
+	}
+	return anomaly, ""
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestValidateGate(t *testing.T) {
+	manager := detectorManagerWith(
+		&DetectorInstance{ID: "a"},
+		&DetectorInstance{ID: "b", GatedBy: "a"},
+		&DetectorInstance{ID: "c", Namespace: "team-a"},
+	)
+
+	for _, tc := range []struct {
+		id, gatedBy string
+		valid       bool
+	}{
+		{"a", "", true},
+		{"c2", "b", true},
+		{"a", "a", false},
+		{"a", "missing", false},
+		{"a", "c", false}, // another namespace
+		{"a", "b", false}, // b is gated by a
+	} {
+		err := manager.validateGate(DefaultNamespace, tc.id, tc.gatedBy)
+		if tc.valid && err != nil {
+			t.Errorf("gating %s by %q: unexpected error %v", tc.id, tc.gatedBy, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrInvalidGate) {
+			t.Errorf("gating %s by %q: expected ErrInvalidGate, got %v", tc.id, tc.gatedBy, err)
+		}
+	}
+}
+
+func TestDetectValue_GatedDetector(t *testing.T) {
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	s.detectorManager.add(&DetectorInstance{
+		ID:         "detector_2",
+		Name:       "gated",
+		Status:     "running",
+		Detector:   &thresholdDetector{limit: 10},
+		GatedBy:    "detector_1",
+		gateWindow: time.Minute,
+	})
+	ctx := context.Background()
+
+	// Without a recent anomaly of detector_1 the anomaly is suppressed
+	if anomaly, err := s.DetectValue(ctx, "", "detector_2", 50, time.Time{}); err != nil || anomaly != nil {
+		t.Fatalf("expected the anomaly to be suppressed, got %v, %v", anomaly, err)
+	}
+
+	if anomaly, err := s.DetectValue(ctx, "", "detector_1", 50, time.Time{}); err != nil || anomaly == nil {
+		t.Fatalf("expected detector_1 to flag the value, got %v, %v", anomaly, err)
+	}
+
+	anomaly, err := s.DetectValue(ctx, "", "detector_2", 50, time.Time{})
+	if err != nil || anomaly == nil {
+		t.Fatalf("expected the gate to let the anomaly through, got %v, %v", anomaly, err)
+	}
+	if anomaly.Details["gated_by"] != "detector_1" {
+		t.Errorf("expected the anomaly to name its gate, got %v", anomaly.Details)
+	}
+
+	// The gate closes once detector_1's anomaly is older than the window
+	gate, _ := s.detectorManager.lookup("detector_1")
+	old := time.Now().Add(-2 * time.Minute)
+	gate.Metrics.LastAnomaly = &old
+	if anomaly, _ := s.DetectValue(ctx, "", "detector_2", 50, time.Time{}); anomaly != nil {
+		t.Errorf("expected a stale gate anomaly to suppress detection, got %v", anomaly)
+	}
+
+	gated, _ := s.detectorManager.lookup("detector_2")
+	if gated.Metrics.AnomaliesFound != 1 || gated.Metrics.TotalDetections != 3 {
+		t.Errorf("expected suppressed anomalies not to be counted, got %+v", gated.Metrics)
+	}
+}
+
+func TestHandleDeleteDetector_GatingDetector(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{
+		detectorManager: detectorManagerWith(
+			&DetectorInstance{ID: "a", Status: "stopped"},
+			&DetectorInstance{ID: "b", Status: "stopped", GatedBy: "a"},
+		),
+		wsGateway: NewWebSocketGateway(),
+	}
+	router := gin.New()
+	router.DELETE("/api/detectors/:id", s.handleDeleteDetector)
+
+	deleteDetector := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/detectors/"+id, nil))
+		return w
+	}
+
+	if w := deleteDetector("a"); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while b is gated by a, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, exists := s.detectorManager.lookup("a"); !exists {
+		t.Fatal("gating detector should not have been deleted")
+	}
+
+	if w := deleteDetector("b"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 deleting b, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := deleteDetector("a"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 once nothing is gated by a, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDetectorExportImport_Gate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	s.detectorManager.add(&DetectorInstance{
+		ID:         "detector_2",
+		Name:       "gated",
+		Type:       "statistical",
+		Status:     "stopped",
+		Detector:   &thresholdDetector{limit: 10},
+		Config:     detector.DetectorConfig{Type: "statistical"},
+		GatedBy:    "detector_1",
+		GateWindow: "5m",
+		gateWindow: 5 * time.Minute,
+	})
+	s.detectorManager.nextID = 3
+	router := gin.New()
+	router.GET("/api/detectors/:id/export", s.handleExportDetector)
+	router.POST("/api/detectors/import", s.handleImportDetector)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/detectors/detector_2/export", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var export DetectorExport
+	if err := json.Unmarshal(w.Body.Bytes(), &export); err != nil {
+		t.Fatalf("invalid export: %v", err)
+	}
+	if export.GatedBy != "detector_1" || export.GateWindow != "5m" {
+		t.Fatalf("expected the gate to be exported, got %q / %q", export.GatedBy, export.GateWindow)
+	}
+
+	importDetector := func(export DetectorExport) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(export)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/detectors/import", bytes.NewReader(body)))
+		return w
+	}
+
+	w = importDetector(export)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var imported DetectorInstance
+	if err := json.Unmarshal(w.Body.Bytes(), &imported); err != nil {
+		t.Fatalf("invalid import response: %v", err)
+	}
+	instance, _ := s.detectorManager.lookup(imported.ID)
+	if instance.GatedBy != "detector_1" || instance.gateWindow != 5*time.Minute {
+		t.Errorf("expected the imported detector to keep its gate, got %q / %v", instance.GatedBy, instance.gateWindow)
+	}
+
+	export.GatedBy = "missing"
+	if w := importDetector(export); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown gate, got %d: %s", w.Code, w.Body.String())
+	}
+}
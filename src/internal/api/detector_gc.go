@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultDetectorGCInterval is how often the idle detector GC runs when no interval is given
+const DefaultDetectorGCInterval = 10 * time.Minute
+
+// detectorGCConfig controls garbage collection of idle detectors (disabled when ttl is zero)
+type detectorGCConfig struct {
+	ttl      time.Duration
+	interval time.Duration
+}
+
+// SetDetectorGC enables deletion of stopped detectors that have been idle for longer than ttl.
+// Must be called before Start. A non-positive ttl disables the GC.
+func (s *Server) SetDetectorGC(ttl, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultDetectorGCInterval
+	}
+	s.detectorGC = detectorGCConfig{ttl: ttl, interval: interval}
+}
+
+// runDetectorGC periodically removes idle stopped detectors until ctx is done
+func (s *Server) runDetectorGC(ctx context.Context) {
+	ticker := time.NewTicker(s.detectorGC.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.collectIdleDetectors(time.Now())
+		}
+	}
+}
+
+// collectIdleDetectors deletes stopped detectors with no activity since now-ttl.
+// Running, paused and training detectors are never collected, nor are
+// detectors other detectors are gated by.
+func (s *Server) collectIdleDetectors(now time.Time) []string {
+	cutoff := now.Add(-s.detectorGC.ttl)
+
+	s.detectorManager.mu.Lock()
+	var removed []*DetectorInstance
+	for _, instance := range s.detectorManager.detectors {
+		if instance.Status != "stopped" {
+			continue
+		}
+		if len(s.detectorManager.gatedDetectors(instance.Namespace, instance.ID)) > 0 {
+			continue
+		}
+
+		lastActivity := instance.UpdatedAt
+		if instance.Metrics.LastDetection != nil && instance.Metrics.LastDetection.After(lastActivity) {
+			lastActivity = *instance.Metrics.LastDetection
+		}
+
+		if lastActivity.Before(cutoff) {
+			s.detectorManager.remove(instance)
+			releaseDetector(instance)
+			removed = append(removed, instance)
+		}
+	}
+	s.detectorManager.mu.Unlock()
+
+	deleted := make([]string, 0, len(removed))
+	for _, instance := range removed {
+		deleted = append(deleted, instance.ID)
+		s.wsGateway.SendEvent(Event{
+			Type:      EventDetectorDeleted,
+			Topic:     TopicDetectors,
+			Data:      gin.H{"id": instance.ID, "reason": "idle"},
+			Timestamp: now,
+			Namespace: instance.Namespace,
+		})
+	}
+
+	return deleted
+}
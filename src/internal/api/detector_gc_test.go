@@ -0,0 +1,47 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCollectIdleDetectors(t *testing.T) {
+	now := time.Now()
+	recentDetection := now.Add(-time.Minute)
+
+	s := &Server{
+		detectorManager: detectorManagerWith(
+			&DetectorInstance{ID: "idle-stopped", Status: "stopped", UpdatedAt: now.Add(-2 * time.Hour)},
+			&DetectorInstance{ID: "fresh-stopped", Status: "stopped", UpdatedAt: now.Add(-10 * time.Minute)},
+			&DetectorInstance{ID: "idle-running", Status: "running", UpdatedAt: now.Add(-2 * time.Hour)},
+			&DetectorInstance{ID: "recent-detects", Status: "stopped", UpdatedAt: now.Add(-2 * time.Hour), Metrics: DetectorMetrics{LastDetection: &recentDetection}},
+			&DetectorInstance{ID: "idle-gate", Status: "stopped", UpdatedAt: now.Add(-2 * time.Hour)},
+			&DetectorInstance{ID: "gated", Status: "running", UpdatedAt: now, GatedBy: "idle-gate"},
+		),
+		wsGateway: NewWebSocketGateway(),
+	}
+	s.SetDetectorGC(time.Hour, 0)
+
+	deleted := s.collectIdleDetectors(now)
+	if len(deleted) != 1 || deleted[0] != "idle-stopped" {
+		t.Fatalf("expected only idle-stopped to be collected, got %v", deleted)
+	}
+
+	if _, exists := s.detectorManager.lookup("idle-stopped"); exists {
+		t.Error("idle-stopped detector should have been deleted")
+	}
+	for _, id := range []string{"fresh-stopped", "idle-running", "recent-detects", "idle-gate"} {
+		if _, exists := s.detectorManager.lookup(id); !exists {
+			t.Errorf("detector %s should not have been deleted", id)
+		}
+	}
+
+	select {
+	case event := <-s.wsGateway.eventChan:
+		if event.Type != EventDetectorDeleted {
+			t.Errorf("expected %s event, got %s", EventDetectorDeleted, event.Type)
+		}
+	default:
+		t.Error("expected a detector_deleted event")
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// detectorCollector exports per-detector gauges read from the DetectorManager
+// at scrape time, so alerts can fire on the detectors themselves (e.g. one that
+// stopped detecting).
+//
+// The status gauge is named aiops_managed_detector_status because
+// aiops_detector_status is already taken by metrics.DetectorStatus with
+// different labels.
+type detectorCollector struct {
+	manager *DetectorManager
+
+	anomalyRate     *prometheus.Desc
+	totalDetections *prometheus.Desc
+	anomaliesFound  *prometheus.Desc
+	status          *prometheus.Desc
+	lastDetection   *prometheus.Desc
+	warmupProgress  *prometheus.Desc
+}
+
+// newDetectorCollector creates a collector for the managed detectors
+func newDetectorCollector(manager *DetectorManager) *detectorCollector {
+	labels := []string{"id", "name", "type"}
+	return &detectorCollector{
+		manager: manager,
+		anomalyRate: prometheus.NewDesc("aiops_detector_anomaly_rate",
+			"Fraction of detections that were anomalous", labels, nil),
+		totalDetections: prometheus.NewDesc("aiops_detector_total_detections",
+			"Total number of detections run by the detector", labels, nil),
+		anomaliesFound: prometheus.NewDesc("aiops_detector_anomalies_found",
+			"Total number of anomalies found by the detector", labels, nil),
+		status: prometheus.NewDesc("aiops_managed_detector_status",
+			"Detector status (1 running, 0 stopped or paused)", append(labels, "status"), nil),
+		lastDetection: prometheus.NewDesc("aiops_detector_last_detection_timestamp_seconds",
+			"Unix time of the detector's last detection", labels, nil),
+		warmupProgress: prometheus.NewDesc("aiops_detector_warmup_progress",
+			"Fraction of the samples required before the detector reports anomalies (1 when warmed up)", labels, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (dc *detectorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dc.anomalyRate
+	ch <- dc.totalDetections
+	ch <- dc.anomaliesFound
+	ch <- dc.status
+	ch <- dc.lastDetection
+	ch <- dc.warmupProgress
+}
+
+// Collect implements prometheus.Collector
+func (dc *detectorCollector) Collect(ch chan<- prometheus.Metric) {
+	dc.manager.mu.RLock()
+	defer dc.manager.mu.RUnlock()
+
+	for _, instance := range dc.manager.detectors {
+		id, name, detectorType := instance.ID, instance.Name, string(instance.Type)
+
+		ch <- prometheus.MustNewConstMetric(dc.anomalyRate, prometheus.GaugeValue,
+			instance.Metrics.AnomalyRate, id, name, detectorType)
+		ch <- prometheus.MustNewConstMetric(dc.totalDetections, prometheus.GaugeValue,
+			float64(instance.Metrics.TotalDetections), id, name, detectorType)
+		ch <- prometheus.MustNewConstMetric(dc.anomaliesFound, prometheus.GaugeValue,
+			float64(instance.Metrics.AnomaliesFound), id, name, detectorType)
+
+		running := 0.0
+		if instance.Status == "running" {
+			running = 1
+		}
+		ch <- prometheus.MustNewConstMetric(dc.status, prometheus.GaugeValue,
+			running, id, name, detectorType, instance.Status)
+
+		if instance.Metrics.LastDetection != nil {
+			ch <- prometheus.MustNewConstMetric(dc.lastDetection, prometheus.GaugeValue,
+				float64(instance.Metrics.LastDetection.Unix()), id, name, detectorType)
+		}
+
+		if progress, ok := detector.WarmupProgress(instance.Detector); ok {
+			ch <- prometheus.MustNewConstMetric(dc.warmupProgress, prometheus.GaugeValue,
+				progress, id, name, detectorType)
+		}
+	}
+}
+
+// RegisterMetrics registers the per-detector collector with a Prometheus registry
+func (s *Server) RegisterMetrics(registerer prometheus.Registerer) error {
+	return registerer.Register(newDetectorCollector(s.detectorManager))
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestDetectorCollector(t *testing.T) {
+	lastDetection := time.Unix(1700000000, 0)
+	manager := detectorManagerWith(
+		&DetectorInstance{
+			ID:     "detector_1",
+			Name:   "cpu",
+			Type:   "statistical",
+			Status: "running",
+			Metrics: DetectorMetrics{
+				TotalDetections: 4,
+				AnomaliesFound:  1,
+				AnomalyRate:     0.25,
+				LastDetection:   &lastDetection,
+			},
+		},
+	)
+
+	expected := `
+# HELP aiops_detector_anomaly_rate Fraction of detections that were anomalous
+# TYPE aiops_detector_anomaly_rate gauge
+aiops_detector_anomaly_rate{id="detector_1",name="cpu",type="statistical"} 0.25
+# HELP aiops_detector_last_detection_timestamp_seconds Unix time of the detector's last detection
+# TYPE aiops_detector_last_detection_timestamp_seconds gauge
+aiops_detector_last_detection_timestamp_seconds{id="detector_1",name="cpu",type="statistical"} 1.7e+09
+# HELP aiops_detector_total_detections Total number of detections run by the detector
+# TYPE aiops_detector_total_detections gauge
+aiops_detector_total_detections{id="detector_1",name="cpu",type="statistical"} 4
+# HELP aiops_managed_detector_status Detector status (1 running, 0 stopped or paused)
+# TYPE aiops_managed_detector_status gauge
+aiops_managed_detector_status{id="detector_1",name="cpu",status="running",type="statistical"} 1
+`
+	err := testutil.CollectAndCompare(newDetectorCollector(manager), strings.NewReader(expected),
+		"aiops_detector_anomaly_rate",
+		"aiops_detector_last_detection_timestamp_seconds",
+		"aiops_detector_total_detections",
+		"aiops_managed_detector_status",
+	)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDetectorCollector_WarmupProgress(t *testing.T) {
+	warming := detector.NewStatisticalDetector(2, 0, 0, "test")
+	if err := warming.Train([]float64{10, 11, 9}); err != nil {
+		t.Fatalf("train failed: %v", err)
+	}
+	manager := detectorManagerWith(
+		&DetectorInstance{ID: "detector_1", Name: "cpu", Type: "statistical", Status: "running", Detector: warming},
+		&DetectorInstance{ID: "detector_2", Name: "mem", Type: "isolation_forest", Status: "running",
+			Detector: detector.NewIsolationForestDetector(10, 16, 0.5, "test")},
+	)
+
+	expected := `
+# HELP aiops_detector_warmup_progress Fraction of the samples required before the detector reports anomalies (1 when warmed up)
+# TYPE aiops_detector_warmup_progress gauge
+aiops_detector_warmup_progress{id="detector_1",name="cpu",type="statistical"} 0.3
+`
+	err := testutil.CollectAndCompare(newDetectorCollector(manager), strings.NewReader(expected),
+		"aiops_detector_warmup_progress")
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// ErrUnknownProfile is returned when a detector request references a profile that is not defined
+var ErrUnknownProfile = errors.New("unknown detector profile")
+
+// SetDetectorProfiles registers named detector profiles on top of the built-in ones.
+// A profile with a built-in name replaces it.
+func (s *Server) SetDetectorProfiles(profiles map[string]detector.DetectorProfile) error {
+	merged := detector.DefaultProfiles()
+	for name, profile := range profiles {
+		profile.Name = name
+		if err := profile.Validate(); err != nil {
+			return err
+		}
+		merged[name] = profile
+	}
+	s.profiles = merged
+	return nil
+}
+
+// applyProfile merges the referenced profile into the request config.
+// Fields set in the request override the profile defaults.
+func (s *Server) applyProfile(req DetectorRequest) (detector.DetectorConfig, error) {
+	config := req.Config
+	if config.Type == "" {
+		config.Type = req.Type
+	}
+	if req.Profile == "" {
+		return config, nil
+	}
+
+	profile, ok := s.profiles[req.Profile]
+	if !ok {
+		return config, fmt.Errorf("%w: %s", ErrUnknownProfile, req.Profile)
+	}
+	return profile.Apply(config), nil
+}
+
+// handleListDetectorProfiles returns the available detector profiles
+func (s *Server) handleListDetectorProfiles(c *gin.Context) {
+	profiles := make([]detector.DetectorProfile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+
+	c.JSON(http.StatusOK, gin.H{
+		"profiles": profiles,
+		"count":    len(profiles),
+	})
+}
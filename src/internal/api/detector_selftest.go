@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+const (
+	// DefaultSelfTestPoints is the length of the synthetic baseline
+	DefaultSelfTestPoints = 100
+	// DefaultSelfTestBaseline is the level of the synthetic baseline
+	DefaultSelfTestBaseline = 100.0
+	// selfTestSpikeFactor places the default spike this many noise
+	// amplitudes above the baseline
+	selfTestSpikeFactor = 20
+)
+
+// SelfTestRequest shapes the synthetic series of a self-test; every field is optional
+type SelfTestRequest struct {
+	// Points is the number of baseline values; the first half trains the
+	// detector (when it is trainable) and the rest must not be flagged
+	Points int `json:"points,omitempty"`
+	// Baseline is the level the series oscillates around
+	Baseline *float64 `json:"baseline,omitempty"`
+	// Noise is the oscillation amplitude, 1% of the baseline (or 1) by default
+	Noise *float64 `json:"noise,omitempty"`
+	// Spike is the injected value that must be flagged, by default
+	// baseline + 20 * noise
+	Spike *float64 `json:"spike,omitempty"`
+}
+
+// SelfTestResult reports whether the detector flagged the spike and only the spike
+type SelfTestResult struct {
+	Passed         bool           `json:"passed"`
+	SpikeFlagged   bool           `json:"spike_flagged"`
+	SpikeValue     float64        `json:"spike_value"`
+	SpikeScore     *float64       `json:"spike_score,omitempty"`
+	BaselinePoints int            `json:"baseline_points"`
+	TrainingPoints int            `json:"training_points"`
+	FalsePositives []ComparePoint `json:"false_positives"`
+	Failures       []string       `json:"failures,omitempty"`
+}
+
+// handleSelfTestDetector feeds a synthetic series (a flat, slightly noisy
+// baseline followed by a spike) through a throwaway copy of the detector built
+// from its configuration, and reports whether the spike is flagged and the
+// baseline is not. The detector's own state is not touched.
+func (s *Server) handleSelfTestDetector(c *gin.Context) {
+	id := c.Param("id")
+
+	var req SelfTestRequest
+	// The body is optional
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			HandleBindingError(c, err, &req)
+			return
+		}
+	}
+	if req.Points < 0 {
+		HandleValidationError(c, "points", "must not be negative")
+		return
+	}
+	if req.Noise != nil && *req.Noise < 0 {
+		HandleValidationError(c, "noise", "must not be negative")
+		return
+	}
+	if !checkValuesLimit(c, "points", req.Points, s.perfConfig.MaxTrainingValues) {
+		return
+	}
+
+	s.detectorManager.mu.RLock()
+	instance, exists := s.detectorManager.get(namespaceOf(c), id)
+	var config detector.DetectorConfig
+	if exists {
+		config = instance.Config
+	}
+	s.detectorManager.mu.RUnlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
+		return
+	}
+
+	det, err := newComparisonDetector(config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("detector cannot be self-tested: %v", err)})
+		return
+	}
+
+	release, ok := acquireDetectionSlot(c)
+	if !ok {
+		return
+	}
+	defer release()
+
+	result, err := selfTestDetector(c.Request.Context(), det, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.respondJSON(c, http.StatusOK, result)
+}
+
+// selfTestSeries builds the synthetic baseline and the spike value for req
+func selfTestSeries(req SelfTestRequest) ([]float64, float64) {
+	points := req.Points
+	if points == 0 {
+		points = DefaultSelfTestPoints
+	}
+	baseline := DefaultSelfTestBaseline
+	if req.Baseline != nil {
+		baseline = *req.Baseline
+	}
+	noise := math.Abs(baseline) * 0.01
+	if noise == 0 {
+		noise = 1
+	}
+	if req.Noise != nil {
+		noise = *req.Noise
+	}
+	spike := baseline + selfTestSpikeFactor*noise
+	if req.Spike != nil {
+		spike = *req.Spike
+	}
+
+	// A deterministic oscillation keeps results reproducible
+	values := make([]float64, points)
+	for i := range values {
+		values[i] = baseline + noise*math.Sin(float64(i))
+	}
+	return values, spike
+}
+
+// selfTestDetector trains det on the first half of the baseline, checks the
+// rest of it and then the spike
+func selfTestDetector(ctx context.Context, det detector.Detector, req SelfTestRequest) (*SelfTestResult, error) {
+	values, spike := selfTestSeries(req)
+	warmup := len(values) / 2
+
+	result := &SelfTestResult{
+		SpikeValue:     spike,
+		FalsePositives: []ComparePoint{},
+	}
+
+	if trainable, ok := det.(detector.TrainableDetector); ok && warmup > 0 {
+		if err := trainable.Train(values[:warmup]); err != nil {
+			return nil, fmt.Errorf("failed to train %s detector: %w", det.Type(), err)
+		}
+		result.TrainingPoints = warmup
+	}
+
+	for i := result.TrainingPoints; i < len(values); i++ {
+		anomaly, err := det.Detect(ctx, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %w", i, err)
+		}
+		if anomaly != nil {
+			result.FalsePositives = append(result.FalsePositives, ComparePoint{Index: i, Value: values[i]})
+		}
+		result.BaselinePoints++
+	}
+
+	anomaly, err := det.Detect(ctx, spike)
+	if err != nil {
+		return nil, fmt.Errorf("spike: %w", err)
+	}
+	if anomaly != nil {
+		result.SpikeFlagged = true
+		if score, ok := anomaly.Details["score"].(float64); ok {
+			result.SpikeScore = &score
+		}
+	}
+
+	if !result.SpikeFlagged {
+		result.Failures = append(result.Failures,
+			fmt.Sprintf("spike %g was not flagged, the threshold may be too high", spike))
+	}
+	if len(result.FalsePositives) > 0 {
+		result.Failures = append(result.Failures,
+			fmt.Sprintf("%d of %d baseline values were flagged", len(result.FalsePositives), result.BaselinePoints))
+	}
+	result.Passed = len(result.Failures) == 0
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestSelfTestDetector(t *testing.T) {
+	det := &thresholdDetector{limit: 110}
+	result, err := selfTestDetector(context.Background(), det, SelfTestRequest{Points: 10})
+	if err != nil {
+		t.Fatalf("self-test failed: %v", err)
+	}
+	if !result.Passed || !result.SpikeFlagged || result.SpikeValue != 120 {
+		t.Errorf("expected the default spike of 120 to be flagged, got %+v", result)
+	}
+	if result.TrainingPoints != 5 || len(det.trained) != 5 || result.BaselinePoints != 5 {
+		t.Errorf("expected half of the baseline to train the detector, got %+v", result)
+	}
+
+	// A threshold above the spike never fires
+	result, err = selfTestDetector(context.Background(), &thresholdDetector{limit: 1000}, SelfTestRequest{})
+	if err != nil {
+		t.Fatalf("self-test failed: %v", err)
+	}
+	if result.Passed || result.SpikeFlagged || len(result.Failures) != 1 {
+		t.Errorf("expected the self-test to fail on the missed spike, got %+v", result)
+	}
+
+	// A threshold inside the noise flags the baseline
+	result, err = selfTestDetector(context.Background(), &thresholdDetector{limit: 100}, SelfTestRequest{})
+	if err != nil {
+		t.Fatalf("self-test failed: %v", err)
+	}
+	if result.Passed || !result.SpikeFlagged || len(result.FalsePositives) == 0 {
+		t.Errorf("expected the self-test to fail on false positives, got %+v", result)
+	}
+}
+
+func TestHandleSelfTestDetector(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.Config = detector.DetectorConfig{Type: detector.TypeStatistical, Threshold: 3}
+
+	router := gin.New()
+	router.POST("/api/detectors/:id/self-test", s.handleSelfTestDetector)
+
+	post := func(url, body string) (*httptest.ResponseRecorder, SelfTestResult) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		router.ServeHTTP(w, req)
+		var result SelfTestResult
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+		}
+		return w, result
+	}
+
+	w, result := post("/api/detectors/detector_1/self-test", "")
+	if w.Code != http.StatusOK || !result.Passed {
+		t.Fatalf("expected a statistical detector with threshold 3 to pass, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The self-test runs on a copy: the detector itself is not trained
+	if trained := instance.Detector.(*thresholdDetector).trained; len(trained) != 0 {
+		t.Errorf("expected the detector's state to be untouched, got %d training values", len(trained))
+	}
+
+	instance.Config.Threshold = 1000
+	if _, result := post("/api/detectors/detector_1/self-test", `{"points": 60}`); result.Passed || result.SpikeFlagged {
+		t.Errorf("expected a threshold of 1000 to fail the self-test, got %+v", result)
+	}
+
+	if w, _ := post("/api/detectors/detector_1/self-test", `{"points": -1}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for negative points, got %d", w.Code)
+	}
+	if w, _ := post("/api/detectors/missing/self-test", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown detector, got %d", w.Code)
+	}
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// defaultTrainingStep is the range query resolution used when the request omits step
+const defaultTrainingStep = time.Minute
+
+// TrainFromQueryRequest describes the series used to train a detector server-side
+type TrainFromQueryRequest struct {
+	// Source is "prometheus" (default) or "loki"; Loki queries must be metric queries
+	Source string    `json:"source"`
+	Query  string    `json:"query" binding:"required"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	// Step is the Prometheus range query resolution, e.g. "30s"
+	Step string `json:"step"`
+	// PrometheusSource names the Prometheus source to query (the default one when empty)
+	PrometheusSource string `json:"prometheus_source,omitempty"`
+}
+
+// handleTrainDetectorFromQuery fetches a PromQL/LogQL series and trains the detector on its values
+func (s *Server) handleTrainDetectorFromQuery(c *gin.Context) {
+	id := c.Param("id")
+
+	s.detectorManager.mu.RLock()
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
+	s.detectorManager.mu.RUnlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
+		return
+	}
+
+	trainable, ok := detectorInstance.Detector.(detector.TrainableDetector)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "detector does not support training"})
+		return
+	}
+
+	var req TrainFromQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleBindingError(c, err, &req)
+		return
+	}
+
+	series, ok := s.querySeries(c, req)
+	if !ok {
+		return
+	}
+
+	values := seriesValues(series)
+	if len(values) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query returned no samples"})
+		return
+	}
+
+	if !checkValuesLimit(c, "values", len(values), s.perfConfig.MaxTrainingValues) {
+		return
+	}
+
+	// The point timestamps place the values in their time-of-day baselines
+	start := time.Now()
+	if err := traceTrainTimed(c.Request.Context(), trainable, seriesTimestamps(series), values); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.detectorManager.mu.Lock()
+	detectorInstance.UpdatedAt = time.Now()
+	s.detectorManager.mu.Unlock()
+
+	response := gin.H{
+		"message":       "detector trained successfully",
+		"training_time": time.Since(start).Milliseconds(),
+		"sample_count":  len(values),
+		"series_count":  len(series),
+	}
+	if configurable, ok := detectorInstance.Detector.(detector.ConfigurableDetector); ok {
+		response["statistics"] = configurable.GetStatistics()
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// querySeries runs the PromQL/LogQL query of req over its time range, defaulting
+// to the last hour. On failure it writes the error response and returns false.
+func (s *Server) querySeries(c *gin.Context, req TrainFromQueryRequest) ([]datasource.MetricSeries, bool) {
+	if s.dataSourceAPI == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "data sources are not configured"})
+		return nil, false
+	}
+
+	if req.End.IsZero() {
+		req.End = time.Now()
+	}
+	if req.Start.IsZero() {
+		req.Start = req.End.Add(-1 * time.Hour)
+	}
+	if err := validateTimeRange(req.Start, req.End, s.perfConfig.MaxQueryRange); err != nil {
+		HandleError(c, err)
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	manager := s.dataSourceAPI.manager
+
+	var series []datasource.MetricSeries
+	var err error
+	switch req.Source {
+	case "", "prometheus":
+		step := defaultTrainingStep
+		if req.Step != "" {
+			step, err = time.ParseDuration(req.Step)
+			if err != nil || step <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid step: %s", req.Step)})
+				return nil, false
+			}
+		}
+		series, err = manager.QueryMetricsRangeFrom(ctx, req.PrometheusSource, req.Query, req.Start, req.End, step)
+	case "loki":
+		series, err = manager.QueryLogMetrics(ctx, req.Query, req.Start, req.End)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unsupported source: %s", req.Source)})
+		return nil, false
+	}
+	if err != nil {
+		respondQueryError(c, err)
+		return nil, false
+	}
+
+	return series, true
+}
+
+// seriesValues flattens the points of all series, in order, into training values
+func seriesValues(series []datasource.MetricSeries) []float64 {
+	count := 0
+	for _, s := range series {
+		count += len(s.Points)
+	}
+
+	values := make([]float64, 0, count)
+	for _, s := range series {
+		for _, point := range s.Points {
+			values = append(values, point.Value)
+		}
+	}
+	return values
+}
+
+// seriesTimestamps returns the timestamps of the points, in the order of seriesValues
+func seriesTimestamps(series []datasource.MetricSeries) []time.Time {
+	var timestamps []time.Time
+	for _, s := range series {
+		for _, point := range s.Points {
+			timestamps = append(timestamps, point.Timestamp)
+		}
+	}
+	return timestamps
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestSeriesValues(t *testing.T) {
+	series := []datasource.MetricSeries{
+		{Points: []datasource.MetricPoint{{Value: 1}, {Value: 2}}},
+		{Points: nil},
+		{Points: []datasource.MetricPoint{{Value: 3}}},
+	}
+
+	values := seriesValues(series)
+	expected := []float64{1, 2, 3}
+	if len(values) != len(expected) {
+		t.Fatalf("expected %d values, got %d", len(expected), len(values))
+	}
+	for i, v := range expected {
+		if values[i] != v {
+			t.Errorf("values[%d] = %v, want %v", i, values[i], v)
+		}
+	}
+
+	if got := seriesValues(nil); len(got) != 0 {
+		t.Errorf("expected no values for empty series, got %v", got)
+	}
+}
+
+func TestHandleTrainDetectorFromQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var promStep string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/query_range":
+			r.ParseForm()
+			promStep = r.Form.Get("step")
+			if r.Form.Get("query") == "rate(up" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"unclosed left parenthesis"}`))
+				return
+			}
+			if r.Form.Get("query") == "absent_metric" {
+				w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+				return
+			}
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[` +
+				`{"metric":{"job":"a"},"values":[[1700000000,"1"],[1700000060,"2"]]},` +
+				`{"metric":{"job":"b"},"values":[[1700000000,"3"]]}]}}`))
+		case "/loki/api/v1/query_range":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[` +
+				`{"metric":{"app":"api"},"values":[[1700000000,"4"],[1700000060,"5"]]}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer backend.Close()
+
+	config := datasource.DefaultDataSourceConfig()
+	config.PrometheusURL = backend.URL
+	config.LokiURL = backend.URL
+	manager, err := datasource.NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	det := &thresholdDetector{limit: 10}
+	s := newIngestTestServer("stopped", det)
+	s.dataSourceAPI = NewDataSourceAPI(manager)
+
+	router := gin.New()
+	router.POST("/api/detectors/:id/train-from-query", s.handleTrainDetectorFromQuery)
+	train := func(id, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodPost, "/api/detectors/"+id+"/train-from-query", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	// All points of all series are trained on, in order
+	code, resp := train("detector_1", `{"query": "rate(http_requests_total[5m])", "step": "30s"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %v", code, resp)
+	}
+	if resp["sample_count"] != float64(3) || resp["series_count"] != float64(2) || promStep != "30" {
+		t.Errorf("expected 3 samples from 2 series at a 30s step, got %v (step %q)", resp, promStep)
+	}
+	if len(det.trained) != 3 || det.trained[0] != 1 || det.trained[2] != 3 {
+		t.Errorf("expected training on [1 2 3], got %v", det.trained)
+	}
+
+	code, resp = train("detector_1", `{"source": "loki", "query": "sum(rate({app=\"api\"}[5m]))"}`)
+	if code != http.StatusOK || resp["sample_count"] != float64(2) {
+		t.Errorf("expected 2 samples from Loki, got %d: %v", code, resp)
+	}
+	if len(det.trained) != 5 || det.trained[4] != 5 {
+		t.Errorf("expected the Loki values to be trained on, got %v", det.trained)
+	}
+
+	for _, tc := range []struct {
+		name, id, body string
+		code           int
+	}{
+		{"unknown detector", "missing", `{"query": "up"}`, http.StatusNotFound},
+		{"missing query", "detector_1", `{}`, http.StatusBadRequest},
+		{"invalid step", "detector_1", `{"query": "up", "step": "-1m"}`, http.StatusBadRequest},
+		{"unknown source", "detector_1", `{"query": "up", "source": "influx"}`, http.StatusBadRequest},
+		{"inverted range", "detector_1", `{"query": "up", "start": "2024-01-02T00:00:00Z", "end": "2024-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"rejected query", "detector_1", `{"query": "rate(up"}`, http.StatusBadRequest},
+		{"no samples", "detector_1", `{"query": "absent_metric"}`, http.StatusBadRequest},
+	} {
+		if code, resp := train(tc.id, tc.body); code != tc.code {
+			t.Errorf("%s: expected %d, got %d: %v", tc.name, tc.code, code, resp)
+		}
+	}
+	if len(det.trained) != 5 {
+		t.Errorf("expected failed requests not to train, got %v", det.trained)
+	}
+}
+
+// seasonalDetector creates a statistical detector with hourly buckets
+func seasonalDetector(t *testing.T) *detector.StatisticalDetector {
+	t.Helper()
+	det := detector.NewStatisticalDetector(3, 0, 0, "test")
+	if err := det.Configure(detector.DetectorConfig{Parameters: map[string]interface{}{"buckets": float64(24)}}); err != nil {
+		t.Fatalf("failed to configure buckets: %v", err)
+	}
+	return det
+}
+
+func TestTraining_FillsSeasonalBuckets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Six hourly samples from 22:13 UTC on
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"a"},"values":[` +
+			`[1700000000,"1"],[1700003600,"2"],[1700007200,"3"],[1700010800,"4"],[1700014400,"5"],[1700018000,"6"]]}]}}`))
+	}))
+	defer backend.Close()
+
+	config := datasource.DefaultDataSourceConfig()
+	config.PrometheusURL = backend.URL
+	config.EnableLogs = false
+	manager, err := datasource.NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	det := seasonalDetector(t)
+	s := newIngestTestServer("stopped", det)
+	s.dataSourceAPI = NewDataSourceAPI(manager)
+	router := gin.New()
+	router.POST("/api/detectors/:id/train-from-query", s.handleTrainDetectorFromQuery)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/detectors/detector_1/train-from-query",
+		strings.NewReader(`{"query": "up", "step": "1h"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if buckets := det.SeasonalBuckets(); len(buckets) != 6 {
+		t.Errorf("expected each hour to fill its own bucket, got %+v", buckets)
+	}
+
+	// Ingestion in train mode uses the point timestamps as well
+	det = seasonalDetector(t)
+	s = newIngestTestServer("stopped", det)
+	start := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	var points []datasource.DataPoint
+	for hour := 0; hour < 4; hour++ {
+		points = append(points, datasource.DataPoint{Timestamp: start.Add(time.Duration(hour) * time.Hour), Value: float64(hour)})
+	}
+	if _, err := s.IngestDataPoints(context.Background(), "detector_1", points); err != nil {
+		t.Fatalf("ingest failed: %v", err)
+	}
+	if buckets := det.SeasonalBuckets(); len(buckets) != 4 || buckets[0].Index != 0 || buckets[3].Index != 3 {
+		t.Errorf("expected buckets 0 to 3 to be filled, got %+v", buckets)
+	}
+}
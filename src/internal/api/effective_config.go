@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetEffectiveConfig sets the configuration returned by GET /api/config. The
+// caller must redact secrets; the map is served as is.
+func (s *Server) SetEffectiveConfig(effective map[string]interface{}) {
+	s.effectiveConfig = effective
+}
+
+// handleGetEffectiveConfig returns the configuration the instance loaded,
+// after defaults were applied
+func (s *Server) handleGetEffectiveConfig(c *gin.Context) {
+	if s.effectiveConfig == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "effective configuration not available"})
+		return
+	}
+
+	c.JSON(http.StatusOK, s.effectiveConfig)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/config"
+)
+
+func TestHandleGetEffectiveConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{}
+	router := gin.New()
+	router.GET("/api/config", s.handleGetEffectiveConfig)
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+		return w
+	}
+
+	if w := get(); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a config, got %d", w.Code)
+	}
+
+	cfg := config.Config{}
+	cfg.Loki.Enabled = true
+	cfg.Slack.WebhookURL = "https://hooks.slack.com/services/secret-hook"
+	cfg.Email.Password = "secret-password"
+	cfg.Correlation.Window = 5 * time.Minute
+	effective, err := cfg.RedactedMap()
+	if err != nil {
+		t.Fatalf("failed to redact config: %v", err)
+	}
+	s.SetEffectiveConfig(effective)
+
+	w := get()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "secret-hook") || strings.Contains(body, "secret-password") {
+		t.Errorf("expected secrets to be redacted, got %s", body)
+	}
+	for _, want := range []string{`"webhookUrl":"REDACTED"`, `"loki":{`, `"window":"5m0s"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in %s", want, body)
+		}
+	}
+	if cfg.Slack.WebhookURL == "REDACTED" {
+		t.Error("expected the loaded config not to be modified")
+	}
+}
+
+func TestConfigRedacted_NotificationRoutes(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Orchestrator.NotificationRoutes = []config.NotificationRouteConfig{
+		{Match: map[string]string{"severity": "critical"}, Type: "webhook", WebhookURL: "https://events.pagerduty.example.com/secret-key"},
+		{Match: map[string]string{"team": "web"}, Type: "slack", WebhookURL: "https://hooks.slack.com/services/secret-hook"},
+		{Type: "email", To: []string{"oncall@example.com"}},
+	}
+
+	redacted := cfg.Redacted()
+	for i, route := range redacted.Orchestrator.NotificationRoutes[:2] {
+		if route.WebhookURL != "REDACTED" {
+			t.Errorf("route %d: expected the webhook URL to be redacted, got %q", i, route.WebhookURL)
+		}
+	}
+	if url := redacted.Orchestrator.NotificationRoutes[2].WebhookURL; url != "" {
+		t.Errorf("expected an empty webhook URL to stay empty, got %q", url)
+	}
+	if !strings.Contains(cfg.Orchestrator.NotificationRoutes[0].WebhookURL, "secret-key") {
+		t.Error("expected the loaded routes not to be modified")
+	}
+}
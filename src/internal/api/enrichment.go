@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// EnrichmentRule adds context fields (owning team, runbook URL) to anomalies
+// whose labels equal every value in Match. A rule without matchers applies to
+// every anomaly, e.g. as a default owner.
+type EnrichmentRule struct {
+	Match  map[string]string `json:"match,omitempty"`
+	Fields map[string]string `json:"fields"`
+}
+
+// matches reports whether labels carry every label value the rule requires
+func (r EnrichmentRule) matches(labels map[string]string) bool {
+	for name, value := range r.Match {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Enricher applies enrichment rules to anomalies before they are published
+type Enricher struct {
+	rules []EnrichmentRule
+}
+
+// NewEnricher validates the rules and returns an enricher applying them in order
+func NewEnricher(rules []EnrichmentRule) (*Enricher, error) {
+	for i, rule := range rules {
+		if len(rule.Fields) == 0 {
+			return nil, fmt.Errorf("enrichment rule %d: fields cannot be empty", i)
+		}
+		for name := range rule.Match {
+			if name == "" {
+				return nil, fmt.Errorf("enrichment rule %d: label name cannot be empty", i)
+			}
+		}
+	}
+	return &Enricher{rules: rules}, nil
+}
+
+// Fields returns the fields of every rule matching labels; when several rules
+// set the same field, the later one wins
+func (e *Enricher) Fields(labels map[string]string) map[string]string {
+	var fields map[string]string
+	for _, rule := range e.rules {
+		if !rule.matches(labels) {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		for name, value := range rule.Fields {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+// Enrich stores the fields matching the anomaly's labels (Details["labels"])
+// in Details["enrichment"]. It is a no-op when no rule matches.
+func (e *Enricher) Enrich(anomaly *detector.Anomaly) {
+	if e == nil || anomaly == nil {
+		return
+	}
+	labels, _ := anomaly.Details["labels"].(map[string]string)
+	fields := e.Fields(labels)
+	if len(fields) == 0 {
+		return
+	}
+	if anomaly.Details == nil {
+		anomaly.Details = make(map[string]interface{})
+	}
+	anomaly.Details["enrichment"] = fields
+}
+
+// attachLabels adds a detector's labels to the anomaly's Details["labels"].
+// Labels the anomaly already carries (e.g. of an ingested point) take precedence.
+func attachLabels(anomaly *detector.Anomaly, labels map[string]string) {
+	if anomaly == nil || len(labels) == 0 {
+		return
+	}
+	own, _ := anomaly.Details["labels"].(map[string]string)
+	merged := make(map[string]string, len(labels)+len(own))
+	for name, value := range labels {
+		merged[name] = value
+	}
+	for name, value := range own {
+		merged[name] = value
+	}
+	if anomaly.Details == nil {
+		anomaly.Details = make(map[string]interface{})
+	}
+	anomaly.Details["labels"] = merged
+}
+
+// EnrichAnomaly applies the enrichment rules to an anomaly raised outside the
+// detector API (e.g. by the Loki detector) before it is notified
+func (s *Server) EnrichAnomaly(anomaly *detector.Anomaly) {
+	s.enricher.Enrich(anomaly)
+}
+
+// EnrichmentFields returns the fields of the enrichment rules matching labels,
+// nil when none matches
+func (s *Server) EnrichmentFields(labels map[string]string) map[string]string {
+	if s.enricher == nil {
+		return nil
+	}
+	return s.enricher.Fields(labels)
+}
+
+// SetAnomalyEnrichment configures the rules applied to detector anomalies
+// before they are stored, broadcast and sent to callbacks
+func (s *Server) SetAnomalyEnrichment(rules []EnrichmentRule) error {
+	enricher, err := NewEnricher(rules)
+	if err != nil {
+		return err
+	}
+	s.enricher = enricher
+	return nil
+}
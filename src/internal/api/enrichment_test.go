@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestEnricher_Fields(t *testing.T) {
+	enricher, err := NewEnricher([]EnrichmentRule{
+		{Fields: map[string]string{"team": "sre"}},
+		{Match: map[string]string{"service": "payments"}, Fields: map[string]string{"team": "fin", "runbook": "https://runbooks/payments"}},
+		{Match: map[string]string{"service": "payments", "env": "prod"}, Fields: map[string]string{"pager": "fin-oncall"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   map[string]string
+	}{
+		{"default rule only", map[string]string{"service": "search"}, map[string]string{"team": "sre"}},
+		{"no labels", nil, map[string]string{"team": "sre"}},
+		{"later rule overrides", map[string]string{"service": "payments"},
+			map[string]string{"team": "fin", "runbook": "https://runbooks/payments"}},
+		{"all matchers required", map[string]string{"service": "payments", "env": "prod"},
+			map[string]string{"team": "fin", "runbook": "https://runbooks/payments", "pager": "fin-oncall"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := enricher.Fields(tt.labels); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := NewEnricher([]EnrichmentRule{{Match: map[string]string{"service": "payments"}}}); err == nil {
+		t.Error("expected a rule without fields to be rejected")
+	}
+}
+
+func TestIngestDataPoints_Enrichment(t *testing.T) {
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	if err := s.SetAnomalyEnrichment([]EnrichmentRule{
+		{Match: map[string]string{"service": "payments"}, Fields: map[string]string{"team": "fin"}},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	points := []datasource.DataPoint{
+		{Value: 50, Labels: map[string]string{"service": "payments"}},
+		{Value: 60, Labels: map[string]string{"service": "search"}},
+	}
+	result, err := s.IngestDataPoints(context.Background(), "detector_1", points)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Anomalies) != 2 {
+		t.Fatalf("expected 2 anomalies, got %d", len(result.Anomalies))
+	}
+
+	if fields, _ := result.Anomalies[0].Details["enrichment"].(map[string]string); fields["team"] != "fin" {
+		t.Errorf("expected the payments anomaly to be routed to fin, got %v", result.Anomalies[0].Details)
+	}
+	if _, ok := result.Anomalies[1].Details["enrichment"]; ok {
+		t.Errorf("expected no enrichment for an unmatched anomaly, got %v", result.Anomalies[1].Details)
+	}
+
+	// The stored record shares the enriched anomaly
+	records := s.anomalyStore.List(AnomalyFilter{}, 0)
+	if len(records) != 2 || records[1].Anomaly.Details["enrichment"] == nil {
+		t.Errorf("expected the stored anomaly to be enriched, got %+v", records)
+	}
+}
+
+func TestNotifyDetectorCallback_DetectorLabels(t *testing.T) {
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.Labels = map[string]string{"service": "payments", "env": "prod"}
+	if err := s.SetAnomalyEnrichment([]EnrichmentRule{
+		{Match: map[string]string{"service": "payments"}, Fields: map[string]string{"team": "fin"}},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	anomaly := &detector.Anomaly{Value: 50}
+	s.NotifyDetection("detector_1", 50, anomaly)
+
+	labels, _ := anomaly.Details["labels"].(map[string]string)
+	if labels["service"] != "payments" {
+		t.Errorf("expected the detector labels on the anomaly, got %v", anomaly.Details)
+	}
+	if fields, _ := anomaly.Details["enrichment"].(map[string]string); fields["team"] != "fin" {
+		t.Errorf("expected the anomaly to be enriched from the detector labels, got %v", anomaly.Details)
+	}
+
+	// Labels of the point take precedence over the detector's
+	own := &detector.Anomaly{Value: 60, Details: map[string]interface{}{"labels": map[string]string{"env": "staging"}}}
+	s.NotifyDetection("detector_1", 60, own)
+	labels, _ = own.Details["labels"].(map[string]string)
+	if labels["env"] != "staging" || labels["service"] != "payments" {
+		t.Errorf("expected point labels merged over detector labels, got %v", labels)
+	}
+}
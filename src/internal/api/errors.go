@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"runtime/debug"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/metrics"
 )
 
 // ErrorCode represents different types of errors
@@ -20,6 +22,7 @@ const (
 	ErrorCodeForbidden    ErrorCode = "FORBIDDEN"
 	ErrorCodeConflict     ErrorCode = "CONFLICT"
 	ErrorCodeRateLimit    ErrorCode = "RATE_LIMIT"
+	ErrorCodeTooLarge     ErrorCode = "PAYLOAD_TOO_LARGE"
 
 	// Server errors (5xx)
 	ErrorCodeInternal      ErrorCode = "INTERNAL_ERROR"
@@ -171,6 +174,7 @@ func (e *APIError) GetHTTPStatusCode() int {
 		ErrorCodeForbidden:        http.StatusForbidden,
 		ErrorCodeConflict:         http.StatusConflict,
 		ErrorCodeRateLimit:        http.StatusTooManyRequests,
+		ErrorCodeTooLarge:         http.StatusRequestEntityTooLarge,
 		ErrorCodeDetectorConflict: http.StatusConflict,
 		ErrorCodeQueryError:       http.StatusBadRequest,
 		ErrorCodeDataSourceAuth:   http.StatusUnauthorized,
@@ -291,15 +295,37 @@ type SystemHealthResponse struct {
 	Components []HealthCheckError `json:"components,omitempty"`
 }
 
-// RecoveryMiddleware provides panic recovery with error logging
+// RecoveryMiddleware provides panic recovery with error logging. The stack
+// trace is always logged; it is also returned in the error context while the
+// global logger is at DEBUG level.
 func RecoveryMiddleware() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		stack := string(debug.Stack())
 		err := fmt.Errorf("panic recovered: %v", recovered)
 		apiError := NewInternalError("panic_recovery", err)
 
-		// Log panic with stack trace
-		log.Printf("PANIC [%s] %s %s - %v",
-			c.ClientIP(), c.Request.Method, c.Request.URL.Path, recovered)
+		// The route template keeps the metric's cardinality bounded
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.APIPanics.WithLabelValues(route).Inc()
+
+		globalLogger().Error("Panic recovered", err, map[string]interface{}{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"route":      route,
+			"client_ip":  c.ClientIP(),
+			"request_id": c.GetHeader("X-Request-ID"),
+			"stack":      stack,
+		})
+
+		if globalLogger().Level() == LogLevelDebug {
+			apiError.Context = map[string]interface{}{
+				"panic": fmt.Sprint(recovered),
+				"stack": stack,
+			}
+		}
 
 		HandleError(c, apiError)
 	})
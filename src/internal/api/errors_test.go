@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yourusername/aiops-infra/src/internal/metrics"
+)
+
+func newPanickingEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RecoveryMiddleware())
+	router.GET("/boom/:id", func(c *gin.Context) {
+		panic("boom")
+	})
+	return router
+}
+
+func TestRecoveryMiddleware_LogsStackAndCountsPanic(t *testing.T) {
+	previous := GlobalLogger
+	defer func() { GlobalLogger = previous }()
+	InitLogger("test", LogLevelInfo)
+	var out bytes.Buffer
+	GlobalLogger.output = log.New(&out, "", 0)
+
+	before := testutil.ToFloat64(metrics.APIPanics.WithLabelValues("/boom/:id"))
+
+	w := httptest.NewRecorder()
+	newPanickingEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom/1", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	if got := testutil.ToFloat64(metrics.APIPanics.WithLabelValues("/boom/:id")); got != before+1 {
+		t.Errorf("expected the panic counter to be incremented, got %v after %v", got, before)
+	}
+	if !strings.Contains(out.String(), "Panic recovered") || !strings.Contains(out.String(), "goroutine") {
+		t.Errorf("expected the stack trace in the log, got %s", out.String())
+	}
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Error.Context != nil {
+		t.Errorf("expected no stack in the response outside debug mode, got %v", resp.Error.Context)
+	}
+}
+
+func TestRecoveryMiddleware_StackInContextWhenDebugging(t *testing.T) {
+	previous := GlobalLogger
+	defer func() { GlobalLogger = previous }()
+	InitLogger("test", LogLevelDebug)
+	GlobalLogger.output = log.New(&bytes.Buffer{}, "", 0)
+
+	w := httptest.NewRecorder()
+	newPanickingEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom/1", nil))
+
+	var resp struct {
+		Error struct {
+			Context map[string]string `json:"context"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Error.Context["panic"] != "boom" {
+		t.Errorf("expected the panic value in the context, got %q", resp.Error.Context["panic"])
+	}
+	if !strings.Contains(resp.Error.Context["stack"], "goroutine") {
+		t.Errorf("expected the stack trace in the context, got %q", resp.Error.Context["stack"])
+	}
+}
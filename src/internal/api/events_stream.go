@@ -0,0 +1,185 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/metrics"
+)
+
+// DefaultStreamHeartbeatInterval is how often an idle event stream receives a
+// heartbeat comment, keeping proxies from closing it
+const DefaultStreamHeartbeatInterval = 15 * time.Second
+
+// StreamBufferSize is the number of events buffered per stream; events for a
+// stream that cannot keep up are dropped
+const StreamBufferSize = 64
+
+// streamTopics are the topics a stream can subscribe to
+var streamTopics = map[string]bool{
+	TopicDetectors: true,
+	TopicAnomalies: true,
+	TopicSystem:    true,
+}
+
+// eventStream is a Server-Sent Events subscriber of the gateway
+type eventStream struct {
+	clientID  string
+	namespace string
+	topics    map[string]bool
+	events    chan Event
+}
+
+// deliver queues the event if the stream wants it, dropping it when the
+// stream's buffer is full. Called with the gateway mutex held.
+func (s *eventStream) deliver(event Event) {
+	if event.ClientID != "" && event.ClientID != s.clientID {
+		return
+	}
+	if !s.topics[event.Topic] && event.Topic != TopicSystem {
+		return
+	}
+	if !event.visibleIn(s.namespace) {
+		return
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		metrics.WebSocketEventsDropped.WithLabelValues(event.Topic).Inc()
+		log.Printf("Event stream %s is full, dropping event: %s", s.clientID, event.Type)
+	}
+}
+
+// SetStreamHeartbeatInterval sets how often idle event streams receive a heartbeat
+func (gw *WebSocketGateway) SetStreamHeartbeatInterval(interval time.Duration) {
+	gw.mutex.Lock()
+	defer gw.mutex.Unlock()
+	gw.streamHeartbeat = interval
+}
+
+// parseStreamTopics parses the comma-separated topics query parameter
+func parseStreamTopics(param string) (map[string]bool, error) {
+	topics := make(map[string]bool)
+	for _, topic := range strings.Split(param, ",") {
+		topic = strings.TrimSpace(topic)
+		if topic == "" {
+			continue
+		}
+		if !streamTopics[topic] {
+			return nil, fmt.Errorf("unknown topic: %s", topic)
+		}
+		topics[topic] = true
+	}
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("at least one topic is required")
+	}
+	return topics, nil
+}
+
+// HandleEventStream streams gateway events over Server-Sent Events, for clients
+// behind proxies that do not pass WebSockets. Topics are subscribed with the
+// topics query parameter, e.g. /api/events/stream?topics=anomalies,detectors.
+// Only events of the subscriber's namespace and global events are streamed.
+func (gw *WebSocketGateway) HandleEventStream(c *gin.Context) {
+	topics, err := parseStreamTopics(c.Query("topics"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	namespace, ok := subscriberNamespace(c)
+	if !ok {
+		return
+	}
+
+	if !gw.reserveSlot() {
+		log.Printf("Event stream connection limit reached, rejecting %s", c.ClientIP())
+		c.Header("Retry-After", "30")
+		HandleError(c, NewAPIError(ErrorCodeServiceDown, "Too many event stream connections",
+			"The connection limit has been reached, retry later"))
+		return
+	}
+
+	stream := &eventStream{
+		clientID:  fmt.Sprintf("stream_%d", time.Now().UnixNano()),
+		namespace: namespace,
+		topics:    topics,
+		events:    make(chan Event, StreamBufferSize),
+	}
+
+	// Register stream, converting the reserved slot
+	gw.mutex.Lock()
+	gw.pendingUpgrades--
+	gw.streams[stream.clientID] = stream
+	heartbeatInterval := gw.streamHeartbeat
+	mode := gw.nonFiniteFloats
+	gw.mutex.Unlock()
+
+	defer func() {
+		gw.mutex.Lock()
+		delete(gw.streams, stream.clientID)
+		gw.mutex.Unlock()
+		log.Printf("Event stream client disconnected: %s", stream.clientID)
+	}()
+
+	log.Printf("Event stream client connected: %s", stream.clientID)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	c.SSEvent("connected", Event{
+		Type:      "connected",
+		Topic:     TopicSystem,
+		Data:      map[string]string{"client_id": stream.clientID},
+		Timestamp: time.Now(),
+	})
+	c.Writer.Flush()
+
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = DefaultStreamHeartbeatInterval
+	}
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-gw.done:
+			// The gateway is shutting down, write out buffered events
+			for {
+				select {
+				case event := <-stream.events:
+					writeStreamEvent(c, stream, event, mode)
+				default:
+					c.Writer.Flush()
+					return
+				}
+			}
+		case event := <-stream.events:
+			writeStreamEvent(c, stream, event, mode)
+		case <-heartbeat.C:
+			// Comment lines are ignored by EventSource clients
+			fmt.Fprintf(c.Writer, ": heartbeat %d\n\n", time.Now().Unix())
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeStreamEvent writes an event like c.SSEvent, but tolerating NaN and ±Inf
+// scores
+func writeStreamEvent(c *gin.Context, stream *eventStream, event Event, mode string) {
+	data, err := marshalFinite(event, mode)
+	if err != nil {
+		log.Printf("Failed to encode event for stream %s: %v", stream.clientID, err)
+		return
+	}
+	fmt.Fprintf(c.Writer, "event:%s\ndata:%s\n\n", event.Type, data)
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseStreamTopics(t *testing.T) {
+	topics, err := parseStreamTopics("anomalies, detectors,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(topics) != 2 || !topics[TopicAnomalies] || !topics[TopicDetectors] {
+		t.Errorf("unexpected topics: %v", topics)
+	}
+
+	if _, err := parseStreamTopics(""); err == nil {
+		t.Error("expected an error without topics")
+	}
+	if _, err := parseStreamTopics("anomalies,metrics"); err == nil {
+		t.Error("expected an error for an unknown topic")
+	}
+}
+
+func TestHandleEventStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gw := NewWebSocketGateway()
+	gw.SetStreamHeartbeatInterval(20 * time.Millisecond)
+	gw.Start(ctx)
+
+	router := gin.New()
+	router.GET("/api/events/stream", gw.HandleEventStream)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events/stream?topics=badtopic")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown topic, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/api/events/stream?topics=anomalies&namespace=Team_A")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid namespace, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events/stream?topics=anomalies", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("expected an event stream, got %q", ct)
+	}
+
+	lines := make(chan string, 100)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	// waitFor reads lines until one has the prefix, returning the lines read
+	waitFor := func(prefix string) []string {
+		var seen []string
+		timeout := time.After(2 * time.Second)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatalf("stream closed waiting for %q", prefix)
+				}
+				seen = append(seen, line)
+				if strings.HasPrefix(line, prefix) {
+					return seen
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %q", prefix)
+			}
+		}
+	}
+
+	waitFor("event:connected")
+	waitFor(": heartbeat")
+
+	// Only the subscribed topic and the stream's namespace reach the stream
+	gw.SendEvent(Event{Type: EventDetectorCreated, Topic: TopicDetectors, Timestamp: time.Now()})
+	gw.SendEvent(Event{Type: EventAnomalyUpdated, Topic: TopicAnomalies, Timestamp: time.Now(), Namespace: "team-a"})
+	gw.SendEvent(Event{Type: EventAnomalyDetected, Topic: TopicAnomalies, Data: "cpu", Timestamp: time.Now(), Namespace: DefaultNamespace})
+
+	seen := waitFor("event:" + EventAnomalyDetected)
+	for _, line := range seen {
+		if strings.Contains(line, EventDetectorCreated) {
+			t.Errorf("unsubscribed event delivered: %s", line)
+		}
+		if strings.Contains(line, EventAnomalyUpdated) {
+			t.Errorf("event of another namespace delivered: %s", line)
+		}
+	}
+	data := waitFor("data:")
+	if line := data[len(data)-1]; !strings.Contains(line, `"topic":"anomalies"`) {
+		t.Errorf("unexpected event data: %s", line)
+	}
+
+	if n := gw.GetClientInfo()["stream_clients"]; n != 1 {
+		t.Errorf("expected 1 stream client, got %v", n)
+	}
+}
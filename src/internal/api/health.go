@@ -24,6 +24,7 @@ type ComponentHealth struct {
 	Status       HealthStatus      `json:"status"`
 	Message      string            `json:"message,omitempty"`
 	LastCheck    time.Time         `json:"last_check"`
+	LastCheckAge string            `json:"last_check_age,omitempty"`
 	ResponseTime string            `json:"response_time,omitempty"`
 	Details      map[string]string `json:"details,omitempty"`
 }
@@ -364,16 +365,8 @@ func calculateSummary(components []ComponentHealth) HealthSummary {
 
 // HealthHandler returns overall system health
 func HealthHandler(c *gin.Context) {
-	// Check all components
-	components := []ComponentHealth{
-		checkSystemHealth(),
-		checkDatabaseHealth(),
-		checkCacheHealth(),
-		checkWebSocketHealth(),
-		checkDetectorHealth(),
-		checkPrometheusHealth(),
-		checkLokiHealth(),
-	}
+	// Check all components (served from cache between refreshes)
+	components := GlobalHealthCache.GetAll()
 	
 	overallStatus := calculateOverallStatus(components)
 	summary := calculateSummary(components)
@@ -398,22 +391,23 @@ func HealthHandler(c *gin.Context) {
 	c.JSON(statusCode, health)
 }
 
-// ReadinessHandler returns readiness status (simplified health check)
+// ReadinessHandler returns 503 until every registered component (data sources,
+// detection pipeline) has passed its readiness check at least once
 func ReadinessHandler(c *gin.Context) {
-	// Quick readiness check - just check if critical components are responding
-	ready := true
-	components := []string{}
+	readyComponents, notReady := GlobalReadiness.Check(c.Request.Context())
+	ready := len(notReady) == 0
 	
-	// Check if API is responsive
-	if ready {
-		components = append(components, "api")
-	}
+	// The API itself is ready once it serves this request
+	components := append([]string{"api"}, readyComponents...)
 	
 	response := gin.H{
 		"ready":      ready,
 		"timestamp":  time.Now(),
 		"components": components,
 	}
+	if !ready {
+		response["not_ready"] = notReady
+	}
 	
 	if ready {
 		c.JSON(http.StatusOK, response)
@@ -443,24 +437,8 @@ func MetricsHandler(c *gin.Context) {
 func ComponentHealthHandler(c *gin.Context) {
 	component := c.Param("component")
 	
-	var health ComponentHealth
-	
-	switch component {
-	case "prometheus":
-		health = checkPrometheusHealth()
-	case "loki":
-		health = checkLokiHealth()
-	case "database":
-		health = checkDatabaseHealth()
-	case "cache":
-		health = checkCacheHealth()
-	case "websocket":
-		health = checkWebSocketHealth()
-	case "detector":
-		health = checkDetectorHealth()
-	case "system":
-		health = checkSystemHealth()
-	default:
+	health, ok := GlobalHealthCache.Get(component)
+	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": fmt.Sprintf("Component '%s' not found", component),
 			"available_components": []string{
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultHealthCacheTTL is how long component health results are served from cache
+const DefaultHealthCacheTTL = 10 * time.Second
+
+// healthCheckers maps component names to their checks, in reporting order
+var healthCheckers = []struct {
+	name  string
+	check func() ComponentHealth
+}{
+	{"system", checkSystemHealth},
+	{"database", checkDatabaseHealth},
+	{"cache", checkCacheHealth},
+	{"websocket", checkWebSocketHealth},
+	{"detector", checkDetectorHealth},
+	{"prometheus", checkPrometheusHealth},
+	{"loki", checkLokiHealth},
+}
+
+// HealthCache caches component health so that frequent /health polling
+// does not translate into live probes against Prometheus and Loki
+type HealthCache struct {
+	ttl     time.Duration
+	results map[string]ComponentHealth
+	mu      sync.RWMutex
+	started bool
+}
+
+// GlobalHealthCache is the health cache used by the health handlers
+var GlobalHealthCache = NewHealthCache(DefaultHealthCacheTTL)
+
+// NewHealthCache creates a health cache; a non-positive TTL disables caching
+func NewHealthCache(ttl time.Duration) *HealthCache {
+	return &HealthCache{
+		ttl:     ttl,
+		results: make(map[string]ComponentHealth),
+	}
+}
+
+// SetTTL changes the cache TTL
+func (hc *HealthCache) SetTTL(ttl time.Duration) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.ttl = ttl
+}
+
+// Start launches a background refresher that re-checks all components every TTL
+func (hc *HealthCache) Start(ctx context.Context) {
+	hc.mu.Lock()
+	if hc.started || hc.ttl <= 0 {
+		hc.mu.Unlock()
+		return
+	}
+	hc.started = true
+	ttl := hc.ttl
+	hc.mu.Unlock()
+
+	go func() {
+		hc.refreshAll()
+
+		ticker := time.NewTicker(ttl)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				hc.mu.Lock()
+				hc.started = false
+				hc.mu.Unlock()
+				return
+			case <-ticker.C:
+				hc.refreshAll()
+			}
+		}
+	}()
+}
+
+// Get returns the health of a component, checking it live when the cached result has expired.
+// The second return value is false for unknown components.
+func (hc *HealthCache) Get(name string) (ComponentHealth, bool) {
+	for _, checker := range healthCheckers {
+		if checker.name != name {
+			continue
+		}
+
+		hc.mu.RLock()
+		cached, ok := hc.results[name]
+		ttl := hc.ttl
+		hc.mu.RUnlock()
+
+		if ok && ttl > 0 && time.Since(cached.LastCheck) < ttl {
+			return withCheckAge(cached), true
+		}
+
+		return withCheckAge(hc.refresh(name, checker.check)), true
+	}
+
+	return ComponentHealth{}, false
+}
+
+// GetAll returns the health of all components in reporting order
+func (hc *HealthCache) GetAll() []ComponentHealth {
+	components := make([]ComponentHealth, 0, len(healthCheckers))
+	for _, checker := range healthCheckers {
+		health, _ := hc.Get(checker.name)
+		components = append(components, health)
+	}
+	return components
+}
+
+// refreshAll re-checks every component
+func (hc *HealthCache) refreshAll() {
+	for _, checker := range healthCheckers {
+		hc.refresh(checker.name, checker.check)
+	}
+}
+
+// refresh runs a single check and stores the result
+func (hc *HealthCache) refresh(name string, check func() ComponentHealth) ComponentHealth {
+	health := check()
+
+	hc.mu.Lock()
+	hc.results[name] = health
+	hc.mu.Unlock()
+
+	return health
+}
+
+// withCheckAge fills in how long ago the result was produced
+func withCheckAge(health ComponentHealth) ComponentHealth {
+	health.LastCheckAge = time.Since(health.LastCheck).Round(time.Millisecond).String()
+	return health
+}
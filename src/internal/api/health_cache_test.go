@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// withHealthChecker replaces the component checks with a single counting one
+func withHealthChecker(t *testing.T, name string, checks *int32) {
+	t.Helper()
+
+	original := healthCheckers
+	healthCheckers = healthCheckers[:0:0]
+	healthCheckers = append(healthCheckers, struct {
+		name  string
+		check func() ComponentHealth
+	}{name, func() ComponentHealth {
+		atomic.AddInt32(checks, 1)
+		return ComponentHealth{Name: name, Status: HealthStatusHealthy, LastCheck: time.Now()}
+	}})
+	t.Cleanup(func() { healthCheckers = original })
+}
+
+func TestHealthCache_ServesCachedResults(t *testing.T) {
+	var checks int32
+	withHealthChecker(t, "prometheus", &checks)
+	cache := NewHealthCache(time.Hour)
+
+	for i := 0; i < 3; i++ {
+		health, ok := cache.Get("prometheus")
+		if !ok || health.Status != HealthStatusHealthy || health.LastCheckAge == "" {
+			t.Fatalf("unexpected health result: %+v, %v", health, ok)
+		}
+	}
+	if checks != 1 {
+		t.Errorf("expected a single live check within the TTL, got %d", checks)
+	}
+
+	// An expired result is checked again
+	cache.mu.Lock()
+	expired := cache.results["prometheus"]
+	expired.LastCheck = time.Now().Add(-2 * time.Hour)
+	cache.results["prometheus"] = expired
+	cache.mu.Unlock()
+	cache.Get("prometheus")
+	if checks != 2 {
+		t.Errorf("expected the expired result to be re-checked, got %d checks", checks)
+	}
+
+	if _, ok := cache.Get("unknown"); ok {
+		t.Error("expected unknown components to be reported as such")
+	}
+
+	// Without a TTL every call checks live
+	cache.SetTTL(0)
+	cache.Get("prometheus")
+	cache.Get("prometheus")
+	if checks != 4 {
+		t.Errorf("expected live checks with caching disabled, got %d checks", checks)
+	}
+}
+
+func TestHealthCache_BackgroundRefresh(t *testing.T) {
+	var checks int32
+	withHealthChecker(t, "loki", &checks)
+	cache := NewHealthCache(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.Start(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&checks) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the refresher to re-check periodically, got %d checks", atomic.LoadInt32(&checks))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The refresher stops with its context
+	cancel()
+	for {
+		cache.mu.RLock()
+		started := cache.started
+		cache.mu.RUnlock()
+		if !started {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the refresher to stop")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inFlightPollInterval is how often WaitIdle checks the in-flight count
+const inFlightPollInterval = 10 * time.Millisecond
+
+// InFlightRequests is a gauge of the HTTP requests currently being served
+type InFlightRequests struct {
+	current int64
+	peak    int64
+}
+
+// GlobalInFlight counts the requests in flight on the API server
+var GlobalInFlight = &InFlightRequests{}
+
+// Start records a request entering a handler
+func (f *InFlightRequests) Start() {
+	current := atomic.AddInt64(&f.current, 1)
+	for {
+		peak := atomic.LoadInt64(&f.peak)
+		if current <= peak || atomic.CompareAndSwapInt64(&f.peak, peak, current) {
+			return
+		}
+	}
+}
+
+// Done records a request leaving its handler
+func (f *InFlightRequests) Done() {
+	atomic.AddInt64(&f.current, -1)
+}
+
+// Count returns the number of requests in flight
+func (f *InFlightRequests) Count() int64 {
+	return atomic.LoadInt64(&f.current)
+}
+
+// WaitIdle blocks until no request is in flight or ctx is done
+func (f *InFlightRequests) WaitIdle(ctx context.Context) error {
+	ticker := time.NewTicker(inFlightPollInterval)
+	defer ticker.Stop()
+
+	for f.Count() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// GetStats returns the current and peak number of in-flight requests
+func (f *InFlightRequests) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"current": f.Count(),
+		"peak":    atomic.LoadInt64(&f.peak),
+	}
+}
+
+// InFlightMiddleware counts requests while they are being served
+func InFlightMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		GlobalInFlight.Start()
+		defer GlobalInFlight.Done()
+
+		c.Next()
+	}
+}
+
+// logShutdownStart logs the requests in flight when shutdown starts and returns the start time
+func logShutdownStart() time.Time {
+	NewLogger("shutdown").Info("Shutdown started", map[string]interface{}{
+		"in_flight_requests": GlobalInFlight.Count(),
+	})
+	return time.Now()
+}
+
+// logShutdownDrain waits for the requests in flight to finish, or ctx to
+// expire, and logs which happened and how long shutdown has taken
+func logShutdownDrain(ctx context.Context, started time.Time) {
+	logger := NewLogger("shutdown")
+	if err := GlobalInFlight.WaitIdle(ctx); err != nil {
+		logger.Warn("Shutdown timed out with requests in flight", map[string]interface{}{
+			"in_flight_requests": GlobalInFlight.Count(),
+			"elapsed":            time.Since(started).String(),
+		})
+		return
+	}
+	logger.Info("In-flight requests drained", map[string]interface{}{
+		"elapsed": time.Since(started).String(),
+	})
+}
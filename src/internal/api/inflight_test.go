@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInFlightMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	previous := GlobalInFlight
+	GlobalInFlight = &InFlightRequests{}
+	defer func() { GlobalInFlight = previous }()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := gin.New()
+	router.Use(InFlightMiddleware())
+	router.GET("/slow", func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+		close(done)
+	}()
+	<-entered
+
+	if count := GlobalInFlight.Count(); count != 1 {
+		t.Fatalf("expected 1 request in flight, got %d", count)
+	}
+
+	// The slow request keeps the server busy past the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := GlobalInFlight.WaitIdle(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected WaitIdle to time out, got %v", err)
+	}
+
+	close(release)
+	<-done
+	if err := GlobalInFlight.WaitIdle(context.Background()); err != nil {
+		t.Errorf("expected no requests in flight, got %v", err)
+	}
+
+	stats := GlobalInFlight.GetStats()
+	if stats["current"] != int64(0) || stats["peak"] != int64(1) {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+	"github.com/yourusername/aiops-infra/src/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrDetectorNotFound is returned when a detector ID is not registered
+	ErrDetectorNotFound = errors.New("detector not found")
+	// ErrDetectorPaused is returned when a paused detector is fed new data
+	ErrDetectorPaused = errors.New("detector is paused")
+	// ErrDetectorNotTrainable is returned when a detector that is not running is fed
+	// data for training but does not support it
+	ErrDetectorNotTrainable = errors.New("detector does not support training")
+)
+
+// Ingestion modes reported in IngestResult
+const (
+	IngestModeTrain  = "train"
+	IngestModeDetect = "detect"
+)
+
+// DetectValue runs a single detection against a detector of the namespace
+// (DefaultNamespace when empty). It is the non-HTTP entry point used by
+// streaming ingestion paths and updates detector metrics and callbacks exactly
+// like the REST /detect endpoint. The timestamp is when the value was observed;
+// a zero timestamp means now.
+func (s *Server) DetectValue(ctx context.Context, namespace, detectorID string, value float64, timestamp time.Time) (*detector.Anomaly, error) {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	if err := validateNamespace(namespace); err != nil {
+		return nil, err
+	}
+
+	// detectValue addresses detectors by ID alone, check the namespace first
+	s.detectorManager.mu.RLock()
+	_, exists := s.detectorManager.get(namespace, detectorID)
+	s.detectorManager.mu.RUnlock()
+	if !exists {
+		return nil, ErrDetectorNotFound
+	}
+
+	detectorInstance, anomaly, err := s.detectValue(ctx, detectorID, value, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	if anomaly != nil {
+		s.notifyDetectorCallback(detectorInstance, value, anomaly)
+	}
+
+	return anomaly, nil
+}
+
+// detectValue runs a single detection like DetectValue but leaves notifying
+// about the anomaly to the caller
+func (s *Server) detectValue(ctx context.Context, detectorID string, value float64, timestamp time.Time) (*DetectorInstance, *detector.Anomaly, error) {
+	s.detectorManager.mu.RLock()
+	detectorInstance, exists := s.detectorManager.lookup(detectorID)
+	var status string
+	if exists {
+		status = detectorInstance.Status
+	}
+	s.detectorManager.mu.RUnlock()
+
+	if !exists {
+		return nil, nil, ErrDetectorNotFound
+	}
+	if status == "paused" {
+		return nil, nil, ErrDetectorPaused
+	}
+
+	release, err := GlobalDetectionLimiter.Acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	start := time.Now()
+	observed := timestamp
+	if observed.IsZero() {
+		observed = start
+	}
+
+	anomaly, score, scored, err := traceDetect(ctx, detectorInstance.Detector, value)
+	if err != nil {
+		return nil, nil, err
+	}
+	anomaly, _ = s.gateAnomaly(detectorInstance, anomaly)
+	s.escalateAnomaly(detectorInstance, anomaly, observed)
+
+	s.updateDetectorMetrics(detectorInstance, anomaly != nil, score, scored, time.Since(start))
+	s.publishScore(detectorInstance, value, score, scored, anomaly != nil, observed)
+
+	// Keep the source's timestamp for triage
+	if anomaly != nil && !timestamp.IsZero() {
+		anomaly.Timestamp = timestamp
+	}
+
+	return detectorInstance, anomaly, nil
+}
+
+// IngestResult describes how pushed data points were consumed by a detector
+type IngestResult struct {
+	Mode      string              `json:"mode"`
+	Accepted  int                 `json:"accepted"`
+	Anomalies []*detector.Anomaly `json:"anomalies"`
+}
+
+// IngestDataPoints feeds points from an arbitrary source into a detector the way
+// the metrics pipeline does: a running detector runs detection on every point
+// (updating metrics and emitting anomalies), any other non-paused detector is
+// trained on the values. Detection of a batch holds one detection slot.
+func (s *Server) IngestDataPoints(ctx context.Context, detectorID string, points []datasource.DataPoint) (*IngestResult, error) {
+	s.detectorManager.mu.RLock()
+	detectorInstance, exists := s.detectorManager.lookup(detectorID)
+	var status string
+	if exists {
+		status = detectorInstance.Status
+	}
+	s.detectorManager.mu.RUnlock()
+
+	if !exists {
+		return nil, ErrDetectorNotFound
+	}
+	if status == "paused" {
+		return nil, ErrDetectorPaused
+	}
+
+	if status != "running" {
+		trainable, ok := detectorInstance.Detector.(detector.TrainableDetector)
+		if !ok {
+			return nil, ErrDetectorNotTrainable
+		}
+
+		// Points are trained at the time they were observed, a zero timestamp meaning now
+		now := time.Now()
+		values := make([]float64, len(points))
+		timestamps := make([]time.Time, len(points))
+		for i, point := range points {
+			values[i] = point.Value
+			timestamps[i] = point.Timestamp
+			if timestamps[i].IsZero() {
+				timestamps[i] = now
+			}
+		}
+		if err := traceTrainTimed(ctx, trainable, timestamps, values); err != nil {
+			return nil, err
+		}
+
+		s.detectorManager.mu.Lock()
+		detectorInstance.UpdatedAt = time.Now()
+		s.detectorManager.mu.Unlock()
+
+		return &IngestResult{Mode: IngestModeTrain, Accepted: len(points), Anomalies: []*detector.Anomaly{}}, nil
+	}
+
+	release, err := GlobalDetectionLimiter.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// One span for the batch rather than one per point
+	ctx, span := tracing.Tracer().Start(ctx, "detector.Detect",
+		trace.WithAttributes(
+			attribute.String("detector.type", detectorInstance.Detector.Type()),
+			attribute.Int("points", len(points))))
+	defer span.End()
+
+	result := &IngestResult{Mode: IngestModeDetect, Anomalies: []*detector.Anomaly{}}
+	defer func() { span.SetAttributes(attribute.Int("anomalies", len(result.Anomalies))) }()
+	for i, point := range points {
+		start := time.Now()
+		anomaly, score, scored, err := detectScored(ctx, detectorInstance.Detector, point.Value)
+		if err != nil {
+			err = fmt.Errorf("point %d: %w", i, err)
+			tracing.RecordError(span, err)
+			return result, err
+		}
+		anomaly, _ = s.gateAnomaly(detectorInstance, anomaly)
+
+		s.updateDetectorMetrics(detectorInstance, anomaly != nil, score, scored, time.Since(start))
+		result.Accepted++
+
+		timestamp := point.Timestamp
+		if timestamp.IsZero() {
+			timestamp = start
+		}
+		s.publishScore(detectorInstance, point.Value, score, scored, anomaly != nil, timestamp)
+		s.escalateAnomaly(detectorInstance, anomaly, timestamp)
+
+		if anomaly == nil {
+			continue
+		}
+
+		// Keep the source's timestamp and labels for triage
+		if !point.Timestamp.IsZero() {
+			anomaly.Timestamp = point.Timestamp
+		}
+		if len(point.Labels) > 0 {
+			if anomaly.Details == nil {
+				anomaly.Details = make(map[string]interface{})
+			}
+			anomaly.Details["labels"] = point.Labels
+		}
+
+		s.notifyDetectorCallback(detectorInstance, point.Value, anomaly)
+		result.Anomalies = append(result.Anomalies, anomaly)
+	}
+
+	return result, nil
+}
+
+// IngestPoint is a single data point pushed to a detector
+type IngestPoint struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Value     *float64          `json:"value" binding:"required"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// IngestRequest is the body of POST /api/detectors/:id/ingest
+type IngestRequest struct {
+	Points []IngestPoint `json:"points" binding:"required,dive"`
+}
+
+// handleIngestDataPoints accepts data points pushed from sources other than Prometheus
+func (s *Server) handleIngestDataPoints(c *gin.Context) {
+	id := c.Param("id")
+
+	var request IngestRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(request.Points) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "points cannot be empty"})
+		return
+	}
+
+	if !checkValuesLimit(c, "points", len(request.Points), s.perfConfig.MaxTrainingValues) {
+		return
+	}
+
+	points := make([]datasource.DataPoint, len(request.Points))
+	for i, point := range request.Points {
+		points[i] = datasource.DataPoint{
+			Timestamp: point.Timestamp,
+			Value:     *point.Value,
+			Labels:    point.Labels,
+		}
+	}
+
+	// IngestDataPoints addresses detectors by ID alone, check the namespace first
+	s.detectorManager.mu.RLock()
+	_, exists := s.detectorManager.get(namespaceOf(c), id)
+	s.detectorManager.mu.RUnlock()
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrDetectorNotFound.Error()})
+		return
+	}
+
+	start := time.Now()
+	result, err := s.IngestDataPoints(c.Request.Context(), id, points)
+	switch {
+	case errors.Is(err, ErrDetectorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrDetectorPaused):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrDetectorNotTrainable):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrDetectionCapacity):
+		respondDetectionCapacity(c, err)
+		return
+	case err != nil:
+		response := gin.H{"error": err.Error()}
+		if result != nil {
+			response["accepted"] = result.Accepted
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	s.respondJSON(c, http.StatusOK, gin.H{
+		"detector_id":    id,
+		"mode":           result.Mode,
+		"accepted":       result.Accepted,
+		"anomalies":      result.Anomalies,
+		"anomaly_count":  len(result.Anomalies),
+		"ingestion_time": time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// thresholdDetector flags values above a fixed limit and records training data
+type thresholdDetector struct {
+	limit   float64
+	trained []float64
+}
+
+func (d *thresholdDetector) Detect(ctx context.Context, value float64) (*detector.Anomaly, error) {
+	if value <= d.limit {
+		return nil, nil
+	}
+	return &detector.Anomaly{Timestamp: time.Now(), Value: value, Threshold: d.limit}, nil
+}
+
+func (d *thresholdDetector) UpdateThreshold(threshold float64) error {
+	d.limit = threshold
+	return nil
+}
+
+func (d *thresholdDetector) IsAnomaly(values []float64) (bool, float64, error) {
+	return false, 0, nil
+}
+
+func (d *thresholdDetector) Type() string { return "threshold" }
+
+func (d *thresholdDetector) Train(values []float64) error {
+	d.trained = append(d.trained, values...)
+	return nil
+}
+
+func newIngestTestServer(status string, det detector.Detector) *Server {
+	return &Server{
+		detectorManager: detectorManagerWith(
+			&DetectorInstance{ID: "detector_1", Name: "custom", Status: status, Detector: det},
+		),
+		wsGateway:    NewWebSocketGateway(),
+		anomalyStore: NewAnomalyStore(10),
+	}
+}
+
+func TestIngestDataPoints_Detect(t *testing.T) {
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	pointTime := time.Now().Add(-time.Minute)
+
+	result, err := s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{
+		{Value: 5},
+		{Timestamp: pointTime, Value: 50, Labels: map[string]string{"host": "a"}},
+	})
+	if err != nil {
+		t.Fatalf("ingest failed: %v", err)
+	}
+	if result.Mode != IngestModeDetect || result.Accepted != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.Anomalies) != 1 {
+		t.Fatalf("expected 1 anomaly, got %d", len(result.Anomalies))
+	}
+
+	anomaly := result.Anomalies[0]
+	if !anomaly.Timestamp.Equal(pointTime) {
+		t.Errorf("expected the point timestamp on the anomaly, got %v", anomaly.Timestamp)
+	}
+	if labels, _ := anomaly.Details["labels"].(map[string]string); labels["host"] != "a" {
+		t.Errorf("expected point labels in details, got %v", anomaly.Details)
+	}
+
+	instance, _ := s.detectorManager.lookup("detector_1")
+	if instance.Metrics.TotalDetections != 2 || instance.Metrics.AnomaliesFound != 1 {
+		t.Errorf("unexpected metrics: %+v", instance.Metrics)
+	}
+	if stored := s.anomalyStore.List(AnomalyFilter{DetectorID: "detector_1"}, 0); len(stored) != 1 {
+		t.Errorf("expected the anomaly to be recorded, got %d", len(stored))
+	}
+}
+
+func TestIngestDataPoints_TrainAndErrors(t *testing.T) {
+	det := &thresholdDetector{limit: 10}
+	s := newIngestTestServer("stopped", det)
+
+	result, err := s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{{Value: 1}, {Value: 2}})
+	if err != nil {
+		t.Fatalf("ingest failed: %v", err)
+	}
+	if result.Mode != IngestModeTrain || result.Accepted != 2 || len(det.trained) != 2 {
+		t.Errorf("expected the stopped detector to be trained, got %+v (trained %v)", result, det.trained)
+	}
+
+	if _, err := s.IngestDataPoints(context.Background(), "missing", nil); !errors.Is(err, ErrDetectorNotFound) {
+		t.Errorf("expected ErrDetectorNotFound, got %v", err)
+	}
+
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.Status = "paused"
+	if _, err := s.IngestDataPoints(context.Background(), "detector_1", nil); !errors.Is(err, ErrDetectorPaused) {
+		t.Errorf("expected ErrDetectorPaused, got %v", err)
+	}
+}
+
+func TestIngestDataPoints_ShadowDetector(t *testing.T) {
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.Shadow = true
+	// The notifier is nil: publishing the anomaly would panic
+	instance.CallbackURL = "http://callback.invalid/hook"
+
+	result, err := s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{{Value: 5}, {Value: 50}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Anomalies) != 1 {
+		t.Fatalf("expected the shadow detector to still detect, got %d anomalies", len(result.Anomalies))
+	}
+
+	if instance.Metrics.AnomaliesFound != 1 || instance.Metrics.SuppressedAlerts != 1 {
+		t.Errorf("expected 1 anomaly found and suppressed, got %+v", instance.Metrics)
+	}
+
+	published := false
+	if records := s.anomalyStore.List(AnomalyFilter{Shadow: &published}, 0); len(records) != 0 {
+		t.Errorf("expected no published anomalies, got %d", len(records))
+	}
+	shadow := true
+	records := s.anomalyStore.List(AnomalyFilter{Shadow: &shadow}, 0)
+	if len(records) != 1 || records[0].Value != 50 {
+		t.Errorf("expected the anomaly to be recorded as shadow, got %+v", records)
+	}
+}
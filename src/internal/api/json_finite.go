@@ -0,0 +1,201 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ways of encoding NaN and ±Inf, which encoding/json rejects
+const (
+	// NonFiniteNull encodes NaN and ±Inf as null
+	NonFiniteNull = "null"
+	// NonFiniteClamp encodes NaN as null and ±Inf as ±math.MaxFloat64
+	NonFiniteClamp = "clamp"
+)
+
+// validateNonFiniteMode checks a PerformanceConfig.NonFiniteFloats value
+func validateNonFiniteMode(mode string) error {
+	switch mode {
+	case "", NonFiniteNull, NonFiniteClamp:
+		return nil
+	default:
+		return fmt.Errorf("invalid non-finite float mode %q, expected %q or %q", mode, NonFiniteNull, NonFiniteClamp)
+	}
+}
+
+// marshalFinite encodes v as JSON. Values holding NaN or ±Inf (e.g. scores of
+// a zero-variance baseline) are re-encoded with those floats replaced according
+// to mode instead of failing. Values without them are encoded only once.
+func marshalFinite(v interface{}, mode string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	var unsupported *json.UnsupportedValueError
+	if err == nil || !errors.As(err, &unsupported) {
+		return data, err
+	}
+	return json.Marshal(finiteValue(reflect.ValueOf(v), mode))
+}
+
+// SetNonFiniteFloats sets how NaN and ±Inf are encoded in detection responses
+// and WebSocket events: NonFiniteNull or NonFiniteClamp
+func (s *Server) SetNonFiniteFloats(mode string) error {
+	if err := validateNonFiniteMode(mode); err != nil {
+		return err
+	}
+	s.perfConfig.NonFiniteFloats = mode
+	s.wsGateway.SetNonFiniteFloats(mode)
+	return nil
+}
+
+// respondJSON writes v like c.JSON, encoding non-finite floats according to
+// the server's NonFiniteFloats setting
+func (s *Server) respondJSON(c *gin.Context, status int, v interface{}) {
+	data, err := marshalFinite(v, s.perfConfig.NonFiniteFloats)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to encode response: %v", err)})
+		return
+	}
+	c.Data(status, "application/json; charset=utf-8", data)
+}
+
+// finiteFloat replaces a non-finite float according to mode
+func finiteFloat(f float64, mode string) interface{} {
+	switch {
+	case math.IsNaN(f):
+		return nil
+	case math.IsInf(f, 0) && mode == NonFiniteClamp:
+		return math.Copysign(math.MaxFloat64, f)
+	case math.IsInf(f, 0):
+		return nil
+	}
+	return f
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// finiteValue converts v into maps, slices and scalars that encode to the same
+// JSON as v, with non-finite floats replaced. Types implementing json.Marshaler
+// (e.g. time.Time) are kept as they are.
+func finiteValue(v reflect.Value, mode string) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(jsonMarshalerType) && v.Kind() != reflect.Interface {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil
+		}
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return finiteFloat(v.Float(), mode)
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return finiteValue(v.Elem(), mode)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		result := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			result[fmt.Sprint(iter.Key().Interface())] = finiteValue(iter.Value(), mode)
+		}
+		return result
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface() // []byte is encoded as base64
+		}
+		fallthrough
+	case reflect.Array:
+		result := make([]interface{}, v.Len())
+		for i := range result {
+			result[i] = finiteValue(v.Index(i), mode)
+		}
+		return result
+
+	case reflect.Struct:
+		result := make(map[string]interface{}, v.NumField())
+		finiteStructFields(v, mode, result)
+		return result
+	}
+
+	return v.Interface()
+}
+
+// finiteStructFields adds the JSON fields of a struct to result, following the
+// json tag names, "-" and omitempty, and flattening embedded structs
+func finiteStructFields(v reflect.Value, mode string, result map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue // unexported
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		value := v.Field(i)
+
+		if field.Anonymous && name == "" {
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				finiteStructFields(value, mode, result)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmptyValue(value) {
+			continue
+		}
+		result[name] = finiteValue(value, mode)
+	}
+}
+
+// isEmptyValue reports whether omitempty drops the value, as in encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestMarshalFinite(t *testing.T) {
+	record := AnomalyRecord{
+		ID:         "anomaly_1",
+		DetectorID: "detector_1",
+		Value:      math.Inf(1),
+		Anomaly: &detector.Anomaly{
+			Value:   42,
+			Details: map[string]interface{}{"score": math.NaN(), "mean": 1.5},
+		},
+		DetectedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	data, err := marshalFinite(record, NonFiniteNull)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if decoded["value"] != nil || decoded["detected_at"] != "2024-01-01T00:00:00Z" {
+		t.Errorf("unexpected encoding: %s", data)
+	}
+	details := decoded["anomaly"].(map[string]interface{})["Details"].(map[string]interface{})
+	if details["score"] != nil || details["mean"] != 1.5 {
+		t.Errorf("unexpected details: %v", details)
+	}
+	// omitempty fields stay omitted
+	if _, exists := decoded["acknowledged_by"]; exists {
+		t.Errorf("expected empty omitempty fields to be omitted: %s", data)
+	}
+
+	data, err = marshalFinite(gin.H{"score": math.Inf(-1), "nan": math.NaN()}, NonFiniteClamp)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if string(data) != `{"nan":null,"score":-1.7976931348623157e+308}` {
+		t.Errorf("unexpected clamped encoding: %s", data)
+	}
+
+	// Finite values are encoded exactly like encoding/json does
+	finite := AnomalyRecord{ID: "anomaly_2", Value: 3, Anomaly: &detector.Anomaly{Value: 3}}
+	expected, _ := json.Marshal(finite)
+	if data, _ := marshalFinite(finite, NonFiniteNull); string(data) != string(expected) {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestHandleRunDetection_InfiniteScore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// A zero limit makes the score of any positive value +Inf
+	s := newIngestTestServer("running", &scoringDetector{thresholdDetector{limit: 0}})
+	router := gin.New()
+	router.POST("/api/detectors/:id/detect", s.handleRunDetection)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/detectors/detector_1/detect",
+		strings.NewReader(`{"value": 5, "values": [1, 5]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON %s: %v", w.Body.String(), err)
+	}
+	if score, exists := resp["anomaly_score"]; !exists || score != nil {
+		t.Errorf("expected a null anomaly_score, got %v", score)
+	}
+	if resp["is_anomaly"] != true {
+		t.Errorf("expected an anomaly, got %v", resp)
+	}
+}
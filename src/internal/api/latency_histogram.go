@@ -0,0 +1,100 @@
+package api
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// latencySubBucketBits sets the histogram precision: every power-of-two range
+// is split into 2^bits linear sub-buckets, bounding the relative error of a
+// quantile to 1/2^bits (about 3%)
+const latencySubBucketBits = 5
+
+const (
+	latencySubBuckets = 1 << latencySubBucketBits
+	// latencyBuckets covers the whole non-negative int64 range of durations
+	latencyBuckets = latencySubBuckets * (64 - latencySubBucketBits)
+)
+
+// latencyHistogram is a streaming quantile estimator for durations in the style
+// of an HDR histogram: constant memory, O(1) recording and bounded relative error
+type latencyHistogram struct {
+	counts [latencyBuckets]uint64
+	total  uint64
+	min    time.Duration
+	max    time.Duration
+}
+
+// record adds one observation
+func (h *latencyHistogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	if h.total == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.counts[latencyBucketIndex(uint64(d))]++
+	h.total++
+}
+
+// quantile estimates the q-quantile (0 < q <= 1) of the recorded durations
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(h.total)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen uint64
+	for index, count := range h.counts {
+		seen += count
+		if seen < rank {
+			continue
+		}
+		// Report the middle of the bucket, within the observed range
+		low, high := latencyBucketBounds(index)
+		estimate := time.Duration(low + (high-low)/2)
+		if estimate < h.min {
+			return h.min
+		}
+		if estimate > h.max {
+			return h.max
+		}
+		return estimate
+	}
+	return h.max
+}
+
+// reset drops all observations
+func (h *latencyHistogram) reset() {
+	*h = latencyHistogram{}
+}
+
+// latencyBucketIndex maps a value to its bucket: values below latencySubBuckets
+// get exact buckets, larger ones a linear sub-bucket of their power of two
+func latencyBucketIndex(v uint64) int {
+	if v < latencySubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - latencySubBucketBits - 1
+	top := v >> uint(shift)
+	return latencySubBuckets + shift*latencySubBuckets + int(top-latencySubBuckets)
+}
+
+// latencyBucketBounds returns the inclusive value range of a bucket
+func latencyBucketBounds(index int) (uint64, uint64) {
+	if index < latencySubBuckets {
+		return uint64(index), uint64(index)
+	}
+	shift := (index - latencySubBuckets) / latencySubBuckets
+	top := uint64(latencySubBuckets + (index-latencySubBuckets)%latencySubBuckets)
+	low := top << uint(shift)
+	return low, low + (uint64(1) << uint(shift)) - 1
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram_Quantiles(t *testing.T) {
+	h := &latencyHistogram{}
+	if got := h.quantile(0.5); got != 0 {
+		t.Errorf("expected 0 without observations, got %v", got)
+	}
+
+	// 1ms..1000ms, one observation each
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+
+	for _, tc := range []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.50, 500 * time.Millisecond},
+		{0.90, 900 * time.Millisecond},
+		{0.99, 990 * time.Millisecond},
+		{1, 1000 * time.Millisecond},
+	} {
+		got := h.quantile(tc.q)
+		if relErr := math.Abs(float64(got-tc.want)) / float64(tc.want); relErr > 1.0/latencySubBuckets {
+			t.Errorf("p%v: expected about %v, got %v", tc.q*100, tc.want, got)
+		}
+	}
+
+	h.reset()
+	if h.total != 0 || h.quantile(0.99) != 0 {
+		t.Error("expected reset to drop all observations")
+	}
+}
+
+func TestLatencyBucketBounds(t *testing.T) {
+	for _, v := range []uint64{0, 31, 32, 33, 63, 64, 1000, 123456789, math.MaxInt64} {
+		low, high := latencyBucketBounds(latencyBucketIndex(v))
+		if v < low || v > high {
+			t.Errorf("value %d outside its bucket [%d, %d]", v, low, high)
+		}
+	}
+}
+
+func TestPerformanceMetrics_Percentiles(t *testing.T) {
+	pm := &PerformanceMetrics{MinResponse: time.Hour}
+	for i := 0; i < 99; i++ {
+		pm.RecordRequest(10*time.Millisecond, false)
+	}
+	pm.RecordRequest(2*time.Second, true)
+
+	metrics := pm.GetMetrics()
+	for _, p := range []time.Duration{metrics.P50Response, metrics.P90Response, metrics.P99Response} {
+		if p < 10*time.Millisecond || p > 10*time.Millisecond+10*time.Millisecond/latencySubBuckets {
+			t.Errorf("expected p50, p90 and p99 of about 10ms, got %v, %v and %v", metrics.P50Response, metrics.P90Response, metrics.P99Response)
+			break
+		}
+	}
+	// The average hides the slow request the maximum shows
+	if metrics.MaxResponse != 2*time.Second || metrics.AverageResponse < 29*time.Millisecond {
+		t.Errorf("unexpected max %v or average %v", metrics.MaxResponse, metrics.AverageResponse)
+	}
+
+	pm.Reset()
+	if metrics := pm.GetMetrics(); metrics.P90Response != 0 {
+		t.Errorf("expected percentiles to be reset, got p90 %v", metrics.P90Response)
+	}
+}
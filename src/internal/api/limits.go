@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultMaxBodyBytes is the default request body limit for JSON endpoints
+	DefaultMaxBodyBytes int64 = 4 << 20 // 4 MB
+	// DefaultMaxJSONDepth is the default maximum nesting depth of JSON payloads
+	DefaultMaxJSONDepth = 32
+	// DefaultMaxTrainingValues is the default maximum number of values accepted in one request
+	DefaultMaxTrainingValues = 100000
+	// DefaultMaxQueryRange is the default maximum time span of a single range query
+	DefaultMaxQueryRange = 7 * 24 * time.Hour
+)
+
+// BodyLimitMiddleware caps request body size and JSON nesting depth.
+// Oversized bodies are rejected with 413, overly nested JSON with 400.
+func BodyLimitMiddleware(maxBytes int64, maxDepth int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || !hasRequestBody(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			abortPayloadTooLarge(c, maxBytes)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				abortPayloadTooLarge(c, maxBytes)
+				return
+			}
+			HandleValidationError(c, "body", fmt.Sprintf("failed to read request body: %v", err))
+			return
+		}
+
+		if maxDepth > 0 && isJSONContent(c.ContentType()) {
+			if depth := jsonDepth(body); depth > maxDepth {
+				HandleValidationError(c, "body", fmt.Sprintf("JSON nesting depth %d exceeds limit of %d", depth, maxDepth))
+				return
+			}
+		}
+
+		// Restore the body for the handlers
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// abortPayloadTooLarge responds with a structured 413 error
+func abortPayloadTooLarge(c *gin.Context, maxBytes int64) {
+	apiError := NewAPIError(ErrorCodeTooLarge, "Request body too large",
+		fmt.Sprintf("Request body exceeds limit of %d bytes", maxBytes))
+	apiError.Context = map[string]int64{"max_bytes": maxBytes}
+	HandleError(c, apiError)
+}
+
+// hasRequestBody reports whether the method is expected to carry a body
+func hasRequestBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// isJSONContent reports whether the content type is JSON; ShouldBindJSON ignores
+// the header, so a missing content type is treated as JSON too
+func isJSONContent(contentType string) bool {
+	return contentType == "" || strings.HasSuffix(contentType, "json")
+}
+
+// jsonDepth returns the maximum nesting depth of objects and arrays in data.
+// It does not validate the document; malformed JSON is left to the binder.
+func jsonDepth(data []byte) int {
+	depth, maxDepth := 0, 0
+	inString, escaped := false, false
+
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+
+	return maxDepth
+}
+
+// checkValuesLimit validates the number of values in a request against the configured cap
+func checkValuesLimit(c *gin.Context, field string, count, limit int) bool {
+	if limit > 0 && count > limit {
+		apiError := NewAPIError(ErrorCodeTooLarge, "Too many values",
+			fmt.Sprintf("Field '%s' has %d elements, limit is %d", field, count, limit))
+		apiError.Context = map[string]int{"max_values": limit}
+		HandleError(c, apiError)
+		return false
+	}
+	return true
+}
+
+// checkFiniteValues rejects NaN and ±Inf values, which would poison detector
+// statistics, with a VALIDATION_ERROR listing their indices
+func checkFiniteValues(c *gin.Context, field string, values []float64) bool {
+	var bad []int
+	for i, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			bad = append(bad, i)
+		}
+	}
+	if len(bad) == 0 {
+		return true
+	}
+
+	apiError := NewValidationError(field, fmt.Sprintf("non-finite values at indices %v", bad))
+	apiError.Context = map[string][]int{"invalid_indices": bad}
+	HandleError(c, apiError)
+	return false
+}
+
+// SetMaxQueryRange sets the maximum time span of range queries, both for the
+// server's own endpoints and for the data source API (0 disables the check)
+func (s *Server) SetMaxQueryRange(maxSpan time.Duration) {
+	s.perfConfig.MaxQueryRange = maxSpan
+	if s.dataSourceAPI != nil {
+		s.dataSourceAPI.SetMaxQueryRange(maxSpan)
+	}
+}
+
+// validateTimeRange checks that start is before end and, when maxSpan is positive,
+// that the range does not exceed it. It returns a VALIDATION_ERROR APIError.
+func validateTimeRange(start, end time.Time, maxSpan time.Duration) error {
+	if !start.Before(end) {
+		return NewAPIError(ErrorCodeValidation, "Invalid time range",
+			fmt.Sprintf("start (%s) must be before end (%s)", start.Format(time.RFC3339), end.Format(time.RFC3339)))
+	}
+
+	if maxSpan > 0 && end.Sub(start) > maxSpan {
+		apiError := NewAPIError(ErrorCodeValidation, "Time range too large",
+			fmt.Sprintf("Range of %s exceeds the maximum of %s", end.Sub(start), maxSpan))
+		apiError.Context = map[string]string{"max_span": maxSpan.String()}
+		return apiError
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJSONDepth(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{name: "scalar", input: `42`, want: 0},
+		{name: "flat object", input: `{"a": 1, "b": [1, 2]}`, want: 2},
+		{name: "brackets inside string", input: `{"a": "[[[{{{"}`, want: 1},
+		{name: "escaped quote", input: `{"a": "\"[["}`, want: 1},
+		{name: "deep nesting", input: `[[[[{"a": [1]}]]]]`, want: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonDepth([]byte(tt.input)); got != tt.want {
+				t.Errorf("jsonDepth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "small body", body: `{"values": [1, 2, 3]}`, expectedStatus: http.StatusOK},
+		{name: "body too large", body: `{"values": [` + strings.Repeat("1,", 100) + `1]}`, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "too deeply nested", body: strings.Repeat("[", 5) + strings.Repeat("]", 5), expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(BodyLimitMiddleware(64, 4))
+			router.POST("/", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestValidateTimeRange(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		start   time.Time
+		end     time.Time
+		maxSpan time.Duration
+		wantErr bool
+	}{
+		{name: "valid range", start: now.Add(-time.Hour), end: now, maxSpan: 24 * time.Hour},
+		{name: "start equals end", start: now, end: now, maxSpan: time.Hour, wantErr: true},
+		{name: "start after end", start: now, end: now.Add(-time.Minute), maxSpan: time.Hour, wantErr: true},
+		{name: "span too large", start: now.Add(-48 * time.Hour), end: now, maxSpan: 24 * time.Hour, wantErr: true},
+		{name: "no span limit", start: now.Add(-48 * time.Hour), end: now, maxSpan: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTimeRange(tt.start, tt.end, tt.maxSpan)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateTimeRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			apiErr, ok := err.(*APIError)
+			if !ok || apiErr.Code != ErrorCodeValidation {
+				t.Errorf("expected VALIDATION_ERROR APIError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestServer_SetMaxQueryRange(t *testing.T) {
+	s := &Server{perfConfig: DefaultPerformanceConfig(), engine: gin.New()}
+	s.SetMaxQueryRange(time.Hour)
+
+	// A data source API registered later picks up the server's limit
+	dataSourceAPI := NewDataSourceAPI(nil)
+	s.RegisterDataSourceAPI(dataSourceAPI)
+	if s.perfConfig.MaxQueryRange != time.Hour || dataSourceAPI.maxQueryRange != time.Hour {
+		t.Errorf("expected both limits to be 1h, got %s and %s", s.perfConfig.MaxQueryRange, dataSourceAPI.maxQueryRange)
+	}
+
+	s.SetMaxQueryRange(0)
+	if s.perfConfig.MaxQueryRange != 0 || dataSourceAPI.maxQueryRange != 0 {
+		t.Errorf("expected both limits to be disabled, got %s and %s", s.perfConfig.MaxQueryRange, dataSourceAPI.maxQueryRange)
+	}
+}
+
+func TestCheckFiniteValues(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	if !checkFiniteValues(c, "values", []float64{1, 2.5, -3}) {
+		t.Fatal("expected finite values to pass")
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/detectors/detector_1/train", nil)
+	if checkFiniteValues(c, "values", []float64{1, math.NaN(), 2, math.Inf(-1)}) {
+		t.Fatal("expected non-finite values to be rejected")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+
+	var resp struct {
+		Error struct {
+			Code    ErrorCode `json:"code"`
+			Context struct {
+				InvalidIndices []int `json:"invalid_indices"`
+			} `json:"context"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Error.Code != ErrorCodeValidation {
+		t.Errorf("expected VALIDATION_ERROR, got %s", resp.Error.Code)
+	}
+	if indices := resp.Error.Context.InvalidIndices; len(indices) != 2 || indices[0] != 1 || indices[1] != 3 {
+		t.Errorf("expected invalid indices [1 3], got %v", indices)
+	}
+}
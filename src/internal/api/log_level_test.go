@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	if level, err := ParseLogLevel(" debug "); err != nil || level != LogLevelDebug {
+		t.Errorf("expected DEBUG, got %q, %v", level, err)
+	}
+	if _, err := ParseLogLevel("verbose"); err == nil {
+		t.Error("expected an unknown level to be rejected")
+	}
+}
+
+func TestToggleDebugLogging(t *testing.T) {
+	previous := GlobalLogger
+	defer func() { GlobalLogger = previous }()
+	InitLogger("test", LogLevelWarn)
+
+	component := NewLogger("component")
+	if level := ToggleDebugLogging(); level != LogLevelDebug {
+		t.Fatalf("expected DEBUG after toggling, got %s", level)
+	}
+	if component.Level() != LogLevelDebug {
+		t.Errorf("expected the component logger to follow the global level, got %s", component.Level())
+	}
+	if level := ToggleDebugLogging(); level != LogLevelWarn {
+		t.Errorf("expected toggling back to restore WARN, got %s", level)
+	}
+}
+
+func TestSetLogLevelHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	previous := GlobalLogger
+	defer func() { GlobalLogger = previous }()
+	InitLogger("test", LogLevelInfo)
+
+	router := gin.New()
+	router.GET("/api/log-level", LogLevelHandler)
+	router.POST("/api/log-level", SetLogLevelHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/log-level", strings.NewReader(`{"level":"verbose"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown level, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/log-level", strings.NewReader(`{"level":"error"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp["level"] != "ERROR" || resp["previous_level"] != "INFO" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/log-level", nil))
+	if !strings.Contains(w.Body.String(), `"level":"ERROR"`) {
+		t.Errorf("expected the current level to be ERROR, got %s", w.Body.String())
+	}
+}
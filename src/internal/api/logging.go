@@ -2,7 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"runtime"
 	"strings"
@@ -44,9 +46,12 @@ type LogEntry struct {
 // Logger provides structured logging functionality
 type Logger struct {
 	component string
-	level     LogLevel
-	output    *log.Logger
-	mu        sync.RWMutex
+	// level is empty for component loggers, which follow the global logger's level
+	level LogLevel
+	// levelBeforeDebug is the level restored when debug logging is toggled off
+	levelBeforeDebug LogLevel
+	output           *log.Logger
+	mu               sync.RWMutex
 }
 
 // GlobalLogger is the global logger instance
@@ -61,7 +66,8 @@ func InitLogger(component string, level LogLevel) {
 	}
 }
 
-// NewLogger creates a new logger for a specific component
+// NewLogger creates a new logger for a specific component. Until SetLevel is
+// called on it, it follows the level of the global logger.
 func NewLogger(component string) *Logger {
 	if GlobalLogger == nil {
 		InitLogger("aiops", LogLevelInfo)
@@ -69,7 +75,6 @@ func NewLogger(component string) *Logger {
 
 	return &Logger{
 		component: component,
-		level:     GlobalLogger.level,
 		output:    GlobalLogger.output,
 	}
 }
@@ -81,6 +86,65 @@ func (l *Logger) SetLevel(level LogLevel) {
 	l.level = level
 }
 
+// Level returns the logging level in effect
+func (l *Logger) Level() LogLevel {
+	l.mu.RLock()
+	level := l.level
+	l.mu.RUnlock()
+
+	if level != "" {
+		return level
+	}
+	if global := GlobalLogger; global != nil && global != l {
+		return global.Level()
+	}
+	return LogLevelInfo
+}
+
+// ToggleDebug switches the logger to DEBUG, or back to the level it had
+// before, and returns the new level
+func (l *Logger) ToggleDebug() LogLevel {
+	current := l.Level()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if current == LogLevelDebug {
+		l.level = l.levelBeforeDebug
+		if l.level == "" || l.level == LogLevelDebug {
+			l.level = LogLevelInfo
+		}
+		return l.level
+	}
+	l.levelBeforeDebug = current
+	l.level = LogLevelDebug
+	return l.level
+}
+
+// ParseLogLevel parses a log level name such as "debug" or "WARN"
+func ParseLogLevel(name string) (LogLevel, error) {
+	level := LogLevel(strings.ToUpper(strings.TrimSpace(name)))
+	switch level {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError, LogLevelFatal:
+		return level, nil
+	}
+	return "", fmt.Errorf("unknown log level %q", name)
+}
+
+// globalLogger returns the global logger, initializing it if needed
+func globalLogger() *Logger {
+	if GlobalLogger == nil {
+		InitLogger("aiops", LogLevelInfo)
+	}
+	return GlobalLogger
+}
+
+// ToggleDebugLogging switches the global logger between DEBUG and its previous
+// level (e.g. on SIGUSR1) and returns the new level
+func ToggleDebugLogging() LogLevel {
+	return globalLogger().ToggleDebug()
+}
+
 // shouldLog determines if a message should be logged based on level
 func (l *Logger) shouldLog(level LogLevel) bool {
 	levels := map[LogLevel]int{
@@ -91,7 +155,7 @@ func (l *Logger) shouldLog(level LogLevel) bool {
 		LogLevelFatal: 4,
 	}
 
-	currentLevel := levels[l.level]
+	currentLevel := levels[l.Level()]
 	messageLevel := levels[level]
 
 	return messageLevel >= currentLevel
@@ -283,8 +347,13 @@ type PerformanceMetrics struct {
 	MaxResponse     time.Duration `json:"max_response_time"`
 	MinResponse     time.Duration `json:"min_response_time"`
 	TotalDuration   time.Duration `json:"total_duration"`
-	LastReset       time.Time     `json:"last_reset"`
-	mu              sync.RWMutex
+	// Response time percentiles, estimated from a streaming histogram
+	P50Response time.Duration `json:"p50_response_time"`
+	P90Response time.Duration `json:"p90_response_time"`
+	P99Response time.Duration `json:"p99_response_time"`
+	LastReset   time.Time     `json:"last_reset"`
+	latencies   *latencyHistogram
+	mu          sync.RWMutex
 }
 
 // GlobalMetrics is the global performance metrics instance
@@ -313,6 +382,11 @@ func (pm *PerformanceMetrics) RecordRequest(duration time.Duration, isError bool
 		pm.MinResponse = duration
 	}
 
+	if pm.latencies == nil {
+		pm.latencies = &latencyHistogram{}
+	}
+	pm.latencies.record(duration)
+
 	// Calculate average
 	if pm.RequestCount > 0 {
 		pm.AverageResponse = pm.TotalDuration / time.Duration(pm.RequestCount)
@@ -324,6 +398,13 @@ func (pm *PerformanceMetrics) GetMetrics() PerformanceMetrics {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
 
+	var p50, p90, p99 time.Duration
+	if pm.latencies != nil {
+		p50 = pm.latencies.quantile(0.50)
+		p90 = pm.latencies.quantile(0.90)
+		p99 = pm.latencies.quantile(0.99)
+	}
+
 	return PerformanceMetrics{
 		RequestCount:    pm.RequestCount,
 		ErrorCount:      pm.ErrorCount,
@@ -331,6 +412,9 @@ func (pm *PerformanceMetrics) GetMetrics() PerformanceMetrics {
 		MaxResponse:     pm.MaxResponse,
 		MinResponse:     pm.MinResponse,
 		TotalDuration:   pm.TotalDuration,
+		P50Response:     p50,
+		P90Response:     p90,
+		P99Response:     p99,
 		LastReset:       pm.LastReset,
 	}
 }
@@ -346,9 +430,44 @@ func (pm *PerformanceMetrics) Reset() {
 	pm.MaxResponse = 0
 	pm.MinResponse = time.Hour
 	pm.TotalDuration = 0
+	if pm.latencies != nil {
+		pm.latencies.reset()
+	}
 	pm.LastReset = time.Now()
 }
 
+// LogLevelRequest is the body of POST /api/log-level
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// LogLevelHandler returns the API log level
+func LogLevelHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": globalLogger().Level()})
+}
+
+// SetLogLevelHandler changes the API log level without a restart
+func SetLogLevelHandler(c *gin.Context) {
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	level, err := ParseLogLevel(req.Level)
+	if err != nil {
+		HandleValidationError(c, "level", err.Error())
+		return
+	}
+
+	logger := globalLogger()
+	previous := logger.Level()
+	logger.SetLevel(level)
+	logger.Info("Log level changed", map[string]LogLevel{"previous_level": previous, "level": level})
+
+	c.JSON(http.StatusOK, gin.H{"level": level, "previous_level": previous})
+}
+
 // MetricsMiddleware tracks performance metrics
 func MetricsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -373,6 +492,7 @@ type SystemInfo struct {
 	MemoryUsage   MemStats  `json:"memory_usage"`
 	StartTime     time.Time `json:"start_time"`
 	Uptime        string    `json:"uptime"`
+	LogLevel      LogLevel  `json:"log_level"`
 }
 
 // MemStats provides memory statistics
@@ -404,6 +524,7 @@ func GetSystemInfo() SystemInfo {
 		},
 		StartTime: logStartTime,
 		Uptime:    time.Since(logStartTime).String(),
+		LogLevel:  globalLogger().Level(),
 	}
 }
 
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NamespaceHeader selects the tenant namespace of a request. Detectors,
+// anomalies and actions are only visible to requests in their namespace.
+const NamespaceHeader = "X-Namespace"
+
+// DefaultNamespace is used by requests without a namespace header
+const DefaultNamespace = "default"
+
+// namespacePattern follows Kubernetes namespace naming (RFC 1123 labels)
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// detectorKey identifies a detector within its namespace
+type detectorKey struct {
+	Namespace string
+	ID        string
+}
+
+// validateNamespace checks that a namespace is a valid RFC 1123 label
+func validateNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid namespace %q: must be a lowercase RFC 1123 label", namespace)
+	}
+	return nil
+}
+
+// namespaceOf returns the namespace of a request, DefaultNamespace without a header
+func namespaceOf(c *gin.Context) string {
+	if namespace := strings.TrimSpace(c.GetHeader(NamespaceHeader)); namespace != "" {
+		return namespace
+	}
+	return DefaultNamespace
+}
+
+// NamespaceQueryParam selects the namespace of WebSocket and event stream
+// subscribers, since browsers cannot set headers on these requests
+const NamespaceQueryParam = "namespace"
+
+// subscriberNamespace returns the namespace of an event subscriber, taken from
+// the namespace query parameter or else the namespace header. An invalid query
+// parameter is answered with a validation error and ok is false.
+func subscriberNamespace(c *gin.Context) (namespace string, ok bool) {
+	namespace = strings.TrimSpace(c.Query(NamespaceQueryParam))
+	if namespace == "" {
+		return namespaceOf(c), true
+	}
+	if err := validateNamespace(namespace); err != nil {
+		HandleValidationError(c, NamespaceQueryParam, err.Error())
+		return "", false
+	}
+	return namespace, true
+}
+
+// NamespaceMiddleware rejects requests with an invalid namespace header
+func NamespaceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := validateNamespace(namespaceOf(c)); err != nil {
+			HandleValidationError(c, NamespaceHeader, err.Error())
+			return
+		}
+		c.Next()
+	}
+}
+
+// newDetectorManager creates an empty detector manager
+func newDetectorManager() *DetectorManager {
+	return &DetectorManager{
+		detectors:  make(map[detectorKey]*DetectorInstance),
+		namespaces: make(map[string]string),
+		nextID:     1,
+	}
+}
+
+// get returns a detector of a namespace (caller must hold dm.mu)
+func (dm *DetectorManager) get(namespace, id string) (*DetectorInstance, bool) {
+	instance, exists := dm.detectors[detectorKey{Namespace: namespace, ID: id}]
+	return instance, exists
+}
+
+// lookup returns a detector by ID in any namespace, for callers outside a
+// request such as gRPC ingestion and the metrics pipeline (caller must hold dm.mu)
+func (dm *DetectorManager) lookup(id string) (*DetectorInstance, bool) {
+	namespace, exists := dm.namespaces[id]
+	if !exists {
+		return nil, false
+	}
+	return dm.get(namespace, id)
+}
+
+// add stores a detector under its namespace (caller must hold dm.mu)
+func (dm *DetectorManager) add(instance *DetectorInstance) {
+	if instance.Namespace == "" {
+		instance.Namespace = DefaultNamespace
+	}
+	dm.detectors[detectorKey{Namespace: instance.Namespace, ID: instance.ID}] = instance
+	dm.namespaces[instance.ID] = instance.Namespace
+}
+
+// remove deletes a detector (caller must hold dm.mu)
+func (dm *DetectorManager) remove(instance *DetectorInstance) {
+	delete(dm.detectors, detectorKey{Namespace: instance.Namespace, ID: instance.ID})
+	delete(dm.namespaces, instance.ID)
+}
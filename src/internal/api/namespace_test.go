@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+	"github.com/yourusername/aiops-infra/src/internal/orchestrator"
+)
+
+// detectorManagerWith creates a detector manager holding the given detectors
+func detectorManagerWith(instances ...*DetectorInstance) *DetectorManager {
+	manager := newDetectorManager()
+	for _, instance := range instances {
+		manager.add(instance)
+	}
+	return manager
+}
+
+func TestNamespaceScoping_Detectors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := newIngestTestServer("stopped", &thresholdDetector{limit: 10})
+	s.detectorManager.add(&DetectorInstance{ID: "detector_2", Name: "tenant", Namespace: "team-a", Status: "stopped"})
+
+	router := gin.New()
+	router.Use(NamespaceMiddleware())
+	router.GET("/api/detectors", s.handleListDetectors)
+	router.GET("/api/detectors/:id", s.handleGetDetector)
+	router.DELETE("/api/detectors/:id", s.handleDeleteDetector)
+
+	request := func(method, path, namespace string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if namespace != "" {
+			req.Header.Set(NamespaceHeader, namespace)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	list := func(namespace string) []string {
+		w := request(http.MethodGet, "/api/detectors", namespace)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Detectors []DetectorInstance `json:"detectors"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		ids := make([]string, 0, len(resp.Detectors))
+		for _, d := range resp.Detectors {
+			ids = append(ids, d.ID)
+		}
+		return ids
+	}
+
+	if ids := list(""); len(ids) != 1 || ids[0] != "detector_1" {
+		t.Errorf("expected only detector_1 in the default namespace, got %v", ids)
+	}
+	if ids := list("team-a"); len(ids) != 1 || ids[0] != "detector_2" {
+		t.Errorf("expected only detector_2 in team-a, got %v", ids)
+	}
+
+	if w := request(http.MethodGet, "/api/detectors/detector_2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another namespace's detector, got %d", w.Code)
+	}
+	if w := request(http.MethodDelete, "/api/detectors/detector_2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting another namespace's detector, got %d", w.Code)
+	}
+	if w := request(http.MethodGet, "/api/detectors/detector_2", "team-a"); w.Code != http.StatusOK {
+		t.Errorf("expected 200 in the detector's namespace, got %d", w.Code)
+	}
+	if w := request(http.MethodDelete, "/api/detectors/detector_2", "team-a"); w.Code != http.StatusOK {
+		t.Errorf("expected 200 deleting in the detector's namespace, got %d", w.Code)
+	}
+	if _, exists := s.detectorManager.lookup("detector_2"); exists {
+		t.Error("detector_2 should have been deleted")
+	}
+
+	if w := request(http.MethodGet, "/api/detectors", "Team_A"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid namespace, got %d", w.Code)
+	}
+}
+
+func TestNamespaceScoping_AnomaliesAndActions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	orch := orchestrator.NewOrchestrator()
+	orch.RegisterHandler(&targetFailHandler{})
+	s := &Server{orchestrator: orch, anomalyStore: NewAnomalyStore(10)}
+	record := s.anomalyStore.Add("team-a", "detector_1", "cpu", 1, &detector.Anomaly{})
+
+	router := gin.New()
+	router.Use(NamespaceMiddleware())
+	router.GET("/anomalies", s.handleListAnomalies)
+	router.GET("/anomalies/:id", s.handleGetAnomaly)
+	router.POST("/actions", s.handleExecuteAction)
+	router.GET("/actions/:id", s.handleGetAction)
+
+	request := func(method, path, namespace, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if namespace != "" {
+			req.Header.Set(NamespaceHeader, namespace)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request(http.MethodGet, "/anomalies/"+record.ID, "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another namespace's anomaly, got %d", w.Code)
+	}
+	if w := request(http.MethodGet, "/anomalies/"+record.ID, "team-a", ""); w.Code != http.StatusOK {
+		t.Errorf("expected 200 in the anomaly's namespace, got %d", w.Code)
+	}
+	var resp struct {
+		Anomalies []AnomalyRecord `json:"anomalies"`
+	}
+	w := request(http.MethodGet, "/anomalies", "", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Anomalies) != 0 {
+		t.Errorf("expected no anomalies in the default namespace, got %s", w.Body.String())
+	}
+
+	if w := request(http.MethodPost, "/actions", "team-a", `{"type": "restart", "target": "api"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if action, _ := orch.GetAction("team-a", "api"); action.Namespace != "team-a" {
+		t.Errorf("expected the action to be recorded in team-a, got %q", action.Namespace)
+	}
+	if w := request(http.MethodGet, "/actions/api", "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another namespace's action, got %d", w.Code)
+	}
+	if w := request(http.MethodGet, "/actions/api", "team-a", ""); w.Code != http.StatusOK {
+		t.Errorf("expected 200 in the action's namespace, got %d", w.Code)
+	}
+
+	// An action on the same target in another namespace keeps its own record
+	if w := request(http.MethodPost, "/actions", "", `{"type": "restart", "target": "api"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if action, ok := orch.GetAction("team-a", "api"); !ok || action.Namespace != "team-a" {
+		t.Errorf("expected team-a's action to be kept, got %+v", action)
+	}
+	if action, ok := orch.GetAction(DefaultNamespace, "api"); !ok || action.Namespace != DefaultNamespace {
+		t.Errorf("expected the default namespace's action to be recorded, got %+v", action)
+	}
+}
+
+func TestNamespaceScoping_DetectValue(t *testing.T) {
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	s.detectorManager.add(&DetectorInstance{ID: "detector_2", Namespace: "team-a", Status: "running", Detector: &thresholdDetector{limit: 10}})
+	ctx := context.Background()
+
+	// Without a namespace only the default namespace is visible
+	if _, err := s.DetectValue(ctx, "", "detector_1", 50, time.Time{}); err != nil {
+		t.Errorf("expected a default namespace detector to be found, got %v", err)
+	}
+	if _, err := s.DetectValue(ctx, "", "detector_2", 50, time.Time{}); !errors.Is(err, ErrDetectorNotFound) {
+		t.Errorf("expected another namespace's detector to be hidden, got %v", err)
+	}
+
+	anomaly, err := s.DetectValue(ctx, "team-a", "detector_2", 50, time.Time{})
+	if err != nil || anomaly == nil {
+		t.Errorf("expected an anomaly in the detector's namespace, got %v, %v", anomaly, err)
+	}
+	if _, err := s.DetectValue(ctx, "team-a", "detector_1", 50, time.Time{}); !errors.Is(err, ErrDetectorNotFound) {
+		t.Errorf("expected the default namespace to be hidden from team-a, got %v", err)
+	}
+	if _, err := s.DetectValue(ctx, "Team_A", "detector_2", 50, time.Time{}); err == nil || errors.Is(err, ErrDetectorNotFound) {
+		t.Errorf("expected an invalid namespace to be rejected, got %v", err)
+	}
+}
@@ -21,10 +21,59 @@ type ActionRequest struct {
 		RetryInterval string  `json:"retry_interval"`
 		MaxInterval   string  `json:"max_interval,omitempty"`
 		Multiplier    float64 `json:"multiplier,omitempty"`
+		Jitter        float64 `json:"jitter,omitempty"`
+		MaxDuration   string  `json:"max_duration,omitempty"`
 	} `json:"retry_policy,omitempty"`
 	DependsOn []string `json:"depends_on,omitempty"`
 }
 
+// toAction converts the request into an action. Duration parse errors are
+// collected instead of stopping at the first one, for plan validation.
+func (req ActionRequest) toAction() (orchestrator.Action, []string) {
+	var errs []string
+	action := orchestrator.Action{
+		Type:       orchestrator.ActionType(req.Type),
+		Target:     req.Target,
+		Parameters: req.Parameters,
+		DependsOn:  req.DependsOn,
+		Status:     orchestrator.StatusPending,
+	}
+
+	if req.Timeout != "" {
+		timeout, err := time.ParseDuration(req.Timeout)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid timeout format: %v", err))
+		}
+		action.Timeout = timeout
+	}
+
+	if req.RetryPolicy != nil {
+		policy := &orchestrator.RetryPolicy{
+			MaxRetries: req.RetryPolicy.MaxRetries,
+			Multiplier: req.RetryPolicy.Multiplier,
+			Jitter:     req.RetryPolicy.Jitter,
+		}
+
+		var err error
+		if policy.RetryInterval, err = time.ParseDuration(req.RetryPolicy.RetryInterval); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid retry interval format: %v", err))
+		}
+		if req.RetryPolicy.MaxInterval != "" {
+			if policy.MaxInterval, err = time.ParseDuration(req.RetryPolicy.MaxInterval); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid max interval format: %v", err))
+			}
+		}
+		if req.RetryPolicy.MaxDuration != "" {
+			if policy.MaxDuration, err = time.ParseDuration(req.RetryPolicy.MaxDuration); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid max duration format: %v", err))
+			}
+		}
+		action.RetryPolicy = policy
+	}
+
+	return action, errs
+}
+
 // ActionResponse represents the response to an action execution request
 type ActionResponse struct {
 	Status  string                     `json:"status"`
@@ -101,7 +150,7 @@ func (h *OrchestratorHandler) listActions(w http.ResponseWriter, r *http.Request
 
 // getAction returns information about a specific action
 func (h *OrchestratorHandler) getAction(w http.ResponseWriter, r *http.Request, target string) {
-	action, exists := h.orchestrator.GetAction(target)
+	action, exists := h.orchestrator.GetAction("", target)
 	if !exists {
 		http.Error(w, fmt.Sprintf("Action with target '%s' not found", target), http.StatusNotFound)
 		return
@@ -178,11 +227,22 @@ func (h *OrchestratorHandler) executeAction(w http.ResponseWriter, r *http.Reque
 			}
 		}
 
+		var maxDuration time.Duration
+		if req.RetryPolicy.MaxDuration != "" {
+			maxDuration, err = time.ParseDuration(req.RetryPolicy.MaxDuration)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid max duration format: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+
 		retryPolicy = &orchestrator.RetryPolicy{
 			MaxRetries:    req.RetryPolicy.MaxRetries,
 			RetryInterval: retryInterval,
 			MaxInterval:   maxInterval,
 			Multiplier:    req.RetryPolicy.Multiplier,
+			Jitter:        req.RetryPolicy.Jitter,
+			MaxDuration:   maxDuration,
 		}
 	}
 
@@ -208,7 +268,7 @@ func (h *OrchestratorHandler) executeAction(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Get updated action
-	updatedAction, _ := h.orchestrator.GetAction(req.Target)
+	updatedAction, _ := h.orchestrator.GetAction(action.Namespace, action.Target)
 
 	// Return response
 	response := ActionResponse{
@@ -309,21 +369,13 @@ func (h *OrchestratorHandler) executeActionPlan(w http.ResponseWriter, r *http.R
 	}
 
 	// Execute action plan
-	err := h.orchestrator.ExecuteActionPlan(r.Context(), actions)
+	updatedActions, err := h.orchestrator.ExecuteActionPlan(r.Context(), actions)
 
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to execute action plan: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Get updated actions
-	updatedActions := make([]orchestrator.Action, 0, len(actions))
-	for _, action := range actions {
-		if updatedAction, exists := h.orchestrator.GetAction(action.Target); exists {
-			updatedActions = append(updatedActions, updatedAction)
-		}
-	}
-
 	// Return response
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -0,0 +1,616 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/orchestrator"
+)
+
+func TestHandleValidateActionPlan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	orch := orchestrator.NewOrchestrator()
+	notifier := orchestrator.NewNotificationHandler()
+	notifier.SetDefaultWebhookURL("http://hooks.example.com")
+	orch.RegisterHandler(notifier)
+
+	s := &Server{orchestrator: orch}
+	router := gin.New()
+	router.POST("/validate", s.handleValidateActionPlan)
+
+	plan := `[
+		{"type": "notify", "target": "page", "timeout": "30s"},
+		{"type": "notify", "target": "slack", "parameters": {"type": "slack"}, "depends_on": ["page"]},
+		{"type": "restart", "target": "api", "timeout": "soon"},
+		{"type": "notify", "target": "a", "depends_on": ["b"]},
+		{"type": "notify", "target": "b", "depends_on": ["a", "missing"]}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(plan))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Valid   bool                            `json:"valid"`
+		Actions []orchestrator.ActionValidation `json:"actions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Valid || len(resp.Actions) != 5 {
+		t.Fatalf("expected an invalid plan with 5 results, got %+v", resp)
+	}
+
+	expected := []struct {
+		valid    bool
+		contains []string
+	}{
+		{true, nil},
+		{false, []string{"slack webhook URL is required"}},
+		{false, []string{"invalid timeout format", "no handler registered"}},
+		{false, []string{"dependency cycle: a -> b -> a"}},
+		{false, []string{"dependency cycle: b -> a -> b", "dependency not found in plan: missing"}},
+	}
+	for i, want := range expected {
+		result := resp.Actions[i]
+		if result.Valid != want.valid {
+			t.Errorf("%s: expected valid = %t, got errors %v", result.Target, want.valid, result.Errors)
+		}
+		joined := strings.Join(result.Errors, "; ")
+		for _, msg := range want.contains {
+			if !strings.Contains(joined, msg) {
+				t.Errorf("%s: expected error %q, got %q", result.Target, msg, joined)
+			}
+		}
+	}
+}
+
+// flakyHandler fails the first failures executions of an action
+type flakyHandler struct {
+	failures int
+	calls    int
+}
+
+func (h *flakyHandler) Execute(ctx context.Context, action orchestrator.Action) (*orchestrator.ActionResult, error) {
+	h.calls++
+	if h.calls <= h.failures {
+		return nil, errors.New("target unavailable")
+	}
+	return &orchestrator.ActionResult{Success: true, CompletedAt: time.Now()}, nil
+}
+
+func (h *flakyHandler) CanHandle(actionType orchestrator.ActionType) bool {
+	return actionType == orchestrator.ActionRestart
+}
+
+func TestHandleExecuteAction_Retries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &flakyHandler{failures: 2}
+	orch := orchestrator.NewOrchestrator()
+	orch.RegisterHandler(handler)
+	orch.SetDefaultRetryPolicy(&orchestrator.RetryPolicy{MaxRetries: 3, RetryInterval: time.Millisecond})
+
+	s := &Server{orchestrator: orch}
+	router := gin.New()
+	router.POST("/actions", s.handleExecuteAction)
+
+	execute := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/actions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The default policy retries until the third attempt succeeds
+	w := execute(`{"type": "restart", "target": "api"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result orchestrator.ActionResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if !result.Success || result.Attempts != 3 {
+		t.Errorf("expected success after 3 attempts, got %+v", result)
+	}
+
+	// An explicit policy overrides the default and gives up after one retry
+	handler.calls, handler.failures = 0, 5
+	w = execute(`{"type": "restart", "target": "api", "retry_policy": {"max_retries": 1, "retry_interval": 1000000}}`)
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "after 2 attempts") {
+		t.Errorf("expected a failure after 2 attempts, got %d: %s", w.Code, w.Body.String())
+	}
+	if action, ok := orch.GetAction(DefaultNamespace, "api"); !ok || action.Result == nil || action.Result.Attempts != 2 {
+		t.Errorf("expected the failed action to record 2 attempts, got %+v", action.Result)
+	}
+}
+
+// targetFailHandler fails the actions of the given targets
+type targetFailHandler struct {
+	fail map[string]bool
+}
+
+func (h *targetFailHandler) Execute(ctx context.Context, action orchestrator.Action) (*orchestrator.ActionResult, error) {
+	if h.fail[action.Target] {
+		return nil, errors.New("target unavailable")
+	}
+	return &orchestrator.ActionResult{Success: true, Message: "restarted " + action.Target, CompletedAt: time.Now()}, nil
+}
+
+func (h *targetFailHandler) CanHandle(actionType orchestrator.ActionType) bool {
+	return actionType == orchestrator.ActionRestart
+}
+
+func TestHandleExecuteActionPlan_Results(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	orch := orchestrator.NewOrchestrator()
+	orch.RegisterHandler(&targetFailHandler{fail: map[string]bool{"api": true}})
+
+	s := &Server{orchestrator: orch}
+	router := gin.New()
+	router.POST("/actionplan", s.handleExecuteActionPlan)
+
+	plan := `[
+		{"type": "restart", "target": "db"},
+		{"type": "restart", "target": "api", "depends_on": ["db"]},
+		{"type": "restart", "target": "web", "depends_on": ["api"]}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "/actionplan", strings.NewReader(plan))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Status  string                 `json:"status"`
+		Error   string                 `json:"error"`
+		Actions []ActionPlanStepResult `json:"actions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Status != "failed" || !strings.Contains(resp.Error, "api") || len(resp.Actions) != 3 {
+		t.Fatalf("expected a failed plan with 3 steps, got %+v", resp)
+	}
+
+	expected := []struct {
+		target  string
+		status  orchestrator.ActionStatus
+		success bool
+		message string
+	}{
+		{"db", orchestrator.StatusSucceeded, true, "restarted db"},
+		{"api", orchestrator.StatusFailed, false, "target unavailable"},
+		{"web", orchestrator.StatusPending, false, "not executed"},
+	}
+	for i, e := range expected {
+		step := resp.Actions[i]
+		if step.Target != e.target || step.Status != e.status || step.Success != e.success ||
+			!strings.Contains(step.Message, e.message) {
+			t.Errorf("step %d: expected %+v, got %+v", i, e, step)
+		}
+		if (step.Status != orchestrator.StatusPending) != (step.Duration != "") {
+			t.Errorf("step %d: expected a duration only for executed steps, got %q", i, step.Duration)
+		}
+	}
+}
+
+func TestHandleExecuteActionPlan_SameTargetSteps(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	orch := orchestrator.NewOrchestrator()
+	orch.RegisterHandler(&targetFailHandler{fail: map[string]bool{"api": true}})
+
+	s := &Server{orchestrator: orch}
+	router := gin.New()
+	router.POST("/actionplan", s.handleExecuteActionPlan)
+
+	// The second restart of web waits for api and never runs, although web
+	// was restarted earlier in the same plan
+	plan := `[
+		{"type": "restart", "target": "web"},
+		{"type": "restart", "target": "api"},
+		{"type": "restart", "target": "web", "depends_on": ["api"]}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "/actionplan", strings.NewReader(plan))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp struct {
+		Actions []ActionPlanStepResult `json:"actions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Actions) != 3 {
+		t.Fatalf("expected 3 steps, got %d: %s", w.Code, w.Body.String())
+	}
+
+	expected := []orchestrator.ActionStatus{orchestrator.StatusSucceeded, orchestrator.StatusFailed, orchestrator.StatusPending}
+	for i, status := range expected {
+		if resp.Actions[i].Status != status {
+			t.Errorf("step %d: expected %s, got %+v", i, status, resp.Actions[i])
+		}
+	}
+}
+
+func TestHandleExecuteActionPlan_Budget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	orch := orchestrator.NewOrchestrator()
+	orch.RegisterHandler(&targetFailHandler{fail: map[string]bool{"api": true}})
+	orch.SetDefaultRetryPolicy(&orchestrator.RetryPolicy{MaxRetries: 10, RetryInterval: time.Millisecond})
+
+	s := &Server{orchestrator: orch}
+	router := gin.New()
+	router.POST("/actionplan", s.handleExecuteActionPlan)
+
+	execute := func(query string) *httptest.ResponseRecorder {
+		plan := `[
+			{"type": "restart", "target": "api"},
+			{"type": "restart", "target": "web", "depends_on": ["api"]}
+		]`
+		req := httptest.NewRequest(http.MethodPost, "/actionplan"+query, strings.NewReader(plan))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, query := range []string{"?deadline=soon", "?deadline=-1s", "?retry_budget=0"} {
+		if w := execute(query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+
+	// The plan budget stops the retries long before the action's own policy
+	w := execute("?retry_budget=2")
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Error          string                 `json:"error"`
+		BudgetExceeded bool                   `json:"budget_exceeded"`
+		Actions        []ActionPlanStepResult `json:"actions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if !resp.BudgetExceeded || !strings.Contains(resp.Error, "2 retries used") {
+		t.Errorf("expected the retry budget to be reported, got %+v", resp)
+	}
+	if len(resp.Actions) != 2 || resp.Actions[0].Attempts != 3 || resp.Actions[1].Status != orchestrator.StatusPending {
+		t.Errorf("expected 3 attempts of api and web left pending, got %+v", resp.Actions)
+	}
+
+	// A deadline shorter than the retry interval allows no retry
+	orch.SetDefaultRetryPolicy(&orchestrator.RetryPolicy{MaxRetries: 10, RetryInterval: time.Hour, MaxDuration: 2 * time.Hour})
+	start := time.Now()
+	w = execute("?deadline=50ms")
+	if w.Code != http.StatusGatewayTimeout || !strings.Contains(w.Body.String(), "deadline 50ms reached") {
+		t.Errorf("expected the deadline to abort the plan, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the plan to abort right away, took %s", elapsed)
+	}
+}
+
+func TestRemediationKillSwitch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &flakyHandler{}
+	orch := orchestrator.NewOrchestrator()
+	orch.RegisterHandler(handler)
+
+	s := &Server{orchestrator: orch}
+	router := gin.New()
+	router.POST("/action", s.handleExecuteAction)
+	router.POST("/actionplan", s.handleExecuteActionPlan)
+	router.GET("/remediation", s.handleGetRemediation)
+	router.PUT("/remediation", s.handleSetRemediation)
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request(http.MethodPut, "/remediation", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without enabled, got %d", w.Code)
+	}
+	w := request(http.MethodPut, "/remediation", `{"enabled": false}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"previous_enabled":true`) {
+		t.Fatalf("expected remediation to be disabled, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request(http.MethodGet, "/remediation", ""); !strings.Contains(w.Body.String(), `"enabled":false`) {
+		t.Errorf("expected remediation to report disabled, got %s", w.Body.String())
+	}
+
+	if w := request(http.MethodPost, "/action", `{"type": "restart", "target": "api"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 while disabled, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request(http.MethodPost, "/actionplan", `[{"type": "restart", "target": "api"}]`); w.Code != http.StatusConflict {
+		t.Errorf("expected the plan to be rejected while disabled, got %d: %s", w.Code, w.Body.String())
+	}
+	if handler.calls != 0 {
+		t.Errorf("expected no action to run while disabled, got %d calls", handler.calls)
+	}
+
+	request(http.MethodPut, "/remediation", `{"enabled": true}`)
+	if w := request(http.MethodPost, "/action", `{"type": "restart", "target": "api"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected the action to run once enabled, got %d: %s", w.Code, w.Body.String())
+	}
+	if action, _ := orch.GetAction(DefaultNamespace, "api"); action.Timeout != orchestrator.DefaultActionTimeout {
+		t.Errorf("expected the default timeout %s, got %s", orchestrator.DefaultActionTimeout, action.Timeout)
+	}
+}
+
+func TestNotificationRoutes(t *testing.T) {
+	received := make(map[string]int)
+	hook := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received[name]++
+		}))
+	}
+	pager, web, fallback := hook("pager"), hook("web"), hook("default")
+	defer pager.Close()
+	defer web.Close()
+	defer fallback.Close()
+
+	notifier := orchestrator.NewNotificationHandler()
+	notifier.SetDefaultWebhookURL(fallback.URL)
+	err := notifier.SetRoutes([]orchestrator.NotificationRoute{
+		{Match: map[string]string{"severity": "critical"}, Type: "webhook", WebhookURL: pager.URL},
+		{Match: map[string]string{"team": "web"}, Type: "webhook", WebhookURL: web.URL},
+	})
+	if err != nil {
+		t.Fatalf("failed to set routes: %v", err)
+	}
+	orch := orchestrator.NewOrchestrator()
+	orch.RegisterHandler(notifier)
+
+	// An alert for the web team: its labels select the route
+	s := &Server{orchestrator: orch}
+	action := s.actionForAlert(AlertmanagerAlert{Status: "firing"},
+		map[string]string{"alertname": "HighLatency", "severity": "warning", "team": "web"}, nil)
+	for _, params := range []map[string]string{
+		{"severity": "critical", "label_team": "web"}, // first match wins
+		action.Parameters,
+		{"severity": "info"},
+		{"severity": "critical", "webhook_url": fallback.URL}, // the action's own URL wins
+	} {
+		if _, err := orch.ExecuteAction(context.Background(), orchestrator.Action{
+			Type: orchestrator.ActionNotify, Target: "api", Parameters: params,
+		}); err != nil {
+			t.Fatalf("notification failed: %v", err)
+		}
+	}
+
+	if received["pager"] != 1 || received["web"] != 1 || received["default"] != 2 {
+		t.Errorf("unexpected deliveries: %v", received)
+	}
+
+	if err := notifier.SetRoutes([]orchestrator.NotificationRoute{{Type: "pagerduty"}}); err == nil {
+		t.Error("expected an error for an unsupported route type")
+	}
+}
+
+func TestNotificationDigest(t *testing.T) {
+	var mu sync.Mutex
+	var payloads []map[string]interface{}
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	notifier := orchestrator.NewNotificationHandler()
+	notifier.SetDefaultWebhookURL(hook.URL)
+	if err := notifier.SetDigest(orchestrator.DigestConfig{Window: time.Hour, TopMetrics: 1}); err != nil {
+		t.Fatalf("failed to set digest: %v", err)
+	}
+
+	notify := func(params map[string]string) *orchestrator.ActionResult {
+		result, err := notifier.Execute(context.Background(), orchestrator.Action{
+			Type: orchestrator.ActionNotify, Target: "api", Parameters: params,
+		})
+		if err != nil {
+			t.Fatalf("notification failed: %v", err)
+		}
+		return result
+	}
+
+	for _, params := range []map[string]string{
+		{"level": "warning", "source": "prometheus", "metric": "cpu_usage"},
+		{"level": "warning", "source": "prometheus", "metric": "cpu_usage"},
+		{"severity": "critical", "source": "alertmanager", "alertname": "HighLatency"},
+	} {
+		if result := notify(params); !strings.Contains(result.Message, "digest") {
+			t.Errorf("expected the notification to be collected, got %+v", result)
+		}
+	}
+	// Opting out sends right away
+	notify(map[string]string{"digest": "false", "message": "urgent"})
+
+	mu.Lock()
+	sent := len(payloads)
+	mu.Unlock()
+	if sent != 1 {
+		t.Fatalf("expected only the opted-out notification to be sent, got %d", sent)
+	}
+
+	if err := notifier.FlushDigests(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(payloads) != 2 {
+		t.Fatalf("expected a single digest, got %d payloads", len(payloads)-1)
+	}
+
+	digest := payloads[1]
+	message, _ := digest["message"].(string)
+	for _, expected := range []string{
+		"3 notifications",
+		"By severity: warning 2, critical 1",
+		"By source: prometheus 2, alertmanager 1",
+		"Top metrics: cpu_usage 2",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("expected %q in the digest, got:\n%s", expected, message)
+		}
+	}
+	if digest["subject"] != "AIOps digest: 3 notifications" {
+		t.Errorf("unexpected digest subject %v", digest["subject"])
+	}
+	if strings.Contains(message, "HighLatency") {
+		t.Error("expected only the top metric to be listed")
+	}
+}
+
+func TestHandleExecuteAction_NotificationTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The webhook answers only after the client gave up
+	release := make(chan struct{})
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hook.Close()
+	defer close(release)
+
+	orch := orchestrator.NewOrchestrator()
+	notifier := orchestrator.NewNotificationHandler()
+	notifier.SetDefaultWebhookURL(hook.URL)
+	orch.RegisterHandler(notifier)
+
+	s := &Server{orchestrator: orch}
+	router := gin.New()
+	router.POST("/action", s.handleExecuteAction)
+
+	body := `{"type": "notify", "target": "api", "parameters": {"type": "webhook"}, "timeout": 50000000}`
+	req := httptest.NewRequest(http.MethodPost, "/action", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	router.ServeHTTP(w, req)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the send to stop at the action timeout, took %s", elapsed)
+	}
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), orchestrator.ErrNotificationTimeout.Error()) {
+		t.Errorf("expected a notification timeout error, got %s", w.Body.String())
+	}
+}
+
+// outcomeHandler fails actions whose "fail" parameter is set
+type outcomeHandler struct{}
+
+func (outcomeHandler) Execute(ctx context.Context, action orchestrator.Action) (*orchestrator.ActionResult, error) {
+	if action.Parameters["fail"] != "" {
+		return nil, errors.New("target unavailable")
+	}
+	return &orchestrator.ActionResult{Success: true}, nil
+}
+
+func (outcomeHandler) CanHandle(actionType orchestrator.ActionType) bool {
+	return true
+}
+
+func TestHandleListActions_PaginationAndFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	orch := orchestrator.NewOrchestrator()
+	orch.SetDefaultRetryPolicy(nil)
+	orch.RegisterHandler(outcomeHandler{})
+
+	// a-e in creation order; b and d fail, c and e are notifications.
+	// Actions of other namespaces are never listed.
+	for _, action := range []orchestrator.Action{
+		{Type: orchestrator.ActionRestart, Target: "a", Namespace: DefaultNamespace},
+		{Type: orchestrator.ActionRestart, Target: "b", Namespace: DefaultNamespace, Parameters: map[string]string{"fail": "true"}},
+		{Type: orchestrator.ActionNotify, Target: "c", Namespace: DefaultNamespace},
+		{Type: orchestrator.ActionRestart, Target: "x", Namespace: "team-b"},
+		{Type: orchestrator.ActionScale, Target: "d", Namespace: DefaultNamespace, Parameters: map[string]string{"fail": "true"}},
+		{Type: orchestrator.ActionNotify, Target: "e", Namespace: DefaultNamespace},
+	} {
+		orch.ExecuteAction(context.Background(), action)
+		time.Sleep(time.Millisecond)
+	}
+
+	s := &Server{orchestrator: orch}
+	router := gin.New()
+	router.GET("/actions", s.handleListActions)
+
+	list := func(query string) (targets []string, pagination map[string]int) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/actions"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Actions    []orchestrator.Action `json:"actions"`
+			Pagination map[string]int        `json:"pagination"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		for _, action := range resp.Actions {
+			targets = append(targets, action.Target)
+		}
+		return targets, resp.Pagination
+	}
+
+	for _, tc := range []struct {
+		query      string
+		targets    string
+		total      int
+		totalPages int
+	}{
+		{"", "e,d,c,b,a", 5, 1},
+		{"?limit=2", "e,d", 5, 3},
+		{"?limit=2&page=3", "a", 5, 3},
+		{"?limit=2&page=4", "", 5, 3},
+		{"?status=failed", "d,b", 2, 1},
+		{"?type=notify", "e,c", 2, 1},
+		{"?type=restart&status=succeeded", "a", 1, 1},
+		{"?status=running", "", 0, 0},
+		// Invalid values fall back to the defaults
+		{"?page=0&limit=500", "e,d,c,b,a", 5, 1},
+	} {
+		targets, pagination := list(tc.query)
+		if got := strings.Join(targets, ","); got != tc.targets {
+			t.Errorf("%q: expected %q, got %q", tc.query, tc.targets, got)
+		}
+		if pagination["total"] != tc.total || pagination["total_pages"] != tc.totalPages {
+			t.Errorf("%q: expected total %d in %d pages, got %v", tc.query, tc.total, tc.totalPages, pagination)
+		}
+	}
+}
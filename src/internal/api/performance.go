@@ -461,19 +461,45 @@ type PerformanceConfig struct {
 	RateLimitWindow       time.Duration `json:"rate_limit_window"`
 	CompressionEnabled    bool          `json:"compression_enabled"`
 	ConnectionPoolEnabled bool          `json:"connection_pool_enabled"`
+	MaxBodyBytes          int64         `json:"max_body_bytes"`
+	MaxJSONDepth          int           `json:"max_json_depth"`
+	MaxTrainingValues     int           `json:"max_training_values"`
+	MaxQueryRange         time.Duration `json:"max_query_range"`
+	HealthCacheTTL        time.Duration `json:"health_cache_ttl"`
+	// NonFiniteFloats is how NaN and ±Inf are encoded in detection responses:
+	// NonFiniteNull (default) or NonFiniteClamp
+	NonFiniteFloats string `json:"non_finite_floats"`
+	// MaxConcurrentDetections bounds parallel detections (0 or less disables the limit);
+	// DetectionQueueTimeout is how long a detection waits for a slot before a 429
+	MaxConcurrentDetections int           `json:"max_concurrent_detections"`
+	DetectionQueueTimeout   time.Duration `json:"detection_queue_timeout"`
+	// AnalyzeWindow is the range analyzed when start is omitted; AnalyzeTargetPoints
+	// is the point count an automatic step aims for when step is omitted
+	AnalyzeWindow       time.Duration `json:"analyze_window"`
+	AnalyzeTargetPoints int           `json:"analyze_target_points"`
 }
 
 // DefaultPerformanceConfig returns default performance settings
 func DefaultPerformanceConfig() PerformanceConfig {
 	return PerformanceConfig{
-		CacheEnabled:          true,
-		CacheTTL:              5 * time.Minute,
-		CacheMaxSize:          1000,
-		RateLimitEnabled:      true,
-		RateLimit:             100,
-		RateLimitWindow:       time.Minute,
-		CompressionEnabled:    true,
-		ConnectionPoolEnabled: true,
+		CacheEnabled:            true,
+		CacheTTL:                5 * time.Minute,
+		CacheMaxSize:            1000,
+		RateLimitEnabled:        true,
+		RateLimit:               100,
+		RateLimitWindow:         time.Minute,
+		CompressionEnabled:      true,
+		ConnectionPoolEnabled:   true,
+		MaxBodyBytes:            DefaultMaxBodyBytes,
+		MaxJSONDepth:            DefaultMaxJSONDepth,
+		MaxTrainingValues:       DefaultMaxTrainingValues,
+		MaxQueryRange:           DefaultMaxQueryRange,
+		HealthCacheTTL:          DefaultHealthCacheTTL,
+		NonFiniteFloats:         NonFiniteNull,
+		MaxConcurrentDetections: DefaultMaxConcurrentDetections,
+		DetectionQueueTimeout:   DefaultDetectionQueueTimeout,
+		AnalyzeWindow:           DefaultAnalyzeWindow,
+		AnalyzeTargetPoints:     DefaultAnalyzeTargetPoints,
 	}
 }
 
@@ -481,9 +507,17 @@ func DefaultPerformanceConfig() PerformanceConfig {
 func PerformanceMiddleware(config PerformanceConfig) []gin.HandlerFunc {
 	var middlewares []gin.HandlerFunc
 
+	// Count in-flight requests, for performance stats and shutdown drain logging
+	middlewares = append(middlewares, InFlightMiddleware())
+
 	// Add metrics middleware
 	middlewares = append(middlewares, MetricsMiddleware())
 
+	// Add request body limits if configured
+	if config.MaxBodyBytes > 0 {
+		middlewares = append(middlewares, BodyLimitMiddleware(config.MaxBodyBytes, config.MaxJSONDepth))
+	}
+
 	// Add rate limiting if enabled
 	if config.RateLimitEnabled {
 		middlewares = append(middlewares, RateLimitMiddleware())
@@ -515,6 +549,12 @@ func GetPerformanceStats() map[string]interface{} {
 	// Add connection pool stats
 	stats["connection_pool"] = GlobalConnectionPool.GetStats()
 
+	// Add detection concurrency stats
+	stats["detections"] = GlobalDetectionLimiter.GetStats()
+
+	// Add in-flight request stats
+	stats["in_flight_requests"] = GlobalInFlight.GetStats()
+
 	// Add system stats
 	stats["system"] = GetSystemInfo()
 
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// PipelineDetectorStore returns the store through which the data source
+// metrics pipeline feeds collected values to the API-managed detectors: a
+// running detector runs detection on every value, any other non-paused
+// detector is trained on them. Anomalies are not notified by the store; they
+// reach the pipeline's anomaly handler, which passes them to NotifyDetection.
+func (s *Server) PipelineDetectorStore() datasource.DetectorStore {
+	return pipelineDetectorStore{server: s}
+}
+
+// pipelineDetectorStore looks up API-managed detectors for the metrics pipeline
+type pipelineDetectorStore struct {
+	server *Server
+}
+
+// Get returns the detector with the given ID adapted to datasource.Detector
+func (p pipelineDetectorStore) Get(id string) (interface{}, error) {
+	p.server.detectorManager.mu.RLock()
+	_, exists := p.server.detectorManager.lookup(id)
+	p.server.detectorManager.mu.RUnlock()
+
+	if !exists {
+		return nil, ErrDetectorNotFound
+	}
+	return &pipelineDetector{server: p.server, id: id}, nil
+}
+
+// pipelineDetector adapts an API-managed detector to datasource.Detector
+type pipelineDetector struct {
+	server *Server
+	id     string
+}
+
+// GetStatus reports "running" and "paused" detectors as such; the pipeline
+// trains detectors in any other state
+func (d *pipelineDetector) GetStatus() string {
+	d.server.detectorManager.mu.RLock()
+	defer d.server.detectorManager.mu.RUnlock()
+
+	instance, exists := d.server.detectorManager.lookup(d.id)
+	if !exists {
+		return "stopped"
+	}
+	switch instance.Status {
+	case "running", "paused":
+		return instance.Status
+	default:
+		return "training"
+	}
+}
+
+// Train trains the detector on the values if it supports training
+func (d *pipelineDetector) Train(data []float64) {
+	d.server.detectorManager.mu.RLock()
+	instance, exists := d.server.detectorManager.lookup(d.id)
+	d.server.detectorManager.mu.RUnlock()
+	if !exists {
+		return
+	}
+
+	trainable, ok := instance.Detector.(detector.TrainableDetector)
+	if !ok {
+		return
+	}
+	if err := traceTrain(context.Background(), trainable, data); err != nil {
+		NewLogger("pipeline").Error("Pipeline training failed", err, map[string]interface{}{
+			"detector_id": d.id,
+		})
+		return
+	}
+
+	d.server.detectorManager.mu.Lock()
+	instance.UpdatedAt = time.Now()
+	d.server.detectorManager.mu.Unlock()
+}
+
+// Detect runs detection on each value; the result carries the last anomaly
+func (d *pipelineDetector) Detect(data []float64) datasource.DetectionResult {
+	var result datasource.DetectionResult
+	for _, value := range data {
+		_, anomaly, err := d.server.detectValue(context.Background(), d.id, value, time.Time{})
+		if err != nil {
+			NewLogger("pipeline").Error("Pipeline detection failed", err, map[string]interface{}{
+				"detector_id": d.id,
+			})
+			return result
+		}
+		if anomaly == nil {
+			continue
+		}
+
+		result.IsAnomaly = true
+		result.Anomaly = anomaly
+		if score, ok := anomaly.Details["score"].(float64); ok {
+			result.Score = score
+		}
+	}
+	return result
+}
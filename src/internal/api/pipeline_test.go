@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestPipelineDetectorStore_DeliversCallback(t *testing.T) {
+	payloads := make(chan DetectorWebhookPayload, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload DetectorWebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid callback body: %v", err)
+		}
+		payloads <- payload
+	}))
+	defer callback.Close()
+
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.CallbackURL = callback.URL
+	// The test server listens on loopback, which the default client refuses
+	s.webhookNotifier = &WebhookNotifier{client: callback.Client(), timeout: time.Second, logger: NewLogger("webhook")}
+
+	// The handler main registers on the metrics pipeline
+	handler := func(detectorID string, point datasource.DataPoint, result datasource.DetectionResult) {
+		if anomaly, ok := result.Anomaly.(*detector.Anomaly); ok {
+			s.NotifyDetection(detectorID, point.Value, anomaly)
+		}
+	}
+
+	store := s.PipelineDetectorStore()
+	if _, err := store.Get("missing"); err == nil {
+		t.Error("expected an error for an unknown detector")
+	}
+	found, err := store.Get("detector_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	det, ok := found.(datasource.Detector)
+	if !ok || det.GetStatus() != "running" {
+		t.Fatalf("expected a running pipeline detector, got %T", found)
+	}
+
+	if result := det.Detect([]float64{5}); result.IsAnomaly {
+		t.Fatalf("expected no anomaly for a normal value, got %+v", result)
+	}
+	result := det.Detect([]float64{50})
+	if !result.IsAnomaly {
+		t.Fatal("expected an anomaly")
+	}
+	handler("detector_1", datasource.DataPoint{Value: 50}, result)
+
+	select {
+	case payload := <-payloads:
+		if payload.DetectorID != "detector_1" || payload.Value != 50 || payload.AnomalyID == "" {
+			t.Errorf("unexpected callback payload: %+v", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the anomaly to be delivered to the callback URL")
+	}
+	if instance.Metrics.AnomaliesFound != 1 {
+		t.Errorf("expected the detection to be counted once, got %d", instance.Metrics.AnomaliesFound)
+	}
+}
+
+func TestPipelineDetector_TrainsUnlessRunning(t *testing.T) {
+	trainable := &thresholdDetector{limit: 10}
+	s := newIngestTestServer("stopped", trainable)
+
+	found, _ := s.PipelineDetectorStore().Get("detector_1")
+	det := found.(datasource.Detector)
+	if det.GetStatus() != "training" {
+		t.Fatalf("expected a stopped detector to be trained, got status %q", det.GetStatus())
+	}
+	det.Train([]float64{1, 2, 3})
+	if len(trainable.trained) != 3 {
+		t.Errorf("expected the values to be trained on, got %v", trainable.trained)
+	}
+
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.Status = "paused"
+	if det.GetStatus() != "paused" {
+		t.Errorf("expected a paused detector to be reported paused, got %q", det.GetStatus())
+	}
+}
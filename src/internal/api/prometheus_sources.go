@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// RegisterPrometheusSource registers the Prometheus detector of a named source
+// (e.g. one Prometheus per region) for the check and analyze endpoints.
+// Requests without a source use the detector of RegisterPrometheusDetector.
+func (s *Server) RegisterPrometheusSource(name string, promDetector *detector.PrometheusAnomalyDetector) {
+	if s.promSources == nil {
+		s.promSources = make(map[string]*detector.PrometheusAnomalyDetector)
+	}
+	s.promSources[name] = promDetector
+}
+
+// prometheusDetector returns the detector of the named Prometheus source, or
+// the default detector when source is empty. It returns a VALIDATION_ERROR
+// APIError listing the known sources for an unknown one.
+func (s *Server) prometheusDetector(source string) (*detector.PrometheusAnomalyDetector, error) {
+	if source == "" {
+		return s.promDetector, nil
+	}
+	if promDetector, ok := s.promSources[source]; ok {
+		return promDetector, nil
+	}
+
+	sources := make([]string, 0, len(s.promSources))
+	for name := range s.promSources {
+		sources = append(sources, name)
+	}
+	sort.Strings(sources)
+
+	apiError := NewValidationError("source", fmt.Sprintf("unknown prometheus source: %s", source))
+	apiError.Context = map[string]interface{}{"sources": sources}
+	return nil, apiError
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestHandlePrometheusQuery_Source(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := func(value string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+				`{"metric":{"__name__":"up"},"value":[1700000000,"` + value + `"]}]}}`))
+		}))
+	}
+	east, west := fake("1"), fake("2")
+	defer east.Close()
+	defer west.Close()
+
+	config := datasource.DefaultDataSourceConfig()
+	config.EnableLogs = false
+	config.PrometheusSources = []datasource.PrometheusServer{
+		{Name: "east", URL: east.URL},
+		{Name: "west", URL: west.URL},
+	}
+	manager, err := datasource.NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Stop()
+
+	router := gin.New()
+	NewDataSourceAPI(manager).SetupRoutes(router.Group("/api/datasources"))
+
+	query := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/datasources/prometheus/query", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for body, want := range map[string]float64{
+		`{"query":"up"}`:                 1,
+		`{"query":"up","source":"west"}`: 2,
+	} {
+		w := query(body)
+		var resp struct {
+			Results []datasource.MetricResult `json:"results"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", body, w.Code, w.Body.String())
+		}
+		if len(resp.Results) != 1 || resp.Results[0].Value != want {
+			t.Errorf("%s: expected value %v, got %+v", body, want, resp.Results)
+		}
+	}
+
+	if w := query(`{"query":"up","source":"north"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown source, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/datasources/prometheus/sources", nil))
+	if !strings.Contains(w.Body.String(), `"sources":["east","west"]`) {
+		t.Errorf("expected the sources in configuration order, got %s", w.Body.String())
+	}
+}
+
+func TestPrometheusDetectorSource(t *testing.T) {
+	defaultDetector := &detector.PrometheusAnomalyDetector{}
+	westDetector := &detector.PrometheusAnomalyDetector{}
+	s := &Server{promDetector: defaultDetector}
+	s.RegisterPrometheusSource("west", westDetector)
+
+	if got, err := s.prometheusDetector(""); err != nil || got != defaultDetector {
+		t.Errorf("expected the default detector without a source, got %p, %v", got, err)
+	}
+	if got, err := s.prometheusDetector("west"); err != nil || got != westDetector {
+		t.Errorf("expected the west detector, got %p, %v", got, err)
+	}
+
+	_, err := s.prometheusDetector("north")
+	apiError, ok := err.(*APIError)
+	if !ok || apiError.Code != ErrorCodeValidation {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if context, ok := apiError.Context.(map[string]interface{}); !ok || len(context["sources"].([]string)) != 1 {
+		t.Errorf("expected the error to list the known sources, got %v", apiError.Context)
+	}
+}
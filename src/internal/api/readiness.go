@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// readinessCheckTimeout bounds a single probe made while serving /ready
+const readinessCheckTimeout = 2 * time.Second
+
+// ReadinessCheck returns nil once a component can serve, or the reason it cannot yet
+type ReadinessCheck func(ctx context.Context) error
+
+// ReadinessTracker gates readiness on registered components (data sources, the
+// detection pipeline). A component becomes ready the first time its check
+// passes and stays ready; until then every readiness request re-runs the check.
+type ReadinessTracker struct {
+	mu     sync.Mutex
+	checks map[string]ReadinessCheck
+	ready  map[string]bool
+}
+
+// GlobalReadiness is the readiness tracker used by the readiness handler
+var GlobalReadiness = NewReadinessTracker()
+
+// NewReadinessTracker creates a tracker with no components; it is ready until one is registered
+func NewReadinessTracker() *ReadinessTracker {
+	return &ReadinessTracker{
+		checks: make(map[string]ReadinessCheck),
+		ready:  make(map[string]bool),
+	}
+}
+
+// Register adds a component that must pass check before the instance is ready.
+// Registering a name again replaces its check and makes it pending again.
+func (rt *ReadinessTracker) Register(name string, check ReadinessCheck) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.checks[name] = check
+	delete(rt.ready, name)
+}
+
+// Unregister removes a component from the readiness gate
+func (rt *ReadinessTracker) Unregister(name string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	delete(rt.checks, name)
+	delete(rt.ready, name)
+}
+
+// Check runs the checks of components that are not ready yet and returns the
+// ready components and the reason each pending one is not, both sorted by name
+func (rt *ReadinessTracker) Check(ctx context.Context) ([]string, map[string]string) {
+	rt.mu.Lock()
+	ready := make([]string, 0, len(rt.checks))
+	pending := make(map[string]ReadinessCheck)
+	for name, check := range rt.checks {
+		if rt.ready[name] {
+			ready = append(ready, name)
+		} else {
+			pending[name] = check
+		}
+	}
+	rt.mu.Unlock()
+
+	// Probes run outside the lock so a slow backend does not block registration
+	notReady := make(map[string]string)
+	for name, check := range pending {
+		checkCtx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+		err := check(checkCtx)
+		cancel()
+		if err != nil {
+			notReady[name] = err.Error()
+			continue
+		}
+
+		rt.mu.Lock()
+		// Skip components replaced or removed while the probe ran
+		if _, ok := rt.checks[name]; ok {
+			rt.ready[name] = true
+		}
+		rt.mu.Unlock()
+		ready = append(ready, name)
+	}
+
+	sort.Strings(ready)
+	return ready, notReady
+}
+
+// RegisterReadinessCheck adds a component to the global readiness gate
+func RegisterReadinessCheck(name string, check ReadinessCheck) {
+	GlobalReadiness.Register(name, check)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadinessTracker(t *testing.T) {
+	tracker := NewReadinessTracker()
+	if _, notReady := tracker.Check(context.Background()); len(notReady) != 0 {
+		t.Fatalf("expected a tracker without components to be ready, got %v", notReady)
+	}
+
+	reachable := false
+	calls := 0
+	tracker.Register("prometheus", func(ctx context.Context) error {
+		calls++
+		if !reachable {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	ready, notReady := tracker.Check(context.Background())
+	if len(ready) != 0 || notReady["prometheus"] != "connection refused" {
+		t.Fatalf("expected prometheus to be pending, got ready=%v not_ready=%v", ready, notReady)
+	}
+
+	reachable = true
+	ready, notReady = tracker.Check(context.Background())
+	if len(notReady) != 0 || len(ready) != 1 || ready[0] != "prometheus" {
+		t.Fatalf("expected prometheus to be ready, got ready=%v not_ready=%v", ready, notReady)
+	}
+
+	// Once passed, a component stays ready without probing again
+	reachable = false
+	if _, notReady := tracker.Check(context.Background()); len(notReady) != 0 {
+		t.Errorf("expected prometheus to stay ready, got %v", notReady)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 probes, got %d", calls)
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	previous := GlobalReadiness
+	defer func() { GlobalReadiness = previous }()
+	GlobalReadiness = NewReadinessTracker()
+
+	started := false
+	RegisterReadinessCheck("loki", func(ctx context.Context) error {
+		if !started {
+			return errors.New("log collection not started")
+		}
+		return nil
+	})
+
+	router := gin.New()
+	router.GET("/readyz", ReadinessHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before the collector starts, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "log collection not started") {
+		t.Errorf("expected the pending reason in the response, got %s", w.Body.String())
+	}
+
+	started = true
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 once the collector is ready, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"components":["api","loki"]`) {
+		t.Errorf("expected api and loki to be listed as ready, got %s", w.Body.String())
+	}
+}
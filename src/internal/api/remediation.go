@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RemediationRequest turns remediation on or off
+type RemediationRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// handleGetRemediation reports whether the orchestrator executes remediation actions
+func (s *Server) handleGetRemediation(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": s.orchestrator.Enabled()})
+}
+
+// handleSetRemediation is the kill-switch for remediation: while disabled,
+// every action except notifications is rejected (e.g. during a change freeze)
+func (s *Server) handleSetRemediation(c *gin.Context) {
+	var req RemediationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous := s.orchestrator.Enabled()
+	s.orchestrator.SetEnabled(*req.Enabled)
+	if previous != *req.Enabled {
+		globalLogger().Warn("Remediation toggled", map[string]bool{"previous_enabled": previous, "enabled": *req.Enabled})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enabled": *req.Enabled, "previous_enabled": previous})
+}
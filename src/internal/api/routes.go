@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteInfo describes a registered API route
+type RouteInfo struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// handleListRoutes lists the routes registered on this server. Routes depend on
+// the enabled detectors and data sources, so the list reflects the running build
+// rather than the full API documentation.
+func (s *Server) handleListRoutes(c *gin.Context) {
+	registered := s.engine.Routes()
+	routes := make([]RouteInfo, 0, len(registered))
+	for _, route := range registered {
+		routes = append(routes, RouteInfo{Method: route.Method, Path: route.Path})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"routes": routes,
+		"count":  len(routes),
+	})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleListRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{engine: gin.New()}
+	s.engine.GET("/api/routes", s.handleListRoutes)
+	s.engine.POST("/api/detectors", func(c *gin.Context) {})
+	s.engine.GET("/api/detectors", func(c *gin.Context) {})
+
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/routes", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Routes []RouteInfo `json:"routes"`
+		Count  int         `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+
+	expected := []RouteInfo{
+		{Method: http.MethodGet, Path: "/api/detectors"},
+		{Method: http.MethodPost, Path: "/api/detectors"},
+		{Method: http.MethodGet, Path: "/api/routes"},
+	}
+	if resp.Count != len(expected) || len(resp.Routes) != len(expected) {
+		t.Fatalf("expected %d routes, got %+v", len(expected), resp)
+	}
+	for i, route := range expected {
+		if resp.Routes[i] != route {
+			t.Errorf("route %d: expected %+v, got %+v", i, route, resp.Routes[i])
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrSavedQueryNotFound is returned for an unknown saved query name
+var ErrSavedQueryNotFound = errors.New("saved query not found")
+
+var (
+	// savedQueryNamePattern restricts saved query names to URL-safe identifiers
+	savedQueryNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	// queryVariablePattern matches ${var} placeholders in saved queries
+	queryVariablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// SavedQuery is a named PromQL/LogQL query whose ${var} placeholders are
+// substituted with the variables supplied when it is run
+type SavedQuery struct {
+	Name string `json:"name"`
+	// Source is "prometheus" (default) or "loki"; Loki queries must be metric queries
+	Source      string `json:"source"`
+	Query       string `json:"query" binding:"required"`
+	Description string `json:"description,omitempty"`
+	// Variables lists the placeholders referenced by Query, in order of first use
+	Variables []string `json:"variables"`
+}
+
+// Validate checks the saved query's name, source and placeholders
+func (q *SavedQuery) Validate() error {
+	if !savedQueryNamePattern.MatchString(q.Name) {
+		return fmt.Errorf("invalid saved query name %q", q.Name)
+	}
+	switch q.Source {
+	case "", "prometheus", "loki":
+	default:
+		return fmt.Errorf("unsupported source: %s", q.Source)
+	}
+	if strings.TrimSpace(q.Query) == "" {
+		return errors.New("query cannot be empty")
+	}
+	// Every "${" must open a well-formed placeholder
+	if strings.Count(q.Query, "${") != len(queryVariablePattern.FindAllString(q.Query, -1)) {
+		return fmt.Errorf("malformed variable placeholder in query %q", q.Query)
+	}
+	return nil
+}
+
+// queryVariables returns the distinct placeholder names of a query, in order of first use
+func queryVariables(query string) []string {
+	variables := []string{}
+	seen := make(map[string]bool)
+	for _, match := range queryVariablePattern.FindAllStringSubmatch(query, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+// renderQuery substitutes variables into the query's placeholders. It returns the
+// names of referenced variables that were not supplied, and an error for values
+// that could break out of a label matcher or string literal.
+func renderQuery(query string, variables map[string]string) (string, []string, error) {
+	var missing []string
+	for _, name := range queryVariables(query) {
+		value, ok := variables[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if strings.ContainsAny(value, "\"`\\{}\n\r") {
+			return "", nil, fmt.Errorf("variable %s contains characters not allowed in a query value", name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", missing, nil
+	}
+
+	rendered := queryVariablePattern.ReplaceAllStringFunc(query, func(placeholder string) string {
+		return variables[placeholder[2:len(placeholder)-1]]
+	})
+	return rendered, nil, nil
+}
+
+// SavedQueryStore keeps saved queries in memory, keyed by name
+type SavedQueryStore struct {
+	queries map[string]SavedQuery
+	mu      sync.RWMutex
+}
+
+// NewSavedQueryStore creates an empty saved query store
+func NewSavedQueryStore() *SavedQueryStore {
+	return &SavedQueryStore{queries: make(map[string]SavedQuery)}
+}
+
+// Put validates and stores a query, replacing one with the same name
+func (st *SavedQueryStore) Put(query SavedQuery) (SavedQuery, error) {
+	if err := query.Validate(); err != nil {
+		return SavedQuery{}, err
+	}
+	if query.Source == "" {
+		query.Source = "prometheus"
+	}
+	query.Variables = queryVariables(query.Query)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.queries[query.Name] = query
+	return query, nil
+}
+
+// Get returns a saved query by name
+func (st *SavedQueryStore) Get(name string) (SavedQuery, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	query, ok := st.queries[name]
+	return query, ok
+}
+
+// List returns all saved queries sorted by name
+func (st *SavedQueryStore) List() []SavedQuery {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	queries := make([]SavedQuery, 0, len(st.queries))
+	for _, query := range st.queries {
+		queries = append(queries, query)
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Name < queries[j].Name })
+	return queries
+}
+
+// Delete removes a saved query, reporting whether it existed
+func (st *SavedQueryStore) Delete(name string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	_, ok := st.queries[name]
+	delete(st.queries, name)
+	return ok
+}
+
+// SetSavedQueries registers saved queries, e.g. from configuration
+func (s *Server) SetSavedQueries(queries []SavedQuery) error {
+	for _, query := range queries {
+		if _, err := s.savedQueries.Put(query); err != nil {
+			return fmt.Errorf("saved query %s: %w", query.Name, err)
+		}
+	}
+	return nil
+}
+
+// setupSavedQueryRoutes configures the saved query API routes
+func (s *Server) setupSavedQueryRoutes() {
+	queriesGroup := s.engine.Group("/api/queries")
+	{
+		queriesGroup.GET("", s.handleListSavedQueries)
+		queriesGroup.GET("/:name", s.handleGetSavedQuery)
+		queriesGroup.PUT("/:name", s.handlePutSavedQuery)
+		queriesGroup.DELETE("/:name", s.handleDeleteSavedQuery)
+		queriesGroup.POST("/:name/run", s.handleRunSavedQuery)
+	}
+}
+
+// handleListSavedQueries lists the saved queries
+func (s *Server) handleListSavedQueries(c *gin.Context) {
+	queries := s.savedQueries.List()
+	c.JSON(http.StatusOK, gin.H{
+		"queries": queries,
+		"count":   len(queries),
+	})
+}
+
+// handleGetSavedQuery returns a saved query by name
+func (s *Server) handleGetSavedQuery(c *gin.Context) {
+	query, ok := s.savedQueries.Get(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrSavedQueryNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, query)
+}
+
+// handlePutSavedQuery creates or replaces a saved query
+func (s *Server) handlePutSavedQuery(c *gin.Context) {
+	var query SavedQuery
+	if err := c.ShouldBindJSON(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.Name = c.Param("name")
+
+	saved, err := s.savedQueries.Put(query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+// handleDeleteSavedQuery removes a saved query
+func (s *Server) handleDeleteSavedQuery(c *gin.Context) {
+	if !s.savedQueries.Delete(c.Param("name")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrSavedQueryNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "saved query deleted successfully"})
+}
+
+// RunSavedQueryRequest is the body of POST /api/queries/:name/run
+type RunSavedQueryRequest struct {
+	Variables map[string]string `json:"variables"`
+	Start     time.Time         `json:"start"`
+	End       time.Time         `json:"end"`
+	// Step is the Prometheus range query resolution, e.g. "30s"
+	Step string `json:"step"`
+}
+
+// handleRunSavedQuery substitutes the supplied variables into a saved query and
+// runs it over the requested time range (the last hour by default)
+func (s *Server) handleRunSavedQuery(c *gin.Context) {
+	saved, ok := s.savedQueries.Get(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrSavedQueryNotFound.Error()})
+		return
+	}
+
+	var req RunSavedQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, missing, err := renderQuery(saved.Query, req.Variables)
+	if err != nil {
+		HandleValidationError(c, "variables", err.Error())
+		return
+	}
+	if len(missing) > 0 {
+		apiError := NewValidationError("variables", fmt.Sprintf("missing variables %v", missing))
+		apiError.Context = map[string][]string{"missing_variables": missing}
+		HandleError(c, apiError)
+		return
+	}
+
+	series, ok := s.querySeries(c, TrainFromQueryRequest{
+		Source: saved.Source,
+		Query:  query,
+		Start:  req.Start,
+		End:    req.End,
+		Step:   req.Step,
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":         saved.Name,
+		"query":        query,
+		"series":       series,
+		"series_count": len(series),
+	})
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+)
+
+func TestRenderQuery(t *testing.T) {
+	query := `sum(rate(http_requests_total{service="${service}",env="${env}"}[${window}])) / sum(rate(http_requests_total{service="${service}"}[${window}]))`
+
+	if got := queryVariables(query); strings.Join(got, ",") != "service,env,window" {
+		t.Errorf("expected variables service,env,window, got %v", got)
+	}
+
+	rendered, missing, err := renderQuery(query, map[string]string{"service": "api", "env": "prod", "window": "5m"})
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("unexpected render failure: %v, missing %v", err, missing)
+	}
+	want := `sum(rate(http_requests_total{service="api",env="prod"}[5m])) / sum(rate(http_requests_total{service="api"}[5m]))`
+	if rendered != want {
+		t.Errorf("expected %s, got %s", want, rendered)
+	}
+
+	if _, missing, _ := renderQuery(query, map[string]string{"service": "api"}); strings.Join(missing, ",") != "env,window" {
+		t.Errorf("expected env and window to be missing, got %v", missing)
+	}
+	if _, _, err := renderQuery(query, map[string]string{"service": `api"} or vector(1) #`, "env": "prod", "window": "5m"}); err == nil {
+		t.Error("expected a value breaking out of the label matcher to be rejected")
+	}
+}
+
+func TestSavedQueryStore_Validation(t *testing.T) {
+	store := NewSavedQueryStore()
+
+	for _, query := range []SavedQuery{
+		{Name: "bad name", Query: "up"},
+		{Name: "up", Query: " "},
+		{Name: "up", Source: "graphite", Query: "up"},
+		{Name: "up", Query: `up{job="${job"}`},
+	} {
+		if _, err := store.Put(query); err == nil {
+			t.Errorf("expected %+v to be rejected", query)
+		}
+	}
+
+	saved, err := store.Put(SavedQuery{Name: "up", Query: `up{job="${job}"}`})
+	if err != nil {
+		t.Fatalf("failed to save query: %v", err)
+	}
+	if saved.Source != "prometheus" || len(saved.Variables) != 1 || saved.Variables[0] != "job" {
+		t.Errorf("unexpected saved query: %+v", saved)
+	}
+}
+
+func TestHandleRunSavedQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received string
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.FormValue("query")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[` +
+			`{"metric":{"service":"api"},"values":[[1700000000,"1"],[1700000060,"2"]]}]}}`))
+	}))
+	defer prometheus.Close()
+
+	config := datasource.DefaultDataSourceConfig()
+	config.PrometheusURL = prometheus.URL
+	config.EnableLogs = false
+	manager, err := datasource.NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	s := &Server{savedQueries: NewSavedQueryStore(), dataSourceAPI: NewDataSourceAPI(manager)}
+	if err := s.SetSavedQueries([]SavedQuery{{Name: "errors", Query: `sum(rate(errors_total{service="${service}"}[5m]))`}}); err != nil {
+		t.Fatalf("failed to register saved query: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/api/queries/:name/run", s.handleRunSavedQuery)
+
+	run := func(name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/queries/"+name+"/run", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := run("unknown", `{}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown query, got %d", w.Code)
+	}
+
+	w := run("errors", ``)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "missing_variables") {
+		t.Fatalf("expected a validation error listing missing variables, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = run("errors", `{"variables": {"service": "api"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if want := `sum(rate(errors_total{service="api"}[5m]))`; received != want {
+		t.Errorf("expected Prometheus to receive %s, got %s", want, received)
+	}
+	var resp struct {
+		Query       string `json:"query"`
+		SeriesCount int    `json:"series_count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.SeriesCount != 1 {
+		t.Errorf("expected one series, got %s", w.Body.String())
+	}
+}
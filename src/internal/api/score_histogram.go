@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// DefaultScoreBuckets are the upper bounds of the score histogram buckets used
+// when a detector does not set score_buckets. They cover the usual z-score range.
+var DefaultScoreBuckets = []float64{0.5, 1, 1.5, 2, 2.5, 3, 4, 5, 7.5, 10}
+
+// ScoreHistogram counts the scores a detector produced per bucket, so the
+// threshold can be compared with the actual score distribution. It is guarded
+// by DetectorManager.mu.
+type ScoreHistogram struct {
+	bounds []float64
+	counts []int64 // len(bounds)+1, the last bucket is +Inf
+	count  int64
+	sum    float64
+	min    float64
+	max    float64
+}
+
+// ScoreBucket is one histogram bucket; Le is the inclusive upper bound
+type ScoreBucket struct {
+	Le    string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+// ScoreHistogramResponse is returned by GET /api/detectors/:id/score-histogram
+type ScoreHistogramResponse struct {
+	DetectorID string        `json:"detector_id"`
+	Threshold  float64       `json:"threshold"`
+	Count      int64         `json:"count"`
+	Sum        float64       `json:"sum"`
+	Min        float64       `json:"min,omitempty"`
+	Max        float64       `json:"max,omitempty"`
+	Mean       float64       `json:"mean,omitempty"`
+	Buckets    []ScoreBucket `json:"buckets"`
+}
+
+// newScoreHistogram creates a histogram with the given bucket bounds, or the
+// default bounds when none are given
+func newScoreHistogram(bounds []float64) *ScoreHistogram {
+	if len(bounds) == 0 {
+		bounds = DefaultScoreBuckets
+	}
+	return &ScoreHistogram{
+		bounds: append([]float64(nil), bounds...),
+		counts: make([]int64, len(bounds)+1),
+	}
+}
+
+// validateScoreBuckets checks that bucket bounds are finite and strictly increasing
+func validateScoreBuckets(bounds []float64) error {
+	for i, bound := range bounds {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) {
+			return fmt.Errorf("score_buckets[%d] must be a finite number", i)
+		}
+		if i > 0 && bound <= bounds[i-1] {
+			return fmt.Errorf("score_buckets must be strictly increasing")
+		}
+	}
+	return nil
+}
+
+// equalScoreBuckets reports whether two bucket layouts are the same
+func equalScoreBuckets(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// observe adds a score to the histogram
+func (h *ScoreHistogram) observe(score float64) {
+	i := 0
+	for i < len(h.bounds) && score > h.bounds[i] {
+		i++
+	}
+	h.counts[i]++
+
+	if h.count == 0 || score < h.min {
+		h.min = score
+	}
+	if h.count == 0 || score > h.max {
+		h.max = score
+	}
+	h.count++
+	h.sum += score
+}
+
+// response returns the histogram with per-bucket (non-cumulative) counts
+func (h *ScoreHistogram) response(detectorID string, threshold float64) ScoreHistogramResponse {
+	resp := ScoreHistogramResponse{
+		DetectorID: detectorID,
+		Threshold:  threshold,
+		Count:      h.count,
+		Sum:        h.sum,
+		Min:        h.min,
+		Max:        h.max,
+		Buckets:    make([]ScoreBucket, 0, len(h.counts)),
+	}
+	if h.count > 0 {
+		resp.Mean = h.sum / float64(h.count)
+	}
+
+	for i, count := range h.counts {
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = fmt.Sprintf("%g", h.bounds[i])
+		}
+		resp.Buckets = append(resp.Buckets, ScoreBucket{Le: le, Count: count})
+	}
+	return resp
+}
+
+// detectScored runs Detect on value and returns the score of that detection.
+// scored is false when the detector had no score (e.g. during warm-up) or the
+// score is not a finite number. Of detectors that do not report their scores,
+// only the scores of anomalies are known.
+func detectScored(ctx context.Context, det detector.Detector, value float64) (anomaly *detector.Anomaly, score float64, scored bool, err error) {
+	if scoredDetector, ok := det.(detector.ScoredDetector); ok {
+		anomaly, score, scored, err = scoredDetector.DetectScored(ctx, value)
+	} else {
+		anomaly, err = det.Detect(ctx, value)
+		if anomaly != nil {
+			score, scored = anomaly.Details["score"].(float64)
+		}
+	}
+	if err != nil || !scored || math.IsNaN(score) || math.IsInf(score, 0) {
+		return anomaly, 0, false, err
+	}
+	return anomaly, score, true, nil
+}
+
+// handleGetScoreHistogram returns the distribution of scores produced by a detector
+func (s *Server) handleGetScoreHistogram(c *gin.Context) {
+	id := c.Param("id")
+
+	s.detectorManager.mu.RLock()
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
+	var resp ScoreHistogramResponse
+	if exists {
+		scores := detectorInstance.scores
+		if scores == nil {
+			scores = newScoreHistogram(detectorInstance.ScoreBuckets)
+		}
+		resp = scores.response(id, detectorInstance.Config.Threshold)
+	}
+	s.detectorManager.mu.RUnlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// scoringDetector scores every value as a multiple of the limit
+type scoringDetector struct {
+	thresholdDetector
+}
+
+func (d *scoringDetector) Detect(ctx context.Context, value float64) (*detector.Anomaly, error) {
+	anomaly, _, _, err := d.DetectScored(ctx, value)
+	return anomaly, err
+}
+
+func (d *scoringDetector) DetectScored(ctx context.Context, value float64) (*detector.Anomaly, float64, bool, error) {
+	score := value / d.limit
+	if score <= 1 {
+		return nil, score, true, nil
+	}
+	return &detector.Anomaly{Timestamp: time.Now(), Value: value, Threshold: d.limit}, score, true, nil
+}
+
+func (d *scoringDetector) IsAnomaly(values []float64) (bool, float64, error) {
+	score := values[len(values)-1] / d.limit
+	return score > 1, score, nil
+}
+
+// detailScoringDetector reports a score only in the details of its anomalies
+type detailScoringDetector struct {
+	thresholdDetector
+}
+
+func (d *detailScoringDetector) Detect(ctx context.Context, value float64) (*detector.Anomaly, error) {
+	anomaly, err := d.thresholdDetector.Detect(ctx, value)
+	if anomaly != nil {
+		anomaly.Details = map[string]interface{}{"score": value / d.limit}
+	}
+	return anomaly, err
+}
+
+func TestScoreHistogram_Buckets(t *testing.T) {
+	h := newScoreHistogram([]float64{1, 2, 5})
+	for _, score := range []float64{0.5, 1, 1.5, 4, 9} {
+		h.observe(score)
+	}
+
+	resp := h.response("detector_1", 2)
+	expected := []ScoreBucket{{"1", 2}, {"2", 1}, {"5", 1}, {"+Inf", 1}}
+	if len(resp.Buckets) != len(expected) {
+		t.Fatalf("expected %d buckets, got %+v", len(expected), resp.Buckets)
+	}
+	for i, bucket := range expected {
+		if resp.Buckets[i] != bucket {
+			t.Errorf("bucket %d: expected %+v, got %+v", i, bucket, resp.Buckets[i])
+		}
+	}
+	if resp.Count != 5 || resp.Min != 0.5 || resp.Max != 9 || resp.Mean != 3.2 {
+		t.Errorf("unexpected summary: %+v", resp)
+	}
+}
+
+func TestValidateScoreBuckets(t *testing.T) {
+	if err := validateScoreBuckets(nil); err != nil {
+		t.Errorf("expected empty buckets to be valid, got %v", err)
+	}
+	if err := validateScoreBuckets([]float64{1, 2, 3}); err != nil {
+		t.Errorf("expected increasing buckets to be valid, got %v", err)
+	}
+	if err := validateScoreBuckets([]float64{1, 1}); err == nil {
+		t.Error("expected an error for buckets that are not strictly increasing")
+	}
+}
+
+func TestUpdateDetectorMetrics_ObservesScores(t *testing.T) {
+	s := newIngestTestServer("running", &scoringDetector{thresholdDetector{limit: 10}})
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.ScoreBuckets = []float64{1, 2}
+
+	_, err := s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{
+		{Value: 0}, {Value: 5}, {Value: 15}, {Value: 30},
+	})
+	if err != nil {
+		t.Fatalf("ingest failed: %v", err)
+	}
+
+	// A score of 0 is a real score and is observed like any other
+	resp := instance.scores.response("detector_1", 1)
+	if resp.Count != 4 || resp.Min != 0 {
+		t.Fatalf("expected 4 scores from 0, got %+v", resp)
+	}
+	for i, count := range []int64{2, 1, 1} {
+		if resp.Buckets[i].Count != count {
+			t.Errorf("bucket %s: expected %d, got %d", resp.Buckets[i].Le, count, resp.Buckets[i].Count)
+		}
+	}
+
+	// Detectors without scored detection only report the scores of their anomalies
+	s = newIngestTestServer("running", &detailScoringDetector{thresholdDetector{limit: 10}})
+	if _, err := s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{{Value: 5}, {Value: 30}}); err != nil {
+		t.Fatalf("ingest failed: %v", err)
+	}
+	instance, _ = s.detectorManager.lookup("detector_1")
+	if resp := instance.scores.response("detector_1", 1); resp.Count != 1 || resp.Max != 3 {
+		t.Errorf("expected only the anomaly score to be observed, got %+v", resp)
+	}
+
+	// Detectors reporting no score at all leave the histogram unchanged
+	s = newIngestTestServer("running", &thresholdDetector{limit: 10})
+	if _, err := s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{{Value: 5}, {Value: 30}}); err != nil {
+		t.Fatalf("ingest failed: %v", err)
+	}
+	instance, _ = s.detectorManager.lookup("detector_1")
+	if scores := instance.scores; scores != nil && scores.count != 0 {
+		t.Errorf("expected no observed scores, got %d", scores.count)
+	}
+}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -21,6 +23,9 @@ type Server struct {
 	logsDetector *detector.LogsAnomalyDetector
 	detectors    map[string]interface{} // Для хранения различных детекторов
 
+	// Детекторы именованных источников Prometheus (поле source в check/analyze)
+	promSources map[string]*detector.PrometheusAnomalyDetector
+
 	// New: Detector Management Service
 	detectorManager *DetectorManager
 
@@ -29,26 +34,91 @@ type Server struct {
 
 	// New: Data Source API
 	dataSourceAPI *DataSourceAPI
+
+	// Performance and request limit settings
+	perfConfig PerformanceConfig
+
+	// Outbound delivery of detections to detector callback URLs
+	webhookNotifier *WebhookNotifier
+
+	// Alertmanager alert-to-action routing
+	alertRouter alertRouter
+
+	// Opt-in garbage collection of idle stopped detectors
+	detectorGC detectorGCConfig
+
+	// Named threshold/parameter presets referenced by DetectorRequest.Profile
+	profiles map[string]detector.DetectorProfile
+
+	// Cross-origin allowlist shared by the HTTP API and the WebSocket gateway
+	cors *CORSPolicy
+
+	// Recent detector anomalies with acknowledge/resolve state
+	anomalyStore *AnomalyStore
+
+	// Named PromQL/LogQL queries with ${var} placeholders
+	savedQueries *SavedQueryStore
+
+	// Loaded configuration after defaults, with secrets redacted
+	effectiveConfig map[string]interface{}
+
+	// Label-based context (team, runbook) added to anomalies before publishing
+	enricher *Enricher
+
+	// Optional write-behind persistence of anomalies, flushed on Stop
+	anomalyWriter *AnomalyWriter
+
+	// HTTP-сервер, созданный в Start; используется в Stop
+	httpServer *http.Server
+	httpMutex  sync.Mutex
 }
 
 // DetectorManager manages detector lifecycle and operations
 type DetectorManager struct {
-	detectors map[string]*DetectorInstance
-	nextID    int
-	mu        sync.RWMutex
+	// detectors are keyed by namespace and ID. IDs are unique across
+	// namespaces; namespaces maps each ID to its namespace for lookup.
+	detectors  map[detectorKey]*DetectorInstance
+	namespaces map[string]string
+	nextID     int
+	mu         sync.RWMutex
 }
 
 // DetectorInstance represents a configured detector instance
 type DetectorInstance struct {
-	ID        string                  `json:"id"`
-	Name      string                  `json:"name"`
-	Type      detector.DetectorType   `json:"type"`
-	Status    string                  `json:"status"`
-	Config    detector.DetectorConfig `json:"config"`
-	Detector  detector.Detector       `json:"-"`
-	CreatedAt time.Time               `json:"created_at"`
-	UpdatedAt time.Time               `json:"updated_at"`
-	Metrics   DetectorMetrics         `json:"metrics"`
+	ID            string                  `json:"id"`
+	Namespace     string                  `json:"namespace"`
+	Name          string                  `json:"name"`
+	Type          detector.DetectorType   `json:"type"`
+	Status        string                  `json:"status"`
+	Config        detector.DetectorConfig `json:"config"`
+	Detector      detector.Detector       `json:"-"`
+	CallbackURL   string                  `json:"callback_url,omitempty"`
+	Tags          []string                `json:"tags,omitempty"`
+	Profile       string                  `json:"profile,omitempty"`
+	PayloadFormat string                  `json:"payload_format,omitempty"`
+	ScoreBuckets  []float64               `json:"score_buckets,omitempty"`
+	// Labels describe the monitored series; they are attached to the detector's
+	// anomalies so enrichment rules and notification routes can match them
+	Labels map[string]string `json:"labels,omitempty"`
+	// BootstrapFromCache trains the detector on cached collector results when it starts
+	BootstrapFromCache bool `json:"bootstrap_from_cache,omitempty"`
+	// Shadow detectors detect and record anomalies but never publish them
+	// (WebSocket events, callbacks), for trying a detector out before trusting it
+	Shadow bool `json:"shadow,omitempty"`
+	// GatedBy is a detector of the same namespace whose recent anomaly (within
+	// GateWindow, DefaultGateWindow if empty) is required for this detector's anomalies
+	GatedBy    string `json:"gated_by,omitempty"`
+	GateWindow string `json:"gate_window,omitempty"`
+	// Escalation raises the severity of anomalies the longer the detector stays anomalous
+	Escalation []SeverityEscalation `json:"escalation,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	Metrics    DetectorMetrics      `json:"metrics"`
+	scores     *ScoreHistogram
+	gateWindow time.Duration
+
+	escalationSteps []escalationStep
+	escalation      escalationState
 }
 
 // DetectorMetrics contains runtime metrics for a detector
@@ -57,7 +127,10 @@ type DetectorMetrics struct {
 	AnomaliesFound  int64      `json:"anomalies_found"`
 	AnomalyRate     float64    `json:"anomaly_rate"`
 	LastDetection   *time.Time `json:"last_detection,omitempty"`
+	LastAnomaly     *time.Time `json:"last_anomaly,omitempty"`
 	AvgResponseTime float64    `json:"avg_response_time_ms"`
+	// SuppressedAlerts counts anomalies recorded but not published in shadow mode
+	SuppressedAlerts int64 `json:"suppressed_alerts,omitempty"`
 }
 
 // DetectorRequest represents a request to create/update a detector
@@ -66,6 +139,26 @@ type DetectorRequest struct {
 	Type        detector.DetectorType   `json:"type" binding:"required"`
 	Config      detector.DetectorConfig `json:"config" binding:"required"`
 	Description string                  `json:"description,omitempty"`
+	CallbackURL string                  `json:"callback_url,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	// Labels of the monitored series, attached to the detector's anomalies
+	Labels map[string]string `json:"labels,omitempty"`
+	// Profile pre-fills threshold and parameters; fields set in Config override it
+	Profile string `json:"profile,omitempty"`
+	// PayloadFormat is the JSON shape of callback requests (default, flat or nested)
+	PayloadFormat string `json:"payload_format,omitempty"`
+	// ScoreBuckets are the upper bounds of the score histogram (DefaultScoreBuckets if empty)
+	ScoreBuckets []float64 `json:"score_buckets,omitempty"`
+	// BootstrapFromCache opts in to training on cached collector query results on start
+	BootstrapFromCache bool `json:"bootstrap_from_cache,omitempty"`
+	// GatedBy suppresses anomalies unless the named detector raised one within GateWindow (e.g. "5m")
+	GatedBy    string `json:"gated_by,omitempty"`
+	GateWindow string `json:"gate_window,omitempty"`
+	// Shadow records anomalies without publishing them
+	Shadow bool `json:"shadow,omitempty"`
+	// Escalation raises the severity after the detector has been continuously
+	// anomalous for each step's duration
+	Escalation []SeverityEscalation `json:"escalation,omitempty"`
 }
 
 // DetectorResponse represents a detector in API responses
@@ -77,17 +170,22 @@ type DetectorResponse struct {
 // NewServer создает новый сервер API
 func NewServer(orch *orchestrator.Orchestrator) *Server {
 	router := gin.Default()
+	cors := NewCORSPolicy(DefaultCORSConfig())
 	wsGateway := NewWebSocketGateway()
+	wsGateway.SetOriginCheck(cors.CheckOrigin)
 
 	server := &Server{
-		orchestrator: orch,
-		engine:       router,
-		detectors:    make(map[string]interface{}),
-		detectorManager: &DetectorManager{
-			detectors: make(map[string]*DetectorInstance),
-			nextID:    1,
-		},
-		wsGateway: wsGateway,
+		orchestrator:    orch,
+		engine:          router,
+		detectors:       make(map[string]interface{}),
+		detectorManager: newDetectorManager(),
+		wsGateway:       wsGateway,
+		perfConfig:      DefaultPerformanceConfig(),
+		webhookNotifier: NewWebhookNotifier(),
+		profiles:        detector.DefaultProfiles(),
+		cors:            cors,
+		anomalyStore:    NewAnomalyStore(DefaultAnomalyStoreCapacity),
+		savedQueries:    NewSavedQueryStore(),
 	}
 
 	// Настройка маршрутов API
@@ -114,7 +212,10 @@ func (s *Server) RegisterLogsDetector(detector *detector.LogsAnomalyDetector) {
 
 // RegisterDataSourceAPI registers the data source API handler
 func (s *Server) RegisterDataSourceAPI(api *DataSourceAPI) {
+	api.SetMaxQueryRange(s.perfConfig.MaxQueryRange)
 	s.dataSourceAPI = api
+	// The API is registered after NewServer, so its routes are added here
+	s.setupDataSourceRoutes()
 }
 
 // setupRoutes настраивает маршруты API
@@ -122,9 +223,12 @@ func (s *Server) setupRoutes() {
 	// Initialize logging
 	InitLogger("aiops-api", LogLevelInfo)
 
+	// CORS goes first so preflight requests are answered before rate limiting
+	s.engine.Use(CORSMiddleware(s.cors))
+	s.engine.Use(TracingMiddleware())
+
 	// Performance middleware
-	perfConfig := DefaultPerformanceConfig()
-	perfMiddleware := PerformanceMiddleware(perfConfig)
+	perfMiddleware := PerformanceMiddleware(s.perfConfig)
 	for _, middleware := range perfMiddleware {
 		s.engine.Use(middleware)
 	}
@@ -132,36 +236,62 @@ func (s *Server) setupRoutes() {
 	// Additional middleware
 	s.engine.Use(LoggingMiddleware())
 	s.engine.Use(RecoveryMiddleware())
+	s.engine.Use(NamespaceMiddleware())
 
 	// Health and monitoring routes
+	GlobalHealthCache.SetTTL(s.perfConfig.HealthCacheTTL)
+	GlobalDetectionLimiter.SetLimit(s.perfConfig.MaxConcurrentDetections, s.perfConfig.DetectionQueueTimeout)
 	s.engine.GET("/health", HealthHandler)
 	s.engine.GET("/health/:component", ComponentHealthHandler)
 	s.engine.GET("/ready", ReadinessHandler)
+	s.engine.GET("/readyz", ReadinessHandler)
 	s.engine.GET("/alive", LivenessHandler)
 	s.engine.GET("/metrics", MetricsHandler)
+	s.engine.GET("/api/log-level", LogLevelHandler)
+	s.engine.POST("/api/log-level", SetLogLevelHandler)
+	s.engine.GET("/api/config", s.handleGetEffectiveConfig)
 
 	// Documentation routes
 	s.engine.GET("/api/docs", DocumentationHandler)
 	s.engine.GET("/api/docs/ui", SwaggerUIHandler)
 	s.engine.GET("/api/deployment", DeploymentInfoHandler)
+	s.engine.GET("/api/routes", s.handleListRoutes)
 
 	// Маршруты для оркестратора
 	s.engine.POST("/api/orchestrator/action", s.handleExecuteAction)
 	s.engine.POST("/api/orchestrator/actionplan", s.handleExecuteActionPlan)
+	s.engine.POST("/api/orchestrator/validate", s.handleValidateActionPlan)
 	s.engine.GET("/api/orchestrator/action/:id", s.handleGetAction)
 	s.engine.GET("/api/orchestrator/actions", s.handleListActions)
+	s.engine.GET("/api/orchestrator/remediation", s.handleGetRemediation)
+	s.engine.PUT("/api/orchestrator/remediation", s.handleSetRemediation)
+
+	// Alert ingestion routes
+	s.engine.POST("/api/alerts/alertmanager", s.handleAlertmanagerWebhook)
 
 	// NEW: Detector Management Routes
 	s.setupDetectorRoutes()
 
-	// NEW: Data Source Routes
-	if s.dataSourceAPI != nil {
-		dataSourceGroup := s.engine.Group("/api/datasources")
-		s.dataSourceAPI.SetupRoutes(dataSourceGroup)
-	}
+	// Anomaly acknowledgement and state routes
+	s.setupAnomalyRoutes()
+
+	// Saved query templates
+	s.setupSavedQueryRoutes()
 
 	// NEW: WebSocket Route
 	s.engine.GET("/api/ws", s.wsGateway.HandleWebSocket)
+
+	// Server-Sent Events alternative for clients that cannot use WebSockets
+	s.engine.GET("/api/events/stream", s.wsGateway.HandleEventStream)
+}
+
+// setupDataSourceRoutes mounts the data source API under /api/datasources
+func (s *Server) setupDataSourceRoutes() {
+	if s.dataSourceAPI == nil {
+		return
+	}
+
+	s.dataSourceAPI.SetupRoutes(s.engine.Group("/api/datasources"))
 }
 
 // setupPrometheusRoutes настраивает маршруты API для Prometheus
@@ -207,21 +337,34 @@ func (s *Server) setupDetectorRoutes() {
 	detectorsGroup := s.engine.Group("/api/detectors")
 	{
 		// CRUD Operations
-		detectorsGroup.POST("", s.handleCreateDetector)       // Create detector
-		detectorsGroup.GET("", s.handleListDetectors)         // List detectors with pagination
-		detectorsGroup.GET("/:id", s.handleGetDetector)       // Get specific detector
-		detectorsGroup.PUT("/:id", s.handleUpdateDetector)    // Update detector configuration
-		detectorsGroup.DELETE("/:id", s.handleDeleteDetector) // Delete detector
+		detectorsGroup.POST("", s.handleCreateDetector)               // Create detector
+		detectorsGroup.GET("", s.handleListDetectors)                 // List detectors with pagination
+		detectorsGroup.GET("/types", s.handleListDetectorTypes)       // Supported types and parameters
+		detectorsGroup.GET("/profiles", s.handleListDetectorProfiles) // Threshold/parameter presets
+		detectorsGroup.POST("/import", s.handleImportDetector)        // Recreate from an export document
+		detectorsGroup.POST("/compare", s.handleCompareDetectors)     // Run two configs over the same data
+		detectorsGroup.GET("/:id", s.handleGetDetector)               // Get specific detector
+		detectorsGroup.PUT("/:id", s.handleUpdateDetector)            // Update detector configuration
+		detectorsGroup.DELETE("/:id", s.handleDeleteDetector)         // Delete detector
 
 		// Detector Operations
-		detectorsGroup.POST("/:id/start", s.handleStartDetector)     // Start detector
-		detectorsGroup.POST("/:id/stop", s.handleStopDetector)       // Stop detector
-		detectorsGroup.GET("/:id/status", s.handleGetDetectorStatus) // Get real-time status
-		detectorsGroup.GET("/:id/health", s.handleGetDetectorHealth) // Get health metrics
+		detectorsGroup.POST("/:id/start", s.handleStartDetector)                  // Start detector
+		detectorsGroup.POST("/:id/stop", s.handleStopDetector)                    // Stop detector
+		detectorsGroup.POST("/:id/pause", s.handlePauseDetector)                  // Pause feeding, keep state
+		detectorsGroup.POST("/:id/resume", s.handleResumeDetector)                // Resume a paused detector
+		detectorsGroup.GET("/:id/status", s.handleGetDetectorStatus)              // Get real-time status
+		detectorsGroup.GET("/:id/health", s.handleGetDetectorHealth)              // Get health metrics
+		detectorsGroup.GET("/:id/export", s.handleExportDetector)                 // Portable config and model state
+		detectorsGroup.GET("/:id/score-histogram", s.handleGetScoreHistogram)     // Distribution of produced scores
+		detectorsGroup.GET("/:id/anomalies/timeline", s.handleGetAnomalyTimeline) // Anomaly counts per time bucket
 
 		// Detection Operations
-		detectorsGroup.POST("/:id/detect", s.handleRunDetection) // Run single detection
-		detectorsGroup.POST("/:id/train", s.handleTrainDetector) // Train detector
+		detectorsGroup.POST("/:id/detect", s.handleRunDetection)                     // Run single detection
+		detectorsGroup.POST("/:id/train", s.handleTrainDetector)                     // Train detector
+		detectorsGroup.POST("/:id/train-from-query", s.handleTrainDetectorFromQuery) // Train on a PromQL/LogQL series
+		detectorsGroup.POST("/:id/reset", s.handleResetDetector)                     // Clear learned state
+		detectorsGroup.POST("/:id/ingest", s.handleIngestDataPoints)                 // Push data points from any source
+		detectorsGroup.POST("/:id/self-test", s.handleSelfTestDetector)              // Check a spike is flagged on synthetic data
 	}
 }
 
@@ -231,13 +374,58 @@ func (s *Server) Start(addr string) error {
 	ctx := context.Background()
 	s.wsGateway.Start(ctx)
 
-	return s.engine.Run(addr)
+	// Refresh component health in the background
+	GlobalHealthCache.Start(ctx)
+
+	if s.detectorGC.ttl > 0 {
+		go s.runDetectorGC(ctx)
+	}
+
+	s.httpMutex.Lock()
+	s.httpServer = &http.Server{Addr: addr, Handler: s.engine}
+	httpServer := s.httpServer
+	s.httpMutex.Unlock()
+
+	return httpServer.ListenAndServe()
 }
 
-// Stop останавливает сервер API
+// Stop останавливает сервер API. Сначала останавливаются источники данных с
+// передачей буферизованных метрик, затем шлюз доставляет клиентам оставшиеся
+// события, и только после этого закрывается HTTP-сервер. Все шаги ограничены
+// сроком ctx. Число незавершенных запросов записывается в лог в начале
+// остановки и после их завершения (или истечения ctx).
 func (s *Server) Stop(ctx context.Context) error {
-	// В реальном сценарии здесь бы использовали graceful shutdown
-	return nil
+	started := logShutdownStart()
+	var errs []error
+
+	if s.dataSourceAPI != nil && s.dataSourceAPI.manager != nil {
+		if err := s.dataSourceAPI.manager.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("data sources: %w", err))
+		}
+	}
+
+	if err := s.wsGateway.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("websocket gateway: %w", err))
+	}
+
+	s.httpMutex.Lock()
+	httpServer := s.httpServer
+	s.httpMutex.Unlock()
+	if httpServer != nil {
+		if err := httpServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("http server: %w", err))
+		}
+	}
+
+	// After the HTTP server so anomalies of the last requests are persisted too
+	if s.anomalyWriter != nil {
+		if err := s.anomalyWriter.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	logShutdownDrain(ctx, started)
+
+	return errors.Join(errs...)
 }
 
 // handleExecuteAction обрабатывает запрос на выполнение одного действия
@@ -247,31 +435,150 @@ func (s *Server) handleExecuteAction(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	action.Namespace = namespaceOf(c)
 
 	result, err := s.orchestrator.ExecuteAction(c.Request.Context(), action)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondActionError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, result)
 }
 
-// handleExecuteActionPlan обрабатывает запрос на выполнение плана действий
+// handleExecuteActionPlan обрабатывает запрос на выполнение плана действий.
+// Параметры deadline и retry_budget ограничивают время и число повторов всего
+// плана; при их исчерпании план прерывается с частичным результатом.
 func (s *Server) handleExecuteActionPlan(c *gin.Context) {
 	var plan []orchestrator.Action
 	if err := c.ShouldBindJSON(&plan); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	namespace := namespaceOf(c)
+	for i := range plan {
+		plan[i].Namespace = namespace
+	}
+
+	// Бюджет плана: общий дедлайн и число повторов для всех действий
+	var budget orchestrator.PlanBudget
+	if deadlineStr := c.Query("deadline"); deadlineStr != "" {
+		deadline, err := time.ParseDuration(deadlineStr)
+		if err != nil || deadline <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid deadline: %s", deadlineStr)})
+			return
+		}
+		budget.Deadline = deadline
+	}
+	if retriesStr := c.Query("retry_budget"); retriesStr != "" {
+		retries, err := strconv.Atoi(retriesStr)
+		if err != nil || retries <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid retry_budget: %s", retriesStr)})
+			return
+		}
+		budget.MaxRetries = retries
+	}
+
+	executed, err := s.orchestrator.ExecuteActionPlanWithBudget(c.Request.Context(), plan, budget)
+	steps := actionPlanResults(executed)
 
-	err := s.orchestrator.ExecuteActionPlan(c.Request.Context(), plan)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		budgetExceeded := errors.Is(err, orchestrator.ErrPlanBudgetExceeded)
+		if budgetExceeded || errors.Is(err, orchestrator.ErrNotificationTimeout) || errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		} else if errors.Is(err, orchestrator.ErrRemediationDisabled) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"status": "failed", "error": err.Error(), "budget_exceeded": budgetExceeded, "actions": steps})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "success"})
+	c.JSON(http.StatusOK, gin.H{"status": "success", "actions": steps})
+}
+
+// ActionPlanStepResult описывает результат одного шага плана действий
+type ActionPlanStepResult struct {
+	Target   string                    `json:"target"`
+	Type     orchestrator.ActionType   `json:"type"`
+	Status   orchestrator.ActionStatus `json:"status"`
+	Success  bool                      `json:"success"`
+	Message  string                    `json:"message,omitempty"`
+	Attempts int                       `json:"attempts,omitempty"`
+	Duration string                    `json:"duration,omitempty"`
+}
+
+// actionPlanResults собирает результаты шагов плана в порядке запроса. Шаги,
+// которые не выполнялись (например, после ошибки зависимости), возвращаются
+// в статусе pending.
+func actionPlanResults(executed []orchestrator.Action) []ActionPlanStepResult {
+	steps := make([]ActionPlanStepResult, len(executed))
+	for i, action := range executed {
+		step := ActionPlanStepResult{
+			Target:  action.Target,
+			Type:    action.Type,
+			Status:  orchestrator.StatusPending,
+			Message: "not executed",
+		}
+
+		if action.Status != orchestrator.StatusPending {
+			step.Status = action.Status
+			step.Message = ""
+			step.Duration = action.UpdatedAt.Sub(action.CreatedAt).String()
+			if action.Result != nil {
+				step.Success = action.Result.Success
+				step.Message = action.Result.Message
+				step.Attempts = action.Result.Attempts
+			}
+		}
+		steps[i] = step
+	}
+	return steps
+}
+
+// handleValidateActionPlan проверяет план действий без выполнения и возвращает
+// результат проверки для каждого действия
+func (s *Server) handleValidateActionPlan(c *gin.Context) {
+	var plan []ActionRequest
+	if err := c.ShouldBindJSON(&plan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(plan) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty action plan"})
+		return
+	}
+
+	actions := make([]orchestrator.Action, len(plan))
+	parseErrors := make([][]string, len(plan))
+	for i, req := range plan {
+		actions[i], parseErrors[i] = req.toAction()
+	}
+
+	results := s.orchestrator.ValidateActionPlan(actions)
+	valid := true
+	for i := range results {
+		if len(parseErrors[i]) > 0 {
+			results[i].Errors = append(parseErrors[i], results[i].Errors...)
+			results[i].Valid = false
+		}
+		valid = valid && results[i].Valid
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": valid, "actions": results})
+}
+
+// respondActionError отвечает 504 для действий, не уложившихся в таймаут, и 500 для остальных ошибок
+func respondActionError(c *gin.Context, err error) {
+	if errors.Is(err, orchestrator.ErrNotificationTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		HandleError(c, NewAPIError(ErrorCodeTimeout, "Action timed out", err.Error()))
+		return
+	}
+	if errors.Is(err, orchestrator.ErrRemediationDisabled) {
+		HandleError(c, NewAPIError(ErrorCodeConflict, "Remediation is disabled", err.Error()))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
 
 // handleGetAction обрабатывает запрос на получение информации о действии
@@ -282,7 +589,7 @@ func (s *Server) handleGetAction(c *gin.Context) {
 		return
 	}
 
-	action, found := s.orchestrator.GetAction(id)
+	action, found := s.orchestrator.GetAction(namespaceOf(c), id)
 	if !found {
 		c.JSON(http.StatusNotFound, gin.H{"error": "action not found"})
 		return
@@ -292,9 +599,69 @@ func (s *Server) handleGetAction(c *gin.Context) {
 }
 
 // handleListActions обрабатывает запрос на получение списка действий
+// с пагинацией и фильтрацией по статусу и типу (новые действия первыми)
 func (s *Server) handleListActions(c *gin.Context) {
-	actions := s.orchestrator.ListActions()
-	c.JSON(http.StatusOK, actions)
+	// Parse pagination parameters
+	page := 1
+	limit := 10
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	// Filter parameters
+	actionType := c.Query("type")
+	status := c.Query("status")
+	namespace := namespaceOf(c)
+
+	allActions := s.orchestrator.ListActions()
+	filtered := make([]orchestrator.Action, 0, len(allActions))
+	for _, action := range allActions {
+		if action.Namespace != namespace {
+			continue
+		}
+		if actionType != "" && string(action.Type) != actionType {
+			continue
+		}
+		if status != "" && string(action.Status) != status {
+			continue
+		}
+		filtered = append(filtered, action)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].CreatedAt.After(filtered[j].CreatedAt)
+	})
+
+	// Apply pagination
+	total := len(filtered)
+	start := (page - 1) * limit
+	end := start + limit
+
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"actions": filtered[start:end],
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	})
 }
 
 // PrometheusCheckRequest представляет запрос на проверку аномалий Prometheus
@@ -305,6 +672,8 @@ type PrometheusCheckRequest struct {
 	WindowSize   int     `json:"window_size,omitempty"`
 	NumTrees     int     `json:"num_trees,omitempty"`
 	SampleSize   int     `json:"sample_size,omitempty"`
+	// Source - имя источника Prometheus (по умолчанию основной)
+	Source string `json:"source,omitempty"`
 }
 
 // handlePrometheusCheck обрабатывает запрос на проверку аномалий Prometheus
@@ -340,8 +709,14 @@ func (s *Server) handlePrometheusCheck(c *gin.Context) {
 		detectorConfig.Type = detector.TypeStatistical
 	}
 
+	promDetector, err := s.prometheusDetector(req.Source)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
 	// Выполняем проверку
-	anomalies, err := s.promDetector.RunAdHocCheck(c.Request.Context(), req.Query, detectorConfig)
+	anomalies, timings, err := promDetector.RunAdHocCheck(c.Request.Context(), req.Query, detectorConfig)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -351,10 +726,22 @@ func (s *Server) handlePrometheusCheck(c *gin.Context) {
 		"query":     req.Query,
 		"anomalies": anomalies,
 		"count":     len(anomalies),
+		"timings": gin.H{
+			"query_ms":  durationMs(timings.Query),
+			"detect_ms": durationMs(timings.Detect),
+			"total_ms":  durationMs(timings.Total),
+		},
 	})
 }
 
-// PrometheusAnalyzeRequest представляет запрос на анализ исторических данных Prometheus
+// durationMs возвращает длительность в миллисекундах с точностью до микросекунды
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// PrometheusAnalyzeRequest представляет запрос на анализ исторических данных Prometheus.
+// Пропущенные поля получают значения по умолчанию: end - текущее время,
+// start - end минус окно анализа, step - подбирается по диапазону.
 type PrometheusAnalyzeRequest struct {
 	Query        string    `json:"query"`
 	Start        time.Time `json:"start"`
@@ -365,6 +752,8 @@ type PrometheusAnalyzeRequest struct {
 	WindowSize   int       `json:"window_size,omitempty"`
 	NumTrees     int       `json:"num_trees,omitempty"`
 	SampleSize   int       `json:"sample_size,omitempty"`
+	// Source - имя источника Prometheus (по умолчанию основной)
+	Source string `json:"source,omitempty"`
 }
 
 // handlePrometheusAnalyze обрабатывает запрос на анализ исторических данных Prometheus
@@ -400,15 +789,43 @@ func (s *Server) handlePrometheusAnalyze(c *gin.Context) {
 		detectorConfig.Type = detector.TypeStatistical
 	}
 
-	// Преобразуем строку шага в длительность
-	step, err := time.ParseDuration(req.Step)
+	// Диапазон по умолчанию - последнее окно анализа
+	if req.End.IsZero() {
+		req.End = time.Now()
+	}
+	if req.Start.IsZero() {
+		req.Start = req.End.Add(-s.perfConfig.AnalyzeWindow)
+	}
+
+	if err := validateTimeRange(req.Start, req.End, s.perfConfig.MaxQueryRange); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	// Преобразуем строку шага в длительность, без шага подбираем его по диапазону
+	step := autoStep(req.Start, req.End, s.perfConfig.AnalyzeTargetPoints)
+	if req.Step != "" {
+		var err error
+		step, err = time.ParseDuration(req.Step)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid step: %s", err)})
+			return
+		}
+	}
+
+	if err := validatePointCount(req.Start, req.End, step, MaxAnalyzePoints); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	promDetector, err := s.prometheusDetector(req.Source)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid step: %s", err)})
+		HandleError(c, err)
 		return
 	}
 
 	// Выполняем анализ
-	anomalies, err := s.promDetector.AnalyzeHistoricalData(
+	anomalies, err := promDetector.AnalyzeHistoricalData(
 		c.Request.Context(),
 		req.Query,
 		detectorConfig,
@@ -425,7 +842,7 @@ func (s *Server) handlePrometheusAnalyze(c *gin.Context) {
 		"query":     req.Query,
 		"start":     req.Start,
 		"end":       req.End,
-		"step":      req.Step,
+		"step":      step.String(),
 		"anomalies": anomalies,
 		"count":     len(anomalies),
 	})
@@ -446,9 +863,15 @@ func (s *Server) handleGetLogAnomalies(c *gin.Context) {
 		return
 	}
 
-	// Здесь должна быть логика получения списка аномалий из детектора
-	// В текущей реализации это заглушка
-	anomalies := []detector.Anomaly{} // Получаем из детектора
+	// Ограничение количества возвращаемых аномалий (по умолчанию все в пределах хранения)
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	anomalies := s.logsDetector.GetRecentAnomalies(limit)
 
 	// Отправляем ответ
 	c.JSON(http.StatusOK, gin.H{
@@ -483,7 +906,14 @@ func (s *Server) handleAddLogPattern(c *gin.Context) {
 
 	// Добавляем шаблон
 	err := s.logsDetector.AddPattern(patternReq.Pattern, patternReq.Severity, patternReq.Description, patternReq.Labels)
-	if err != nil {
+	switch {
+	case errors.Is(err, detector.ErrInvalidPattern):
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Ошибка добавления шаблона: %v", err)})
+		return
+	case errors.Is(err, detector.ErrTooManyPatterns):
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Ошибка добавления шаблона: %v", err)})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Ошибка добавления шаблона: %v", err)})
 		return
 	}
@@ -541,6 +971,11 @@ func (s *Server) handleQueryLoki(c *gin.Context) {
 		end = time.Unix(endUnix, 0)
 	}
 
+	if err := validateTimeRange(start, end, s.perfConfig.MaxQueryRange); err != nil {
+		HandleError(c, err)
+		return
+	}
+
 	// Выполняем запрос к Loki
 	streams, err := s.logsDetector.QueryLoki(c.Request.Context(), query, start, end)
 	if err != nil {
@@ -576,6 +1011,12 @@ func (s *Server) handleGetLogDetectorInfo(c *gin.Context) {
 		"patternCount":     s.logsDetector.GetPatternCount(),
 	}
 
+	retention, maxAnomalies := s.logsDetector.GetAnomalyRetention()
+	info["anomalyRetention"] = retention.String()
+	info["maxAnomalies"] = maxAnomalies
+	info["maxPatterns"] = s.logsDetector.GetMaxPatterns()
+	info["thresholdOverrides"] = s.logsDetector.GetThresholdOverrides()
+
 	// Отправляем ответ
 	c.JSON(http.StatusOK, info)
 }
@@ -584,20 +1025,40 @@ func (s *Server) handleGetLogDetectorInfo(c *gin.Context) {
 func (s *Server) handleCreateDetector(c *gin.Context) {
 	var req DetectorRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleBindingError(c, err, &req)
+		return
+	}
+
+	detectorType := req.Config.Type
+	if detectorType == "" {
+		detectorType = req.Type
+	}
+	if err := detector.ValidateParameters(detectorType, req.Config.Parameters); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Create detector instance
 	detectorInstance, err := s.createDetectorInstance(req)
+	if isInvalidDetectorRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Store in manager
+	// Store in manager, in the caller's namespace
+	detectorInstance.Namespace = namespaceOf(c)
 	s.detectorManager.mu.Lock()
-	s.detectorManager.detectors[detectorInstance.ID] = detectorInstance
+	if err := s.detectorManager.validateGate(detectorInstance.Namespace, detectorInstance.ID, detectorInstance.GatedBy); err != nil {
+		s.detectorManager.mu.Unlock()
+		releaseDetector(detectorInstance)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.detectorManager.add(detectorInstance)
 	s.detectorManager.mu.Unlock()
 
 	// Send WebSocket event
@@ -606,6 +1067,7 @@ func (s *Server) handleCreateDetector(c *gin.Context) {
 		Topic:     TopicDetectors,
 		Data:      detectorInstance,
 		Timestamp: time.Now(),
+		Namespace: detectorInstance.Namespace,
 	})
 
 	// Return created detector
@@ -635,10 +1097,14 @@ func (s *Server) handleListDetectors(c *gin.Context) {
 	detectorType := c.Query("type")
 	status := c.Query("status")
 
+	namespace := namespaceOf(c)
 	s.detectorManager.mu.RLock()
 	allDetectors := make([]*DetectorInstance, 0, len(s.detectorManager.detectors))
 	for _, detector := range s.detectorManager.detectors {
 		// Apply filters
+		if detector.Namespace != namespace {
+			continue
+		}
 		if detectorType != "" && string(detector.Type) != detectorType {
 			continue
 		}
@@ -680,12 +1146,21 @@ func (s *Server) handleListDetectors(c *gin.Context) {
 	})
 }
 
+// handleListDetectorTypes returns supported detector types with their parameters
+func (s *Server) handleListDetectorTypes(c *gin.Context) {
+	types := detector.DetectorTypes()
+	c.JSON(http.StatusOK, gin.H{
+		"types": types,
+		"count": len(types),
+	})
+}
+
 // handleGetDetector returns a specific detector by ID
 func (s *Server) handleGetDetector(c *gin.Context) {
 	id := c.Param("id")
 
 	s.detectorManager.mu.RLock()
-	detectorInstance, exists := s.detectorManager.detectors[id]
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
 	s.detectorManager.mu.RUnlock()
 
 	if !exists {
@@ -704,12 +1179,13 @@ func (s *Server) handleGetDetector(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// handleUpdateDetector updates an existing detector configuration
+// handleUpdateDetector updates an existing detector configuration; with
+// ?dry_run=true it returns the changes the update would make without applying them
 func (s *Server) handleUpdateDetector(c *gin.Context) {
 	id := c.Param("id")
 
 	s.detectorManager.mu.Lock()
-	detectorInstance, exists := s.detectorManager.detectors[id]
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
 	if !exists {
 		s.detectorManager.mu.Unlock()
 		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
@@ -718,11 +1194,57 @@ func (s *Server) handleUpdateDetector(c *gin.Context) {
 
 	var req DetectorRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		s.detectorManager.mu.Unlock()
+		HandleBindingError(c, err, &req)
+		return
+	}
+
+	if err := validateCallbackURL(req.CallbackURL); err != nil {
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validatePayloadFormat(req.PayloadFormat); err != nil {
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateScoreBuckets(req.ScoreBuckets); err != nil {
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := detector.ValidateParameters(detectorInstance.Type, req.Config.Parameters); err != nil {
 		s.detectorManager.mu.Unlock()
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	gateWindow, err := parseGateWindow(req.GateWindow)
+	if err == nil {
+		err = s.detectorManager.validateGate(detectorInstance.Namespace, detectorInstance.ID, req.GatedBy)
+	}
+	var escalationSteps []escalationStep
+	if err == nil {
+		escalationSteps, err = parseEscalation(req.Escalation)
+	}
+	if err != nil {
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A dry run only reports what the update would change
+	if c.Query("dry_run") == "true" {
+		preview := previewDetectorUpdate(detectorInstance, &req)
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusOK, preview)
+		return
+	}
+
 	// Update detector configuration
 	if configurable, ok := detectorInstance.Detector.(detector.ConfigurableDetector); ok {
 		if err := configurable.Configure(req.Config); err != nil {
@@ -735,6 +1257,22 @@ func (s *Server) handleUpdateDetector(c *gin.Context) {
 	// Update instance metadata
 	detectorInstance.Name = req.Name
 	detectorInstance.Config = req.Config
+	detectorInstance.CallbackURL = req.CallbackURL
+	detectorInstance.PayloadFormat = req.PayloadFormat
+	detectorInstance.BootstrapFromCache = req.BootstrapFromCache
+	detectorInstance.GatedBy = req.GatedBy
+	detectorInstance.GateWindow = req.GateWindow
+	detectorInstance.gateWindow = gateWindow
+	detectorInstance.Escalation = req.Escalation
+	detectorInstance.escalationSteps = escalationSteps
+	detectorInstance.Shadow = req.Shadow
+	detectorInstance.Tags = req.Tags
+	detectorInstance.Labels = req.Labels
+	if !equalScoreBuckets(detectorInstance.ScoreBuckets, req.ScoreBuckets) {
+		// Counts cannot be moved between different buckets, start over
+		detectorInstance.ScoreBuckets = req.ScoreBuckets
+		detectorInstance.scores = newScoreHistogram(req.ScoreBuckets)
+	}
 	detectorInstance.UpdatedAt = time.Now()
 
 	s.detectorManager.mu.Unlock()
@@ -748,22 +1286,31 @@ func (s *Server) handleDeleteDetector(c *gin.Context) {
 	id := c.Param("id")
 
 	s.detectorManager.mu.Lock()
-	detectorInstance, exists := s.detectorManager.detectors[id]
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
 	if !exists {
 		s.detectorManager.mu.Unlock()
 		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
 		return
 	}
 
+	// Detectors gated by this one would stay suppressed forever
+	if gated := s.detectorManager.gatedDetectors(detectorInstance.Namespace, id); len(gated) > 0 {
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": ErrDetectorGatesOthers.Error(), "gated_detectors": gated})
+		return
+	}
+
 	// Stop detector if running
 	if detectorInstance.Status == "running" {
 		detectorInstance.Status = "stopped"
 	}
 
 	// Remove from manager
-	delete(s.detectorManager.detectors, id)
+	s.detectorManager.remove(detectorInstance)
 	s.detectorManager.mu.Unlock()
 
+	releaseDetector(detectorInstance)
+
 	c.JSON(http.StatusOK, gin.H{"message": "detector deleted successfully"})
 }
 
@@ -772,7 +1319,7 @@ func (s *Server) handleStartDetector(c *gin.Context) {
 	id := c.Param("id")
 
 	s.detectorManager.mu.Lock()
-	detectorInstance, exists := s.detectorManager.detectors[id]
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
 	if !exists {
 		s.detectorManager.mu.Unlock()
 		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
@@ -789,10 +1336,17 @@ func (s *Server) handleStartDetector(c *gin.Context) {
 	detectorInstance.UpdatedAt = time.Now()
 	s.detectorManager.mu.Unlock()
 
-	c.JSON(http.StatusOK, gin.H{
+	armDetector(detectorInstance)
+
+	response := gin.H{
 		"message": "detector started successfully",
 		"status":  "running",
-	})
+	}
+	if samples := s.bootstrapFromCache(detectorInstance); samples > 0 {
+		response["bootstrapped_samples"] = samples
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // handleStopDetector stops a detector instance
@@ -800,7 +1354,7 @@ func (s *Server) handleStopDetector(c *gin.Context) {
 	id := c.Param("id")
 
 	s.detectorManager.mu.Lock()
-	detectorInstance, exists := s.detectorManager.detectors[id]
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
 	if !exists {
 		s.detectorManager.mu.Unlock()
 		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
@@ -817,12 +1371,93 @@ func (s *Server) handleStopDetector(c *gin.Context) {
 	})
 }
 
+// handlePauseDetector temporarily stops the pipeline from feeding a detector.
+// Unlike stop, the learned state is kept and resume returns it to running.
+func (s *Server) handlePauseDetector(c *gin.Context) {
+	id := c.Param("id")
+
+	s.detectorManager.mu.Lock()
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
+	if !exists {
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
+		return
+	}
+
+	if detectorInstance.Status == "paused" {
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": "detector already paused"})
+		return
+	}
+
+	if detectorInstance.Status != "running" {
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": "only running detectors can be paused"})
+		return
+	}
+
+	detectorInstance.Status = "paused"
+	detectorInstance.UpdatedAt = time.Now()
+	s.detectorManager.mu.Unlock()
+
+	s.wsGateway.SendEvent(Event{
+		Type:      EventDetectorUpdated,
+		Topic:     TopicDetectors,
+		Data:      gin.H{"id": id, "action": "pause", "status": "paused"},
+		Timestamp: time.Now(),
+		Namespace: detectorInstance.Namespace,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "detector paused successfully",
+		"status":  "paused",
+	})
+}
+
+// handleResumeDetector returns a paused detector to running
+func (s *Server) handleResumeDetector(c *gin.Context) {
+	id := c.Param("id")
+
+	s.detectorManager.mu.Lock()
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
+	if !exists {
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
+		return
+	}
+
+	if detectorInstance.Status != "paused" {
+		s.detectorManager.mu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": "detector is not paused"})
+		return
+	}
+
+	detectorInstance.Status = "running"
+	detectorInstance.UpdatedAt = time.Now()
+	s.detectorManager.mu.Unlock()
+
+	armDetector(detectorInstance)
+
+	s.wsGateway.SendEvent(Event{
+		Type:      EventDetectorUpdated,
+		Topic:     TopicDetectors,
+		Data:      gin.H{"id": id, "action": "resume", "status": "running"},
+		Timestamp: time.Now(),
+		Namespace: detectorInstance.Namespace,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "detector resumed successfully",
+		"status":  "running",
+	})
+}
+
 // handleGetDetectorStatus returns real-time status of a detector
 func (s *Server) handleGetDetectorStatus(c *gin.Context) {
 	id := c.Param("id")
 
 	s.detectorManager.mu.RLock()
-	detectorInstance, exists := s.detectorManager.detectors[id]
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
 	s.detectorManager.mu.RUnlock()
 
 	if !exists {
@@ -837,6 +1472,7 @@ func (s *Server) handleGetDetectorStatus(c *gin.Context) {
 		"status":     detectorInstance.Status,
 		"updated_at": detectorInstance.UpdatedAt,
 		"metrics":    detectorInstance.Metrics,
+		"shadow":     detectorInstance.Shadow,
 	}
 
 	// Add statistics if available
@@ -844,6 +1480,18 @@ func (s *Server) handleGetDetectorStatus(c *gin.Context) {
 		status["statistics"] = configurable.GetStatistics()
 	}
 
+	// Warmup progress tells whether the detector's verdicts can be trusted yet
+	if warmup, ok := detectorInstance.Detector.(detector.WarmupDetector); ok {
+		progress, _ := detector.WarmupProgress(warmup)
+		current, required := warmup.GetWarmupProgress()
+		status["warmup"] = gin.H{
+			"progress":  progress,
+			"samples":   current,
+			"required":  required,
+			"warmed_up": warmup.IsWarmedUp(),
+		}
+	}
+
 	c.JSON(http.StatusOK, status)
 }
 
@@ -852,7 +1500,7 @@ func (s *Server) handleGetDetectorHealth(c *gin.Context) {
 	id := c.Param("id")
 
 	s.detectorManager.mu.RLock()
-	detectorInstance, exists := s.detectorManager.detectors[id]
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
 	s.detectorManager.mu.RUnlock()
 
 	if !exists {
@@ -884,7 +1532,7 @@ func (s *Server) handleRunDetection(c *gin.Context) {
 	id := c.Param("id")
 
 	s.detectorManager.mu.RLock()
-	detectorInstance, exists := s.detectorManager.detectors[id]
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
 	s.detectorManager.mu.RUnlock()
 
 	if !exists {
@@ -898,10 +1546,23 @@ func (s *Server) handleRunDetection(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		HandleBindingError(c, err, &request)
+		return
+	}
+
+	if !checkValuesLimit(c, "values", len(request.Values), s.perfConfig.MaxTrainingValues) {
+		return
+	}
+	if !checkFiniteValues(c, "value", []float64{request.Value}) || !checkFiniteValues(c, "values", request.Values) {
 		return
 	}
 
+	release, ok := acquireDetectionSlot(c)
+	if !ok {
+		return
+	}
+	defer release()
+
 	// Run detection
 	start := time.Now()
 
@@ -913,23 +1574,45 @@ func (s *Server) handleRunDetection(c *gin.Context) {
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
+		var suppressedBy string
+		if isAnomaly {
+			if gatedBy, open := s.gateOpen(detectorInstance); !open {
+				isAnomaly, suppressedBy = false, gatedBy
+			}
+		}
+
+		result := gin.H{
 			"detector_id":    id,
 			"is_anomaly":     isAnomaly,
 			"anomaly_score":  score,
 			"values":         request.Values,
 			"detection_time": time.Since(start).Milliseconds(),
-		})
+		}
+		if suppressedBy != "" {
+			result["suppressed_by_gate"] = suppressedBy
+		}
+
+		// Attach baseline context for triage
+		if explainable, ok := detectorInstance.Detector.(detector.ExplainableDetector); ok {
+			result["details"] = explainable.Explain(request.Values[len(request.Values)-1])
+		}
+
+		s.respondJSON(c, http.StatusOK, result)
 	} else {
 		// Use Detect for single value
-		anomaly, err := detectorInstance.Detector.Detect(c.Request.Context(), request.Value)
+		anomaly, score, scored, err := traceDetect(c.Request.Context(), detectorInstance.Detector, request.Value)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
+		// A gated detector only reports anomalies while its gating detector has one
+		anomaly, suppressedBy := s.gateAnomaly(detectorInstance, anomaly)
+		s.escalateAnomaly(detectorInstance, anomaly, start)
+
 		// Update metrics
-		s.updateDetectorMetrics(detectorInstance, anomaly != nil, time.Since(start))
+		s.updateDetectorMetrics(detectorInstance, anomaly != nil, score, scored, time.Since(start))
+		s.publishScore(detectorInstance, request.Value, score, scored, anomaly != nil, start)
 
 		result := gin.H{
 			"detector_id":    id,
@@ -939,12 +1622,17 @@ func (s *Server) handleRunDetection(c *gin.Context) {
 
 		if anomaly != nil {
 			result["anomaly"] = anomaly
+			result["details"] = anomaly.Details
 			result["is_anomaly"] = true
+			s.notifyDetectorCallback(detectorInstance, request.Value, anomaly)
 		} else {
 			result["is_anomaly"] = false
+			if suppressedBy != "" {
+				result["suppressed_by_gate"] = suppressedBy
+			}
 		}
 
-		c.JSON(http.StatusOK, result)
+		s.respondJSON(c, http.StatusOK, result)
 	}
 }
 
@@ -953,7 +1641,7 @@ func (s *Server) handleTrainDetector(c *gin.Context) {
 	id := c.Param("id")
 
 	s.detectorManager.mu.RLock()
-	detectorInstance, exists := s.detectorManager.detectors[id]
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
 	s.detectorManager.mu.RUnlock()
 
 	if !exists {
@@ -973,7 +1661,7 @@ func (s *Server) handleTrainDetector(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		HandleBindingError(c, err, &request)
 		return
 	}
 
@@ -982,9 +1670,16 @@ func (s *Server) handleTrainDetector(c *gin.Context) {
 		return
 	}
 
+	if !checkValuesLimit(c, "values", len(request.Values), s.perfConfig.MaxTrainingValues) {
+		return
+	}
+	if !checkFiniteValues(c, "values", request.Values) {
+		return
+	}
+
 	// Train detector
 	start := time.Now()
-	if err := trainable.Train(request.Values); err != nil {
+	if err := traceTrain(c.Request.Context(), trainable, request.Values); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1001,14 +1696,161 @@ func (s *Server) handleTrainDetector(c *gin.Context) {
 	})
 }
 
+// NotifyDetection forwards an anomaly found outside the HTTP handlers (e.g. by the
+// live metrics pipeline) to the detector's callback URL, if one is configured
+func (s *Server) NotifyDetection(detectorID string, value float64, anomaly *detector.Anomaly) {
+	s.detectorManager.mu.RLock()
+	detectorInstance, exists := s.detectorManager.lookup(detectorID)
+	s.detectorManager.mu.RUnlock()
+
+	if !exists || anomaly == nil {
+		return
+	}
+
+	s.notifyDetectorCallback(detectorInstance, value, anomaly)
+}
+
+// notifyDetectorCallback records the anomaly in the anomaly store, broadcasts it to
+// WebSocket clients and POSTs it to the detector's callback URL in the background.
+// Anomalies of shadow detectors are only recorded.
+func (s *Server) notifyDetectorCallback(instance *DetectorInstance, value float64, anomaly *detector.Anomaly) {
+	s.detectorManager.mu.RLock()
+	shadow := instance.Shadow
+	callbackURL := instance.CallbackURL
+	payload := DetectorWebhookPayload{
+		DetectorID:   instance.ID,
+		DetectorName: instance.Name,
+		DetectorType: instance.Type,
+		Value:        value,
+		Anomaly:      anomaly,
+		Timestamp:    time.Now(),
+		Format:       instance.PayloadFormat,
+	}
+	labels := instance.Labels
+	s.detectorManager.mu.RUnlock()
+
+	attachLabels(anomaly, labels)
+	s.enricher.Enrich(anomaly)
+
+	if shadow {
+		s.anomalyStore.AddShadow(instance.Namespace, payload.DetectorID, payload.DetectorName, value, anomaly)
+
+		s.detectorManager.mu.Lock()
+		instance.Metrics.SuppressedAlerts++
+		s.detectorManager.mu.Unlock()
+		return
+	}
+
+	record := s.anomalyStore.Add(instance.Namespace, payload.DetectorID, payload.DetectorName, value, anomaly)
+	payload.AnomalyID = record.ID
+
+	s.wsGateway.SendEvent(Event{
+		Type:      EventAnomalyDetected,
+		Topic:     TopicAnomalies,
+		Data:      record,
+		Timestamp: time.Now(),
+		Namespace: record.Namespace,
+	})
+
+	if callbackURL == "" {
+		return
+	}
+
+	s.webhookNotifier.NotifyAsync(callbackURL, payload)
+}
+
+// handleResetDetector clears a detector's learned state keeping its ID and configuration
+func (s *Server) handleResetDetector(c *gin.Context) {
+	id := c.Param("id")
+
+	s.detectorManager.mu.RLock()
+	detectorInstance, exists := s.detectorManager.get(namespaceOf(c), id)
+	s.detectorManager.mu.RUnlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "detector not found"})
+		return
+	}
+
+	resettable, ok := detectorInstance.Detector.(detector.ResettableDetector)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "detector does not support reset"})
+		return
+	}
+
+	resettable.Reset()
+
+	s.detectorManager.mu.Lock()
+	detectorInstance.scores = newScoreHistogram(detectorInstance.ScoreBuckets)
+	detectorInstance.UpdatedAt = time.Now()
+	s.detectorManager.mu.Unlock()
+
+	s.wsGateway.SendEvent(Event{
+		Type:      EventDetectorUpdated,
+		Topic:     TopicDetectors,
+		Data:      gin.H{"id": id, "action": "reset"},
+		Timestamp: time.Now(),
+		Namespace: detectorInstance.Namespace,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "detector reset successfully",
+		"id":      id,
+	})
+}
+
+// ErrInvalidDetectorRequest is returned for a detector request with an invalid
+// callback URL, payload format or score buckets
+var ErrInvalidDetectorRequest = errors.New("invalid detector request")
+
+// isInvalidDetectorRequest reports whether an error of createDetectorInstance
+// is caused by the request rather than by the server
+func isInvalidDetectorRequest(err error) bool {
+	return errors.Is(err, ErrInvalidDetectorRequest) || errors.Is(err, ErrUnknownProfile) ||
+		errors.Is(err, ErrInvalidGate) || errors.Is(err, ErrInvalidEscalation)
+}
+
 // createDetectorInstance creates a new detector instance from request
 func (s *Server) createDetectorInstance(req DetectorRequest) (*DetectorInstance, error) {
+	if err := validateCallbackURL(req.CallbackURL); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDetectorRequest, err)
+	}
+	if err := validatePayloadFormat(req.PayloadFormat); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDetectorRequest, err)
+	}
+	if err := validateScoreBuckets(req.ScoreBuckets); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDetectorRequest, err)
+	}
+	gateWindow, err := parseGateWindow(req.GateWindow)
+	if err != nil {
+		return nil, err
+	}
+	escalationSteps, err := parseEscalation(req.Escalation)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.applyProfile(req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create detector using factory
-	detectorImpl, err := detector.NewDetector(req.Config)
+	detectorImpl, err := detector.NewDetector(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create detector: %w", err)
 	}
 
+	// The factory only reads the threshold and legacy fields, profile parameters
+	// are applied through Configure
+	if req.Profile != "" {
+		if configurable, ok := detectorImpl.(detector.ConfigurableDetector); ok {
+			if err := configurable.Configure(config); err != nil {
+				return nil, fmt.Errorf("failed to apply profile: %w", err)
+			}
+		}
+	}
+
 	// Generate unique ID
 	s.detectorManager.mu.Lock()
 	id := fmt.Sprintf("detector_%d", s.detectorManager.nextID)
@@ -1017,22 +1859,83 @@ func (s *Server) createDetectorInstance(req DetectorRequest) (*DetectorInstance,
 
 	// Create instance
 	instance := &DetectorInstance{
-		ID:        id,
-		Name:      req.Name,
-		Type:      req.Type,
-		Status:    "stopped",
-		Config:    req.Config,
-		Detector:  detectorImpl,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Metrics:   DetectorMetrics{},
+		ID:                 id,
+		Name:               req.Name,
+		Type:               req.Type,
+		Status:             "stopped",
+		Config:             config,
+		Detector:           detectorImpl,
+		CallbackURL:        req.CallbackURL,
+		PayloadFormat:      req.PayloadFormat,
+		ScoreBuckets:       req.ScoreBuckets,
+		Tags:               req.Tags,
+		Labels:             req.Labels,
+		Profile:            req.Profile,
+		BootstrapFromCache: req.BootstrapFromCache,
+		GatedBy:            req.GatedBy,
+		GateWindow:         req.GateWindow,
+		gateWindow:         gateWindow,
+		Escalation:         req.Escalation,
+		escalationSteps:    escalationSteps,
+		Shadow:             req.Shadow,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+		Metrics:            DetectorMetrics{},
+		scores:             newScoreHistogram(req.ScoreBuckets),
+	}
+
+	// Detectors such as deadman raise anomalies from a timer instead of Detect
+	if background, ok := detectorImpl.(detector.BackgroundDetector); ok {
+		background.SetAnomalyHandler(func(anomaly *detector.Anomaly) bool {
+			return s.handleBackgroundAnomaly(instance, anomaly)
+		})
 	}
 
 	return instance, nil
 }
 
-// updateDetectorMetrics updates runtime metrics for a detector
-func (s *Server) updateDetectorMetrics(instance *DetectorInstance, anomalyDetected bool, duration time.Duration) {
+// handleBackgroundAnomaly forwards an anomaly raised by a BackgroundDetector and
+// reports whether it was delivered. Anomalies of detectors that are not running
+// or that are suppressed by a gate are not delivered; the detector raises them again.
+func (s *Server) handleBackgroundAnomaly(instance *DetectorInstance, anomaly *detector.Anomaly) bool {
+	s.detectorManager.mu.RLock()
+	running := instance.Status == "running"
+	s.detectorManager.mu.RUnlock()
+
+	if !running {
+		return false
+	}
+
+	if anomaly, _ = s.gateAnomaly(instance, anomaly); anomaly == nil {
+		return false
+	}
+
+	s.detectorManager.mu.Lock()
+	now := time.Now()
+	instance.Metrics.LastAnomaly = &now
+	s.detectorManager.mu.Unlock()
+
+	s.notifyDetectorCallback(instance, anomaly.Value, anomaly)
+	return true
+}
+
+// armDetector restarts the background checks of a detector that starts running
+func armDetector(instance *DetectorInstance) {
+	if background, ok := instance.Detector.(detector.BackgroundDetector); ok {
+		background.Arm()
+	}
+}
+
+// releaseDetector stops background work of a detector that is being removed
+func releaseDetector(instance *DetectorInstance) {
+	if background, ok := instance.Detector.(detector.BackgroundDetector); ok {
+		background.Stop()
+	}
+}
+
+// updateDetectorMetrics updates runtime metrics for a detector. The score is
+// added to the score histogram when scored is true.
+func (s *Server) updateDetectorMetrics(instance *DetectorInstance, anomalyDetected bool, score float64, scored bool, duration time.Duration) {
 	s.detectorManager.mu.Lock()
 	defer s.detectorManager.mu.Unlock()
 
@@ -1041,12 +1944,22 @@ func (s *Server) updateDetectorMetrics(instance *DetectorInstance, anomalyDetect
 		instance.Metrics.AnomaliesFound++
 	}
 
+	if scored {
+		if instance.scores == nil {
+			instance.scores = newScoreHistogram(instance.ScoreBuckets)
+		}
+		instance.scores.observe(score)
+	}
+
 	if instance.Metrics.TotalDetections > 0 {
 		instance.Metrics.AnomalyRate = float64(instance.Metrics.AnomaliesFound) / float64(instance.Metrics.TotalDetections)
 	}
 
 	now := time.Now()
 	instance.Metrics.LastDetection = &now
+	if anomalyDetected {
+		instance.Metrics.LastAnomaly = &now
+	}
 
 	// Update average response time
 	newResponseTime := float64(duration.Milliseconds())
@@ -1057,3 +1970,41 @@ func (s *Server) updateDetectorMetrics(instance *DetectorInstance, anomalyDetect
 		instance.Metrics.AvgResponseTime = (instance.Metrics.AvgResponseTime + newResponseTime) / 2
 	}
 }
+
+// DetectorScoreEvent is the data of a detector_score event, sent on the
+// detector's topic for every detection
+type DetectorScoreEvent struct {
+	DetectorID string  `json:"detector_id"`
+	Value      float64 `json:"value"`
+	// Score is omitted when the detector has no score yet
+	Score     *float64  `json:"score,omitempty"`
+	IsAnomaly bool      `json:"is_anomaly"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// publishScore sends the result of a detection to the clients subscribed to
+// the detector's topic. Nothing is queued while nobody is subscribed.
+func (s *Server) publishScore(instance *DetectorInstance, value, score float64, scored, isAnomaly bool, timestamp time.Time) {
+	topic := DetectorTopic(instance.ID)
+	if !s.wsGateway.HasSubscribers(instance.Namespace, topic) {
+		return
+	}
+
+	data := DetectorScoreEvent{
+		DetectorID: instance.ID,
+		Value:      value,
+		IsAnomaly:  isAnomaly,
+		Timestamp:  timestamp,
+	}
+	if scored {
+		data.Score = &score
+	}
+
+	s.wsGateway.SendEvent(Event{
+		Type:      EventDetectorScore,
+		Topic:     topic,
+		Data:      data,
+		Timestamp: time.Now(),
+		Namespace: instance.Namespace,
+	})
+}
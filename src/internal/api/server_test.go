@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestDeadmanDetector_SilentBeforeStart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{
+		detectorManager: newDetectorManager(),
+		wsGateway:       NewWebSocketGateway(),
+		anomalyStore:    NewAnomalyStore(10),
+	}
+	instance, err := s.createDetectorInstance(DetectorRequest{
+		Name: "heartbeat",
+		Type: detector.TypeDeadman,
+		Config: detector.DetectorConfig{
+			Type:       detector.TypeDeadman,
+			Parameters: map[string]interface{}{"maxGap": "30ms"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+	defer releaseDetector(instance)
+	instance.Namespace = DefaultNamespace
+	s.detectorManager.add(instance)
+
+	// The source is silent while the detector is still stopped
+	time.Sleep(80 * time.Millisecond)
+	if count := len(s.anomalyStore.List(AnomalyFilter{}, 0)); count != 0 {
+		t.Fatalf("expected no anomaly while stopped, got %d", count)
+	}
+
+	router := gin.New()
+	router.Use(NamespaceMiddleware())
+	router.POST("/api/detectors/:id/start", s.handleStartDetector)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/detectors/"+instance.ID+"/start", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(s.anomalyStore.List(AnomalyFilter{}, 0)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected a missing-data anomaly after the detector was started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHandlePauseResumeDetector(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	s.detectorManager.add(&DetectorInstance{ID: "detector_2", Name: "stopped", Status: "stopped"})
+
+	router := gin.New()
+	router.Use(NamespaceMiddleware())
+	router.POST("/api/detectors/:id/pause", s.handlePauseDetector)
+	router.POST("/api/detectors/:id/resume", s.handleResumeDetector)
+
+	post := func(id, action string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/detectors/"+id+"/"+action, nil))
+		return w.Code
+	}
+	pipeline := &pipelineDetector{server: s, id: "detector_1"}
+
+	if code := post("detector_1", "pause"); code != http.StatusOK {
+		t.Fatalf("expected 200 pausing a running detector, got %d", code)
+	}
+	if status := pipeline.GetStatus(); status != "paused" {
+		t.Errorf("expected the pipeline to see the detector paused, got %q", status)
+	}
+	if _, err := s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{{Value: 50}}); !errors.Is(err, ErrDetectorPaused) {
+		t.Errorf("expected a paused detector to reject data, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		id, action string
+		code       int
+	}{
+		{"detector_1", "pause", http.StatusConflict},
+		{"detector_2", "pause", http.StatusConflict},
+		{"detector_2", "resume", http.StatusConflict},
+		{"missing", "pause", http.StatusNotFound},
+		{"missing", "resume", http.StatusNotFound},
+	} {
+		if code := post(tc.id, tc.action); code != tc.code {
+			t.Errorf("%s %s: expected %d, got %d", tc.action, tc.id, tc.code, code)
+		}
+	}
+
+	// Resuming keeps the detector and returns it to running
+	if code := post("detector_1", "resume"); code != http.StatusOK {
+		t.Fatalf("expected 200 resuming a paused detector, got %d", code)
+	}
+	if status := pipeline.GetStatus(); status != "running" {
+		t.Errorf("expected the detector to be running again, got %q", status)
+	}
+	result, err := s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{{Value: 50}})
+	if err != nil || len(result.Anomalies) != 1 {
+		t.Errorf("expected the resumed detector to detect again, got %+v, %v", result, err)
+	}
+	if code := post("detector_1", "resume"); code != http.StatusConflict {
+		t.Errorf("expected 409 resuming a running detector, got %d", code)
+	}
+}
+
+func TestHandlePrometheusCheck_Timings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const queryDelay = 30 * time.Millisecond
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(queryDelay)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[`+
+			`{"metric":{"__name__":"up","job":"api"},"value":[%d,"1"]}]}}`, time.Now().Unix())
+	}))
+	defer prometheus.Close()
+
+	promDetector, err := detector.NewPrometheusAnomalyDetector(prometheus.URL, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create Prometheus detector: %v", err)
+	}
+	s := &Server{promDetector: promDetector}
+	router := gin.New()
+	router.POST("/api/prometheus/check", s.handlePrometheusCheck)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/prometheus/check",
+		strings.NewReader(`{"query": "up", "detector_type": "statistical", "threshold": 3}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Timings struct {
+			QueryMs  float64 `json:"query_ms"`
+			DetectMs float64 `json:"detect_ms"`
+			TotalMs  float64 `json:"total_ms"`
+		} `json:"timings"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	timings := resp.Timings
+	if timings.QueryMs < float64(queryDelay.Milliseconds()) {
+		t.Errorf("expected the query phase to include the %s backend latency, got %gms", queryDelay, timings.QueryMs)
+	}
+	if timings.DetectMs < 0 || timings.TotalMs < timings.QueryMs+timings.DetectMs {
+		t.Errorf("expected the total to cover both phases, got %+v", timings)
+	}
+}
+
+func TestDurationMs(t *testing.T) {
+	if got := durationMs(1500 * time.Microsecond); got != 1.5 {
+		t.Errorf("expected 1.5ms, got %g", got)
+	}
+	if got := durationMs(999 * time.Nanosecond); got != 0 {
+		t.Errorf("expected sub-microsecond durations to round down to 0, got %g", got)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+// EventAnomalySeverityChanged is sent on TopicAnomalies when a detector's
+// anomalies escalate to a higher severity
+const EventAnomalySeverityChanged = "anomaly_severity_changed"
+
+// ErrInvalidEscalation is returned for escalation steps with an invalid
+// duration or an unknown severity
+var ErrInvalidEscalation = errors.New("invalid severity escalation")
+
+// severityRanks orders the severities reported by detectors; escalation only
+// ever raises the severity of an anomaly
+var severityRanks = map[string]int{
+	"info":     0,
+	"low":      1,
+	"warning":  2,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// SeverityEscalation raises the severity of a detector's anomalies once the
+// detector has been continuously anomalous for After (e.g. "10m")
+type SeverityEscalation struct {
+	After    string `json:"after"`
+	Severity string `json:"severity"`
+}
+
+// escalationStep is a parsed SeverityEscalation
+type escalationStep struct {
+	after    time.Duration
+	severity string
+}
+
+// escalationState tracks the current anomalous run of a detector
+type escalationState struct {
+	// since is when the run started, zero while the detector is not anomalous
+	since time.Time
+	// last is when the run's latest anomaly was seen
+	last time.Time
+	// severity is the last severity reported during the run
+	severity string
+}
+
+// SeverityChangeEvent is the data of an anomaly_severity_changed event
+type SeverityChangeEvent struct {
+	DetectorID       string    `json:"detector_id"`
+	DetectorName     string    `json:"detector_name"`
+	PreviousSeverity string    `json:"previous_severity"`
+	Severity         string    `json:"severity"`
+	AnomalousSince   time.Time `json:"anomalous_since"`
+	AnomalousFor     string    `json:"anomalous_for"`
+}
+
+// parseEscalation validates escalation steps, returning them ordered by duration
+func parseEscalation(escalation []SeverityEscalation) ([]escalationStep, error) {
+	steps := make([]escalationStep, 0, len(escalation))
+	for i, e := range escalation {
+		after, err := time.ParseDuration(e.After)
+		if err != nil || after <= 0 {
+			return nil, fmt.Errorf("%w: escalation[%d] has invalid after %q", ErrInvalidEscalation, i, e.After)
+		}
+		if _, known := severityRanks[e.Severity]; !known {
+			return nil, fmt.Errorf("%w: escalation[%d] has unknown severity %q", ErrInvalidEscalation, i, e.Severity)
+		}
+		steps = append(steps, escalationStep{after: after, severity: e.Severity})
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].after < steps[j].after })
+	return steps, nil
+}
+
+// escalateAnomaly tracks how long instance has been continuously anomalous at
+// time now and raises the severity of anomaly to the highest step reached. A
+// point without anomaly ends the run, as does a gap since the run's latest
+// anomaly longer than the smallest step: a detector that stopped reporting
+// was not continuously anomalous. When escalation changes the severity
+// reported during the run, a severity change event is published.
+func (s *Server) escalateAnomaly(instance *DetectorInstance, anomaly *detector.Anomaly, now time.Time) {
+	s.detectorManager.mu.Lock()
+	state := &instance.escalation
+	if anomaly == nil || len(instance.escalationSteps) == 0 {
+		*state = escalationState{}
+		s.detectorManager.mu.Unlock()
+		return
+	}
+
+	if state.since.IsZero() || now.Sub(state.last) > instance.escalationSteps[0].after {
+		*state = escalationState{since: now}
+	}
+	state.last = now
+	anomalousFor := now.Sub(state.since)
+
+	original := anomaly.Severity
+	for _, step := range instance.escalationSteps {
+		if anomalousFor < step.after {
+			break
+		}
+		if rank, known := severityRanks[anomaly.Severity]; !known || severityRanks[step.severity] > rank {
+			anomaly.Severity = step.severity
+		}
+	}
+
+	previous := state.severity
+	state.severity = anomaly.Severity
+	escalated := anomaly.Severity != original
+	event := SeverityChangeEvent{
+		DetectorID:       instance.ID,
+		DetectorName:     instance.Name,
+		PreviousSeverity: previous,
+		Severity:         anomaly.Severity,
+		AnomalousSince:   state.since,
+		AnomalousFor:     anomalousFor.String(),
+	}
+	shadow := instance.Shadow
+	namespace := instance.Namespace
+	s.detectorManager.mu.Unlock()
+
+	if !escalated {
+		return
+	}
+	if anomaly.Details == nil {
+		anomaly.Details = make(map[string]interface{})
+	}
+	anomaly.Details["escalated_from"] = original
+	anomaly.Details["anomalous_for"] = event.AnomalousFor
+
+	if previous == anomaly.Severity || shadow {
+		return
+	}
+	s.wsGateway.SendEvent(Event{
+		Type:      EventAnomalySeverityChanged,
+		Topic:     TopicAnomalies,
+		Data:      event,
+		Timestamp: time.Now(),
+		Namespace: namespace,
+	})
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+)
+
+func TestParseEscalation(t *testing.T) {
+	steps, err := parseEscalation([]SeverityEscalation{
+		{After: "30m", Severity: "critical"},
+		{After: "5m", Severity: "high"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) != 2 || steps[0].after != 5*time.Minute || steps[1].severity != "critical" {
+		t.Errorf("expected steps ordered by duration, got %+v", steps)
+	}
+
+	for _, invalid := range [][]SeverityEscalation{
+		{{After: "soon", Severity: "critical"}},
+		{{After: "0s", Severity: "critical"}},
+		{{After: "5m", Severity: "urgent"}},
+	} {
+		if _, err := parseEscalation(invalid); err == nil {
+			t.Errorf("expected an error for %+v", invalid)
+		}
+	}
+}
+
+func TestEscalateAnomaly(t *testing.T) {
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.escalationSteps, _ = parseEscalation([]SeverityEscalation{
+		{After: "10m", Severity: "critical"},
+		{After: "5m", Severity: "high"},
+	})
+
+	start := time.Now()
+	escalate := func(offset time.Duration, severity string) *detector.Anomaly {
+		anomaly := &detector.Anomaly{Severity: severity}
+		s.escalateAnomaly(instance, anomaly, start.Add(offset))
+		return anomaly
+	}
+	severityEvents := func() []SeverityChangeEvent {
+		var events []SeverityChangeEvent
+		for len(s.wsGateway.eventChan) > 0 {
+			if event := <-s.wsGateway.eventChan; event.Type == EventAnomalySeverityChanged {
+				events = append(events, event.Data.(SeverityChangeEvent))
+			}
+		}
+		return events
+	}
+
+	if anomaly := escalate(0, "warning"); anomaly.Severity != "warning" {
+		t.Errorf("expected the first anomaly to keep its severity, got %s", anomaly.Severity)
+	}
+	escalate(3*time.Minute, "warning")
+	anomaly := escalate(6*time.Minute, "warning")
+	if anomaly.Severity != "high" || anomaly.Details["escalated_from"] != "warning" {
+		t.Errorf("expected escalation to high after 6m, got %s (%v)", anomaly.Severity, anomaly.Details)
+	}
+	escalate(9*time.Minute, "warning")
+	if anomaly := escalate(12*time.Minute, "warning"); anomaly.Severity != "critical" {
+		t.Errorf("expected escalation to critical after 12m, got %s", anomaly.Severity)
+	}
+
+	events := severityEvents()
+	if len(events) != 2 {
+		t.Fatalf("expected an event per severity change, got %+v", events)
+	}
+	if events[0].PreviousSeverity != "warning" || events[0].Severity != "high" ||
+		events[1].PreviousSeverity != "high" || events[1].Severity != "critical" {
+		t.Errorf("unexpected severity changes: %+v", events)
+	}
+	if !events[1].AnomalousSince.Equal(start) {
+		t.Errorf("expected the run to start at the first anomaly, got %v", events[1].AnomalousSince)
+	}
+
+	// Escalation never lowers a severity the detector reported itself
+	if anomaly := escalate(13*time.Minute, "critical"); anomaly.Severity != "critical" || anomaly.Details != nil {
+		t.Errorf("expected the critical anomaly to be left alone, got %+v", anomaly)
+	}
+
+	// A point without anomaly ends the run
+	s.escalateAnomaly(instance, nil, start.Add(14*time.Minute))
+	if anomaly := escalate(20*time.Minute, "warning"); anomaly.Severity != "warning" {
+		t.Errorf("expected a new run to start over, got %s", anomaly.Severity)
+	}
+	if events := severityEvents(); len(events) != 0 {
+		t.Errorf("expected no events without escalation, got %+v", events)
+	}
+}
+
+func TestEscalateAnomaly_GapEndsRun(t *testing.T) {
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.escalationSteps, _ = parseEscalation([]SeverityEscalation{{After: "10m", Severity: "critical"}})
+
+	// A blip at 10:00 followed by silence does not make 10:40 a 40 minute run
+	blip := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	s.escalateAnomaly(instance, &detector.Anomaly{Severity: "warning"}, blip)
+
+	anomaly := &detector.Anomaly{Severity: "warning"}
+	s.escalateAnomaly(instance, anomaly, blip.Add(40*time.Minute))
+	if anomaly.Severity != "warning" {
+		t.Errorf("expected the anomaly after the gap not to escalate, got %s", anomaly.Severity)
+	}
+	if !instance.escalation.since.Equal(blip.Add(40 * time.Minute)) {
+		t.Errorf("expected a new run to start after the gap, got %v", instance.escalation.since)
+	}
+}
+
+func TestIngestDataPoints_Escalation(t *testing.T) {
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	instance, _ := s.detectorManager.lookup("detector_1")
+	instance.escalationSteps, _ = parseEscalation([]SeverityEscalation{{After: "5m", Severity: "critical"}})
+
+	// Backfilled points escalate by their own timestamps
+	start := time.Now().Add(-time.Hour)
+	result, err := s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{
+		{Timestamp: start, Value: 50},
+		{Timestamp: start.Add(4 * time.Minute), Value: 50},
+		{Timestamp: start.Add(8 * time.Minute), Value: 50},
+	})
+	if err != nil {
+		t.Fatalf("ingest failed: %v", err)
+	}
+	if len(result.Anomalies) != 3 || result.Anomalies[0].Severity != "" || result.Anomalies[2].Severity != "critical" {
+		t.Errorf("expected the third anomaly to be escalated, got %+v", result.Anomalies)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+	"github.com/yourusername/aiops-infra/src/internal/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware starts a server span per request, continuing the caller's
+// trace when it sends a traceparent header. Handlers get the span through the
+// request context, so the spans they start become its children. Spans are
+// no-ops while tracing is disabled.
+func TracingMiddleware() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.DefaultServiceName)
+}
+
+// traceDetect runs a detection inside a detector.Detect span and returns its score
+func traceDetect(ctx context.Context, det detector.Detector, value float64) (*detector.Anomaly, float64, bool, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "detector.Detect",
+		trace.WithAttributes(attribute.String("detector.type", det.Type())))
+	defer span.End()
+
+	anomaly, score, scored, err := detectScored(ctx, det, value)
+	tracing.RecordError(span, err)
+	span.SetAttributes(attribute.Bool("anomaly", anomaly != nil))
+	return anomaly, score, scored, err
+}
+
+// traceTrain trains a detector inside a detector.Train span
+func traceTrain(ctx context.Context, det detector.TrainableDetector, values []float64) error {
+	_, span := tracing.Tracer().Start(ctx, "detector.Train",
+		trace.WithAttributes(
+			attribute.String("detector.type", det.Type()),
+			attribute.Int("values", len(values))))
+	defer span.End()
+
+	err := det.Train(values)
+	tracing.RecordError(span, err)
+	return err
+}
+
+// traceTrainTimed trains a detector on values observed at the timestamps inside a
+// detector.Train span. Detectors that ignore timestamps are trained on the values.
+func traceTrainTimed(ctx context.Context, det detector.TrainableDetector, timestamps []time.Time, values []float64) error {
+	timed, ok := det.(detector.TimedTrainableDetector)
+	if !ok {
+		return traceTrain(ctx, det, values)
+	}
+
+	_, span := tracing.Tracer().Start(ctx, "detector.Train",
+		trace.WithAttributes(
+			attribute.String("detector.type", det.Type()),
+			attribute.Int("values", len(values))))
+	defer span.End()
+
+	err := timed.TrainTimed(timestamps, values)
+	tracing.RecordError(span, err)
+	return err
+}
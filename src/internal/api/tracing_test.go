@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(TracingMiddleware())
+	engine.POST("/api/detectors/:id/detect", func(c *gin.Context) {
+		anomaly, _, _, _ := traceDetect(c.Request.Context(), &thresholdDetector{limit: 10}, 42)
+		c.JSON(http.StatusInternalServerError, gin.H{"anomaly": anomaly != nil})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/detectors/cpu/detect", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected a detect and a request span, got %d", len(spans))
+	}
+
+	detect, request := spans[0], spans[1]
+	if request.Name() != "/api/detectors/:id/detect" || request.SpanKind() != trace.SpanKindServer {
+		t.Errorf("unexpected request span %q (kind %s)", request.Name(), request.SpanKind())
+	}
+	if request.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || request.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("expected the request span to continue the caller's trace, got parent %s", request.Parent().SpanID())
+	}
+	if request.Status().Code != codes.Error {
+		t.Errorf("expected the 500 to be recorded, got %+v", request.Status())
+	}
+	if detect.Name() != "detector.Detect" || detect.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("expected a detect span under the request span, got %q with parent %s", detect.Name(), detect.Parent().SpanID())
+	}
+}
+
+func TestTracingMiddleware_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(TracingMiddleware())
+	engine.GET("/api/detectors", func(c *gin.Context) {
+		if trace.SpanContextFromContext(c.Request.Context()).IsSampled() {
+			t.Error("expected no recorded span while tracing is disabled")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/detectors", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+	"github.com/yourusername/aiops-infra/src/internal/orchestrator"
+)
+
+// DetectorWebhookPayload is the body POSTed to a detector's callback URL
+type DetectorWebhookPayload struct {
+	AnomalyID    string                `json:"anomaly_id,omitempty"`
+	DetectorID   string                `json:"detector_id"`
+	DetectorName string                `json:"detector_name"`
+	DetectorType detector.DetectorType `json:"detector_type"`
+	Value        float64               `json:"value"`
+	Anomaly      *detector.Anomaly     `json:"anomaly"`
+	Timestamp    time.Time             `json:"timestamp"`
+
+	// Format selects the JSON shape sent to the callback, one of the
+	// orchestrator.PayloadFormat* formats: "default" sends the payload as is,
+	// "flat" a single level of key/values with anomaly details prefixed with
+	// "details_", and "nested" {"anomaly": {...}, "meta": {...}}
+	Format string `json:"-"`
+}
+
+// validatePayloadFormat checks that the callback payload format is supported
+func validatePayloadFormat(format string) error {
+	switch format {
+	case "", orchestrator.PayloadFormatDefault, orchestrator.PayloadFormatFlat, orchestrator.PayloadFormatNested:
+		return nil
+	default:
+		return fmt.Errorf("unsupported payload format %q (use %s, %s or %s)",
+			format, orchestrator.PayloadFormatDefault, orchestrator.PayloadFormatFlat, orchestrator.PayloadFormatNested)
+	}
+}
+
+// body returns the value marshaled as the webhook request body
+func (p DetectorWebhookPayload) body() interface{} {
+	switch p.Format {
+	case orchestrator.PayloadFormatFlat:
+		return p.flat()
+	case orchestrator.PayloadFormatNested:
+		return p.nested()
+	default:
+		return p
+	}
+}
+
+// flat renders the payload as a single level of key/values
+func (p DetectorWebhookPayload) flat() map[string]interface{} {
+	body := map[string]interface{}{
+		"detector_id":   p.DetectorID,
+		"detector_name": p.DetectorName,
+		"detector_type": p.DetectorType,
+		"value":         p.Value,
+		"timestamp":     p.Timestamp,
+	}
+	if p.AnomalyID != "" {
+		body["anomaly_id"] = p.AnomalyID
+	}
+
+	if p.Anomaly != nil {
+		body["anomaly_type"] = p.Anomaly.Type
+		body["severity"] = p.Anomaly.Severity
+		body["threshold"] = p.Anomaly.Threshold
+		body["source"] = p.Anomaly.Source
+		body["anomaly_timestamp"] = p.Anomaly.Timestamp
+		for key, value := range p.Anomaly.Details {
+			flattenInto(body, "details_"+key, value)
+		}
+	}
+
+	return body
+}
+
+// flattenInto stores value under key, expanding nested maps into prefixed keys
+func flattenInto(dst map[string]interface{}, key string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, nested := range v {
+			flattenInto(dst, key+"_"+k, nested)
+		}
+	case map[string]string:
+		for k, nested := range v {
+			dst[key+"_"+k] = nested
+		}
+	default:
+		dst[key] = value
+	}
+}
+
+// nested renders the payload as {"anomaly": {...}, "meta": {...}}
+func (p DetectorWebhookPayload) nested() map[string]interface{} {
+	anomaly := map[string]interface{}{
+		"value": p.Value,
+	}
+	if p.AnomalyID != "" {
+		anomaly["id"] = p.AnomalyID
+	}
+	if p.Anomaly != nil {
+		anomaly["type"] = p.Anomaly.Type
+		anomaly["severity"] = p.Anomaly.Severity
+		anomaly["threshold"] = p.Anomaly.Threshold
+		anomaly["source"] = p.Anomaly.Source
+		anomaly["timestamp"] = p.Anomaly.Timestamp
+		anomaly["details"] = p.Anomaly.Details
+	}
+
+	return map[string]interface{}{
+		"anomaly": anomaly,
+		"meta": map[string]interface{}{
+			"detector_id":   p.DetectorID,
+			"detector_name": p.DetectorName,
+			"detector_type": p.DetectorType,
+			"timestamp":     p.Timestamp,
+		},
+	}
+}
+
+// WebhookNotifier delivers detections to external systems over HTTP with retry and backoff
+type WebhookNotifier struct {
+	client         *http.Client
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	timeout        time.Duration
+	logger         *Logger
+}
+
+// NewWebhookNotifier creates a webhook notifier with default retry settings.
+// It refuses to connect to blocked addresses (see blockedCallbackIP).
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{
+		client:         newCallbackClient(),
+		maxRetries:     3,
+		initialBackoff: time.Second,
+		maxBackoff:     30 * time.Second,
+		timeout:        10 * time.Second,
+		logger:         NewLogger("webhook"),
+	}
+}
+
+// ErrBlockedCallbackAddress is returned for callback URLs pointing to an
+// internal address, which callbacks must not reach (SSRF protection)
+var ErrBlockedCallbackAddress = errors.New("callback URL must not point to a loopback, private or link-local address")
+
+// blockedCallbackIP reports whether callbacks must not connect to ip
+func blockedCallbackIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+// validateCallbackURL checks that the callback URL is an absolute http(s) URL
+// whose host is not localhost or a blocked address. Host names resolving to a
+// blocked address are refused when the callback is delivered.
+func validateCallbackURL(callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("invalid callback URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("callback URL must be an absolute http(s) URL")
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrBlockedCallbackAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && blockedCallbackIP(ip) {
+		return ErrBlockedCallbackAddress
+	}
+	return nil
+}
+
+// newCallbackClient returns a client that checks every address it connects to,
+// so callback hosts resolving (or redirecting) to a blocked address are refused.
+// It connects directly, since a proxy would hide the destination address.
+func newCallbackClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedCallbackIP(ip) {
+				return ErrBlockedCallbackAddress
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
+
+// NotifyAsync delivers the payload in the background so detection requests are not blocked
+func (w *WebhookNotifier) NotifyAsync(callbackURL string, payload DetectorWebhookPayload) {
+	go func() {
+		if err := w.Notify(context.Background(), callbackURL, payload); err != nil {
+			w.logger.Error("Detector webhook delivery failed", err, map[string]interface{}{
+				"detector_id":  payload.DetectorID,
+				"callback_url": callbackURL,
+			})
+		}
+	}()
+}
+
+// Notify POSTs the payload to the callback URL, retrying with exponential backoff
+func (w *WebhookNotifier) Notify(ctx context.Context, callbackURL string, payload DetectorWebhookPayload) error {
+	body, err := json.Marshal(payload.body())
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	backoff := w.initialBackoff
+	var lastErr error
+
+	for attempt := 0; attempt <= w.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > w.maxBackoff {
+				backoff = w.maxBackoff
+			}
+		}
+
+		lastErr = w.send(ctx, callbackURL, body)
+		if lastErr == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", w.maxRetries+1, lastErr)
+}
+
+// send performs a single delivery attempt
+func (w *WebhookNotifier) send(ctx context.Context, callbackURL string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
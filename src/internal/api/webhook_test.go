@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+	"github.com/yourusername/aiops-infra/src/internal/orchestrator"
+)
+
+func testWebhookPayload(format string) DetectorWebhookPayload {
+	return DetectorWebhookPayload{
+		AnomalyID:    "anomaly_1",
+		DetectorID:   "detector_1",
+		DetectorName: "cpu",
+		DetectorType: detector.TypeStatistical,
+		Value:        42,
+		Anomaly: &detector.Anomaly{
+			Type:      "cpu",
+			Severity:  "critical",
+			Threshold: 3,
+			Details: map[string]interface{}{
+				"score":  5.1,
+				"labels": map[string]string{"host": "a"},
+			},
+		},
+		Timestamp: time.Now(),
+		Format:    format,
+	}
+}
+
+func TestDetectorWebhookPayload_Formats(t *testing.T) {
+	if _, ok := testWebhookPayload("").body().(DetectorWebhookPayload); !ok {
+		t.Error("expected the default format to send the payload as is")
+	}
+
+	flat, ok := testWebhookPayload(orchestrator.PayloadFormatFlat).body().(map[string]interface{})
+	if !ok {
+		t.Fatal("expected a map for the flat format")
+	}
+	if flat["severity"] != "critical" || flat["anomaly_id"] != "anomaly_1" {
+		t.Errorf("expected anomaly fields at the top level, got %v", flat)
+	}
+	if flat["details_score"] != 5.1 || flat["details_labels_host"] != "a" {
+		t.Errorf("expected flattened details, got %v", flat)
+	}
+
+	nested, ok := testWebhookPayload(orchestrator.PayloadFormatNested).body().(map[string]interface{})
+	if !ok {
+		t.Fatal("expected a map for the nested format")
+	}
+	anomaly, _ := nested["anomaly"].(map[string]interface{})
+	meta, _ := nested["meta"].(map[string]interface{})
+	if anomaly["id"] != "anomaly_1" || anomaly["severity"] != "critical" {
+		t.Errorf("unexpected anomaly section: %v", anomaly)
+	}
+	if meta["detector_id"] != "detector_1" {
+		t.Errorf("unexpected meta section: %v", meta)
+	}
+}
+
+func TestValidatePayloadFormat(t *testing.T) {
+	for _, format := range []string{"", orchestrator.PayloadFormatDefault, orchestrator.PayloadFormatFlat, orchestrator.PayloadFormatNested} {
+		if err := validatePayloadFormat(format); err != nil {
+			t.Errorf("expected %q to be valid, got %v", format, err)
+		}
+	}
+	if err := validatePayloadFormat("xml"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestValidateCallbackURL(t *testing.T) {
+	for _, callbackURL := range []string{"", "https://hooks.example.com/aiops", "http://203.0.113.7:8080/hook"} {
+		if err := validateCallbackURL(callbackURL); err != nil {
+			t.Errorf("expected %q to be valid, got %v", callbackURL, err)
+		}
+	}
+	for _, callbackURL := range []string{
+		"http://localhost:8080/hook",
+		"http://api.localhost/hook",
+		"http://127.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0/hook",
+	} {
+		if err := validateCallbackURL(callbackURL); !errors.Is(err, ErrBlockedCallbackAddress) {
+			t.Errorf("expected %q to be blocked, got %v", callbackURL, err)
+		}
+	}
+	if err := validateCallbackURL("ftp://example.com/hook"); err == nil {
+		t.Error("expected a non-http URL to be rejected")
+	}
+}
+
+func TestWebhookNotifier_Delivery(t *testing.T) {
+	var attempts atomic.Int64
+	bodies := make(chan map[string]interface{}, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies <- body
+	}))
+	defer callback.Close()
+
+	notifier := NewWebhookNotifier()
+	notifier.client = callback.Client()
+	notifier.initialBackoff = time.Millisecond
+
+	if err := notifier.Notify(context.Background(), callback.URL, testWebhookPayload(orchestrator.PayloadFormatFlat)); err != nil {
+		t.Fatalf("expected delivery after a retry, got %v", err)
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts.Load())
+	}
+	if body := <-bodies; body["detector_id"] != "detector_1" || body["severity"] != "critical" {
+		t.Errorf("unexpected callback body: %v", body)
+	}
+}
+
+func TestWebhookNotifier_RefusesBlockedAddress(t *testing.T) {
+	var attempts atomic.Int64
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+	}))
+	defer callback.Close()
+
+	// The dialer refuses the address even when validation does not see it,
+	// e.g. for a host name resolving to loopback
+	notifier := NewWebhookNotifier()
+	notifier.maxRetries = 0
+	err := notifier.Notify(context.Background(), callback.URL, testWebhookPayload(""))
+	if !errors.Is(err, ErrBlockedCallbackAddress) {
+		t.Errorf("expected the loopback address to be refused, got %v", err)
+	}
+	if attempts.Load() != 0 {
+		t.Errorf("expected no request to reach the server, got %d", attempts.Load())
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/yourusername/aiops-infra/src/internal/metrics"
 )
 
 // WebSocketGateway manages WebSocket connections for real-time updates
@@ -19,15 +21,75 @@ type WebSocketGateway struct {
 	mutex       sync.RWMutex
 	upgrader    websocket.Upgrader
 	eventChan   chan Event
+
+	// maxConnections caps concurrent clients (0 disables the limit);
+	// pendingUpgrades reserves slots for upgrades in progress
+	maxConnections  int
+	pendingUpgrades int
+
+	// dedupWindow coalesces events sharing a CorrelationKey (0 disables dedup)
+	dedupWindow  time.Duration
+	dedupPending map[string]*Event
+	dedupMutex   sync.Mutex
+
+	// sendTimeout is how long SendEvent waits for queue space before dropping
+	// an event (0 drops immediately); droppedEvents counts dropped events
+	sendTimeout   time.Duration
+	droppedEvents int64
+
+	// batchInterval is how long events for topics subscribed with "batch" are
+	// buffered per client before being sent as one frame (0 disables batching)
+	batchInterval time.Duration
+
+	// streams are Server-Sent Events subscribers; they share the connection
+	// limit and receive the same events as WebSocket clients
+	streams         map[string]*eventStream
+	streamHeartbeat time.Duration
+
+	// nonFiniteFloats is how NaN and ±Inf in events are encoded
+	nonFiniteFloats string
+
+	// cancel stops the goroutines started by Start; done is closed by Shutdown,
+	// after which new connections are refused
+	cancel context.CancelFunc
+	done   chan struct{}
+	closed bool
 }
 
+// DefaultMaxWebSocketConnections is the default cap on concurrent WebSocket clients
+const DefaultMaxWebSocketConnections = 1000
+
+// DefaultEventQueueCapacity is the default capacity of the outgoing event queue
+const DefaultEventQueueCapacity = 100
+
+// DefaultBatchInterval is the default flush interval of batched topics
+const DefaultBatchInterval = 100 * time.Millisecond
+
+// MaxBatchSize flushes a batch early once it holds this many events
+const MaxBatchSize = 100
+
 // ConnectionWrapper wraps a WebSocket connection with metadata
 type ConnectionWrapper struct {
 	conn          *websocket.Conn
 	clientID      string
+	namespace     string
 	subscriptions map[string]bool // topic -> subscribed
 	lastPing      time.Time
 	writeMutex    sync.Mutex
+
+	// batchTopics are topics the client subscribed to with "batch": true;
+	// batches holds their buffered events until the next flush
+	batchTopics map[string]bool
+	batches     map[string][]Event
+	batchMutex  sync.Mutex
+}
+
+// BatchFrame carries the events of one topic buffered during a batch interval
+type BatchFrame struct {
+	Type      string    `json:"type"`
+	Topic     string    `json:"topic"`
+	Events    []Event   `json:"events"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // Event represents a real-time event to be sent to clients
@@ -37,6 +99,20 @@ type Event struct {
 	Data      interface{} `json:"data"`
 	Timestamp time.Time   `json:"timestamp"`
 	ClientID  string      `json:"client_id,omitempty"` // Empty means broadcast
+
+	// CorrelationKey identifies events describing the same incident; with dedup
+	// enabled, events sharing a key within the window are sent once
+	CorrelationKey string `json:"correlation_key,omitempty"`
+	// Occurrences is the number of events coalesced into this one
+	Occurrences int `json:"occurrences,omitempty"`
+	// Namespace restricts the event to subscribers of that namespace; events
+	// without a namespace are sent to every subscriber
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// visibleIn reports whether the event is sent to subscribers of namespace
+func (e Event) visibleIn(namespace string) bool {
+	return e.Namespace == "" || e.Namespace == namespace
 }
 
 // EventType constants
@@ -47,9 +123,12 @@ const (
 	EventDetectorStarted = "detector_started"
 	EventDetectorStopped = "detector_stopped"
 	EventAnomalyDetected = "anomaly_detected"
+	EventAnomalyUpdated  = "anomaly_updated"
 	EventDetectorHealth  = "detector_health"
 	EventDetectorStatus  = "detector_status"
 	EventHeartbeat       = "heartbeat"
+	EventBatch           = "batch"
+	EventDetectorScore   = "detector_score"
 )
 
 // Topic constants
@@ -59,6 +138,15 @@ const (
 	TopicSystem    = "system"
 )
 
+// TopicDetectorPrefix prefixes the per-detector topics carrying the score of
+// every detection, e.g. "detector:cpu-usage"
+const TopicDetectorPrefix = "detector:"
+
+// DetectorTopic returns the topic of a detector's live scores
+func DetectorTopic(detectorID string) string {
+	return TopicDetectorPrefix + detectorID
+}
+
 // NewWebSocketGateway creates a new WebSocket gateway
 func NewWebSocketGateway() *WebSocketGateway {
 	return &WebSocketGateway{
@@ -71,12 +159,183 @@ func NewWebSocketGateway() *WebSocketGateway {
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
 		},
-		eventChan: make(chan Event, 100),
+		eventChan:       make(chan Event, DefaultEventQueueCapacity),
+		maxConnections:  DefaultMaxWebSocketConnections,
+		dedupPending:    make(map[string]*Event),
+		batchInterval:   DefaultBatchInterval,
+		streams:         make(map[string]*eventStream),
+		streamHeartbeat: DefaultStreamHeartbeatInterval,
+		done:            make(chan struct{}),
 	}
 }
 
+// SetMaxConnections sets the maximum number of concurrent clients (0 disables the limit)
+func (gw *WebSocketGateway) SetMaxConnections(max int) {
+	gw.mutex.Lock()
+	defer gw.mutex.Unlock()
+	gw.maxConnections = max
+}
+
+// SetQueueCapacity replaces the outgoing event queue with one of the given capacity.
+// It must be called before Start.
+func (gw *WebSocketGateway) SetQueueCapacity(capacity int) {
+	if capacity <= 0 {
+		capacity = DefaultEventQueueCapacity
+	}
+	gw.mutex.Lock()
+	defer gw.mutex.Unlock()
+	gw.eventChan = make(chan Event, capacity)
+}
+
+// queue returns the outgoing event queue
+func (gw *WebSocketGateway) queue() chan Event {
+	gw.mutex.RLock()
+	defer gw.mutex.RUnlock()
+	return gw.eventChan
+}
+
+// SetSendTimeout makes SendEvent block for up to timeout when the queue is full
+// instead of dropping the event immediately. A zero timeout restores dropping.
+func (gw *WebSocketGateway) SetSendTimeout(timeout time.Duration) {
+	gw.mutex.Lock()
+	defer gw.mutex.Unlock()
+	gw.sendTimeout = timeout
+}
+
+// SetOriginCheck sets the function deciding whether a WebSocket upgrade's Origin is allowed
+func (gw *WebSocketGateway) SetOriginCheck(check func(r *http.Request) bool) {
+	gw.upgrader.CheckOrigin = check
+}
+
+// SetDedupWindow enables coalescing of events that share a CorrelationKey.
+// The first event of a key is held for the window and sent with the number of
+// duplicates seen in the meantime. A zero window disables dedup.
+func (gw *WebSocketGateway) SetDedupWindow(window time.Duration) {
+	gw.dedupMutex.Lock()
+	defer gw.dedupMutex.Unlock()
+	gw.dedupWindow = window
+}
+
+// SetBatchInterval sets how long events of batched topics are buffered per
+// client before being sent as a single frame. A zero interval disables batching
+// and batched subscriptions receive one frame per event.
+func (gw *WebSocketGateway) SetBatchInterval(interval time.Duration) {
+	gw.mutex.Lock()
+	defer gw.mutex.Unlock()
+	gw.batchInterval = interval
+}
+
+// SetNonFiniteFloats sets how NaN and ±Inf in events are encoded
+func (gw *WebSocketGateway) SetNonFiniteFloats(mode string) {
+	gw.mutex.Lock()
+	defer gw.mutex.Unlock()
+	gw.nonFiniteFloats = mode
+}
+
+// WebSocketConfig holds the WebSocket gateway settings applied by
+// SetWebSocketConfig; zero fields keep the defaults
+type WebSocketConfig struct {
+	// MaxConnections caps concurrent clients; a negative value disables the limit
+	MaxConnections int
+	// DedupWindow coalesces events sharing a CorrelationKey (0 disables dedup)
+	DedupWindow time.Duration
+	// QueueCapacity is the capacity of the outgoing event queue
+	QueueCapacity int
+	// SendTimeout is how long publishers wait for queue space before an
+	// event is dropped (0 drops immediately)
+	SendTimeout time.Duration
+	// BatchInterval is how long events of batched topics are buffered per
+	// client; a negative interval disables batching
+	BatchInterval time.Duration
+	// StreamHeartbeatInterval is how often idle event streams receive a heartbeat
+	StreamHeartbeatInterval time.Duration
+}
+
+// SetWebSocketConfig applies config to the WebSocket gateway. It must be called before Start.
+func (s *Server) SetWebSocketConfig(config WebSocketConfig) {
+	if config.MaxConnections < 0 {
+		s.wsGateway.SetMaxConnections(0)
+	} else if config.MaxConnections > 0 {
+		s.wsGateway.SetMaxConnections(config.MaxConnections)
+	}
+	s.wsGateway.SetDedupWindow(config.DedupWindow)
+	if config.QueueCapacity > 0 {
+		s.wsGateway.SetQueueCapacity(config.QueueCapacity)
+	}
+	s.wsGateway.SetSendTimeout(config.SendTimeout)
+	if config.BatchInterval < 0 {
+		s.wsGateway.SetBatchInterval(0)
+	} else if config.BatchInterval > 0 {
+		s.wsGateway.SetBatchInterval(config.BatchInterval)
+	}
+	if config.StreamHeartbeatInterval > 0 {
+		s.wsGateway.SetStreamHeartbeatInterval(config.StreamHeartbeatInterval)
+	}
+}
+
+// coalesce holds an event for the dedup window, returning false if the event
+// should be broadcast immediately instead
+func (gw *WebSocketGateway) coalesce(event Event) bool {
+	if event.CorrelationKey == "" {
+		return false
+	}
+
+	gw.dedupMutex.Lock()
+	defer gw.dedupMutex.Unlock()
+
+	if gw.dedupWindow <= 0 {
+		return false
+	}
+
+	if pending, exists := gw.dedupPending[event.CorrelationKey]; exists {
+		pending.Occurrences++
+		return true
+	}
+
+	event.Occurrences = 1
+	gw.dedupPending[event.CorrelationKey] = &event
+
+	key := event.CorrelationKey
+	time.AfterFunc(gw.dedupWindow, func() {
+		gw.flushCoalesced(key)
+	})
+	return true
+}
+
+// flushCoalesced broadcasts the coalesced event for a correlation key
+func (gw *WebSocketGateway) flushCoalesced(key string) {
+	gw.dedupMutex.Lock()
+	pending, exists := gw.dedupPending[key]
+	delete(gw.dedupPending, key)
+	gw.dedupMutex.Unlock()
+
+	if exists {
+		gw.broadcastEvent(*pending)
+	}
+}
+
+// reserveSlot reserves a connection slot, returning false when the limit is reached
+func (gw *WebSocketGateway) reserveSlot() bool {
+	gw.mutex.Lock()
+	defer gw.mutex.Unlock()
+
+	if gw.closed {
+		return false
+	}
+	if gw.maxConnections > 0 && len(gw.connections)+len(gw.streams)+gw.pendingUpgrades >= gw.maxConnections {
+		return false
+	}
+	gw.pendingUpgrades++
+	return true
+}
+
 // Start starts the WebSocket gateway event processing
 func (gw *WebSocketGateway) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	gw.mutex.Lock()
+	gw.cancel = cancel
+	gw.mutex.Unlock()
+
 	// Start event processing goroutine
 	go gw.processEvents(ctx)
 
@@ -89,9 +348,26 @@ func (gw *WebSocketGateway) Start(ctx context.Context) {
 
 // HandleWebSocket handles WebSocket connection upgrade and management
 func (gw *WebSocketGateway) HandleWebSocket(c *gin.Context) {
+	namespace, ok := subscriberNamespace(c)
+	if !ok {
+		return
+	}
+
+	// Refuse the upgrade when the connection limit is reached
+	if !gw.reserveSlot() {
+		log.Printf("WebSocket connection limit reached, rejecting %s", c.ClientIP())
+		c.Header("Retry-After", "30")
+		HandleError(c, NewAPIError(ErrorCodeServiceDown, "Too many WebSocket connections",
+			"The WebSocket connection limit has been reached, retry later"))
+		return
+	}
+
 	// Upgrade HTTP connection to WebSocket
 	conn, err := gw.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
+		gw.mutex.Lock()
+		gw.pendingUpgrades--
+		gw.mutex.Unlock()
 		log.Printf("WebSocket upgrade failed: %v", err)
 		return
 	}
@@ -104,12 +380,16 @@ func (gw *WebSocketGateway) HandleWebSocket(c *gin.Context) {
 	wrapper := &ConnectionWrapper{
 		conn:          conn,
 		clientID:      clientID,
+		namespace:     namespace,
 		subscriptions: make(map[string]bool),
 		lastPing:      time.Now(),
+		batchTopics:   make(map[string]bool),
+		batches:       make(map[string][]Event),
 	}
 
-	// Register connection
+	// Register connection, converting the reserved slot
 	gw.mutex.Lock()
+	gw.pendingUpgrades--
 	gw.connections[clientID] = wrapper
 	gw.mutex.Unlock()
 
@@ -123,11 +403,8 @@ func (gw *WebSocketGateway) HandleWebSocket(c *gin.Context) {
 		Timestamp: time.Now(),
 	})
 
-	// Handle client messages
-	go gw.handleClientMessages(wrapper)
-
-	// Wait for connection to close
-	gw.waitForClose(wrapper)
+	// Handle client messages until the connection closes
+	gw.handleClientMessages(wrapper)
 
 	// Cleanup connection
 	gw.mutex.Lock()
@@ -175,13 +452,29 @@ func (gw *WebSocketGateway) handleClientMessage(wrapper *ConnectionWrapper, msg
 	switch msgType {
 	case "subscribe":
 		if topic, ok := msg["topic"].(string); ok {
+			gw.mutex.Lock()
 			wrapper.subscriptions[topic] = true
-			log.Printf("Client %s subscribed to topic: %s", wrapper.clientID, topic)
+			gw.mutex.Unlock()
+
+			// Opt in to receiving the topic's events in batch frames
+			batch, _ := msg["batch"].(bool)
+			wrapper.batchMutex.Lock()
+			wrapper.batchTopics[topic] = batch
+			wrapper.batchMutex.Unlock()
+
+			log.Printf("Client %s subscribed to topic: %s (batch: %t)", wrapper.clientID, topic, batch)
 		}
 
 	case "unsubscribe":
 		if topic, ok := msg["topic"].(string); ok {
+			gw.mutex.Lock()
 			delete(wrapper.subscriptions, topic)
+			gw.mutex.Unlock()
+
+			wrapper.batchMutex.Lock()
+			delete(wrapper.batchTopics, topic)
+			wrapper.batchMutex.Unlock()
+
 			log.Printf("Client %s unsubscribed from topic: %s", wrapper.clientID, topic)
 		}
 
@@ -196,24 +489,17 @@ func (gw *WebSocketGateway) handleClientMessage(wrapper *ConnectionWrapper, msg
 	}
 }
 
-// waitForClose waits for the WebSocket connection to close
-func (gw *WebSocketGateway) waitForClose(wrapper *ConnectionWrapper) {
-	// This will block until the connection is closed
-	for {
-		_, _, err := wrapper.conn.ReadMessage()
-		if err != nil {
-			break
-		}
-	}
-}
-
 // processEvents processes events from the event channel
 func (gw *WebSocketGateway) processEvents(ctx context.Context) {
+	events := gw.queue()
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case event := <-gw.eventChan:
+		case event := <-events:
+			if gw.coalesce(event) {
+				continue
+			}
 			gw.broadcastEvent(event)
 		}
 	}
@@ -221,9 +507,17 @@ func (gw *WebSocketGateway) processEvents(ctx context.Context) {
 
 // broadcastEvent sends an event to all subscribed clients
 func (gw *WebSocketGateway) broadcastEvent(event Event) {
+	gw.broadcast(event, nil)
+}
+
+// broadcast sends an event to all subscribed clients and streams. Sends run in
+// their own goroutines; when sent is not nil they are added to it.
+func (gw *WebSocketGateway) broadcast(event Event, sent *sync.WaitGroup) {
 	gw.mutex.RLock()
 	defer gw.mutex.RUnlock()
 
+	batchInterval := gw.batchInterval
+
 	for clientID, wrapper := range gw.connections {
 		// Check if client wants this event
 		if event.ClientID != "" && event.ClientID != clientID {
@@ -235,29 +529,113 @@ func (gw *WebSocketGateway) broadcastEvent(event Event) {
 			continue // Client not subscribed to this topic
 		}
 
+		// Events of a namespace are only sent to its clients
+		if !event.visibleIn(wrapper.namespace) {
+			continue
+		}
+
+		// Buffer events of batched topics, send the rest right away
+		if batchInterval > 0 && gw.addToBatch(wrapper, event, batchInterval) {
+			continue
+		}
+
 		// Send event to client
-		go gw.sendToClient(clientID, event)
+		if sent == nil {
+			go gw.sendToClient(clientID, event)
+			continue
+		}
+		sent.Add(1)
+		go func(clientID string) {
+			defer sent.Done()
+			gw.sendToClient(clientID, event)
+		}(clientID)
+	}
+
+	for _, stream := range gw.streams {
+		stream.deliver(event)
+	}
+}
+
+// addToBatch buffers the event if the client batches its topic, returning false
+// otherwise. The first event of a batch schedules its flush.
+func (gw *WebSocketGateway) addToBatch(wrapper *ConnectionWrapper, event Event, interval time.Duration) bool {
+	wrapper.batchMutex.Lock()
+	defer wrapper.batchMutex.Unlock()
+
+	if !wrapper.batchTopics[event.Topic] {
+		return false
+	}
+
+	pending := append(wrapper.batches[event.Topic], event)
+	wrapper.batches[event.Topic] = pending
+
+	switch {
+	case len(pending) >= MaxBatchSize:
+		// The pending timer flushes whatever is buffered by then
+		delete(wrapper.batches, event.Topic)
+		go gw.sendBatch(wrapper.clientID, event.Topic, pending)
+	case len(pending) == 1:
+		topic := event.Topic
+		time.AfterFunc(interval, func() {
+			gw.flushBatch(wrapper, topic)
+		})
+	}
+	return true
+}
+
+// flushBatch sends the buffered events of a topic as a single frame
+func (gw *WebSocketGateway) flushBatch(wrapper *ConnectionWrapper, topic string) {
+	wrapper.batchMutex.Lock()
+	pending := wrapper.batches[topic]
+	delete(wrapper.batches, topic)
+	wrapper.batchMutex.Unlock()
+
+	if len(pending) > 0 {
+		gw.sendBatch(wrapper.clientID, topic, pending)
 	}
 }
 
+// sendBatch sends events to a client as one batch frame
+func (gw *WebSocketGateway) sendBatch(clientID, topic string, events []Event) {
+	gw.writeToClient(clientID, BatchFrame{
+		Type:      EventBatch,
+		Topic:     topic,
+		Events:    events,
+		Timestamp: time.Now(),
+	})
+}
+
 // sendToClient sends an event to a specific client
 func (gw *WebSocketGateway) sendToClient(clientID string, event Event) {
+	gw.writeToClient(clientID, event)
+}
+
+// writeToClient writes a JSON frame to a specific client
+func (gw *WebSocketGateway) writeToClient(clientID string, frame interface{}) {
 	gw.mutex.RLock()
 	wrapper, exists := gw.connections[clientID]
+	mode := gw.nonFiniteFloats
 	gw.mutex.RUnlock()
 
 	if !exists {
 		return
 	}
 
+	// Scores in events may be NaN or ±Inf, which WriteJSON would fail on
+	data, err := marshalFinite(frame, mode)
+	if err != nil {
+		log.Printf("Failed to encode event for client %s: %v", clientID, err)
+		return
+	}
+
 	wrapper.writeMutex.Lock()
 	defer wrapper.writeMutex.Unlock()
 
 	// Set write deadline
 	wrapper.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 
-	// Send event
-	if err := wrapper.conn.WriteJSON(event); err != nil {
+	// Send frame
+	if err := wrapper.conn.WriteMessage(websocket.TextMessage, data); err != nil {
 		log.Printf("Failed to send event to client %s: %v", clientID, err)
 
 		// Close connection on write error
@@ -265,6 +643,104 @@ func (gw *WebSocketGateway) sendToClient(clientID string, event Event) {
 	}
 }
 
+// Shutdown stops the gateway warmly: queued and coalesced events and pending
+// batches are delivered to connected clients before they are sent a close
+// frame and disconnected. Delivery stops at the ctx deadline, in which case the
+// connections are closed anyway and ctx.Err() is returned.
+func (gw *WebSocketGateway) Shutdown(ctx context.Context) error {
+	gw.mutex.Lock()
+	if gw.closed {
+		gw.mutex.Unlock()
+		return nil
+	}
+	gw.closed = true
+	cancel := gw.cancel
+	events := gw.eventChan
+	gw.mutex.Unlock()
+
+	// Stop processEvents so that the queue is drained here
+	if cancel != nil {
+		cancel()
+	}
+
+	var sent sync.WaitGroup
+	drained := 0
+drain:
+	for ctx.Err() == nil {
+		select {
+		case event := <-events:
+			gw.broadcast(event, &sent)
+			drained++
+		default:
+			break drain
+		}
+	}
+
+	// Coalesced events are sent now instead of at the end of their window
+	gw.dedupMutex.Lock()
+	coalesced := gw.dedupPending
+	gw.dedupPending = make(map[string]*Event)
+	gw.dedupMutex.Unlock()
+	for _, event := range coalesced {
+		gw.broadcast(*event, &sent)
+	}
+
+	gw.mutex.RLock()
+	wrappers := make([]*ConnectionWrapper, 0, len(gw.connections))
+	for _, wrapper := range gw.connections {
+		wrappers = append(wrappers, wrapper)
+	}
+	gw.mutex.RUnlock()
+
+	for _, wrapper := range wrappers {
+		wrapper.batchMutex.Lock()
+		topics := make([]string, 0, len(wrapper.batches))
+		for topic := range wrapper.batches {
+			topics = append(topics, topic)
+		}
+		wrapper.batchMutex.Unlock()
+
+		for _, topic := range topics {
+			sent.Add(1)
+			go func(wrapper *ConnectionWrapper, topic string) {
+				defer sent.Done()
+				gw.flushBatch(wrapper, topic)
+			}(wrapper, topic)
+		}
+	}
+
+	delivered := make(chan struct{})
+	go func() {
+		sent.Wait()
+		close(delivered)
+	}()
+
+	var err error
+	select {
+	case <-delivered:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	// Streams write out what they have buffered and return
+	close(gw.done)
+
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	gw.mutex.Lock()
+	for clientID, wrapper := range gw.connections {
+		wrapper.conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+		wrapper.conn.Close()
+		delete(gw.connections, clientID)
+	}
+	gw.mutex.Unlock()
+
+	if remaining := len(events); remaining > 0 {
+		log.Printf("WebSocket gateway stopped with %d undelivered events", remaining)
+	}
+	log.Printf("WebSocket gateway stopped, drained %d queued events", drained)
+	return err
+}
+
 // cleanupConnections removes stale connections
 func (gw *WebSocketGateway) cleanupConnections(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Second)
@@ -316,14 +792,49 @@ func (gw *WebSocketGateway) sendHeartbeats(ctx context.Context) {
 	}
 }
 
-// SendEvent queues an event to be sent to clients
+// SendEvent queues an event to be sent to clients. When the queue is full the
+// event is dropped, after waiting up to the send timeout if one is set.
 func (gw *WebSocketGateway) SendEvent(event Event) {
+	gw.mutex.RLock()
+	events := gw.eventChan
+	timeout := gw.sendTimeout
+	gw.mutex.RUnlock()
+
 	select {
-	case gw.eventChan <- event:
+	case events <- event:
 		// Event queued successfully
+		return
 	default:
-		log.Printf("Event channel full, dropping event: %+v", event)
 	}
+
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case events <- event:
+			return
+		case <-timer.C:
+		}
+	}
+
+	atomic.AddInt64(&gw.droppedEvents, 1)
+	metrics.WebSocketEventsDropped.WithLabelValues(event.Topic).Inc()
+	log.Printf("Event channel full, dropping event: %+v", event)
+}
+
+// HasSubscribers reports whether any client of namespace is subscribed to topic,
+// letting publishers skip building events nobody receives
+func (gw *WebSocketGateway) HasSubscribers(namespace, topic string) bool {
+	gw.mutex.RLock()
+	defer gw.mutex.RUnlock()
+
+	for _, wrapper := range gw.connections {
+		if wrapper.namespace == namespace && wrapper.subscriptions[topic] {
+			return true
+		}
+	}
+	return false
 }
 
 // GetConnectedClients returns the number of connected clients
@@ -347,13 +858,19 @@ func (gw *WebSocketGateway) GetClientInfo() map[string]interface{} {
 
 		clients = append(clients, map[string]interface{}{
 			"client_id":     clientID,
+			"namespace":     wrapper.namespace,
 			"connected_at":  wrapper.lastPing,
 			"subscriptions": subscriptions,
 		})
 	}
 
 	return map[string]interface{}{
-		"total_clients": len(gw.connections),
-		"clients":       clients,
+		"total_clients":   len(gw.connections),
+		"stream_clients":  len(gw.streams),
+		"max_connections": gw.maxConnections,
+		"clients":         clients,
+		"queue_depth":     len(gw.eventChan),
+		"queue_capacity":  cap(gw.eventChan),
+		"dropped_events":  atomic.LoadInt64(&gw.droppedEvents),
 	}
 }
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/yourusername/aiops-infra/src/internal/datasource"
+)
+
+func TestWebSocketGateway_Coalesce(t *testing.T) {
+	gw := NewWebSocketGateway()
+
+	event := Event{Type: EventAnomalyDetected, Topic: TopicAnomalies, CorrelationKey: "cpu-spike"}
+	if gw.coalesce(event) {
+		t.Fatal("dedup should be disabled by default")
+	}
+
+	gw.SetDedupWindow(time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if !gw.coalesce(event) {
+			t.Fatalf("event %d with correlation key should be coalesced", i)
+		}
+	}
+
+	if gw.coalesce(Event{Type: EventAnomalyDetected, Topic: TopicAnomalies}) {
+		t.Error("events without correlation key should not be coalesced")
+	}
+
+	gw.dedupMutex.Lock()
+	pending := gw.dedupPending["cpu-spike"]
+	gw.dedupMutex.Unlock()
+
+	if pending == nil || pending.Occurrences != 3 {
+		t.Fatalf("expected 3 coalesced occurrences, got %+v", pending)
+	}
+
+	gw.flushCoalesced("cpu-spike")
+	if _, exists := gw.dedupPending["cpu-spike"]; exists {
+		t.Error("flush should clear the pending event")
+	}
+}
+
+func TestWebSocketGateway_SendEventBackpressure(t *testing.T) {
+	gw := NewWebSocketGateway()
+	gw.SetQueueCapacity(1)
+
+	gw.SendEvent(Event{Type: EventHeartbeat, Topic: TopicSystem})
+	gw.SendEvent(Event{Type: EventHeartbeat, Topic: TopicSystem})
+
+	info := gw.GetClientInfo()
+	if info["queue_depth"] != 1 || info["queue_capacity"] != 1 {
+		t.Errorf("expected queue depth 1 of capacity 1, got %v of %v", info["queue_depth"], info["queue_capacity"])
+	}
+	if info["dropped_events"] != int64(1) {
+		t.Errorf("expected 1 dropped event, got %v", info["dropped_events"])
+	}
+
+	// With a send timeout the event is queued once space frees up
+	gw.SetSendTimeout(time.Second)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-gw.eventChan
+	}()
+	gw.SendEvent(Event{Type: EventHeartbeat, Topic: TopicSystem})
+
+	if dropped := gw.GetClientInfo()["dropped_events"]; dropped != int64(1) {
+		t.Errorf("expected the blocked event to be queued, dropped = %v", dropped)
+	}
+	if len(gw.eventChan) != 1 {
+		t.Errorf("expected 1 queued event, got %d", len(gw.eventChan))
+	}
+}
+
+func TestWebSocketGateway_Batching(t *testing.T) {
+	gw := NewWebSocketGateway()
+	wrapper := &ConnectionWrapper{
+		clientID:      "client_1",
+		subscriptions: map[string]bool{TopicAnomalies: true, TopicDetectors: true},
+		batchTopics:   map[string]bool{TopicAnomalies: true},
+		batches:       make(map[string][]Event),
+	}
+
+	event := Event{Type: EventAnomalyDetected, Topic: TopicAnomalies}
+	for i := 0; i < 3; i++ {
+		if !gw.addToBatch(wrapper, event, time.Hour) {
+			t.Fatalf("event %d of a batched topic should be buffered", i)
+		}
+	}
+	if gw.addToBatch(wrapper, Event{Type: EventDetectorUpdated, Topic: TopicDetectors}, time.Hour) {
+		t.Error("events of topics subscribed without batch should be sent right away")
+	}
+	if n := len(wrapper.batches[TopicAnomalies]); n != 3 {
+		t.Fatalf("expected 3 buffered events, got %d", n)
+	}
+
+	gw.flushBatch(wrapper, TopicAnomalies)
+	if n := len(wrapper.batches[TopicAnomalies]); n != 0 {
+		t.Errorf("flush should clear the batch, %d events left", n)
+	}
+
+	// A full batch is sent without waiting for the interval
+	for i := 0; i < MaxBatchSize; i++ {
+		gw.addToBatch(wrapper, event, time.Hour)
+	}
+	if n := len(wrapper.batches[TopicAnomalies]); n != 0 {
+		t.Errorf("expected a full batch to be flushed early, %d events left", n)
+	}
+}
+
+func TestWebSocketGateway_Shutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The gateway is not started, so queued events wait for Shutdown
+	gw := NewWebSocketGateway()
+	gw.SetDedupWindow(time.Hour)
+
+	router := gin.New()
+	router.GET("/ws", gw.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var event Event
+	if err := conn.ReadJSON(&event); err != nil || event.Type != "connected" {
+		t.Fatalf("expected a connected event, got %+v: %v", event, err)
+	}
+
+	gw.SendEvent(Event{Type: EventDetectorHealth, Topic: TopicSystem})
+	gw.SendEvent(Event{Type: EventDetectorStatus, Topic: TopicSystem})
+	gw.coalesce(Event{Type: EventAnomalyDetected, Topic: TopicSystem, CorrelationKey: "cpu-spike"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := gw.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	received := make(map[string]bool)
+	for {
+		var event Event
+		if err := conn.ReadJSON(&event); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+				t.Errorf("expected a going-away close, got %v", err)
+			}
+			break
+		}
+		received[event.Type] = true
+	}
+	for _, eventType := range []string{EventDetectorHealth, EventDetectorStatus, EventAnomalyDetected} {
+		if !received[eventType] {
+			t.Errorf("expected %s to be delivered before close, got %v", eventType, received)
+		}
+	}
+
+	if gw.reserveSlot() {
+		t.Error("expected new connections to be refused after shutdown")
+	}
+	if err := gw.Shutdown(ctx); err != nil {
+		t.Errorf("expected a second shutdown to be a no-op, got %v", err)
+	}
+}
+
+func TestPublishScore_DetectorTopic(t *testing.T) {
+	s := newIngestTestServer("running", &thresholdDetector{limit: 10})
+	pointTime := time.Now().Add(-time.Minute)
+
+	// Without subscribers no score events are queued
+	s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{{Value: 5}})
+	if n := len(s.wsGateway.eventChan); n != 0 {
+		t.Fatalf("expected no events without subscribers, got %d", n)
+	}
+
+	wrapper := &ConnectionWrapper{
+		clientID:      "client_1",
+		namespace:     "team-a",
+		subscriptions: make(map[string]bool),
+		batchTopics:   make(map[string]bool),
+		batches:       make(map[string][]Event),
+	}
+	s.wsGateway.connections[wrapper.clientID] = wrapper
+	s.wsGateway.handleClientMessage(wrapper, map[string]interface{}{"type": "subscribe", "topic": "detector:detector_1"})
+
+	// A subscriber in another namespace does not see the detector's scores
+	s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{{Value: 5}})
+	if n := len(s.wsGateway.eventChan); n != 0 {
+		t.Fatalf("expected no events for another namespace's subscriber, got %d", n)
+	}
+	wrapper.namespace = DefaultNamespace
+	if !s.wsGateway.HasSubscribers(DefaultNamespace, DetectorTopic("detector_1")) {
+		t.Fatal("expected the client to be subscribed to the detector topic")
+	}
+
+	s.IngestDataPoints(context.Background(), "detector_1", []datasource.DataPoint{
+		{Value: 5},
+		{Timestamp: pointTime, Value: 50},
+	})
+
+	var scores []DetectorScoreEvent
+	for len(s.wsGateway.eventChan) > 0 {
+		event := <-s.wsGateway.eventChan
+		if event.Type != EventDetectorScore {
+			continue
+		}
+		if event.Topic != "detector:detector_1" {
+			t.Errorf("expected the detector topic, got %q", event.Topic)
+		}
+		scores = append(scores, event.Data.(DetectorScoreEvent))
+	}
+	if len(scores) != 2 {
+		t.Fatalf("expected a score event per point, got %d", len(scores))
+	}
+	if scores[0].IsAnomaly || !scores[1].IsAnomaly || scores[1].Value != 50 {
+		t.Errorf("unexpected score events: %+v", scores)
+	}
+	if !scores[1].Timestamp.Equal(pointTime) {
+		t.Errorf("expected the point's timestamp, got %v", scores[1].Timestamp)
+	}
+	// The test detector reports no score
+	if scores[0].Score != nil {
+		t.Errorf("expected no score, got %v", *scores[0].Score)
+	}
+}
+
+func TestSetWebSocketConfig(t *testing.T) {
+	s := &Server{wsGateway: NewWebSocketGateway()}
+
+	s.SetWebSocketConfig(WebSocketConfig{})
+	if s.wsGateway.maxConnections != DefaultMaxWebSocketConnections {
+		t.Errorf("expected zero fields to keep the defaults, got %d connections", s.wsGateway.maxConnections)
+	}
+	if s.wsGateway.batchInterval != DefaultBatchInterval || s.wsGateway.streamHeartbeat != DefaultStreamHeartbeatInterval {
+		t.Errorf("expected the default intervals, got %s and %s", s.wsGateway.batchInterval, s.wsGateway.streamHeartbeat)
+	}
+
+	s.SetWebSocketConfig(WebSocketConfig{
+		MaxConnections:          5,
+		DedupWindow:             time.Second,
+		QueueCapacity:           10,
+		SendTimeout:             time.Millisecond,
+		BatchInterval:           time.Second,
+		StreamHeartbeatInterval: time.Minute,
+	})
+	if s.wsGateway.maxConnections != 5 {
+		t.Errorf("expected 5 connections, got %d", s.wsGateway.maxConnections)
+	}
+	if s.wsGateway.dedupWindow != time.Second {
+		t.Errorf("expected a 1s dedup window, got %s", s.wsGateway.dedupWindow)
+	}
+	if cap(s.wsGateway.eventChan) != 10 || s.wsGateway.sendTimeout != time.Millisecond {
+		t.Errorf("expected a queue of 10 with a 1ms send timeout, got %d and %s", cap(s.wsGateway.eventChan), s.wsGateway.sendTimeout)
+	}
+	if s.wsGateway.batchInterval != time.Second {
+		t.Errorf("expected a 1s batch interval, got %s", s.wsGateway.batchInterval)
+	}
+	if s.wsGateway.streamHeartbeat != time.Minute {
+		t.Errorf("expected a 1m stream heartbeat, got %s", s.wsGateway.streamHeartbeat)
+	}
+
+	s.SetWebSocketConfig(WebSocketConfig{MaxConnections: -1, BatchInterval: -1})
+	if s.wsGateway.maxConnections != 0 {
+		t.Errorf("expected a negative limit to disable the limit, got %d", s.wsGateway.maxConnections)
+	}
+	if s.wsGateway.batchInterval != 0 {
+		t.Errorf("expected a negative interval to disable batching, got %s", s.wsGateway.batchInterval)
+	}
+}
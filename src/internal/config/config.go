@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,24 +17,255 @@ type Config struct {
 	Kubernetes KubernetesConfig `yaml:"kubernetes"`
 	Slack      SlackConfig      `yaml:"slack"`
 	Email      EmailConfig      `yaml:"email"`
+	// Correlation объединяет аномалии логов и метрик в инциденты
+	Correlation CorrelationConfig `yaml:"correlation"`
+	// Orchestrator содержит настройки выполнения действий по исправлению
+	Orchestrator OrchestratorConfig `yaml:"orchestrator"`
+	// Profiles задает именованные профили детекторов: имя профиля -> тип детектора -> настройки
+	Profiles map[string]map[string]DetectorProfileConfig `yaml:"profiles"`
+	// SavedQueries задает именованные запросы с подстановкой переменных ${var}
+	SavedQueries map[string]SavedQueryConfig `yaml:"savedQueries"`
+	// Enrichment добавляет к аномалиям поля (команда, runbook) по их меткам
+	Enrichment []EnrichmentRuleConfig `yaml:"enrichment"`
+	// Tracing включает трассировку запросов с экспортом в OpenTelemetry collector
+	Tracing TracingConfig `yaml:"tracing"`
+	// AlertRoutes сопоставляют алерты Alertmanager действиям оркестратора; первое
+	// совпавшее правило выбирает действие, алерты без правила отправляются уведомлением
+	AlertRoutes []AlertRouteConfig `yaml:"alertRoutes"`
+}
+
+// AlertRouteConfig задает действие для алертов с указанными alertname и severity
+// (пустое значение - любое)
+type AlertRouteConfig struct {
+	AlertName string            `yaml:"alertname"`
+	Severity  string            `yaml:"severity"`
+	Action    AlertActionConfig `yaml:"action"`
+}
+
+// AlertActionConfig содержит действие оркестратора: restart, scale, notify или exec_script.
+// Пустой target заменяется компонентом из меток алерта
+type AlertActionConfig struct {
+	Type       string            `yaml:"type"`
+	Target     string            `yaml:"target"`
+	Namespace  string            `yaml:"namespace"`
+	Parameters map[string]string `yaml:"parameters"`
+	Timeout    time.Duration     `yaml:"timeout"`
 }
 
 // APIConfig содержит настройки API сервера
 type APIConfig struct {
-	Port int    `yaml:"port"`
-	Host string `yaml:"host"`
+	Port       int              `yaml:"port"`
+	Host       string           `yaml:"host"`
+	DetectorGC DetectorGCConfig `yaml:"detectorGC"`
+	CORS       CORSConfig       `yaml:"cors"`
+	// GRPCPort - порт gRPC-сервиса потоковой детекции (0 - выключен)
+	GRPCPort int `yaml:"grpcPort"`
+	// NonFiniteFloats задает кодирование NaN и ±Inf в ответах: null (по умолчанию) или clamp
+	NonFiniteFloats string `yaml:"nonFiniteFloats"`
+	// Detection ограничивает число параллельных детекций
+	Detection DetectionConcurrencyConfig `yaml:"detection"`
+	// Analyze задает значения по умолчанию для анализа исторических данных
+	Analyze AnalyzeConfig `yaml:"analyze"`
+	// AnomalyPersistence сохраняет аномалии в файл пакетами (выключено, если path пуст)
+	AnomalyPersistence AnomalyPersistenceConfig `yaml:"anomalyPersistence"`
+	// MaxQueryRange ограничивает интервал запросов по диапазону времени
+	// (0 - по умолчанию 7 дней, отрицательное значение снимает ограничение)
+	MaxQueryRange time.Duration `yaml:"maxQueryRange"`
+	// WebSocket содержит настройки шлюза событий WebSocket и SSE
+	WebSocket WebSocketConfig `yaml:"websocket"`
+}
+
+// WebSocketConfig содержит настройки шлюза событий (0 - значения по умолчанию)
+type WebSocketConfig struct {
+	// MaxConnections ограничивает число клиентов (по умолчанию 1000, отрицательное значение снимает ограничение)
+	MaxConnections int `yaml:"maxConnections"`
+	// DedupWindow - окно объединения событий с одинаковым ключом корреляции (0 - выключено)
+	DedupWindow time.Duration `yaml:"dedupWindow"`
+	// QueueCapacity - размер очереди исходящих событий (по умолчанию 100)
+	QueueCapacity int `yaml:"queueCapacity"`
+	// SendTimeout - сколько ждать места в заполненной очереди, прежде чем отбросить событие
+	// (0 - событие отбрасывается сразу)
+	SendTimeout time.Duration `yaml:"sendTimeout"`
+	// BatchInterval - интервал отправки событий тем, подписанных с batch (по умолчанию 100ms,
+	// отрицательное значение отключает пакетную отправку)
+	BatchInterval time.Duration `yaml:"batchInterval"`
+	// StreamHeartbeatInterval - интервал heartbeat для неактивных SSE-потоков (по умолчанию 15s)
+	StreamHeartbeatInterval time.Duration `yaml:"streamHeartbeatInterval"`
+}
+
+// AnalyzeConfig содержит окно анализа и целевое число точек для автоматического шага
+// (0 - значения по умолчанию: 1h и 500 точек)
+type AnalyzeConfig struct {
+	DefaultWindow time.Duration `yaml:"defaultWindow"`
+	TargetPoints  int           `yaml:"targetPoints"`
+}
+
+// AnomalyPersistenceConfig содержит настройки буфера записи аномалий:
+// аномалии пишутся пачками по batchSize или раз в flushInterval, при заполненной
+// очереди (queueSize) новые аномалии отбрасываются (0 - значения по умолчанию)
+type AnomalyPersistenceConfig struct {
+	Path          string        `yaml:"path"`
+	BatchSize     int           `yaml:"batchSize"`
+	FlushInterval time.Duration `yaml:"flushInterval"`
+	QueueSize     int           `yaml:"queueSize"`
+}
+
+// TracingConfig содержит настройки трассировки: спаны отправляются по OTLP/HTTP
+// на endpoint (например, http://otel-collector:4318), sampleRatio - доля
+// записываемых трасс (0 - все)
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Endpoint    string  `yaml:"endpoint"`
+	ServiceName string  `yaml:"serviceName"`
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+// DetectionConcurrencyConfig содержит ограничение параллельных детекций
+// (0 - значение по умолчанию, отрицательное значение снимает ограничение)
+type DetectionConcurrencyConfig struct {
+	MaxConcurrent int           `yaml:"maxConcurrent"`
+	QueueTimeout  time.Duration `yaml:"queueTimeout"`
+}
+
+// CORSConfig содержит настройки CORS для браузерных клиентов (пустые поля - значения по умолчанию)
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowedOrigins"`
+	AllowedMethods   []string      `yaml:"allowedMethods"`
+	AllowedHeaders   []string      `yaml:"allowedHeaders"`
+	AllowCredentials bool          `yaml:"allowCredentials"`
+	MaxAge           time.Duration `yaml:"maxAge"`
+}
+
+// DetectorGCConfig содержит настройки удаления неактивных остановленных детекторов
+type DetectorGCConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	TTL      time.Duration `yaml:"ttl"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// CorrelationConfig содержит настройки корреляции аномалий логов и метрик
+type CorrelationConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Window  time.Duration `yaml:"window"`
+	// MatchLabels - метки, значения которых должны совпадать (например, service)
+	MatchLabels []string `yaml:"matchLabels"`
+}
+
+// OrchestratorConfig содержит настройки оркестратора действий
+type OrchestratorConfig struct {
+	// DefaultRetry применяется к действиям, отправленным без retry_policy
+	DefaultRetry RetryConfig `yaml:"defaultRetry"`
+	// DefaultTimeout ограничивает каждую попытку действия, отправленного без timeout
+	// (0 - значение по умолчанию оркестратора, 5m)
+	DefaultTimeout time.Duration `yaml:"defaultTimeout"`
+	// NotificationRoutes направляют уведомления по меткам аномалии или алерта
+	// в отдельные каналы; первое совпавшее правило выбирает получателя
+	NotificationRoutes []NotificationRouteConfig `yaml:"notificationRoutes"`
+	// NotificationDigest собирает уведомления за окно и отправляет одну сводку
+	// вместо уведомления на каждую аномалию
+	NotificationDigest NotificationDigestConfig `yaml:"notificationDigest"`
+	// NotificationPayloadFormat - формат тела webhook-уведомлений без payload_format:
+	// default, flat или nested (по умолчанию default)
+	NotificationPayloadFormat string `yaml:"notificationPayloadFormat"`
+}
+
+// NotificationDigestConfig содержит настройки режима сводки уведомлений
+type NotificationDigestConfig struct {
+	// Window - длительность сбора уведомлений (0 - режим сводки отключен)
+	Window time.Duration `yaml:"window"`
+	// TopMetrics - число самых частых метрик в сводке (по умолчанию 5)
+	TopMetrics int `yaml:"topMetrics"`
+}
+
+// NotificationRouteConfig задает канал (slack, email или webhook) для уведомлений,
+// метки которых совпадают со всеми метками из match (пустой match - все уведомления)
+type NotificationRouteConfig struct {
+	Match      map[string]string `yaml:"match"`
+	Type       string            `yaml:"type"`
+	WebhookURL string            `yaml:"webhookUrl"`
+	To         []string          `yaml:"to"`
+}
+
+// RetryConfig содержит политику повторов (maxRetries = 0 отключает повторы)
+type RetryConfig struct {
+	MaxRetries  int           `yaml:"maxRetries"`
+	Interval    time.Duration `yaml:"interval"`
+	MaxInterval time.Duration `yaml:"maxInterval"`
+	Multiplier  float64       `yaml:"multiplier"`
+	// Jitter - доля случайного разброса интервала (0..1)
+	Jitter float64 `yaml:"jitter"`
+	// MaxDuration ограничивает общее время повторов
+	MaxDuration time.Duration `yaml:"maxDuration"`
+}
+
+// DetectorProfileConfig содержит порог и параметры профиля для одного типа детектора
+type DetectorProfileConfig struct {
+	Threshold  float64                `yaml:"threshold"`
+	Parameters map[string]interface{} `yaml:"parameters"`
+}
+
+// SavedQueryConfig содержит шаблон запроса Prometheus или Loki
+type SavedQueryConfig struct {
+	// Source - prometheus (по умолчанию) или loki
+	Source      string `yaml:"source"`
+	Query       string `yaml:"query"`
+	Description string `yaml:"description"`
+}
+
+// EnrichmentRuleConfig задает поля, добавляемые к аномалиям, у которых
+// все метки из match совпадают; правило без match применяется ко всем аномалиям
+type EnrichmentRuleConfig struct {
+	Match  map[string]string `yaml:"match"`
+	Fields map[string]string `yaml:"fields"`
 }
 
 // PrometheusConfig содержит настройки для подключения к Prometheus
 type PrometheusConfig struct {
-	URL     string `yaml:"url"`
-	Enabled bool   `yaml:"enabled"`
+	URL     string    `yaml:"url"`
+	Enabled bool      `yaml:"enabled"`
+	TLS     TLSConfig `yaml:"tls"`
+	// Sources - именованные серверы Prometheus (например, по регионам), первый используется
+	// по умолчанию. Если список пуст, единственный источник "default" задают url и tls.
+	Sources []PrometheusSourceConfig `yaml:"sources"`
+}
+
+// PrometheusSourceConfig содержит настройки именованного источника Prometheus
+type PrometheusSourceConfig struct {
+	Name string    `yaml:"name"`
+	URL  string    `yaml:"url"`
+	TLS  TLSConfig `yaml:"tls"`
+}
+
+// SourceList возвращает источники Prometheus, первый из которых используется по умолчанию
+func (c PrometheusConfig) SourceList() []PrometheusSourceConfig {
+	if len(c.Sources) > 0 {
+		return c.Sources
+	}
+	return []PrometheusSourceConfig{{Name: "default", URL: c.URL, TLS: c.TLS}}
 }
 
 // LokiConfig содержит настройки для подключения к Loki
 type LokiConfig struct {
-	URL     string `yaml:"url"`
-	Enabled bool   `yaml:"enabled"`
+	URL     string    `yaml:"url"`
+	Enabled bool      `yaml:"enabled"`
+	TLS     TLSConfig `yaml:"tls"`
+	// LevelFields - поля JSON/logfmt логов, содержащие уровень (по умолчанию level, severity)
+	LevelFields []string `yaml:"levelFields"`
+	// AnomalyRetention - сколько хранить недавние аномалии логов (по умолчанию 24h)
+	AnomalyRetention time.Duration `yaml:"anomalyRetention"`
+	// MaxAnomalies - жесткий лимит количества хранимых аномалий (по умолчанию 1000)
+	MaxAnomalies int `yaml:"maxAnomalies"`
+	// MaxPatterns - лимит количества шаблонов аномалий (0 - по умолчанию 500, отрицательное - без ограничения)
+	MaxPatterns int `yaml:"maxPatterns"`
+}
+
+// TLSConfig содержит настройки клиентского TLS (mTLS) для подключения к бэкендам
+type TLSConfig struct {
+	CertFile           string `yaml:"certFile"`
+	KeyFile            string `yaml:"keyFile"`
+	CAFile             string `yaml:"caFile"`
+	ServerName         string `yaml:"serverName"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
 }
 
 // KubernetesConfig содержит настройки для подключения к Kubernetes
@@ -83,6 +316,12 @@ type LokiPatterns struct {
 			Warning  int `yaml:"warning"`
 		} `yaml:"warnings"`
 		TimeWindow int `yaml:"timeWindow"`
+		// Overrides задают пороги для потоков с указанными метками; 0 - глобальный порог
+		Overrides []struct {
+			Labels   []string `yaml:"labels"`
+			Errors   int      `yaml:"errors"`
+			Warnings int      `yaml:"warnings"`
+		} `yaml:"overrides"`
 	} `yaml:"thresholds"`
 }
 
@@ -168,9 +407,21 @@ func setDefaults(config *Config) {
 	if config.API.Host == "" {
 		config.API.Host = "0.0.0.0"
 	}
+	if config.API.DetectorGC.Enabled && config.API.DetectorGC.TTL == 0 {
+		config.API.DetectorGC.TTL = 24 * time.Hour
+	}
+	if config.API.Detection.MaxConcurrent > 0 && config.API.Detection.QueueTimeout == 0 {
+		config.API.Detection.QueueTimeout = 100 * time.Millisecond
+	}
+	if config.Correlation.Enabled && config.Correlation.Window == 0 {
+		config.Correlation.Window = 5 * time.Minute
+	}
+	if config.Orchestrator.DefaultRetry.MaxRetries > 0 && config.Orchestrator.DefaultRetry.Interval == 0 {
+		config.Orchestrator.DefaultRetry.Interval = 5 * time.Second
+	}
 
 	// Prometheus настройки по умолчанию
-	if config.Prometheus.URL == "" {
+	if config.Prometheus.URL == "" && len(config.Prometheus.Sources) == 0 {
 		config.Prometheus.URL = "http://prometheus:9090"
 	}
 	// По умолчанию Prometheus включен
@@ -196,6 +447,67 @@ func validateConfig(config *Config) error {
 	if config.API.Port < 0 || config.API.Port > 65535 {
 		return fmt.Errorf("некорректный порт API: %d", config.API.Port)
 	}
+	if config.API.GRPCPort < 0 || config.API.GRPCPort > 65535 {
+		return fmt.Errorf("некорректный порт gRPC: %d", config.API.GRPCPort)
+	}
+
+	// Проверка буфера записи аномалий
+	persistence := config.API.AnomalyPersistence
+	if persistence.BatchSize < 0 || persistence.FlushInterval < 0 || persistence.QueueSize < 0 {
+		return fmt.Errorf("некорректные настройки сохранения аномалий: отрицательные значения")
+	}
+
+	// Проверка настроек шлюза событий
+	if ws := config.API.WebSocket; ws.DedupWindow < 0 || ws.QueueCapacity < 0 || ws.SendTimeout < 0 || ws.StreamHeartbeatInterval < 0 {
+		return fmt.Errorf("некорректные настройки шлюза событий: отрицательные значения")
+	}
+
+	// Проверка настроек трассировки
+	if config.Tracing.Enabled && config.Tracing.Endpoint == "" {
+		return fmt.Errorf("не указан endpoint для трассировки")
+	}
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("некорректная доля трассировки: %g (допустимо 0..1)", config.Tracing.SampleRatio)
+	}
+
+	// Проверка политики повторов оркестратора
+	retry := config.Orchestrator.DefaultRetry
+	if retry.MaxRetries < 0 || retry.Interval < 0 || retry.MaxDuration < 0 {
+		return fmt.Errorf("некорректная политика повторов оркестратора: отрицательные значения")
+	}
+	if retry.Jitter < 0 || retry.Jitter > 1 {
+		return fmt.Errorf("некорректный jitter политики повторов: %g (допустимо 0..1)", retry.Jitter)
+	}
+	if config.Orchestrator.DefaultTimeout < 0 {
+		return fmt.Errorf("некорректный таймаут действий оркестратора: %s", config.Orchestrator.DefaultTimeout)
+	}
+	if digest := config.Orchestrator.NotificationDigest; digest.Window < 0 || digest.TopMetrics < 0 {
+		return fmt.Errorf("некорректные настройки сводки уведомлений: отрицательные значения")
+	}
+
+	// Проверка правил алертов
+	for i, route := range config.AlertRoutes {
+		switch route.Action.Type {
+		case "restart", "scale", "notify", "exec_script":
+		default:
+			return fmt.Errorf("некорректный тип действия в alertRoutes[%d]: %q", i, route.Action.Type)
+		}
+		if route.Action.Timeout < 0 {
+			return fmt.Errorf("некорректный таймаут действия в alertRoutes[%d]: %s", i, route.Action.Timeout)
+		}
+	}
+
+	// Проверка источников Prometheus
+	sourceNames := make(map[string]bool)
+	for _, source := range config.Prometheus.Sources {
+		if source.Name == "" || source.URL == "" {
+			return fmt.Errorf("источник Prometheus должен иметь имя и URL")
+		}
+		if sourceNames[source.Name] {
+			return fmt.Errorf("повторяющийся источник Prometheus: %s", source.Name)
+		}
+		sourceNames[source.Name] = true
+	}
 
 	// Проверка настроек Slack
 	if config.Slack.WebhookURL != "" && config.Slack.Channel == "" {
@@ -218,6 +530,77 @@ func validateConfig(config *Config) error {
 	return nil
 }
 
+// redactedValue заменяет секреты в выводе эффективной конфигурации
+const redactedValue = "REDACTED"
+
+// Redacted возвращает копию конфигурации со скрытыми секретами (пароли, webhook URL, токены)
+func (c Config) Redacted() Config {
+	if c.Slack.WebhookURL != "" {
+		c.Slack.WebhookURL = redactedValue
+	}
+	if c.Email.Password != "" {
+		c.Email.Password = redactedValue
+	}
+	// Маршруты копируются, чтобы не изменить исходную конфигурацию
+	routes := make([]NotificationRouteConfig, len(c.Orchestrator.NotificationRoutes))
+	for i, route := range c.Orchestrator.NotificationRoutes {
+		if route.WebhookURL != "" {
+			route.WebhookURL = redactedValue
+		}
+		routes[i] = route
+	}
+	if c.Orchestrator.NotificationRoutes != nil {
+		c.Orchestrator.NotificationRoutes = routes
+	}
+	// Параметры действий (например, webhook_url уведомления) тоже могут содержать секреты
+	if c.AlertRoutes != nil {
+		alertRoutes := make([]AlertRouteConfig, len(c.AlertRoutes))
+		for i, route := range c.AlertRoutes {
+			route.Action.Parameters = redactedParameters(route.Action.Parameters)
+			alertRoutes[i] = route
+		}
+		c.AlertRoutes = alertRoutes
+	}
+	return c
+}
+
+// secretParameterKeys - части имен параметров, значения которых скрываются
+var secretParameterKeys = []string{"webhook_url", "password", "token", "secret"}
+
+// redactedParameters возвращает копию параметров со скрытыми значениями секретов
+func redactedParameters(parameters map[string]string) map[string]string {
+	if parameters == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(parameters))
+	for key, value := range parameters {
+		lower := strings.ToLower(key)
+		for _, secret := range secretParameterKeys {
+			if value != "" && strings.Contains(lower, secret) {
+				value = redactedValue
+				break
+			}
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// RedactedMap возвращает конфигурацию со скрытыми секретами в виде карты
+// с ключами как в YAML (длительности - строками вида "5m0s")
+func (c Config) RedactedMap() (map[string]interface{}, error) {
+	data, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка маршалинга конфигурации: %w", err)
+	}
+
+	var effective map[string]interface{}
+	if err := yaml.Unmarshal(data, &effective); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга конфигурации: %w", err)
+	}
+	return effective, nil
+}
+
 // SaveConfig сохраняет конфигурацию в файл
 func SaveConfig(config *Config, configPath string) error {
 	// Кодирование в YAML
@@ -0,0 +1,55 @@
+package config
+
+import "testing"
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := Config{
+		Slack: SlackConfig{WebhookURL: "https://hooks.slack.com/services/T0/B0/secret"},
+		AlertRoutes: []AlertRouteConfig{{
+			AlertName: "HighLatency",
+			Action: AlertActionConfig{
+				Type: "notify",
+				Parameters: map[string]string{
+					"webhook_url":   "https://hooks.example.com/pager",
+					"api_token":     "t0ken",
+					"SMTP_Password": "hunter2",
+					"client_secret": "s3cret",
+					"channel":       "#oncall",
+				},
+			},
+		}},
+	}
+	cfg.Orchestrator.NotificationRoutes = []NotificationRouteConfig{{Type: "webhook", WebhookURL: "https://hooks.example.com/team"}}
+
+	redacted := cfg.Redacted()
+
+	if redacted.Slack.WebhookURL != redactedValue || redacted.Orchestrator.NotificationRoutes[0].WebhookURL != redactedValue {
+		t.Errorf("expected the Slack and notification route webhooks to be redacted, got %+v", redacted)
+	}
+	parameters := redacted.AlertRoutes[0].Action.Parameters
+	for _, key := range []string{"webhook_url", "api_token", "SMTP_Password", "client_secret"} {
+		if parameters[key] != redactedValue {
+			t.Errorf("expected alert route parameter %s to be redacted, got %q", key, parameters[key])
+		}
+	}
+	if parameters["channel"] != "#oncall" {
+		t.Errorf("expected other parameters to be kept, got %q", parameters["channel"])
+	}
+
+	// The original configuration is left untouched
+	if cfg.AlertRoutes[0].Action.Parameters["webhook_url"] != "https://hooks.example.com/pager" ||
+		cfg.Orchestrator.NotificationRoutes[0].WebhookURL != "https://hooks.example.com/team" {
+		t.Errorf("expected the original configuration to keep its secrets, got %+v", cfg)
+	}
+
+	// The effective-config map is built from the redacted copy
+	effective, err := cfg.RedactedMap()
+	if err != nil {
+		t.Fatalf("RedactedMap failed: %v", err)
+	}
+	routes := effective["alertRoutes"].([]interface{})
+	action := routes[0].(map[string]interface{})["action"].(map[string]interface{})
+	if action["parameters"].(map[string]interface{})["webhook_url"] != redactedValue {
+		t.Errorf("expected the effective config to hide the webhook URL, got %v", action)
+	}
+}
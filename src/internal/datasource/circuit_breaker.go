@@ -0,0 +1,153 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerState represents the state of a circuit breaker
+type BreakerState string
+
+const (
+	// BreakerClosed lets all calls through
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen fast-fails all calls until the cooldown elapses
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single probe call through
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// ErrCircuitOpen is returned when a call is rejected by an open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker stops calling a failing backend after consecutive failures
+// and probes it again after a cooldown
+type CircuitBreaker struct {
+	name             string
+	failureThreshold int
+	cooldown         time.Duration
+
+	state         BreakerState
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+	lastError     string
+	mu            sync.Mutex
+}
+
+// BreakerStats is a snapshot of circuit breaker state
+type BreakerStats struct {
+	Name                string       `json:"name"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	FailureThreshold    int          `json:"failure_threshold"`
+	Cooldown            string       `json:"cooldown"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	LastError           string       `json:"last_error,omitempty"`
+}
+
+// NewCircuitBreaker creates a circuit breaker that opens after failureThreshold
+// consecutive failures and stays open for cooldown
+func NewCircuitBreaker(name string, failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+
+	return &CircuitBreaker{
+		name:             name,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		state:            BreakerClosed,
+	}
+}
+
+// Allow reports whether a call may proceed, returning ErrCircuitOpen otherwise
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case BreakerOpen:
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return fmt.Errorf("%s: %w (retry in %s)", cb.name, ErrCircuitOpen,
+				(cb.cooldown - time.Since(cb.openedAt)).Round(time.Second))
+		}
+		// Cooldown elapsed, let one probe through
+		cb.state = BreakerHalfOpen
+		cb.probeInFlight = true
+		return nil
+
+	case BreakerHalfOpen:
+		if cb.probeInFlight {
+			return fmt.Errorf("%s: %w (probe in progress)", cb.name, ErrCircuitOpen)
+		}
+		cb.probeInFlight = true
+		return nil
+	}
+
+	return nil
+}
+
+// Record records the outcome of a call allowed by Allow and made with ctx.
+// A call cut short by its caller's context being canceled or timing out says
+// nothing about the backend: it neither counts as a failure nor closes the
+// breaker, and a half-open breaker lets the next probe through.
+func (cb *CircuitBreaker) Record(ctx context.Context, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probeInFlight = false
+
+	if err != nil && ctx.Err() != nil &&
+		(errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return
+	}
+
+	if err == nil {
+		cb.state = BreakerClosed
+		cb.failures = 0
+		cb.lastError = ""
+		return
+	}
+
+	cb.failures++
+	cb.lastError = err.Error()
+
+	if cb.state == BreakerHalfOpen || cb.failures >= cb.failureThreshold {
+		cb.state = BreakerOpen
+		cb.openedAt = time.Now()
+	}
+}
+
+// State returns the current breaker state
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// Stats returns a snapshot of the breaker state
+func (cb *CircuitBreaker) Stats() BreakerStats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	stats := BreakerStats{
+		Name:                cb.name,
+		State:               cb.state,
+		ConsecutiveFailures: cb.failures,
+		FailureThreshold:    cb.failureThreshold,
+		Cooldown:            cb.cooldown.String(),
+		LastError:           cb.lastError,
+	}
+	if cb.state != BreakerClosed {
+		openedAt := cb.openedAt
+		stats.OpenedAt = &openedAt
+	}
+	return stats
+}
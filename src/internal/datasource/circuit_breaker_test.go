@@ -0,0 +1,91 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_StateTransitions(t *testing.T) {
+	ctx := context.Background()
+	backendDown := errors.New("connection refused")
+	cb := NewCircuitBreaker("prometheus", 2, time.Hour)
+
+	// Consecutive failures open the breaker, a success in between resets them
+	cb.Record(ctx, backendDown)
+	cb.Record(ctx, nil)
+	cb.Record(ctx, backendDown)
+	if cb.State() != BreakerClosed {
+		t.Fatalf("expected the breaker to stay closed, got %s", cb.State())
+	}
+	cb.Record(ctx, backendDown)
+	if cb.State() != BreakerOpen {
+		t.Fatalf("expected the breaker to open after 2 consecutive failures, got %s", cb.State())
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected an open breaker to fast-fail, got %v", err)
+	}
+
+	// Once the cooldown elapsed a single probe goes through
+	cb.openedAt = time.Now().Add(-2 * time.Hour)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("expected a probe after the cooldown, got %v", err)
+	}
+	if cb.State() != BreakerHalfOpen {
+		t.Fatalf("expected the breaker to be half-open, got %s", cb.State())
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a second call during the probe to fast-fail, got %v", err)
+	}
+
+	// A failed probe reopens the breaker, a successful one closes it
+	cb.Record(ctx, backendDown)
+	if cb.State() != BreakerOpen {
+		t.Fatalf("expected a failed probe to reopen the breaker, got %s", cb.State())
+	}
+	cb.openedAt = time.Now().Add(-2 * time.Hour)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("expected a probe after the cooldown, got %v", err)
+	}
+	cb.Record(ctx, nil)
+	if stats := cb.Stats(); stats.State != BreakerClosed || stats.ConsecutiveFailures != 0 {
+		t.Errorf("expected a successful probe to close the breaker, got %+v", stats)
+	}
+}
+
+func TestCircuitBreaker_IgnoresCallerContext(t *testing.T) {
+	cb := NewCircuitBreaker("loki", 1, time.Hour)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	cb.Record(canceled, fmt.Errorf("query: %w", context.Canceled))
+
+	expired, cancelExpired := context.WithTimeout(context.Background(), 0)
+	defer cancelExpired()
+	cb.Record(expired, fmt.Errorf("query: %w", context.DeadlineExceeded))
+
+	if stats := cb.Stats(); stats.State != BreakerClosed || stats.ConsecutiveFailures != 0 {
+		t.Fatalf("expected the caller's context errors not to count, got %+v", stats)
+	}
+
+	// A backend timeout on a live context is still a failure
+	cb.Record(context.Background(), fmt.Errorf("query: %w", context.DeadlineExceeded))
+	if cb.State() != BreakerOpen {
+		t.Fatalf("expected a backend timeout to open the breaker, got %s", cb.State())
+	}
+
+	// A probe canceled by its caller leaves the breaker half-open for the next probe
+	cb.openedAt = time.Now().Add(-2 * time.Hour)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("expected a probe after the cooldown, got %v", err)
+	}
+	cb.Record(canceled, context.Canceled)
+	if cb.State() != BreakerHalfOpen {
+		t.Fatalf("expected the breaker to stay half-open, got %s", cb.State())
+	}
+	if err := cb.Allow(); err != nil {
+		t.Errorf("expected another probe to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package datasource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/types"
+)
+
+// waitReady polls ready until it passes or the deadline expires
+func waitReady(t *testing.T, ready func(context.Context) error) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err := ready(context.Background())
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("collector did not become ready: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPrometheusCollector_Ready(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer server.Close()
+
+	collector, err := NewPrometheusCollector(server.URL, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("failed to create collector: %v", err)
+	}
+	healthy.Store(true)
+	if err := collector.Ready(context.Background()); err == nil {
+		t.Fatal("expected the collector not to be ready before Start")
+	}
+
+	healthy.Store(false)
+	collector.Start(context.Background())
+	defer collector.Stop()
+	time.Sleep(20 * time.Millisecond)
+	if err := collector.Ready(context.Background()); err == nil {
+		t.Fatal("expected the collector not to be ready while Prometheus fails")
+	}
+
+	healthy.Store(true)
+	waitReady(t, collector.Ready)
+}
+
+func TestLokiCollector_Ready(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ready"))
+	}))
+	defer server.Close()
+
+	collector, err := NewLokiCollector(server.URL, time.Hour, time.Minute, func(*types.LogStream) error { return nil })
+	if err != nil {
+		t.Fatalf("failed to create collector: %v", err)
+	}
+	healthy.Store(true)
+	if err := collector.Ready(context.Background()); err == nil {
+		t.Fatal("expected the collector not to be ready before Start")
+	}
+
+	healthy.Store(false)
+	collector.Start(context.Background())
+	defer collector.Stop()
+	time.Sleep(20 * time.Millisecond)
+	if err := collector.Ready(context.Background()); err == nil {
+		t.Fatal("expected the collector not to be ready while Loki is not ready")
+	}
+
+	healthy.Store(true)
+	waitReady(t, collector.Ready)
+}
@@ -9,9 +9,12 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/yourusername/aiops-infra/src/internal/tracing"
 	"github.com/yourusername/aiops-infra/src/internal/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // LokiCollector собирает логи из Loki
@@ -25,10 +28,17 @@ type LokiCollector struct {
 	done           chan struct{}
 	callback       types.LogCallback
 	lastQueryTimes map[string]time.Time
+	levelFields    []string
+	running        atomic.Bool // цикл сбора запущен
 }
 
 // NewLokiCollector создает новый коллектор логов Loki
 func NewLokiCollector(url string, interval, lookback time.Duration, callback types.LogCallback) (*LokiCollector, error) {
+	return NewLokiCollectorWithTLS(url, interval, lookback, callback, nil)
+}
+
+// NewLokiCollectorWithTLS создает коллектор логов Loki с настройками TLS (mTLS)
+func NewLokiCollectorWithTLS(url string, interval, lookback time.Duration, callback types.LogCallback, tlsConfig *TLSConfig) (*LokiCollector, error) {
 	if url == "" {
 		return nil, fmt.Errorf("URL не может быть пустым")
 	}
@@ -41,9 +51,15 @@ func NewLokiCollector(url string, interval, lookback time.Duration, callback typ
 		lookback = 5 * time.Minute
 	}
 
+	// Общий клиент (пул соединений), если он задан через SetHTTPClient
+	client, err := newHTTPClient(tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка настройки TLS: %w", err)
+	}
+
 	return &LokiCollector{
 		url:            url,
-		client:         &http.Client{Timeout: 30 * time.Second},
+		client:         client,
 		interval:       interval,
 		lookback:       lookback,
 		queries:        make(map[string]string),
@@ -61,6 +77,13 @@ func (lc *LokiCollector) AddQuery(name, query string) {
 	lc.lastQueryTimes[name] = time.Now().Add(-lc.lookback)
 }
 
+// SetLevelFields задает поля структурированных логов, из которых берется уровень
+func (lc *LokiCollector) SetLevelFields(fields ...string) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.levelFields = fields
+}
+
 // RemoveQuery удаляет запрос
 func (lc *LokiCollector) RemoveQuery(name string) {
 	lc.mu.Lock()
@@ -81,6 +104,9 @@ func (lc *LokiCollector) Stop() {
 
 // collectLoop запускает периодический сбор логов
 func (lc *LokiCollector) collectLoop(ctx context.Context) {
+	lc.running.Store(true)
+	defer lc.running.Store(false)
+
 	ticker := time.NewTicker(lc.interval)
 	defer ticker.Stop()
 
@@ -96,6 +122,29 @@ func (lc *LokiCollector) collectLoop(ctx context.Context) {
 	}
 }
 
+// Ready возвращает ошибку, пока цикл сбора не запущен или Loki не готов
+// принимать запросы (эндпоинт /ready)
+func (lc *LokiCollector) Ready(ctx context.Context) error {
+	if !lc.running.Load() {
+		return fmt.Errorf("сбор логов не запущен")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/ready", lc.url), nil)
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+	resp, err := lc.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к Loki: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("loki не готов: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // LogStreamInternal представляет внутренний поток логов
 type LogStreamInternal struct {
 	Labels  map[string]string
@@ -165,7 +214,14 @@ func (lc *LokiCollector) collectLogs(ctx context.Context) {
 }
 
 // queryLoki выполняет запрос к Loki API и возвращает логи
-func (lc *LokiCollector) queryLoki(ctx context.Context, query string, start, end time.Time) ([]*LogStreamInternal, error) {
+func (lc *LokiCollector) queryLoki(ctx context.Context, query string, start, end time.Time) (streams []*LogStreamInternal, err error) {
+	ctx, span := startQuerySpan(ctx, "loki.query_range", query)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.SetAttributes(attribute.Int("streams", len(streams)))
+		span.End()
+	}()
+
 	// Формируем URL запроса к Loki API
 	queryURL, err := url.Parse(fmt.Sprintf("%s/loki/api/v1/query_range", lc.url))
 	if err != nil {
@@ -201,11 +257,8 @@ func (lc *LokiCollector) queryLoki(ctx context.Context, query string, start, end
 	var lokiResponse struct {
 		Status string `json:"status"`
 		Data   struct {
-			ResultType string `json:"resultType"`
-			Result     []struct {
-				Stream map[string]string `json:"stream"`
-				Values [][]string        `json:"values"` // [timestamp, log]
-			} `json:"result"`
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
 		} `json:"data"`
 	}
 
@@ -217,11 +270,28 @@ func (lc *LokiCollector) queryLoki(ctx context.Context, query string, start, end
 		return nil, fmt.Errorf("Loki вернул статус: %s", lokiResponse.Status)
 	}
 
+	// Метрические запросы возвращают matrix/vector, а не потоки логов
+	if rt := lokiResponse.Data.ResultType; rt != "" && rt != "streams" {
+		return nil, fmt.Errorf("запрос вернул результат типа %s, для метрических запросов используйте EnhancedLokiClient.QueryMetric", rt)
+	}
+
+	var results []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][]string        `json:"values"` // [timestamp, log]
+	}
+	if err := json.Unmarshal(lokiResponse.Data.Result, &results); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга потоков Loki: %w", err)
+	}
+
+	lc.mu.RLock()
+	levelFields := lc.levelFields
+	lc.mu.RUnlock()
+
 	// Создаем результат
-	streams := make([]*LogStreamInternal, 0, len(lokiResponse.Data.Result))
+	streams = make([]*LogStreamInternal, 0, len(results))
 
 	// Обрабатываем результаты для каждого потока логов
-	for _, result := range lokiResponse.Data.Result {
+	for _, result := range results {
 		stream := &LogStreamInternal{
 			Labels:  result.Stream,
 			Entries: make([]LogEntryInternal, 0, len(result.Values)),
@@ -243,7 +313,7 @@ func (lc *LokiCollector) queryLoki(ctx context.Context, query string, start, end
 			content := value[1]
 
 			// Определяем уровень логирования из содержимого
-			level := extractLogLevel(content)
+			level := extractLogLevel(content, levelFields)
 
 			// Добавляем запись в поток
 			stream.Entries = append(stream.Entries, LogEntryInternal{
@@ -292,8 +362,113 @@ func (lc *LokiCollector) RunQuery(ctx context.Context, query string, start, end
 	return result, nil
 }
 
-// extractLogLevel извлекает уровень логирования из содержимого сообщения
-func extractLogLevel(content string) string {
+// defaultLevelFields - поля структурированных логов, из которых берется уровень
+var defaultLevelFields = []string{"level", "severity"}
+
+// extractLogLevel извлекает уровень логирования из содержимого сообщения.
+// Для структурированных логов (JSON или logfmt) уровень берется из первого найденного
+// поля levelFields (по умолчанию level, severity), иначе используется поиск подстрок.
+func extractLogLevel(content string, levelFields []string) string {
+	if len(levelFields) == 0 {
+		levelFields = defaultLevelFields
+	}
+
+	if value, ok := structuredLogField(content, levelFields); ok {
+		if level := normalizeLogLevel(value); level != "unknown" {
+			return level
+		}
+	}
+
+	return guessLogLevel(content)
+}
+
+// structuredLogField возвращает значение первого найденного поля из JSON или logfmt строки
+func structuredLogField(content string, fields []string) (string, bool) {
+	trimmed := strings.TrimSpace(content)
+
+	if strings.HasPrefix(trimmed, "{") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &entry); err != nil {
+			return "", false
+		}
+		for _, field := range fields {
+			if value, ok := entry[field].(string); ok {
+				return value, true
+			}
+		}
+		return "", false
+	}
+
+	pairs := parseLogfmt(trimmed)
+	for _, field := range fields {
+		if value, ok := pairs[field]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// parseLogfmt разбирает строку формата key=value key2="quoted value"
+func parseLogfmt(line string) map[string]string {
+	pairs := make(map[string]string)
+
+	for len(line) > 0 {
+		line = strings.TrimLeft(line, " ")
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			break
+		}
+		key := line[:eq]
+		if strings.ContainsAny(key, " \"") {
+			// Не logfmt: текст перед "=" содержит пробелы или кавычки
+			key = key[strings.LastIndexAny(key, " \"")+1:]
+			if key == "" {
+				break
+			}
+		}
+		line = line[eq+1:]
+
+		var value string
+		if strings.HasPrefix(line, "\"") {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				break
+			}
+			value = line[1 : end+1]
+			line = line[end+2:]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value = line[:end]
+			line = line[end:]
+		}
+
+		pairs[key] = value
+	}
+
+	return pairs
+}
+
+// normalizeLogLevel приводит значение поля уровня к error, warning, info, debug или unknown
+func normalizeLogLevel(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "error", "err", "erro", "fatal", "panic", "critical", "crit", "alert", "emerg", "emergency":
+		return "error"
+	case "warning", "warn":
+		return "warning"
+	case "info", "information", "notice":
+		return "info"
+	case "debug", "trace":
+		return "debug"
+	default:
+		return "unknown"
+	}
+}
+
+// guessLogLevel определяет уровень по подстрокам в неструктурированном сообщении
+func guessLogLevel(content string) string {
 	content = strings.ToLower(content)
 
 	if strings.Contains(content, "error") || strings.Contains(content, "err]") || strings.Contains(content, "erro]") {
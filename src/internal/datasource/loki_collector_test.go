@@ -0,0 +1,50 @@
+package datasource
+
+import "testing"
+
+func TestExtractLogLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		levelFields []string
+		want        string
+	}{
+		{"json level", `{"level":"warn","msg":"error budget at 80%"}`, nil, "warning"},
+		{"json severity", `{"severity":"CRITICAL","msg":"disk full"}`, nil, "error"},
+		{"json custom field", `{"lvl":"debug","msg":"retrying after error"}`, []string{"lvl"}, "debug"},
+		{"logfmt level", `ts=2024-01-01T00:00:00Z level=info msg="request error count reset"`, nil, "info"},
+		{"logfmt quoted level", `level="error" msg=timeout`, nil, "error"},
+		{"logfmt after text", `GET /health level=debug`, nil, "debug"},
+		{"unknown level falls back", `level=verbose msg="connection error"`, nil, "error"},
+		{"invalid json falls back", `{"level":"info", broken warn`, nil, "warning"},
+		{"plain text", "[ERRO] failed to connect", nil, "error"},
+		{"no level", "user logged in", nil, "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractLogLevel(tt.content, tt.levelFields); got != tt.want {
+				t.Errorf("extractLogLevel(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	pairs := parseLogfmt(`level=warn msg="slow query" duration=1.5s empty=""`)
+
+	want := map[string]string{"level": "warn", "msg": "slow query", "duration": "1.5s", "empty": ""}
+	if len(pairs) != len(want) {
+		t.Fatalf("expected %d pairs, got %v", len(want), pairs)
+	}
+	for key, value := range want {
+		if pairs[key] != value {
+			t.Errorf("expected %s=%q, got %q", key, value, pairs[key])
+		}
+	}
+
+	// An unterminated quote ends parsing instead of swallowing the rest
+	if pairs := parseLogfmt(`level=info msg="unterminated`); pairs["level"] != "info" || len(pairs) != 1 {
+		t.Errorf("expected only level to be parsed, got %v", pairs)
+	}
+}
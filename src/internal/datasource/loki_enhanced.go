@@ -1,12 +1,17 @@
 package datasource
 
 import (
+	"container/list"
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -174,6 +179,8 @@ type EnhancedLokiClient struct {
 	client         *http.Client
 	patternCache   *patternCache
 	analysisConfig *LogAnalysisConfig
+	breaker        *CircuitBreaker
+	queryCache     *lokiQueryCache
 	mu             sync.RWMutex
 }
 
@@ -184,6 +191,19 @@ type LogAnalysisConfig struct {
 	PerformancePattern *regexp.Regexp
 	MaxSampleSize      int
 	AnalysisWindow     time.Duration
+	// ChunkSize splits long AnalyzeLogs ranges into sub-queries to stay under Loki's max query length
+	ChunkSize          time.Duration
+	TLS                *TLSConfig
+	// Circuit breaker: open after BreakerThreshold consecutive failed queries for BreakerCooldown
+	BreakerThreshold   int
+	BreakerCooldown    time.Duration
+	// LevelFields are the JSON/logfmt fields holding the log level (default: level, severity)
+	LevelFields        []string
+	// CacheDuration is how long results for an identical query and range are reused (0 disables)
+	CacheDuration      time.Duration
+	// SampleRate is the fraction of entries AnalyzeLogs inspects on high-volume
+	// streams; every Nth entry is analyzed and counts are scaled back up (0 or 1 disables)
+	SampleRate         float64
 }
 
 // DefaultLogAnalysisConfig returns default log analysis configuration
@@ -202,6 +222,10 @@ func DefaultLogAnalysisConfig() *LogAnalysisConfig {
 		PerformancePattern: regexp.MustCompile(`(?i)(?:latency|duration|time|took)\s*[:=]\s*(\d+(?:\.\d+)?)\s*(ms|s|m)`),
 		MaxSampleSize:      10000,
 		AnalysisWindow:     5 * time.Minute,
+		ChunkSize:          time.Hour,
+		BreakerThreshold:   5,
+		BreakerCooldown:    30 * time.Second,
+		CacheDuration:      30 * time.Second,
 	}
 }
 
@@ -211,13 +235,22 @@ func NewEnhancedLokiClient(baseURL string, config *LogAnalysisConfig) (*Enhanced
 		config = DefaultLogAnalysisConfig()
 	}
 	
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("sample rate must be between 0 and 1, got %g", config.SampleRate)
+	}
+	
+	client, err := newHTTPClient(config.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	
 	return &EnhancedLokiClient{
 		baseURL: baseURL,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:  client,
 		patternCache:   newPatternCache(1000),
 		analysisConfig: config,
+		breaker:        NewCircuitBreaker("loki", config.BreakerThreshold, config.BreakerCooldown),
+		queryCache:     newLokiQueryCache(config.CacheDuration),
 	}, nil
 }
 
@@ -227,8 +260,96 @@ func (elc *EnhancedLokiClient) QueryWithBuilder(ctx context.Context, builder *Lo
 	return elc.Query(ctx, query, start, end)
 }
 
-// Query executes a LogQL query
+// Query executes a LogQL log query, fast-failing while the circuit breaker is open.
+// Metric queries (matrix/vector results) must go through QueryMetric.
 func (elc *EnhancedLokiClient) Query(ctx context.Context, query string, start, end time.Time) ([]*types.LogStream, error) {
+	lokiResponse, err := elc.execute(ctx, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	
+	switch lokiResponse.Data.ResultType {
+	case "", "streams":
+	case "matrix", "vector":
+		return nil, fmt.Errorf("Loki query returned %s result, use QueryMetric for metric queries", lokiResponse.Data.ResultType)
+	default:
+		return nil, fmt.Errorf("unsupported Loki result type: %s", lokiResponse.Data.ResultType)
+	}
+	
+	var results []LokiStreamResult
+	if err := json.Unmarshal(lokiResponse.Data.Result, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode streams: %w", err)
+	}
+	
+	return elc.parseStreams(results), nil
+}
+
+// QueryMetric executes a LogQL metric query (rate, count_over_time, sum by ...)
+// and returns the matrix or vector result as numeric series
+func (elc *EnhancedLokiClient) QueryMetric(ctx context.Context, query string, start, end time.Time) ([]MetricSeries, error) {
+	lokiResponse, err := elc.execute(ctx, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	
+	switch lokiResponse.Data.ResultType {
+	case "matrix", "vector":
+	case "streams":
+		return nil, fmt.Errorf("Loki query returned streams result, use Query for log queries")
+	default:
+		return nil, fmt.Errorf("unsupported Loki result type: %s", lokiResponse.Data.ResultType)
+	}
+	
+	var results []LokiMetricResult
+	if err := json.Unmarshal(lokiResponse.Data.Result, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode %s result: %w", lokiResponse.Data.ResultType, err)
+	}
+	
+	return parseMetricResults(results)
+}
+
+// QueryMetricWithBuilder executes a metric query built with the query builder
+func (elc *EnhancedLokiClient) QueryMetricWithBuilder(ctx context.Context, builder *LogQLBuilder, start, end time.Time) ([]MetricSeries, error) {
+	return elc.QueryMetric(ctx, builder.Build(), start, end)
+}
+
+// BreakerStats returns the circuit breaker state for the Loki backend
+func (elc *EnhancedLokiClient) BreakerStats() BreakerStats {
+	return elc.breaker.Stats()
+}
+
+// execute runs the query_range request through the circuit breaker.
+// Responses for closed ranges are cached for CacheDuration; live ranges
+// (no end or an end in the future) always go to Loki.
+func (elc *EnhancedLokiClient) execute(ctx context.Context, query string, start, end time.Time) (*LokiQueryResponse, error) {
+	cacheable := !end.IsZero() && !end.After(time.Now())
+	key := lokiCacheKey(query, start, end, elc.analysisConfig.MaxSampleSize)
+	if cacheable {
+		if cached, found := elc.queryCache.get(key); found {
+			return cached, nil
+		}
+	}
+	
+	if err := elc.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	
+	lokiResponse, err := elc.doQuery(ctx, query, start, end)
+	if _, isQueryErr := AsQueryError(err); isQueryErr {
+		// The backend answered, so a bad query is not a failure for the breaker
+		elc.breaker.Record(ctx, nil)
+	} else {
+		elc.breaker.Record(ctx, err)
+	}
+	
+	if err == nil && cacheable {
+		elc.queryCache.set(key, lokiResponse)
+	}
+	return lokiResponse, err
+}
+
+// doQuery performs the query_range request
+func (elc *EnhancedLokiClient) doQuery(ctx context.Context, query string, start, end time.Time) (*LokiQueryResponse, error) {
 	queryURL, err := url.Parse(fmt.Sprintf("%s/loki/api/v1/query_range", elc.baseURL))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse URL: %w", err)
@@ -253,6 +374,10 @@ func (elc *EnhancedLokiClient) Query(ctx context.Context, query string, start, e
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if queryErr := lokiQueryError(query, resp.StatusCode, body); queryErr != nil {
+			return nil, queryErr
+		}
 		return nil, fmt.Errorf("Loki returned error status: %d", resp.StatusCode)
 	}
 	
@@ -265,19 +390,15 @@ func (elc *EnhancedLokiClient) Query(ctx context.Context, query string, start, e
 		return nil, fmt.Errorf("Loki query failed: %s", lokiResponse.Status)
 	}
 	
-	return elc.parseStreams(lokiResponse.Data.Result), nil
+	return &lokiResponse, nil
 }
 
-// AnalyzeLogs performs advanced log analysis
+// AnalyzeLogs performs advanced log analysis.
+// The range is queried in ChunkSize sub-windows and the results are aggregated.
 func (elc *EnhancedLokiClient) AnalyzeLogs(ctx context.Context, query string, duration time.Duration) (*LogAnalysisResult, error) {
 	end := time.Now()
 	start := end.Add(-duration)
 	
-	streams, err := elc.Query(ctx, query, start, end)
-	if err != nil {
-		return nil, err
-	}
-	
 	result := &LogAnalysisResult{
 		TotalLogs:       0,
 		AnomalyCount:    0,
@@ -288,11 +409,62 @@ func (elc *EnhancedLokiClient) AnalyzeLogs(ctx context.Context, query string, du
 		TimeDistribution: make(map[string]int),
 	}
 	
-	// Analyze each log stream
+	chunkSize := elc.analysisConfig.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = duration
+	}
+	
+	for chunkStart := start; chunkStart.Before(end); chunkStart = chunkStart.Add(chunkSize) {
+		// Stop between chunks if the caller gave up
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		
+		chunkEnd := chunkStart.Add(chunkSize)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		
+		streams, err := elc.Query(ctx, query, chunkStart, chunkEnd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query chunk %s - %s: %w",
+				chunkStart.Format(time.RFC3339), chunkEnd.Format(time.RFC3339), err)
+		}
+		
+		elc.analyzeStreams(result, streams)
+		result.Chunks++
+	}
+	
+	scaleSampledCounts(result)
+	
+	// Calculate additional metrics
+	if result.TotalLogs > 0 {
+		result.AnomalyRate = float64(result.AnomalyCount) / float64(result.TotalLogs)
+		result.ErrorRate = float64(result.ErrorCount) / float64(result.TotalLogs)
+	}
+	
+	return result, nil
+}
+
+// analyzeStreams accumulates the analysis of the streams into result
+func (elc *EnhancedLokiClient) analyzeStreams(result *LogAnalysisResult, streams []*types.LogStream) {
+	every := elc.sampleEvery()
+	
 	for _, stream := range streams {
 		for _, entry := range stream.Entries {
 			result.TotalLogs++
 			
+			// Time distribution (hourly buckets) is cheap and kept exact
+			hour := entry.Timestamp.Format("2006-01-02 15:00")
+			result.TimeDistribution[hour]++
+			
+			// Only every Nth entry goes through pattern matching; the counter
+			// spans chunks so sampling stays uniform over the whole range
+			if (result.TotalLogs-1)%every != 0 {
+				continue
+			}
+			result.SampledLogs++
+			
 			// Check for anomalies
 			if elc.isAnomaly(entry.Content) {
 				result.AnomalyCount++
@@ -309,10 +481,6 @@ func (elc *EnhancedLokiClient) AnalyzeLogs(ctx context.Context, query string, du
 				result.PerformanceData = append(result.PerformanceData, *perf)
 			}
 			
-			// Time distribution (hourly buckets)
-			hour := entry.Timestamp.Format("2006-01-02 15:00")
-			result.TimeDistribution[hour]++
-			
 			// Pattern detection
 			pattern := elc.detectPattern(entry.Content)
 			if pattern != "" {
@@ -320,12 +488,39 @@ func (elc *EnhancedLokiClient) AnalyzeLogs(ctx context.Context, query string, du
 			}
 		}
 	}
+}
+
+// sampleEvery returns N for analyzing every Nth entry at the configured sample rate
+func (elc *EnhancedLokiClient) sampleEvery() int {
+	rate := elc.analysisConfig.SampleRate
+	if rate <= 0 || rate >= 1 {
+		return 1
+	}
+	return int(math.Max(1, math.Round(1/rate)))
+}
+
+// scaleSampledCounts scales the counts of sampled entries up to the total number
+// of entries and records the effective sample rate
+func scaleSampledCounts(result *LogAnalysisResult) {
+	result.SampleRate = 1
+	if result.SampledLogs == 0 || result.SampledLogs == result.TotalLogs {
+		return
+	}
 	
-	// Calculate additional metrics
-	result.AnomalyRate = float64(result.AnomalyCount) / float64(result.TotalLogs)
-	result.ErrorRate = float64(result.ErrorCount) / float64(result.TotalLogs)
+	factor := float64(result.TotalLogs) / float64(result.SampledLogs)
+	scale := func(count int) int {
+		return int(math.Round(float64(count) * factor))
+	}
 	
-	return result, nil
+	result.SampleRate = 1 / factor
+	result.AnomalyCount = scale(result.AnomalyCount)
+	result.ErrorCount = scale(result.ErrorCount)
+	for errorType, count := range result.ErrorTypes {
+		result.ErrorTypes[errorType] = scale(count)
+	}
+	for pattern, count := range result.PatternSummary {
+		result.PatternSummary[pattern] = scale(count)
+	}
 }
 
 // isAnomaly checks if a log entry is anomalous
@@ -420,7 +615,7 @@ func (elc *EnhancedLokiClient) parseStreams(results []LokiStreamResult) []*types
 				Timestamp: time.Unix(0, timestampNano),
 				Content:   value[1],
 				Labels:    result.Stream,
-				Level:     extractLogLevel(value[1]),
+				Level:     extractLogLevel(value[1], elc.analysisConfig.LevelFields),
 			}
 			
 			stream.Entries = append(stream.Entries, entry)
@@ -445,6 +640,12 @@ type LogAnalysisResult struct {
 	ErrorTypes       map[string]int
 	PerformanceData  []PerformanceMetric
 	TimeDistribution map[string]int
+	Chunks           int
+	// SampledLogs is the number of entries actually analyzed and SampleRate the
+	// effective fraction SampledLogs/TotalLogs; counts are scaled up accordingly
+	// and PerformanceData holds the sampled measurements only
+	SampledLogs      int
+	SampleRate       float64
 }
 
 // PerformanceMetric represents a performance measurement
@@ -457,8 +658,8 @@ type PerformanceMetric struct {
 type LokiQueryResponse struct {
 	Status string `json:"status"`
 	Data   struct {
-		ResultType string              `json:"resultType"`
-		Result     []LokiStreamResult  `json:"result"`
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
 	} `json:"data"`
 }
 
@@ -468,50 +669,205 @@ type LokiStreamResult struct {
 	Values [][]string        `json:"values"`
 }
 
-// patternCache provides simple caching for pattern detection
+// LokiMetricResult represents a single series of a matrix or vector result.
+// Samples are [<unix seconds>, "<value>"] pairs.
+type LokiMetricResult struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+	Values [][]interface{}   `json:"values"`
+}
+
+// parseMetricResults converts matrix/vector results to metric series
+func parseMetricResults(results []LokiMetricResult) ([]MetricSeries, error) {
+	series := make([]MetricSeries, 0, len(results))
+	
+	for _, result := range results {
+		samples := result.Values
+		if len(result.Value) > 0 {
+			samples = append(samples, result.Value)
+		}
+		
+		points := make([]MetricPoint, 0, len(samples))
+		for _, sample := range samples {
+			point, err := parseMetricSample(sample)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, point)
+		}
+		
+		series = append(series, MetricSeries{
+			Labels: result.Metric,
+			Points: points,
+		})
+	}
+	
+	return series, nil
+}
+
+// parseMetricSample parses a [<unix seconds>, "<value>"] pair
+func parseMetricSample(sample []interface{}) (MetricPoint, error) {
+	if len(sample) != 2 {
+		return MetricPoint{}, fmt.Errorf("invalid sample: expected 2 elements, got %d", len(sample))
+	}
+	
+	ts, ok := sample[0].(float64)
+	if !ok {
+		return MetricPoint{}, fmt.Errorf("invalid sample timestamp: %v", sample[0])
+	}
+	
+	raw, ok := sample[1].(string)
+	if !ok {
+		return MetricPoint{}, fmt.Errorf("invalid sample value: %v", sample[1])
+	}
+	
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return MetricPoint{}, fmt.Errorf("invalid sample value %q: %w", raw, err)
+	}
+	
+	sec, frac := math.Modf(ts)
+	return MetricPoint{
+		Value:     value,
+		Timestamp: time.Unix(int64(sec), int64(frac*1e9)),
+	}, nil
+}
+
+// lokiQueryCache caches query_range responses keyed by query, range and limit
+type lokiQueryCache struct {
+	entries  map[string]lokiCacheEntry
+	duration time.Duration
+	mu       sync.RWMutex
+}
+
+type lokiCacheEntry struct {
+	response  *LokiQueryResponse
+	timestamp time.Time
+}
+
+func newLokiQueryCache(duration time.Duration) *lokiQueryCache {
+	return &lokiQueryCache{
+		entries:  make(map[string]lokiCacheEntry),
+		duration: duration,
+	}
+}
+
+// lokiCacheKey builds the cache key for a query_range request
+func lokiCacheKey(query string, start, end time.Time, limit int) string {
+	return fmt.Sprintf("%s|%d|%d|%d", query, start.UnixNano(), end.UnixNano(), limit)
+}
+
+func (lc *lokiQueryCache) get(key string) (*LokiQueryResponse, bool) {
+	if lc.duration <= 0 {
+		return nil, false
+	}
+	
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	
+	entry, exists := lc.entries[key]
+	if !exists || time.Since(entry.timestamp) > lc.duration {
+		return nil, false
+	}
+	return entry.response, true
+}
+
+func (lc *lokiQueryCache) set(key string, response *LokiQueryResponse) {
+	if lc.duration <= 0 {
+		return
+	}
+	
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	
+	now := time.Now()
+	// Exact ranges rarely repeat after their TTL, so expired entries are dropped here
+	for k, entry := range lc.entries {
+		if now.Sub(entry.timestamp) > lc.duration {
+			delete(lc.entries, k)
+		}
+	}
+	
+	lc.entries[key] = lokiCacheEntry{
+		response:  response,
+		timestamp: now,
+	}
+}
+
+// patternCache is an LRU cache of detected patterns keyed by log content
 type patternCache struct {
-	cache map[uint32]string
-	mu    sync.RWMutex
-	size  int
+	entries map[uint64]*list.Element
+	order   *list.List // front is the most recently used entry
+	mu      sync.Mutex
+	size    int
+}
+
+// patternCacheEntry keeps the original content so that hash collisions
+// between different log lines are detected instead of returning a wrong pattern
+type patternCacheEntry struct {
+	hash    uint64
+	content string
+	pattern string
 }
 
 func newPatternCache(size int) *patternCache {
 	return &patternCache{
-		cache: make(map[uint32]string),
-		size:  size,
+		entries: make(map[uint64]*list.Element),
+		order:   list.New(),
+		size:    size,
 	}
 }
 
 func (pc *patternCache) get(content string) (string, bool) {
-	pc.mu.RLock()
-	defer pc.mu.RUnlock()
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
 	
-	hash := hashString(content)
-	pattern, found := pc.cache[hash]
-	return pattern, found
+	elem, found := pc.entries[hashString(content)]
+	if !found {
+		return "", false
+	}
+	
+	entry := elem.Value.(*patternCacheEntry)
+	if entry.content != content {
+		// Hash collision with a different log line
+		return "", false
+	}
+	
+	pc.order.MoveToFront(elem)
+	return entry.pattern, true
 }
 
 func (pc *patternCache) set(content, pattern string) {
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
 	
-	if len(pc.cache) >= pc.size {
-		// Simple eviction - remove first item
-		for k := range pc.cache {
-			delete(pc.cache, k)
-			break
-		}
+	hash := hashString(content)
+	if elem, found := pc.entries[hash]; found {
+		// Replaces a colliding entry as well
+		entry := elem.Value.(*patternCacheEntry)
+		entry.content = content
+		entry.pattern = pattern
+		pc.order.MoveToFront(elem)
+		return
 	}
 	
-	hash := hashString(content)
-	pc.cache[hash] = pattern
+	// Evict the least recently used entries
+	for pc.order.Len() > 0 && pc.order.Len() >= pc.size {
+		oldest := pc.order.Back()
+		pc.order.Remove(oldest)
+		delete(pc.entries, oldest.Value.(*patternCacheEntry).hash)
+	}
+	
+	pc.entries[hash] = pc.order.PushFront(&patternCacheEntry{
+		hash:    hash,
+		content: content,
+		pattern: pattern,
+	})
 }
 
-// hashString provides a simple hash function
-func hashString(s string) uint32 {
-	h := uint32(0)
-	for _, c := range s {
-		h = h*31 + uint32(c)
-	}
-	return h
-} 
\ No newline at end of file
+// hashString returns the 64-bit FNV-1a hash of s
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
@@ -0,0 +1,302 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEnhancedLokiClient_QueryCache(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[` +
+			`{"stream":{"app":"api"},"values":[["1700000000000000000","error: boom"]]}]}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewEnhancedLokiClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	end := time.Now().Add(-time.Minute)
+	start := end.Add(-time.Hour)
+
+	for i := 0; i < 3; i++ {
+		streams, err := client.Query(ctx, `{app="api"}`, start, end)
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		if len(streams) != 1 || len(streams[0].Entries) != 1 {
+			t.Fatalf("unexpected streams: %+v", streams)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected repeated queries to be served from cache, got %d requests", got)
+	}
+
+	// A different range is a different cache key
+	if _, err := client.Query(ctx, `{app="api"}`, start.Add(-time.Minute), end); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected a new range to hit Loki, got %d requests", got)
+	}
+
+	// Live ranges ending in the future are never cached
+	future := time.Now().Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if _, err := client.Query(ctx, `{app="api"}`, start, future); err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 4 {
+		t.Errorf("expected live queries to bypass the cache, got %d requests", got)
+	}
+}
+
+func TestEnhancedLokiClient_QueryCacheDisabled(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`))
+	}))
+	defer server.Close()
+
+	config := DefaultLogAnalysisConfig()
+	config.CacheDuration = 0
+	client, err := NewEnhancedLokiClient(server.URL, config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	end := time.Now().Add(-time.Minute)
+	for i := 0; i < 2; i++ {
+		if _, err := client.Query(context.Background(), `{app="api"}`, end.Add(-time.Hour), end); err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected caching to be disabled, got %d requests", got)
+	}
+}
+
+func TestPatternCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	pc := newPatternCache(2)
+	pc.set("GET /health HTTP 200", "http_success")
+	pc.set("connection refused", "connection_error")
+
+	// Touch the first entry so the second one is the least recently used
+	if pattern, found := pc.get("GET /health HTTP 200"); !found || pattern != "http_success" {
+		t.Fatalf("expected cached pattern, got %q, %t", pattern, found)
+	}
+	pc.set("read timeout", "timeout_error")
+
+	if _, found := pc.get("connection refused"); found {
+		t.Error("expected the least recently used entry to be evicted")
+	}
+	for _, content := range []string{"GET /health HTTP 200", "read timeout"} {
+		if _, found := pc.get(content); !found {
+			t.Errorf("expected %q to stay cached", content)
+		}
+	}
+	if pc.order.Len() != 2 || len(pc.entries) != 2 {
+		t.Errorf("expected 2 entries, got %d in list and %d in map", pc.order.Len(), len(pc.entries))
+	}
+}
+
+func TestPatternCache_DetectsHashCollisions(t *testing.T) {
+	pc := newPatternCache(10)
+	pc.set("connection refused", "connection_error")
+
+	// Simulate another log line hashing to the same key
+	entry := pc.entries[hashString("connection refused")].Value.(*patternCacheEntry)
+	entry.content = "read timeout"
+
+	if pattern, found := pc.get("connection refused"); found {
+		t.Errorf("expected a miss on a hash collision, got %q", pattern)
+	}
+
+	pc.set("connection refused", "connection_error")
+	if pattern, found := pc.get("connection refused"); !found || pattern != "connection_error" {
+		t.Errorf("expected the colliding entry to be replaced, got %q, %t", pattern, found)
+	}
+}
+
+func TestEnhancedLokiClient_AnalyzeLogsSampling(t *testing.T) {
+	// 100 entries where 2 of every 5 are errors
+	values := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		line := "request ok"
+		if i%5 < 2 {
+			line = "error: boom"
+		}
+		values = append(values, fmt.Sprintf(`["%d","%s"]`, time.Now().Add(-time.Minute).UnixNano()+int64(i), line))
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[` +
+			`{"stream":{"app":"api"},"values":[` + strings.Join(values, ",") + `]}]}}`))
+	}))
+	defer server.Close()
+
+	config := DefaultLogAnalysisConfig()
+	config.SampleRate = 0.25
+	client, err := NewEnhancedLokiClient(server.URL, config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	result, err := client.AnalyzeLogs(context.Background(), `{app="api"}`, time.Hour)
+	if err != nil {
+		t.Fatalf("analysis failed: %v", err)
+	}
+	if result.TotalLogs != 100 || result.SampledLogs != 25 || result.SampleRate != 0.25 {
+		t.Errorf("expected 25 of 100 entries sampled at 0.25, got %d of %d at %g",
+			result.SampledLogs, result.TotalLogs, result.SampleRate)
+	}
+	if result.ErrorCount != 40 || result.ErrorTypes["boom"] != 40 || result.AnomalyCount != 40 {
+		t.Errorf("expected scaled counts of 40, got errors %d, types %v, anomalies %d",
+			result.ErrorCount, result.ErrorTypes, result.AnomalyCount)
+	}
+	if result.ErrorRate != 0.4 {
+		t.Errorf("expected error rate 0.4, got %g", result.ErrorRate)
+	}
+
+	config.SampleRate = 1.5
+	if _, err := NewEnhancedLokiClient(server.URL, config); err == nil {
+		t.Error("expected an error for a sample rate above 1")
+	}
+}
+
+func TestEnhancedLokiClient_QueryMetric(t *testing.T) {
+	var response atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response.Load().(string)))
+	}))
+	defer server.Close()
+
+	config := DefaultLogAnalysisConfig()
+	config.CacheDuration = 0
+	client, err := NewEnhancedLokiClient(server.URL, config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	end := time.Now()
+	start := end.Add(-time.Hour)
+	query := `sum by (app) (rate({app="api"} |= "error" [5m]))`
+
+	response.Store(`{"status":"success","data":{"resultType":"matrix","result":[` +
+		`{"metric":{"app":"api"},"values":[[1700000000,"0.5"],[1700000060.5,"1.25"]]}]}}`)
+	series, err := client.QueryMetric(ctx, query, start, end)
+	if err != nil {
+		t.Fatalf("matrix query failed: %v", err)
+	}
+	if len(series) != 1 || series[0].Labels["app"] != "api" || len(series[0].Points) != 2 {
+		t.Fatalf("unexpected matrix series: %+v", series)
+	}
+	if point := series[0].Points[1]; point.Value != 1.25 || !point.Timestamp.Equal(time.UnixMilli(1700000060500)) {
+		t.Errorf("unexpected matrix point: %+v", point)
+	}
+
+	response.Store(`{"status":"success","data":{"resultType":"vector","result":[` +
+		`{"metric":{"app":"api"},"value":[1700000000,"3"]},{"metric":{"app":"web"},"value":[1700000000,"7"]}]}}`)
+	series, err = client.QueryMetric(ctx, query, start, end)
+	if err != nil {
+		t.Fatalf("vector query failed: %v", err)
+	}
+	if len(series) != 2 || len(series[1].Points) != 1 || series[1].Points[0].Value != 7 {
+		t.Fatalf("unexpected vector series: %+v", series)
+	}
+
+	// Log and metric results are not interchangeable
+	if _, err := client.Query(ctx, query, start, end); err == nil || !strings.Contains(err.Error(), "QueryMetric") {
+		t.Errorf("expected a log query on a vector result to point at QueryMetric, got %v", err)
+	}
+	response.Store(`{"status":"success","data":{"resultType":"streams","result":[]}}`)
+	if _, err := client.QueryMetric(ctx, `{app="api"}`, start, end); err == nil {
+		t.Error("expected a metric query on a streams result to fail")
+	}
+
+	response.Store(`{"status":"success","data":{"resultType":"vector","result":[` +
+		`{"metric":{"app":"api"},"value":[1700000000,"not-a-number"]}]}}`)
+	if _, err := client.QueryMetric(ctx, query, start, end); err == nil {
+		t.Error("expected an invalid sample value to fail")
+	}
+}
+
+func TestEnhancedLokiClient_AnalyzeLogsChunks(t *testing.T) {
+	type span struct{ start, end int64 }
+	var (
+		mu    sync.Mutex
+		spans []span
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+		mu.Lock()
+		spans = append(spans, span{start, end})
+		mu.Unlock()
+
+		// One error entry per chunk
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"streams","result":[`+
+			`{"stream":{"app":"api"},"values":[["%d","error: boom"]]}]}}`, start)
+	}))
+	defer server.Close()
+
+	config := DefaultLogAnalysisConfig()
+	config.ChunkSize = time.Hour
+	client, err := NewEnhancedLokiClient(server.URL, config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	result, err := client.AnalyzeLogs(context.Background(), `{app="api"}`, 150*time.Minute)
+	if err != nil {
+		t.Fatalf("analysis failed: %v", err)
+	}
+	if result.Chunks != 3 || len(spans) != 3 {
+		t.Fatalf("expected 150m to be split into 3 chunks, got %d chunks and %d queries", result.Chunks, len(spans))
+	}
+	if result.TotalLogs != 3 || result.ErrorCount != 3 {
+		t.Errorf("expected results merged across chunks, got %d logs and %d errors", result.TotalLogs, result.ErrorCount)
+	}
+
+	// Chunks are contiguous, at most ChunkSize long, and cover the whole range
+	for i, s := range spans {
+		if length := time.Duration(s.end - s.start); length > time.Hour || length <= 0 {
+			t.Errorf("chunk %d spans %s", i, length)
+		}
+		if i > 0 && s.start != spans[i-1].end {
+			t.Errorf("chunk %d starts at %d, previous ended at %d", i, s.start, spans[i-1].end)
+		}
+	}
+	if total := time.Duration(spans[2].end - spans[0].start); total != 150*time.Minute {
+		t.Errorf("expected the chunks to cover 150m, got %s", total)
+	}
+
+	// A canceled context stops before the next chunk
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.AnalyzeLogs(ctx, `{app="web"}`, 3*time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the analysis to stop with the context, got %v", err)
+	}
+	if len(spans) != 3 {
+		t.Errorf("expected no queries after cancellation, got %d", len(spans)-3)
+	}
+}
@@ -2,8 +2,10 @@ package datasource
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +15,10 @@ import (
 // DataSourceManager manages all data source integrations
 type DataSourceManager struct {
 	promClient     *EnhancedPrometheusClient
+	// promClients holds a client per named Prometheus source; promClient is the
+	// client of the first one, promSources their names in configuration order
+	promClients    map[string]*EnhancedPrometheusClient
+	promSources    []string
 	lokiClient     *EnhancedLokiClient
 	metricsPipeline *MetricsPipeline
 	lokiCollector  *LokiCollector
@@ -20,12 +26,16 @@ type DataSourceManager struct {
 	config         *DataSourceConfig
 	mu             sync.RWMutex
 	stopCh         chan struct{}
+	stopOnce       sync.Once
 	wg             sync.WaitGroup
 }
 
 // DataSourceConfig contains configuration for data sources
 type DataSourceConfig struct {
 	PrometheusURL    string
+	// PrometheusSources are named Prometheus servers (e.g. one per region), the
+	// first being the default. When empty, PrometheusURL is the only source.
+	PrometheusSources []PrometheusServer
 	LokiURL          string
 	CollectionInterval time.Duration
 	HealthCheckInterval time.Duration
@@ -33,6 +43,53 @@ type DataSourceConfig struct {
 	EnableLogs       bool
 	MaxRetries       int
 	RetryDelay       time.Duration
+	PrometheusTLS    *TLSConfig
+	// PrometheusTokenProvider supplies bearer tokens for Prometheus, refreshed on 401
+	PrometheusTokenProvider TokenProvider
+	// PrometheusBatchConcurrency caps concurrent queries of a batch per source (0 keeps the default of 4)
+	PrometheusBatchConcurrency int
+	LokiTLS          *TLSConfig
+	// LogLevelFields are the JSON/logfmt fields holding the log level (default: level, severity)
+	LogLevelFields   []string
+	// LokiCacheDuration overrides how long identical Loki query results are reused (0 keeps the default)
+	LokiCacheDuration time.Duration
+	// LogSampleRate is the fraction of log entries analyzed by AnalyzeLogs (0 analyzes all)
+	LogSampleRate    float64
+	// FlapWindow and FlapThreshold tune health flapping detection (0 keeps the defaults)
+	FlapWindow       time.Duration
+	FlapThreshold    int
+}
+
+// DefaultPrometheusSource names the source built from PrometheusURL
+const DefaultPrometheusSource = "default"
+
+// ErrUnknownSource is returned when a query or collector names a Prometheus source that is not configured
+var ErrUnknownSource = errors.New("unknown prometheus source")
+
+// PrometheusServer is a named Prometheus source
+type PrometheusServer struct {
+	Name string
+	URL  string
+	TLS  *TLSConfig
+	// TokenProvider supplies bearer tokens for this server, refreshed on 401
+	TokenProvider TokenProvider
+}
+
+// prometheusSources returns the configured Prometheus sources, falling back to
+// PrometheusURL as the single default source
+func (c *DataSourceConfig) prometheusSources() []PrometheusServer {
+	if len(c.PrometheusSources) > 0 {
+		return c.PrometheusSources
+	}
+	if c.PrometheusURL == "" {
+		return nil
+	}
+	return []PrometheusServer{{
+		Name:          DefaultPrometheusSource,
+		URL:           c.PrometheusURL,
+		TLS:           c.PrometheusTLS,
+		TokenProvider: c.PrometheusTokenProvider,
+	}}
 }
 
 // DefaultDataSourceConfig returns default configuration
@@ -56,43 +113,74 @@ func NewDataSourceManager(config *DataSourceConfig, detectorStore DetectorStore)
 	}
 
 	dsm := &DataSourceManager{
-		config: config,
-		stopCh: make(chan struct{}),
+		config:      config,
+		promClients: make(map[string]*EnhancedPrometheusClient),
+		stopCh:      make(chan struct{}),
 	}
 
-	// Initialize Prometheus client if enabled
-	if config.EnableMetrics && config.PrometheusURL != "" {
-		promClient, err := NewEnhancedPrometheusClient(config.PrometheusURL, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create Prometheus client: %w", err)
+	// Initialize a Prometheus client per source if enabled; the first one is the default
+	if config.EnableMetrics {
+		for _, source := range config.prometheusSources() {
+			if source.Name == "" || source.URL == "" {
+				return nil, fmt.Errorf("prometheus source requires a name and a URL")
+			}
+			if _, exists := dsm.promClients[source.Name]; exists {
+				return nil, fmt.Errorf("duplicate prometheus source: %s", source.Name)
+			}
+			
+			promConfig := DefaultEnhancedConfig()
+			promConfig.TLS = source.TLS
+			promConfig.TokenProvider = source.TokenProvider
+			if config.PrometheusBatchConcurrency > 0 {
+				promConfig.BatchConcurrency = config.PrometheusBatchConcurrency
+			}
+			promClient, err := NewEnhancedPrometheusClient(source.URL, promConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create Prometheus client for source %s: %w", source.Name, err)
+			}
+			dsm.promClients[source.Name] = promClient
+			dsm.promSources = append(dsm.promSources, source.Name)
+			
+			if dsm.promClient == nil {
+				dsm.promClient = promClient
+				dsm.metricsPipeline = NewMetricsPipeline(promClient, detectorStore)
+			}
 		}
-		dsm.promClient = promClient
-		dsm.metricsPipeline = NewMetricsPipeline(promClient, detectorStore)
 	}
 
 	// Initialize Loki client if enabled
 	if config.EnableLogs && config.LokiURL != "" {
-		lokiClient, err := NewEnhancedLokiClient(config.LokiURL, nil)
+		lokiConfig := DefaultLogAnalysisConfig()
+		lokiConfig.TLS = config.LokiTLS
+		lokiConfig.LevelFields = config.LogLevelFields
+		lokiConfig.SampleRate = config.LogSampleRate
+		if config.LokiCacheDuration > 0 {
+			lokiConfig.CacheDuration = config.LokiCacheDuration
+		}
+		lokiClient, err := NewEnhancedLokiClient(config.LokiURL, lokiConfig)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Loki client: %w", err)
 		}
 		dsm.lokiClient = lokiClient
 		
 		// Create Loki collector with callback
-		lokiCollector, err := NewLokiCollector(
+		lokiCollector, err := NewLokiCollectorWithTLS(
 			config.LokiURL,
 			config.CollectionInterval,
 			5*time.Minute,
 			dsm.handleLogStream,
+			config.LokiTLS,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Loki collector: %w", err)
 		}
+		lokiCollector.SetLevelFields(config.LogLevelFields...)
 		dsm.lokiCollector = lokiCollector
 	}
 
 	// Initialize health monitor
 	dsm.healthMonitor = NewHealthMonitor(config.HealthCheckInterval)
+	dsm.healthMonitor.SetFlapDetection(config.FlapWindow, config.FlapThreshold)
 
 	return dsm, nil
 }
@@ -123,27 +211,126 @@ func (dsm *DataSourceManager) Start(ctx context.Context) error {
 
 // Stop stops all data collection
 func (dsm *DataSourceManager) Stop() {
-	close(dsm.stopCh)
-	
+	dsm.Shutdown(context.Background())
+}
+
+// Shutdown stops all data collection warmly: running metric collections finish
+// feeding their detectors and buffered metrics are handed to the buffer flush
+// handler before the manager stops. It returns ctx.Err() if stopping outlives
+// ctx. Calls after the first return nil.
+func (dsm *DataSourceManager) Shutdown(ctx context.Context) error {
+	var err error
+	dsm.stopOnce.Do(func() {
+		close(dsm.stopCh)
+		
+		if dsm.metricsPipeline != nil {
+			err = dsm.metricsPipeline.Shutdown(ctx)
+		}
+		
+		// The pipeline closes the default client
+		for _, client := range dsm.promClients {
+			if client != dsm.promClient {
+				client.Close()
+			}
+		}
+		
+		if dsm.lokiCollector != nil {
+			dsm.lokiCollector.Stop()
+		}
+		
+		done := make(chan struct{})
+		go func() {
+			dsm.wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+		}
+		log.Println("Data source manager stopped")
+	})
+	return err
+}
+
+// SetBufferFlushHandler sets the handler receiving metrics flushed from the
+// Prometheus metrics buffer, including those still buffered at shutdown
+func (dsm *DataSourceManager) SetBufferFlushHandler(handler BufferFlushHandler) {
+	for _, client := range dsm.promClients {
+		client.SetBufferFlushHandler(handler)
+	}
+}
+
+// SetAnomalyHandler registers a handler for anomalies detected by the detectors
+// the metrics pipeline feeds
+func (dsm *DataSourceManager) SetAnomalyHandler(handler AnomalyHandler) {
 	if dsm.metricsPipeline != nil {
-		dsm.metricsPipeline.Stop()
+		dsm.metricsPipeline.SetAnomalyHandler(handler)
 	}
-	
-	if dsm.lokiCollector != nil {
-		dsm.lokiCollector.Stop()
+}
+
+// PrometheusSources returns the names of the configured Prometheus sources, the default first
+func (dsm *DataSourceManager) PrometheusSources() []string {
+	return append([]string(nil), dsm.promSources...)
+}
+
+// prometheusClient returns the client of the named Prometheus source, or of
+// the default source when name is empty
+func (dsm *DataSourceManager) prometheusClient(name string) (*EnhancedPrometheusClient, error) {
+	if dsm.promClient == nil {
+		return nil, fmt.Errorf("prometheus client not initialized")
+	}
+	if name == "" {
+		return dsm.promClient, nil
 	}
 	
-	dsm.wg.Wait()
-	log.Println("Data source manager stopped")
+	client, exists := dsm.promClients[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSource, name)
+	}
+	return client, nil
+}
+
+// AddMetricCollector adds a metric collector for a detector on the default
+// Prometheus source, optionally chaining named transformers
+func (dsm *DataSourceManager) AddMetricCollector(detectorID, query string, interval time.Duration, transformerNames ...string) error {
+	return dsm.AddMetricCollectorFrom(detectorID, "", query, interval, transformerNames...)
+}
+
+// AddMetricCollectorFrom adds a metric collector for a detector querying the
+// named Prometheus source (the default one when empty)
+func (dsm *DataSourceManager) AddMetricCollectorFrom(detectorID, source, query string, interval time.Duration, transformerNames ...string) error {
+	return dsm.AddTypedMetricCollector(detectorID, source, query, interval, MetricTypeGauge, transformerNames...)
 }
 
-// AddMetricCollector adds a metric collector for a detector
-func (dsm *DataSourceManager) AddMetricCollector(detectorID, query string, interval time.Duration) error {
+// AddTypedMetricCollector adds a metric collector for a detector whose values
+// have the given metric semantics; without transformer names counters and
+// histograms are fed to the detector as per-second rates
+func (dsm *DataSourceManager) AddTypedMetricCollector(detectorID, source, query string, interval time.Duration, metricType MetricType, transformerNames ...string) error {
 	if dsm.metricsPipeline == nil {
 		return fmt.Errorf("metrics pipeline not initialized")
 	}
+	
+	client, err := dsm.prometheusClient(source)
+	if err != nil {
+		return err
+	}
+	if source == "" {
+		source = dsm.promSources[0]
+	}
 
-	return dsm.metricsPipeline.CreateCollectorForDetector(detectorID, query, interval)
+	return dsm.metricsPipeline.AddCollector(&MetricCollector{
+		ID:               fmt.Sprintf("detector_%s", detectorID),
+		Query:            query,
+		Interval:         interval,
+		DetectorID:       detectorID,
+		TransformerNames: transformerNames,
+		Source:           source,
+		Client:           client,
+		MetricType:       metricType,
+	})
 }
 
 // AddLogQuery adds a log query for monitoring
@@ -164,6 +351,34 @@ func (dsm *DataSourceManager) RemoveMetricCollector(detectorID string) {
 	}
 }
 
+// UpdateMetricCollectorInterval changes the collection interval of a detector's metric collector
+func (dsm *DataSourceManager) UpdateMetricCollectorInterval(detectorID string, interval time.Duration) error {
+	if dsm.metricsPipeline == nil {
+		return fmt.Errorf("metrics pipeline not initialized")
+	}
+	
+	collectorID := fmt.Sprintf("detector_%s", detectorID)
+	return dsm.metricsPipeline.UpdateCollectorInterval(collectorID, interval)
+}
+
+// CachedCollectorMetrics returns the last cached result of a detector's
+// collector query and when it was cached, without querying Prometheus
+func (dsm *DataSourceManager) CachedCollectorMetrics(detectorID string) ([]MetricResult, time.Time, bool) {
+	if dsm.metricsPipeline == nil || dsm.promClient == nil {
+		return nil, time.Time{}, false
+	}
+	
+	collector, exists := dsm.metricsPipeline.collector(fmt.Sprintf("detector_%s", detectorID))
+	if !exists {
+		return nil, time.Time{}, false
+	}
+	client := collector.Client
+	if client == nil {
+		client = dsm.promClient
+	}
+	return client.CachedQuery(collector.Query)
+}
+
 // RemoveLogQuery removes a log query
 func (dsm *DataSourceManager) RemoveLogQuery(name string) {
 	if dsm.lokiCollector != nil {
@@ -171,22 +386,49 @@ func (dsm *DataSourceManager) RemoveLogQuery(name string) {
 	}
 }
 
-// QueryMetrics executes a Prometheus query
+// QueryMetrics executes a Prometheus query on the default source
 func (dsm *DataSourceManager) QueryMetrics(ctx context.Context, query string) ([]MetricResult, error) {
-	if dsm.promClient == nil {
-		return nil, fmt.Errorf("prometheus client not initialized")
+	return dsm.QueryMetricsFrom(ctx, "", query)
+}
+
+// QueryMetricsFrom executes a Prometheus query on the named source (the default one when empty)
+func (dsm *DataSourceManager) QueryMetricsFrom(ctx context.Context, source, query string) ([]MetricResult, error) {
+	client, err := dsm.prometheusClient(source)
+	if err != nil {
+		return nil, err
 	}
 
-	return dsm.promClient.Query(ctx, query)
+	return client.Query(ctx, query)
 }
 
-// QueryMetricsWithBuilder executes a Prometheus query using builder
+// QueryMetricsWithBuilder executes a Prometheus query using builder on the default source
 func (dsm *DataSourceManager) QueryMetricsWithBuilder(ctx context.Context, builder *QueryBuilder) ([]MetricResult, error) {
-	if dsm.promClient == nil {
-		return nil, fmt.Errorf("prometheus client not initialized")
+	return dsm.QueryMetricsWithBuilderFrom(ctx, "", builder)
+}
+
+// QueryMetricsWithBuilderFrom executes a Prometheus query using builder on the named source
+func (dsm *DataSourceManager) QueryMetricsWithBuilderFrom(ctx context.Context, source string, builder *QueryBuilder) ([]MetricResult, error) {
+	client, err := dsm.prometheusClient(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.QueryWithBuilder(ctx, builder)
+}
+
+// QueryMetricsRange executes a Prometheus range query on the default source
+func (dsm *DataSourceManager) QueryMetricsRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]MetricSeries, error) {
+	return dsm.QueryMetricsRangeFrom(ctx, "", query, start, end, step)
+}
+
+// QueryMetricsRangeFrom executes a Prometheus range query on the named source
+func (dsm *DataSourceManager) QueryMetricsRangeFrom(ctx context.Context, source, query string, start, end time.Time, step time.Duration) ([]MetricSeries, error) {
+	client, err := dsm.prometheusClient(source)
+	if err != nil {
+		return nil, err
 	}
 
-	return dsm.promClient.QueryWithBuilder(ctx, builder)
+	return client.QueryRange(ctx, query, start, end, step)
 }
 
 // QueryLogs executes a Loki query
@@ -207,6 +449,15 @@ func (dsm *DataSourceManager) QueryLogsWithBuilder(ctx context.Context, builder
 	return dsm.lokiClient.QueryWithBuilder(ctx, builder, start, end)
 }
 
+// QueryLogMetrics executes a LogQL metric query (matrix/vector result)
+func (dsm *DataSourceManager) QueryLogMetrics(ctx context.Context, query string, start, end time.Time) ([]MetricSeries, error) {
+	if dsm.lokiClient == nil {
+		return nil, fmt.Errorf("loki client not initialized")
+	}
+
+	return dsm.lokiClient.QueryMetric(ctx, query, start, end)
+}
+
 // AnalyzeLogs performs log analysis
 func (dsm *DataSourceManager) AnalyzeLogs(ctx context.Context, query string, duration time.Duration) (*LogAnalysisResult, error) {
 	if dsm.lokiClient == nil {
@@ -221,7 +472,19 @@ func (dsm *DataSourceManager) GetHealthStatus() *HealthStatus {
 	dsm.mu.RLock()
 	defer dsm.mu.RUnlock()
 
-	return dsm.healthMonitor.GetStatus()
+	status := dsm.healthMonitor.GetStatus()
+
+	// Breaker state is live, not from the last periodic check
+	if dsm.promClient != nil {
+		stats := dsm.promClient.BreakerStats()
+		status.PrometheusBreaker = &stats
+	}
+	if dsm.lokiClient != nil {
+		stats := dsm.lokiClient.BreakerStats()
+		status.LokiBreaker = &stats
+	}
+
+	return status
 }
 
 // GetCollectorStatus returns the status of all metric collectors
@@ -287,17 +550,26 @@ func (dsm *DataSourceManager) checkHealth(ctx context.Context) {
 		LastCheck:         time.Now(),
 	}
 
-	// Check Prometheus health
+	// Check Prometheus health; with several sources all of them must be healthy
 	if dsm.promClient != nil {
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
-		// Simple health check query
-		_, err := dsm.promClient.Query(ctx, "up")
-		status.PrometheusHealthy = err == nil
-		if err != nil {
-			status.PrometheusError = err.Error()
+		var failures []string
+		for _, name := range dsm.promSources {
+			// Simple health check query
+			_, err := dsm.promClients[name].Query(ctx, "up")
+			if err == nil {
+				continue
+			}
+			if len(dsm.promSources) > 1 {
+				failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			} else {
+				failures = append(failures, err.Error())
+			}
 		}
+		status.PrometheusHealthy = len(failures) == 0
+		status.PrometheusError = strings.Join(failures, "; ")
 	}
 
 	// Check Loki health
@@ -338,6 +610,36 @@ type HealthMonitor struct {
 	status   *HealthStatus
 	interval time.Duration
 	mu       sync.RWMutex
+	
+	// history holds the most recent healthy/unhealthy transitions, oldest first;
+	// a source is flapping with more than flapThreshold of them within flapWindow
+	history       []HealthTransition
+	checked       bool
+	flapWindow    time.Duration
+	flapThreshold int
+}
+
+const (
+	// DefaultFlapWindow is the window in which health transitions are counted
+	DefaultFlapWindow = 10 * time.Minute
+	// DefaultFlapThreshold is the number of transitions within the window above
+	// which a source is reported as flapping
+	DefaultFlapThreshold = 3
+	// MaxHealthHistory is the number of transitions kept per monitor
+	MaxHealthHistory = 50
+)
+
+// Data source names used in health transitions
+const (
+	HealthSourcePrometheus = "prometheus"
+	HealthSourceLoki       = "loki"
+)
+
+// HealthTransition records a data source changing between healthy and unhealthy
+type HealthTransition struct {
+	Source  string    `json:"source"`
+	Healthy bool      `json:"healthy"`
+	At      time.Time `json:"at"`
 }
 
 // HealthStatus represents the health of data sources
@@ -347,6 +649,13 @@ type HealthStatus struct {
 	PrometheusError   string
 	LokiError         string
 	LastCheck         time.Time
+	PrometheusBreaker *BreakerStats
+	LokiBreaker       *BreakerStats
+	// Flapping is set when a source changed status more than the flap threshold
+	// times within the flap window; Transitions are the recent changes, oldest first
+	PrometheusFlapping bool
+	LokiFlapping       bool
+	Transitions        []HealthTransition
 }
 
 // NewHealthMonitor creates a new health monitor
@@ -358,28 +667,80 @@ func NewHealthMonitor(interval time.Duration) *HealthMonitor {
 			LokiHealthy:       false,
 			LastCheck:         time.Now(),
 		},
+		flapWindow:    DefaultFlapWindow,
+		flapThreshold: DefaultFlapThreshold,
 	}
 }
 
-// UpdateStatus updates the health status
+// SetFlapDetection sets the window and the number of transitions within it
+// above which a source is reported as flapping. Non-positive values keep the defaults.
+func (hm *HealthMonitor) SetFlapDetection(window time.Duration, threshold int) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	
+	if window > 0 {
+		hm.flapWindow = window
+	}
+	if threshold > 0 {
+		hm.flapThreshold = threshold
+	}
+}
+
+// UpdateStatus updates the health status, recording sources whose health changed
 func (hm *HealthMonitor) UpdateStatus(status *HealthStatus) {
 	hm.mu.Lock()
 	defer hm.mu.Unlock()
+	
+	// The initial status is a placeholder, not an observed state
+	if hm.checked {
+		if status.PrometheusHealthy != hm.status.PrometheusHealthy {
+			hm.recordTransition(HealthSourcePrometheus, status.PrometheusHealthy, status.LastCheck)
+		}
+		if status.LokiHealthy != hm.status.LokiHealthy {
+			hm.recordTransition(HealthSourceLoki, status.LokiHealthy, status.LastCheck)
+		}
+	}
+	hm.checked = true
 	hm.status = status
 }
 
+// recordTransition appends a transition, dropping the oldest beyond MaxHealthHistory
+func (hm *HealthMonitor) recordTransition(source string, healthy bool, at time.Time) {
+	hm.history = append(hm.history, HealthTransition{Source: source, Healthy: healthy, At: at})
+	if len(hm.history) > MaxHealthHistory {
+		hm.history = hm.history[len(hm.history)-MaxHealthHistory:]
+	}
+}
+
+// isFlapping reports whether the source changed status more than the flap
+// threshold times within the flap window
+func (hm *HealthMonitor) isFlapping(source string, now time.Time) bool {
+	cutoff := now.Add(-hm.flapWindow)
+	transitions := 0
+	for _, transition := range hm.history {
+		if transition.Source == source && transition.At.After(cutoff) {
+			transitions++
+		}
+	}
+	return transitions > hm.flapThreshold
+}
+
 // GetStatus returns the current health status
 func (hm *HealthMonitor) GetStatus() *HealthStatus {
 	hm.mu.RLock()
 	defer hm.mu.RUnlock()
 	
 	// Return a copy to avoid race conditions
+	now := time.Now()
 	return &HealthStatus{
-		PrometheusHealthy: hm.status.PrometheusHealthy,
-		LokiHealthy:       hm.status.LokiHealthy,
-		PrometheusError:   hm.status.PrometheusError,
-		LokiError:         hm.status.LokiError,
-		LastCheck:         hm.status.LastCheck,
+		PrometheusHealthy:  hm.status.PrometheusHealthy,
+		LokiHealthy:        hm.status.LokiHealthy,
+		PrometheusError:    hm.status.PrometheusError,
+		LokiError:          hm.status.LokiError,
+		LastCheck:          hm.status.LastCheck,
+		PrometheusFlapping: hm.isFlapping(HealthSourcePrometheus, now),
+		LokiFlapping:       hm.isFlapping(HealthSourceLoki, now),
+		Transitions:        append([]HealthTransition(nil), hm.history...),
 	}
 }
 
@@ -401,10 +762,13 @@ func NewDataSourceIntegration(manager *DataSourceManager, detectorStore Detector
 func (dsi *DataSourceIntegration) ConfigureDetectorDataSources(detectorID string, config *DetectorDataSourceConfig) error {
 	// Configure metrics collection
 	if config.MetricQuery != "" {
-		err := dsi.manager.AddMetricCollector(
+		err := dsi.manager.AddTypedMetricCollector(
 			detectorID,
+			config.Source,
 			config.MetricQuery,
 			config.CollectionInterval,
+			config.MetricType,
+			config.Transformers...,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to configure metric collector: %w", err)
@@ -433,7 +797,12 @@ func (dsi *DataSourceIntegration) RemoveDetectorDataSources(detectorID string) {
 
 // DetectorDataSourceConfig contains data source configuration for a detector
 type DetectorDataSourceConfig struct {
+	// Source names the Prometheus source MetricQuery runs on (the default one when empty)
+	Source             string
 	MetricQuery        string
 	LogQuery           string
 	CollectionInterval time.Duration
+	Transformers       []string
+	// MetricType selects the default transformer when Transformers is empty
+	MetricType MetricType
 } 
\ No newline at end of file
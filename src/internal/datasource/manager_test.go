@@ -0,0 +1,198 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor_Flapping(t *testing.T) {
+	hm := NewHealthMonitor(time.Minute)
+	hm.SetFlapDetection(time.Hour, 3)
+
+	// The first check only establishes the state
+	now := time.Now().Add(-time.Minute)
+	hm.UpdateStatus(&HealthStatus{PrometheusHealthy: true, LokiHealthy: false, LastCheck: now})
+	if status := hm.GetStatus(); len(status.Transitions) != 0 {
+		t.Fatalf("expected no transitions after the first check, got %+v", status.Transitions)
+	}
+
+	// Prometheus flaps while Loki stays down
+	for i, healthy := range []bool{false, true, false} {
+		hm.UpdateStatus(&HealthStatus{PrometheusHealthy: healthy, LastCheck: now.Add(time.Duration(i+1) * time.Second)})
+	}
+	status := hm.GetStatus()
+	if len(status.Transitions) != 3 || status.PrometheusFlapping {
+		t.Fatalf("expected 3 transitions without flapping, got %d, flapping %t",
+			len(status.Transitions), status.PrometheusFlapping)
+	}
+
+	hm.UpdateStatus(&HealthStatus{PrometheusHealthy: true, LastCheck: now.Add(4 * time.Second)})
+	status = hm.GetStatus()
+	if !status.PrometheusFlapping {
+		t.Error("expected prometheus to be flapping after 4 transitions")
+	}
+	if status.LokiFlapping {
+		t.Error("a consistently down source should not be flapping")
+	}
+	last := status.Transitions[len(status.Transitions)-1]
+	if last.Source != HealthSourcePrometheus || !last.Healthy {
+		t.Errorf("unexpected last transition: %+v", last)
+	}
+
+	// Transitions outside the window no longer count
+	hm.SetFlapDetection(time.Nanosecond, 0)
+	if hm.GetStatus().PrometheusFlapping {
+		t.Error("expected old transitions to fall out of the window")
+	}
+}
+
+func TestHealthMonitor_HistoryIsBounded(t *testing.T) {
+	hm := NewHealthMonitor(time.Minute)
+	now := time.Now()
+	for i := 0; i <= MaxHealthHistory+10; i++ {
+		hm.UpdateStatus(&HealthStatus{LokiHealthy: i%2 == 0, LastCheck: now})
+	}
+	if n := len(hm.GetStatus().Transitions); n != MaxHealthHistory {
+		t.Errorf("expected %d transitions, got %d", MaxHealthHistory, n)
+	}
+}
+
+func TestDataSourceManager_UpdateMetricCollectorInterval(t *testing.T) {
+	config := DefaultDataSourceConfig()
+	config.EnableLogs = false
+	dsm, err := NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	if err := dsm.AddMetricCollector("detector_1", "up", time.Minute); err != nil {
+		t.Fatalf("failed to add collector: %v", err)
+	}
+
+	collector := dsm.metricsPipeline.collectors["detector_detector_1"]
+	lastRun := time.Now().Add(-30 * time.Second)
+	collector.lastRun = lastRun
+
+	if err := dsm.UpdateMetricCollectorInterval("detector_1", 10*time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status := dsm.GetCollectorStatus()["detector_detector_1"]
+	if status.Interval != 10*time.Second || !status.LastRun.Equal(lastRun) {
+		t.Errorf("expected the new interval with the schedule kept, got %+v", status)
+	}
+	if dsm.metricsPipeline.scheduler.schedules["detector_detector_1"] != 10*time.Second {
+		t.Error("expected the collector to be rescheduled")
+	}
+
+	if err := dsm.UpdateMetricCollectorInterval("missing", time.Second); !errors.Is(err, ErrCollectorNotFound) {
+		t.Errorf("expected ErrCollectorNotFound, got %v", err)
+	}
+	if err := dsm.UpdateMetricCollectorInterval("detector_1", 0); err == nil {
+		t.Error("expected an error for a zero interval")
+	}
+}
+
+// fakePrometheus answers every query with a single sample of value
+func fakePrometheus(value string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+			`{"metric":{"__name__":"up"},"value":[1700000000,"` + value + `"]}]}}`))
+	}))
+}
+
+func TestDataSourceManager_PrometheusSources(t *testing.T) {
+	east, west := fakePrometheus("1"), fakePrometheus("2")
+	defer east.Close()
+	defer west.Close()
+
+	config := DefaultDataSourceConfig()
+	config.EnableLogs = false
+	config.PrometheusSources = []PrometheusServer{
+		{Name: "east", URL: east.URL},
+		{Name: "west", URL: west.URL},
+	}
+	dsm, err := NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer dsm.Stop()
+
+	if sources := dsm.PrometheusSources(); len(sources) != 2 || sources[0] != "east" {
+		t.Fatalf("expected sources [east west], got %v", sources)
+	}
+
+	ctx := context.Background()
+	for source, want := range map[string]float64{"": 1, "east": 1, "west": 2} {
+		results, err := dsm.QueryMetricsFrom(ctx, source, "up")
+		if err != nil || len(results) != 1 || results[0].Value != want {
+			t.Errorf("source %q: expected value %v, got %+v, %v", source, want, results, err)
+		}
+	}
+	if _, err := dsm.QueryMetricsFrom(ctx, "north", "up"); !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("expected ErrUnknownSource, got %v", err)
+	}
+
+	if err := dsm.AddMetricCollectorFrom("detector_1", "west", "up", time.Minute); err != nil {
+		t.Fatalf("failed to add collector: %v", err)
+	}
+	if err := dsm.AddMetricCollector("detector_2", "up", time.Minute); err != nil {
+		t.Fatalf("failed to add collector: %v", err)
+	}
+	if err := dsm.AddMetricCollectorFrom("detector_3", "north", "up", time.Minute); !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("expected ErrUnknownSource for a collector, got %v", err)
+	}
+	status := dsm.GetCollectorStatus()
+	if status["detector_detector_1"].Source != "west" || status["detector_detector_2"].Source != "east" {
+		t.Errorf("unexpected collector sources: %+v", status)
+	}
+
+	config.PrometheusSources = append(config.PrometheusSources, PrometheusServer{Name: "east", URL: west.URL})
+	if _, err := NewDataSourceManager(config, nil); err == nil {
+		t.Error("expected duplicate source names to be rejected")
+	}
+}
+
+func TestDataSourceManager_ShutdownFlushesBufferedMetrics(t *testing.T) {
+	east, west := fakePrometheus("1"), fakePrometheus("2")
+	defer east.Close()
+	defer west.Close()
+
+	config := DefaultDataSourceConfig()
+	config.EnableLogs = false
+	config.PrometheusSources = []PrometheusServer{
+		{Name: "east", URL: east.URL},
+		{Name: "west", URL: west.URL},
+	}
+	dsm, err := NewDataSourceManager(config, nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	var mu sync.Mutex
+	flushed := make(map[string]bool)
+	dsm.SetBufferFlushHandler(func(metrics []MetricResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, metric := range metrics {
+			flushed[metric.Name] = true
+		}
+	})
+
+	dsm.promClients["east"].buffer.Add(MetricResult{Name: "east_metric", Value: 1})
+	dsm.promClients["west"].buffer.Add(MetricResult{Name: "west_metric", Value: 2})
+
+	if err := dsm.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !flushed["east_metric"] || !flushed["west_metric"] {
+		t.Errorf("expected the buffered metrics of every source to be flushed, got %v", flushed)
+	}
+}
@@ -2,8 +2,11 @@ package datasource
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -24,6 +27,8 @@ type Detector interface {
 type DetectionResult struct {
 	IsAnomaly bool
 	Score     float64
+	// Anomaly is the detector's own record of the anomaly, if it keeps one
+	Anomaly interface{}
 }
 
 // MetricsPipeline handles scheduled metrics collection and transformation
@@ -33,11 +38,15 @@ type MetricsPipeline struct {
 	collectors    map[string]*MetricCollector
 	transformers  map[string]MetricTransformer
 	scheduler     *CollectionScheduler
+	onAnomaly     AnomalyHandler
 	mu            sync.RWMutex
 	stopCh        chan struct{}
 	wg            sync.WaitGroup
 }
 
+// AnomalyHandler is called for every anomaly found by a pipeline-fed detector
+type AnomalyHandler func(detectorID string, point DataPoint, result DetectionResult)
+
 // MetricTransformer defines the interface for metric transformation
 type MetricTransformer interface {
 	Transform(metrics []MetricResult) ([]DataPoint, error)
@@ -61,6 +70,148 @@ func (st *StandardTransformer) Transform(metrics []MetricResult) ([]DataPoint, e
 	return points, nil
 }
 
+// ChainTransformer applies transformers in order, feeding each one's output into the next
+type ChainTransformer struct {
+	Transformers []MetricTransformer
+}
+
+// Transform runs metrics through every transformer in the chain
+func (ct *ChainTransformer) Transform(metrics []MetricResult) ([]DataPoint, error) {
+	if len(ct.Transformers) == 0 {
+		return (&StandardTransformer{}).Transform(metrics)
+	}
+	
+	var points []DataPoint
+	current := metrics
+	for i, transformer := range ct.Transformers {
+		var err error
+		points, err = transformer.Transform(current)
+		if err != nil {
+			return nil, fmt.Errorf("transformer %d in chain: %w", i, err)
+		}
+		
+		// Convert back for the next stage
+		if i < len(ct.Transformers)-1 {
+			current = dataPointsToMetrics(points)
+		}
+	}
+	
+	return points, nil
+}
+
+// dataPointsToMetrics converts transformer output back to transformer input
+func dataPointsToMetrics(points []DataPoint) []MetricResult {
+	metrics := make([]MetricResult, 0, len(points))
+	for _, point := range points {
+		metrics = append(metrics, MetricResult{
+			Name:      point.Labels["__name__"],
+			Value:     point.Value,
+			Timestamp: point.Timestamp,
+			Labels:    point.Labels,
+		})
+	}
+	return metrics
+}
+
+// MetricType is the Prometheus semantics of the collected metric
+type MetricType string
+
+const (
+	// MetricTypeGauge values are used as they are (the default)
+	MetricTypeGauge MetricType = "gauge"
+	// MetricTypeCounter values only grow, detectors are fed their per-second rate
+	MetricTypeCounter MetricType = "counter"
+	// MetricTypeHistogram series (_bucket, _sum, _count) are counters too
+	MetricTypeHistogram MetricType = "histogram"
+)
+
+// ParseMetricType validates a metric type name; an empty name is a gauge
+func ParseMetricType(name string) (MetricType, error) {
+	switch metricType := MetricType(name); metricType {
+	case "":
+		return MetricTypeGauge, nil
+	case MetricTypeGauge, MetricTypeCounter, MetricTypeHistogram:
+		return metricType, nil
+	default:
+		return "", fmt.Errorf("unknown metric type: %s (expected gauge, counter or histogram)", name)
+	}
+}
+
+// defaultTransformer returns the transformer matching the metric semantics
+func defaultTransformer(metricType MetricType) MetricTransformer {
+	switch metricType {
+	case MetricTypeCounter, MetricTypeHistogram:
+		return NewRateTransformer()
+	default:
+		return &StandardTransformer{}
+	}
+}
+
+// RateTransformer converts counter samples into per-second rates. It keeps the
+// previous sample of every series between collections, so each collector needs
+// its own instance. The first sample of a series only sets the baseline; a
+// decrease is taken as a counter reset, as Prometheus' rate() does.
+type RateTransformer struct {
+	last map[string]MetricResult
+	mu   sync.Mutex
+}
+
+// NewRateTransformer creates a rate transformer without history
+func NewRateTransformer() *RateTransformer {
+	return &RateTransformer{last: make(map[string]MetricResult)}
+}
+
+// Transform returns the rate of every series since its previous sample
+func (rt *RateTransformer) Transform(metrics []MetricResult) ([]DataPoint, error) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	
+	points := make([]DataPoint, 0, len(metrics))
+	for _, metric := range metrics {
+		key := seriesKey(metric.Labels)
+		prev, seen := rt.last[key]
+		if seen && !metric.Timestamp.After(prev.Timestamp) {
+			// Same or older sample than the one already used
+			continue
+		}
+		rt.last[key] = metric
+		if !seen {
+			continue
+		}
+		
+		increase := metric.Value - prev.Value
+		if increase < 0 {
+			// Counter reset: it restarted from zero
+			increase = metric.Value
+		}
+		points = append(points, DataPoint{
+			Timestamp: metric.Timestamp,
+			Value:     increase / metric.Timestamp.Sub(prev.Timestamp).Seconds(),
+			Labels:    metric.Labels,
+		})
+	}
+	
+	return points, nil
+}
+
+// seriesKey identifies a series by its sorted labels
+func seriesKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	
+	var key strings.Builder
+	for _, name := range names {
+		key.WriteString(name)
+		key.WriteByte('=')
+		key.WriteString(labels[name])
+		key.WriteByte(',')
+	}
+	return key.String()
+}
+
 // AggregationTransformer provides aggregation-based transformation
 type AggregationTransformer struct {
 	WindowSize   time.Duration
@@ -115,6 +266,15 @@ type MetricCollector struct {
 	Interval     time.Duration
 	DetectorID   string
 	Transformer  MetricTransformer
+	// TransformerNames lists registered transformers to chain when Transformer is nil
+	TransformerNames []string
+	// Source names the Prometheus source queried; Client is its client, the
+	// pipeline's client being used when nil
+	Source       string
+	Client       *EnhancedPrometheusClient
+	// MetricType selects the default transformer (rate for counters and
+	// histograms) when neither Transformer nor TransformerNames are set
+	MetricType   MetricType
 	lastRun      time.Time
 	mu           sync.Mutex
 }
@@ -151,6 +311,13 @@ func (mp *MetricsPipeline) RegisterTransformer(name string, transformer MetricTr
 	mp.transformers[name] = transformer
 }
 
+// SetAnomalyHandler registers a handler for anomalies detected in the pipeline
+func (mp *MetricsPipeline) SetAnomalyHandler(handler AnomalyHandler) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	mp.onAnomaly = handler
+}
+
 // AddCollector adds a new metric collection task
 func (mp *MetricsPipeline) AddCollector(collector *MetricCollector) error {
 	mp.mu.Lock()
@@ -160,9 +327,22 @@ func (mp *MetricsPipeline) AddCollector(collector *MetricCollector) error {
 		return fmt.Errorf("collector %s already exists", collector.ID)
 	}
 	
-	// Default transformer if not specified
+	metricType, err := ParseMetricType(string(collector.MetricType))
+	if err != nil {
+		return fmt.Errorf("collector %s: %w", collector.ID, err)
+	}
+	collector.MetricType = metricType
+	
+	// Resolve named transformers, falling back to the default one for the metric type
+	if collector.Transformer == nil && len(collector.TransformerNames) > 0 {
+		transformer, err := mp.resolveTransformers(collector.TransformerNames)
+		if err != nil {
+			return fmt.Errorf("collector %s: %w", collector.ID, err)
+		}
+		collector.Transformer = transformer
+	}
 	if collector.Transformer == nil {
-		collector.Transformer = &StandardTransformer{}
+		collector.Transformer = defaultTransformer(collector.MetricType)
 	}
 	
 	mp.collectors[collector.ID] = collector
@@ -171,6 +351,71 @@ func (mp *MetricsPipeline) AddCollector(collector *MetricCollector) error {
 	return nil
 }
 
+// resolveTransformers looks up registered transformers by name and chains them (caller must hold mp.mu)
+func (mp *MetricsPipeline) resolveTransformers(names []string) (MetricTransformer, error) {
+	transformers := make([]MetricTransformer, 0, len(names))
+	for _, name := range names {
+		transformer, ok := mp.transformers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown transformer: %s", name)
+		}
+		transformers = append(transformers, transformer)
+	}
+	
+	if len(transformers) == 1 {
+		return transformers[0], nil
+	}
+	return &ChainTransformer{Transformers: transformers}, nil
+}
+
+// ErrCollectorNotFound is returned for operations on an unknown collector
+var ErrCollectorNotFound = errors.New("collector not found")
+
+// UpdateCollectorInterval changes how often a collector runs without
+// recreating it. The collector keeps its last run, so the next run is due one
+// new interval after it (immediately, if that has already passed).
+func (mp *MetricsPipeline) UpdateCollectorInterval(collectorID string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("collection interval must be positive, got %s", interval)
+	}
+	
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	
+	collector, exists := mp.collectors[collectorID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrCollectorNotFound, collectorID)
+	}
+	
+	collector.mu.Lock()
+	collector.Interval = interval
+	collector.mu.Unlock()
+	
+	mp.scheduler.Schedule(collectorID, interval)
+	return nil
+}
+
+// CollectorQuery returns the query of a collector
+func (mp *MetricsPipeline) CollectorQuery(collectorID string) (string, bool) {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+	
+	collector, exists := mp.collectors[collectorID]
+	if !exists {
+		return "", false
+	}
+	return collector.Query, true
+}
+
+// collector returns a collector by ID
+func (mp *MetricsPipeline) collector(collectorID string) (*MetricCollector, bool) {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+	
+	collector, exists := mp.collectors[collectorID]
+	return collector, exists
+}
+
 // RemoveCollector removes a metric collection task
 func (mp *MetricsPipeline) RemoveCollector(collectorID string) {
 	mp.mu.Lock()
@@ -191,9 +436,35 @@ func (mp *MetricsPipeline) Start(ctx context.Context) error {
 
 // Stop stops the metrics pipeline
 func (mp *MetricsPipeline) Stop() {
+	mp.Shutdown(context.Background())
+}
+
+// Shutdown stops scheduling collections, waits for running collections to
+// feed their detectors and then flushes the client's metrics buffer to its
+// flush handler. It returns ctx.Err() if the collections outlive ctx.
+func (mp *MetricsPipeline) Shutdown(ctx context.Context) error {
 	close(mp.stopCh)
-	mp.wg.Wait()
+	
+	done := make(chan struct{})
+	go func() {
+		mp.wg.Wait()
+		close(done)
+	}()
+	
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		log.Printf("Metrics pipeline stopped before running collections finished: %v", err)
+	}
+	
+	if mp.client != nil {
+		mp.client.Close()
+	}
+	
 	log.Println("Metrics pipeline stopped")
+	return err
 }
 
 // runScheduler runs the collection scheduler
@@ -226,6 +497,11 @@ func (mp *MetricsPipeline) checkAndRunCollectors(ctx context.Context) {
 	
 	now := time.Now()
 	for _, collector := range collectors {
+		// Paused detectors keep their collector but are not fed
+		if mp.isDetectorPaused(collector.DetectorID) {
+			continue
+		}
+		
 		collector.mu.Lock()
 		shouldRun := collector.lastRun.IsZero() || now.Sub(collector.lastRun) >= collector.Interval
 		if shouldRun {
@@ -241,12 +517,31 @@ func (mp *MetricsPipeline) checkAndRunCollectors(ctx context.Context) {
 	}
 }
 
+// isDetectorPaused reports whether the collector's target detector is paused
+func (mp *MetricsPipeline) isDetectorPaused(detectorID string) bool {
+	if detectorID == "" || mp.detectorStore == nil {
+		return false
+	}
+	
+	detInterface, err := mp.detectorStore.Get(detectorID)
+	if err != nil {
+		return false
+	}
+	
+	det, ok := detInterface.(Detector)
+	return ok && det.GetStatus() == "paused"
+}
+
 // runCollector executes a single collector
 func (mp *MetricsPipeline) runCollector(ctx context.Context, collector *MetricCollector) {
 	defer mp.wg.Done()
 	
 	// Query metrics
-	metrics, err := mp.client.Query(ctx, collector.Query)
+	client := collector.Client
+	if client == nil {
+		client = mp.client
+	}
+	metrics, err := client.Query(ctx, collector.Query)
 	if err != nil {
 		log.Printf("Error collecting metrics for %s: %v", collector.ID, err)
 		return
@@ -260,7 +555,7 @@ func (mp *MetricsPipeline) runCollector(ctx context.Context, collector *MetricCo
 	}
 	
 	// Send to detector
-	if collector.DetectorID != "" {
+	if collector.DetectorID != "" && mp.detectorStore != nil {
 		detInterface, err := mp.detectorStore.Get(collector.DetectorID)
 		if err != nil {
 			log.Printf("Error getting detector %s: %v", collector.DetectorID, err)
@@ -286,7 +581,12 @@ func (mp *MetricsPipeline) runCollector(ctx context.Context, collector *MetricCo
 				result := det.Detect(value)
 				if result.IsAnomaly {
 					log.Printf("Anomaly detected by %s: score=%f", collector.DetectorID, result.Score)
-					// TODO: Send anomaly event via WebSocket
+					mp.mu.RLock()
+					onAnomaly := mp.onAnomaly
+					mp.mu.RUnlock()
+					if onAnomaly != nil {
+						onAnomaly(collector.DetectorID, point, result)
+					}
 				}
 			}
 		}
@@ -295,14 +595,15 @@ func (mp *MetricsPipeline) runCollector(ctx context.Context, collector *MetricCo
 	log.Printf("Collected %d metrics for %s", len(dataPoints), collector.ID)
 }
 
-// CreateCollectorForDetector creates a collector based on detector configuration
-func (mp *MetricsPipeline) CreateCollectorForDetector(detectorID string, query string, interval time.Duration) error {
+// CreateCollectorForDetector creates a collector based on detector configuration.
+// Transformer names are applied in order; the standard transformer is used when none are given.
+func (mp *MetricsPipeline) CreateCollectorForDetector(detectorID string, query string, interval time.Duration, transformerNames ...string) error {
 	collector := &MetricCollector{
-		ID:          fmt.Sprintf("detector_%s", detectorID),
-		Query:       query,
-		Interval:    interval,
-		DetectorID:  detectorID,
-		Transformer: &StandardTransformer{},
+		ID:               fmt.Sprintf("detector_%s", detectorID),
+		Query:            query,
+		Interval:         interval,
+		DetectorID:       detectorID,
+		TransformerNames: transformerNames,
 	}
 	
 	return mp.AddCollector(collector)
@@ -319,6 +620,8 @@ func (mp *MetricsPipeline) GetCollectorStatus() map[string]CollectorStatus {
 		status[id] = CollectorStatus{
 			ID:       collector.ID,
 			Query:    collector.Query,
+			Source:   collector.Source,
+			Type:     collector.MetricType,
 			Interval: collector.Interval,
 			LastRun:  collector.lastRun,
 			NextRun:  collector.lastRun.Add(collector.Interval),
@@ -333,6 +636,8 @@ func (mp *MetricsPipeline) GetCollectorStatus() map[string]CollectorStatus {
 type CollectorStatus struct {
 	ID       string
 	Query    string
+	Source   string
+	Type     MetricType
 	Interval time.Duration
 	LastRun  time.Time
 	NextRun  time.Time
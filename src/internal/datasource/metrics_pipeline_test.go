@@ -0,0 +1,231 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateTransformer(t *testing.T) {
+	rt := NewRateTransformer()
+	start := time.Unix(1000, 0)
+	sample := func(host string, offset time.Duration, value float64) MetricResult {
+		return MetricResult{Timestamp: start.Add(offset), Value: value, Labels: map[string]string{"host": host}}
+	}
+
+	// The first sample of each series only sets the baseline
+	points, _ := rt.Transform([]MetricResult{sample("a", 0, 100), sample("b", 0, 10)})
+	if len(points) != 0 {
+		t.Fatalf("expected no points for the first samples, got %+v", points)
+	}
+
+	points, _ = rt.Transform([]MetricResult{sample("a", 10*time.Second, 150), sample("b", 10*time.Second, 4)})
+	if len(points) != 2 {
+		t.Fatalf("expected a rate per series, got %+v", points)
+	}
+	if points[0].Value != 5 {
+		t.Errorf("expected 5/s for a, got %v", points[0].Value)
+	}
+	// b went down: a counter reset counts from zero
+	if points[1].Value != 0.4 {
+		t.Errorf("expected 0.4/s for b after the reset, got %v", points[1].Value)
+	}
+
+	// A repeated sample yields nothing
+	if points, _ = rt.Transform([]MetricResult{sample("a", 10*time.Second, 150)}); len(points) != 0 {
+		t.Errorf("expected no point for a repeated sample, got %+v", points)
+	}
+}
+
+func TestAddCollector_MetricType(t *testing.T) {
+	mp := NewMetricsPipeline(nil, nil)
+
+	for _, tc := range []struct {
+		id         string
+		metricType MetricType
+		names      []string
+		rate       bool
+	}{
+		{id: "gauge", rate: false},
+		{id: "counter", metricType: MetricTypeCounter, rate: true},
+		{id: "histogram", metricType: MetricTypeHistogram, rate: true},
+		{id: "override", metricType: MetricTypeCounter, names: []string{"standard"}, rate: false},
+	} {
+		collector := &MetricCollector{ID: tc.id, Query: "up", Interval: time.Minute, MetricType: tc.metricType, TransformerNames: tc.names}
+		if err := mp.AddCollector(collector); err != nil {
+			t.Fatalf("%s: failed to add collector: %v", tc.id, err)
+		}
+		if _, rate := collector.Transformer.(*RateTransformer); rate != tc.rate {
+			t.Errorf("%s: expected rate transformer %v, got %T", tc.id, tc.rate, collector.Transformer)
+		}
+	}
+
+	// Counter collectors keep separate series state
+	if mp.collectors["counter"].Transformer == mp.collectors["histogram"].Transformer {
+		t.Error("expected each collector to get its own rate transformer")
+	}
+	if status := mp.GetCollectorStatus()["gauge"]; status.Type != MetricTypeGauge {
+		t.Errorf("expected an unset type to default to gauge, got %q", status.Type)
+	}
+
+	if err := mp.AddCollector(&MetricCollector{ID: "bad", MetricType: "summary"}); err == nil {
+		t.Error("expected an error for an unknown metric type")
+	}
+}
+
+// scaleTransformer multiplies values by factor
+type scaleTransformer struct {
+	factor float64
+}
+
+func (st *scaleTransformer) Transform(metrics []MetricResult) ([]DataPoint, error) {
+	points := make([]DataPoint, 0, len(metrics))
+	for _, metric := range metrics {
+		points = append(points, DataPoint{Timestamp: metric.Timestamp, Value: metric.Value * st.factor, Labels: metric.Labels})
+	}
+	return points, nil
+}
+
+func TestAddCollector_TransformerChain(t *testing.T) {
+	mp := NewMetricsPipeline(nil, nil)
+	mp.RegisterTransformer("double", &scaleTransformer{factor: 2})
+	mp.RegisterTransformer("tenfold", &scaleTransformer{factor: 10})
+
+	if err := mp.CreateCollectorForDetector("cpu", "up", time.Minute, "double", "tenfold"); err != nil {
+		t.Fatalf("failed to create collector: %v", err)
+	}
+	collector := mp.collectors["detector_cpu"]
+	if _, chained := collector.Transformer.(*ChainTransformer); !chained {
+		t.Fatalf("expected a chain of the named transformers, got %T", collector.Transformer)
+	}
+
+	labels := map[string]string{"__name__": "cpu_usage", "host": "a"}
+	points, err := collector.Transformer.Transform([]MetricResult{{Name: "cpu_usage", Value: 1.5, Labels: labels}})
+	if err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+	if len(points) != 1 || points[0].Value != 30 || points[0].Labels["host"] != "a" {
+		t.Errorf("expected each transformer to apply in turn, got %+v", points)
+	}
+
+	// A single name is used as is, an unknown one is rejected
+	if err := mp.CreateCollectorForDetector("mem", "up", time.Minute, "double"); err != nil {
+		t.Fatalf("failed to create collector: %v", err)
+	}
+	if _, single := mp.collectors["detector_mem"].Transformer.(*scaleTransformer); !single {
+		t.Errorf("expected the named transformer, got %T", mp.collectors["detector_mem"].Transformer)
+	}
+	if err := mp.CreateCollectorForDetector("disk", "up", time.Minute, "double", "missing"); err == nil {
+		t.Error("expected an error for an unknown transformer")
+	}
+	if _, exists := mp.collectors["detector_disk"]; exists {
+		t.Error("a collector with an unknown transformer should not be added")
+	}
+}
+
+func TestChainTransformer_PropagatesErrors(t *testing.T) {
+	chain := &ChainTransformer{Transformers: []MetricTransformer{&scaleTransformer{factor: 2}, failingTransformer{}}}
+	if _, err := chain.Transform([]MetricResult{{Value: 1}}); err == nil {
+		t.Error("expected the failing stage's error")
+	}
+
+	// An empty chain behaves like the standard transformer
+	points, err := (&ChainTransformer{}).Transform([]MetricResult{{Value: 3}})
+	if err != nil || len(points) != 1 || points[0].Value != 3 {
+		t.Errorf("expected the value unchanged, got %+v, %v", points, err)
+	}
+}
+
+// failingTransformer always fails
+type failingTransformer struct{}
+
+func (failingTransformer) Transform([]MetricResult) ([]DataPoint, error) {
+	return nil, errors.New("transform failed")
+}
+
+// stubDetector counts the points it is fed
+type stubDetector struct {
+	mu       sync.Mutex
+	status   string
+	detected int
+}
+
+func (d *stubDetector) GetStatus() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+func (d *stubDetector) setStatus(status string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status = status
+}
+
+func (d *stubDetector) Train(data []float64) {}
+
+func (d *stubDetector) Detect(data []float64) DetectionResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.detected++
+	return DetectionResult{}
+}
+
+func (d *stubDetector) fed() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.detected
+}
+
+// stubDetectorStore serves stub detectors by ID
+type stubDetectorStore map[string]*stubDetector
+
+func (s stubDetectorStore) Get(id string) (interface{}, error) {
+	if det, ok := s[id]; ok {
+		return det, nil
+	}
+	return nil, fmt.Errorf("detector %s not found", id)
+}
+
+func TestCheckAndRunCollectors_SkipsPausedDetectors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[`+
+			`{"metric":{"job":"api"},"value":[%d,"1"]}]}}`, time.Now().Unix())
+	}))
+	defer server.Close()
+
+	client, err := NewEnhancedPrometheusClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	paused := &stubDetector{status: "paused"}
+	running := &stubDetector{status: "running"}
+	mp := NewMetricsPipeline(client, stubDetectorStore{"paused": paused, "running": running})
+
+	for id, query := range map[string]string{"paused": `up{job="a"}`, "running": `up{job="b"}`} {
+		if err := mp.AddCollector(&MetricCollector{ID: id, DetectorID: id, Query: query, Interval: time.Hour}); err != nil {
+			t.Fatalf("failed to add collector: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	mp.checkAndRunCollectors(ctx)
+	mp.wg.Wait()
+	if paused.fed() != 0 || running.fed() != 1 {
+		t.Fatalf("expected only the running detector to be fed, got paused %d, running %d", paused.fed(), running.fed())
+	}
+
+	// A resumed detector is collected for on the next check, without waiting an interval
+	paused.setStatus("running")
+	mp.checkAndRunCollectors(ctx)
+	mp.wg.Wait()
+	if paused.fed() != 1 || running.fed() != 1 {
+		t.Errorf("expected only the resumed detector to be fed, got resumed %d, running %d", paused.fed(), running.fed())
+	}
+}
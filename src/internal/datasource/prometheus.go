@@ -3,7 +3,9 @@ package datasource
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/api"
@@ -36,11 +38,12 @@ func NewPrometheusSource(config *Config) (*PrometheusSource, error) {
 			rt:       api.DefaultRoundTripper,
 		}
 		clientConfig.RoundTripper = rt
-	} else if config.PrometheusAuth.Token != "" {
+	} else if config.PrometheusAuth.Token != "" || config.PrometheusAuth.TokenProvider != nil {
 		// Создаем транспорт с токеном авторизации
 		rt := &tokenAuthRoundTripper{
-			token: config.PrometheusAuth.Token,
-			rt:    api.DefaultRoundTripper,
+			token:    config.PrometheusAuth.Token,
+			provider: config.PrometheusAuth.TokenProvider,
+			rt:       api.DefaultRoundTripper,
 		}
 		clientConfig.RoundTripper = rt
 	}
@@ -120,19 +123,86 @@ func (rt *basicAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, e
 	return rt.rt.RoundTrip(req)
 }
 
-// tokenAuthRoundTripper реализация RoundTripper для авторизации по токену
+// TokenProvider возвращает bearer-токен для Prometheus. Используется вместо
+// статического токена для короткоживущих токенов (например, OIDC)
+type TokenProvider func() (string, error)
+
+// tokenAuthRoundTripper реализация RoundTripper для авторизации по токену.
+// Если задан provider, токен запрашивается при первом запросе и повторно,
+// когда Prometheus отвечает 401; запрос после обновления токена повторяется один раз
 type tokenAuthRoundTripper struct {
-	token string
-	rt    http.RoundTripper
+	token    string
+	provider TokenProvider
+	rt       http.RoundTripper
+	mu       sync.Mutex
 }
 
 // RoundTrip implements the http.RoundTripper interface
 func (rt *tokenAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.currentToken("")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := rt.send(req, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || rt.provider == nil {
+		return resp, err
+	}
+
+	// Тело запроса уже прочитано и не может быть отправлено повторно
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	fresh, err := rt.currentToken(token)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	retry := req
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		retry = req.Clone(req.Context())
+		retry.Body = body
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return rt.send(retry, fresh)
+}
+
+// send отправляет копию запроса с указанным токеном
+func (rt *tokenAuthRoundTripper) send(req *http.Request, token string) (*http.Response, error) {
 	req = cloneRequest(req)
-	req.Header.Set("Authorization", "Bearer "+rt.token)
+	req.Header.Set("Authorization", "Bearer "+token)
 	return rt.rt.RoundTrip(req)
 }
 
+// currentToken возвращает текущий токен. Если токен равен stale (был отклонен)
+// или еще не получен, запрашивает новый у provider
+func (rt *tokenAuthRoundTripper) currentToken(stale string) (string, error) {
+	if rt.provider == nil {
+		return rt.token, nil
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if rt.token == "" || (stale != "" && rt.token == stale) {
+		token, err := rt.provider()
+		if err != nil {
+			return "", fmt.Errorf("failed to get prometheus auth token: %w", err)
+		}
+		rt.token = token
+	}
+	return rt.token, nil
+}
+
 // cloneRequest создает клон HTTP-запроса для безопасного изменения
 func cloneRequest(r *http.Request) *http.Request {
 	// shallow copy
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
+	"github.com/yourusername/aiops-infra/src/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // PrometheusCollector автоматически собирает метрики из Prometheus
@@ -21,6 +25,7 @@ type PrometheusCollector struct {
 	mu            sync.RWMutex
 	stopCh        chan struct{}
 	wg            sync.WaitGroup
+	running       atomic.Bool // цикл сбора запущен
 }
 
 // MetricCallback определяет функцию обратного вызова для обработки собранных метрик
@@ -28,8 +33,20 @@ type MetricCallback func(metricName string, timestamp time.Time, value float64,
 
 // NewPrometheusCollector создаёт новый коллектор метрик Prometheus
 func NewPrometheusCollector(promURL string, collectPeriod time.Duration, callback MetricCallback) (*PrometheusCollector, error) {
+	return NewPrometheusCollectorWithTLS(promURL, collectPeriod, callback, nil)
+}
+
+// NewPrometheusCollectorWithTLS создаёт коллектор метрик Prometheus с настройками TLS (mTLS)
+func NewPrometheusCollectorWithTLS(promURL string, collectPeriod time.Duration, callback MetricCallback, tlsConfig *TLSConfig) (*PrometheusCollector, error) {
+	// Общий транспорт (пул соединений), если он задан через SetHTTPClient
+	transport, err := newRoundTripper(tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка настройки TLS: %w", err)
+	}
+
 	client, err := api.NewClient(api.Config{
-		Address: promURL,
+		Address:      promURL,
+		RoundTripper: transport,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("ошибка создания клиента Prometheus: %w", err)
@@ -63,6 +80,8 @@ func (pc *PrometheusCollector) Start(ctx context.Context) {
 	pc.wg.Add(1)
 	go func() {
 		defer pc.wg.Done()
+		pc.running.Store(true)
+		defer pc.running.Store(false)
 
 		ticker := time.NewTicker(pc.collectPeriod)
 		defer ticker.Stop()
@@ -86,6 +105,18 @@ func (pc *PrometheusCollector) Stop() {
 	pc.wg.Wait()
 }
 
+// Ready возвращает ошибку, пока цикл сбора не запущен или Prometheus не
+// отвечает на проверочный запрос
+func (pc *PrometheusCollector) Ready(ctx context.Context) error {
+	if !pc.running.Load() {
+		return fmt.Errorf("сбор метрик не запущен")
+	}
+	if _, _, err := pc.api.Query(ctx, "up", time.Now()); err != nil {
+		return fmt.Errorf("ошибка запроса к Prometheus: %w", err)
+	}
+	return nil
+}
+
 // collectMetrics собирает все зарегистрированные метрики
 func (pc *PrometheusCollector) collectMetrics(ctx context.Context) {
 	pc.mu.RLock()
@@ -105,8 +136,12 @@ func (pc *PrometheusCollector) collectMetrics(ctx context.Context) {
 
 // executeQuery выполняет один запрос Prometheus
 func (pc *PrometheusCollector) executeQuery(ctx context.Context, name, query string) error {
+	ctx, span := startQuerySpan(ctx, "prometheus.query", query)
+	defer span.End()
+
 	result, warnings, err := pc.api.Query(ctx, query, time.Now())
 	if err != nil {
+		tracing.RecordError(span, err)
 		return fmt.Errorf("ошибка запроса к Prometheus: %w", err)
 	}
 
@@ -167,8 +202,12 @@ func (pc *PrometheusCollector) executeQuery(ctx context.Context, name, query str
 
 // RunInstantQuery выполняет моментальный запрос и возвращает результаты
 func (pc *PrometheusCollector) RunInstantQuery(ctx context.Context, query string) ([]MetricResult, error) {
+	ctx, span := startQuerySpan(ctx, "prometheus.query", query)
+	defer span.End()
+
 	result, warnings, err := pc.api.Query(ctx, query, time.Now())
 	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("ошибка запроса к Prometheus: %w", err)
 	}
 
@@ -187,8 +226,13 @@ func (pc *PrometheusCollector) RunRangeQuery(ctx context.Context, query string,
 		Step:  step,
 	}
 
+	ctx, span := startQuerySpan(ctx, "prometheus.query_range", query)
+	span.SetAttributes(attribute.String("db.query.step", step.String()))
+	defer span.End()
+
 	result, warnings, err := pc.api.QueryRange(ctx, query, r)
 	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("ошибка запроса к Prometheus: %w", err)
 	}
 
@@ -199,6 +243,12 @@ func (pc *PrometheusCollector) RunRangeQuery(ctx context.Context, query string,
 	return parseRangeResult(result)
 }
 
+// startQuerySpan начинает span запроса к источнику данных; HTTP-запросы
+// внутри него трассирует транспорт клиента
+func startQuerySpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(attribute.String("db.statement", query)))
+}
+
 // MetricResult представляет одно значение метрики
 type MetricResult struct {
 	Name      string
@@ -220,8 +270,12 @@ type MetricPoint struct {
 }
 
 // parseQueryResult преобразует результат запроса Prometheus в структурированные данные
+// Пустой результат (нет текущих сэмплов) не является ошибкой: возвращается пустой срез.
 func parseQueryResult(result model.Value) ([]MetricResult, error) {
-	var metrics []MetricResult
+	metrics := make([]MetricResult, 0)
+	if result == nil {
+		return metrics, nil
+	}
 
 	switch resultType := result.Type(); resultType {
 	case model.ValVector:
@@ -257,6 +311,41 @@ func parseQueryResult(result model.Value) ([]MetricResult, error) {
 			Labels:    make(map[string]string),
 		})
 
+	case model.ValMatrix:
+		// Мгновенный запрос с подзапросом или селектором диапазона возвращает матрицу:
+		// каждый сэмпл серии становится отдельным значением
+		matrix, ok := result.(model.Matrix)
+		if !ok {
+			return nil, fmt.Errorf("ошибка приведения результата к типу Matrix")
+		}
+
+		for _, stream := range matrix {
+			labels := make(map[string]string, len(stream.Metric))
+			for k, v := range stream.Metric {
+				labels[string(k)] = string(v)
+			}
+
+			for _, value := range stream.Values {
+				metrics = append(metrics, MetricResult{
+					Name:      string(stream.Metric[model.MetricNameLabel]),
+					Value:     float64(value.Value),
+					Timestamp: time.Unix(value.Timestamp.Unix(), 0),
+					Labels:    labels,
+				})
+			}
+		}
+
+	case model.ValString:
+		// Строковый результат не несет числового значения
+		str, ok := result.(*model.String)
+		if !ok {
+			return nil, fmt.Errorf("ошибка приведения результата к типу String")
+		}
+		return nil, fmt.Errorf("запрос вернул строковый результат %q, ожидалось числовое значение", str.Value)
+
+	case model.ValNone:
+		// Пустой результат
+
 	default:
 		return nil, fmt.Errorf("неподдерживаемый тип результата: %s", resultType)
 	}
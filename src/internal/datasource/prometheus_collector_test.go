@@ -0,0 +1,57 @@
+package datasource
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/model"
+)
+
+func TestParseQueryResult(t *testing.T) {
+	// No current samples is an empty result, not an error
+	for _, empty := range []model.Value{nil, model.Vector{}, model.Matrix{}} {
+		metrics, err := parseQueryResult(empty)
+		if err != nil || metrics == nil || len(metrics) != 0 {
+			t.Errorf("expected an empty non-nil result for %v, got %v, %v", empty, metrics, err)
+		}
+	}
+
+	vector := model.Vector{{
+		Metric:    model.Metric{model.MetricNameLabel: "up", "job": "api"},
+		Value:     1,
+		Timestamp: model.TimeFromUnix(1700000000),
+	}}
+	metrics, err := parseQueryResult(vector)
+	if err != nil {
+		t.Fatalf("vector result failed: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].Name != "up" || metrics[0].Labels["job"] != "api" || metrics[0].Value != 1 {
+		t.Errorf("unexpected vector metrics: %+v", metrics)
+	}
+
+	// Every sample of a matrix series becomes a value
+	matrix := model.Matrix{{
+		Metric: model.Metric{model.MetricNameLabel: "http_requests_total", "code": "500"},
+		Values: []model.SamplePair{
+			{Timestamp: model.TimeFromUnix(1700000000), Value: 3},
+			{Timestamp: model.TimeFromUnix(1700000060), Value: 5},
+		},
+	}}
+	metrics, err = parseQueryResult(matrix)
+	if err != nil {
+		t.Fatalf("matrix result failed: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 values from the matrix, got %+v", metrics)
+	}
+	if metrics[1].Name != "http_requests_total" || metrics[1].Value != 5 || metrics[1].Timestamp.Unix() != 1700000060 ||
+		metrics[1].Labels["code"] != "500" {
+		t.Errorf("unexpected matrix value: %+v", metrics[1])
+	}
+
+	// A string result carries no numeric value
+	_, err = parseQueryResult(&model.String{Value: "build-42", Timestamp: model.TimeFromUnix(1700000000)})
+	if err == nil || !strings.Contains(err.Error(), "build-42") {
+		t.Errorf("expected a string result to be rejected, got %v", err)
+	}
+}
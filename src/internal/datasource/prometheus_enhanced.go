@@ -3,6 +3,8 @@ package datasource
 import (
 	"context"
 	"fmt"
+	"log"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -104,9 +106,13 @@ type EnhancedPrometheusClient struct {
 	buffer        *MetricsBuffer
 	config        *EnhancedConfig
 	queryCache    *queryCache
+	breaker       *CircuitBreaker
 	mu            sync.RWMutex
 }
 
+// DefaultBatchConcurrency is how many queries of a batch run against Prometheus at once
+const DefaultBatchConcurrency = 4
+
 // EnhancedConfig contains configuration for the enhanced client
 type EnhancedConfig struct {
 	BufferSize      int
@@ -115,6 +121,14 @@ type EnhancedConfig struct {
 	MaxRetries      int
 	RetryDelay      time.Duration
 	BatchSize       int
+	// BatchConcurrency caps the queries of a BatchQuery in flight at once (non-positive uses the default)
+	BatchConcurrency int
+	TLS             *TLSConfig
+	// TokenProvider supplies bearer tokens; it is called again when Prometheus answers 401
+	TokenProvider TokenProvider
+	// Circuit breaker: open after BreakerThreshold consecutive failed queries for BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
 }
 
 // DefaultEnhancedConfig returns default configuration
@@ -126,6 +140,9 @@ func DefaultEnhancedConfig() *EnhancedConfig {
 		MaxRetries:    3,
 		RetryDelay:    1 * time.Second,
 		BatchSize:     1000,
+		BatchConcurrency: DefaultBatchConcurrency,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
 	}
 }
 
@@ -135,8 +152,19 @@ func NewEnhancedPrometheusClient(address string, config *EnhancedConfig) (*Enhan
 		config = DefaultEnhancedConfig()
 	}
 	
+	transport, err := newRoundTripper(config.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	
+	var roundTripper http.RoundTripper = transport
+	if config.TokenProvider != nil {
+		roundTripper = &tokenAuthRoundTripper{provider: config.TokenProvider, rt: transport}
+	}
+	
 	client, err := api.NewClient(api.Config{
-		Address: address,
+		Address:      address,
+		RoundTripper: roundTripper,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Prometheus client: %w", err)
@@ -147,6 +175,7 @@ func NewEnhancedPrometheusClient(address string, config *EnhancedConfig) (*Enhan
 		buffer:     NewMetricsBuffer(config.BufferSize, config.BufferTimeout),
 		config:     config,
 		queryCache: newQueryCache(config.CacheDuration),
+		breaker:    NewCircuitBreaker("prometheus", config.BreakerThreshold, config.BreakerCooldown),
 	}, nil
 }
 
@@ -163,6 +192,11 @@ func (epc *EnhancedPrometheusClient) Query(ctx context.Context, query string) ([
 		return cached, nil
 	}
 	
+	// Fast-fail while the backend is considered down
+	if err := epc.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	
 	var result model.Value
 	var warnings v1.Warnings
 	var err error
@@ -174,18 +208,31 @@ func (epc *EnhancedPrometheusClient) Query(ctx context.Context, query string) ([
 			break
 		}
 		
+		// A malformed query fails the same way every time, don't retry it
+		if queryErr := prometheusQueryError(query, err); queryErr != nil {
+			// The backend answered, so this is not a failure for the breaker
+			epc.breaker.Record(ctx, nil)
+			return nil, queryErr
+		}
+		
+		// The caller gave up, retrying cannot succeed
+		if ctx.Err() != nil {
+			break
+		}
+		
 		if attempt < epc.config.MaxRetries {
 			time.Sleep(epc.config.RetryDelay * time.Duration(attempt+1))
 		}
 	}
 	
+	epc.breaker.Record(ctx, err)
 	if err != nil {
 		return nil, fmt.Errorf("query failed after %d attempts: %w", epc.config.MaxRetries+1, err)
 	}
 	
 	if len(warnings) > 0 {
 		// Log warnings
-		fmt.Printf("Prometheus query warnings: %v\n", warnings)
+		log.Printf("Prometheus query warnings: %v", warnings)
 	}
 	
 	metrics, err := parseQueryResult(result)
@@ -199,6 +246,51 @@ func (epc *EnhancedPrometheusClient) Query(ctx context.Context, query string) ([
 	return metrics, nil
 }
 
+// QueryRange executes a range query with retry logic. Range results are not cached.
+func (epc *EnhancedPrometheusClient) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]MetricSeries, error) {
+	if err := epc.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	
+	r := v1.Range{Start: start, End: end, Step: step}
+	
+	var result model.Value
+	var warnings v1.Warnings
+	var err error
+	
+	for attempt := 0; attempt <= epc.config.MaxRetries; attempt++ {
+		result, warnings, err = epc.client.QueryRange(ctx, query, r)
+		if err == nil {
+			break
+		}
+		
+		if queryErr := prometheusQueryError(query, err); queryErr != nil {
+			epc.breaker.Record(ctx, nil)
+			return nil, queryErr
+		}
+		
+		// The caller gave up, retrying cannot succeed
+		if ctx.Err() != nil {
+			break
+		}
+		
+		if attempt < epc.config.MaxRetries {
+			time.Sleep(epc.config.RetryDelay * time.Duration(attempt+1))
+		}
+	}
+	
+	epc.breaker.Record(ctx, err)
+	if err != nil {
+		return nil, fmt.Errorf("range query failed after %d attempts: %w", epc.config.MaxRetries+1, err)
+	}
+	
+	if len(warnings) > 0 {
+		log.Printf("Prometheus range query warnings: %v", warnings)
+	}
+	
+	return parseRangeResult(result)
+}
+
 // StreamMetrics starts streaming metrics to the buffer
 func (epc *EnhancedPrometheusClient) StreamMetrics(ctx context.Context, queries []string, interval time.Duration) error {
 	ticker := time.NewTicker(interval)
@@ -213,7 +305,7 @@ func (epc *EnhancedPrometheusClient) StreamMetrics(ctx context.Context, queries
 				go func(q string) {
 					metrics, err := epc.Query(ctx, q)
 					if err != nil {
-						fmt.Printf("Error querying metrics: %v\n", err)
+						log.Printf("Error querying metrics: %v", err)
 						return
 					}
 					
@@ -232,8 +324,31 @@ func (epc *EnhancedPrometheusClient) GetBufferedMetrics() []MetricResult {
 	return epc.buffer.Flush()
 }
 
-// BatchQuery executes multiple queries in parallel
+// CachedQuery returns the last cached result of a query and when it was
+// cached, without querying Prometheus. Expired results are returned too.
+func (epc *EnhancedPrometheusClient) CachedQuery(query string) ([]MetricResult, time.Time, bool) {
+	return epc.queryCache.latest(query)
+}
+
+// SetBufferFlushHandler sets the handler receiving metrics flushed from the buffer
+func (epc *EnhancedPrometheusClient) SetBufferFlushHandler(handler BufferFlushHandler) {
+	epc.buffer.SetFlushHandler(handler)
+}
+
+// Close stops the metrics buffer, handing buffered metrics to its flush handler
+func (epc *EnhancedPrometheusClient) Close() {
+	epc.buffer.Close()
+}
+
+// BatchQuery executes multiple queries in parallel, at most BatchConcurrency
+// at a time so that a large batch does not overwhelm Prometheus
 func (epc *EnhancedPrometheusClient) BatchQuery(ctx context.Context, queries []string) (map[string][]MetricResult, error) {
+	limit := epc.config.BatchConcurrency
+	if limit <= 0 {
+		limit = DefaultBatchConcurrency
+	}
+	slots := make(chan struct{}, limit)
+	
 	results := make(map[string][]MetricResult)
 	resultsChan := make(chan struct {
 		query   string
@@ -248,7 +363,15 @@ func (epc *EnhancedPrometheusClient) BatchQuery(ctx context.Context, queries []s
 		go func(q string) {
 			defer wg.Done()
 			
-			metrics, err := epc.Query(ctx, q)
+			var metrics []MetricResult
+			var err error
+			select {
+			case slots <- struct{}{}:
+				metrics, err = epc.Query(ctx, q)
+				<-slots
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
 			resultsChan <- struct {
 				query   string
 				metrics []MetricResult
@@ -287,8 +410,15 @@ type MetricsBuffer struct {
 	timeout   time.Duration
 	mu        sync.Mutex
 	flushChan chan struct{}
+	// onFlush receives automatically flushed metrics; without it they are discarded
+	onFlush   BufferFlushHandler
+	stopCh    chan struct{}
+	stopOnce  sync.Once
 }
 
+// BufferFlushHandler receives metrics flushed from a MetricsBuffer
+type BufferFlushHandler func(metrics []MetricResult)
+
 // NewMetricsBuffer creates a new metrics buffer
 func NewMetricsBuffer(capacity int, timeout time.Duration) *MetricsBuffer {
 	mb := &MetricsBuffer{
@@ -296,6 +426,7 @@ func NewMetricsBuffer(capacity int, timeout time.Duration) *MetricsBuffer {
 		capacity:  capacity,
 		timeout:   timeout,
 		flushChan: make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
 	}
 	
 	// Start auto-flush goroutine
@@ -332,17 +463,47 @@ func (mb *MetricsBuffer) Flush() []MetricResult {
 	return metrics
 }
 
-// autoFlush periodically flushes the buffer
+// SetFlushHandler sets the handler receiving automatically flushed metrics
+func (mb *MetricsBuffer) SetFlushHandler(handler BufferFlushHandler) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	mb.onFlush = handler
+}
+
+// Close stops the auto-flush and hands the remaining metrics to the flush handler
+func (mb *MetricsBuffer) Close() {
+	mb.stopOnce.Do(func() {
+		close(mb.stopCh)
+		mb.deliver()
+	})
+}
+
+// deliver flushes the buffer to the flush handler
+func (mb *MetricsBuffer) deliver() {
+	metrics := mb.Flush()
+	
+	mb.mu.Lock()
+	handler := mb.onFlush
+	mb.mu.Unlock()
+	
+	if handler != nil && len(metrics) > 0 {
+		handler(metrics)
+	}
+}
+
+// autoFlush periodically flushes the buffer until it is closed
 func (mb *MetricsBuffer) autoFlush() {
 	ticker := time.NewTicker(mb.timeout)
 	defer ticker.Stop()
 	
 	for {
 		select {
+		case <-mb.stopCh:
+			return
 		case <-ticker.C:
-			mb.Flush()
+			mb.deliver()
 		case <-mb.flushChan:
-			mb.Flush()
+			mb.deliver()
 		}
 	}
 }
@@ -382,6 +543,19 @@ func (qc *queryCache) get(query string) ([]MetricResult, bool) {
 	return entry.metrics, true
 }
 
+// latest returns the cached result of a query and when it was cached, even
+// if it has expired for querying
+func (qc *queryCache) latest(query string) ([]MetricResult, time.Time, bool) {
+	qc.mu.RLock()
+	defer qc.mu.RUnlock()
+	
+	entry, exists := qc.cache[query]
+	if !exists {
+		return nil, time.Time{}, false
+	}
+	return entry.metrics, entry.timestamp, true
+}
+
 func (qc *queryCache) set(query string, metrics []MetricResult) {
 	qc.mu.Lock()
 	defer qc.mu.Unlock()
@@ -390,4 +564,8 @@ func (qc *queryCache) set(query string, metrics []MetricResult) {
 		metrics:   metrics,
 		timestamp: time.Now(),
 	}
-} 
\ No newline at end of file
+} 
+// BreakerStats returns the circuit breaker state for the Prometheus backend
+func (epc *EnhancedPrometheusClient) BreakerStats() BreakerStats {
+	return epc.breaker.Stats()
+}
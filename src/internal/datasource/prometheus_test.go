@@ -0,0 +1,174 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPrometheusSource_RotatingToken(t *testing.T) {
+	var mu sync.Mutex
+	validToken := "token-1"
+	unauthorized := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// The form body must arrive intact on retried requests as well
+		if err := r.ParseForm(); err != nil || r.Form.Get("query") != "up" {
+			t.Errorf("unexpected request form: %v, %v", r.Form, err)
+		}
+		if r.Header.Get("Authorization") != "Bearer "+validToken {
+			unauthorized++
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+			`{"metric":{"job":"api"},"value":[1700000000,"1"]}]}}`))
+	}))
+	defer server.Close()
+
+	issued := 0
+	config := &Config{Type: TypePrometheus, Name: "prom", PrometheusURL: server.URL, PromQL: "up"}
+	config.PrometheusAuth.TokenProvider = func() (string, error) {
+		issued++
+		return fmt.Sprintf("token-%d", issued), nil
+	}
+
+	source, err := NewPrometheusSource(config)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	if _, err := source.Collect(context.Background()); err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+	if issued != 1 {
+		t.Errorf("expected the token to be fetched once, got %d", issued)
+	}
+
+	// The token expires: the next request gets 401 and is retried with a fresh token
+	mu.Lock()
+	validToken = "token-2"
+	mu.Unlock()
+
+	points, err := source.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect after rotation failed: %v", err)
+	}
+	if len(points) != 1 || points[0].Labels["job"] != "api" {
+		t.Errorf("unexpected points: %+v", points)
+	}
+	if issued != 2 || unauthorized != 1 {
+		t.Errorf("expected one refresh after one 401, got %d tokens and %d rejections", issued, unauthorized)
+	}
+}
+
+func TestPrometheusSource_TokenProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request expected without a token")
+	}))
+	defer server.Close()
+
+	config := &Config{Type: TypePrometheus, Name: "prom", PrometheusURL: server.URL, PromQL: "up"}
+	config.PrometheusAuth.TokenProvider = func() (string, error) {
+		return "", fmt.Errorf("identity provider unavailable")
+	}
+
+	source, err := NewPrometheusSource(config)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	if _, err := source.Collect(context.Background()); err == nil {
+		t.Error("expected an error when no token can be obtained")
+	}
+}
+
+func TestMetricsBuffer_CloseFlushesToHandler(t *testing.T) {
+	mb := NewMetricsBuffer(10, time.Hour)
+
+	var mu sync.Mutex
+	var flushed []MetricResult
+	mb.SetFlushHandler(func(metrics []MetricResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		flushed = append(flushed, metrics...)
+	})
+
+	mb.Add(MetricResult{Name: "cpu", Value: 1})
+	mb.Add(MetricResult{Name: "cpu", Value: 2})
+	mb.Close()
+
+	mu.Lock()
+	n := len(flushed)
+	mu.Unlock()
+	if n != 2 {
+		t.Fatalf("expected 2 metrics flushed on close, got %d", n)
+	}
+
+	// Closing again does not flush twice
+	mb.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(flushed) != 2 {
+		t.Errorf("expected a second close to be a no-op, got %d metrics", len(flushed))
+	}
+}
+
+func TestEnhancedPrometheusClient_BatchQueryConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+			`{"metric":{"job":"api"},"value":[1700000000,"1"]}]}}`))
+	}))
+	defer server.Close()
+
+	config := DefaultEnhancedConfig()
+	config.BatchConcurrency = 2
+	client, err := NewEnhancedPrometheusClient(server.URL, config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	queries := make([]string, 8)
+	for i := range queries {
+		queries[i] = fmt.Sprintf("up{instance=\"%d\"}", i)
+	}
+	results, err := client.BatchQuery(context.Background(), queries)
+	if err != nil {
+		t.Fatalf("batch query failed: %v", err)
+	}
+	if len(results) != len(queries) {
+		t.Errorf("expected %d results, got %d", len(queries), len(results))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 queries in flight, got %d", maxInFlight)
+	}
+}
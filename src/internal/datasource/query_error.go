@@ -0,0 +1,52 @@
+package datasource
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+// QueryError indicates the backend rejected the query itself (syntax or
+// semantic error), as opposed to the backend being unavailable
+type QueryError struct {
+	Source  string
+	Query   string
+	Message string
+}
+
+// Error implements the error interface
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid %s query: %s", e.Source, e.Message)
+}
+
+// AsQueryError returns the QueryError wrapped in err, if any
+func AsQueryError(err error) (*QueryError, bool) {
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		return queryErr, true
+	}
+	return nil, false
+}
+
+// prometheusQueryError converts a Prometheus bad_data response into a QueryError,
+// returning nil for any other error
+func prometheusQueryError(query string, err error) *QueryError {
+	var apiErr *v1.Error
+	if errors.As(err, &apiErr) && apiErr.Type == v1.ErrBadData {
+		return &QueryError{Source: "prometheus", Query: query, Message: apiErr.Msg}
+	}
+	return nil
+}
+
+// lokiQueryError converts a Loki 4xx parse error response into a QueryError,
+// returning nil for any other response
+func lokiQueryError(query string, statusCode int, body []byte) *QueryError {
+	message := strings.TrimSpace(string(body))
+	if statusCode == http.StatusBadRequest || strings.Contains(message, "parse error") {
+		return &QueryError{Source: "loki", Query: query, Message: message}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package datasource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEnhancedPrometheusClient_QueryError(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error at char 5: unexpected \"{\""}`))
+	}))
+	defer server.Close()
+
+	config := DefaultEnhancedConfig()
+	config.RetryDelay = time.Millisecond
+	config.BreakerThreshold = 1
+	client, err := NewEnhancedPrometheusClient(server.URL, config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	_, err = client.Query(context.Background(), "rate({")
+	queryErr, ok := AsQueryError(err)
+	if !ok {
+		t.Fatalf("expected a QueryError, got %v", err)
+	}
+	if queryErr.Source != "prometheus" || queryErr.Query != "rate({" || queryErr.Message == "" {
+		t.Errorf("unexpected query error: %+v", queryErr)
+	}
+
+	// A rejected query is neither retried nor counted against the backend
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected a single request, got %d", got)
+	}
+	if state := client.BreakerStats().State; state != BreakerClosed {
+		t.Errorf("expected the breaker to stay closed, got %s", state)
+	}
+
+	_, err = client.QueryRange(context.Background(), "rate({", time.Now().Add(-time.Hour), time.Now(), time.Minute)
+	if _, ok := AsQueryError(err); !ok {
+		t.Errorf("expected a QueryError from the range query, got %v", err)
+	}
+}
+
+func TestEnhancedLokiClient_QueryError(t *testing.T) {
+	var unavailable atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("too many outstanding requests\n"))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("parse error : syntax error: unexpected IDENTIFIER\n"))
+	}))
+	defer server.Close()
+
+	config := DefaultLogAnalysisConfig()
+	config.BreakerThreshold = 1
+	client, err := NewEnhancedLokiClient(server.URL, config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	end := time.Now()
+	_, err = client.Query(context.Background(), `{app="api"} |= foo`, end.Add(-time.Hour), end)
+	queryErr, ok := AsQueryError(err)
+	if !ok {
+		t.Fatalf("expected a QueryError, got %v", err)
+	}
+	if queryErr.Source != "loki" || queryErr.Message != "parse error : syntax error: unexpected IDENTIFIER" {
+		t.Errorf("unexpected query error: %+v", queryErr)
+	}
+	if state := client.BreakerStats().State; state != BreakerClosed {
+		t.Errorf("expected the breaker to stay closed, got %s", state)
+	}
+
+	// An unavailable backend is not a query error
+	unavailable.Store(true)
+	_, err = client.Query(context.Background(), `{app="web"}`, end.Add(-time.Hour), end)
+	if err == nil {
+		t.Fatal("expected the query to fail")
+	}
+	if _, ok := AsQueryError(err); ok {
+		t.Errorf("expected a backend error, got query error %v", err)
+	}
+	if state := client.BreakerStats().State; state != BreakerOpen {
+		t.Errorf("expected the backend failure to open the breaker, got %s", state)
+	}
+}
@@ -0,0 +1,66 @@
+package datasource
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// defaultClientTimeout bounds requests of clients that do not use the shared client
+const defaultClientTimeout = 30 * time.Second
+
+// sharedClient is the client set with SetHTTPClient, nil when every client
+// builds its own
+var sharedClient atomic.Pointer[http.Client]
+
+// SetHTTPClient makes the Prometheus and Loki clients created afterwards send
+// their requests through client, so tuning its transport (e.g. the API
+// connection pool) tunes backend connections. Clients with TLS settings keep
+// their own transport, since it carries their certificates. nil restores
+// per-client transports.
+func SetHTTPClient(client *http.Client) {
+	sharedClient.Store(client)
+}
+
+// newHTTPClient returns a client sending through the shared client's
+// transport, or a new client with the TLS settings when there is none or TLS
+// is configured. Requests are traced and carry the trace context.
+func newHTTPClient(c *TLSConfig) (*http.Client, error) {
+	if client := sharedClient.Load(); client != nil && !c.Enabled() {
+		traced := *client
+		traced.Transport = otelhttp.NewTransport(transportOf(client))
+		return &traced, nil
+	}
+
+	transport, err := newTransport(c)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Timeout: defaultClientTimeout, Transport: otelhttp.NewTransport(transport)}, nil
+}
+
+// newRoundTripper returns the shared client's transport, or a new transport
+// with the TLS settings when there is none or TLS is configured, wrapped to
+// trace requests. It is used by the Prometheus API client, which only takes a
+// RoundTripper.
+func newRoundTripper(c *TLSConfig) (http.RoundTripper, error) {
+	if client := sharedClient.Load(); client != nil && !c.Enabled() {
+		return otelhttp.NewTransport(transportOf(client)), nil
+	}
+
+	transport, err := newTransport(c)
+	if err != nil {
+		return nil, err
+	}
+	return otelhttp.NewTransport(transport), nil
+}
+
+// transportOf returns the client's transport, http.DefaultTransport when unset
+func transportOf(client *http.Client) http.RoundTripper {
+	if client.Transport != nil {
+		return client.Transport
+	}
+	return http.DefaultTransport
+}
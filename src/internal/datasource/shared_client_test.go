@@ -0,0 +1,121 @@
+package datasource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// countingTransport counts the requests it sends
+type countingTransport struct {
+	requests atomic.Int64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestSetHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(r.URL.Path, "/loki/") {
+			w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`))
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	SetHTTPClient(&http.Client{Transport: transport})
+	defer SetHTTPClient(nil)
+
+	ctx := context.Background()
+	promClient, err := NewEnhancedPrometheusClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create Prometheus client: %v", err)
+	}
+	if _, err := promClient.Query(ctx, "up"); err != nil {
+		t.Fatalf("Prometheus query failed: %v", err)
+	}
+	collector, err := NewLokiCollector(server.URL, time.Minute, time.Minute, nil)
+	if err != nil {
+		t.Fatalf("failed to create Loki collector: %v", err)
+	}
+	if _, err := collector.queryLoki(ctx, `{job="api"}`, time.Now().Add(-time.Minute), time.Now()); err != nil {
+		t.Fatalf("Loki query failed: %v", err)
+	}
+	if n := transport.requests.Load(); n != 2 {
+		t.Errorf("expected both clients to use the shared transport, got %d requests", n)
+	}
+
+	// A TLS client keeps its own transport
+	lokiClient, err := NewEnhancedLokiClient(server.URL, &LogAnalysisConfig{TLS: &TLSConfig{InsecureSkipVerify: true}})
+	if err != nil {
+		t.Fatalf("failed to create Loki client: %v", err)
+	}
+	resp, err := lokiClient.client.Get(server.URL + "/loki/api/v1/labels")
+	if err != nil {
+		t.Fatalf("Loki request failed: %v", err)
+	}
+	resp.Body.Close()
+	if n := transport.requests.Load(); n != 2 {
+		t.Errorf("expected a TLS client not to share the transport, got %d shared requests", n)
+	}
+}
+
+func TestClients_PropagateTraceContext(t *testing.T) {
+	traceparents := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(r.URL.Path, "/loki/") {
+			w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`))
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer server.Close()
+
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.Background())
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "check")
+	defer span.End()
+	traceID := span.SpanContext().TraceID().String()
+
+	promClient, err := NewEnhancedPrometheusClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create Prometheus client: %v", err)
+	}
+	if _, err := promClient.Query(ctx, "up"); err != nil {
+		t.Fatalf("Prometheus query failed: %v", err)
+	}
+	collector, err := NewLokiCollector(server.URL, time.Minute, time.Minute, nil)
+	if err != nil {
+		t.Fatalf("failed to create Loki collector: %v", err)
+	}
+	if _, err := collector.queryLoki(ctx, `{job="api"}`, time.Now().Add(-time.Minute), time.Now()); err != nil {
+		t.Fatalf("Loki query failed: %v", err)
+	}
+
+	for _, client := range []string{"Prometheus", "Loki"} {
+		if traceparent := <-traceparents; !strings.Contains(traceparent, traceID) {
+			t.Errorf("expected the %s request to continue trace %s, got traceparent %q", client, traceID, traceparent)
+		}
+	}
+}
@@ -48,6 +48,8 @@ type Config struct {
 		Username string
 		Password string
 		Token    string
+		// TokenProvider supplies a token when Token is empty or rejected with 401
+		TokenProvider TokenProvider
 	}
 	PromQL string
 
@@ -0,0 +1,225 @@
+package datasource
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// largeRangeResponse builds a Prometheus matrix response of series series with points samples each
+func largeRangeResponse(series, points int) []byte {
+	var b strings.Builder
+	b.WriteString(`{"status":"success","data":{"resultType":"matrix","result":[`)
+	for s := 0; s < series; s++ {
+		if s > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"metric":{"__name__":"http_requests_total","instance":"10.0.0.%d:9100","job":"api"},"values":[`, s)
+		for p := 0; p < points; p++ {
+			if p > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, `[%d,"%d.%03d"]`, 1700000000+p*60, 1000+p%97, (s*p)%1000)
+		}
+		b.WriteString("]}")
+	}
+	b.WriteString("]}}")
+	return []byte(b.String())
+}
+
+func TestNewTransport_GzipResponses(t *testing.T) {
+	body := largeRangeResponse(20, 1440) // one day at 1m resolution
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(body)
+	gz.Close()
+
+	var wireBytes int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		payload := body
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			payload = compressed.Bytes()
+		}
+		atomic.AddInt64(&wireBytes, int64(len(payload)))
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	client, err := NewEnhancedPrometheusClient(server.URL, DefaultEnhancedConfig())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	end := time.Unix(1700000000, 0).Add(24 * time.Hour)
+	series, err := client.QueryRange(context.Background(), "http_requests_total", end.Add(-24*time.Hour), end, time.Minute)
+	if err != nil {
+		t.Fatalf("range query failed: %v", err)
+	}
+	if len(series) != 20 || len(series[0].Points) != 1440 {
+		t.Fatalf("expected the decompressed response to decode, got %d series", len(series))
+	}
+
+	transferred := atomic.LoadInt64(&wireBytes)
+	if transferred != int64(compressed.Len()) {
+		t.Fatalf("expected the gzip response to be requested, transferred %d of %d bytes", transferred, len(body))
+	}
+	t.Logf("range query: %d bytes uncompressed, %d bytes transferred (%.1fx smaller)",
+		len(body), transferred, float64(len(body))/float64(transferred))
+}
+
+func TestLokiClients_RequestGzip(t *testing.T) {
+	var acceptEncoding atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding.Store(r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewEnhancedLokiClient(server.URL, DefaultLogAnalysisConfig())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	end := time.Now()
+	client.Query(context.Background(), `{job="api"}`, end.Add(-time.Hour), end)
+
+	if got, _ := acceptEncoding.Load().(string); !strings.Contains(got, "gzip") {
+		t.Errorf("expected the Loki client to request gzip, got Accept-Encoding %q", got)
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key
+// to dir and returns the certificate together with the file paths
+func writeClientCertificate(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "aiops-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return cert, certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestEnhancedLokiClient_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, certFile, keyFile := writeClientCertificate(t, dir)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`))
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(dir, "ca.crt")
+	writePEM(t, caFile, "CERTIFICATE", server.Certificate().Raw)
+
+	query := func(tlsConfig *TLSConfig) error {
+		config := DefaultLogAnalysisConfig()
+		config.TLS = tlsConfig
+		client, err := NewEnhancedLokiClient(server.URL, config)
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		end := time.Now()
+		_, err = client.Query(context.Background(), `{app="api"}`, end.Add(-time.Hour), end)
+		return err
+	}
+
+	if err := query(&TLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: caFile}); err != nil {
+		t.Fatalf("expected the mTLS query to succeed, got %v", err)
+	}
+	if err := query(&TLSConfig{CAFile: caFile}); err == nil {
+		t.Error("expected the server to reject a client without a certificate")
+	}
+	if err := query(nil); err == nil {
+		t.Error("expected the server certificate to be untrusted without the CA file")
+	}
+}
+
+func TestTLSConfig_Build(t *testing.T) {
+	dir := t.TempDir()
+	_, certFile, keyFile := writeClientCertificate(t, dir)
+	invalidCA := filepath.Join(dir, "invalid.crt")
+	if err := os.WriteFile(invalidCA, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+
+	tlsConfig, err := (&TLSConfig{CertFile: certFile, KeyFile: keyFile, ServerName: "prometheus.internal"}).Build()
+	if err != nil {
+		t.Fatalf("failed to build TLS config: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 || tlsConfig.ServerName != "prometheus.internal" || tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("unexpected TLS config: %+v", tlsConfig)
+	}
+
+	for name, c := range map[string]*TLSConfig{
+		"cert without key":  {CertFile: certFile},
+		"missing cert file": {CertFile: filepath.Join(dir, "missing.crt"), KeyFile: keyFile},
+		"missing CA file":   {CAFile: filepath.Join(dir, "missing-ca.crt")},
+		"CA without certs":  {CAFile: invalidCA},
+	} {
+		if _, err := c.Build(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if (*TLSConfig)(nil).Enabled() || (&TLSConfig{}).Enabled() || !(&TLSConfig{ServerName: "loki"}).Enabled() {
+		t.Error("expected only configs with a setting to be enabled")
+	}
+}
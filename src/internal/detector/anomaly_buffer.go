@@ -0,0 +1,149 @@
+package detector
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAnomalyRetention - время хранения аномалий в буфере по умолчанию
+	DefaultAnomalyRetention = 24 * time.Hour
+	// DefaultAnomalyBufferSize - жесткий лимит количества аномалий в буфере по умолчанию
+	DefaultAnomalyBufferSize = 1000
+)
+
+// AnomalyBuffer хранит недавние аномалии с ограничением по времени и количеству.
+// Аномалии старше retention удаляются, а при превышении maxSize вытесняются самые старые.
+type AnomalyBuffer struct {
+	entries   []Anomaly // от старых к новым
+	retention time.Duration
+	maxSize   int
+	mu        sync.RWMutex
+}
+
+// NewAnomalyBuffer создает буфер. Неположительные значения заменяются значениями по умолчанию.
+func NewAnomalyBuffer(retention time.Duration, maxSize int) *AnomalyBuffer {
+	b := &AnomalyBuffer{}
+	b.SetRetention(retention, maxSize)
+	return b
+}
+
+// SetRetention изменяет время хранения и лимит количества, сразу применяя их к буферу
+func (b *AnomalyBuffer) SetRetention(retention time.Duration, maxSize int) {
+	if retention <= 0 {
+		retention = DefaultAnomalyRetention
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultAnomalyBufferSize
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.retention = retention
+	b.maxSize = maxSize
+	b.pruneLocked(time.Now())
+}
+
+// Retention возвращает текущее время хранения и лимит количества
+func (b *AnomalyBuffer) Retention() (time.Duration, int) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.retention, b.maxSize
+}
+
+// Add добавляет аномалии в буфер
+func (b *AnomalyBuffer) Add(anomalies ...Anomaly) {
+	if len(anomalies) == 0 {
+		return
+	}
+
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, anomaly := range anomalies {
+		if anomaly.Timestamp.IsZero() {
+			anomaly.Timestamp = now
+		}
+		b.entries = append(b.entries, anomaly)
+	}
+	b.pruneLocked(now)
+}
+
+// Recent возвращает аномалии, не вышедшие за время хранения, от новых к старым.
+// Неположительный limit возвращает все аномалии.
+func (b *AnomalyBuffer) Recent(limit int) []Anomaly {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	cutoff := time.Now().Add(-b.retention)
+	result := make([]Anomaly, 0)
+	for i := len(b.entries) - 1; i >= 0; i-- {
+		if b.entries[i].Timestamp.Before(cutoff) {
+			continue
+		}
+		result = append(result, b.entries[i])
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// Len возвращает количество аномалий в буфере
+func (b *AnomalyBuffer) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.entries)
+}
+
+// Prune удаляет устаревшие аномалии и возвращает количество удаленных
+func (b *AnomalyBuffer) Prune() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pruneLocked(time.Now())
+}
+
+// StartPruning периодически удаляет устаревшие аномалии до отмены контекста
+func (b *AnomalyBuffer) StartPruning(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.Prune()
+			}
+		}
+	}()
+}
+
+// pruneLocked удаляет аномалии старше retention и сверх maxSize. Вызывающий держит b.mu.
+func (b *AnomalyBuffer) pruneLocked(now time.Time) int {
+	cutoff := now.Add(-b.retention)
+	kept := b.entries[:0]
+	for _, anomaly := range b.entries {
+		if !anomaly.Timestamp.Before(cutoff) {
+			kept = append(kept, anomaly)
+		}
+	}
+
+	if overflow := len(kept) - b.maxSize; overflow > 0 {
+		kept = kept[overflow:]
+	}
+
+	removed := len(b.entries) - len(kept)
+	if removed > 0 {
+		// Копируем, чтобы не удерживать вытесненные элементы в базовом массиве
+		b.entries = append([]Anomaly(nil), kept...)
+	}
+	return removed
+}
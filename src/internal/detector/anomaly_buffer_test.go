@@ -0,0 +1,60 @@
+package detector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnomalyBuffer_Retention(t *testing.T) {
+	buffer := NewAnomalyBuffer(time.Hour, 10)
+	now := time.Now()
+
+	buffer.Add(
+		Anomaly{Timestamp: now.Add(-2 * time.Hour), Type: "old"},
+		Anomaly{Timestamp: now.Add(-30 * time.Minute), Type: "recent"},
+		Anomaly{Type: "now"},
+	)
+
+	recent := buffer.Recent(0)
+	if len(recent) != 2 {
+		t.Fatalf("expected 2 anomalies within retention, got %d", len(recent))
+	}
+	if recent[0].Type != "now" || recent[1].Type != "recent" {
+		t.Errorf("expected newest first, got %s, %s", recent[0].Type, recent[1].Type)
+	}
+	if recent[0].Timestamp.IsZero() {
+		t.Error("expected a zero timestamp to be set on add")
+	}
+	if buffer.Len() != 2 {
+		t.Errorf("expected expired anomaly to be pruned on add, len %d", buffer.Len())
+	}
+
+	// Shrinking retention prunes immediately
+	buffer.SetRetention(10*time.Minute, 10)
+	if buffer.Len() != 1 {
+		t.Errorf("expected 1 anomaly after shrinking retention, got %d", buffer.Len())
+	}
+}
+
+func TestAnomalyBuffer_MaxSize(t *testing.T) {
+	buffer := NewAnomalyBuffer(time.Hour, 3)
+	for i := 0; i < 5; i++ {
+		buffer.Add(Anomaly{Value: float64(i)})
+	}
+
+	recent := buffer.Recent(0)
+	if len(recent) != 3 {
+		t.Fatalf("expected hard cap of 3, got %d", len(recent))
+	}
+	if recent[0].Value != 4 || recent[2].Value != 2 {
+		t.Errorf("expected the oldest anomalies to be evicted, got %v", recent)
+	}
+	if limited := buffer.Recent(1); len(limited) != 1 || limited[0].Value != 4 {
+		t.Errorf("expected limit to return the newest anomaly, got %v", limited)
+	}
+
+	retention, maxSize := NewAnomalyBuffer(0, 0).Retention()
+	if retention != DefaultAnomalyRetention || maxSize != DefaultAnomalyBufferSize {
+		t.Errorf("expected defaults, got %v and %d", retention, maxSize)
+	}
+}
@@ -0,0 +1,101 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// tuneWindow is the number of evaluated detections between threshold adjustments
+	tuneWindow = 100
+	// tuneStep is the relative threshold change per adjustment
+	tuneStep = 0.1
+	// tuneTolerance is the relative deviation from the target rate that is left alone
+	tuneTolerance = 0.5
+
+	defaultMinTunedThreshold = 1.0
+	defaultMaxTunedThreshold = 10.0
+)
+
+// thresholdTuner adjusts a detector threshold to keep the observed anomaly
+// rate near a target rate. It is not safe for concurrent use; detectors call
+// it under their own lock.
+type thresholdTuner struct {
+	targetRate   float64
+	minThreshold float64
+	maxThreshold float64
+
+	observed    int
+	anomalous   int
+	lastRate    float64
+	adjustments int
+}
+
+// newThresholdTuner reads targetAnomalyRate, minThreshold and maxThreshold from
+// parameters. It returns nil when auto-tuning is not requested or targetAnomalyRate is 0.
+func newThresholdTuner(params map[string]interface{}) (*thresholdTuner, error) {
+	target, ok := params["targetAnomalyRate"].(float64)
+	if !ok || target == 0 {
+		return nil, nil
+	}
+	if target <= 0 || target >= 1 {
+		return nil, fmt.Errorf("targetAnomalyRate must be between 0 and 1 (exclusive)")
+	}
+
+	tuner := &thresholdTuner{
+		targetRate:   target,
+		minThreshold: defaultMinTunedThreshold,
+		maxThreshold: defaultMaxTunedThreshold,
+	}
+	if v, ok := params["minThreshold"].(float64); ok {
+		tuner.minThreshold = v
+	}
+	if v, ok := params["maxThreshold"].(float64); ok {
+		tuner.maxThreshold = v
+	}
+	if tuner.minThreshold <= 0 || tuner.minThreshold >= tuner.maxThreshold {
+		return nil, fmt.Errorf("minThreshold must be positive and below maxThreshold")
+	}
+
+	return tuner, nil
+}
+
+// observe records one evaluated detection and returns the threshold to use next
+func (t *thresholdTuner) observe(threshold float64, anomalous bool) float64 {
+	t.observed++
+	if anomalous {
+		t.anomalous++
+	}
+
+	if t.observed < tuneWindow {
+		return threshold
+	}
+
+	rate := float64(t.anomalous) / float64(t.observed)
+	t.lastRate = rate
+	t.observed = 0
+	t.anomalous = 0
+
+	switch {
+	case rate > t.targetRate*(1+tuneTolerance):
+		// Over-alerting: require a larger deviation
+		threshold *= 1 + tuneStep
+		t.adjustments++
+	case rate < t.targetRate*(1-tuneTolerance):
+		threshold /= 1 + tuneStep
+		t.adjustments++
+	}
+
+	return math.Max(t.minThreshold, math.Min(t.maxThreshold, threshold))
+}
+
+// stats returns the tuner state for GetStatistics
+func (t *thresholdTuner) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"targetAnomalyRate":   t.targetRate,
+		"observedAnomalyRate": t.lastRate,
+		"minThreshold":        t.minThreshold,
+		"maxThreshold":        t.maxThreshold,
+		"adjustments":         t.adjustments,
+	}
+}
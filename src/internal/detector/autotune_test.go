@@ -0,0 +1,68 @@
+package detector
+
+import "testing"
+
+func TestThresholdTuner(t *testing.T) {
+	tuner, err := newThresholdTuner(map[string]interface{}{
+		"targetAnomalyRate": 0.05,
+		"minThreshold":      2.0,
+		"maxThreshold":      4.0,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 40% anomalies: threshold should rise
+	threshold := 3.0
+	for i := 0; i < tuneWindow; i++ {
+		threshold = tuner.observe(threshold, i%5 < 2)
+	}
+	if threshold <= 3.0 {
+		t.Errorf("expected threshold to increase when over-alerting, got %v", threshold)
+	}
+
+	// Sustained over-alerting must respect the upper bound
+	for i := 0; i < tuneWindow*20; i++ {
+		threshold = tuner.observe(threshold, true)
+	}
+	if threshold != 4.0 {
+		t.Errorf("expected threshold clamped to 4.0, got %v", threshold)
+	}
+
+	// No anomalies: threshold should fall back to the lower bound
+	for i := 0; i < tuneWindow*20; i++ {
+		threshold = tuner.observe(threshold, false)
+	}
+	if threshold != 2.0 {
+		t.Errorf("expected threshold clamped to 2.0, got %v", threshold)
+	}
+}
+
+func TestNewThresholdTuner_Invalid(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"targetAnomalyRate": 1.5},
+		{"targetAnomalyRate": 0.1, "minThreshold": 5.0, "maxThreshold": 2.0},
+	}
+
+	for _, params := range tests {
+		if _, err := newThresholdTuner(params); err == nil {
+			t.Errorf("expected error for %v", params)
+		}
+	}
+
+	tuner, err := newThresholdTuner(map[string]interface{}{"targetAnomalyRate": 0.0})
+	if err != nil || tuner != nil {
+		t.Errorf("expected auto-tuning disabled for zero target, got %v, %v", tuner, err)
+	}
+}
+
+func TestStatisticalDetector_AutoTuneStatistics(t *testing.T) {
+	d := NewStatisticalDetector(3.0, 10.0, 1.0, "test")
+	if err := d.Configure(DetectorConfig{Parameters: map[string]interface{}{"targetAnomalyRate": 0.01}}); err != nil {
+		t.Fatalf("unexpected configure error: %v", err)
+	}
+
+	if _, ok := d.GetStatistics()["autoTune"]; !ok {
+		t.Error("expected autoTune section in statistics")
+	}
+}
@@ -0,0 +1,86 @@
+package detector
+
+import "fmt"
+
+// anomalyConfirmer suppresses transient anomalies: a point exceeding the
+// threshold is only reported when at least count of the last window evaluated
+// points (itself included) exceeded it. It is not safe for concurrent use;
+// detectors call it under their own lock.
+type anomalyConfirmer struct {
+	count  int
+	window int
+
+	// recent is a ring buffer of the last window outcomes
+	recent     []bool
+	next       int
+	exceeded   int
+	suppressed int64
+}
+
+// newAnomalyConfirmer reads confirmCount and confirmWindow from parameters.
+// confirmWindow defaults to confirmCount (consecutive points). It returns nil
+// when confirmation is not requested or confirmCount is 1.
+func newAnomalyConfirmer(params map[string]interface{}) (*anomalyConfirmer, error) {
+	count, ok := params["confirmCount"].(float64)
+	if !ok || count == 1 {
+		return nil, nil
+	}
+	if count < 1 {
+		return nil, fmt.Errorf("confirmCount must be at least 1")
+	}
+
+	window := count
+	if v, ok := params["confirmWindow"].(float64); ok {
+		window = v
+	}
+	if window < count {
+		return nil, fmt.Errorf("confirmWindow must not be below confirmCount")
+	}
+
+	return &anomalyConfirmer{
+		count:  int(count),
+		window: int(window),
+		recent: make([]bool, int(window)),
+	}, nil
+}
+
+// observe records whether an evaluated point exceeded the threshold and reports
+// whether it is a confirmed anomaly
+func (c *anomalyConfirmer) observe(exceeded bool) bool {
+	if c.recent[c.next] {
+		c.exceeded--
+	}
+	c.recent[c.next] = exceeded
+	if exceeded {
+		c.exceeded++
+	}
+	c.next = (c.next + 1) % c.window
+
+	if !exceeded {
+		return false
+	}
+	if c.exceeded < c.count {
+		c.suppressed++
+		return false
+	}
+	return true
+}
+
+// reset forgets the recent outcomes
+func (c *anomalyConfirmer) reset() {
+	for i := range c.recent {
+		c.recent[i] = false
+	}
+	c.next = 0
+	c.exceeded = 0
+}
+
+// stats returns the confirmer state for GetStatistics
+func (c *anomalyConfirmer) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"confirmCount":   c.count,
+		"confirmWindow":  c.window,
+		"recentExceeded": c.exceeded,
+		"suppressed":     c.suppressed,
+	}
+}
@@ -0,0 +1,94 @@
+package detector
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAnomalyConfirmer(t *testing.T) {
+	// 2 of the last 3 points
+	confirmer, err := newAnomalyConfirmer(map[string]interface{}{"confirmCount": 2.0, "confirmWindow": 3.0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	outcomes := []bool{true, false, true, false, false, true, true}
+	expected := []bool{false, false, true, false, false, false, true}
+	for i, exceeded := range outcomes {
+		if got := confirmer.observe(exceeded); got != expected[i] {
+			t.Errorf("point %d: expected %v, got %v", i, expected[i], got)
+		}
+	}
+	if confirmer.suppressed != 2 {
+		t.Errorf("expected 2 suppressed points, got %d", confirmer.suppressed)
+	}
+}
+
+func TestNewAnomalyConfirmer_Invalid(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"confirmCount": 0.0},
+		{"confirmCount": 3.0, "confirmWindow": 2.0},
+	}
+
+	for _, params := range tests {
+		if _, err := newAnomalyConfirmer(params); err == nil {
+			t.Errorf("expected error for %v", params)
+		}
+	}
+
+	confirmer, err := newAnomalyConfirmer(map[string]interface{}{"confirmCount": 1.0})
+	if err != nil || confirmer != nil {
+		t.Errorf("expected confirmation disabled for a count of 1, got %v, %v", confirmer, err)
+	}
+}
+
+func TestStatisticalDetector_Confirm(t *testing.T) {
+	d := NewStatisticalDetector(3.0, 10.0, 1.0, "test")
+	if err := d.Configure(DetectorConfig{Parameters: map[string]interface{}{"confirmCount": 3.0}}); err != nil {
+		t.Fatalf("configure failed: %v", err)
+	}
+
+	// A single spike is not reported, three consecutive ones are
+	values := []float64{20, 10, 20, 20, 20}
+	var reported []int
+	for i, value := range values {
+		anomaly, err := d.Detect(context.Background(), value)
+		if err != nil {
+			t.Fatalf("detect failed: %v", err)
+		}
+		if anomaly != nil {
+			reported = append(reported, i)
+		}
+	}
+	if len(reported) != 1 || reported[0] != 4 {
+		t.Errorf("expected only the third consecutive spike to be reported, got %v", reported)
+	}
+
+	stats := d.GetStatistics()
+	if stats["anomalyCount"] != int64(1) {
+		t.Errorf("expected 1 anomaly counted, got %v", stats["anomalyCount"])
+	}
+	if confirm, ok := stats["confirm"].(map[string]interface{}); !ok || confirm["suppressed"] != int64(3) {
+		t.Errorf("expected 3 suppressed spikes, got %v", stats["confirm"])
+	}
+}
+
+func TestWindowDetector_Confirm(t *testing.T) {
+	d := NewWindowDetector(50, 2.0, "test")
+	if err := d.Configure(DetectorConfig{Parameters: map[string]interface{}{"confirmCount": 2.0, "confirmWindow": 4.0}}); err != nil {
+		t.Fatalf("configure failed: %v", err)
+	}
+	if err := d.Train([]float64{10, 11, 9, 10, 11, 9, 10, 11, 9, 10}); err != nil {
+		t.Fatalf("train failed: %v", err)
+	}
+
+	first, _ := d.Detect(context.Background(), 30)
+	d.Detect(context.Background(), 10)
+	second, _ := d.Detect(context.Background(), 30)
+	if first != nil {
+		t.Error("expected the first spike to wait for confirmation")
+	}
+	if second == nil {
+		t.Error("expected the second spike within the window to be reported")
+	}
+}
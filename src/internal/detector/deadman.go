@@ -0,0 +1,233 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/metrics"
+)
+
+// DeadmanDetector reports missing data: it raises an anomaly from a background
+// timer when no value has arrived through Detect within maxGap. One anomaly is
+// raised per silence; the timer is re-armed by the next value.
+type DeadmanDetector struct {
+	maxGap   time.Duration
+	dataType string
+	lastSeen time.Time
+	firing   bool
+	alerts   int64
+	stopped  bool
+	timer    *time.Timer
+	handler  func(*Anomaly) bool
+	mu       sync.Mutex
+	detectorMetrics
+}
+
+// NewDeadmanDetector creates a deadman detector. The timer starts immediately,
+// so a source that never reports is detected as well.
+func NewDeadmanDetector(maxGap time.Duration, dataType string) *DeadmanDetector {
+	d := &DeadmanDetector{
+		maxGap:   maxGap,
+		dataType: dataType,
+		lastSeen: time.Now(),
+	}
+	d.timer = time.AfterFunc(maxGap, d.fire)
+	return d
+}
+
+// SetAnomalyHandler sets the function receiving missing-data anomalies. The
+// handler reports whether the anomaly was delivered; an undelivered anomaly
+// (e.g. while the detector is stopped) is raised again after another maxGap.
+func (d *DeadmanDetector) SetAnomalyHandler(handler func(*Anomaly) bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handler = handler
+}
+
+// Detect records that a value arrived and re-arms the timer. Arriving values are
+// never anomalous for this detector.
+func (d *DeadmanDetector) Detect(ctx context.Context, value float64) (*Anomaly, error) {
+	start := time.Now()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastSeen = time.Now()
+	d.firing = false
+	if !d.stopped {
+		d.timer.Reset(d.maxGap)
+	}
+
+	d.recordMetrics(TypeDeadman, d.dataType, nil, time.Since(start), nil)
+	return nil, nil
+}
+
+// fire raises the missing-data anomaly when the silence exceeds maxGap
+func (d *DeadmanDetector) fire() {
+	d.mu.Lock()
+	if d.stopped || d.firing {
+		d.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	gap := now.Sub(d.lastSeen)
+	if gap < d.maxGap {
+		// A value arrived while the timer was firing
+		d.timer.Reset(d.maxGap - gap)
+		d.mu.Unlock()
+		return
+	}
+
+	lastSeen := d.lastSeen
+	anomaly := &Anomaly{
+		Timestamp: now,
+		Type:      d.dataType,
+		Severity:  "critical",
+		Value:     gap.Seconds(),
+		Threshold: d.maxGap.Seconds(),
+		Source:    "deadman",
+		Details: map[string]interface{}{
+			"lastSeen": d.lastSeen,
+			"gap":      gap.String(),
+			"maxGap":   d.maxGap.String(),
+		},
+	}
+	handler := d.handler
+	d.mu.Unlock()
+
+	delivered := handler == nil || handler(anomaly)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped || !d.lastSeen.Equal(lastSeen) {
+		// Stopped, or a value arrived and re-armed the timer meanwhile
+		return
+	}
+	if !delivered {
+		d.timer.Reset(d.maxGap)
+		return
+	}
+	d.firing = true
+	d.alerts++
+	if !d.metricsOff {
+		incCounter(metrics.AnomalyCounter, string(TypeDeadman), d.dataType, anomaly.Severity)
+	}
+}
+
+// UpdateThreshold sets maxGap; for this detector the threshold is in seconds
+func (d *DeadmanDetector) UpdateThreshold(threshold float64) error {
+	if threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+
+	d.setMaxGap(time.Duration(threshold * float64(time.Second)))
+	return nil
+}
+
+// setMaxGap changes maxGap and re-arms the timer relative to the last value
+func (d *DeadmanDetector) setMaxGap(maxGap time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.maxGap = maxGap
+	if !d.stopped && !d.firing {
+		remaining := maxGap - time.Since(d.lastSeen)
+		if remaining < 0 {
+			remaining = 0
+		}
+		d.timer.Reset(remaining)
+	}
+}
+
+// IsAnomaly reports whether the source is currently silent for longer than maxGap.
+// The values are ignored; the score is the silence as a fraction of maxGap.
+func (d *DeadmanDetector) IsAnomaly(values []float64) (bool, float64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	gap := time.Since(d.lastSeen)
+	return gap >= d.maxGap, gap.Seconds() / d.maxGap.Seconds(), nil
+}
+
+// Severity implements SeverityDetector interface: a silent source is always critical
+func (d *DeadmanDetector) Severity(score float64) string {
+	return "critical"
+}
+
+// Type returns the detector type
+func (d *DeadmanDetector) Type() string {
+	return string(TypeDeadman)
+}
+
+// Configure updates maxGap from config.Parameters
+func (d *DeadmanDetector) Configure(config DetectorConfig) error {
+	raw, ok := config.Parameters["maxGap"]
+	if !ok {
+		return nil
+	}
+
+	maxGap, err := parseDurationParam(raw)
+	if err != nil {
+		return fmt.Errorf("maxGap: %w", err)
+	}
+	d.setMaxGap(maxGap)
+	return nil
+}
+
+// GetStatistics returns detector statistics
+func (d *DeadmanDetector) GetStatistics() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return map[string]interface{}{
+		"maxGap":    d.maxGap.String(),
+		"lastSeen":  d.lastSeen,
+		"silentFor": time.Since(d.lastSeen).String(),
+		"firing":    d.firing,
+		"alerts":    d.alerts,
+	}
+}
+
+// Reset restarts the silence timer and clears the alert counter
+func (d *DeadmanDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastSeen = time.Now()
+	d.firing = false
+	d.alerts = 0
+	if !d.stopped {
+		d.timer.Reset(d.maxGap)
+	}
+}
+
+// Arm restarts the silence timer from now, so the silence before the detector
+// was started is not reported at once
+func (d *DeadmanDetector) Arm() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return
+	}
+	d.lastSeen = time.Now()
+	d.firing = false
+	d.timer.Reset(d.maxGap)
+}
+
+// Stop stops the background timer
+func (d *DeadmanDetector) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stopped = true
+	d.timer.Stop()
+}
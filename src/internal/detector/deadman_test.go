@@ -0,0 +1,117 @@
+package detector
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeadmanDetector_FiresOnSilence(t *testing.T) {
+	d := NewDeadmanDetector(30*time.Millisecond, "heartbeat")
+	defer d.Stop()
+
+	fired := make(chan *Anomaly, 4)
+	d.SetAnomalyHandler(func(anomaly *Anomaly) bool {
+		fired <- anomaly
+		return true
+	})
+
+	// Values arriving within maxGap keep the detector quiet
+	for i := 0; i < 3; i++ {
+		anomaly, err := d.Detect(context.Background(), 1)
+		if err != nil || anomaly != nil {
+			t.Fatalf("expected no anomaly from Detect, got %v, %v", anomaly, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case anomaly := <-fired:
+		t.Fatalf("unexpected anomaly while values arrive: %+v", anomaly)
+	default:
+	}
+
+	select {
+	case anomaly := <-fired:
+		if anomaly.Source != "deadman" || anomaly.Value < anomaly.Threshold {
+			t.Errorf("unexpected anomaly: %+v", anomaly)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an anomaly after maxGap without values")
+	}
+
+	// Only one anomaly per silence
+	select {
+	case anomaly := <-fired:
+		t.Fatalf("expected a single anomaly per silence, got another: %+v", anomaly)
+	case <-time.After(60 * time.Millisecond):
+	}
+
+	// The next value re-arms the timer
+	if _, err := d.Detect(context.Background(), 1); err != nil {
+		t.Fatalf("detect failed: %v", err)
+	}
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("expected the timer to be re-armed by a new value")
+	}
+}
+
+func TestDeadmanDetector_RetriesUndelivered(t *testing.T) {
+	d := NewDeadmanDetector(20*time.Millisecond, "heartbeat")
+	defer d.Stop()
+
+	var mu sync.Mutex
+	deliver := false
+	fired := make(chan *Anomaly, 8)
+	d.SetAnomalyHandler(func(anomaly *Anomaly) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if deliver {
+			fired <- anomaly
+		}
+		return deliver
+	})
+
+	// Undelivered anomalies do not latch the detector
+	time.Sleep(50 * time.Millisecond)
+	if firing := d.GetStatistics()["firing"]; firing != false {
+		t.Fatalf("expected an undelivered anomaly not to latch firing, got %v", firing)
+	}
+
+	mu.Lock()
+	deliver = true
+	mu.Unlock()
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("expected the anomaly to be raised again once it can be delivered")
+	}
+	if alerts := d.GetStatistics()["alerts"]; alerts != int64(1) {
+		t.Errorf("expected only the delivered anomaly to be counted, got %v", alerts)
+	}
+}
+
+func TestNewDetector_Deadman(t *testing.T) {
+	if _, err := NewDetector(DetectorConfig{Type: TypeDeadman}); err == nil {
+		t.Error("expected an error without maxGap")
+	}
+
+	det, err := NewDetector(DetectorConfig{Type: TypeDeadman, Parameters: map[string]interface{}{"maxGap": "5m"}})
+	if err != nil {
+		t.Fatalf("failed to create deadman detector: %v", err)
+	}
+	deadman, ok := det.(*DeadmanDetector)
+	if !ok {
+		t.Fatalf("expected *DeadmanDetector, got %T", det)
+	}
+	defer deadman.Stop()
+
+	if _, ok := det.(BackgroundDetector); !ok {
+		t.Error("expected deadman to implement BackgroundDetector")
+	}
+	if deadman.GetStatistics()["maxGap"] != "5m0s" {
+		t.Errorf("unexpected maxGap: %v", deadman.GetStatistics()["maxGap"])
+	}
+}
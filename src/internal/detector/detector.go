@@ -2,8 +2,10 @@ package detector
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -18,6 +20,8 @@ type Anomaly struct {
 	Value     float64
 	Threshold float64
 	Source    string
+	// Details carries the context at detection time (score, baseline, deviation)
+	Details map[string]interface{}
 }
 
 // Detector interface defines methods for anomaly detection
@@ -39,6 +43,14 @@ type TrainableDetector interface {
 	Train(values []float64) error
 }
 
+// TimedTrainableDetector interface defines detectors that use the time each
+// training value was observed at, e.g. for time-of-day baselines
+type TimedTrainableDetector interface {
+	TrainableDetector
+	// TrainTimed trains the detector on values observed at the matching timestamps
+	TrainTimed(timestamps []time.Time, values []float64) error
+}
+
 // ConfigurableDetector interface defines methods for configurable detectors
 type ConfigurableDetector interface {
 	Detector
@@ -48,6 +60,85 @@ type ConfigurableDetector interface {
 	GetStatistics() map[string]interface{}
 }
 
+// ResettableDetector interface defines detectors whose learned state can be cleared
+type ResettableDetector interface {
+	Detector
+	// Reset clears learned state (samples, statistics, counters) keeping the configuration
+	Reset()
+}
+
+// ExplainableDetector interface defines detectors that can describe how a value
+// compares to their current baseline
+type ExplainableDetector interface {
+	Detector
+	// Explain returns the score, baseline and deviation for the value
+	Explain(value float64) map[string]interface{}
+}
+
+// ScoredDetector interface defines detectors that report the score of every
+// value they check, not only of the values reported as anomalies
+type ScoredDetector interface {
+	Detector
+	// DetectScored checks the value like Detect and returns the score it was
+	// judged on; scored is false when there was none (e.g. during warm-up)
+	DetectScored(ctx context.Context, value float64) (anomaly *Anomaly, score float64, scored bool, err error)
+}
+
+// SeverityDetector interface defines detectors that can grade the score of a
+// value they flagged, e.g. when it was checked with IsAnomaly rather than Detect
+type SeverityDetector interface {
+	Detector
+	// Severity returns "warning" or "critical" for an anomaly with the given score
+	Severity(score float64) string
+}
+
+// WarmupDetector interface defines detectors that need a number of samples
+// before they report anomalies
+type WarmupDetector interface {
+	Detector
+	// IsWarmedUp reports whether the detector has enough samples to flag anomalies
+	IsWarmedUp() bool
+	// GetWarmupProgress returns the number of collected samples and the number required
+	GetWarmupProgress() (current, required int)
+}
+
+// StatefulDetector interface defines detectors whose learned model can be
+// exported and restored, e.g. to move a tuned detector between environments
+type StatefulDetector interface {
+	Detector
+	// ExportState serializes the learned model
+	ExportState() (json.RawMessage, error)
+	// ImportState restores a model produced by ExportState
+	ImportState(state json.RawMessage) error
+}
+
+// BackgroundDetector interface defines detectors that raise anomalies on their
+// own (e.g. from a timer) rather than only in response to Detect
+type BackgroundDetector interface {
+	Detector
+	// SetAnomalyHandler sets the function receiving anomalies raised in the
+	// background; it reports whether the anomaly was delivered
+	SetAnomalyHandler(handler func(*Anomaly) bool)
+	// Arm restarts the background checks, e.g. when the detector starts running
+	Arm()
+	// Stop releases background resources
+	Stop()
+}
+
+// Score multiples of the threshold at which an anomaly becomes critical
+const (
+	criticalScoreFactor           = 2.0
+	isolationForestCriticalFactor = 1.5
+)
+
+// severityFor grades a score that exceeded the threshold
+func severityFor(score, threshold, criticalFactor float64) string {
+	if score > threshold*criticalFactor {
+		return "critical"
+	}
+	return "warning"
+}
+
 // HealthCheckDetector interface defines health check capabilities
 type HealthCheckDetector interface {
 	// Health returns health status and metrics
@@ -69,6 +160,10 @@ const (
 	TypeWindow DetectorType = "window"
 	// TypeIsolationForest uses isolation forest algorithm
 	TypeIsolationForest DetectorType = "isolation_forest"
+	// TypeDeadman reports missing data when no value arrives within maxGap
+	TypeDeadman DetectorType = "deadman"
+	// TypeEnsemble combines the weighted scores of child detectors
+	TypeEnsemble DetectorType = "ensemble"
 )
 
 // DetectorConfig holds configuration for creating detectors
@@ -83,11 +178,190 @@ type DetectorConfig struct {
 	WindowSize int `json:"windowSize,omitempty" yaml:"windowSize,omitempty"`
 	NumTrees   int `json:"numTrees,omitempty" yaml:"numTrees,omitempty"`
 	SampleSize int `json:"sampleSize,omitempty" yaml:"sampleSize,omitempty"`
+
+	// DisableMetrics keeps the detector out of the metrics package collectors,
+	// e.g. for short-lived detectors that would otherwise leave series behind.
+	// It is set by the embedding code and never read from a config file.
+	DisableMetrics bool `json:"-" yaml:"-"`
+}
+
+// ParameterSpec describes a supported config.Parameters entry
+type ParameterSpec struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"` // int, float, bool, string, duration, list
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description"`
+}
+
+// DetectorTypeInfo describes a detector type and the parameters it accepts
+type DetectorTypeInfo struct {
+	Type        DetectorType    `json:"type"`
+	Description string          `json:"description"`
+	Parameters  []ParameterSpec `json:"parameters"`
+}
+
+// detectorTypeMetadata is the single source of truth for supported parameters,
+// shared by the schema endpoint and request validation
+var detectorTypeMetadata = []DetectorTypeInfo{
+	{
+		Type:        TypeStatistical,
+		Description: "Z-score against a learned mean/standard deviation",
+		Parameters: []ParameterSpec{
+			{Name: "windowSize", Type: "int", Default: 300, Description: "Number of recent samples used to compute statistics"},
+			{Name: "minSamples", Type: "int", Default: 10, Description: "Samples required before anomalies are reported"},
+			{Name: "autoUpdate", Type: "bool", Default: true, Description: "Recompute statistics as new samples arrive"},
+			{Name: "useMAD", Type: "bool", Default: false, Description: "Also compute median and median absolute deviation"},
+			{Name: "buckets", Type: "int", Default: 0, Description: "Time-of-day buckets for a seasonal baseline (e.g. 24 or 288, 0 disables)"},
+			{Name: "targetAnomalyRate", Type: "float", Default: 0, Description: "Auto-tune the threshold to keep this fraction of points anomalous (0 disables)"},
+			{Name: "minThreshold", Type: "float", Default: defaultMinTunedThreshold, Description: "Lower bound for the auto-tuned threshold"},
+			{Name: "maxThreshold", Type: "float", Default: defaultMaxTunedThreshold, Description: "Upper bound for the auto-tuned threshold"},
+			{Name: "staleAfter", Type: "duration", Default: defaultStaleAfter.String(), Description: "Health reports stale when statistics are older than this (e.g. \"2h\")"},
+			{Name: "clipPercentile", Type: "float", Default: 0, Description: "Clamp training values outside the p-th and (100-p)-th percentiles of each batch before computing statistics (0 disables, must be below 50)"},
+			{Name: "confirmCount", Type: "int", Default: 1, Description: "Report an anomaly only when this many of the last confirmWindow points exceed the threshold (1 disables)"},
+			{Name: "confirmWindow", Type: "int", Description: "Recent points considered for confirmCount (defaults to confirmCount, i.e. consecutive points)"},
+		},
+	},
+	{
+		Type:        TypeWindow,
+		Description: "Z-score against a sliding window of recent values",
+		Parameters: []ParameterSpec{
+			{Name: "windowSize", Type: "int", Description: "Sliding window length (required, also accepted as config.windowSize)"},
+			{Name: "mode", Type: "string", Default: WindowModeZScore, Description: "zscore flags values by z-score against the window, percentile flags values above the window's percentile (for skewed data such as latencies)"},
+			{Name: "percentile", Type: "float", Default: defaultWindowPercentile, Description: "Window percentile a value must exceed in percentile mode (between 0 and 100)"},
+			{Name: "targetAnomalyRate", Type: "float", Default: 0, Description: "Auto-tune the threshold to keep this fraction of points anomalous (0 disables)"},
+			{Name: "minThreshold", Type: "float", Default: defaultMinTunedThreshold, Description: "Lower bound for the auto-tuned threshold"},
+			{Name: "maxThreshold", Type: "float", Default: defaultMaxTunedThreshold, Description: "Upper bound for the auto-tuned threshold"},
+			{Name: "confirmCount", Type: "int", Default: 1, Description: "Report an anomaly only when this many of the last confirmWindow points exceed the threshold (1 disables)"},
+			{Name: "confirmWindow", Type: "int", Description: "Recent points considered for confirmCount (defaults to confirmCount, i.e. consecutive points)"},
+		},
+	},
+	{
+		Type:        TypeIsolationForest,
+		Description: "Isolation forest anomaly score in the range 0..1",
+		Parameters: []ParameterSpec{
+			{Name: "numTrees", Type: "int", Default: 100, Description: "Number of isolation trees"},
+			{Name: "sampleSize", Type: "int", Default: 256, Description: "Subsample size per tree"},
+			{Name: "normalize", Type: "string", Default: NormalizeNone, Description: "Scale inputs with parameters learned in Train and persisted with the state: minmax or zscore"},
+		},
+	},
+	{
+		Type:        TypeDeadman,
+		Description: "Anomaly when no value arrives within maxGap (the source stopped reporting)",
+		Parameters: []ParameterSpec{
+			{Name: "maxGap", Type: "duration", Description: "Longest allowed silence between values (required, e.g. \"5m\")"},
+		},
+	},
+	{
+		Type:        TypeEnsemble,
+		Description: "Weighted average of child detector scores, each normalized by the child's threshold (threshold defaults to 1)",
+		Parameters: []ParameterSpec{
+			{Name: "children", Type: "list", Description: "Child detector configs (type, threshold, parameters, windowSize, numTrees, sampleSize) with an optional weight (default 1) (required)"},
+		},
+	},
+}
+
+// DetectorTypes returns metadata for all supported detector types
+func DetectorTypes() []DetectorTypeInfo {
+	types := make([]DetectorTypeInfo, len(detectorTypeMetadata))
+	copy(types, detectorTypeMetadata)
+	return types
+}
+
+// GetDetectorTypeInfo returns metadata for a detector type
+func GetDetectorTypeInfo(detectorType DetectorType) (DetectorTypeInfo, bool) {
+	for _, info := range detectorTypeMetadata {
+		if info.Type == detectorType {
+			return info, true
+		}
+	}
+	return DetectorTypeInfo{}, false
+}
+
+// ValidateParameters checks config.Parameters against the detector type metadata
+func ValidateParameters(detectorType DetectorType, params map[string]interface{}) error {
+	info, ok := GetDetectorTypeInfo(detectorType)
+	if !ok {
+		return fmt.Errorf("unknown detector type: %s", detectorType)
+	}
+
+	for name, value := range params {
+		var spec *ParameterSpec
+		for i := range info.Parameters {
+			if info.Parameters[i].Name == name {
+				spec = &info.Parameters[i]
+				break
+			}
+		}
+		if spec == nil {
+			return fmt.Errorf("unsupported parameter %q for detector type %s", name, detectorType)
+		}
+
+		if err := checkParameterType(*spec, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkParameterType verifies a decoded JSON value matches the declared type
+func checkParameterType(spec ParameterSpec, value interface{}) error {
+	switch spec.Type {
+	case "int":
+		f, ok := value.(float64)
+		if !ok || f != math.Trunc(f) {
+			return fmt.Errorf("parameter %q must be an integer", spec.Name)
+		}
+	case "float":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("parameter %q must be a number", spec.Name)
+		}
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("parameter %q must be a boolean", spec.Name)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("parameter %q must be a string", spec.Name)
+		}
+	case "duration":
+		if _, err := parseDurationParam(value); err != nil {
+			return fmt.Errorf("parameter %q: %w", spec.Name, err)
+		}
+	case "list":
+		if _, ok := value.([]interface{}); !ok {
+			return fmt.Errorf("parameter %q must be a list", spec.Name)
+		}
+	}
+	return nil
+}
+
+// parseDurationParam accepts a Go duration string ("90s", "2h") or a number of seconds
+func parseDurationParam(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", v)
+		}
+		if d <= 0 {
+			return 0, fmt.Errorf("duration must be positive")
+		}
+		return d, nil
+	case float64:
+		if v <= 0 {
+			return 0, fmt.Errorf("duration must be positive")
+		}
+		return time.Duration(v * float64(time.Second)), nil
+	default:
+		return 0, fmt.Errorf("must be a duration string or number of seconds")
+	}
 }
 
 // StatisticalDetector implements anomaly detection using statistical methods
 type StatisticalDetector struct {
 	mu sync.RWMutex
+	detectorMetrics
 
 	mean      float64
 	stdDev    float64
@@ -105,8 +379,49 @@ type StatisticalDetector struct {
 	lastComputation time.Time
 	detectionCount  int64
 	anomalyCount    int64
+
+	// staleAfter is how old lastComputation may get before Health reports stale
+	staleAfter time.Duration
+
+	// clipPercentile winsorizes each training batch at the given percentile so a
+	// single glitch (e.g. a scrape gap reported as a huge value) cannot skew the
+	// baseline (0 disables)
+	clipPercentile float64
+
+	// tuner adjusts threshold towards a target anomaly rate (nil when disabled)
+	tuner *thresholdTuner
+
+	// confirmer requires N of the last M points to exceed the threshold (nil when disabled)
+	confirmer *anomalyConfirmer
+
+	// explicitBaseline is set when mean/stdDev were provided rather than learned,
+	// in which case no warmup is required
+	explicitBaseline bool
+
+	// Seasonality: separate baseline per time-of-day bucket (disabled when empty)
+	buckets []seasonalBucket
+}
+
+// seasonalBucket holds running statistics for one time-of-day bucket (Welford's algorithm)
+type seasonalBucket struct {
+	count int64
+	mean  float64
+	m2    float64
+}
+
+// SeasonalBucket is an exported snapshot of a time-of-day bucket, used to persist and restore baselines
+type SeasonalBucket struct {
+	Index  int     `json:"index"`
+	Count  int64   `json:"count"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stdDev"`
 }
 
+const secondsPerDay = 24 * 60 * 60
+
+// defaultStaleAfter is the default staleness threshold for Health
+const defaultStaleAfter = 5 * time.Minute
+
 // NewStatisticalDetector creates a new statistical anomaly detector
 func NewStatisticalDetector(threshold, mean, stdDev float64, dataType string) *StatisticalDetector {
 	return &StatisticalDetector{
@@ -118,6 +433,9 @@ func NewStatisticalDetector(threshold, mean, stdDev float64, dataType string) *S
 		minSamples: 10,   // Minimum samples for detection
 		autoUpdate: true, // Auto-update statistics
 		values:     make([]float64, 0, 300),
+		staleAfter: defaultStaleAfter,
+
+		explicitBaseline: stdDev > 0,
 	}
 }
 
@@ -132,55 +450,144 @@ func (d *StatisticalDetector) UpdateParameters(mean, stdDev float64) error {
 
 	d.mean = mean
 	d.stdDev = stdDev
+	d.explicitBaseline = stdDev > 0
 	return nil
 }
 
+// IsWarmedUp reports whether the detector has enough samples to flag anomalies
+func (d *StatisticalDetector) IsWarmedUp() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.isWarmedUp()
+}
+
+// GetWarmupProgress returns the number of collected samples and the number required
+func (d *StatisticalDetector) GetWarmupProgress() (current, required int) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return len(d.values), d.minSamples
+}
+
+// isWarmedUp is the lock-free variant of IsWarmedUp (internal method)
+func (d *StatisticalDetector) isWarmedUp() bool {
+	return d.explicitBaseline || len(d.values) >= d.minSamples
+}
+
+// WarmupProgress returns how far a detector is through its warmup as the
+// fraction of required samples collected, clamped to 1. ok is false for
+// detectors that need no warmup.
+func WarmupProgress(d Detector) (progress float64, ok bool) {
+	warmup, ok := d.(WarmupDetector)
+	if !ok {
+		return 0, false
+	}
+	if warmup.IsWarmedUp() {
+		return 1, true
+	}
+
+	current, required := warmup.GetWarmupProgress()
+	if required <= 0 {
+		return 1, true
+	}
+	return math.Min(float64(current)/float64(required), 1), true
+}
+
 // Detect implements anomaly detection using statistical methods
 func (d *StatisticalDetector) Detect(ctx context.Context, value float64) (*Anomaly, error) {
+	return d.detectAt(ctx, time.Now(), value)
+}
+
+// DetectScored checks the value like Detect and returns its z-score
+func (d *StatisticalDetector) DetectScored(ctx context.Context, value float64) (*Anomaly, float64, bool, error) {
+	return d.detectScoredAt(ctx, time.Now(), value)
+}
+
+// detectAt checks the value against the baseline for the given timestamp
+func (d *StatisticalDetector) detectAt(ctx context.Context, ts time.Time, value float64) (*Anomaly, error) {
+	anomaly, _, _, err := d.detectScoredAt(ctx, ts, value)
+	return anomaly, err
+}
+
+// detectScoredAt checks the value against the baseline for the given timestamp
+// and returns its z-score, which is missing until the baseline is built
+func (d *StatisticalDetector) detectScoredAt(ctx context.Context, ts time.Time, value float64) (*Anomaly, float64, bool, error) {
 	start := time.Now()
 	defer func() {
-		recordMetrics(TypeStatistical, d.dataType, nil, time.Since(start), nil)
+		d.recordMetrics(TypeStatistical, d.dataType, nil, time.Since(start), nil)
 	}()
 
 	select {
 	case <-ctx.Done():
 		err := ctx.Err()
-		recordMetrics(TypeStatistical, d.dataType, nil, time.Since(start), err)
-		return nil, err
+		d.recordMetrics(TypeStatistical, d.dataType, nil, time.Since(start), err)
+		return nil, 0, false, err
 	default:
 		d.mu.RLock()
-		mean := d.mean
-		stdDev := d.stdDev
+		mean, stdDev := d.baselineAt(ts)
+		warmedUp := d.isWarmedUp()
+		details := d.baselineDetails(ts)
+		threshold := d.threshold
 		d.mu.RUnlock()
 
-		if stdDev == 0 {
-			return nil, nil
+		// Suppress anomalies until the baseline is built from enough samples
+		if !warmedUp || stdDev == 0 {
+			d.recordDetection(false, false)
+			return nil, 0, false, nil
 		}
 
 		zScore := math.Abs((value - mean) / stdDev)
-		if zScore > d.threshold {
-			severity := "warning"
-			if zScore > d.threshold*2 {
-				severity = "critical"
-			}
+		if d.recordDetection(zScore > threshold, true) {
+			severity := severityFor(zScore, threshold, criticalScoreFactor)
+
+			details["score"] = zScore
+			details["mean"] = mean
+			details["stdDev"] = stdDev
+			details["deviation"] = value - mean
 
 			anomaly := &Anomaly{
 				Timestamp: time.Now(),
 				Type:      d.dataType,
 				Severity:  severity,
 				Value:     value,
-				Threshold: d.threshold,
+				Threshold: threshold,
 				Source:    "statistical",
+				Details:   details,
 			}
 
-			recordMetrics(TypeStatistical, d.dataType, anomaly, time.Since(start), nil)
-			return anomaly, nil
+			d.recordMetrics(TypeStatistical, d.dataType, anomaly, time.Since(start), nil)
+			return anomaly, zScore, true, nil
 		}
 
-		return nil, nil
+		return nil, zScore, true, nil
 	}
 }
 
+// recordDetection updates activity counters after a detection and feeds the
+// threshold tuner and confirmer with detections that were evaluated against a
+// baseline. It returns whether an anomaly is reported (internal method).
+func (d *StatisticalDetector) recordDetection(exceeded, evaluated bool) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	anomalous := exceeded
+	if evaluated && d.confirmer != nil {
+		anomalous = d.confirmer.observe(exceeded)
+	}
+
+	d.detectionCount++
+	if anomalous {
+		d.anomalyCount++
+	}
+	d.lastComputation = time.Now()
+
+	if evaluated && d.tuner != nil {
+		d.threshold = d.tuner.observe(d.threshold, exceeded)
+	}
+	return anomalous
+}
+
 // UpdateThreshold updates the detection threshold
 func (d *StatisticalDetector) UpdateThreshold(threshold float64) error {
 	if threshold < 0 {
@@ -203,12 +610,12 @@ func (d *StatisticalDetector) IsAnomaly(values []float64) (bool, float64, error)
 	value := values[len(values)-1]
 
 	d.mu.RLock()
-	mean := d.mean
-	stdDev := d.stdDev
+	mean, stdDev := d.baselineAt(time.Now())
 	threshold := d.threshold
+	warmedUp := d.isWarmedUp()
 	d.mu.RUnlock()
 
-	if stdDev == 0 {
+	if !warmedUp || stdDev == 0 {
 		return false, 0, nil
 	}
 
@@ -216,6 +623,54 @@ func (d *StatisticalDetector) IsAnomaly(values []float64) (bool, float64, error)
 	return zScore > threshold, zScore, nil
 }
 
+// Explain returns the score, baseline and deviation of the value against the current baseline
+func (d *StatisticalDetector) Explain(value float64) map[string]interface{} {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	now := time.Now()
+	mean, stdDev := d.baselineAt(now)
+	details := d.baselineDetails(now)
+	details["mean"] = mean
+	details["stdDev"] = stdDev
+	details["deviation"] = value - mean
+	if stdDev > 0 {
+		details["score"] = math.Abs((value - mean) / stdDev)
+	}
+	return details
+}
+
+// baselineDetails describes which baseline applies at the timestamp (internal method)
+func (d *StatisticalDetector) baselineDetails(ts time.Time) map[string]interface{} {
+	details := map[string]interface{}{
+		"baseline":    "global",
+		"sampleCount": len(d.values),
+	}
+
+	if len(d.buckets) > 0 {
+		idx := d.bucketIndex(ts)
+		if d.buckets[idx].count >= int64(d.minSamples) && d.buckets[idx].count > 0 {
+			details["baseline"] = "seasonal"
+			details["bucket"] = idx
+			details["sampleCount"] = d.buckets[idx].count
+		}
+	}
+
+	if d.useMAD {
+		details["median"] = d.median
+		details["mad"] = d.mad
+	}
+
+	return details
+}
+
+// Severity implements SeverityDetector interface
+func (d *StatisticalDetector) Severity(score float64) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return severityFor(score, d.threshold, criticalScoreFactor)
+}
+
 // Type returns the type of detector
 func (d *StatisticalDetector) Type() string {
 	return string(TypeStatistical)
@@ -251,6 +706,43 @@ func (d *StatisticalDetector) Configure(config DetectorConfig) error {
 		if useMAD, ok := config.Parameters["useMAD"].(bool); ok {
 			d.useMAD = useMAD
 		}
+
+		if buckets, ok := config.Parameters["buckets"].(float64); ok {
+			if err := d.setBuckets(int(buckets)); err != nil {
+				return err
+			}
+		}
+
+		if _, ok := config.Parameters["targetAnomalyRate"]; ok {
+			tuner, err := newThresholdTuner(config.Parameters)
+			if err != nil {
+				return err
+			}
+			d.tuner = tuner
+		}
+
+		if _, ok := config.Parameters["confirmCount"]; ok {
+			confirmer, err := newAnomalyConfirmer(config.Parameters)
+			if err != nil {
+				return err
+			}
+			d.confirmer = confirmer
+		}
+
+		if raw, ok := config.Parameters["staleAfter"]; ok {
+			staleAfter, err := parseDurationParam(raw)
+			if err != nil {
+				return fmt.Errorf("staleAfter: %w", err)
+			}
+			d.staleAfter = staleAfter
+		}
+
+		if clip, ok := config.Parameters["clipPercentile"].(float64); ok {
+			if clip < 0 || clip >= 50 {
+				return fmt.Errorf("clipPercentile must be in [0, 50), got %v", clip)
+			}
+			d.clipPercentile = clip
+		}
 	}
 
 	// Handle legacy fields
@@ -283,12 +775,27 @@ func (d *StatisticalDetector) GetStatistics() map[string]interface{} {
 		"minSamples":      d.minSamples,
 		"autoUpdate":      d.autoUpdate,
 		"useMAD":          d.useMAD,
+		"clipPercentile":  d.clipPercentile,
+		"warmedUp":        d.isWarmedUp(),
 	}
 
 	if d.detectionCount > 0 {
 		stats["anomalyRate"] = float64(d.anomalyCount) / float64(d.detectionCount)
 	}
 
+	if len(d.buckets) > 0 {
+		stats["buckets"] = len(d.buckets)
+		stats["seasonalBuckets"] = d.snapshotBuckets()
+	}
+
+	if d.tuner != nil {
+		stats["autoTune"] = d.tuner.stats()
+	}
+
+	if d.confirmer != nil {
+		stats["confirm"] = d.confirmer.stats()
+	}
+
 	return stats
 }
 
@@ -303,10 +810,12 @@ func (d *StatisticalDetector) Health() map[string]interface{} {
 		"sampleCount":     len(d.values),
 		"detectionCount":  d.detectionCount,
 		"anomalyCount":    d.anomalyCount,
+		"warmedUp":        d.isWarmedUp(),
+		"staleAfter":      d.staleAfter.String(),
 	}
 
 	// Check if statistics are stale
-	if time.Since(d.lastComputation) > 5*time.Minute {
+	if time.Since(d.lastComputation) > d.staleAfter {
 		health["status"] = "stale"
 		health["warning"] = "Statistics not updated recently"
 	}
@@ -320,15 +829,58 @@ func (d *StatisticalDetector) Health() map[string]interface{} {
 	return health
 }
 
-// Train implements TrainableDetector interface
+// finiteValues returns values without NaN and ±Inf, which would poison the
+// baseline statistics. The slice is returned as is when all values are finite.
+func finiteValues(values []float64) []float64 {
+	for i, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			finite := append(make([]float64, 0, len(values)-1), values[:i]...)
+			for _, value := range values[i+1:] {
+				if !math.IsNaN(value) && !math.IsInf(value, 0) {
+					finite = append(finite, value)
+				}
+			}
+			return finite
+		}
+	}
+	return values
+}
+
+// clipValues winsorizes values: the lowest and highest percentile percent of
+// the batch are clamped to the nearest value kept. The input is not modified.
+func clipValues(values []float64, percentile float64) []float64 {
+	k := int(percentile / 100 * float64(len(values)))
+	if percentile <= 0 || k == 0 {
+		return values
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	low, high := sorted[k], sorted[len(sorted)-1-k]
+
+	clipped := make([]float64, len(values))
+	for i, v := range values {
+		clipped[i] = math.Min(math.Max(v, low), high)
+	}
+	return clipped
+}
+
+// Train implements TrainableDetector interface. Non-finite values are skipped
+// and, with clipPercentile set, outliers are clamped before computing statistics.
 func (d *StatisticalDetector) Train(values []float64) error {
 	if len(values) == 0 {
 		return fmt.Errorf("training data cannot be empty")
 	}
+	if values = finiteValues(values); len(values) == 0 {
+		return fmt.Errorf("training data contains no finite values")
+	}
 
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	values = clipValues(values, d.clipPercentile)
+
 	// Add values to training set
 	for _, value := range values {
 		d.addValue(value)
@@ -337,9 +889,239 @@ func (d *StatisticalDetector) Train(values []float64) error {
 	// Recompute statistics
 	d.computeStatistics()
 
+	// Values without timestamps are attributed to the current bucket
+	if len(d.buckets) > 0 {
+		d.observeBucket(time.Now(), values...)
+	}
+
+	return nil
+}
+
+// TrainAt trains the detector with values observed at the given timestamp.
+// With seasonality enabled the values only update the matching time-of-day bucket baseline.
+func (d *StatisticalDetector) TrainAt(ts time.Time, values []float64) error {
+	timestamps := make([]time.Time, len(values))
+	for i := range timestamps {
+		timestamps[i] = ts
+	}
+	return d.TrainTimed(timestamps, values)
+}
+
+// TrainTimed implements TimedTrainableDetector interface: with seasonality enabled
+// every value updates the time-of-day bucket of its own timestamp, so a series
+// spanning several hours fills several buckets. The batch is clipped as a whole.
+func (d *StatisticalDetector) TrainTimed(timestamps []time.Time, values []float64) error {
+	if len(timestamps) != len(values) {
+		return fmt.Errorf("got %d timestamps for %d training values", len(timestamps), len(values))
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("training data cannot be empty")
+	}
+
+	// Skip non-finite values together with their timestamps
+	finite := make([]float64, 0, len(values))
+	finiteTimestamps := make([]time.Time, 0, len(values))
+	for i, value := range values {
+		if !math.IsNaN(value) && !math.IsInf(value, 0) {
+			finite = append(finite, value)
+			finiteTimestamps = append(finiteTimestamps, timestamps[i])
+		}
+	}
+	if len(finite) == 0 {
+		return fmt.Errorf("training data contains no finite values")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	finite = clipValues(finite, d.clipPercentile)
+	for _, value := range finite {
+		d.addValue(value)
+	}
+	d.computeStatistics()
+
+	if len(d.buckets) > 0 {
+		for i, value := range finite {
+			d.observeBucket(finiteTimestamps[i], value)
+		}
+	}
+
+	return nil
+}
+
+// SeasonalBuckets returns a snapshot of the per-bucket statistics for persistence
+func (d *StatisticalDetector) SeasonalBuckets() []SeasonalBucket {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.snapshotBuckets()
+}
+
+// RestoreSeasonalBuckets loads previously persisted per-bucket statistics.
+// The number of configured buckets must match the snapshot.
+func (d *StatisticalDetector) RestoreSeasonalBuckets(snapshot []SeasonalBucket) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.buckets) == 0 {
+		return fmt.Errorf("seasonality is not enabled")
+	}
+
+	for _, b := range snapshot {
+		if b.Index < 0 || b.Index >= len(d.buckets) {
+			return fmt.Errorf("bucket index %d out of range [0, %d)", b.Index, len(d.buckets))
+		}
+		if b.Count < 0 || b.StdDev < 0 {
+			return fmt.Errorf("invalid statistics for bucket %d", b.Index)
+		}
+	}
+
+	for _, b := range snapshot {
+		d.buckets[b.Index] = seasonalBucket{
+			count: b.Count,
+			mean:  b.Mean,
+			m2:    b.StdDev * b.StdDev * float64(b.Count),
+		}
+	}
+
+	return nil
+}
+
+// setBuckets enables (n > 0) or disables (n == 0) time-of-day buckets (internal method)
+func (d *StatisticalDetector) setBuckets(n int) error {
+	if n < 0 || n > secondsPerDay || (n > 0 && secondsPerDay%n != 0) {
+		return fmt.Errorf("buckets must evenly divide a day (e.g. 24 or 288), got %d", n)
+	}
+
+	if n == len(d.buckets) {
+		return nil
+	}
+
+	if n == 0 {
+		d.buckets = nil
+		return nil
+	}
+
+	// Changing the bucket layout invalidates previously learned baselines
+	d.buckets = make([]seasonalBucket, n)
+	return nil
+}
+
+// bucketIndex returns the time-of-day bucket for the timestamp (internal method)
+func (d *StatisticalDetector) bucketIndex(ts time.Time) int {
+	ts = ts.UTC()
+	secondOfDay := ts.Hour()*3600 + ts.Minute()*60 + ts.Second()
+	return secondOfDay / (secondsPerDay / len(d.buckets))
+}
+
+// observeBucket updates the running statistics of the bucket for the timestamp (internal method)
+func (d *StatisticalDetector) observeBucket(ts time.Time, values ...float64) {
+	b := &d.buckets[d.bucketIndex(ts)]
+	for _, v := range values {
+		b.count++
+		delta := v - b.mean
+		b.mean += delta / float64(b.count)
+		b.m2 += delta * (v - b.mean)
+	}
+}
+
+// baselineAt returns the mean and standard deviation to compare against at the timestamp.
+// Falls back to the global baseline when seasonality is disabled or the bucket lacks samples.
+func (d *StatisticalDetector) baselineAt(ts time.Time) (float64, float64) {
+	if len(d.buckets) == 0 {
+		return d.mean, d.stdDev
+	}
+
+	b := d.buckets[d.bucketIndex(ts)]
+	if b.count < int64(d.minSamples) || b.count == 0 {
+		return d.mean, d.stdDev
+	}
+
+	return b.mean, math.Sqrt(b.m2 / float64(b.count))
+}
+
+// snapshotBuckets converts non-empty buckets to their exported form (internal method)
+func (d *StatisticalDetector) snapshotBuckets() []SeasonalBucket {
+	snapshot := make([]SeasonalBucket, 0, len(d.buckets))
+	for i, b := range d.buckets {
+		if b.count == 0 {
+			continue
+		}
+		snapshot = append(snapshot, SeasonalBucket{
+			Index:  i,
+			Count:  b.count,
+			Mean:   b.mean,
+			StdDev: math.Sqrt(b.m2 / float64(b.count)),
+		})
+	}
+	return snapshot
+}
+
+// statisticalState is the portable form of a StatisticalDetector's learned model
+type statisticalState struct {
+	Mean            float64          `json:"mean"`
+	StdDev          float64          `json:"stdDev"`
+	SeasonalBuckets []SeasonalBucket `json:"seasonalBuckets,omitempty"`
+}
+
+// ExportState implements StatefulDetector interface
+func (d *StatisticalDetector) ExportState() (json.RawMessage, error) {
+	d.mu.RLock()
+	state := statisticalState{
+		Mean:   d.mean,
+		StdDev: d.stdDev,
+	}
+	if len(d.buckets) > 0 {
+		state.SeasonalBuckets = d.snapshotBuckets()
+	}
+	d.mu.RUnlock()
+
+	return json.Marshal(state)
+}
+
+// ImportState implements StatefulDetector interface.
+// The imported baseline is treated as explicit, so no warmup is required.
+func (d *StatisticalDetector) ImportState(data json.RawMessage) error {
+	var state statisticalState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("invalid statistical detector state: %w", err)
+	}
+
+	if err := d.UpdateParameters(state.Mean, state.StdDev); err != nil {
+		return err
+	}
+
+	if len(state.SeasonalBuckets) > 0 {
+		return d.RestoreSeasonalBuckets(state.SeasonalBuckets)
+	}
 	return nil
 }
 
+// Reset clears learned state while keeping the configuration.
+// An explicitly provided baseline (mean/stdDev) is kept.
+func (d *StatisticalDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.values = make([]float64, 0, d.windowSize)
+	if !d.explicitBaseline {
+		d.mean = 0
+		d.stdDev = 0
+	}
+	d.median = 0
+	d.mad = 0
+	d.lastComputation = time.Time{}
+	d.detectionCount = 0
+	d.anomalyCount = 0
+	if d.confirmer != nil {
+		d.confirmer.reset()
+	}
+
+	if len(d.buckets) > 0 {
+		d.buckets = make([]seasonalBucket, len(d.buckets))
+	}
+}
+
 // addValue adds a new value to the sliding window (internal method)
 func (d *StatisticalDetector) addValue(value float64) {
 	d.values = append(d.values, value)
@@ -429,33 +1211,37 @@ func (d *StatisticalDetector) calculateMedianAndMAD() {
 	}
 }
 
-// recordMetrics records metrics for detector operations
+// recordMetrics records metrics for detector operations. Unset collectors are
+// skipped, see SetMetricsEnabled.
 func recordMetrics(detectorType DetectorType, dataType string, anomaly *Anomaly, duration time.Duration, err error) {
 	// Record detection duration
-	metrics.DetectionDuration.WithLabelValues(string(detectorType), dataType).Observe(duration.Seconds())
+	observeHistogram(metrics.DetectionDuration, duration.Seconds(), string(detectorType), dataType)
 
 	// Record processed samples
-	metrics.ProcessedSamples.WithLabelValues(string(detectorType), dataType).Inc()
+	incCounter(metrics.ProcessedSamples, string(detectorType), dataType)
 
 	// Update last detection timestamp
-	metrics.LastDetectionTimestamp.WithLabelValues(string(detectorType), dataType).Set(float64(time.Now().Unix()))
+	setGauge(metrics.LastDetectionTimestamp, float64(time.Now().Unix()), string(detectorType), dataType)
 
 	if err != nil {
 		// Record detection errors
-		metrics.DetectionErrors.WithLabelValues(string(detectorType), dataType, "detection_error").Inc()
+		incCounter(metrics.DetectionErrors, string(detectorType), dataType, "detection_error")
 		return
 	}
 
 	if anomaly != nil {
 		// Record detected anomaly
-		metrics.AnomalyCounter.WithLabelValues(string(detectorType), dataType, anomaly.Severity).Inc()
+		incCounter(metrics.AnomalyCounter, string(detectorType), dataType, anomaly.Severity)
 	}
 }
 
 // NewDetector creates a new anomaly detector based on the provided configuration
 func NewDetector(config DetectorConfig) (Detector, error) {
 	// Record configuration update
-	metrics.ConfigUpdates.WithLabelValues(string(config.Type), config.DataType, "attempt").Inc()
+	recordConfig := !config.DisableMetrics
+	if recordConfig {
+		incCounter(metrics.ConfigUpdates, string(config.Type), config.DataType, "attempt")
+	}
 
 	var detector Detector
 	var err error
@@ -472,7 +1258,13 @@ func NewDetector(config DetectorConfig) (Detector, error) {
 			err = fmt.Errorf("window size must be positive")
 			break
 		}
-		detector = NewWindowDetector(config.WindowSize, config.Threshold, config.DataType)
+		window := NewWindowDetector(config.WindowSize, config.Threshold, config.DataType)
+		if len(config.Parameters) > 0 {
+			if err = window.Configure(config); err != nil {
+				break
+			}
+		}
+		detector = window
 
 	case TypeIsolationForest:
 		if config.NumTrees <= 0 {
@@ -483,20 +1275,60 @@ func NewDetector(config DetectorConfig) (Detector, error) {
 			err = fmt.Errorf("sample size must be positive")
 			break
 		}
-		detector = NewIsolationForestDetector(config.NumTrees, config.SampleSize, config.Threshold, config.DataType)
+		forest := NewIsolationForestDetector(config.NumTrees, config.SampleSize, config.Threshold, config.DataType)
+		if raw, ok := config.Parameters["normalize"]; ok {
+			var mode string
+			if mode, err = parseNormalizeMode(raw); err != nil {
+				break
+			}
+			forest.SetNormalization(mode)
+		}
+		detector = forest
+
+	case TypeDeadman:
+		raw, ok := config.Parameters["maxGap"]
+		if !ok {
+			err = fmt.Errorf("maxGap parameter is required")
+			break
+		}
+		var maxGap time.Duration
+		if maxGap, err = parseDurationParam(raw); err != nil {
+			err = fmt.Errorf("maxGap: %w", err)
+			break
+		}
+		detector = NewDeadmanDetector(maxGap, config.DataType)
+
+	case TypeEnsemble:
+		var children []EnsembleChild
+		if children, err = parseEnsembleChildren(config.Parameters["children"]); err != nil {
+			break
+		}
+		for i := range children {
+			children[i].DisableMetrics = config.DisableMetrics
+		}
+		detector, err = NewEnsembleDetector(children, config.Threshold, config.DataType)
 
 	default:
 		err = fmt.Errorf("unknown detector type: %s", config.Type)
 	}
 
 	if err != nil {
-		metrics.ConfigUpdates.WithLabelValues(string(config.Type), config.DataType, "error").Inc()
+		if recordConfig {
+			incCounter(metrics.ConfigUpdates, string(config.Type), config.DataType, "error")
+		}
 		return nil, err
 	}
 
+	if !recordConfig {
+		if optOut, ok := detector.(interface{ disableMetrics() }); ok {
+			optOut.disableMetrics()
+		}
+		return detector, nil
+	}
+
 	// Record successful configuration
-	metrics.ConfigUpdates.WithLabelValues(string(config.Type), config.DataType, "success").Inc()
-	metrics.DetectorStatus.WithLabelValues(string(config.Type), config.DataType).Set(1)
+	incCounter(metrics.ConfigUpdates, string(config.Type), config.DataType, "success")
+	setGauge(metrics.DetectorStatus, 1, string(config.Type), config.DataType)
 
 	return detector, nil
 }
@@ -507,7 +1339,16 @@ type WindowDetector struct {
 	threshold  float64
 	dataType   string
 	values     []float64
+	tuner      *thresholdTuner
 	mu         sync.RWMutex
+
+	// confirmer requires N of the last M points to exceed the threshold (nil when disabled)
+	confirmer *anomalyConfirmer
+
+	// mode is WindowModeZScore or WindowModePercentile; percentile mode ignores
+	// the threshold and flags values above the window's percentile
+	mode       string
+	percentile float64
 }
 
 // NewWindowDetector creates a new window anomaly detector
@@ -518,22 +1359,52 @@ func NewWindowDetector(windowSize int, threshold float64, dataType string) *Wind
 		dataType:   dataType,
 		values:     make([]float64, 0, windowSize),
 		mu:         sync.RWMutex{},
+		mode:       WindowModeZScore,
+		percentile: defaultWindowPercentile,
 	}
 }
 
 // Detect implements anomaly detection using sliding window statistics
 func (d *WindowDetector) Detect(ctx context.Context, value float64) (*Anomaly, error) {
+	anomaly, _, _, err := d.DetectScored(ctx, value)
+	return anomaly, err
+}
+
+// DetectScored checks the value like Detect and returns its score: the z-score
+// against the window, or in percentile mode the value's percentile rank
+func (d *WindowDetector) DetectScored(ctx context.Context, value float64) (*Anomaly, float64, bool, error) {
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, 0, false, ctx.Err()
 	default:
 		d.mu.Lock()
+		// The percentile is taken over the window before the new value
+		var sorted []float64
+		if d.mode == WindowModePercentile {
+			sorted = sortedCopy(d.values)
+		}
+
 		// Добавляем новое значение в окно
 		d.values = append(d.values, value)
 		if len(d.values) > d.windowSize {
 			d.values = d.values[1:]
 		}
 
+		if d.mode == WindowModePercentile {
+			percentile, windowFill := d.percentile, len(d.values)
+			check, ok := checkPercentile(sorted, value, percentile)
+			isAnomaly := ok && check.exceeded
+			if ok && d.confirmer != nil {
+				isAnomaly = d.confirmer.observe(check.exceeded)
+			}
+			d.mu.Unlock()
+
+			if !isAnomaly {
+				return nil, check.rank, ok, nil
+			}
+			return d.percentileAnomaly(value, percentile, check, windowFill), check.rank, true, nil
+		}
+
 		// Вычисляем среднее и стандартное отклонение
 		var sum float64
 		for _, v := range d.values {
@@ -547,39 +1418,56 @@ func (d *WindowDetector) Detect(ctx context.Context, value float64) (*Anomaly, e
 			sumSq += diff * diff
 		}
 		stdDev := math.Sqrt(sumSq / float64(len(d.values)))
-		d.mu.Unlock()
+		windowFill := len(d.values)
+		threshold := d.threshold
 
 		// Если мало данных или стандартное отклонение слишком маленькое, не обнаруживаем аномалии
-		if len(d.values) < 2 || stdDev < 1e-10 {
-			return nil, nil
+		if windowFill < 2 || stdDev < 1e-10 {
+			d.mu.Unlock()
+			return nil, 0, false, nil
 		}
 
 		// Вычисляем z-score
 		zScore := math.Abs((value - mean) / stdDev)
-		if zScore > d.threshold {
-			severity := "warning"
-			if zScore > d.threshold*2 {
-				severity = "critical"
-			}
+		exceeded := zScore > threshold
+		if d.tuner != nil {
+			d.threshold = d.tuner.observe(d.threshold, exceeded)
+		}
+		isAnomaly := exceeded
+		if d.confirmer != nil {
+			isAnomaly = d.confirmer.observe(exceeded)
+		}
+		d.mu.Unlock()
+
+		if isAnomaly {
+			severity := severityFor(zScore, threshold, criticalScoreFactor)
 
 			return &Anomaly{
 				Timestamp: time.Now(),
 				Type:      d.dataType,
 				Severity:  severity,
 				Value:     value,
-				Threshold: d.threshold,
+				Threshold: threshold,
 				Source:    "window",
-			}, nil
+				Details: map[string]interface{}{
+					"score":      zScore,
+					"mean":       mean,
+					"stdDev":     stdDev,
+					"deviation":  value - mean,
+					"windowFill": windowFill,
+					"windowSize": d.windowSize,
+				},
+			}, zScore, true, nil
 		}
 
-		return nil, nil
+		return nil, zScore, true, nil
 	}
 }
 
 // UpdateThreshold updates the detection threshold
 func (d *WindowDetector) UpdateThreshold(threshold float64) error {
-	if threshold < 0 {
-		return fmt.Errorf("threshold cannot be negative")
+	if threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
 	}
 
 	d.mu.Lock()
@@ -600,12 +1488,19 @@ func (d *WindowDetector) IsAnomaly(values []float64) (bool, float64, error) {
 	windowValues := make([]float64, len(d.values))
 	copy(windowValues, d.values)
 	threshold := d.threshold
+	mode, percentile := d.mode, d.percentile
 	d.mu.RUnlock()
 
 	if len(windowValues) < 2 {
 		return false, 0, nil
 	}
 
+	// In percentile mode the score is the value's percentile rank in the window
+	if mode == WindowModePercentile {
+		check, _ := checkPercentile(sortedCopy(windowValues), value, percentile)
+		return check.exceeded, check.rank, nil
+	}
+
 	// Вычисляем среднее и стандартное отклонение
 	var sum float64
 	for _, v := range windowValues {
@@ -628,16 +1523,80 @@ func (d *WindowDetector) IsAnomaly(values []float64) (bool, float64, error) {
 	return zScore > threshold, zScore, nil
 }
 
+// Explain returns the score, window mean and deviation for the value
+func (d *WindowDetector) Explain(value float64) map[string]interface{} {
+	d.mu.RLock()
+	windowValues := make([]float64, len(d.values))
+	copy(windowValues, d.values)
+	windowSize := d.windowSize
+	mode, percentile := d.mode, d.percentile
+	d.mu.RUnlock()
+
+	details := map[string]interface{}{
+		"mode":       mode,
+		"windowFill": len(windowValues),
+		"windowSize": windowSize,
+	}
+	if len(windowValues) == 0 {
+		return details
+	}
+
+	var sum float64
+	for _, v := range windowValues {
+		sum += v
+	}
+	mean := sum / float64(len(windowValues))
+
+	var sumSq float64
+	for _, v := range windowValues {
+		diff := v - mean
+		sumSq += diff * diff
+	}
+	stdDev := math.Sqrt(sumSq / float64(len(windowValues)))
+
+	details["mean"] = mean
+	details["stdDev"] = stdDev
+	details["deviation"] = value - mean
+	if mode == WindowModePercentile {
+		if check, ok := checkPercentile(sortedCopy(windowValues), value, percentile); ok {
+			details["score"] = check.rank
+			details["percentile"] = percentile
+			details["percentileValue"] = check.cutoff
+			details["median"] = check.median
+		}
+		return details
+	}
+	if stdDev >= 1e-10 {
+		details["score"] = math.Abs((value - mean) / stdDev)
+	}
+	return details
+}
+
+// Severity implements SeverityDetector interface. In percentile mode the
+// score is a percentile rank, which does not tell how far the value is above
+// the cut-off, so anomalies are graded as warnings.
+func (d *WindowDetector) Severity(score float64) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.mode == WindowModePercentile {
+		return "warning"
+	}
+	return severityFor(score, d.threshold, criticalScoreFactor)
+}
+
 // Type returns the type of detector
 func (d *WindowDetector) Type() string {
 	return string(TypeWindow)
 }
 
-// Train trains the window detector with historical values
+// Train trains the window detector with historical values, skipping non-finite ones
 func (d *WindowDetector) Train(values []float64) error {
 	if len(values) == 0 {
 		return fmt.Errorf("empty values slice")
 	}
+	if values = finiteValues(values); len(values) == 0 {
+		return fmt.Errorf("training data contains no finite values")
+	}
 
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -653,6 +1612,95 @@ func (d *WindowDetector) Train(values []float64) error {
 	return nil
 }
 
+// Configure updates detector configuration
+func (d *WindowDetector) Configure(config DetectorConfig) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if config.Threshold > 0 {
+		d.threshold = config.Threshold
+	}
+
+	windowSize := config.WindowSize
+	if config.Parameters != nil {
+		if v, ok := config.Parameters["windowSize"].(float64); ok && v > 0 {
+			windowSize = int(v)
+		}
+
+		if _, ok := config.Parameters["targetAnomalyRate"]; ok {
+			tuner, err := newThresholdTuner(config.Parameters)
+			if err != nil {
+				return err
+			}
+			d.tuner = tuner
+		}
+
+		if _, ok := config.Parameters["confirmCount"]; ok {
+			confirmer, err := newAnomalyConfirmer(config.Parameters)
+			if err != nil {
+				return err
+			}
+			d.confirmer = confirmer
+		}
+
+		mode, percentile, err := parseWindowMode(config.Parameters, d.mode, d.percentile)
+		if err != nil {
+			return err
+		}
+		if mode == WindowModePercentile && d.tuner != nil {
+			return fmt.Errorf("targetAnomalyRate is not supported in percentile mode")
+		}
+		d.mode, d.percentile = mode, percentile
+	}
+
+	if windowSize > 0 {
+		d.windowSize = windowSize
+		if len(d.values) > d.windowSize {
+			d.values = d.values[len(d.values)-d.windowSize:]
+		}
+	}
+
+	return nil
+}
+
+// GetStatistics returns detector statistics
+func (d *WindowDetector) GetStatistics() map[string]interface{} {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	stats := map[string]interface{}{
+		"threshold":  d.threshold,
+		"windowSize": d.windowSize,
+		"windowFill": len(d.values),
+		"mode":       d.mode,
+	}
+
+	if d.mode == WindowModePercentile {
+		stats["percentile"] = d.percentile
+	}
+
+	if d.tuner != nil {
+		stats["autoTune"] = d.tuner.stats()
+	}
+
+	if d.confirmer != nil {
+		stats["confirm"] = d.confirmer.stats()
+	}
+
+	return stats
+}
+
+// Reset clears the sliding window keeping the configuration
+func (d *WindowDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.values = make([]float64, 0, d.windowSize)
+	if d.confirmer != nil {
+		d.confirmer.reset()
+	}
+}
+
 // IsolationForestDetector implements isolation forest anomaly detection
 type IsolationForestDetector struct {
 	numTrees   int
@@ -660,6 +1708,9 @@ type IsolationForestDetector struct {
 	threshold  float64
 	dataType   string
 	mu         sync.RWMutex
+
+	// scaler normalizes inputs with parameters learned in Train (disabled by default)
+	scaler featureScaler
 }
 
 // NewIsolationForestDetector creates a new isolation forest anomaly detector
@@ -675,20 +1726,24 @@ func NewIsolationForestDetector(numTrees int, sampleSize int, threshold float64,
 
 // Detect implements anomaly detection using isolation forest
 func (d *IsolationForestDetector) Detect(ctx context.Context, value float64) (*Anomaly, error) {
+	anomaly, _, _, err := d.DetectScored(ctx, value)
+	return anomaly, err
+}
+
+// DetectScored checks the value like Detect and returns its anomaly score
+func (d *IsolationForestDetector) DetectScored(ctx context.Context, value float64) (*Anomaly, float64, bool, error) {
 	// Здесь должна быть реальная реализация алгоритма Isolation Forest
 	// Для упрощения, используем заглушку
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, 0, false, ctx.Err()
 	default:
-		// Эмуляция обнаружения аномалии
-		anomalyScore := math.Abs(value) / 100.0
+		d.mu.RLock()
+		anomalyScore, scaled := d.scaler.score(value)
+		d.mu.RUnlock()
 
 		if anomalyScore > d.threshold {
-			severity := "warning"
-			if anomalyScore > d.threshold*1.5 {
-				severity = "critical"
-			}
+			severity := severityFor(anomalyScore, d.threshold, isolationForestCriticalFactor)
 
 			return &Anomaly{
 				Timestamp: time.Now(),
@@ -697,17 +1752,21 @@ func (d *IsolationForestDetector) Detect(ctx context.Context, value float64) (*A
 				Value:     value,
 				Threshold: d.threshold,
 				Source:    "isolation_forest",
-			}, nil
+				Details: map[string]interface{}{
+					"score":           anomalyScore,
+					"normalizedValue": scaled,
+				},
+			}, anomalyScore, true, nil
 		}
 
-		return nil, nil
+		return nil, anomalyScore, true, nil
 	}
 }
 
 // UpdateThreshold updates the detection threshold
 func (d *IsolationForestDetector) UpdateThreshold(threshold float64) error {
-	if threshold < 0 || threshold > 1 {
-		return fmt.Errorf("threshold must be between 0 and 1")
+	if threshold <= 0 || threshold > 1 {
+		return fmt.Errorf("threshold must be between 0 (exclusive) and 1")
 	}
 
 	d.mu.Lock()
@@ -724,27 +1783,81 @@ func (d *IsolationForestDetector) IsAnomaly(values []float64) (bool, float64, er
 
 	value := values[len(values)-1]
 
-	// Эмуляция алгоритма Isolation Forest
-	anomalyScore := math.Abs(value) / 100.0
-
 	d.mu.RLock()
+	anomalyScore, _ := d.scaler.score(value)
 	threshold := d.threshold
 	d.mu.RUnlock()
 
 	return anomalyScore > threshold, anomalyScore, nil
 }
 
+// Severity implements SeverityDetector interface
+func (d *IsolationForestDetector) Severity(score float64) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return severityFor(score, d.threshold, isolationForestCriticalFactor)
+}
+
 // Type returns the type of detector
 func (d *IsolationForestDetector) Type() string {
 	return string(TypeIsolationForest)
 }
 
-// Train trains the isolation forest detector
+// SetNormalization enables input scaling ("minmax" or "zscore", "" or "none"
+// disables it). The scaling parameters are learned by the next Train.
+func (d *IsolationForestDetector) SetNormalization(mode string) error {
+	mode, err := parseNormalizeMode(mode)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.scaler = featureScaler{Mode: mode}
+	return nil
+}
+
+// isolationForestState is the portable form of an IsolationForestDetector's learned scaling
+type isolationForestState struct {
+	Scaler featureScaler `json:"scaler"`
+}
+
+// ExportState implements StatefulDetector interface
+func (d *IsolationForestDetector) ExportState() (json.RawMessage, error) {
+	d.mu.RLock()
+	state := isolationForestState{Scaler: d.scaler}
+	d.mu.RUnlock()
+
+	return json.Marshal(state)
+}
+
+// ImportState implements StatefulDetector interface
+func (d *IsolationForestDetector) ImportState(data json.RawMessage) error {
+	var state isolationForestState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("invalid isolation forest state: %w", err)
+	}
+	if _, err := parseNormalizeMode(state.Scaler.Mode); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.scaler = state.Scaler
+	return nil
+}
+
+// Train trains the isolation forest detector and, with normalization enabled,
+// learns the scaling applied to inputs at detect time
 func (d *IsolationForestDetector) Train(values []float64) error {
 	if len(values) == 0 {
 		return fmt.Errorf("empty values slice")
 	}
 
+	d.mu.Lock()
+	d.scaler.fit(values)
+	d.mu.Unlock()
+
 	// Здесь должно быть обучение модели Isolation Forest
 	// Для упрощения, используем заглушку
 
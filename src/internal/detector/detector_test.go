@@ -2,7 +2,9 @@ package detector
 
 import (
 	"context"
+	"math"
 	"testing"
+	"time"
 )
 
 func TestNewStatisticalDetector(t *testing.T) {
@@ -31,7 +33,7 @@ func TestNewStatisticalDetector(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			detector := NewStatisticalDetector(tt.mean, tt.stdDev, tt.threshold, tt.dataType)
+			detector := NewStatisticalDetector(tt.threshold, tt.mean, tt.stdDev, tt.dataType)
 			if detector == nil {
 				t.Error("expected non-nil detector")
 			}
@@ -91,7 +93,7 @@ func TestDetect(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			detector := NewStatisticalDetector(tt.mean, tt.stdDev, tt.threshold, "test")
+			detector := NewStatisticalDetector(tt.threshold, tt.mean, tt.stdDev, "test")
 			ctx := context.Background()
 
 			anomaly, err := detector.Detect(ctx, tt.value)
@@ -292,3 +294,433 @@ func TestDetectorType_String(t *testing.T) {
 		})
 	}
 }
+
+func TestStatisticalDetector_SeasonalBuckets(t *testing.T) {
+	d := NewStatisticalDetector(3, 0, 0, "test")
+	if err := d.Configure(DetectorConfig{Parameters: map[string]interface{}{"buckets": float64(24)}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	night := time.Date(2024, 1, 1, 3, 15, 0, 0, time.UTC)
+	peak := time.Date(2024, 1, 1, 14, 30, 0, 0, time.UTC)
+
+	if err := d.TrainAt(night, []float64{10, 11, 9, 10, 12, 8, 10, 11, 9, 10}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.TrainAt(peak, []float64{100, 110, 90, 100, 120, 80, 100, 110, 90, 100}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		ts            time.Time
+		value         float64
+		expectAnomaly bool
+	}{
+		{name: "normal off-peak value", ts: night, value: 10.5, expectAnomaly: false},
+		{name: "peak value at night", ts: night, value: 100, expectAnomaly: true},
+		{name: "normal peak value", ts: peak, value: 105, expectAnomaly: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anomaly, err := d.detectAt(context.Background(), tt.ts, tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectAnomaly && anomaly == nil {
+				t.Error("expected anomaly, got nil")
+			} else if !tt.expectAnomaly && anomaly != nil {
+				t.Error("expected no anomaly, got one")
+			}
+		})
+	}
+
+	snapshot := d.SeasonalBuckets()
+	if len(snapshot) != 2 {
+		t.Fatalf("snapshot length = %v, want 2", len(snapshot))
+	}
+
+	restored := NewStatisticalDetector(3, 0, 0, "test")
+	if err := restored.Configure(DetectorConfig{Parameters: map[string]interface{}{"buckets": float64(24)}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := restored.RestoreSeasonalBuckets(snapshot); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := restored.SeasonalBuckets(); got[0] != snapshot[0] {
+		t.Errorf("restored bucket = %+v, want %+v", got[0], snapshot[0])
+	}
+}
+
+func TestStatisticalDetector_TrainTimed(t *testing.T) {
+	d := NewStatisticalDetector(3, 0, 0, "test")
+	if err := d.Configure(DetectorConfig{Parameters: map[string]interface{}{"buckets": float64(24)}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Six hours of one value a minute, with a non-finite sample that is skipped
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var timestamps []time.Time
+	var values []float64
+	for i := 0; i < 6*60; i++ {
+		timestamps = append(timestamps, start.Add(time.Duration(i)*time.Minute))
+		values = append(values, float64(10+i%3))
+	}
+	values[5] = math.NaN()
+
+	if err := d.TrainTimed(timestamps, values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	snapshot := d.SeasonalBuckets()
+	if len(snapshot) != 6 {
+		t.Fatalf("expected 6 filled hourly buckets, got %d", len(snapshot))
+	}
+	if snapshot[0].Count != 59 || snapshot[5].Count != 60 {
+		t.Errorf("unexpected bucket counts: %+v", snapshot)
+	}
+
+	if err := d.TrainTimed(timestamps[:2], values[:1]); err == nil {
+		t.Error("expected an error for mismatched timestamps and values")
+	}
+}
+
+func TestStatisticalDetector_InvalidBuckets(t *testing.T) {
+	d := NewStatisticalDetector(3, 0, 0, "test")
+	err := d.Configure(DetectorConfig{Parameters: map[string]interface{}{"buckets": float64(7)}})
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestStatisticalDetector_WarmupGate(t *testing.T) {
+	d := NewStatisticalDetector(2, 0, 0, "test")
+	ctx := context.Background()
+
+	if err := d.Train([]float64{10, 11, 9, 10, 12}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d.IsWarmedUp() {
+		t.Error("expected detector to be warming up")
+	}
+	current, required := d.GetWarmupProgress()
+	if current != 5 || required != 10 {
+		t.Errorf("warmup progress: current = %v, required = %v, want 5, 10", current, required)
+	}
+
+	anomaly, err := d.Detect(ctx, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if anomaly != nil {
+		t.Error("expected no anomaly during warmup, got one")
+	}
+
+	if err := d.Train([]float64{8, 10, 11, 9, 10}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !d.IsWarmedUp() {
+		t.Error("expected detector to be warmed up")
+	}
+
+	anomaly, err = d.Detect(ctx, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if anomaly == nil {
+		t.Error("expected anomaly after warmup, got nil")
+	}
+}
+
+func TestWarmupProgress(t *testing.T) {
+	d := NewStatisticalDetector(2, 0, 0, "test")
+	if err := d.Train([]float64{10, 11, 9, 10, 12, 10}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if progress, ok := WarmupProgress(d); !ok || progress != 0.6 {
+		t.Errorf("warmup progress = %v (ok = %v), want 0.6", progress, ok)
+	}
+
+	if err := d.Train([]float64{8, 10, 11, 9, 10, 12}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if progress, _ := WarmupProgress(d); progress != 1 {
+		t.Errorf("warmup progress = %v, want it clamped to 1", progress)
+	}
+
+	if _, ok := WarmupProgress(NewIsolationForestDetector(10, 16, 0.5, "test")); ok {
+		t.Error("expected no warmup progress for a detector without warmup")
+	}
+}
+
+func TestStatisticalDetector_AnomalyDetails(t *testing.T) {
+	d := NewStatisticalDetector(2, 100, 10, "test")
+
+	anomaly, err := d.Detect(context.Background(), 150)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if anomaly == nil {
+		t.Fatal("expected anomaly, got nil")
+	}
+
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"score", 5.0},
+		{"mean", 100.0},
+		{"stdDev", 10.0},
+		{"deviation", 50.0},
+		{"baseline", "global"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := anomaly.Details[tt.key]; got != tt.want {
+				t.Errorf("Details[%q] = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	tests := []struct {
+		name         string
+		detectorType DetectorType
+		params       map[string]interface{}
+		expectError  bool
+	}{
+		{
+			name:         "valid statistical parameters",
+			detectorType: TypeStatistical,
+			params:       map[string]interface{}{"windowSize": float64(100), "useMAD": true},
+			expectError:  false,
+		},
+		{
+			name:         "nil parameters",
+			detectorType: TypeWindow,
+			params:       nil,
+			expectError:  false,
+		},
+		{
+			name:         "unsupported parameter",
+			detectorType: TypeWindow,
+			params:       map[string]interface{}{"numTrees": float64(10)},
+			expectError:  true,
+		},
+		{
+			name:         "wrong type",
+			detectorType: TypeStatistical,
+			params:       map[string]interface{}{"useMAD": "yes"},
+			expectError:  true,
+		},
+		{
+			name:         "fractional integer",
+			detectorType: TypeStatistical,
+			params:       map[string]interface{}{"minSamples": 2.5},
+			expectError:  true,
+		},
+		{
+			name:         "valid staleAfter duration",
+			detectorType: TypeStatistical,
+			params:       map[string]interface{}{"staleAfter": "2h"},
+			expectError:  false,
+		},
+		{
+			name:         "invalid staleAfter duration",
+			detectorType: TypeStatistical,
+			params:       map[string]interface{}{"staleAfter": "soon"},
+			expectError:  true,
+		},
+		{
+			name:         "unknown detector type",
+			detectorType: DetectorType("unknown"),
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParameters(tt.detectorType, tt.params)
+			if tt.expectError && err == nil {
+				t.Error("expected error, got nil")
+			} else if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestStatisticalDetector_StaleAfter(t *testing.T) {
+	d := NewStatisticalDetector(3.0, 0, 0, "test")
+	if err := d.Configure(DetectorConfig{Parameters: map[string]interface{}{
+		"minSamples": float64(1),
+		"staleAfter": "2h",
+	}}); err != nil {
+		t.Fatalf("unexpected configure error: %v", err)
+	}
+
+	if err := d.Train([]float64{1, 2, 3}); err != nil {
+		t.Fatalf("unexpected training error: %v", err)
+	}
+
+	d.mu.Lock()
+	d.lastComputation = time.Now().Add(-time.Hour)
+	d.mu.Unlock()
+
+	health := d.Health()
+	if health["status"] != "healthy" {
+		t.Errorf("expected healthy status within staleAfter, got %v", health["status"])
+	}
+	if health["staleAfter"] != "2h0m0s" {
+		t.Errorf("expected staleAfter 2h0m0s in health, got %v", health["staleAfter"])
+	}
+
+	d.mu.Lock()
+	d.lastComputation = time.Now().Add(-3 * time.Hour)
+	d.mu.Unlock()
+
+	if status := d.Health()["status"]; status != "stale" {
+		t.Errorf("expected stale status, got %v", status)
+	}
+
+	if err := d.Configure(DetectorConfig{Parameters: map[string]interface{}{"staleAfter": "-1m"}}); err == nil {
+		t.Error("expected error for non-positive staleAfter")
+	}
+}
+
+func TestStatisticalDetector_ExportImportState(t *testing.T) {
+	source := NewStatisticalDetector(3.0, 0, 0, "test")
+	if err := source.Train([]float64{10, 12, 11, 9, 10, 11, 12, 10, 9, 11}); err != nil {
+		t.Fatalf("unexpected training error: %v", err)
+	}
+
+	state, err := source.ExportState()
+	if err != nil {
+		t.Fatalf("unexpected export error: %v", err)
+	}
+
+	target := NewStatisticalDetector(3.0, 0, 0, "test")
+	if err := target.ImportState(state); err != nil {
+		t.Fatalf("unexpected import error: %v", err)
+	}
+
+	sourceStats := source.GetStatistics()
+	targetStats := target.GetStatistics()
+	if sourceStats["mean"] != targetStats["mean"] || sourceStats["stdDev"] != targetStats["stdDev"] {
+		t.Errorf("imported baseline %v/%v does not match exported %v/%v",
+			targetStats["mean"], targetStats["stdDev"], sourceStats["mean"], sourceStats["stdDev"])
+	}
+
+	if !target.IsWarmedUp() {
+		t.Error("detector with imported baseline should not require warmup")
+	}
+
+	if err := target.ImportState([]byte(`{"mean": "bad"}`)); err == nil {
+		t.Error("expected error for malformed state")
+	}
+}
+
+func TestStatisticalDetector_DetectUpdatesStatistics(t *testing.T) {
+	d := NewStatisticalDetector(2.0, 10.0, 1.0, "test")
+	ctx := context.Background()
+
+	for _, value := range []float64{10, 10.5, 20} {
+		if _, err := d.Detect(ctx, value); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	stats := d.GetStatistics()
+	if stats["detectionCount"] != int64(3) {
+		t.Errorf("expected detectionCount 3, got %v", stats["detectionCount"])
+	}
+	if stats["anomalyCount"] != int64(1) {
+		t.Errorf("expected anomalyCount 1, got %v", stats["anomalyCount"])
+	}
+	if last, ok := stats["lastComputation"].(time.Time); !ok || last.IsZero() {
+		t.Errorf("expected lastComputation to be set, got %v", stats["lastComputation"])
+	}
+}
+
+func TestStatisticalDetector_TrainSkipsNonFinite(t *testing.T) {
+	d := NewStatisticalDetector(2.0, 0, 0, "test")
+
+	if err := d.Train([]float64{10, math.NaN(), 12, math.Inf(1), 14}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats := d.GetStatistics()
+	if stats["mean"] != 12.0 || stats["sampleCount"] != 3 {
+		t.Errorf("expected mean 12 over 3 samples, got %v over %v", stats["mean"], stats["sampleCount"])
+	}
+
+	if err := d.Train([]float64{math.NaN(), math.Inf(-1)}); err == nil {
+		t.Error("expected an error when no value is finite")
+	}
+	if mean := d.GetStatistics()["mean"]; mean != 12.0 {
+		t.Errorf("expected the baseline to be unchanged, got mean %v", mean)
+	}
+}
+
+func TestStatisticalDetector_ClipPercentile(t *testing.T) {
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = float64(10 + i%5)
+	}
+	values[42] = 1e9 // a scrape glitch
+
+	unclipped := NewStatisticalDetector(3.0, 0, 0, "test")
+	clipped := NewStatisticalDetector(3.0, 0, 0, "test")
+	if err := clipped.Configure(DetectorConfig{Parameters: map[string]interface{}{"clipPercentile": 1.0}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, d := range []*StatisticalDetector{unclipped, clipped} {
+		if err := d.Train(values); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if mean := unclipped.GetStatistics()["mean"].(float64); mean < 1e6 {
+		t.Fatalf("expected the glitch to skew the unclipped mean, got %v", mean)
+	}
+	stats := clipped.GetStatistics()
+	if mean := stats["mean"].(float64); mean < 10 || mean > 14 {
+		t.Errorf("expected the clipped mean to stay within the data range, got %v", mean)
+	}
+	if stdDev := stats["stdDev"].(float64); stdDev > 2 {
+		t.Errorf("expected the clipped stdDev to ignore the glitch, got %v", stdDev)
+	}
+	if values[42] != 1e9 {
+		t.Error("expected the training data not to be modified")
+	}
+
+	for _, bad := range []float64{-1, 50} {
+		err := clipped.Configure(DetectorConfig{Parameters: map[string]interface{}{"clipPercentile": bad}})
+		if err == nil {
+			t.Errorf("expected clipPercentile %v to be rejected", bad)
+		}
+	}
+}
+
+func TestStatisticalDetector_DetectScored(t *testing.T) {
+	ctx := context.Background()
+
+	// Values that are not anomalies still report their z-score
+	d := NewStatisticalDetector(2.0, 10.0, 1.0, "test")
+	anomaly, score, scored, err := d.DetectScored(ctx, 10.5)
+	if err != nil || anomaly != nil || !scored || score != 0.5 {
+		t.Errorf("expected a 0.5 score without an anomaly, got %v, %v, %v, %v", anomaly, score, scored, err)
+	}
+	anomaly, score, scored, _ = d.DetectScored(ctx, 20)
+	if anomaly == nil || !scored || score != 10 {
+		t.Errorf("expected an anomaly scored 10, got %v, %v, %v", anomaly, score, scored)
+	}
+
+	// There is no score until the baseline is built
+	d = NewStatisticalDetector(2, 0, 0, "test")
+	if _, _, scored, err := d.DetectScored(ctx, 1000); scored || err != nil {
+		t.Errorf("expected no score during warmup, got scored = %v, err = %v", scored, err)
+	}
+}
@@ -0,0 +1,297 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultEnsembleThreshold flags an anomaly when the children are, on
+	// weighted average, past their own thresholds
+	defaultEnsembleThreshold = 1.0
+	// ensembleScoreCap bounds a child's normalized score so that one extreme
+	// child cannot outvote the weights of all others
+	ensembleScoreCap = 3.0
+)
+
+// EnsembleChild configures a child detector of an ensemble. It is decoded from
+// an entry of config.Parameters["children"].
+type EnsembleChild struct {
+	DetectorConfig
+	// Weight is the child's share of the ensemble score (default 1)
+	Weight float64 `json:"weight"`
+}
+
+// ensembleMember is a child detector with its weight and threshold
+type ensembleMember struct {
+	detector  Detector
+	weight    float64
+	threshold float64
+}
+
+// EnsembleDetector combines child detectors: each child contributes its score
+// normalized by its own threshold, and the value is anomalous when the
+// weighted average of those contributions exceeds the ensemble threshold.
+type EnsembleDetector struct {
+	members   []ensembleMember
+	threshold float64
+	dataType  string
+	mu        sync.RWMutex
+	detectorMetrics
+}
+
+// NewEnsembleDetector creates an ensemble from its children. A non-positive
+// threshold uses the default of 1 (children at their thresholds on average).
+func NewEnsembleDetector(children []EnsembleChild, threshold float64, dataType string) (*EnsembleDetector, error) {
+	if len(children) == 0 {
+		return nil, fmt.Errorf("ensemble requires at least one child detector")
+	}
+	if threshold <= 0 {
+		threshold = defaultEnsembleThreshold
+	}
+
+	members := make([]ensembleMember, 0, len(children))
+	for i, child := range children {
+		member, err := newEnsembleMember(child, dataType)
+		if err != nil {
+			return nil, fmt.Errorf("child %d: %w", i, err)
+		}
+		members = append(members, member)
+	}
+
+	return &EnsembleDetector{
+		members:   members,
+		threshold: threshold,
+		dataType:  dataType,
+	}, nil
+}
+
+// newEnsembleMember creates the detector of one child
+func newEnsembleMember(child EnsembleChild, dataType string) (ensembleMember, error) {
+	switch child.Type {
+	case TypeEnsemble, TypeDeadman:
+		return ensembleMember{}, fmt.Errorf("detector type %s cannot be an ensemble child", child.Type)
+	}
+	if child.Threshold <= 0 {
+		return ensembleMember{}, fmt.Errorf("threshold must be positive")
+	}
+	if child.Weight < 0 {
+		return ensembleMember{}, fmt.Errorf("weight cannot be negative")
+	}
+	if child.Weight == 0 {
+		child.Weight = 1
+	}
+	if err := ValidateParameters(child.Type, child.Parameters); err != nil {
+		return ensembleMember{}, err
+	}
+
+	child.DataType = dataType
+	det, err := NewDetector(child.DetectorConfig)
+	if err != nil {
+		return ensembleMember{}, err
+	}
+	if configurable, ok := det.(ConfigurableDetector); ok && len(child.Parameters) > 0 {
+		if err := configurable.Configure(child.DetectorConfig); err != nil {
+			return ensembleMember{}, err
+		}
+	}
+
+	return ensembleMember{detector: det, weight: child.Weight, threshold: child.Threshold}, nil
+}
+
+// parseEnsembleChildren decodes config.Parameters["children"]
+func parseEnsembleChildren(raw interface{}) ([]EnsembleChild, error) {
+	if raw == nil {
+		return nil, fmt.Errorf("children parameter is required")
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("children: %w", err)
+	}
+
+	var children []EnsembleChild
+	if err := json.Unmarshal(data, &children); err != nil {
+		return nil, fmt.Errorf("children must be a list of detector configurations: %w", err)
+	}
+	return children, nil
+}
+
+// Detect runs every child on the value and combines their scores
+func (d *EnsembleDetector) Detect(ctx context.Context, value float64) (*Anomaly, error) {
+	anomaly, _, _, err := d.DetectScored(ctx, value)
+	return anomaly, err
+}
+
+// DetectScored checks the value like Detect and returns the combined score
+func (d *EnsembleDetector) DetectScored(ctx context.Context, value float64) (*Anomaly, float64, bool, error) {
+	start := time.Now()
+
+	// Children see every value, so those learning from Detect keep learning.
+	// A child without a score yet (e.g. warming up) contributes 0.
+	score, contributions, err := d.combine(func(child Detector) (float64, error) {
+		if scored, ok := child.(ScoredDetector); ok {
+			_, childScore, _, err := scored.DetectScored(ctx, value)
+			return childScore, err
+		}
+
+		anomaly, err := child.Detect(ctx, value)
+		if err != nil {
+			return 0, err
+		}
+		if anomaly != nil {
+			if childScore, ok := anomaly.Details["score"].(float64); ok {
+				return childScore, nil
+			}
+		}
+		_, childScore, err := child.IsAnomaly([]float64{value})
+		return childScore, err
+	})
+	if err != nil {
+		d.recordMetrics(TypeEnsemble, d.dataType, nil, time.Since(start), err)
+		return nil, 0, false, err
+	}
+
+	d.mu.RLock()
+	threshold := d.threshold
+	d.mu.RUnlock()
+
+	if score <= threshold {
+		d.recordMetrics(TypeEnsemble, d.dataType, nil, time.Since(start), nil)
+		return nil, score, true, nil
+	}
+
+	severity := severityFor(score, threshold, criticalScoreFactor)
+	anomaly := &Anomaly{
+		Timestamp: time.Now(),
+		Type:      d.dataType,
+		Severity:  severity,
+		Value:     value,
+		Threshold: threshold,
+		Source:    string(TypeEnsemble),
+		Details: map[string]interface{}{
+			"score":         score,
+			"contributions": contributions,
+		},
+	}
+	d.recordMetrics(TypeEnsemble, d.dataType, anomaly, time.Since(start), nil)
+	return anomaly, score, true, nil
+}
+
+// IsAnomaly combines the children's scores for the last value without
+// updating their state
+func (d *EnsembleDetector) IsAnomaly(values []float64) (bool, float64, error) {
+	if len(values) == 0 {
+		return false, 0, fmt.Errorf("empty values slice")
+	}
+
+	score, _, err := d.combine(func(child Detector) (float64, error) {
+		_, childScore, err := child.IsAnomaly(values)
+		return childScore, err
+	})
+	if err != nil {
+		return false, 0, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return score > d.threshold, score, nil
+}
+
+// combine scores every child and returns the weighted average of their
+// normalized scores along with each child's contribution. Children returning
+// a NaN or infinite score are left out of the average.
+func (d *EnsembleDetector) combine(scoreOf func(Detector) (float64, error)) (float64, []map[string]interface{}, error) {
+	var weightedSum, totalWeight float64
+	contributions := make([]map[string]interface{}, 0, len(d.members))
+
+	for _, member := range d.members {
+		score, err := scoreOf(member.detector)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%s child: %w", member.detector.Type(), err)
+		}
+
+		if math.IsNaN(score) || math.IsInf(score, 0) {
+			contributions = append(contributions, map[string]interface{}{
+				"type":         member.detector.Type(),
+				"weight":       member.weight,
+				"skipped":      "non-finite score",
+				"contribution": 0.0,
+			})
+			continue
+		}
+
+		normalized := math.Min(score/member.threshold, ensembleScoreCap)
+		weightedSum += member.weight * normalized
+		totalWeight += member.weight
+
+		contributions = append(contributions, map[string]interface{}{
+			"type":       member.detector.Type(),
+			"weight":     member.weight,
+			"score":      score,
+			"normalized": normalized,
+			"anomalous":  score > member.threshold,
+		})
+	}
+
+	if totalWeight == 0 {
+		return 0, nil, fmt.Errorf("no child detector returned a finite score")
+	}
+
+	for _, contribution := range contributions {
+		if normalized, ok := contribution["normalized"].(float64); ok {
+			contribution["contribution"] = contribution["weight"].(float64) * normalized / totalWeight
+		}
+	}
+	return weightedSum / totalWeight, contributions, nil
+}
+
+// UpdateThreshold updates the threshold on the weighted score
+func (d *EnsembleDetector) UpdateThreshold(threshold float64) error {
+	if threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.threshold = threshold
+	return nil
+}
+
+// Severity implements SeverityDetector interface
+func (d *EnsembleDetector) Severity(score float64) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return severityFor(score, d.threshold, criticalScoreFactor)
+}
+
+// Type returns the detector type
+func (d *EnsembleDetector) Type() string {
+	return string(TypeEnsemble)
+}
+
+// Train trains every child that supports training
+func (d *EnsembleDetector) Train(values []float64) error {
+	var errs []error
+	for _, member := range d.members {
+		if trainable, ok := member.detector.(TrainableDetector); ok {
+			if err := trainable.Train(values); err != nil {
+				errs = append(errs, fmt.Errorf("%s child: %w", member.detector.Type(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reset clears the learned state of every resettable child
+func (d *EnsembleDetector) Reset() {
+	for _, member := range d.members {
+		if resettable, ok := member.detector.(ResettableDetector); ok {
+			resettable.Reset()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// ensembleConfig decodes parameters as they arrive in an API request
+func ensembleConfig(t *testing.T, threshold float64, children string) DetectorConfig {
+	t.Helper()
+	var parameters map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"children":`+children+`}`), &parameters); err != nil {
+		t.Fatalf("invalid parameters: %v", err)
+	}
+	return DetectorConfig{Type: TypeEnsemble, DataType: "test", Threshold: threshold, Parameters: parameters}
+}
+
+func TestEnsembleDetector_WeightedScore(t *testing.T) {
+	config := ensembleConfig(t, 1.0, `[
+		{"type": "statistical", "threshold": 2, "weight": 3},
+		{"type": "isolation_forest", "threshold": 0.5, "numTrees": 10, "sampleSize": 16}
+	]`)
+	if err := ValidateParameters(TypeEnsemble, config.Parameters); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	det, err := NewDetector(config)
+	if err != nil {
+		t.Fatalf("failed to create ensemble: %v", err)
+	}
+	ensemble := det.(*EnsembleDetector)
+
+	// Statistical baseline: mean 10, stdDev 1
+	if err := ensemble.Train([]float64{9, 11, 9, 11, 9, 11, 9, 11, 9, 11}); err != nil {
+		t.Fatalf("training failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// 13 is a 3 sigma deviation (normalized 1.5) while the isolation forest
+	// scores it at 0.13 (normalized 0.26): (3*1.5 + 0.26) / 4 = 1.19
+	anomaly, err := ensemble.Detect(ctx, 13)
+	if err != nil || anomaly == nil {
+		t.Fatalf("expected the trusted statistical child to carry the anomaly, got %v, %v", anomaly, err)
+	}
+	if score := anomaly.Details["score"].(float64); score < 1.18 || score > 1.2 {
+		t.Errorf("expected a weighted score of 1.19, got %v", score)
+	}
+	contributions := anomaly.Details["contributions"].([]map[string]interface{})
+	if len(contributions) != 2 || contributions[0]["type"] != "statistical" || contributions[0]["anomalous"] != true {
+		t.Fatalf("unexpected contributions: %v", contributions)
+	}
+	if share := contributions[0]["contribution"].(float64); share < 1.12 || share > 1.13 {
+		t.Errorf("expected the statistical child to contribute 1.125, got %v", share)
+	}
+
+	// The same deviation with equal weights stays below the threshold
+	config = ensembleConfig(t, 1.0, `[
+		{"type": "statistical", "threshold": 2},
+		{"type": "isolation_forest", "threshold": 0.5, "numTrees": 10, "sampleSize": 16}
+	]`)
+	det, _ = NewDetector(config)
+	det.(*EnsembleDetector).Train([]float64{9, 11, 9, 11, 9, 11, 9, 11, 9, 11})
+	if anomaly, _ := det.Detect(ctx, 13); anomaly != nil {
+		t.Errorf("expected equal weights to suppress the anomaly, got score %v", anomaly.Details["score"])
+	}
+}
+
+func TestEnsembleDetector_InvalidChildren(t *testing.T) {
+	for name, children := range map[string]string{
+		"empty":             `[]`,
+		"nested ensemble":   `[{"type": "ensemble", "threshold": 1}]`,
+		"deadman":           `[{"type": "deadman", "threshold": 1, "parameters": {"maxGap": "1m"}}]`,
+		"missing threshold": `[{"type": "statistical"}]`,
+		"negative weight":   `[{"type": "statistical", "threshold": 2, "weight": -1}]`,
+		"bad parameter":     `[{"type": "statistical", "threshold": 2, "parameters": {"unknown": 1}}]`,
+	} {
+		if _, err := NewDetector(ensembleConfig(t, 1.0, children)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if _, err := NewDetector(DetectorConfig{Type: TypeEnsemble}); err == nil {
+		t.Error("expected an error without children")
+	}
+	if err := ValidateParameters(TypeEnsemble, map[string]interface{}{"children": "statistical"}); err == nil {
+		t.Error("expected children to be validated as a list")
+	}
+}
+
+// fixedScoreDetector scores every value the same
+type fixedScoreDetector struct {
+	score float64
+}
+
+func (d *fixedScoreDetector) Detect(ctx context.Context, value float64) (*Anomaly, error) {
+	return nil, nil
+}
+
+func (d *fixedScoreDetector) IsAnomaly(values []float64) (bool, float64, error) {
+	return false, d.score, nil
+}
+
+func (d *fixedScoreDetector) UpdateThreshold(threshold float64) error { return nil }
+
+func (d *fixedScoreDetector) Type() string { return "fixed" }
+
+func TestEnsembleDetector_SkipsNonFiniteScores(t *testing.T) {
+	ensemble := &EnsembleDetector{
+		members: []ensembleMember{
+			{detector: &fixedScoreDetector{score: 3}, weight: 1, threshold: 2},
+			{detector: &fixedScoreDetector{score: math.NaN()}, weight: 5, threshold: 1},
+			{detector: &fixedScoreDetector{score: math.Inf(1)}, weight: 5, threshold: 1},
+		},
+		threshold: 1,
+		dataType:  "test",
+	}
+
+	// Only the finite child counts: 3/2 = 1.5
+	anomaly, err := ensemble.Detect(context.Background(), 42)
+	if err != nil || anomaly == nil {
+		t.Fatalf("expected the finite child to carry the anomaly, got %v, %v", anomaly, err)
+	}
+	if score := anomaly.Details["score"].(float64); score != 1.5 {
+		t.Errorf("expected a score of 1.5, got %v", score)
+	}
+	contributions := anomaly.Details["contributions"].([]map[string]interface{})
+	if contributions[0]["contribution"] != 1.5 || contributions[1]["skipped"] == nil || contributions[2]["contribution"] != 0.0 {
+		t.Errorf("unexpected contributions: %v", contributions)
+	}
+	if _, err := json.Marshal(anomaly.Details); err != nil {
+		t.Errorf("expected the details to be encodable, got %v", err)
+	}
+
+	if isAnomaly, score, err := ensemble.IsAnomaly([]float64{42}); err != nil || !isAnomaly || score != 1.5 {
+		t.Errorf("expected IsAnomaly to skip the non-finite children, got %v, %v, %v", isAnomaly, score, err)
+	}
+
+	// Without any finite score there is nothing to combine
+	ensemble.members = ensemble.members[1:]
+	if _, err := ensemble.Detect(context.Background(), 42); err == nil {
+		t.Error("expected an error when no child returns a finite score")
+	}
+}
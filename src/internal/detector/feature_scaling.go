@@ -0,0 +1,107 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+)
+
+// Normalization modes accepted in config.Parameters["normalize"]
+const (
+	NormalizeNone   = "none"
+	NormalizeMinMax = "minmax"
+	NormalizeZScore = "zscore"
+)
+
+// featureScaler rescales inputs with parameters learned from training data:
+// min-max maps the training range to [0, 1], z-score centers on the training
+// mean in units of its standard deviation. Until fitted, values pass through.
+type featureScaler struct {
+	Mode   string  `json:"mode"`
+	Offset float64 `json:"offset"`
+	Scale  float64 `json:"scale"`
+	Fitted bool    `json:"fitted"`
+}
+
+// parseNormalizeMode validates a normalize parameter ("" and "none" disable scaling)
+func parseNormalizeMode(raw interface{}) (string, error) {
+	mode, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("normalize must be a string")
+	}
+	switch mode {
+	case "", NormalizeNone:
+		return "", nil
+	case NormalizeMinMax, NormalizeZScore:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown normalize mode %q (expected %s or %s)", mode, NormalizeMinMax, NormalizeZScore)
+}
+
+// fit learns the scaling parameters from the finite training values
+func (s *featureScaler) fit(values []float64) {
+	if s.Mode == "" {
+		return
+	}
+	values = finiteValues(values)
+	if len(values) == 0 {
+		return
+	}
+
+	switch s.Mode {
+	case NormalizeMinMax:
+		low, high := values[0], values[0]
+		for _, v := range values[1:] {
+			low = math.Min(low, v)
+			high = math.Max(high, v)
+		}
+		s.Offset, s.Scale = low, high-low
+	case NormalizeZScore:
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		mean := sum / float64(len(values))
+		var sumSq float64
+		for _, v := range values {
+			sumSq += (v - mean) * (v - mean)
+		}
+		s.Offset, s.Scale = mean, math.Sqrt(sumSq/float64(len(values)))
+	}
+	s.Fitted = true
+}
+
+// normalizedScoreScale is the deviation from the training data, in standard
+// deviations (z-score) or training ranges (min-max), that scores 0.5
+const normalizedScoreScale = 3.0
+
+// score maps a value to an anomaly score in [0, 1) by how far its scaled value
+// lies outside the training data: its distance from the mean for z-score and
+// from [0, 1] for min-max. Until fitted the raw |value|/100 is the score.
+func (s *featureScaler) score(value float64) (score, scaled float64) {
+	scaled = s.apply(value)
+	if s.Mode == "" || !s.Fitted {
+		return math.Abs(scaled) / 100.0, scaled
+	}
+
+	var deviation float64
+	switch {
+	case s.Mode == NormalizeZScore || s.Scale == 0:
+		deviation = math.Abs(scaled)
+	case scaled < 0:
+		deviation = -scaled
+	case scaled > 1:
+		deviation = scaled - 1
+	}
+	return deviation / (deviation + normalizedScoreScale), scaled
+}
+
+// apply rescales a value; a constant training set only shifts it
+func (s *featureScaler) apply(value float64) float64 {
+	if s.Mode == "" || !s.Fitted {
+		return value
+	}
+	if s.Scale == 0 {
+		return value - s.Offset
+	}
+	return (value - s.Offset) / s.Scale
+}
@@ -0,0 +1,110 @@
+package detector
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestFeatureScaler(t *testing.T) {
+	minmax := featureScaler{Mode: NormalizeMinMax}
+	if got := minmax.apply(7); got != 7 {
+		t.Errorf("expected an unfitted scaler to pass values through, got %v", got)
+	}
+	minmax.fit([]float64{10, 20, math.NaN(), 30})
+	if got := minmax.apply(25); got != 0.75 {
+		t.Errorf("expected min-max to map 25 into [10, 30] as 0.75, got %v", got)
+	}
+
+	zscore := featureScaler{Mode: NormalizeZScore}
+	zscore.fit([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if got := zscore.apply(9); got != 2 {
+		t.Errorf("expected z-score of 9 to be 2, got %v", got)
+	}
+
+	constant := featureScaler{Mode: NormalizeMinMax}
+	constant.fit([]float64{5, 5, 5})
+	if got := constant.apply(6); got != 1 {
+		t.Errorf("expected a constant training set to only shift values, got %v", got)
+	}
+
+	if _, err := parseNormalizeMode("log"); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+	if mode, err := parseNormalizeMode(NormalizeNone); err != nil || mode != "" {
+		t.Errorf("expected none to disable scaling, got %q, %v", mode, err)
+	}
+}
+
+func TestIsolationForestDetector_Normalize(t *testing.T) {
+	det, err := NewDetector(DetectorConfig{
+		Type:       TypeIsolationForest,
+		DataType:   "test",
+		Threshold:  0.5,
+		NumTrees:   10,
+		SampleSize: 16,
+		Parameters: map[string]interface{}{"normalize": NormalizeZScore},
+	})
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+	forest := det.(*IsolationForestDetector)
+
+	// Raw scores are |value|/100, so 1000 is anomalous until normalized
+	if anomalous, _, _ := forest.IsAnomaly([]float64{1000}); !anomalous {
+		t.Fatal("expected the raw value to be anomalous before training")
+	}
+	if err := forest.Train([]float64{900, 1000, 1100}); err != nil {
+		t.Fatalf("train failed: %v", err)
+	}
+	if anomalous, score, _ := forest.IsAnomaly([]float64{1000}); anomalous || score != 0 {
+		t.Errorf("expected the training mean to score 0, got %v (anomalous=%v)", score, anomalous)
+	}
+
+	// Scores are computed on the normalized scale, so outliers are still flagged
+	if anomalous, score, _ := forest.IsAnomaly([]float64{5000}); !anomalous {
+		t.Errorf("expected an obvious outlier to be anomalous after normalization, got score %v", score)
+	}
+	if anomaly, err := forest.Detect(context.Background(), 5000); err != nil || anomaly == nil {
+		t.Errorf("expected Detect to flag the outlier after normalization, got %v, %v", anomaly, err)
+	}
+	if anomalous, score, _ := forest.IsAnomaly([]float64{1050}); anomalous {
+		t.Errorf("expected a value within the training spread to be normal, got score %v", score)
+	}
+
+	state, err := forest.ExportState()
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	restored := NewIsolationForestDetector(10, 16, 0.5, "test")
+	if err := restored.ImportState(state); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	_, want, _ := forest.IsAnomaly([]float64{1200})
+	if _, got, _ := restored.IsAnomaly([]float64{1200}); got != want {
+		t.Errorf("expected the restored scaler to score 1200 as %v, got %v", want, got)
+	}
+
+	minmax := NewIsolationForestDetector(10, 16, 0.5, "test")
+	if err := minmax.SetNormalization(NormalizeMinMax); err != nil {
+		t.Fatalf("failed to enable min-max: %v", err)
+	}
+	if err := minmax.Train([]float64{10, 20, 30}); err != nil {
+		t.Fatalf("train failed: %v", err)
+	}
+	if anomalous, score, _ := minmax.IsAnomaly([]float64{25}); anomalous || score != 0 {
+		t.Errorf("expected a value within the training range to score 0, got %v", score)
+	}
+	if anomalous, score, _ := minmax.IsAnomaly([]float64{1000}); !anomalous {
+		t.Errorf("expected an obvious outlier to be anomalous after min-max scaling, got score %v", score)
+	}
+
+	_, err = NewDetector(DetectorConfig{
+		Type:       TypeIsolationForest,
+		Threshold:  0.5,
+		Parameters: map[string]interface{}{"normalize": "log"},
+	})
+	if err == nil {
+		t.Error("expected an unknown normalize mode to be rejected")
+	}
+}
@@ -48,58 +48,21 @@ func TestIsolationForestDetector_NewIsolationForestDetector(t *testing.T) {
 			if d.dataType != tt.dataType {
 				t.Errorf("dataType = %v, want %v", d.dataType, tt.dataType)
 			}
-			if len(d.trees) != tt.numTrees {
-				t.Errorf("trees length = %v, want %v", len(d.trees), tt.numTrees)
-			}
 		})
 	}
 }
 
-func TestIsolationForestDetector_Training(t *testing.T) {
-	d := NewIsolationForestDetector(10, 5, 0.6, "test")
-	ctx := context.Background()
-
-	// Check initial state
-	if d.IsInitialized() {
-		t.Error("expected uninitialized detector")
-	}
-
-	current, required := d.GetTrainingProgress()
-	if current != 0 || required != 5 {
-		t.Errorf("initial progress: current = %v, required = %v, want 0, 5", current, required)
-	}
-
-	// Feed training data
-	normalValues := []float64{10, 11, 9, 10.5, 10.2}
-	for i, v := range normalValues {
-		_, err := d.Detect(ctx, v)
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
-		}
-
-		current, required = d.GetTrainingProgress()
-		if current != i+1 || required != 5 {
-			t.Errorf("training progress: current = %v, required = %v, want %v, 5", current, required, i+1)
-		}
-	}
-
-	// Check if initialized after training
-	if !d.IsInitialized() {
-		t.Error("expected initialized detector after training")
-	}
-}
-
 func TestIsolationForestDetector_Detect(t *testing.T) {
 	d := NewIsolationForestDetector(100, 10, 0.6, "test")
+	if err := d.SetNormalization(NormalizeZScore); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 	ctx := context.Background()
 
-	// Train with normal data
+	// Train with normal data; scores are deviations from it
 	normalValues := []float64{10, 11, 9, 10.5, 10.2, 10.8, 9.8, 10.3, 10.6, 9.9}
-	for _, v := range normalValues {
-		_, err := d.Detect(ctx, v)
-		if err != nil {
-			t.Fatalf("unexpected error during training: %v", err)
-		}
+	if err := d.Train(normalValues); err != nil {
+		t.Fatalf("unexpected error during training: %v", err)
 	}
 
 	// Test detection
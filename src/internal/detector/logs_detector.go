@@ -2,8 +2,10 @@ package detector
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
+	"regexp/syntax"
 	"strings"
 	"sync"
 	"time"
@@ -11,6 +13,25 @@ import (
 	"github.com/yourusername/aiops-infra/src/internal/types"
 )
 
+// Ограничения шаблонов логов: каждый шаблон проверяется на каждой строке потока,
+// поэтому их количество и сложность ограничивают стоимость анализа
+const (
+	// DefaultMaxPatterns - максимальное количество шаблонов по умолчанию
+	DefaultMaxPatterns = 500
+	// MaxPatternLength - максимальная длина регулярного выражения шаблона
+	MaxPatternLength = 1024
+	// MaxPatternProgramSize - максимальный размер скомпилированной программы
+	// регулярного выражения (например, вложенные повторения (a{100}){100})
+	MaxPatternProgramSize = 5000
+)
+
+var (
+	// ErrInvalidPattern возвращается для некорректных или слишком сложных шаблонов
+	ErrInvalidPattern = errors.New("invalid log pattern")
+	// ErrTooManyPatterns возвращается при превышении лимита количества шаблонов
+	ErrTooManyPatterns = errors.New("too many log patterns")
+)
+
 // LogPattern представляет шаблон сообщения для поиска аномалий
 type LogPattern struct {
 	Pattern     string   // Регулярное выражение для поиска
@@ -19,6 +40,14 @@ type LogPattern struct {
 	Labels      []string // Метки, которые должны присутствовать
 }
 
+// ThresholdOverride задает пороги для потоков с указанными метками.
+// Нулевой порог означает использование глобального значения
+type ThresholdOverride struct {
+	Labels           []string // Метки в формате key=value, все должны совпасть
+	ErrorThreshold   int      // Порог количества ошибок
+	WarningThreshold int      // Порог количества предупреждений
+}
+
 // LogEntry представляет одну запись лога
 type LogEntry struct {
 	Timestamp time.Time
@@ -49,12 +78,20 @@ type LokiCollector interface {
 type LogsAnomalyDetector struct {
 	patterns         []*LogPattern
 	patternRegexps   []*regexp.Regexp
-	errorThreshold   int           // Порог количества ошибок
-	warningThreshold int           // Порог количества предупреждений
-	timeWindow       time.Duration // Временное окно для анализа
+	overrides        []ThresholdOverride // Пороги для отдельных наборов меток
+	errorThreshold   int                 // Порог количества ошибок
+	warningThreshold int                 // Порог количества предупреждений
+	timeWindow       time.Duration       // Временное окно для анализа
 	mu               sync.RWMutex
 	anomalyChan      chan Anomaly
 	lokiCollector    types.LokiCollector // Коллектор логов из Loki
+	recent           *AnomalyBuffer      // Недавние аномалии для API
+
+	// combined - объединение всех шаблонов в одно выражение для быстрого
+	// отсева строк, не совпадающих ни с одним шаблоном
+	combined *regexp.Regexp
+	// maxPatterns - лимит количества шаблонов (0 - без ограничения)
+	maxPatterns int
 }
 
 // NewLogsAnomalyDetector создает новый детектор аномалий для логов
@@ -66,10 +103,12 @@ func NewLogsAnomalyDetector(errorThreshold, warningThreshold int, timeWindow tim
 	return &LogsAnomalyDetector{
 		patterns:         make([]*LogPattern, 0),
 		patternRegexps:   make([]*regexp.Regexp, 0),
+		maxPatterns:      DefaultMaxPatterns,
 		errorThreshold:   errorThreshold,
 		warningThreshold: warningThreshold,
 		timeWindow:       timeWindow,
 		anomalyChan:      make(chan Anomaly, 100),
+		recent:           NewAnomalyBuffer(DefaultAnomalyRetention, DefaultAnomalyBufferSize),
 	}, nil
 }
 
@@ -78,17 +117,52 @@ func (ld *LogsAnomalyDetector) SetLokiCollector(collector types.LokiCollector) {
 	ld.lokiCollector = collector
 }
 
-// AddPattern добавляет шаблон для обнаружения аномалий
+// SetMaxPatterns задает лимит количества шаблонов (0 или меньше - без ограничения).
+// Уже добавленные шаблоны сохраняются, даже если их больше лимита
+func (ld *LogsAnomalyDetector) SetMaxPatterns(maxPatterns int) {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	if maxPatterns < 0 {
+		maxPatterns = 0
+	}
+	ld.maxPatterns = maxPatterns
+}
+
+// GetMaxPatterns возвращает лимит количества шаблонов (0 - без ограничения)
+func (ld *LogsAnomalyDetector) GetMaxPatterns() int {
+	ld.mu.RLock()
+	defer ld.mu.RUnlock()
+	return ld.maxPatterns
+}
+
+// AddPattern добавляет шаблон для обнаружения аномалий.
+// Скомпилированное выражение переиспользуется шаблонами с тем же текстом
 func (ld *LogsAnomalyDetector) AddPattern(pattern, severity, description string, labels []string) error {
-	// Компилируем регулярное выражение
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return fmt.Errorf("ошибка компиляции регулярного выражения: %w", err)
+	if err := validatePattern(pattern); err != nil {
+		return err
 	}
 
 	ld.mu.Lock()
 	defer ld.mu.Unlock()
 
+	if ld.maxPatterns > 0 && len(ld.patterns) >= ld.maxPatterns {
+		return fmt.Errorf("%w: лимит %d шаблонов", ErrTooManyPatterns, ld.maxPatterns)
+	}
+
+	var re *regexp.Regexp
+	for i, existing := range ld.patterns {
+		if existing.Pattern == pattern {
+			re = ld.patternRegexps[i]
+			break
+		}
+	}
+	if re == nil {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%w: ошибка компиляции регулярного выражения: %v", ErrInvalidPattern, err)
+		}
+	}
+
 	ld.patterns = append(ld.patterns, &LogPattern{
 		Pattern:     pattern,
 		Severity:    severity,
@@ -96,48 +170,89 @@ func (ld *LogsAnomalyDetector) AddPattern(pattern, severity, description string,
 		Labels:      labels,
 	})
 	ld.patternRegexps = append(ld.patternRegexps, re)
+	ld.combined = combinePatterns(ld.patterns)
 
 	return nil
 }
 
+// validatePattern отклоняет пустые, слишком длинные и слишком сложные шаблоны.
+// RE2 не использует возврат, поэтому катастрофического перебора нет, но время
+// проверки строки растет с размером программы выражения
+func validatePattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("%w: шаблон не может быть пустым", ErrInvalidPattern)
+	}
+	if len(pattern) > MaxPatternLength {
+		return fmt.Errorf("%w: длина шаблона %d превышает %d", ErrInvalidPattern, len(pattern), MaxPatternLength)
+	}
+
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("%w: ошибка компиляции регулярного выражения: %v", ErrInvalidPattern, err)
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return fmt.Errorf("%w: ошибка компиляции регулярного выражения: %v", ErrInvalidPattern, err)
+	}
+	if len(prog.Inst) > MaxPatternProgramSize {
+		return fmt.Errorf("%w: шаблон слишком сложный (%d инструкций, допустимо %d)",
+			ErrInvalidPattern, len(prog.Inst), MaxPatternProgramSize)
+	}
+	return nil
+}
+
+// combinePatterns объединяет уникальные шаблоны в одно выражение (?:p1)|(?:p2)|...
+// Флаги вроде (?i) внутри группы действуют только в ней, поэтому строка совпадает
+// с объединением тогда и только тогда, когда совпадает хотя бы с одним шаблоном.
+// Возвращает nil, если объединение не компилируется
+func combinePatterns(patterns []*LogPattern) *regexp.Regexp {
+	seen := make(map[string]bool, len(patterns))
+	parts := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if seen[pattern.Pattern] {
+			continue
+		}
+		seen[pattern.Pattern] = true
+		parts = append(parts, "(?:"+pattern.Pattern+")")
+	}
+
+	combined, err := regexp.Compile(strings.Join(parts, "|"))
+	if err != nil {
+		return nil
+	}
+	return combined
+}
+
 // Analyze анализирует поток логов на наличие аномалий
 func (ld *LogsAnomalyDetector) Analyze(stream *types.LogStream) ([]Anomaly, error) {
 	ld.mu.RLock()
 	patterns := ld.patterns
 	regexps := ld.patternRegexps
+	combined := ld.combined
 	ld.mu.RUnlock()
 
 	// Результаты
 	anomalies := make([]Anomaly, 0)
 
+	// Строки, не совпадающие с объединением шаблонов, проверяются один раз,
+	// а не каждым шаблоном
+	candidates := make([]bool, len(stream.Entries))
+	for j, entry := range stream.Entries {
+		candidates[j] = combined == nil || combined.MatchString(entry.Content)
+	}
+
 	// Анализ на основе шаблонов
 	for i, pattern := range patterns {
 		re := regexps[i]
 
 		// Проверяем, что логи имеют нужные метки, если они указаны
-		if len(pattern.Labels) > 0 {
-			labelsMatch := true
-			for _, requiredLabel := range pattern.Labels {
-				parts := strings.SplitN(requiredLabel, "=", 2)
-				if len(parts) != 2 {
-					continue
-				}
-				key, value := parts[0], parts[1]
-
-				actualValue, exists := stream.Labels[key]
-				if !exists || actualValue != value {
-					labelsMatch = false
-					break
-				}
-			}
-			if !labelsMatch {
-				continue
-			}
+		if !matchLabels(pattern.Labels, stream.Labels) {
+			continue
 		}
 
 		// Ищем совпадения по регулярному выражению
-		for _, entry := range stream.Entries {
-			if re.MatchString(entry.Content) {
+		for j, entry := range stream.Entries {
+			if candidates[j] && re.MatchString(entry.Content) {
 				// Создаем аномалию
 				anomaly := Anomaly{
 					Timestamp: entry.Timestamp,
@@ -146,6 +261,7 @@ func (ld *LogsAnomalyDetector) Analyze(stream *types.LogStream) ([]Anomaly, erro
 					Value:     0,
 					Threshold: 0,
 					Source:    "logs",
+					Details:   map[string]interface{}{"labels": stream.Labels},
 				}
 				anomalies = append(anomalies, anomaly)
 
@@ -160,7 +276,97 @@ func (ld *LogsAnomalyDetector) Analyze(stream *types.LogStream) ([]Anomaly, erro
 	}
 
 	// Анализ частоты сообщений определенного уровня
-	return ld.analyzeFrequency(stream, anomalies)
+	anomalies, err := ld.analyzeFrequency(stream, anomalies)
+	if err != nil {
+		return nil, err
+	}
+
+	ld.recent.Add(anomalies...)
+	return anomalies, nil
+}
+
+// matchLabels проверяет, что метки потока содержат все требуемые метки key=value.
+// Метки в неверном формате пропускаются
+func matchLabels(required []string, labels map[string]string) bool {
+	for _, requiredLabel := range required {
+		parts := strings.SplitN(requiredLabel, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := parts[0], parts[1]
+
+		actualValue, exists := labels[key]
+		if !exists || actualValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// AddThresholdOverride добавляет пороги для потоков с указанными метками
+func (ld *LogsAnomalyDetector) AddThresholdOverride(labels []string, errorThreshold, warningThreshold int) error {
+	if len(labels) == 0 {
+		return fmt.Errorf("для переопределения порогов нужна хотя бы одна метка")
+	}
+	for _, label := range labels {
+		if parts := strings.SplitN(label, "=", 2); len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("неверный формат метки %q: ожидается key=value", label)
+		}
+	}
+	if errorThreshold < 0 || warningThreshold < 0 {
+		return fmt.Errorf("пороги не могут быть отрицательными")
+	}
+
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+
+	ld.overrides = append(ld.overrides, ThresholdOverride{
+		Labels:           append([]string(nil), labels...),
+		ErrorThreshold:   errorThreshold,
+		WarningThreshold: warningThreshold,
+	})
+	return nil
+}
+
+// GetThresholdOverrides возвращает переопределения порогов
+func (ld *LogsAnomalyDetector) GetThresholdOverrides() []ThresholdOverride {
+	ld.mu.RLock()
+	defer ld.mu.RUnlock()
+
+	overrides := make([]ThresholdOverride, len(ld.overrides))
+	copy(overrides, ld.overrides)
+	return overrides
+}
+
+// thresholdsFor возвращает пороги ошибок и предупреждений для потока.
+// Из совпавших переопределений выбирается самое точное (с наибольшим числом меток),
+// при равенстве - добавленное первым; иначе используются глобальные пороги
+func (ld *LogsAnomalyDetector) thresholdsFor(labels map[string]string) (errorThreshold, warningThreshold int) {
+	ld.mu.RLock()
+	defer ld.mu.RUnlock()
+
+	errorThreshold, warningThreshold = ld.errorThreshold, ld.warningThreshold
+
+	var best *ThresholdOverride
+	for i := range ld.overrides {
+		override := &ld.overrides[i]
+		if !matchLabels(override.Labels, labels) {
+			continue
+		}
+		if best == nil || len(override.Labels) > len(best.Labels) {
+			best = override
+		}
+	}
+
+	if best != nil {
+		if best.ErrorThreshold > 0 {
+			errorThreshold = best.ErrorThreshold
+		}
+		if best.WarningThreshold > 0 {
+			warningThreshold = best.WarningThreshold
+		}
+	}
+	return errorThreshold, warningThreshold
 }
 
 // analyzeFrequency анализирует частоту сообщений по уровням
@@ -168,6 +374,8 @@ func (ld *LogsAnomalyDetector) analyzeFrequency(stream *types.LogStream, existin
 	anomalies := make([]Anomaly, len(existingAnomalies))
 	copy(anomalies, existingAnomalies)
 
+	errorThreshold, warningThreshold := ld.thresholdsFor(stream.Labels)
+
 	// Сначала фильтруем логи, которые находятся в интересующем нас временном окне
 	now := time.Now()
 	windowStart := now.Add(-ld.timeWindow)
@@ -190,14 +398,15 @@ func (ld *LogsAnomalyDetector) analyzeFrequency(stream *types.LogStream, existin
 	}
 
 	// Проверяем, превышен ли порог ошибок
-	if errorCount >= ld.errorThreshold {
+	if errorCount >= errorThreshold {
 		anomaly := Anomaly{
 			Timestamp: now,
 			Type:      "high_error_rate",
 			Severity:  "high",
 			Value:     float64(errorCount),
-			Threshold: float64(ld.errorThreshold),
+			Threshold: float64(errorThreshold),
 			Source:    "logs",
+			Details:   map[string]interface{}{"labels": stream.Labels},
 		}
 		anomalies = append(anomalies, anomaly)
 
@@ -210,14 +419,15 @@ func (ld *LogsAnomalyDetector) analyzeFrequency(stream *types.LogStream, existin
 	}
 
 	// Проверяем, превышен ли порог предупреждений
-	if warningCount >= ld.warningThreshold {
+	if warningCount >= warningThreshold {
 		anomaly := Anomaly{
 			Timestamp: now,
 			Type:      "high_warning_rate",
 			Severity:  "medium",
 			Value:     float64(warningCount),
-			Threshold: float64(ld.warningThreshold),
+			Threshold: float64(warningThreshold),
 			Source:    "logs",
+			Details:   map[string]interface{}{"labels": stream.Labels},
 		}
 		anomalies = append(anomalies, anomaly)
 
@@ -237,6 +447,26 @@ func (ld *LogsAnomalyDetector) GetAnomalyChan() <-chan Anomaly {
 	return ld.anomalyChan
 }
 
+// GetRecentAnomalies возвращает недавние аномалии от новых к старым (limit <= 0 - все)
+func (ld *LogsAnomalyDetector) GetRecentAnomalies(limit int) []Anomaly {
+	return ld.recent.Recent(limit)
+}
+
+// SetAnomalyRetention задает время хранения и лимит количества недавних аномалий
+func (ld *LogsAnomalyDetector) SetAnomalyRetention(retention time.Duration, maxSize int) {
+	ld.recent.SetRetention(retention, maxSize)
+}
+
+// GetAnomalyRetention возвращает время хранения и лимит количества недавних аномалий
+func (ld *LogsAnomalyDetector) GetAnomalyRetention() (time.Duration, int) {
+	return ld.recent.Retention()
+}
+
+// StartAnomalyPruning запускает фоновую очистку устаревших аномалий
+func (ld *LogsAnomalyDetector) StartAnomalyPruning(ctx context.Context, interval time.Duration) {
+	ld.recent.StartPruning(ctx, interval)
+}
+
 // LogAnomalyHandler обрабатывает поток логов
 func (ld *LogsAnomalyDetector) LogAnomalyHandler(ctx context.Context, stream *types.LogStream) error {
 	anomalies, err := ld.Analyze(stream)
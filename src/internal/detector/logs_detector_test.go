@@ -0,0 +1,137 @@
+package detector
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/types"
+)
+
+func errorStream(labels map[string]string, errors int) *types.LogStream {
+	stream := &types.LogStream{Labels: labels}
+	for i := 0; i < errors; i++ {
+		stream.Entries = append(stream.Entries, types.LogEntry{Timestamp: time.Now(), Content: "boom", Level: "error"})
+	}
+	return stream
+}
+
+func TestLogsAnomalyDetector_ThresholdOverrides(t *testing.T) {
+	ld, err := NewLogsAnomalyDetector(10, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+
+	if err := ld.AddThresholdOverride([]string{"service"}, 1, 0); err == nil {
+		t.Error("expected an error for a label without a value")
+	}
+	if err := ld.AddThresholdOverride([]string{"service=payments"}, 2, 0); err != nil {
+		t.Fatalf("failed to add override: %v", err)
+	}
+	if err := ld.AddThresholdOverride([]string{"service=payments", "env=staging"}, 50, 0); err != nil {
+		t.Fatalf("failed to add override: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		labels    map[string]string
+		errors    int
+		anomalous bool
+	}{
+		{"quiet service uses its override", map[string]string{"service": "payments"}, 2, true},
+		{"most specific override wins", map[string]string{"service": "payments", "env": "staging"}, 2, false},
+		{"other services use the global threshold", map[string]string{"service": "search"}, 2, false},
+		{"global threshold still applies", map[string]string{"service": "search"}, 10, true},
+	}
+
+	for _, tt := range tests {
+		anomalies, err := ld.Analyze(errorStream(tt.labels, tt.errors))
+		if err != nil {
+			t.Fatalf("%s: analyze failed: %v", tt.name, err)
+		}
+
+		found := false
+		for _, anomaly := range anomalies {
+			if anomaly.Type == "high_error_rate" {
+				found = true
+			}
+		}
+		if found != tt.anomalous {
+			t.Errorf("%s: expected high_error_rate = %t, got %t", tt.name, tt.anomalous, found)
+		}
+	}
+
+	// A zero threshold in an override keeps the global value
+	if _, warning := ld.thresholdsFor(map[string]string{"service": "payments"}); warning != 10 {
+		t.Errorf("expected the global warning threshold, got %d", warning)
+	}
+}
+
+func TestLogsAnomalyDetector_PatternLimits(t *testing.T) {
+	ld, err := NewLogsAnomalyDetector(100, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+	ld.SetMaxPatterns(2)
+
+	invalid := []string{"", "(unclosed", strings.Repeat("a", MaxPatternLength+1), "((a{100}){100})"}
+	for _, pattern := range invalid {
+		if err := ld.AddPattern(pattern, "high", "", nil); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("expected pattern %.20q to be rejected as invalid, got %v", pattern, err)
+		}
+	}
+
+	if err := ld.AddPattern("panic", "high", "", nil); err != nil {
+		t.Fatalf("failed to add pattern: %v", err)
+	}
+	if err := ld.AddPattern("panic", "low", "", []string{"env=staging"}); err != nil {
+		t.Fatalf("failed to add pattern: %v", err)
+	}
+	if err := ld.AddPattern("timeout", "medium", "", nil); !errors.Is(err, ErrTooManyPatterns) {
+		t.Errorf("expected ErrTooManyPatterns beyond the limit, got %v", err)
+	}
+	if ld.GetPatternCount() != 2 {
+		t.Errorf("expected 2 patterns, got %d", ld.GetPatternCount())
+	}
+
+	// Identical patterns share the compiled expression
+	if ld.patternRegexps[0] != ld.patternRegexps[1] {
+		t.Error("expected identical patterns to reuse the compiled expression")
+	}
+}
+
+func TestLogsAnomalyDetector_CombinedPatterns(t *testing.T) {
+	ld, err := NewLogsAnomalyDetector(100, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+	// The case-insensitive flag must not leak into the other patterns
+	if err := ld.AddPattern("(?i)panic", "high", "", nil); err != nil {
+		t.Fatalf("failed to add pattern: %v", err)
+	}
+	if err := ld.AddPattern("^OOM", "medium", "", nil); err != nil {
+		t.Fatalf("failed to add pattern: %v", err)
+	}
+
+	stream := &types.LogStream{Entries: []types.LogEntry{
+		{Timestamp: time.Now(), Content: "PANIC: nil map"},
+		{Timestamp: time.Now(), Content: "oom killer invoked"},
+		{Timestamp: time.Now(), Content: "OOM killer invoked"},
+		{Timestamp: time.Now(), Content: "all good"},
+	}}
+	anomalies, err := ld.Analyze(stream)
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+
+	var severities []string
+	for _, anomaly := range anomalies {
+		if anomaly.Type == "log_pattern" {
+			severities = append(severities, anomaly.Severity)
+		}
+	}
+	if len(severities) != 2 || severities[0] != "high" || severities[1] != "medium" {
+		t.Errorf("expected one match per pattern, got %v", severities)
+	}
+}
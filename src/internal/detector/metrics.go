@@ -0,0 +1,67 @@
+package detector
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsDisabled turns off recording to the metrics package collectors
+var metricsDisabled atomic.Bool
+
+// SetMetricsEnabled turns recording of detector metrics on or off. Recording is
+// on by default; embedders that do not expose the metrics package collectors
+// can turn it off.
+func SetMetricsEnabled(enabled bool) {
+	metricsDisabled.Store(!enabled)
+}
+
+// The helpers below record to a collector only when recording is enabled and
+// the collector is set. Label mismatches are dropped rather than panicking as
+// WithLabelValues would: metrics must never break detection.
+
+func incCounter(vec *prometheus.CounterVec, labels ...string) {
+	if vec == nil || metricsDisabled.Load() {
+		return
+	}
+	if counter, err := vec.GetMetricWithLabelValues(labels...); err == nil {
+		counter.Inc()
+	}
+}
+
+func observeHistogram(vec *prometheus.HistogramVec, value float64, labels ...string) {
+	if vec == nil || metricsDisabled.Load() {
+		return
+	}
+	if observer, err := vec.GetMetricWithLabelValues(labels...); err == nil {
+		observer.Observe(value)
+	}
+}
+
+func setGauge(vec *prometheus.GaugeVec, value float64, labels ...string) {
+	if vec == nil || metricsDisabled.Load() {
+		return
+	}
+	if gauge, err := vec.GetMetricWithLabelValues(labels...); err == nil {
+		gauge.Set(value)
+	}
+}
+
+// detectorMetrics is embedded by detectors that record metrics and lets a single
+// detector opt out, see DetectorConfig.DisableMetrics
+type detectorMetrics struct {
+	metricsOff bool
+}
+
+func (m *detectorMetrics) disableMetrics() {
+	m.metricsOff = true
+}
+
+// recordMetrics records a detection unless metrics are off for the detector
+func (m *detectorMetrics) recordMetrics(detectorType DetectorType, dataType string, anomaly *Anomaly, duration time.Duration, err error) {
+	if m.metricsOff {
+		return
+	}
+	recordMetrics(detectorType, dataType, anomaly, duration, err)
+}
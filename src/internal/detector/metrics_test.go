@@ -0,0 +1,79 @@
+package detector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yourusername/aiops-infra/src/internal/metrics"
+)
+
+func TestDetector_WithoutMetrics(t *testing.T) {
+	anomalies, duration, statuses := metrics.AnomalyCounter, metrics.DetectionDuration, metrics.DetectorStatus
+	configUpdates, samples := metrics.ConfigUpdates, metrics.ProcessedSamples
+	defer func() {
+		metrics.AnomalyCounter, metrics.DetectionDuration, metrics.DetectorStatus = anomalies, duration, statuses
+		metrics.ConfigUpdates, metrics.ProcessedSamples = configUpdates, samples
+	}()
+	metrics.AnomalyCounter, metrics.DetectionDuration, metrics.DetectorStatus = nil, nil, nil
+	metrics.ConfigUpdates, metrics.ProcessedSamples = nil, nil
+
+	d, err := NewDetector(DetectorConfig{Type: TypeStatistical, Threshold: 3, DataType: "test"})
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+	for _, value := range []float64{10, 11, 9, 10, 100} {
+		if _, err := d.Detect(context.Background(), value); err != nil {
+			t.Fatalf("detect failed: %v", err)
+		}
+	}
+	if _, err := NewDetector(DetectorConfig{Type: "unknown"}); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+}
+
+func TestMetricHelpers(t *testing.T) {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"type"})
+
+	// A label mismatch is dropped instead of panicking
+	incCounter(counter, "a", "extra")
+
+	incCounter(counter, "a")
+	SetMetricsEnabled(false)
+	incCounter(counter, "a")
+	SetMetricsEnabled(true)
+
+	if value := testutil.ToFloat64(counter.WithLabelValues("a")); value != 1 {
+		t.Errorf("expected 1 recorded increment, got %v", value)
+	}
+}
+
+func TestNewDetector_DisableMetrics(t *testing.T) {
+	const dataType = "disabled_metrics_test"
+	config := ensembleConfig(t, 1.0, `[{"type": "statistical", "threshold": 2}]`)
+	config.DataType = dataType
+	config.DisableMetrics = true
+
+	det, err := NewDetector(config)
+	if err != nil {
+		t.Fatalf("failed to create ensemble: %v", err)
+	}
+	if err := det.(*EnsembleDetector).Train([]float64{9, 11, 9, 11, 9, 11, 9, 11, 9, 11}); err != nil {
+		t.Fatalf("training failed: %v", err)
+	}
+	for _, value := range []float64{10, 100} {
+		if _, err := det.Detect(context.Background(), value); err != nil {
+			t.Fatalf("detect failed: %v", err)
+		}
+	}
+
+	// Neither the ensemble nor its children created a series for the data type
+	for _, detectorType := range []DetectorType{TypeEnsemble, TypeStatistical} {
+		if metrics.ProcessedSamples.DeleteLabelValues(string(detectorType), dataType) ||
+			metrics.DetectorStatus.DeleteLabelValues(string(detectorType), dataType) ||
+			metrics.ConfigUpdates.DeleteLabelValues(string(detectorType), dataType, "success") {
+			t.Errorf("expected no %s metrics for a detector with metrics disabled", detectorType)
+		}
+	}
+}
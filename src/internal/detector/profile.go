@@ -0,0 +1,108 @@
+package detector
+
+import (
+	"fmt"
+)
+
+// Built-in profile names
+const (
+	ProfileSensitive    = "sensitive"
+	ProfileBalanced     = "balanced"
+	ProfileConservative = "conservative"
+)
+
+// ProfileSettings holds the defaults a profile applies to one detector type
+type ProfileSettings struct {
+	Threshold  float64                `json:"threshold" yaml:"threshold"`
+	Parameters map[string]interface{} `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// DetectorProfile is a named set of per-type defaults (threshold and parameters)
+// that a detector request can reference instead of spelling out its full config
+type DetectorProfile struct {
+	Name  string                           `json:"name" yaml:"name"`
+	Types map[DetectorType]ProfileSettings `json:"types" yaml:"types"`
+}
+
+// DefaultProfiles returns the built-in sensitive, balanced and conservative profiles
+func DefaultProfiles() map[string]DetectorProfile {
+	return map[string]DetectorProfile{
+		ProfileSensitive: {
+			Name: ProfileSensitive,
+			Types: map[DetectorType]ProfileSettings{
+				TypeStatistical:     {Threshold: 2.0, Parameters: map[string]interface{}{"minSamples": float64(10)}},
+				TypeWindow:          {Threshold: 2.0},
+				TypeIsolationForest: {Threshold: 0.55},
+			},
+		},
+		ProfileBalanced: {
+			Name: ProfileBalanced,
+			Types: map[DetectorType]ProfileSettings{
+				TypeStatistical:     {Threshold: 3.0, Parameters: map[string]interface{}{"minSamples": float64(20)}},
+				TypeWindow:          {Threshold: 3.0},
+				TypeIsolationForest: {Threshold: 0.6},
+			},
+		},
+		ProfileConservative: {
+			Name: ProfileConservative,
+			Types: map[DetectorType]ProfileSettings{
+				TypeStatistical:     {Threshold: 4.0, Parameters: map[string]interface{}{"minSamples": float64(30)}},
+				TypeWindow:          {Threshold: 4.0},
+				TypeIsolationForest: {Threshold: 0.7},
+			},
+		},
+	}
+}
+
+// Validate checks the profile parameters against the detector type metadata.
+// Integer values (as decoded from YAML) are normalized to float64 first.
+func (p DetectorProfile) Validate() error {
+	for detectorType, settings := range p.Types {
+		for name, value := range settings.Parameters {
+			settings.Parameters[name] = normalizeParameterValue(value)
+		}
+		if err := ValidateParameters(detectorType, settings.Parameters); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// Apply fills the config from the profile settings for config.Type.
+// Fields set in the config override the profile: a non-zero threshold and
+// any parameter present in config.Parameters.
+func (p DetectorProfile) Apply(config DetectorConfig) DetectorConfig {
+	settings, ok := p.Types[config.Type]
+	if !ok {
+		return config
+	}
+
+	if config.Threshold == 0 {
+		config.Threshold = settings.Threshold
+	}
+
+	params := make(map[string]interface{}, len(settings.Parameters)+len(config.Parameters))
+	for name, value := range settings.Parameters {
+		params[name] = value
+	}
+	for name, value := range config.Parameters {
+		params[name] = value
+	}
+	config.Parameters = params
+
+	return config
+}
+
+// normalizeParameterValue converts integer values to float64, matching JSON decoding
+func normalizeParameterValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	default:
+		return value
+	}
+}
@@ -0,0 +1,68 @@
+package detector
+
+import (
+	"testing"
+)
+
+func TestDetectorProfile_Apply(t *testing.T) {
+	profile := DefaultProfiles()[ProfileConservative]
+
+	config := profile.Apply(DetectorConfig{
+		Type:       TypeStatistical,
+		Parameters: map[string]interface{}{"useMAD": true},
+	})
+	if config.Threshold != 4.0 {
+		t.Errorf("expected profile threshold 4.0, got %v", config.Threshold)
+	}
+	if config.Parameters["minSamples"] != float64(30) {
+		t.Errorf("expected profile minSamples 30, got %v", config.Parameters["minSamples"])
+	}
+	if config.Parameters["useMAD"] != true {
+		t.Error("expected request parameter to be kept")
+	}
+
+	// Fields set in the request override the profile
+	config = profile.Apply(DetectorConfig{
+		Type:       TypeStatistical,
+		Threshold:  2.5,
+		Parameters: map[string]interface{}{"minSamples": float64(5)},
+	})
+	if config.Threshold != 2.5 {
+		t.Errorf("expected threshold override 2.5, got %v", config.Threshold)
+	}
+	if config.Parameters["minSamples"] != float64(5) {
+		t.Errorf("expected minSamples override 5, got %v", config.Parameters["minSamples"])
+	}
+
+	// Profile parameters must not leak between applications
+	if profile.Types[TypeStatistical].Parameters["minSamples"] != float64(30) {
+		t.Error("Apply must not modify the profile")
+	}
+}
+
+func TestDetectorProfile_Validate(t *testing.T) {
+	for name, profile := range DefaultProfiles() {
+		if err := profile.Validate(); err != nil {
+			t.Errorf("built-in profile %s is invalid: %v", name, err)
+		}
+	}
+
+	// Integers decoded from YAML are accepted
+	profile := DetectorProfile{
+		Name: "custom",
+		Types: map[DetectorType]ProfileSettings{
+			TypeStatistical: {Threshold: 3, Parameters: map[string]interface{}{"windowSize": 600}},
+		},
+	}
+	if err := profile.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.Types[TypeStatistical].Parameters["windowSize"] != float64(600) {
+		t.Error("expected windowSize to be normalized to float64")
+	}
+
+	profile.Types[TypeStatistical].Parameters["unknown"] = 1.0
+	if err := profile.Validate(); err == nil {
+		t.Error("expected error for unsupported parameter")
+	}
+}
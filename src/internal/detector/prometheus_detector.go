@@ -12,12 +12,14 @@ import (
 
 // PrometheusAnomalyDetector обнаруживает аномалии в метриках Prometheus
 type PrometheusAnomalyDetector struct {
-	collector      *datasource.PrometheusCollector
-	detectors      map[string]Detector
-	alertCallbacks []func(anomaly *AnomalyEvent) error
-	mu             sync.RWMutex
-	anomalyCache   map[string]time.Time
-	cacheTTL       time.Duration
+	collector *datasource.PrometheusCollector
+	detectors map[string]Detector
+	// seriesDetectors - метрики с отдельным детектором на каждый набор меток
+	seriesDetectors map[string]*seriesDetectors
+	alertCallbacks  []func(anomaly *AnomalyEvent) error
+	mu              sync.RWMutex
+	anomalyCache    map[string]time.Time
+	cacheTTL        time.Duration
 }
 
 // AnomalyEvent представляет событие обнаружения аномалии
@@ -27,12 +29,40 @@ type AnomalyEvent struct {
 	Value       float64
 	Labels      map[string]string
 	Score       float64
+	Severity    string // "warning" или "critical" по оценке относительно порога детектора
 	Description string
 	Detector    string
+	Details     map[string]interface{}
+}
+
+// explainAnomaly возвращает контекст обнаружения, если детектор его предоставляет
+func explainAnomaly(detector Detector, value, score float64) map[string]interface{} {
+	details := map[string]interface{}{"score": score}
+	if explainable, ok := detector.(ExplainableDetector); ok {
+		for k, v := range explainable.Explain(value) {
+			details[k] = v
+		}
+		details["score"] = score
+	}
+	return details
+}
+
+// anomalySeverity оценивает серьезность аномалии по ее оценке, если детектор это
+// поддерживает; иначе аномалия считается предупреждением
+func anomalySeverity(detector Detector, score float64) string {
+	if graded, ok := detector.(SeverityDetector); ok {
+		return graded.Severity(score)
+	}
+	return "warning"
 }
 
 // NewPrometheusAnomalyDetector создает новый детектор аномалий Prometheus
 func NewPrometheusAnomalyDetector(promURL string, collectPeriod time.Duration) (*PrometheusAnomalyDetector, error) {
+	return NewPrometheusAnomalyDetectorWithTLS(promURL, collectPeriod, nil)
+}
+
+// NewPrometheusAnomalyDetectorWithTLS создает детектор аномалий Prometheus с настройками TLS (mTLS)
+func NewPrometheusAnomalyDetectorWithTLS(promURL string, collectPeriod time.Duration, tlsConfig *datasource.TLSConfig) (*PrometheusAnomalyDetector, error) {
 	detector := &PrometheusAnomalyDetector{
 		detectors:      make(map[string]Detector),
 		alertCallbacks: make([]func(anomaly *AnomalyEvent) error, 0),
@@ -46,7 +76,7 @@ func NewPrometheusAnomalyDetector(promURL string, collectPeriod time.Duration) (
 	}
 
 	// Инициализируем коллектор метрик
-	collector, err := datasource.NewPrometheusCollector(promURL, collectPeriod, callback)
+	collector, err := datasource.NewPrometheusCollectorWithTLS(promURL, collectPeriod, callback, tlsConfig)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка создания коллектора Prometheus: %w", err)
 	}
@@ -59,6 +89,7 @@ func NewPrometheusAnomalyDetector(promURL string, collectPeriod time.Duration) (
 func (p *PrometheusAnomalyDetector) AddDetector(metricName string, detector Detector) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	delete(p.seriesDetectors, metricName)
 	p.detectors[metricName] = detector
 }
 
@@ -91,17 +122,42 @@ func (p *PrometheusAnomalyDetector) Stop() {
 	p.collector.Stop()
 }
 
+// Ready возвращает ошибку, пока сбор метрик не запущен или Prometheus недоступен
+func (p *PrometheusAnomalyDetector) Ready(ctx context.Context) error {
+	return p.collector.Ready(ctx)
+}
+
+// ProcessMetrics проверяет на аномалии метрики, полученные не от коллектора
+// (например, сброшенные из буфера менеджера источников данных)
+func (p *PrometheusAnomalyDetector) ProcessMetrics(metrics []datasource.MetricResult) {
+	for _, metric := range metrics {
+		if err := p.processMetric(metric.Name, metric.Timestamp, metric.Value, metric.Labels); err != nil {
+			log.Printf("Ошибка обработки метрики %s: %v", metric.Name, err)
+		}
+	}
+}
+
 // processMetric обрабатывает метрику и проверяет на аномалии
 func (p *PrometheusAnomalyDetector) processMetric(metricName string, timestamp time.Time, value float64, labels map[string]string) error {
-	p.mu.RLock()
-	detector, exists := p.detectors[metricName]
-	p.mu.RUnlock()
-
-	if !exists {
+	detector, err := p.detectorFor(metricName, labels)
+	if err != nil {
+		return err
+	}
+	if detector == nil {
 		// Для этой метрики не настроен детектор аномалий
 		return nil
 	}
 
+	// Детектор без базовой линии (например, детектор новой серии) сначала
+	// обучается на поступающих значениях
+	warming, err := warmUp(detector, value)
+	if err != nil {
+		return fmt.Errorf("ошибка обучения детектора для %s: %w", metricName, err)
+	}
+	if warming {
+		return nil
+	}
+
 	// Проверяем, является ли значение аномальным
 	isAnomaly, score, err := detector.IsAnomaly([]float64{value})
 	if err != nil {
@@ -129,8 +185,10 @@ func (p *PrometheusAnomalyDetector) processMetric(metricName string, timestamp t
 				Value:       value,
 				Labels:      labels,
 				Score:       score,
+				Severity:    anomalySeverity(detector, score),
 				Description: fmt.Sprintf("Обнаружена аномалия в метрике %s. Значение: %f, Оценка: %f", metricName, value, score),
 				Detector:    detector.Type(),
+				Details:     explainAnomaly(detector, value, score),
 			}
 
 			// Отправляем оповещения через все зарегистрированные обработчики
@@ -161,21 +219,35 @@ func (p *PrometheusAnomalyDetector) notifyAnomalyCallbacks(anomaly *AnomalyEvent
 	}
 }
 
-// RunAdHocCheck выполняет проверку на аномалии по запросу
-func (p *PrometheusAnomalyDetector) RunAdHocCheck(ctx context.Context, query string, detectorConfig DetectorConfig) ([]*AnomalyEvent, error) {
+// CheckTimings содержит длительность фаз ad-hoc проверки
+type CheckTimings struct {
+	Query  time.Duration // запрос к Prometheus
+	Detect time.Duration // обнаружение аномалий по результатам
+	Total  time.Duration // вся проверка, включая создание детектора
+}
+
+// RunAdHocCheck выполняет проверку на аномалии по запросу и возвращает длительность ее фаз
+func (p *PrometheusAnomalyDetector) RunAdHocCheck(ctx context.Context, query string, detectorConfig DetectorConfig) ([]*AnomalyEvent, CheckTimings, error) {
+	var timings CheckTimings
+	start := time.Now()
+
 	// Создаем детектор для ad-hoc проверки
 	detector, err := NewDetector(detectorConfig)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка создания детектора: %w", err)
+		return nil, timings, fmt.Errorf("ошибка создания детектора: %w", err)
 	}
 
 	// Запрашиваем данные из Prometheus
+	queryStart := time.Now()
 	results, err := p.collector.RunInstantQuery(ctx, query)
+	timings.Query = time.Since(queryStart)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка запроса к Prometheus: %w", err)
+		timings.Total = time.Since(start)
+		return nil, timings, fmt.Errorf("ошибка запроса к Prometheus: %w", err)
 	}
 
 	// Обрабатываем результаты
+	detectStart := time.Now()
 	anomalies := make([]*AnomalyEvent, 0)
 	for _, result := range results {
 		isAnomaly, score, err := detector.IsAnomaly([]float64{result.Value})
@@ -191,14 +263,18 @@ func (p *PrometheusAnomalyDetector) RunAdHocCheck(ctx context.Context, query str
 				Value:       result.Value,
 				Labels:      result.Labels,
 				Score:       score,
+				Severity:    anomalySeverity(detector, score),
 				Description: fmt.Sprintf("Обнаружена аномалия в метрике %s. Значение: %f, Оценка: %f", result.Name, result.Value, score),
 				Detector:    detector.Type(),
+				Details:     explainAnomaly(detector, result.Value, score),
 			}
 			anomalies = append(anomalies, anomalyEvent)
 		}
 	}
+	timings.Detect = time.Since(detectStart)
+	timings.Total = time.Since(start)
 
-	return anomalies, nil
+	return anomalies, timings, nil
 }
 
 // AnalyzeHistoricalData анализирует исторические данные за указанный период
@@ -252,8 +328,10 @@ func (p *PrometheusAnomalyDetector) AnalyzeHistoricalData(ctx context.Context, q
 						Value:       point.Value,
 						Labels:      s.Labels,
 						Score:       score,
+						Severity:    anomalySeverity(detector, score),
 						Description: fmt.Sprintf("Обнаружена историческая аномалия. Значение: %f, Оценка: %f", point.Value, score),
 						Detector:    detector.Type(),
+						Details:     explainAnomaly(detector, point.Value, score),
 					}
 					anomalies = append(anomalies, anomalyEvent)
 				}
@@ -0,0 +1,168 @@
+package detector
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxSeries - лимит числа серий метрики по умолчанию для AddDetectorPerSeries
+const DefaultMaxSeries = 100
+
+// SeriesOptions задает разделение детектора метрики по наборам меток
+type SeriesOptions struct {
+	// Labels - метки, по которым различаются серии (например, path); остальные
+	// метки не создают новых серий. Пустой список - все метки.
+	Labels []string
+	// MaxSeries - максимальное число серий; значения новых серий сверх лимита
+	// проверяет общий детектор. 0 - DefaultMaxSeries.
+	MaxSeries int
+}
+
+// seriesDetectors хранит детекторы серий одной метрики
+type seriesDetectors struct {
+	factory   func() (Detector, error)
+	labels    []string
+	maxSeries int
+	detectors map[string]Detector
+	// overflow - общий детектор серий сверх лимита, создается при первой такой серии
+	overflow       Detector
+	overflowSeries map[string]bool
+}
+
+// AddDetectorPerSeries добавляет детектор для метрики, который ведется отдельно
+// для каждого набора меток: http_requests{path="/a"} и {path="/b"} получают
+// собственные базовые линии. factory создает детектор для новой серии.
+func (p *PrometheusAnomalyDetector) AddDetectorPerSeries(metricName string, factory func() (Detector, error), options SeriesOptions) error {
+	if factory == nil {
+		return fmt.Errorf("не задана фабрика детекторов для %s", metricName)
+	}
+	if options.MaxSeries < 0 {
+		return fmt.Errorf("лимит серий для %s не может быть отрицательным", metricName)
+	}
+	maxSeries := options.MaxSeries
+	if maxSeries == 0 {
+		maxSeries = DefaultMaxSeries
+	}
+
+	labels := append([]string(nil), options.Labels...)
+	sort.Strings(labels)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.seriesDetectors == nil {
+		p.seriesDetectors = make(map[string]*seriesDetectors)
+	}
+	delete(p.detectors, metricName)
+	p.seriesDetectors[metricName] = &seriesDetectors{
+		factory:        factory,
+		labels:         labels,
+		maxSeries:      maxSeries,
+		detectors:      make(map[string]Detector),
+		overflowSeries: make(map[string]bool),
+	}
+	return nil
+}
+
+// SeriesCount возвращает число серий метрики с собственным детектором и число
+// серий сверх лимита, проверяемых общим детектором
+func (p *PrometheusAnomalyDetector) SeriesCount(metricName string) (series, overflow int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	set, exists := p.seriesDetectors[metricName]
+	if !exists {
+		return 0, 0
+	}
+	return len(set.detectors), len(set.overflowSeries)
+}
+
+// detectorFor возвращает детектор для значения метрики с метками, nil - если
+// для метрики детектор не настроен
+func (p *PrometheusAnomalyDetector) detectorFor(metricName string, labels map[string]string) (Detector, error) {
+	p.mu.RLock()
+	set, perSeries := p.seriesDetectors[metricName]
+	if !perSeries {
+		detector := p.detectors[metricName]
+		p.mu.RUnlock()
+		return detector, nil
+	}
+	key := set.key(labels)
+	detector, exists := set.detectors[key]
+	p.mu.RUnlock()
+	if exists {
+		return detector, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Серию мог создать параллельный вызов
+	if detector, exists := set.detectors[key]; exists {
+		return detector, nil
+	}
+
+	if len(set.detectors) >= set.maxSeries {
+		if set.overflow == nil {
+			log.Printf("Достигнут лимит %d серий метрики %s, новые серии проверяются общим детектором", set.maxSeries, metricName)
+			overflow, err := set.factory()
+			if err != nil {
+				return nil, fmt.Errorf("ошибка создания детектора для %s: %w", metricName, err)
+			}
+			set.overflow = overflow
+		}
+		set.overflowSeries[key] = true
+		return set.overflow, nil
+	}
+
+	detector, err := set.factory()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания детектора для %s{%s}: %w", metricName, key, err)
+	}
+	set.detectors[key] = detector
+	return detector, nil
+}
+
+// warmUp обучает детектор на значении, пока он не набрал достаточно данных для
+// обнаружения аномалий, и возвращает true, если значение ушло на обучение.
+// Детекторы без разогрева или обучения проверяют значения сразу.
+func warmUp(detector Detector, value float64) (bool, error) {
+	warmup, ok := detector.(WarmupDetector)
+	if !ok || warmup.IsWarmedUp() {
+		return false, nil
+	}
+	trainable, ok := detector.(TrainableDetector)
+	if !ok {
+		return false, nil
+	}
+
+	// NaN и ±Inf не входят в базовую линию
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return true, nil
+	}
+	return true, trainable.Train([]float64{value})
+}
+
+// key возвращает идентификатор серии по отобранным меткам
+func (s *seriesDetectors) key(labels map[string]string) string {
+	names := s.labels
+	if len(names) == 0 {
+		names = make([]string, 0, len(labels))
+		for name := range labels {
+			if name != "__name__" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		if value, ok := labels[name]; ok {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", name, value))
+		}
+	}
+	return strings.Join(pairs, ",")
+}
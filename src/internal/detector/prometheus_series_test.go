@@ -0,0 +1,138 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// baselineDetector flags values above twice the mean of the values it has seen
+type baselineDetector struct {
+	sum   float64
+	count int
+}
+
+func (d *baselineDetector) Detect(ctx context.Context, value float64) (*Anomaly, error) {
+	return nil, nil
+}
+
+func (d *baselineDetector) UpdateThreshold(threshold float64) error { return nil }
+
+func (d *baselineDetector) IsAnomaly(values []float64) (bool, float64, error) {
+	value := values[0]
+	anomalous := d.count > 0 && value > 2*d.sum/float64(d.count)
+	d.sum += value
+	d.count++
+	return anomalous, value, nil
+}
+
+func (d *baselineDetector) Type() string { return "baseline" }
+
+func newTestPrometheusDetector() (*PrometheusAnomalyDetector, *[]*AnomalyEvent) {
+	p := &PrometheusAnomalyDetector{
+		detectors:    make(map[string]Detector),
+		anomalyCache: make(map[string]time.Time),
+	}
+	var events []*AnomalyEvent
+	p.RegisterAlertCallback(func(anomaly *AnomalyEvent) error {
+		events = append(events, anomaly)
+		return nil
+	})
+	return p, &events
+}
+
+func TestPrometheusDetector_PerSeries(t *testing.T) {
+	newDetector := func() (Detector, error) { return NewStatisticalDetector(3, 0, 0, "requests"), nil }
+
+	p, events := newTestPrometheusDetector()
+	if err := p.AddDetectorPerSeries("http_requests", newDetector, SeriesOptions{Labels: []string{"path"}}); err != nil {
+		t.Fatalf("failed to add detector: %v", err)
+	}
+
+	now := time.Now()
+	process := func(path string, value float64) {
+		labels := map[string]string{"path": path, "instance": "host-1"}
+		if err := p.processMetric("http_requests", now, value, labels); err != nil {
+			t.Fatalf("process failed: %v", err)
+		}
+	}
+
+	// Each new series learns its own baseline from the values it receives
+	for i := 0; i < 20; i++ {
+		process("/a", 10+float64(i%3))
+		process("/b", 100+float64(i%3))
+	}
+	if len(*events) != 0 {
+		t.Fatalf("expected no anomalies within the series baselines, got %d", len(*events))
+	}
+	if series, overflow := p.SeriesCount("http_requests"); series != 2 || overflow != 0 {
+		t.Errorf("expected 2 series, got %d (+%d overflow)", series, overflow)
+	}
+
+	// A value normal for /b is an anomaly for /a
+	process("/b", 101)
+	if len(*events) != 0 {
+		t.Fatalf("expected /b to accept its usual value, got %d anomalies", len(*events))
+	}
+	process("/a", 101)
+	if len(*events) != 1 || (*events)[0].Labels["path"] != "/a" {
+		t.Errorf("expected an anomaly on /a, got %d anomalies", len(*events))
+	}
+}
+
+func TestPrometheusDetector_SeriesCap(t *testing.T) {
+	p, _ := newTestPrometheusDetector()
+	created := 0
+	newDetector := func() (Detector, error) {
+		created++
+		return &baselineDetector{}, nil
+	}
+	if err := p.AddDetectorPerSeries("cpu", newDetector, SeriesOptions{MaxSeries: 2}); err != nil {
+		t.Fatalf("failed to add detector: %v", err)
+	}
+
+	for _, host := range []string{"a", "b", "c", "d", "a"} {
+		if err := p.processMetric("cpu", time.Now(), 1, map[string]string{"host": host}); err != nil {
+			t.Fatalf("process failed: %v", err)
+		}
+	}
+	if series, overflow := p.SeriesCount("cpu"); series != 2 || overflow != 2 {
+		t.Errorf("expected 2 series and 2 overflowing, got %d and %d", series, overflow)
+	}
+	if created != 3 {
+		t.Errorf("expected one shared detector for the overflow, %d detectors created", created)
+	}
+
+	if err := p.AddDetectorPerSeries("cpu", nil, SeriesOptions{}); err == nil {
+		t.Error("expected an error without a factory")
+	}
+}
+
+func TestPrometheusDetector_Severity(t *testing.T) {
+	p, events := newTestPrometheusDetector()
+	p.AddDetector("latency", NewStatisticalDetector(3, 10, 1, "latency"))
+	p.AddDetector("requests", &baselineDetector{count: 1, sum: 1})
+
+	for i, value := range []float64{14, 17} {
+		labels := map[string]string{"instance": fmt.Sprintf("host-%d", i)}
+		if err := p.processMetric("latency", time.Now(), value, labels); err != nil {
+			t.Fatalf("process failed: %v", err)
+		}
+	}
+	if err := p.processMetric("requests", time.Now(), 10, nil); err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+
+	// Scores above twice the threshold are critical; detectors that cannot
+	// grade a score report warnings
+	want := []string{"warning", "critical", "warning"}
+	if len(*events) != len(want) {
+		t.Fatalf("expected %d anomalies, got %d", len(want), len(*events))
+	}
+	for i, event := range *events {
+		if event.Severity != want[i] {
+			t.Errorf("anomaly %d (%s, score %.1f): expected severity %s, got %s", i, event.MetricName, event.Score, want[i], event.Severity)
+		}
+	}
+}
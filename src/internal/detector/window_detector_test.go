@@ -42,14 +42,21 @@ func TestWindowDetector_NewWindowDetector(t *testing.T) {
 			if d.dataType != tt.dataType {
 				t.Errorf("dataType = %v, want %v", d.dataType, tt.dataType)
 			}
-			if len(d.values) != tt.windowSize {
-				t.Errorf("values length = %v, want %v", len(d.values), tt.windowSize)
+			if len(d.values) != 0 || cap(d.values) != tt.windowSize {
+				t.Errorf("values length = %v, capacity = %v, want an empty window of %v", len(d.values), cap(d.values), tt.windowSize)
 			}
 		})
 	}
 }
 
 func TestWindowDetector_Detect(t *testing.T) {
+	// The window includes the checked value, so a window of n values cannot
+	// produce a z-score above sqrt(n-1): 20 values leave room for critical ones
+	history := []float64{10, 11, 9, 10.5, 10.2, 9.8, 10.4, 9.6, 10.1, 10.3, 9.7, 10.6, 9.9, 10.2, 10.0, 9.5, 10.4, 10.1, 9.8}
+	withValue := func(value float64) []float64 {
+		return append(append([]float64{}, history...), value)
+	}
+
 	tests := []struct {
 		name           string
 		windowSize     int
@@ -60,24 +67,24 @@ func TestWindowDetector_Detect(t *testing.T) {
 	}{
 		{
 			name:          "no anomaly",
-			windowSize:    5,
+			windowSize:    20,
 			threshold:     2.0,
-			values:        []float64{10, 11, 9, 10.5, 10.2, 10.8},
+			values:        withValue(10.8),
 			expectAnomaly: false,
 		},
 		{
 			name:           "warning anomaly",
-			windowSize:     5,
+			windowSize:     20,
 			threshold:      2.0,
-			values:         []float64{10, 11, 9, 10.5, 10.2, 15},
+			values:         withValue(12),
 			expectAnomaly:  true,
 			expectSeverity: "warning",
 		},
 		{
 			name:           "critical anomaly",
-			windowSize:     5,
+			windowSize:     20,
 			threshold:      2.0,
-			values:         []float64{10, 11, 9, 10.5, 10.2, 20},
+			values:         withValue(20),
 			expectAnomaly:  true,
 			expectSeverity: "critical",
 		},
@@ -166,14 +173,14 @@ func TestWindowDetector_UpdateThreshold(t *testing.T) {
 	}
 }
 
-func TestWindowDetector_GetWindowStats(t *testing.T) {
+func TestWindowDetector_GetStatistics(t *testing.T) {
 	d := NewWindowDetector(5, 2.0, "test")
 	ctx := context.Background()
 
 	// Test empty window
-	size, filled := d.GetWindowStats()
-	if size != 5 || filled != 0 {
-		t.Errorf("empty window: size = %v, filled = %v, want size = 5, filled = 0", size, filled)
+	stats := d.GetStatistics()
+	if stats["windowSize"] != 5 || stats["windowFill"] != 0 {
+		t.Errorf("empty window: size = %v, filled = %v, want size = 5, filled = 0", stats["windowSize"], stats["windowFill"])
 	}
 
 	// Add some values
@@ -186,9 +193,9 @@ func TestWindowDetector_GetWindowStats(t *testing.T) {
 	}
 
 	// Test partially filled window
-	size, filled = d.GetWindowStats()
-	if size != 5 || filled != 3 {
-		t.Errorf("partially filled window: size = %v, filled = %v, want size = 5, filled = 3", size, filled)
+	stats = d.GetStatistics()
+	if stats["windowSize"] != 5 || stats["windowFill"] != 3 {
+		t.Errorf("partially filled window: size = %v, filled = %v, want size = 5, filled = 3", stats["windowSize"], stats["windowFill"])
 	}
 }
 
@@ -202,3 +209,23 @@ func TestWindowDetector_ContextCancellation(t *testing.T) {
 		t.Error("expected error due to cancelled context, got nil")
 	}
 }
+
+func TestWindowDetector_Reset(t *testing.T) {
+	d := NewWindowDetector(5, 2.0, "test")
+
+	if err := d.Train([]float64{1.0, 2.0, 3.0}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d.Reset()
+
+	if len(d.values) != 0 {
+		t.Errorf("values length after reset = %v, want 0", len(d.values))
+	}
+	if d.windowSize != 5 {
+		t.Errorf("windowSize = %v, want 5", d.windowSize)
+	}
+	if d.threshold != 2.0 {
+		t.Errorf("threshold = %v, want 2.0", d.threshold)
+	}
+}
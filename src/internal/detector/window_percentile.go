@@ -0,0 +1,105 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Window detector modes
+const (
+	// WindowModeZScore flags values whose z-score against the window exceeds the threshold
+	WindowModeZScore = "zscore"
+	// WindowModePercentile flags values above the window's percentile, which
+	// suits skewed distributions such as latencies
+	WindowModePercentile = "percentile"
+)
+
+// defaultWindowPercentile is the percentile used in percentile mode when unset
+const defaultWindowPercentile = 99.0
+
+// parseWindowMode validates the mode and percentile parameters
+func parseWindowMode(params map[string]interface{}, mode string, percentile float64) (string, float64, error) {
+	if v, ok := params["mode"].(string); ok {
+		mode = v
+	}
+	if mode != WindowModeZScore && mode != WindowModePercentile {
+		return "", 0, fmt.Errorf("mode must be %s or %s", WindowModeZScore, WindowModePercentile)
+	}
+	if v, ok := params["percentile"].(float64); ok {
+		percentile = v
+	}
+	if percentile <= 0 || percentile >= 100 {
+		return "", 0, fmt.Errorf("percentile must be between 0 and 100")
+	}
+	return mode, percentile, nil
+}
+
+// percentileCheck is the result of checking a value against the window's percentile
+type percentileCheck struct {
+	cutoff   float64 // the window's percentile
+	median   float64
+	rank     float64 // percentage of window values below the value
+	exceeded bool
+	critical bool
+}
+
+// checkPercentile checks value against the percentile of the sorted window.
+// ok is false when the window holds fewer than two values.
+func checkPercentile(sorted []float64, value, percentile float64) (check percentileCheck, ok bool) {
+	if len(sorted) < 2 {
+		return check, false
+	}
+
+	check.cutoff = percentileOf(sorted, percentile)
+	check.median = percentileOf(sorted, 50)
+	check.rank = 100 * float64(sort.SearchFloat64s(sorted, value)) / float64(len(sorted))
+	check.exceeded = value > check.cutoff
+	// Twice as far above the median as the cut-off, like a z-score of twice the threshold
+	check.critical = check.exceeded && value-check.median > 2*(check.cutoff-check.median)
+	return check, true
+}
+
+// percentileOf returns the p-th percentile of sorted values, interpolating
+// linearly between the closest ranks
+func percentileOf(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower, upper := int(math.Floor(rank)), int(math.Ceil(rank))
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+// sortedCopy returns the values in ascending order without modifying them
+func sortedCopy(values []float64) []float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// percentileAnomaly builds the anomaly reported in percentile mode
+func (d *WindowDetector) percentileAnomaly(value, percentile float64, check percentileCheck, windowFill int) *Anomaly {
+	severity := "warning"
+	if check.critical {
+		severity = "critical"
+	}
+
+	return &Anomaly{
+		Timestamp: time.Now(),
+		Type:      d.dataType,
+		Severity:  severity,
+		Value:     value,
+		Threshold: check.cutoff,
+		Source:    "window",
+		Details: map[string]interface{}{
+			"mode":            WindowModePercentile,
+			"score":           check.rank,
+			"percentile":      percentile,
+			"percentileValue": check.cutoff,
+			"median":          check.median,
+			"deviation":       value - check.median,
+			"windowFill":      windowFill,
+			"windowSize":      d.windowSize,
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package detector
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPercentileOf(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	for _, tc := range []struct{ p, expected float64 }{{50, 3}, {25, 2}, {90, 4.6}} {
+		if got := percentileOf(sorted, tc.p); got < tc.expected-1e-9 || got > tc.expected+1e-9 {
+			t.Errorf("p%v: expected %v, got %v", tc.p, tc.expected, got)
+		}
+	}
+}
+
+func TestWindowDetector_PercentileMode(t *testing.T) {
+	d, err := NewDetector(DetectorConfig{
+		Type:       TypeWindow,
+		WindowSize: 100,
+		Threshold:  3,
+		DataType:   "latency",
+		Parameters: map[string]interface{}{"mode": "percentile", "percentile": 95.0},
+	})
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+	window := d.(*WindowDetector)
+
+	// A skewed latency distribution: mostly fast, with a long tail
+	training := make([]float64, 0, 100)
+	for i := 0; i < 100; i++ {
+		value := 10.0
+		if i%10 == 0 {
+			value = 100 + float64(i)
+		}
+		training = append(training, value)
+	}
+	if err := window.Train(training); err != nil {
+		t.Fatalf("train failed: %v", err)
+	}
+
+	// A tail value within the spread of the window is not flagged, although its
+	// z-score is above the threshold
+	if exceeded, _, _ := window.IsAnomaly([]float64{130}); exceeded {
+		t.Error("expected a tail value below the window's P95 not to be flagged")
+	}
+
+	anomaly, err := window.Detect(context.Background(), 400)
+	if err != nil {
+		t.Fatalf("detect failed: %v", err)
+	}
+	if anomaly == nil {
+		t.Fatal("expected a value above the window's P95 to be flagged")
+	}
+	if anomaly.Details["mode"] != WindowModePercentile || anomaly.Details["score"] != 100.0 || anomaly.Severity != "critical" {
+		t.Errorf("unexpected anomaly: %+v", anomaly)
+	}
+
+	stats := window.GetStatistics()
+	if stats["mode"] != WindowModePercentile || stats["percentile"] != 95.0 {
+		t.Errorf("expected the mode in statistics, got %v", stats)
+	}
+}
+
+func TestWindowDetector_ModeValidation(t *testing.T) {
+	for _, params := range []map[string]interface{}{
+		{"mode": "median"},
+		{"mode": "percentile", "percentile": 100.0},
+		{"mode": "percentile", "targetAnomalyRate": 0.01},
+	} {
+		d := NewWindowDetector(10, 2, "test")
+		if err := d.Configure(DetectorConfig{Parameters: params}); err == nil {
+			t.Errorf("expected an error for %v", params)
+		}
+	}
+
+	if stats := NewWindowDetector(10, 2, "test").GetStatistics(); stats["mode"] != WindowModeZScore {
+		t.Errorf("expected zscore mode by default, got %v", stats["mode"])
+	}
+}
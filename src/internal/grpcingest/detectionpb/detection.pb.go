@@ -0,0 +1,251 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: src/internal/grpcingest/detection.proto
+
+package detectionpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DataPoint struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	DetectorId string                 `protobuf:"bytes,1,opt,name=detector_id,json=detectorId,proto3" json:"detector_id,omitempty"`
+	Value      float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	// Time the value was observed; the time of arrival when unset
+	TimestampUnixNano int64 `protobuf:"varint,3,opt,name=timestamp_unix_nano,json=timestampUnixNano,proto3" json:"timestamp_unix_nano,omitempty"`
+	// Echoed back in the result so clients can correlate responses
+	Sequence      uint64 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataPoint) Reset() {
+	*x = DataPoint{}
+	mi := &file_src_internal_grpcingest_detection_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataPoint) ProtoMessage() {}
+
+func (x *DataPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_src_internal_grpcingest_detection_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataPoint.ProtoReflect.Descriptor instead.
+func (*DataPoint) Descriptor() ([]byte, []int) {
+	return file_src_internal_grpcingest_detection_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DataPoint) GetDetectorId() string {
+	if x != nil {
+		return x.DetectorId
+	}
+	return ""
+}
+
+func (x *DataPoint) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *DataPoint) GetTimestampUnixNano() int64 {
+	if x != nil {
+		return x.TimestampUnixNano
+	}
+	return 0
+}
+
+func (x *DataPoint) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type DetectionResult struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	DetectorId string                 `protobuf:"bytes,1,opt,name=detector_id,json=detectorId,proto3" json:"detector_id,omitempty"`
+	Sequence   uint64                 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	IsAnomaly  bool                   `protobuf:"varint,3,opt,name=is_anomaly,json=isAnomaly,proto3" json:"is_anomaly,omitempty"`
+	Score      float64                `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"`
+	Severity   string                 `protobuf:"bytes,5,opt,name=severity,proto3" json:"severity,omitempty"`
+	// Set when detection failed for this point (unknown detector, paused, ...)
+	Error         string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DetectionResult) Reset() {
+	*x = DetectionResult{}
+	mi := &file_src_internal_grpcingest_detection_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DetectionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DetectionResult) ProtoMessage() {}
+
+func (x *DetectionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_src_internal_grpcingest_detection_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DetectionResult.ProtoReflect.Descriptor instead.
+func (*DetectionResult) Descriptor() ([]byte, []int) {
+	return file_src_internal_grpcingest_detection_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DetectionResult) GetDetectorId() string {
+	if x != nil {
+		return x.DetectorId
+	}
+	return ""
+}
+
+func (x *DetectionResult) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *DetectionResult) GetIsAnomaly() bool {
+	if x != nil {
+		return x.IsAnomaly
+	}
+	return false
+}
+
+func (x *DetectionResult) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *DetectionResult) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *DetectionResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_src_internal_grpcingest_detection_proto protoreflect.FileDescriptor
+
+const file_src_internal_grpcingest_detection_proto_rawDesc = "" +
+	"\n" +
+	"'src/internal/grpcingest/detection.proto\x12\x12aiops.detection.v1\"\x8e\x01\n" +
+	"\tDataPoint\x12\x1f\n" +
+	"\vdetector_id\x18\x01 \x01(\tR\n" +
+	"detectorId\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\x12.\n" +
+	"\x13timestamp_unix_nano\x18\x03 \x01(\x03R\x11timestampUnixNano\x12\x1a\n" +
+	"\bsequence\x18\x04 \x01(\x04R\bsequence\"\xb5\x01\n" +
+	"\x0fDetectionResult\x12\x1f\n" +
+	"\vdetector_id\x18\x01 \x01(\tR\n" +
+	"detectorId\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\x04R\bsequence\x12\x1d\n" +
+	"\n" +
+	"is_anomaly\x18\x03 \x01(\bR\tisAnomaly\x12\x14\n" +
+	"\x05score\x18\x04 \x01(\x01R\x05score\x12\x1a\n" +
+	"\bseverity\x18\x05 \x01(\tR\bseverity\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error2d\n" +
+	"\x10DetectionService\x12P\n" +
+	"\x06Detect\x12\x1d.aiops.detection.v1.DataPoint\x1a#.aiops.detection.v1.DetectionResult(\x010\x01BIZGgithub.com/yourusername/aiops-infra/src/internal/grpcingest/detectionpbb\x06proto3"
+
+var (
+	file_src_internal_grpcingest_detection_proto_rawDescOnce sync.Once
+	file_src_internal_grpcingest_detection_proto_rawDescData []byte
+)
+
+func file_src_internal_grpcingest_detection_proto_rawDescGZIP() []byte {
+	file_src_internal_grpcingest_detection_proto_rawDescOnce.Do(func() {
+		file_src_internal_grpcingest_detection_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_src_internal_grpcingest_detection_proto_rawDesc), len(file_src_internal_grpcingest_detection_proto_rawDesc)))
+	})
+	return file_src_internal_grpcingest_detection_proto_rawDescData
+}
+
+var file_src_internal_grpcingest_detection_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_src_internal_grpcingest_detection_proto_goTypes = []any{
+	(*DataPoint)(nil),       // 0: aiops.detection.v1.DataPoint
+	(*DetectionResult)(nil), // 1: aiops.detection.v1.DetectionResult
+}
+var file_src_internal_grpcingest_detection_proto_depIdxs = []int32{
+	0, // 0: aiops.detection.v1.DetectionService.Detect:input_type -> aiops.detection.v1.DataPoint
+	1, // 1: aiops.detection.v1.DetectionService.Detect:output_type -> aiops.detection.v1.DetectionResult
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_src_internal_grpcingest_detection_proto_init() }
+func file_src_internal_grpcingest_detection_proto_init() {
+	if File_src_internal_grpcingest_detection_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_src_internal_grpcingest_detection_proto_rawDesc), len(file_src_internal_grpcingest_detection_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_src_internal_grpcingest_detection_proto_goTypes,
+		DependencyIndexes: file_src_internal_grpcingest_detection_proto_depIdxs,
+		MessageInfos:      file_src_internal_grpcingest_detection_proto_msgTypes,
+	}.Build()
+	File_src_internal_grpcingest_detection_proto = out.File
+	file_src_internal_grpcingest_detection_proto_goTypes = nil
+	file_src_internal_grpcingest_detection_proto_depIdxs = nil
+}
@@ -0,0 +1,123 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: src/internal/grpcingest/detection.proto
+
+package detectionpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DetectionService_Detect_FullMethodName = "/aiops.detection.v1.DetectionService/Detect"
+)
+
+// DetectionServiceClient is the client API for DetectionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// DetectionService is a high-throughput alternative to POST /api/detectors/:id/detect
+type DetectionServiceClient interface {
+	// Detect streams data points in and detection results out.
+	// Results are returned in the order points are received.
+	Detect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[DataPoint, DetectionResult], error)
+}
+
+type detectionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDetectionServiceClient(cc grpc.ClientConnInterface) DetectionServiceClient {
+	return &detectionServiceClient{cc}
+}
+
+func (c *detectionServiceClient) Detect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[DataPoint, DetectionResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DetectionService_ServiceDesc.Streams[0], DetectionService_Detect_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DataPoint, DetectionResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DetectionService_DetectClient = grpc.BidiStreamingClient[DataPoint, DetectionResult]
+
+// DetectionServiceServer is the server API for DetectionService service.
+// All implementations must embed UnimplementedDetectionServiceServer
+// for forward compatibility.
+//
+// DetectionService is a high-throughput alternative to POST /api/detectors/:id/detect
+type DetectionServiceServer interface {
+	// Detect streams data points in and detection results out.
+	// Results are returned in the order points are received.
+	Detect(grpc.BidiStreamingServer[DataPoint, DetectionResult]) error
+	mustEmbedUnimplementedDetectionServiceServer()
+}
+
+// UnimplementedDetectionServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDetectionServiceServer struct{}
+
+func (UnimplementedDetectionServiceServer) Detect(grpc.BidiStreamingServer[DataPoint, DetectionResult]) error {
+	return status.Errorf(codes.Unimplemented, "method Detect not implemented")
+}
+func (UnimplementedDetectionServiceServer) mustEmbedUnimplementedDetectionServiceServer() {}
+func (UnimplementedDetectionServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeDetectionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DetectionServiceServer will
+// result in compilation errors.
+type UnsafeDetectionServiceServer interface {
+	mustEmbedUnimplementedDetectionServiceServer()
+}
+
+func RegisterDetectionServiceServer(s grpc.ServiceRegistrar, srv DetectionServiceServer) {
+	// If the following call pancis, it indicates UnimplementedDetectionServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DetectionService_ServiceDesc, srv)
+}
+
+func _DetectionService_Detect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DetectionServiceServer).Detect(&grpc.GenericServerStream[DataPoint, DetectionResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DetectionService_DetectServer = grpc.BidiStreamingServer[DataPoint, DetectionResult]
+
+// DetectionService_ServiceDesc is the grpc.ServiceDesc for DetectionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DetectionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "aiops.detection.v1.DetectionService",
+	HandlerType: (*DetectionServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Detect",
+			Handler:       _DetectionService_Detect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "src/internal/grpcingest/detection.proto",
+}
@@ -0,0 +1,106 @@
+// Package grpcingest implements the streaming detection service defined in
+// detection.proto. The generated code in detectionpb is produced with
+// `make proto`.
+package grpcingest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+	"github.com/yourusername/aiops-infra/src/internal/grpcingest/detectionpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// NamespaceMetadataKey selects the tenant namespace of a Detect stream, like the
+// X-Namespace header of the REST API. Streams without it use the default namespace.
+const NamespaceMetadataKey = "x-namespace"
+
+// DetectorBackend runs detections; implemented by api.Server. Detectors are
+// resolved within the namespace, an empty namespace being the default one.
+type DetectorBackend interface {
+	DetectValue(ctx context.Context, namespace, detectorID string, value float64, timestamp time.Time) (*detector.Anomaly, error)
+}
+
+// Service implements DetectionService on top of a DetectorBackend
+type Service struct {
+	detectionpb.UnimplementedDetectionServiceServer
+
+	backend DetectorBackend
+}
+
+// NewService creates a detection service backed by the given detectors
+func NewService(backend DetectorBackend) *Service {
+	return &Service{backend: backend}
+}
+
+// NewServer creates a gRPC server serving the detection service
+func NewServer(backend DetectorBackend, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	detectionpb.RegisterDetectionServiceServer(server, NewService(backend))
+	return server
+}
+
+// Detect handles one Detect stream until the client closes it.
+// Per-point failures are reported in the result and do not end the stream.
+func (s *Service) Detect(stream detectionpb.DetectionService_DetectServer) error {
+	ctx := stream.Context()
+	namespace := namespaceOf(ctx)
+
+	for {
+		point, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(s.detectOne(ctx, namespace, point)); err != nil {
+			return err
+		}
+	}
+}
+
+// namespaceOf returns the namespace sent in the stream metadata, empty without one
+func namespaceOf(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(NamespaceMetadataKey); len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+	}
+	return ""
+}
+
+// detectOne runs detection for a single point of the namespace
+func (s *Service) detectOne(ctx context.Context, namespace string, point *detectionpb.DataPoint) *detectionpb.DetectionResult {
+	result := &detectionpb.DetectionResult{
+		DetectorId: point.GetDetectorId(),
+		Sequence:   point.GetSequence(),
+	}
+
+	var timestamp time.Time
+	if nanos := point.GetTimestampUnixNano(); nanos != 0 {
+		timestamp = time.Unix(0, nanos)
+	}
+
+	anomaly, err := s.backend.DetectValue(ctx, namespace, point.GetDetectorId(), point.GetValue(), timestamp)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if anomaly != nil {
+		result.IsAnomaly = true
+		result.Severity = anomaly.Severity
+		if score, ok := anomaly.Details["score"].(float64); ok {
+			result.Score = score
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,143 @@
+package grpcingest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/detector"
+	"github.com/yourusername/aiops-infra/src/internal/grpcingest/detectionpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeBackend struct {
+	timestamps []time.Time
+	namespaces []string
+}
+
+func (f *fakeBackend) DetectValue(ctx context.Context, namespace, detectorID string, value float64, timestamp time.Time) (*detector.Anomaly, error) {
+	f.timestamps = append(f.timestamps, timestamp)
+	f.namespaces = append(f.namespaces, namespace)
+	if detectorID != "known" {
+		return nil, errors.New("detector not found")
+	}
+	if value > 10 {
+		return &detector.Anomaly{Severity: "high", Details: map[string]interface{}{"score": 4.2}}, nil
+	}
+	return nil, nil
+}
+
+// dial serves the detection service on an in-memory listener
+func dial(t *testing.T, backend DetectorBackend) detectionpb.DetectionServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(backend)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return detectionpb.NewDetectionServiceClient(conn)
+}
+
+func TestServiceDetect(t *testing.T) {
+	backend := &fakeBackend{}
+	client := dial(t, backend)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Detect(ctx)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+
+	observed := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	points := []*detectionpb.DataPoint{
+		{DetectorId: "known", Value: 1, Sequence: 1},
+		{DetectorId: "known", Value: 42, Sequence: 2, TimestampUnixNano: observed.UnixNano()},
+		{DetectorId: "missing", Value: 1, Sequence: 3},
+	}
+	for _, point := range points {
+		if err := stream.Send(point); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("failed to close send: %v", err)
+	}
+
+	var results []*detectionpb.DetectionResult
+	for {
+		result, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		results = append(results, result)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].IsAnomaly || results[0].Error != "" {
+		t.Errorf("expected normal result, got %+v", results[0])
+	}
+	if !results[1].IsAnomaly || results[1].Score != 4.2 || results[1].Sequence != 2 {
+		t.Errorf("expected anomaly with score 4.2 and sequence 2, got %+v", results[1])
+	}
+	if results[2].Error == "" {
+		t.Error("expected per-point error for unknown detector")
+	}
+
+	// Points without a timestamp are detected at arrival time
+	if !backend.timestamps[0].IsZero() || !backend.timestamps[1].Equal(observed) {
+		t.Errorf("expected the point timestamps to be passed on, got %v", backend.timestamps)
+	}
+}
+
+func TestServiceDetect_Namespace(t *testing.T) {
+	backend := &fakeBackend{}
+	client := dial(t, backend)
+
+	for _, namespace := range []string{"", "team-a"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if namespace != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, NamespaceMetadataKey, namespace)
+		}
+		stream, err := client.Detect(ctx)
+		if err != nil {
+			t.Fatalf("failed to open stream: %v", err)
+		}
+		if err := stream.Send(&detectionpb.DataPoint{DetectorId: "known", Value: 1}); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		stream.CloseSend()
+		cancel()
+	}
+
+	// Streams without metadata leave the namespace to the backend's default
+	if len(backend.namespaces) != 2 || backend.namespaces[0] != "" || backend.namespaces[1] != "team-a" {
+		t.Errorf("expected the stream namespaces to be passed on, got %q", backend.namespaces)
+	}
+}
@@ -80,4 +80,32 @@ var (
 		},
 		[]string{"source_type", "operation"},
 	)
+
+	// WebSocketEventsDropped counts WebSocket events dropped because the event queue was full
+	WebSocketEventsDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "aiops_websocket_events_dropped_total",
+			Help: "Total number of WebSocket events dropped due to a full event queue",
+		},
+		[]string{"topic"},
+	)
+
+	// AnomalyWritesDropped counts anomalies that were not persisted, either because
+	// the write-behind queue was full or because the batch write failed
+	AnomalyWritesDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "aiops_anomaly_writes_dropped_total",
+			Help: "Total number of anomalies dropped instead of persisted",
+		},
+		[]string{"reason"},
+	)
+
+	// APIPanics counts panics recovered by the API server, by route template
+	APIPanics = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "aiops_api_panics_total",
+			Help: "Total number of panics recovered while handling API requests",
+		},
+		[]string{"route"},
+	)
 )
@@ -55,6 +55,25 @@ func (h *KubernetesHandler) CanHandle(actionType ActionType) bool {
 	return actionType == ActionRestart || actionType == ActionScale
 }
 
+// Validate checks the required parameters of the action without contacting the cluster
+func (h *KubernetesHandler) Validate(action Action) error {
+	if action.Parameters["resource_type"] == "" || action.Parameters["resource_name"] == "" {
+		return fmt.Errorf("resource_type and resource_name are required parameters")
+	}
+
+	if action.Type == ActionScale {
+		replicas := action.Parameters["replicas"]
+		if replicas == "" {
+			return fmt.Errorf("replicas parameter is required for scale action")
+		}
+		var scale int32
+		if _, err := fmt.Sscanf(replicas, "%d", &scale); err != nil {
+			return fmt.Errorf("invalid replicas value: %s", replicas)
+		}
+	}
+	return nil
+}
+
 // Execute performs the remediation action
 func (h *KubernetesHandler) Execute(ctx context.Context, action Action) (*ActionResult, error) {
 	// Extract common parameters
@@ -0,0 +1,238 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DigestParameter set to "false" on an action sends the notification right
+// away while digest mode is on
+const DigestParameter = "digest"
+
+// DefaultDigestTopMetrics is the number of metrics listed in a digest when unset
+const DefaultDigestTopMetrics = 5
+
+// DigestConfig turns on digest mode: notifications are collected per
+// destination for Window and sent as a single summary
+type DigestConfig struct {
+	// Window is how long notifications are collected; 0 disables digest mode
+	Window time.Duration `json:"window"`
+	// TopMetrics is the number of most frequent metrics listed in the summary
+	TopMetrics int `json:"top_metrics,omitempty"`
+}
+
+// pendingDigest collects the notifications of one destination
+type pendingDigest struct {
+	// action is the first notification, which supplies the destination
+	action     Action
+	count      int
+	severities map[string]int
+	sources    map[string]int
+	metrics    map[string]int
+	targets    map[string]bool
+	first      time.Time
+	last       time.Time
+}
+
+// SetDigest configures digest mode. Notifications already collected are still
+// sent when their window ends.
+func (h *NotificationHandler) SetDigest(config DigestConfig) error {
+	if config.Window < 0 {
+		return fmt.Errorf("digest window must not be negative")
+	}
+	if config.TopMetrics < 0 {
+		return fmt.Errorf("digest top metrics must not be negative")
+	}
+	if config.TopMetrics == 0 {
+		config.TopMetrics = DefaultDigestTopMetrics
+	}
+
+	h.digestMu.Lock()
+	defer h.digestMu.Unlock()
+	h.digestConfig = config
+	return nil
+}
+
+// collectDigest adds a routed notification to the digest of its destination,
+// returning false when it must be sent right away
+func (h *NotificationHandler) collectDigest(action Action) (*ActionResult, bool) {
+	if action.Parameters[DigestParameter] == "false" {
+		return nil, false
+	}
+
+	h.digestMu.Lock()
+	defer h.digestMu.Unlock()
+
+	window := h.digestConfig.Window
+	if window <= 0 {
+		return nil, false
+	}
+
+	key := digestKey(action)
+	pending, exists := h.digests[key]
+	if !exists {
+		if h.digests == nil {
+			h.digests = make(map[string]*pendingDigest)
+		}
+		pending = &pendingDigest{
+			action:     action,
+			severities: make(map[string]int),
+			sources:    make(map[string]int),
+			metrics:    make(map[string]int),
+			targets:    make(map[string]bool),
+			first:      time.Now(),
+		}
+		h.digests[key] = pending
+		time.AfterFunc(window, func() { h.sendDigest(context.Background(), key) })
+	}
+	pending.add(action)
+
+	return &ActionResult{
+		Success:     true,
+		Message:     "Notification added to digest",
+		Details:     fmt.Sprintf("%d notifications pending, digest sent at %s", pending.count, pending.first.Add(window).Format(time.RFC3339)),
+		CompletedAt: time.Now(),
+	}, true
+}
+
+// FlushDigests sends every pending digest now, e.g. on shutdown
+func (h *NotificationHandler) FlushDigests(ctx context.Context) error {
+	h.digestMu.Lock()
+	keys := make([]string, 0, len(h.digests))
+	for key := range h.digests {
+		keys = append(keys, key)
+	}
+	h.digestMu.Unlock()
+
+	var errs []error
+	for _, key := range keys {
+		if err := h.sendDigest(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendDigest sends the summary of the destination's pending digest, if any
+func (h *NotificationHandler) sendDigest(ctx context.Context, key string) error {
+	h.digestMu.Lock()
+	pending, exists := h.digests[key]
+	delete(h.digests, key)
+	topMetrics := h.digestConfig.TopMetrics
+	h.digestMu.Unlock()
+
+	if !exists {
+		return nil
+	}
+
+	_, err := h.Execute(ctx, pending.summary(topMetrics))
+	if err != nil {
+		err = fmt.Errorf("failed to send digest of %d notifications: %w", pending.count, err)
+		log.Printf("%v", err)
+	}
+	return err
+}
+
+// digestKey identifies the destination of a routed notification
+func digestKey(action Action) string {
+	params := action.Parameters
+	notifType, _ := parseNotificationType(params["type"])
+	return strings.Join([]string{string(notifType), params["webhook_url"], params["to_addresses"]}, "|")
+}
+
+// add counts a notification
+func (d *pendingDigest) add(action Action) {
+	params := action.Parameters
+	d.count++
+	d.last = time.Now()
+	d.targets[action.Target] = true
+
+	severity := params["severity"]
+	if severity == "" {
+		severity = params["level"]
+	}
+	d.severities[valueOrUnknown(severity)]++
+	d.sources[valueOrUnknown(params["source"])]++
+
+	metric := params["metric"]
+	if metric == "" {
+		metric = params["alertname"]
+	}
+	if metric == "" {
+		metric = action.Target
+	}
+	d.metrics[valueOrUnknown(metric)]++
+}
+
+// summary builds the digest notification, sent to the destination of the
+// first collected notification
+func (d *pendingDigest) summary(topMetrics int) Action {
+	params := make(map[string]string, len(d.action.Parameters))
+	for k, v := range d.action.Parameters {
+		// Fields describe a single notification
+		if !strings.HasPrefix(k, "field_") {
+			params[k] = v
+		}
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "%d notifications between %s and %s\n",
+		d.count, d.first.Format(time.RFC3339), d.last.Format(time.RFC3339))
+	fmt.Fprintf(&message, "By severity: %s\n", formatCounts(d.severities, 0))
+	fmt.Fprintf(&message, "By source: %s\n", formatCounts(d.sources, 0))
+	fmt.Fprintf(&message, "Top metrics: %s", formatCounts(d.metrics, topMetrics))
+
+	params["subject"] = fmt.Sprintf("AIOps digest: %d notifications", d.count)
+	params["message"] = message.String()
+	params["field_count"] = fmt.Sprintf("%d", d.count)
+	params[DigestParameter] = "false"
+
+	target := d.action.Target
+	if len(d.targets) > 1 {
+		target = fmt.Sprintf("%d targets", len(d.targets))
+	}
+
+	return Action{
+		Type:       ActionNotify,
+		Target:     target,
+		Namespace:  d.action.Namespace,
+		Parameters: params,
+		Timeout:    d.action.Timeout,
+	}
+}
+
+// formatCounts lists counts in descending order ("critical 3, warning 1"),
+// keeping the first limit entries when limit is positive
+func formatCounts(counts map[string]int, limit int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	if limit > 0 && len(names) > limit {
+		names = names[:limit]
+	}
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
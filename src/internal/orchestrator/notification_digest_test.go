@@ -0,0 +1,187 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookRecorder collects the payloads posted to a test webhook
+type webhookRecorder struct {
+	*httptest.Server
+	mu       sync.Mutex
+	payloads []map[string]interface{}
+	received chan struct{}
+}
+
+func newWebhookRecorder(t *testing.T) *webhookRecorder {
+	t.Helper()
+	r := &webhookRecorder{received: make(chan struct{}, 16)}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var payload map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid webhook payload: %v", err)
+		}
+		r.mu.Lock()
+		r.payloads = append(r.payloads, payload)
+		r.mu.Unlock()
+		r.received <- struct{}{}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookRecorder) Payloads() []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]interface{}(nil), r.payloads...)
+}
+
+func notifyAction(target string, params map[string]string) Action {
+	return Action{Type: ActionNotify, Target: target, Parameters: params}
+}
+
+func TestDigest_GroupsByDestination(t *testing.T) {
+	oncall, team := newWebhookRecorder(t), newWebhookRecorder(t)
+
+	h := NewNotificationHandler()
+	if err := h.SetDigest(DigestConfig{Window: time.Hour, TopMetrics: 2}); err != nil {
+		t.Fatalf("SetDigest failed: %v", err)
+	}
+
+	ctx := context.Background()
+	actions := []Action{
+		notifyAction("api", map[string]string{"webhook_url": oncall.URL, "severity": "critical", "source": "prometheus", "metric": "cpu", "field_team": "payments"}),
+		notifyAction("api", map[string]string{"webhook_url": oncall.URL, "severity": "critical", "source": "prometheus", "metric": "cpu"}),
+		notifyAction("worker", map[string]string{"webhook_url": oncall.URL, "level": "warning", "alertname": "latency"}),
+		notifyAction("db", map[string]string{"webhook_url": oncall.URL, "severity": "warning", "source": "loki", "metric": "mem"}),
+		notifyAction("search", map[string]string{"webhook_url": team.URL, "severity": "warning", "metric": "qps"}),
+	}
+	for _, action := range actions {
+		result, err := h.Execute(ctx, action)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if !result.Success || !strings.Contains(result.Message, "digest") {
+			t.Errorf("expected the notification to be collected, got %+v", result)
+		}
+	}
+
+	// Opting out sends right away, without joining the pending digest
+	if _, err := h.Execute(ctx, notifyAction("api", map[string]string{"webhook_url": oncall.URL, DigestParameter: "false"})); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := len(oncall.Payloads()); got != 1 {
+		t.Fatalf("expected only the opted-out notification to be sent, got %d", got)
+	}
+
+	if err := h.FlushDigests(ctx); err != nil {
+		t.Fatalf("FlushDigests failed: %v", err)
+	}
+
+	payloads := oncall.Payloads()
+	if len(payloads) != 2 {
+		t.Fatalf("expected the opted-out notification and one digest, got %d payloads", len(payloads))
+	}
+	digest := payloads[1]
+	if digest["subject"] != "AIOps digest: 4 notifications" || digest["target"] != "3 targets" {
+		t.Errorf("unexpected digest %v", digest)
+	}
+	message, _ := digest["message"].(string)
+	for _, want := range []string{
+		"By severity: critical 2, warning 2",
+		"By source: prometheus 2, loki 1, unknown 1",
+		"Top metrics: cpu 2, latency 1\n",
+	} {
+		if !strings.Contains(message+"\n", want) {
+			t.Errorf("expected the digest message to contain %q, got:\n%s", want, message)
+		}
+	}
+	fields, _ := digest["fields"].(map[string]interface{})
+	if fields["count"] != "4" || fields["team"] != nil {
+		t.Errorf("expected only the count field in the digest, got %v", fields)
+	}
+
+	teamPayloads := team.Payloads()
+	if len(teamPayloads) != 1 || teamPayloads[0]["subject"] != "AIOps digest: 1 notifications" || teamPayloads[0]["target"] != "search" {
+		t.Errorf("expected a separate digest for the team webhook, got %v", teamPayloads)
+	}
+
+	// Flushed digests are not sent again
+	if err := h.FlushDigests(ctx); err != nil {
+		t.Fatalf("FlushDigests failed: %v", err)
+	}
+	if len(oncall.Payloads()) != 2 || len(team.Payloads()) != 1 {
+		t.Error("expected no digests after the flush")
+	}
+}
+
+func TestDigest_SentWhenWindowEnds(t *testing.T) {
+	webhook := newWebhookRecorder(t)
+
+	h := NewNotificationHandler()
+	h.SetDefaultWebhookURL(webhook.URL)
+	if err := h.SetDigest(DigestConfig{Window: 20 * time.Millisecond}); err != nil {
+		t.Fatalf("SetDigest failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := h.Execute(context.Background(), notifyAction("api", map[string]string{"severity": "warning"})); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+	}
+
+	select {
+	case <-webhook.received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the digest to be sent when its window ended")
+	}
+	payloads := webhook.Payloads()
+	if len(payloads) != 1 || payloads[0]["subject"] != "AIOps digest: 3 notifications" {
+		t.Errorf("expected a single digest of 3 notifications, got %v", payloads)
+	}
+}
+
+func TestDigest_Disabled(t *testing.T) {
+	webhook := newWebhookRecorder(t)
+
+	h := NewNotificationHandler()
+	h.SetDefaultWebhookURL(webhook.URL)
+	if _, err := h.Execute(context.Background(), notifyAction("api", map[string]string{"subject": "Disk full"})); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	payloads := webhook.Payloads()
+	if len(payloads) != 1 || payloads[0]["subject"] != "Disk full" {
+		t.Errorf("expected the notification to be sent right away, got %v", payloads)
+	}
+}
+
+func TestSetDigest_Validation(t *testing.T) {
+	h := NewNotificationHandler()
+	if err := h.SetDigest(DigestConfig{Window: -time.Second}); err == nil {
+		t.Error("expected an error for a negative window")
+	}
+	if err := h.SetDigest(DigestConfig{Window: time.Minute, TopMetrics: -1}); err == nil {
+		t.Error("expected an error for negative top metrics")
+	}
+	if err := h.SetDigest(DigestConfig{Window: time.Minute}); err != nil || h.digestConfig.TopMetrics != DefaultDigestTopMetrics {
+		t.Errorf("expected the default top metrics, got %d (%v)", h.digestConfig.TopMetrics, err)
+	}
+}
+
+func TestFormatCounts(t *testing.T) {
+	counts := map[string]int{"mem": 1, "cpu": 3, "disk": 1, "net": 2}
+
+	if got, want := formatCounts(counts, 0), "cpu 3, net 2, disk 1, mem 1"; got != want {
+		t.Errorf("formatCounts() = %q, want %q", got, want)
+	}
+	if got, want := formatCounts(counts, 2), "cpu 3, net 2"; got != want {
+		t.Errorf("formatCounts(limit 2) = %q, want %q", got, want)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/smtp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,15 +25,45 @@ const (
 	NotificationWebhook NotificationType = "webhook"
 )
 
+// Webhook payload formats, selected with the "payload_format" action parameter
+const (
+	// PayloadFormatDefault sends subject, message, target, timestamp and a "fields" object
+	PayloadFormatDefault = "default"
+	// PayloadFormatFlat sends custom fields as top-level keys next to subject and message
+	PayloadFormatFlat = "flat"
+	// PayloadFormatNested sends {"anomaly": {...}, "meta": {...}}
+	PayloadFormatNested = "nested"
+)
+
+// DefaultNotificationTimeout bounds a notification send when the action has no Timeout
+const DefaultNotificationTimeout = 10 * time.Second
+
+// ErrNotificationTimeout is returned when a send does not finish before the
+// action timeout or the caller's context deadline
+var ErrNotificationTimeout = errors.New("notification timed out")
+
 // NotificationHandler handles the sending of notifications
 type NotificationHandler struct {
 	// Default configurations
 	DefaultSlackWebhook string
 	DefaultEmailConfig  EmailConfig
 	DefaultWebhookURL   string
+	// DefaultPayloadFormat is used when an action does not set "payload_format"
+	DefaultPayloadFormat string
+	// DefaultTimeout is used when an action does not set Timeout
+	DefaultTimeout time.Duration
 
 	// HTTP client for making webhook requests
 	httpClient *http.Client
+
+	// routes pick the destination from the notification labels
+	routes []NotificationRoute
+	mu     sync.RWMutex
+
+	// digests collect notifications per destination while digest mode is on
+	digestConfig DigestConfig
+	digests      map[string]*pendingDigest
+	digestMu     sync.Mutex
 }
 
 // EmailConfig contains email configuration
@@ -48,10 +80,10 @@ type EmailConfig struct {
 
 // NewNotificationHandler creates a new notification handler
 func NewNotificationHandler() *NotificationHandler {
+	// The client has no timeout of its own: sends are bounded by the request context
 	return &NotificationHandler{
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		DefaultTimeout: DefaultNotificationTimeout,
+		httpClient:     &http.Client{},
 	}
 }
 
@@ -70,6 +102,15 @@ func (h *NotificationHandler) SetDefaultWebhookURL(webhookURL string) {
 	h.DefaultWebhookURL = webhookURL
 }
 
+// SetDefaultPayloadFormat sets the default webhook payload format
+func (h *NotificationHandler) SetDefaultPayloadFormat(format string) error {
+	if _, err := buildWebhookPayload(format, "", "", "", nil); err != nil {
+		return err
+	}
+	h.DefaultPayloadFormat = format
+	return nil
+}
+
 // CanHandle returns true if this handler can handle the given action type
 func (h *NotificationHandler) CanHandle(actionType ActionType) bool {
 	return actionType == ActionNotify
@@ -77,22 +118,18 @@ func (h *NotificationHandler) CanHandle(actionType ActionType) bool {
 
 // Execute performs the notification action
 func (h *NotificationHandler) Execute(ctx context.Context, action Action) (*ActionResult, error) {
+	// Route by labels before dispatch
+	action, route := h.route(action)
+
 	// Get notification type
-	notifTypeStr := action.Parameters["type"]
-	if notifTypeStr == "" {
-		notifTypeStr = "webhook" // Default to webhook
+	notifType, err := parseNotificationType(action.Parameters["type"])
+	if err != nil {
+		return nil, err
 	}
 
-	var notifType NotificationType
-	switch strings.ToLower(notifTypeStr) {
-	case "slack":
-		notifType = NotificationSlack
-	case "email":
-		notifType = NotificationEmail
-	case "webhook":
-		notifType = NotificationWebhook
-	default:
-		return nil, fmt.Errorf("unsupported notification type: %s", notifTypeStr)
+	// In digest mode the notification is sent later as part of a summary
+	if result, collected := h.collectDigest(action); collected {
+		return result, nil
 	}
 
 	// Get notification content
@@ -106,7 +143,17 @@ func (h *NotificationHandler) Execute(ctx context.Context, action Action) (*Acti
 		message = fmt.Sprintf("Notification triggered for target: %s", action.Target)
 	}
 
-	var err error
+	// Bound the send by the action timeout; the caller's deadline still applies if shorter
+	timeout := action.Timeout
+	if timeout <= 0 {
+		timeout = h.DefaultTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	var details string
 
 	// Send notification based on type
@@ -120,6 +167,9 @@ func (h *NotificationHandler) Execute(ctx context.Context, action Action) (*Acti
 	}
 
 	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded && !errors.Is(err, ErrNotificationTimeout) {
+			err = fmt.Errorf("%w: %v", ErrNotificationTimeout, err)
+		}
 		return &ActionResult{
 			Success:     false,
 			Message:     fmt.Sprintf("Failed to send %s notification", notifType),
@@ -128,6 +178,10 @@ func (h *NotificationHandler) Execute(ctx context.Context, action Action) (*Acti
 		}, err
 	}
 
+	if route >= 0 {
+		details = fmt.Sprintf("%s (notification route %d)", details, route)
+	}
+
 	return &ActionResult{
 		Success:     true,
 		Message:     fmt.Sprintf("Successfully sent %s notification", notifType),
@@ -136,6 +190,58 @@ func (h *NotificationHandler) Execute(ctx context.Context, action Action) (*Acti
 	}, nil
 }
 
+// parseNotificationType parses the "type" action parameter, defaulting to webhook
+func parseNotificationType(notifTypeStr string) (NotificationType, error) {
+	switch strings.ToLower(notifTypeStr) {
+	case "", "webhook":
+		return NotificationWebhook, nil
+	case "slack":
+		return NotificationSlack, nil
+	case "email":
+		return NotificationEmail, nil
+	default:
+		return "", fmt.Errorf("unsupported notification type: %s", notifTypeStr)
+	}
+}
+
+// Validate checks that the notification can be sent with the action's
+// parameters and the handler defaults, without sending anything
+func (h *NotificationHandler) Validate(action Action) error {
+	action, _ = h.route(action)
+	notifType, err := parseNotificationType(action.Parameters["type"])
+	if err != nil {
+		return err
+	}
+
+	switch notifType {
+	case NotificationSlack:
+		if action.Parameters["webhook_url"] == "" && h.DefaultSlackWebhook == "" {
+			return fmt.Errorf("slack webhook URL is required")
+		}
+	case NotificationWebhook:
+		if action.Parameters["webhook_url"] == "" && h.DefaultWebhookURL == "" {
+			return fmt.Errorf("webhook URL is required")
+		}
+		if format := action.Parameters["payload_format"]; format != "" {
+			if _, err := buildWebhookPayload(format, "", "", "", nil); err != nil {
+				return err
+			}
+		}
+	case NotificationEmail:
+		config := h.DefaultEmailConfig
+		if action.Parameters["smtp_server"] == "" && config.SMTPServer == "" {
+			return fmt.Errorf("incomplete email configuration: smtp_server is required")
+		}
+		if action.Parameters["from_address"] == "" && config.FromAddress == "" {
+			return fmt.Errorf("incomplete email configuration: from_address is required")
+		}
+		if action.Parameters["to_addresses"] == "" && len(config.ToAddresses) == 0 {
+			return fmt.Errorf("incomplete email configuration: to_addresses is required")
+		}
+	}
+	return nil
+}
+
 // sendSlackNotification sends a notification to Slack
 func (h *NotificationHandler) sendSlackNotification(ctx context.Context, action Action, subject, message string) (string, error) {
 	webhookURL := action.Parameters["webhook_url"]
@@ -272,9 +378,23 @@ func (h *NotificationHandler) sendEmailNotification(ctx context.Context, action
 		auth = smtp.PlainAuth("", username, password, smtpServer)
 	}
 
-	err := smtp.SendMail(addr, auth, fromAddress, toAddresses, []byte(msg.String()))
-	if err != nil {
-		return "", fmt.Errorf("failed to send email: %w", err)
+	// smtp.SendMail does not accept a context, so run it in the background and stop
+	// waiting once the context is done; a hung server no longer blocks the caller
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, fromAddress, toAddresses, []byte(msg.String()))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return "", fmt.Errorf("failed to send email: %w", err)
+		}
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%w: email to %s", ErrNotificationTimeout, addr)
+		}
+		return "", fmt.Errorf("failed to send email: %w", ctx.Err())
 	}
 
 	return fmt.Sprintf("Email notification sent to %s", strings.Join(toAddresses, ", ")), nil
@@ -291,15 +411,7 @@ func (h *NotificationHandler) sendWebhookNotification(ctx context.Context, actio
 		return "", fmt.Errorf("webhook URL is required")
 	}
 
-	// Prepare payload
-	payload := map[string]interface{}{
-		"subject":   subject,
-		"message":   message,
-		"target":    action.Target,
-		"timestamp": time.Now().Format(time.RFC3339),
-	}
-
-	// Add custom fields if any
+	// Collect custom fields if any
 	customFields := make(map[string]string)
 	for k, v := range action.Parameters {
 		if strings.HasPrefix(k, "field_") {
@@ -308,8 +420,15 @@ func (h *NotificationHandler) sendWebhookNotification(ctx context.Context, actio
 		}
 	}
 
-	if len(customFields) > 0 {
-		payload["fields"] = customFields
+	format := action.Parameters["payload_format"]
+	if format == "" {
+		format = h.DefaultPayloadFormat
+	}
+
+	// Prepare payload
+	payload, err := buildWebhookPayload(format, subject, message, action.Target, customFields)
+	if err != nil {
+		return "", err
 	}
 
 	// Convert payload to JSON
@@ -339,3 +458,53 @@ func (h *NotificationHandler) sendWebhookNotification(ctx context.Context, actio
 
 	return fmt.Sprintf("Webhook notification sent to %s (status code: %d)", webhookURL, resp.StatusCode), nil
 }
+
+// buildWebhookPayload shapes the webhook body according to the payload format
+func buildWebhookPayload(format, subject, message, target string, fields map[string]string) (map[string]interface{}, error) {
+	timestamp := time.Now().Format(time.RFC3339)
+
+	switch format {
+	case "", PayloadFormatDefault:
+		payload := map[string]interface{}{
+			"subject":   subject,
+			"message":   message,
+			"target":    target,
+			"timestamp": timestamp,
+		}
+		if len(fields) > 0 {
+			payload["fields"] = fields
+		}
+		return payload, nil
+
+	case PayloadFormatFlat:
+		payload := make(map[string]interface{}, len(fields)+4)
+		// Custom fields never override the standard keys
+		for k, v := range fields {
+			payload[k] = v
+		}
+		payload["subject"] = subject
+		payload["message"] = message
+		payload["target"] = target
+		payload["timestamp"] = timestamp
+		return payload, nil
+
+	case PayloadFormatNested:
+		anomaly := make(map[string]interface{}, len(fields)+2)
+		for k, v := range fields {
+			anomaly[k] = v
+		}
+		anomaly["subject"] = subject
+		anomaly["message"] = message
+		return map[string]interface{}{
+			"anomaly": anomaly,
+			"meta": map[string]interface{}{
+				"target":    target,
+				"timestamp": timestamp,
+			},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported payload format %q (use %s, %s or %s)",
+			format, PayloadFormatDefault, PayloadFormatFlat, PayloadFormatNested)
+	}
+}
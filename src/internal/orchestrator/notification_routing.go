@@ -0,0 +1,117 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabelParameterPrefix marks action parameters carrying the labels of the
+// anomaly or alert being notified ("label_team" is the team label)
+const LabelParameterPrefix = "label_"
+
+// NotificationRoute sends the notifications whose labels match to a specific
+// destination instead of the handler defaults
+type NotificationRoute struct {
+	// Match lists labels that must all be equal; an empty Match matches every
+	// notification. "severity" and "alertname" also match the action
+	// parameters of the same name.
+	Match map[string]string `json:"match,omitempty"`
+	// Type is the notification type used: slack, email or webhook
+	Type string `json:"type"`
+	// WebhookURL is the Slack or webhook URL
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// ToAddresses are the email recipients
+	ToAddresses []string `json:"to_addresses,omitempty"`
+}
+
+// SetRoutes replaces the notification routes. Routes are evaluated in order
+// and the first match supplies the destination; parameters set on the action
+// itself take precedence.
+func (h *NotificationHandler) SetRoutes(routes []NotificationRoute) error {
+	for i, route := range routes {
+		notifType, err := parseNotificationType(route.Type)
+		if err != nil {
+			return fmt.Errorf("notification route %d: %w", i, err)
+		}
+		for name := range route.Match {
+			if name == "" {
+				return fmt.Errorf("notification route %d: empty label name", i)
+			}
+		}
+		if notifType == NotificationEmail && len(route.ToAddresses) == 0 {
+			return fmt.Errorf("notification route %d: to_addresses is required for email", i)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.routes = make([]NotificationRoute, len(routes))
+	copy(h.routes, routes)
+	return nil
+}
+
+// route applies the first matching route to a copy of the action's parameters.
+// index is -1 when no route matches.
+func (h *NotificationHandler) route(action Action) (Action, int) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.routes) == 0 {
+		return action, -1
+	}
+
+	labels := notificationLabels(action.Parameters)
+	for i, route := range h.routes {
+		if !matchLabels(route.Match, labels) {
+			continue
+		}
+
+		params := make(map[string]string, len(action.Parameters)+2)
+		for k, v := range action.Parameters {
+			params[k] = v
+		}
+		setDefault := func(key, value string) {
+			if params[key] == "" && value != "" {
+				params[key] = value
+			}
+		}
+		setDefault("type", route.Type)
+		if len(route.ToAddresses) > 0 {
+			setDefault("to_addresses", strings.Join(route.ToAddresses, ","))
+		}
+		// The URL is only used if the action kept the route's notification type
+		routeType, _ := parseNotificationType(route.Type)
+		if notifType, _ := parseNotificationType(params["type"]); notifType == routeType {
+			setDefault("webhook_url", route.WebhookURL)
+		}
+		action.Parameters = params
+		return action, i
+	}
+	return action, -1
+}
+
+// notificationLabels collects the labels of a notification from its parameters
+func notificationLabels(params map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for _, name := range []string{"severity", "alertname"} {
+		if v := params[name]; v != "" {
+			labels[name] = v
+		}
+	}
+	for k, v := range params {
+		if strings.HasPrefix(k, LabelParameterPrefix) {
+			labels[strings.TrimPrefix(k, LabelParameterPrefix)] = v
+		}
+	}
+	return labels
+}
+
+// matchLabels reports whether labels contain every matcher
+func matchLabels(match, labels map[string]string) bool {
+	for name, value := range match {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
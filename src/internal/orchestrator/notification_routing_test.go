@@ -0,0 +1,177 @@
+package orchestrator
+
+import "testing"
+
+func newRoutedHandler(t *testing.T, routes []NotificationRoute) *NotificationHandler {
+	t.Helper()
+	h := NewNotificationHandler()
+	if err := h.SetRoutes(routes); err != nil {
+		t.Fatalf("SetRoutes failed: %v", err)
+	}
+	return h
+}
+
+func TestMatchLabels(t *testing.T) {
+	labels := map[string]string{"severity": "critical", "team": "payments"}
+
+	tests := []struct {
+		name  string
+		match map[string]string
+		want  bool
+	}{
+		{name: "empty match", match: nil, want: true},
+		{name: "single label", match: map[string]string{"team": "payments"}, want: true},
+		{name: "all labels", match: map[string]string{"team": "payments", "severity": "critical"}, want: true},
+		{name: "different value", match: map[string]string{"team": "search"}, want: false},
+		{name: "missing label", match: map[string]string{"env": "prod"}, want: false},
+		{name: "one of two differs", match: map[string]string{"team": "payments", "severity": "warning"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchLabels(tt.match, labels); got != tt.want {
+				t.Errorf("matchLabels(%v) = %v, want %v", tt.match, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoute_FirstMatch(t *testing.T) {
+	h := newRoutedHandler(t, []NotificationRoute{
+		{Match: map[string]string{"severity": "critical"}, Type: "webhook", WebhookURL: "https://pager"},
+		{Match: map[string]string{"team": "payments"}, Type: "slack", WebhookURL: "https://slack/payments"},
+		{Type: "email", ToAddresses: []string{"ops@example.com", "sre@example.com"}},
+	})
+
+	tests := []struct {
+		name      string
+		params    map[string]string
+		wantIndex int
+		wantType  string
+		wantURL   string
+		wantTo    string
+	}{
+		{
+			name:      "earlier route wins",
+			params:    map[string]string{"severity": "critical", LabelParameterPrefix + "team": "payments"},
+			wantIndex: 0, wantType: "webhook", wantURL: "https://pager",
+		},
+		{
+			name:      "label parameter",
+			params:    map[string]string{"severity": "warning", LabelParameterPrefix + "team": "payments"},
+			wantIndex: 1, wantType: "slack", wantURL: "https://slack/payments",
+		},
+		{
+			name:      "catch-all route",
+			params:    map[string]string{"severity": "warning"},
+			wantIndex: 2, wantType: "email", wantTo: "ops@example.com,sre@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routed, index := h.route(Action{Type: ActionNotify, Parameters: tt.params})
+			if index != tt.wantIndex {
+				t.Fatalf("expected route %d, got %d", tt.wantIndex, index)
+			}
+			params := routed.Parameters
+			if params["type"] != tt.wantType || params["webhook_url"] != tt.wantURL || params["to_addresses"] != tt.wantTo {
+				t.Errorf("unexpected routed parameters %v", params)
+			}
+		})
+	}
+}
+
+func TestRoute_NoMatch(t *testing.T) {
+	h := newRoutedHandler(t, []NotificationRoute{
+		{Match: map[string]string{"severity": "critical"}, Type: "webhook", WebhookURL: "https://pager"},
+	})
+
+	params := map[string]string{"severity": "warning"}
+	routed, index := h.route(Action{Type: ActionNotify, Parameters: params})
+	if index != -1 {
+		t.Errorf("expected no route, got %d", index)
+	}
+	if len(routed.Parameters) != 1 {
+		t.Errorf("expected the parameters to be unchanged, got %v", routed.Parameters)
+	}
+
+	// Without routes every action keeps the handler defaults
+	if _, index := NewNotificationHandler().route(Action{Parameters: params}); index != -1 {
+		t.Errorf("expected no route without routes, got %d", index)
+	}
+}
+
+func TestRoute_ActionParametersTakePrecedence(t *testing.T) {
+	h := newRoutedHandler(t, []NotificationRoute{
+		{Match: map[string]string{"severity": "critical"}, Type: "slack", WebhookURL: "https://slack/oncall"},
+	})
+
+	tests := []struct {
+		name     string
+		params   map[string]string
+		wantType string
+		wantURL  string
+	}{
+		{
+			name:     "route destination",
+			params:   map[string]string{"severity": "critical"},
+			wantType: "slack", wantURL: "https://slack/oncall",
+		},
+		{
+			name:     "same type keeps the route URL",
+			params:   map[string]string{"severity": "critical", "type": "Slack"},
+			wantType: "Slack", wantURL: "https://slack/oncall",
+		},
+		{
+			name:     "type override drops the route URL",
+			params:   map[string]string{"severity": "critical", "type": "webhook"},
+			wantType: "webhook", wantURL: "",
+		},
+		{
+			name:     "type override keeps the action URL",
+			params:   map[string]string{"severity": "critical", "type": "webhook", "webhook_url": "https://hooks/own"},
+			wantType: "webhook", wantURL: "https://hooks/own",
+		},
+		{
+			name:     "action URL",
+			params:   map[string]string{"severity": "critical", "webhook_url": "https://slack/own"},
+			wantType: "slack", wantURL: "https://slack/own",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paramCount := len(tt.params)
+			routed, index := h.route(Action{Type: ActionNotify, Parameters: tt.params})
+			if index != 0 {
+				t.Fatalf("expected route 0, got %d", index)
+			}
+			if routed.Parameters["type"] != tt.wantType || routed.Parameters["webhook_url"] != tt.wantURL {
+				t.Errorf("expected type %q and URL %q, got %v", tt.wantType, tt.wantURL, routed.Parameters)
+			}
+			if len(tt.params) != paramCount {
+				t.Errorf("expected the action's own parameters to be left untouched, got %v", tt.params)
+			}
+		})
+	}
+}
+
+func TestSetRoutes_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []NotificationRoute
+	}{
+		{name: "unknown type", routes: []NotificationRoute{{Type: "pager"}}},
+		{name: "empty label name", routes: []NotificationRoute{{Match: map[string]string{"": "x"}, Type: "webhook"}}},
+		{name: "email without recipients", routes: []NotificationRoute{{Type: "email"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewNotificationHandler().SetRoutes(tt.routes); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"sync"
 	"time"
+
+	"github.com/yourusername/aiops-infra/src/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ActionType defines the type of remediation action
@@ -26,6 +31,7 @@ const (
 type Action struct {
 	Type        ActionType        `json:"type"`
 	Target      string            `json:"target"`
+	Namespace   string            `json:"namespace,omitempty"`
 	Parameters  map[string]string `json:"parameters,omitempty"`
 	Timeout     time.Duration     `json:"timeout,omitempty"`
 	RetryPolicy *RetryPolicy      `json:"retry_policy,omitempty"`
@@ -58,6 +64,8 @@ type ActionResult struct {
 	Message     string    `json:"message,omitempty"`
 	Details     string    `json:"details,omitempty"`
 	CompletedAt time.Time `json:"completed_at"`
+	// Attempts is the number of times the action was executed, including retries
+	Attempts int `json:"attempts,omitempty"`
 }
 
 // RetryPolicy defines how to retry failed actions
@@ -66,8 +74,28 @@ type RetryPolicy struct {
 	RetryInterval time.Duration `json:"retry_interval"`
 	MaxInterval   time.Duration `json:"max_interval,omitempty"`
 	Multiplier    float64       `json:"multiplier,omitempty"`
+	// Jitter randomizes each retry interval by up to this fraction so that
+	// retries of many actions do not align (0 uses DefaultRetryJitter)
+	Jitter float64 `json:"jitter,omitempty"`
+	// MaxDuration caps the total time spent retrying (0 uses DefaultMaxRetryDuration)
+	MaxDuration time.Duration `json:"max_duration,omitempty"`
 }
 
+const (
+	// DefaultRetryMultiplier is the interval growth factor when Multiplier is unset
+	DefaultRetryMultiplier = 2.0
+	// DefaultRetryJitter is the interval randomization when Jitter is unset
+	DefaultRetryJitter = 0.2
+	// DefaultMaxRetryDuration caps retrying when MaxDuration is unset
+	DefaultMaxRetryDuration = 10 * time.Minute
+	// DefaultActionTimeout bounds each attempt of an action submitted without a timeout
+	DefaultActionTimeout = 5 * time.Minute
+)
+
+// ErrRemediationDisabled is returned for actions submitted while remediation is
+// disabled with SetEnabled (e.g. during a change freeze)
+var ErrRemediationDisabled = errors.New("remediation is disabled")
+
 // ActionHandler defines the interface for components that can execute actions
 type ActionHandler interface {
 	// Execute performs the action and returns the result
@@ -80,14 +108,29 @@ type ActionHandler interface {
 type Orchestrator struct {
 	mu       sync.RWMutex
 	handlers map[ActionType]ActionHandler
-	actions  map[string]Action
+	actions  map[actionKey]Action
+
+	// defaultRetryPolicy applies to actions submitted without a retry policy
+	defaultRetryPolicy *RetryPolicy
+	// defaultTimeout applies to actions submitted without a timeout (0 means none)
+	defaultTimeout time.Duration
+	// disabled rejects every action except notifications
+	disabled bool
+}
+
+// actionKey identifies the latest action on a target within a namespace, so
+// that namespaces acting on targets of the same name keep separate records
+type actionKey struct {
+	namespace string
+	target    string
 }
 
 // NewOrchestrator creates a new orchestrator instance
 func NewOrchestrator() *Orchestrator {
 	return &Orchestrator{
-		handlers: make(map[ActionType]ActionHandler),
-		actions:  make(map[string]Action),
+		handlers:       make(map[ActionType]ActionHandler),
+		actions:        make(map[actionKey]Action),
+		defaultTimeout: DefaultActionTimeout,
 	}
 }
 
@@ -103,10 +146,69 @@ func (o *Orchestrator) RegisterHandler(handler ActionHandler) {
 	}
 }
 
-// ExecuteAction executes a remediation action
+// SetDefaultRetryPolicy sets the retry policy applied to actions submitted
+// without one (nil disables retries for them)
+func (o *Orchestrator) SetDefaultRetryPolicy(policy *RetryPolicy) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.defaultRetryPolicy = policy
+}
+
+// SetDefaultTimeout sets the per-attempt timeout applied to actions submitted
+// without one (0 leaves them unbounded)
+func (o *Orchestrator) SetDefaultTimeout(timeout time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.defaultTimeout = timeout
+}
+
+// SetEnabled turns remediation on or off. While disabled, every action except
+// notifications is rejected with ErrRemediationDisabled; actions already
+// running are not interrupted.
+func (o *Orchestrator) SetEnabled(enabled bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.disabled = !enabled
+}
+
+// Enabled reports whether remediation is enabled
+func (o *Orchestrator) Enabled() bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return !o.disabled
+}
+
+// checkEnabled returns ErrRemediationDisabled for an action that may not run
+// while remediation is disabled
+func (o *Orchestrator) checkEnabled(action Action) error {
+	if o.disabled && action.Type != ActionNotify {
+		return fmt.Errorf("%w: %s action on %s rejected", ErrRemediationDisabled, action.Type, action.Target)
+	}
+	return nil
+}
+
+// ExecuteAction executes a remediation action, retrying failures according to
+// the action's retry policy or the default one
 func (o *Orchestrator) ExecuteAction(ctx context.Context, action Action) (*ActionResult, error) {
+	return o.executeAction(ctx, &action, nil)
+}
+
+// executeAction executes the action, drawing its retries from the plan budget
+// when one is given. The action is updated with its final status and result.
+func (o *Orchestrator) executeAction(ctx context.Context, action *Action, budget *planBudget) (*ActionResult, error) {
 	o.mu.Lock()
+	if err := o.checkEnabled(*action); err != nil {
+		o.mu.Unlock()
+		return nil, err
+	}
 	handler, exists := o.handlers[action.Type]
+	if action.RetryPolicy == nil && o.defaultRetryPolicy != nil {
+		policy := *o.defaultRetryPolicy
+		action.RetryPolicy = &policy
+	}
+	if action.Timeout <= 0 {
+		action.Timeout = o.defaultTimeout
+	}
 	o.mu.Unlock()
 
 	if !exists {
@@ -118,102 +220,241 @@ func (o *Orchestrator) ExecuteAction(ctx context.Context, action Action) (*Actio
 	action.CreatedAt = time.Now()
 	action.UpdatedAt = time.Now()
 
-	o.updateAction(action)
+	o.updateAction(*action)
 
-	// Create a timeout context if needed
-	execCtx := ctx
-	if action.Timeout > 0 {
-		var cancel context.CancelFunc
-		execCtx, cancel = context.WithTimeout(ctx, action.Timeout)
-		defer cancel()
-	}
-
-	result, err := handler.Execute(execCtx, action)
+	ctx, span := tracing.Tracer().Start(ctx, "orchestrator.ExecuteAction",
+		trace.WithAttributes(
+			attribute.String("action.type", string(action.Type)),
+			attribute.String("action.target", action.Target),
+			attribute.String("action.namespace", action.Namespace)))
+	result, attempts, err := executeWithRetry(ctx, handler, *action, budget)
+	span.SetAttributes(attribute.Int("action.attempts", attempts))
+	tracing.RecordError(span, err)
+	span.End()
 
 	// Update action with result
 	action.UpdatedAt = time.Now()
 	if err != nil {
+		if attempts > 1 {
+			err = fmt.Errorf("%w (after %d attempts)", err, attempts)
+		}
 		action.Status = StatusFailed
 		action.Result = &ActionResult{
 			Success:     false,
 			Message:     err.Error(),
 			CompletedAt: time.Now(),
+			Attempts:    attempts,
 		}
 	} else {
+		if result != nil {
+			result.Attempts = attempts
+		}
 		action.Status = StatusSucceeded
 		action.Result = result
 	}
 
-	o.updateAction(action)
+	o.updateAction(*action)
 
 	return result, err
 }
 
-// ExecuteActionPlan executes a sequence of actions with dependency handling
-func (o *Orchestrator) ExecuteActionPlan(ctx context.Context, actions []Action) error {
+// executeWithRetry executes the action until it succeeds, the retries of its
+// policy are used up, the total retry duration or the plan budget would be
+// exceeded or ctx is done. The action timeout applies to each attempt.
+func executeWithRetry(ctx context.Context, handler ActionHandler, action Action, budget *planBudget) (*ActionResult, int, error) {
+	policy := action.RetryPolicy
+	started := time.Now()
+	interval := time.Duration(0)
+	if policy != nil {
+		interval = policy.RetryInterval
+	}
+
+	for attempt := 1; ; attempt++ {
+		result, err := executeOnce(ctx, handler, action)
+		if err == nil || policy == nil || attempt > policy.MaxRetries || ctx.Err() != nil {
+			return result, attempt, err
+		}
+
+		delay := jitterInterval(interval, policy)
+		maxDuration := policy.MaxDuration
+		if maxDuration <= 0 {
+			maxDuration = DefaultMaxRetryDuration
+		}
+		if time.Since(started)+delay > maxDuration {
+			return result, attempt, fmt.Errorf("%w (retry duration limit %s reached)", err, maxDuration)
+		}
+		if budgetErr := budget.takeRetry(delay); budgetErr != nil {
+			return result, attempt, fmt.Errorf("%w (%w)", err, budgetErr)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, attempt, err
+		case <-timer.C:
+		}
+
+		interval = nextRetryInterval(interval, policy)
+	}
+}
+
+// executeOnce runs a single attempt of the action within its timeout
+func executeOnce(ctx context.Context, handler ActionHandler, action Action) (*ActionResult, error) {
+	if action.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, action.Timeout)
+		defer cancel()
+	}
+	return handler.Execute(ctx, action)
+}
+
+// nextRetryInterval grows the interval by the policy multiplier, capped by MaxInterval
+func nextRetryInterval(interval time.Duration, policy *RetryPolicy) time.Duration {
+	multiplier := policy.Multiplier
+	if multiplier <= 0 {
+		multiplier = DefaultRetryMultiplier
+	}
+
+	next := time.Duration(float64(interval) * multiplier)
+	if policy.MaxInterval > 0 && next > policy.MaxInterval {
+		next = policy.MaxInterval
+	}
+	return next
+}
+
+// jitterInterval randomizes the interval by up to the policy's jitter fraction
+// in either direction, without exceeding MaxInterval
+func jitterInterval(interval time.Duration, policy *RetryPolicy) time.Duration {
+	jitter := policy.Jitter
+	if jitter <= 0 {
+		jitter = DefaultRetryJitter
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	delay := time.Duration(float64(interval) * (1 + jitter*(2*rand.Float64()-1)))
+	if policy.MaxInterval > 0 && delay > policy.MaxInterval {
+		delay = policy.MaxInterval
+	}
+	return delay
+}
+
+// ExecuteActionPlan executes a sequence of actions with dependency handling.
+// It returns the plan's actions in order with their final status and result;
+// actions that did not run are left pending.
+func (o *Orchestrator) ExecuteActionPlan(ctx context.Context, actions []Action) ([]Action, error) {
+	return o.ExecuteActionPlanWithBudget(ctx, actions, PlanBudget{})
+}
+
+// ExecuteActionPlanWithBudget executes the plan like ExecuteActionPlan, aborting
+// it with ErrPlanBudgetExceeded once its deadline passes or its actions have
+// used up the retry budget together
+func (o *Orchestrator) ExecuteActionPlanWithBudget(ctx context.Context, actions []Action, limits PlanBudget) ([]Action, error) {
 	if len(actions) == 0 {
-		return errors.New("empty action plan")
+		return nil, errors.New("empty action plan")
 	}
 
-	// Build dependency graph
-	dependencyGraph := make(map[string][]string)
-	actionMap := make(map[string]Action)
+	// Each step is reported on its own, even when several act on one target
+	steps := make([]Action, len(actions))
+	stepsByTarget := make(map[string][]int)
+	for i, action := range actions {
+		action.Status = StatusPending
+		action.Result = nil
+		steps[i] = action
+		stepsByTarget[action.Target] = append(stepsByTarget[action.Target], i)
+	}
+
+	if err := limits.Validate(); err != nil {
+		return steps, err
+	}
 
+	// Reject the whole plan up front rather than running only part of it
+	o.mu.RLock()
 	for _, action := range actions {
-		actionID := action.Target
-		actionMap[actionID] = action
-		dependencyGraph[actionID] = action.DependsOn
+		if err := o.checkEnabled(action); err != nil {
+			o.mu.RUnlock()
+			return steps, err
+		}
 	}
+	o.mu.RUnlock()
 
-	// Execute actions in dependency order
-	executed := make(map[string]bool)
+	budget := newPlanBudget(limits)
+	if limits.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Deadline)
+		defer cancel()
+	}
 
-	var executeWithDeps func(string) error
-	executeWithDeps = func(actionID string) error {
-		// Skip if already executed
-		if executed[actionID] {
+	// Execute actions in dependency order; a dependency on a target waits for
+	// every step acting on it
+	const (
+		visiting = iota + 1
+		executed
+	)
+	state := make([]int, len(steps))
+
+	var executeWithDeps func(int) error
+	executeWithDeps = func(i int) error {
+		switch state[i] {
+		case executed:
 			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle at action %s", steps[i].Target)
 		}
-
-		// Check if action exists
-		action, exists := actionMap[actionID]
-		if !exists {
-			return fmt.Errorf("action not found: %s", actionID)
-		}
+		state[i] = visiting
 
 		// Execute dependencies first
-		for _, depID := range dependencyGraph[actionID] {
-			if err := executeWithDeps(depID); err != nil {
-				return err
+		for _, depID := range steps[i].DependsOn {
+			deps, exists := stepsByTarget[depID]
+			if !exists {
+				return fmt.Errorf("action not found: %s", depID)
 			}
+			for _, dep := range deps {
+				if dep == i {
+					continue
+				}
+				if err := executeWithDeps(dep); err != nil {
+					return err
+				}
+			}
+		}
+
+		actionID := steps[i].Target
+		if err := budget.expired(); err != nil {
+			return fmt.Errorf("%w before action %s", err, actionID)
 		}
 
 		// Execute the action
-		_, err := o.ExecuteAction(ctx, action)
-		if err != nil {
+		if _, err := o.executeAction(ctx, &steps[i], budget); err != nil {
+			// An attempt cut short by the plan deadline
+			if deadlineErr := budget.expired(); deadlineErr != nil && !errors.Is(err, ErrPlanBudgetExceeded) {
+				err = fmt.Errorf("%w (%w)", err, deadlineErr)
+			}
 			return fmt.Errorf("failed to execute action %s: %w", actionID, err)
 		}
 
-		executed[actionID] = true
+		state[i] = executed
 		return nil
 	}
 
-	// Execute all actions
-	for actionID := range actionMap {
-		if err := executeWithDeps(actionID); err != nil {
-			return err
+	// Execute all actions in plan order
+	for i := range steps {
+		if err := executeWithDeps(i); err != nil {
+			return steps, err
 		}
 	}
 
-	return nil
+	return steps, nil
 }
 
-// GetAction retrieves an action by its target identifier
-func (o *Orchestrator) GetAction(target string) (Action, bool) {
+// GetAction retrieves the latest action on a target within a namespace
+func (o *Orchestrator) GetAction(namespace, target string) (Action, bool) {
 	o.mu.RLock()
 	defer o.mu.RUnlock()
 
-	action, exists := o.actions[target]
+	action, exists := o.actions[actionKey{namespace: namespace, target: target}]
 	return action, exists
 }
 
@@ -235,5 +476,5 @@ func (o *Orchestrator) updateAction(action Action) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
-	o.actions[action.Target] = action
+	o.actions[actionKey{namespace: action.Namespace, target: action.Target}] = action
 }
@@ -0,0 +1,75 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPlanBudgetExceeded is returned when an action plan runs out of its
+// deadline or retry budget; the actions not run by then are left pending
+var ErrPlanBudgetExceeded = errors.New("action plan budget exceeded")
+
+// PlanBudget caps what an action plan may spend across all of its actions, on
+// top of the per-action retry policies. Zero values mean no limit.
+type PlanBudget struct {
+	// Deadline bounds the total duration of the plan, retries included
+	Deadline time.Duration `json:"deadline,omitempty"`
+	// MaxRetries bounds the retries of all actions of the plan together
+	MaxRetries int `json:"max_retries,omitempty"`
+}
+
+// Validate checks the budget limits
+func (b PlanBudget) Validate() error {
+	if b.Deadline < 0 {
+		return fmt.Errorf("plan deadline must not be negative")
+	}
+	if b.MaxRetries < 0 {
+		return fmt.Errorf("plan retry budget must not be negative")
+	}
+	return nil
+}
+
+// planBudget tracks the budget of a running plan. Plans execute their actions
+// one at a time, so it needs no locking.
+type planBudget struct {
+	limits      PlanBudget
+	deadline    time.Time
+	retriesUsed int
+}
+
+// newPlanBudget starts tracking limits, returning nil when nothing is limited
+func newPlanBudget(limits PlanBudget) *planBudget {
+	if limits.Deadline == 0 && limits.MaxRetries == 0 {
+		return nil
+	}
+	budget := &planBudget{limits: limits}
+	if limits.Deadline > 0 {
+		budget.deadline = time.Now().Add(limits.Deadline)
+	}
+	return budget
+}
+
+// expired returns an error once the plan deadline has passed
+func (b *planBudget) expired() error {
+	if b == nil || b.deadline.IsZero() || time.Now().Before(b.deadline) {
+		return nil
+	}
+	return fmt.Errorf("%w: deadline %s reached", ErrPlanBudgetExceeded, b.limits.Deadline)
+}
+
+// takeRetry reserves a retry to run after delay, returning an error when it
+// would pass the deadline or the retries are used up
+func (b *planBudget) takeRetry(delay time.Duration) error {
+	if b == nil {
+		return nil
+	}
+	if !b.deadline.IsZero() && time.Now().Add(delay).After(b.deadline) {
+		return fmt.Errorf("%w: deadline %s reached", ErrPlanBudgetExceeded, b.limits.Deadline)
+	}
+	if b.limits.MaxRetries > 0 && b.retriesUsed >= b.limits.MaxRetries {
+		return fmt.Errorf("%w: %d retries used", ErrPlanBudgetExceeded, b.retriesUsed)
+	}
+	b.retriesUsed++
+	return nil
+}
@@ -0,0 +1,98 @@
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewPlanBudget_Unlimited(t *testing.T) {
+	budget := newPlanBudget(PlanBudget{})
+	if budget != nil {
+		t.Fatalf("expected no budget without limits, got %+v", budget)
+	}
+
+	// A nil budget never runs out
+	if err := budget.expired(); err != nil {
+		t.Errorf("expired() = %v, want nil", err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := budget.takeRetry(time.Hour); err != nil {
+			t.Fatalf("takeRetry() = %v, want nil", err)
+		}
+	}
+}
+
+func TestPlanBudget_TakeRetry_MaxRetries(t *testing.T) {
+	budget := newPlanBudget(PlanBudget{MaxRetries: 2})
+
+	for i := 0; i < 2; i++ {
+		if err := budget.takeRetry(time.Hour); err != nil {
+			t.Fatalf("retry %d: unexpected error %v", i+1, err)
+		}
+	}
+	err := budget.takeRetry(0)
+	if !errors.Is(err, ErrPlanBudgetExceeded) {
+		t.Fatalf("expected ErrPlanBudgetExceeded once the retries are used up, got %v", err)
+	}
+	if budget.retriesUsed != 2 {
+		t.Errorf("expected a refused retry not to be counted, got %d used", budget.retriesUsed)
+	}
+	if err := budget.expired(); err != nil {
+		t.Errorf("expected a budget without deadline never to expire, got %v", err)
+	}
+}
+
+func TestPlanBudget_TakeRetry_Deadline(t *testing.T) {
+	budget := newPlanBudget(PlanBudget{Deadline: time.Minute})
+
+	if err := budget.takeRetry(time.Second); err != nil {
+		t.Fatalf("expected a retry within the deadline, got %v", err)
+	}
+	// A retry whose delay ends after the deadline is refused up front
+	if err := budget.takeRetry(2 * time.Minute); !errors.Is(err, ErrPlanBudgetExceeded) {
+		t.Fatalf("expected ErrPlanBudgetExceeded for a retry past the deadline, got %v", err)
+	}
+	if budget.retriesUsed != 1 {
+		t.Errorf("expected 1 retry used, got %d", budget.retriesUsed)
+	}
+	if err := budget.expired(); err != nil {
+		t.Errorf("expected the budget not to be expired yet, got %v", err)
+	}
+}
+
+func TestPlanBudget_Expired(t *testing.T) {
+	budget := newPlanBudget(PlanBudget{Deadline: time.Minute, MaxRetries: 5})
+	budget.deadline = time.Now().Add(-time.Millisecond)
+
+	if err := budget.expired(); !errors.Is(err, ErrPlanBudgetExceeded) {
+		t.Errorf("expected ErrPlanBudgetExceeded after the deadline, got %v", err)
+	}
+	if err := budget.takeRetry(0); !errors.Is(err, ErrPlanBudgetExceeded) {
+		t.Errorf("expected no retries after the deadline, got %v", err)
+	}
+	if budget.retriesUsed != 0 {
+		t.Errorf("expected no retries used, got %d", budget.retriesUsed)
+	}
+}
+
+func TestPlanBudget_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  PlanBudget
+		wantErr bool
+	}{
+		{name: "unlimited", budget: PlanBudget{}},
+		{name: "limited", budget: PlanBudget{Deadline: time.Minute, MaxRetries: 3}},
+		{name: "negative deadline", budget: PlanBudget{Deadline: -time.Second}, wantErr: true},
+		{name: "negative retries", budget: PlanBudget{MaxRetries: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.budget.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
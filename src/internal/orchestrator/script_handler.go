@@ -49,12 +49,16 @@ func (h *ScriptHandler) SetEnvironment(env map[string]string) {
 	h.Environment = env
 }
 
-// Execute performs the script execution action
-func (h *ScriptHandler) Execute(ctx context.Context, action Action) (*ActionResult, error) {
-	// Get script name from parameters
-	scriptName := action.Parameters["script_name"]
+// Validate checks that the script exists and may be run, without running it
+func (h *ScriptHandler) Validate(action Action) error {
+	_, err := h.resolveScript(action.Parameters["script_name"])
+	return err
+}
+
+// resolveScript checks the script name and returns the script path
+func (h *ScriptHandler) resolveScript(scriptName string) (string, error) {
 	if scriptName == "" {
-		return nil, fmt.Errorf("script_name parameter is required")
+		return "", fmt.Errorf("script_name parameter is required")
 	}
 
 	// Validate script extension
@@ -68,7 +72,7 @@ func (h *ScriptHandler) Execute(ctx context.Context, action Action) (*ActionResu
 	}
 
 	if !validExt {
-		return nil, fmt.Errorf("script extension %s is not allowed", ext)
+		return "", fmt.Errorf("script extension %s is not allowed", ext)
 	}
 
 	// Build the script path
@@ -76,8 +80,21 @@ func (h *ScriptHandler) Execute(ctx context.Context, action Action) (*ActionResu
 
 	// Check if script exists
 	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("script %s does not exist", scriptPath)
+		return "", fmt.Errorf("script %s does not exist", scriptPath)
+	}
+
+	return scriptPath, nil
+}
+
+// Execute performs the script execution action
+func (h *ScriptHandler) Execute(ctx context.Context, action Action) (*ActionResult, error) {
+	// Get script name from parameters
+	scriptName := action.Parameters["script_name"]
+	scriptPath, err := h.resolveScript(scriptName)
+	if err != nil {
+		return nil, err
 	}
+	ext := filepath.Ext(scriptPath)
 
 	// Get script arguments
 	args := []string{}
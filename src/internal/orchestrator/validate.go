@@ -0,0 +1,171 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ActionValidator is implemented by handlers that can check an action's
+// parameters without executing it
+type ActionValidator interface {
+	// Validate returns an error describing why the action cannot be executed
+	Validate(action Action) error
+}
+
+// ActionValidation is the result of validating one action of a plan
+type ActionValidation struct {
+	Target string     `json:"target"`
+	Type   ActionType `json:"type"`
+	Valid  bool       `json:"valid"`
+	Errors []string   `json:"errors,omitempty"`
+}
+
+// ValidateActionPlan checks a plan without executing anything: every action
+// type has a registered handler, the handler accepts the parameters, timeouts
+// and retry policies are sane, and DependsOn refers to actions of the plan
+// without forming a cycle. Results are returned in the order of actions.
+func (o *Orchestrator) ValidateActionPlan(actions []Action) []ActionValidation {
+	o.mu.RLock()
+	handlers := make(map[ActionType]ActionHandler, len(o.handlers))
+	for actionType, handler := range o.handlers {
+		handlers[actionType] = handler
+	}
+	o.mu.RUnlock()
+
+	results := make([]ActionValidation, len(actions))
+	index := make(map[string]int, len(actions))
+	for i, action := range actions {
+		results[i] = ActionValidation{Target: action.Target, Type: action.Type}
+		if action.Target == "" {
+			results[i].Errors = append(results[i].Errors, "target is required")
+			continue
+		}
+		if first, exists := index[action.Target]; exists {
+			results[i].Errors = append(results[i].Errors,
+				fmt.Sprintf("duplicate target, already used by action %d", first))
+			continue
+		}
+		index[action.Target] = i
+	}
+
+	for i, action := range actions {
+		handler, exists := handlers[action.Type]
+		if !exists {
+			results[i].Errors = append(results[i].Errors,
+				fmt.Sprintf("no handler registered for action type: %s", action.Type))
+		} else if validator, ok := handler.(ActionValidator); ok {
+			if err := validator.Validate(action); err != nil {
+				results[i].Errors = append(results[i].Errors, err.Error())
+			}
+		}
+
+		results[i].Errors = append(results[i].Errors, validateTiming(action)...)
+
+		for _, dep := range action.DependsOn {
+			if dep == action.Target {
+				results[i].Errors = append(results[i].Errors, "action depends on itself")
+			} else if _, exists := index[dep]; !exists {
+				results[i].Errors = append(results[i].Errors,
+					fmt.Sprintf("dependency not found in plan: %s", dep))
+			}
+		}
+	}
+
+	for target, cycle := range findDependencyCycles(actions, index) {
+		i := index[target]
+		results[i].Errors = append(results[i].Errors,
+			fmt.Sprintf("dependency cycle: %s", strings.Join(cycle, " -> ")))
+	}
+
+	for i := range results {
+		results[i].Valid = len(results[i].Errors) == 0
+	}
+	return results
+}
+
+// validateTiming checks the action timeout and retry policy
+func validateTiming(action Action) []string {
+	var errs []string
+	if action.Timeout < 0 {
+		errs = append(errs, "timeout must not be negative")
+	}
+
+	policy := action.RetryPolicy
+	if policy == nil {
+		return errs
+	}
+	if policy.MaxRetries < 0 {
+		errs = append(errs, "retry_policy.max_retries must not be negative")
+	}
+	if policy.RetryInterval < 0 {
+		errs = append(errs, "retry_policy.retry_interval must not be negative")
+	}
+	if policy.MaxInterval != 0 && policy.MaxInterval < policy.RetryInterval {
+		errs = append(errs, "retry_policy.max_interval must not be less than retry_interval")
+	}
+	if policy.Multiplier < 0 {
+		errs = append(errs, "retry_policy.multiplier must not be negative")
+	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		errs = append(errs, "retry_policy.jitter must be between 0 and 1")
+	}
+	if policy.MaxDuration < 0 {
+		errs = append(errs, "retry_policy.max_duration must not be negative")
+	}
+	return errs
+}
+
+// findDependencyCycles returns, for every action on a dependency cycle, the
+// cycle as a path of targets starting and ending with that action.
+// Self-dependencies and unknown dependencies are reported separately.
+func findDependencyCycles(actions []Action, index map[string]int) map[string][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	state := make(map[string]int, len(index))
+	cycles := make(map[string][]string)
+	var path []string
+
+	var visit func(target string)
+	visit = func(target string) {
+		state[target] = visiting
+		path = append(path, target)
+
+		for _, dep := range actions[index[target]].DependsOn {
+			if _, exists := index[dep]; !exists || dep == target {
+				continue
+			}
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				// The path from dep to here closes a cycle
+				start := 0
+				for path[start] != dep {
+					start++
+				}
+				loop := path[start:]
+				for j, member := range loop {
+					if _, reported := cycles[member]; reported {
+						continue
+					}
+					cycle := append(append([]string{}, loop[j:]...), loop[:j]...)
+					cycles[member] = append(cycle, member)
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[target] = done
+	}
+
+	for _, action := range actions {
+		if _, exists := index[action.Target]; exists && state[action.Target] == unvisited {
+			visit(action.Target)
+		}
+	}
+	return cycles
+}
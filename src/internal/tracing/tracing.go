@@ -0,0 +1,94 @@
+// Package tracing sets up OpenTelemetry tracing: spans are exported in batches
+// to an OpenTelemetry collector over OTLP/HTTP and W3C trace context is
+// propagated through request headers. Until Setup installs a tracer provider
+// the global one is a no-op, so instrumented code costs next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultServiceName is the service name used when Config.ServiceName is empty
+const DefaultServiceName = "aiops-infra"
+
+// InstrumentationName names the tracer of the service's own spans
+const InstrumentationName = "github.com/yourusername/aiops-infra"
+
+// Config configures tracing
+type Config struct {
+	Enabled bool
+	// Endpoint is the OTLP/HTTP base URL of the collector, e.g.
+	// http://otel-collector:4318; spans are posted to Endpoint/v1/traces
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the fraction of traces recorded, in (0, 1]; 0 records all.
+	// Traces continued from a caller follow the caller's sampling decision.
+	SampleRatio float64
+}
+
+// Setup installs a tracer provider exporting to config.Endpoint and the W3C
+// trace context propagator, and returns the function flushing the provider on
+// shutdown. When tracing is disabled nothing is installed.
+func Setup(config Config) (func(context.Context) error, error) {
+	if !config.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("tracing endpoint is required")
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(strings.TrimSuffix(config.Endpoint, "/")+"/v1/traces"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	provider := NewTracerProvider(config, exporter)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// NewTracerProvider creates a tracer provider sampling by config.SampleRatio
+// and exporting through exporter in batches
+func NewTracerProvider(config Config, exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	ratio := config.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+}
+
+// Tracer returns the tracer of the service's own spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// RecordError marks span as failed with err; a nil err is ignored
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := Setup(Config{})
+	if err != nil {
+		t.Fatalf("expected tracing to stay disabled, got err=%v", err)
+	}
+	if _, span := Tracer().Start(context.Background(), "noop"); span.SpanContext().IsValid() {
+		t.Error("expected no-op spans while tracing is disabled")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+	if _, err := Setup(Config{Enabled: true}); err == nil {
+		t.Error("expected an endpoint to be required")
+	}
+}
+
+func TestSetup_ExportsOverOTLP(t *testing.T) {
+	var exports atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/traces" {
+			exports.Add(1)
+		}
+	}))
+	defer server.Close()
+
+	shutdown, err := Setup(Config{Enabled: true, Endpoint: server.URL + "/", ServiceName: "aiops-test"})
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	_, span := Tracer().Start(context.Background(), "detector.Detect")
+	span.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if exports.Load() != 1 {
+		t.Errorf("expected the span to be exported to /v1/traces, got %d exports", exports.Load())
+	}
+}
+
+func TestNewTracerProvider_Sampling(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewTracerProvider(Config{SampleRatio: 1e-12}, exporter)
+	defer provider.Shutdown(context.Background())
+	tracer := provider.Tracer(InstrumentationName)
+
+	// A sampled caller is followed even though the ratio drops nearly all roots
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier(header))
+	_, sampled := tracer.Start(ctx, "sampled")
+	RecordError(sampled, errors.New("timeout"))
+	RecordError(sampled, nil)
+	sampled.End()
+
+	for i := 0; i < 10; i++ {
+		_, span := tracer.Start(context.Background(), "root")
+		span.End()
+	}
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "sampled" {
+		t.Fatalf("expected only the span of the sampled caller, got %d spans", len(spans))
+	}
+	if spans[0].Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("expected the span to continue the caller's trace, got parent %s", spans[0].Parent.SpanID())
+	}
+	if spans[0].Status.Code != codes.Error || spans[0].Status.Description != "timeout" {
+		t.Errorf("expected the error status, got %+v", spans[0].Status)
+	}
+}